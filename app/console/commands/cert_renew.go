@@ -3,6 +3,8 @@ package commands
 import (
 	"crypto/x509"
 	"encoding/pem"
+	"fmt"
+	"strings"
 
 	"github.com/goravel/framework/contracts/console"
 	"github.com/goravel/framework/contracts/console/command"
@@ -41,11 +43,8 @@ func (receiver *CertRenew) Handle(ctx console.Context) error {
 		return err
 	}
 
+	notification := services.NewNotificationImpl()
 	for _, cert := range certs {
-		if !cert.AutoRenew {
-			continue
-		}
-
 		block, _ := pem.Decode([]byte(cert.Cert))
 		if block != nil {
 			data, err := x509.ParseCertificate(block.Bytes)
@@ -58,6 +57,15 @@ func (receiver *CertRenew) Handle(ctx console.Context) error {
 			if endTime.Gt(carbon.Now().AddDays(7)) {
 				continue
 			}
+
+			// 未开启自动续签的证书仅通知即将过期
+			if !cert.AutoRenew {
+				_ = notification.Notify(models.NotificationEventCertExpiring, strings.Join(cert.Domains, ","), "证书即将过期", fmt.Sprintf("证书 %s 将于 %s 过期，请及时续签", strings.Join(cert.Domains, ", "), endTime.ToDateTimeString()))
+				continue
+			}
+		}
+		if !cert.AutoRenew {
+			continue
 		}
 
 		certService := services.NewCertImpl()
@@ -67,6 +75,7 @@ func (receiver *CertRenew) Handle(ctx console.Context) error {
 				"cert_id": cert.ID,
 				"error":   err.Error(),
 			}).Infof("证书续签失败")
+			_ = notification.Notify(models.NotificationEventCertRenewFailed, strings.Join(cert.Domains, ","), "证书续签失败", fmt.Sprintf("证书 %s 续签失败: %s", strings.Join(cert.Domains, ", "), err.Error()))
 		}
 	}
 
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"github.com/goravel/framework/contracts/console"
+	"github.com/goravel/framework/contracts/console/command"
+	"github.com/goravel/framework/facades"
+
+	"panel/app/models"
+	"panel/app/services"
+	"panel/pkg/tools"
+)
+
+type CheckUpdate struct {
+}
+
+// Signature The name and signature of the console command.
+func (receiver *CheckUpdate) Signature() string {
+	return "panel:check-update"
+}
+
+// Description The console command description.
+func (receiver *CheckUpdate) Description() string {
+	return "[面板] 检查更新"
+}
+
+// Extend The console command extend.
+func (receiver *CheckUpdate) Extend() command.Extend {
+	return command.Extend{
+		Category: "panel",
+	}
+}
+
+// Handle Execute the console command.
+func (receiver *CheckUpdate) Handle(ctx console.Context) error {
+	version := facades.Config().GetString("panel.version")
+	remote, err := tools.GetLatestPanelVersion()
+	if err != nil {
+		facades.Log().Tags("面板", "检查更新").With(map[string]any{
+			"error": err.Error(),
+		}).Info("获取最新版本失败")
+		return nil
+	}
+	if tools.VersionCompare(version, remote.Version, ">=") {
+		return nil
+	}
+
+	// 每个新版本只通知一次
+	setting := services.NewSettingImpl()
+	if setting.Get(models.SettingKeyNotifiedVersion) == remote.Version {
+		return nil
+	}
+
+	name := facades.Config().GetString("panel.name")
+	if err = services.NewNotificationImpl().Notify(models.NotificationEventPanelUpdate, remote.Version, name+"有新版本 "+remote.Version,
+		"当前版本: "+version+"\n最新版本: "+remote.Version+"\n\n"+remote.Body); err != nil {
+		facades.Log().Tags("面板", "检查更新").With(map[string]any{
+			"error": err.Error(),
+		}).Info("发送更新通知失败")
+		return nil
+	}
+
+	return setting.Set(models.SettingKeyNotifiedVersion, remote.Version)
+}
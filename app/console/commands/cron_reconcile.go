@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"github.com/goravel/framework/contracts/console"
+	"github.com/goravel/framework/contracts/console/command"
+	"github.com/goravel/framework/facades"
+
+	"panel/app/services"
+)
+
+type CronReconcile struct {
+}
+
+// Signature The name and signature of the console command.
+func (receiver *CronReconcile) Signature() string {
+	return "panel:cron-reconcile"
+}
+
+// Description The console command description.
+func (receiver *CronReconcile) Description() string {
+	return "[面板] 计划任务校准"
+}
+
+// Extend The console command extend.
+func (receiver *CronReconcile) Extend() command.Extend {
+	return command.Extend{
+		Category: "panel",
+	}
+}
+
+// Handle Execute the console command.
+func (receiver *CronReconcile) Handle(ctx console.Context) error {
+	cronService := services.NewCronImpl()
+	drift, err := cronService.Drift()
+	if err != nil {
+		facades.Log().Tags("面板", "计划任务").With(map[string]any{
+			"error": err.Error(),
+		}).Info("检测计划任务差异失败")
+		return nil
+	}
+	if drift.Clean() {
+		return nil
+	}
+
+	facades.Log().Tags("面板", "计划任务").With(map[string]any{
+		"missing":    drift.Missing,
+		"unexpected": drift.Unexpected,
+		"legacy":     drift.Legacy,
+		"no_block":   drift.NoBlock,
+	}).Info("系统计划任务与面板数据不一致，正在重新同步")
+
+	if err = cronService.Sync(); err != nil {
+		facades.Log().Tags("面板", "计划任务").With(map[string]any{
+			"error": err.Error(),
+		}).Info("同步计划任务失败")
+	}
+
+	return nil
+}
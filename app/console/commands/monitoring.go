@@ -1,13 +1,14 @@
 package commands
 
 import (
-	"strconv"
+	"fmt"
+	"strings"
 
 	"github.com/gookit/color"
 	"github.com/goravel/framework/contracts/console"
 	"github.com/goravel/framework/contracts/console/command"
 	"github.com/goravel/framework/facades"
-	"github.com/goravel/framework/support/carbon"
+	"github.com/shirou/gopsutil/disk"
 	"github.com/spf13/cast"
 
 	"panel/app/models"
@@ -37,41 +38,109 @@ func (receiver *Monitoring) Extend() command.Extend {
 
 // Handle Execute the console command.
 func (receiver *Monitoring) Handle(ctx console.Context) error {
-	var setting models.Setting
-	monitor := services.NewSettingImpl().Get(models.SettingKeyMonitor)
-	if !cast.ToBool(monitor) {
+	receiver.checkDisk()
+	receiver.checkServices()
+
+	monitor := cast.ToBool(services.NewSettingImpl().Get(models.SettingKeyMonitor))
+	var rules int64
+	_ = facades.Orm().Query().Model(&models.AlertRule{}).Where("enabled", true).Count(&rules)
+	if !monitor && rules == 0 {
 		return nil
 	}
 
 	info := tools.GetMonitoringInfo()
+	monitorService := services.NewMonitorImpl()
 
-	err := facades.Orm().Query().Create(&models.Monitor{
-		Info: info,
-	})
+	// 未开启监控时不保存数据，速率类指标无法计算
+	sample, err := monitorService.Record(info, monitor)
 	if err != nil {
 		facades.Log().Infof("[面板] 系统监控保存失败: %s", err.Error())
 		color.Redf("[面板] 系统监控保存失败: %s", err.Error())
+	}
+	if rules > 0 && sample != nil {
+		if err = services.NewAlertImpl().Evaluate(sample); err != nil {
+			facades.Log().Infof("[面板] 监控告警计算失败: %s", err.Error())
+			color.Redf("[面板] 监控告警计算失败: %s", err.Error())
+		}
+	}
+	if !monitor {
 		return nil
 	}
 
-	// 删除过期数据
-	err = facades.Orm().Query().Where("key", "monitor_days").First(&setting)
-	if err != nil {
-		return nil
+	if err = monitorService.Rollup(); err != nil {
+		facades.Log().Infof("[面板] 系统监控数据聚合失败: %s", err.Error())
+		color.Redf("[面板] 系统监控数据聚合失败: %s", err.Error())
 	}
-	if setting.Value == "0" || len(setting.Value) == 0 {
-		return nil
+	if err = monitorService.Prune(); err != nil {
+		facades.Log().Infof("[面板] 系统监控删除过期数据失败: %s", err.Error())
 	}
 
-	days, err := strconv.Atoi(setting.Value)
+	return nil
+}
+
+// checkDisk 检查磁盘使用率
+func (receiver *Monitoring) checkDisk() {
+	threshold := cast.ToFloat64(services.NewSettingImpl().Get(models.SettingKeyNotificationDiskUsage, "90"))
+	if threshold <= 0 {
+		return
+	}
+
+	partitions, err := disk.Partitions(false)
 	if err != nil {
-		return nil
+		return
+	}
+	for _, partition := range partitions {
+		if ignoredPartition(partition) {
+			continue
+		}
+		usage, err := disk.Usage(partition.Mountpoint)
+		if err != nil || usage.Total == 0 || usage.UsedPercent < threshold {
+			continue
+		}
+
+		_ = services.NewNotificationImpl().Notify(models.NotificationEventDiskFull, partition.Mountpoint, "磁盘 "+partition.Mountpoint+" 空间不足",
+			fmt.Sprintf("磁盘 %s 已使用 %.2f%%（%s / %s），超过告警阈值 %.0f%%", partition.Mountpoint, usage.UsedPercent, tools.FormatBytes(float64(usage.Used)), tools.FormatBytes(float64(usage.Total)), threshold))
 	}
-	_, err = facades.Orm().Query().Where("created_at < ?", carbon.Now().SubDays(days).ToDateTimeString()).Delete(&models.Monitor{})
+}
+
+// ignoredPartition 只读和伪文件系统（如 snap 的 squashfs 挂载）始终为满，不参与检查
+func ignoredPartition(partition disk.PartitionStat) bool {
+	switch partition.Fstype {
+	case "squashfs", "iso9660", "tmpfs", "devtmpfs", "overlay", "ramfs":
+		return true
+	}
+	if strings.HasPrefix(partition.Device, "/dev/loop") {
+		return true
+	}
+	for _, opt := range strings.Split(partition.Opts, ",") {
+		if opt == "ro" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkServices 检查已安装插件的服务状态
+func (receiver *Monitoring) checkServices() {
+	plugin := services.NewPluginImpl()
+	plugins, err := plugin.AllInstalled()
 	if err != nil {
-		facades.Log().Infof("[面板] 系统监控删除过期数据失败: %s", err.Error())
-		return nil
+		return
 	}
 
-	return nil
+	for _, installed := range plugins {
+		service := plugin.ServiceName(installed.Slug)
+		if len(service) == 0 {
+			continue
+		}
+
+		status, err := tools.ServiceStatus(service)
+		if err != nil || status {
+			continue
+		}
+
+		_ = services.NewNotificationImpl().Notify(models.NotificationEventServiceDown, service, "服务 "+service+" 停止运行",
+			fmt.Sprintf("%s 的服务 %s 当前未运行，请检查", plugin.GetBySlug(installed.Slug).Name, service))
+	}
 }
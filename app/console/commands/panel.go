@@ -16,6 +16,7 @@ import (
 
 	"panel/app/models"
 	"panel/app/services"
+	"panel/pkg/siteclone"
 	"panel/pkg/tools"
 )
 
@@ -228,6 +229,28 @@ func (receiver *Panel) Handle(ctx console.Context) error {
 
 		color.Greenln("清理任务成功")
 
+	case "syncCron":
+		if err := services.NewCronImpl().Sync(); err != nil {
+			color.Redln("同步计划任务失败: " + err.Error())
+			return nil
+		}
+
+		color.Greenln("同步计划任务成功")
+
+	case "cronRun":
+		if len(arg1) == 0 {
+			color.Redln("参数错误")
+			return nil
+		}
+
+		run, err := services.NewCronImpl().Run(cast.ToUint(arg1))
+		if err != nil {
+			color.Redln("运行计划任务失败: " + err.Error())
+			return nil
+		}
+
+		color.Greenln("计划任务运行结束，状态: " + run.Status)
+
 	case "backup":
 		backupType := arg1
 		name := arg2
@@ -245,7 +268,7 @@ func (receiver *Panel) Handle(ctx console.Context) error {
 
 		if !tools.Exists(path) {
 			if err := tools.Mkdir(path, 0644); err != nil {
-				color.Redln("|-创建备份目录失败: " + err.Error())
+				receiver.backupFailed(backupType, name, "创建备份目录失败: "+err.Error())
 				return nil
 			}
 		}
@@ -255,14 +278,14 @@ func (receiver *Panel) Handle(ctx console.Context) error {
 			color.Yellowln("|-目标网站: " + name)
 			var website models.Website
 			if err := facades.Orm().Query().Where("name", name).FirstOrFail(&website); err != nil {
-				color.Redln("|-网站不存在")
+				receiver.backupFailed(backupType, name, "网站不存在")
 				color.Greenln(hr)
 				return nil
 			}
 
 			backupFile := path + "/" + website.Name + "_" + carbon.Now().ToShortDateTimeString() + ".zip"
 			if _, err := tools.Exec(`cd '` + website.Path + `' && zip -r '` + backupFile + `' .`); err != nil {
-				color.Redln("|-备份失败: " + err.Error())
+				receiver.backupFailed(backupType, name, "备份失败: "+err.Error())
 				return nil
 			}
 			color.Greenln("|-备份成功")
@@ -273,7 +296,7 @@ func (receiver *Panel) Handle(ctx console.Context) error {
 
 			err := os.Setenv("MYSQL_PWD", rootPassword)
 			if err != nil {
-				color.Redln("|-备份MySQL数据库失败: " + err.Error())
+				receiver.backupFailed(backupType, name, "备份MySQL数据库失败: "+err.Error())
 				color.Greenln(hr)
 				return nil
 			}
@@ -281,23 +304,23 @@ func (receiver *Panel) Handle(ctx console.Context) error {
 			color.Greenln("|-目标MySQL数据库: " + name)
 			color.Greenln("|-开始导出")
 			if _, err = tools.Exec(`mysqldump -uroot ` + name + ` > /tmp/` + backupFile + ` 2>&1`); err != nil {
-				color.Redln("|-导出失败: " + err.Error())
+				receiver.backupFailed(backupType, name, "导出失败: "+err.Error())
 				return nil
 			}
 			color.Greenln("|-导出成功")
 			color.Greenln("|-开始压缩")
 			if _, err = tools.Exec("cd /tmp && zip -r " + backupFile + ".zip " + backupFile); err != nil {
-				color.Redln("|-压缩失败: " + err.Error())
+				receiver.backupFailed(backupType, name, "压缩失败: "+err.Error())
 				return nil
 			}
 			if err := tools.Remove("/tmp/" + backupFile); err != nil {
-				color.Redln("|-删除失败: " + err.Error())
+				receiver.backupFailed(backupType, name, "删除失败: "+err.Error())
 				return nil
 			}
 			color.Greenln("|-压缩成功")
 			color.Greenln("|-开始移动")
 			if err := tools.Mv("/tmp/"+backupFile+".zip", path+"/"+backupFile+".zip"); err != nil {
-				color.Redln("|-移动失败: " + err.Error())
+				receiver.backupFailed(backupType, name, "移动失败: "+err.Error())
 				return nil
 			}
 			color.Greenln("|-移动成功")
@@ -308,12 +331,12 @@ func (receiver *Panel) Handle(ctx console.Context) error {
 			backupFile := name + "_" + carbon.Now().ToShortDateTimeString() + ".sql"
 			check, err := tools.Exec(`su - postgres -c "psql -l" 2>&1`)
 			if err != nil {
-				color.Redln("|-获取数据库失败: " + err.Error())
+				receiver.backupFailed(backupType, name, "获取数据库失败: "+err.Error())
 				color.Greenln(hr)
 				return nil
 			}
 			if strings.Contains(check, name) {
-				color.Redln("|-数据库不存在")
+				receiver.backupFailed(backupType, name, "数据库不存在")
 				color.Greenln(hr)
 				return nil
 			}
@@ -321,23 +344,23 @@ func (receiver *Panel) Handle(ctx console.Context) error {
 			color.Greenln("|-目标PostgreSQL数据库: " + name)
 			color.Greenln("|-开始导出")
 			if _, err = tools.Exec(`su - postgres -c "pg_dump '` + name + `'" > /tmp/` + backupFile + ` 2>&1`); err != nil {
-				color.Redln("|-导出失败: " + err.Error())
+				receiver.backupFailed(backupType, name, "导出失败: "+err.Error())
 				return nil
 			}
 			color.Greenln("|-导出成功")
 			color.Greenln("|-开始压缩")
 			if _, err = tools.Exec("cd /tmp && zip -r " + backupFile + ".zip " + backupFile); err != nil {
-				color.Redln("|-压缩失败: " + err.Error())
+				receiver.backupFailed(backupType, name, "压缩失败: "+err.Error())
 				return nil
 			}
 			if err := tools.Remove("/tmp/" + backupFile); err != nil {
-				color.Redln("|-删除失败: " + err.Error())
+				receiver.backupFailed(backupType, name, "删除失败: "+err.Error())
 				return nil
 			}
 			color.Greenln("|-压缩成功")
 			color.Greenln("|-开始移动")
 			if err := tools.Mv("/tmp/"+backupFile+".zip", path+"/"+backupFile+".zip"); err != nil {
-				color.Redln("|-移动失败: " + err.Error())
+				receiver.backupFailed(backupType, name, "移动失败: "+err.Error())
 				return nil
 			}
 			color.Greenln("|-移动成功")
@@ -347,7 +370,7 @@ func (receiver *Panel) Handle(ctx console.Context) error {
 		color.Greenln(hr)
 		files, err := os.ReadDir(path)
 		if err != nil {
-			color.Redln("|-清理失败: " + err.Error())
+			receiver.backupFailed(backupType, name, "清理失败: "+err.Error())
 			return nil
 		}
 		var filteredFiles []os.FileInfo
@@ -367,7 +390,7 @@ func (receiver *Panel) Handle(ctx console.Context) error {
 			fileToDelete := filepath.Join(path, filteredFiles[i].Name())
 			color.Yellowln("|-清理备份: " + fileToDelete)
 			if err := tools.Remove(fileToDelete); err != nil {
-				color.Redln("|-清理失败: " + err.Error())
+				receiver.backupFailed(backupType, name, "清理失败: "+err.Error())
 				return nil
 			}
 		}
@@ -549,6 +572,23 @@ func (receiver *Panel) Handle(ctx console.Context) error {
 
 		color.Greenln("删除设置成功")
 
+	case "replaceDomain":
+		from := arg1
+		to := arg2
+		file := arg3
+		if len(from) == 0 || len(to) == 0 || len(file) == 0 {
+			color.Redln("参数错误")
+			os.Exit(1)
+		}
+
+		// 由克隆及上线脚本调用，失败时需返回非零退出码以中止脚本
+		if err := replaceDomain(file, from, to); err != nil {
+			color.Redln("替换域名失败: " + err.Error())
+			os.Exit(1)
+		}
+
+		color.Greenln("替换域名成功")
+
 	default:
 		color.Yellowln(facades.Config().GetString("panel.name") + "命令行工具 - " + facades.Config().GetString("panel.version"))
 		color.Greenln("请使用以下命令：")
@@ -558,6 +598,8 @@ func (receiver *Panel) Handle(ctx console.Context) error {
 		color.Greenln("panel getEntrance 获取面板访问入口")
 		color.Greenln("panel deleteEntrance 删除面板访问入口")
 		color.Greenln("panel cleanTask 清理面板运行中和等待中的任务[任务卡住时使用]")
+		color.Greenln("panel syncCron 根据面板数据重写系统计划任务")
+		color.Greenln("panel cronRun {id} 运行计划任务并记录运行结果")
 		color.Greenln("panel backup {website/mysql/postgresql} {name} {path} {save_copies} 备份网站 / MySQL数据库 / PostgreSQL数据库到指定目录并保留指定数量")
 		color.Greenln("panel cutoff {website_name} {save_copies} 切割网站日志并保留指定数量")
 		color.Redln("以下命令请在开发者指导下使用：")
@@ -570,7 +612,41 @@ func (receiver *Panel) Handle(ctx console.Context) error {
 		color.Yellowln("panel getSetting {name} 获取面板设置数据")
 		color.Yellowln("panel writeSetting {name} {value} 写入 / 更新面板设置数据")
 		color.Yellowln("panel deleteSetting {name} 删除面板设置数据")
+		color.Yellowln("panel replaceDomain {from} {to} {file} 替换数据库备份文件中的域名并修正序列化数据")
 	}
 
 	return nil
 }
+
+// backupFailed 输出备份失败信息并发送通知
+func (receiver *Panel) backupFailed(backupType, name, reason string) {
+	color.Redln("|-" + reason)
+	if err := services.NewNotificationImpl().Notify(models.NotificationEventBackupFailed, backupType+" "+name, name+" 备份失败", fmt.Sprintf("%s %s 备份失败: %s", backupType, name, reason)); err != nil {
+		color.Redln("|-发送通知失败: " + err.Error())
+	}
+}
+
+// replaceDomain 替换数据库备份文件中的域名
+func replaceDomain(file, from, to string) error {
+	src, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(dst.Name())
+
+	if err = siteclone.Replace(src, dst, from, to); err != nil {
+		dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(dst.Name(), file)
+}
@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"github.com/goravel/framework/contracts/console"
+	"github.com/goravel/framework/contracts/console/command"
+	"github.com/goravel/framework/facades"
+
+	"panel/app/services"
+)
+
+type PhpFpmStat struct {
+}
+
+// Signature The name and signature of the console command.
+func (receiver *PhpFpmStat) Signature() string {
+	return "panel:php-fpm-stat"
+}
+
+// Description The console command description.
+func (receiver *PhpFpmStat) Description() string {
+	return "[面板] PHP-FPM 进程池状态采样"
+}
+
+// Extend The console command extend.
+func (receiver *PhpFpmStat) Extend() command.Extend {
+	return command.Extend{
+		Category: "panel",
+	}
+}
+
+// Handle Execute the console command.
+func (receiver *PhpFpmStat) Handle(ctx console.Context) error {
+	fpmService := services.NewPhpFpmImpl()
+	if err := fpmService.Collect(); err != nil {
+		facades.Log().Tags("面板", "PHP-FPM").With(map[string]any{
+			"error": err.Error(),
+		}).Info("采样进程池状态失败")
+	}
+	if err := fpmService.Prune(); err != nil {
+		facades.Log().Tags("面板", "PHP-FPM").With(map[string]any{
+			"error": err.Error(),
+		}).Info("删除过期进程池状态失败")
+	}
+
+	return nil
+}
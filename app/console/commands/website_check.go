@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"sync"
+
+	"github.com/goravel/framework/contracts/console"
+	"github.com/goravel/framework/contracts/console/command"
+	"github.com/goravel/framework/facades"
+
+	"panel/app/models"
+	"panel/app/services"
+)
+
+// websiteCheckConcurrency 同时执行的检测数
+const websiteCheckConcurrency = 10
+
+type WebsiteCheck struct {
+}
+
+// Signature The name and signature of the console command.
+func (receiver *WebsiteCheck) Signature() string {
+	return "panel:website-check"
+}
+
+// Description The console command description.
+func (receiver *WebsiteCheck) Description() string {
+	return "[面板] 网站可用性检测"
+}
+
+// Extend The console command extend.
+func (receiver *WebsiteCheck) Extend() command.Extend {
+	return command.Extend{
+		Category: "panel",
+	}
+}
+
+// Handle Execute the console command.
+func (receiver *WebsiteCheck) Handle(ctx console.Context) error {
+	checkService := services.NewWebsiteCheckImpl()
+	checks, err := checkService.Due()
+	if err != nil {
+		facades.Log().Tags("面板", "网站检测").With(map[string]any{
+			"error": err.Error(),
+		}).Info("获取网站检测失败")
+		return nil
+	}
+
+	// 并发检测，串行写入数据库
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	limit := make(chan struct{}, websiteCheckConcurrency)
+	for _, check := range checks {
+		wg.Add(1)
+		limit <- struct{}{}
+		go func(check models.WebsiteCheck) {
+			defer wg.Done()
+			defer func() { <-limit }()
+
+			result := checkService.Probe(check)
+			mu.Lock()
+			defer mu.Unlock()
+			if _, err := checkService.Save(check, result); err != nil {
+				facades.Log().Tags("面板", "网站检测").With(map[string]any{
+					"check_id": check.ID,
+					"error":    err.Error(),
+				}).Info("保存网站检测结果失败")
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	if err = checkService.Prune(); err != nil {
+		facades.Log().Tags("面板", "网站检测").With(map[string]any{
+			"error": err.Error(),
+		}).Info("删除过期检测记录失败")
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"github.com/goravel/framework/contracts/console"
+	"github.com/goravel/framework/contracts/console/command"
+	"github.com/goravel/framework/facades"
+
+	"panel/app/services"
+)
+
+type WebsiteExpiry struct {
+}
+
+// Signature The name and signature of the console command.
+func (receiver *WebsiteExpiry) Signature() string {
+	return "panel:website-expiry"
+}
+
+// Description The console command description.
+func (receiver *WebsiteExpiry) Description() string {
+	return "[面板] 网站到期检查"
+}
+
+// Extend The console command extend.
+func (receiver *WebsiteExpiry) Extend() command.Extend {
+	return command.Extend{
+		Category: "panel",
+	}
+}
+
+// Handle Execute the console command.
+func (receiver *WebsiteExpiry) Handle(ctx console.Context) error {
+	if err := services.NewWebsiteSuspensionImpl().Check(); err != nil {
+		facades.Log().Tags("面板", "网站管理").With(map[string]any{
+			"error": err.Error(),
+		}).Info("检查网站到期失败")
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/goravel/framework/contracts/console"
+	"github.com/goravel/framework/contracts/console/command"
+	"github.com/goravel/framework/facades"
+
+	"panel/app/services"
+)
+
+type WebsiteStat struct {
+}
+
+// Signature The name and signature of the console command.
+func (receiver *WebsiteStat) Signature() string {
+	return "panel:website-stat"
+}
+
+// Description The console command description.
+func (receiver *WebsiteStat) Description() string {
+	return "[面板] 网站资源使用统计"
+}
+
+// Extend The console command extend.
+func (receiver *WebsiteStat) Extend() command.Extend {
+	return command.Extend{
+		Category: "panel",
+	}
+}
+
+// Handle Execute the console command.
+func (receiver *WebsiteStat) Handle(ctx console.Context) error {
+	statService := services.NewWebsiteStatImpl()
+	if err := statService.Collect(time.Second); err != nil {
+		facades.Log().Tags("面板", "网站统计").With(map[string]any{
+			"error": err.Error(),
+		}).Info("统计网站资源使用失败")
+	}
+	if err := statService.Prune(); err != nil {
+		facades.Log().Tags("面板", "网站统计").With(map[string]any{
+			"error": err.Error(),
+		}).Info("删除过期网站统计失败")
+	}
+
+	return nil
+}
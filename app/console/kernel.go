@@ -15,6 +15,12 @@ func (kernel *Kernel) Schedule() []schedule.Event {
 	return []schedule.Event{
 		facades.Schedule().Command("panel:monitoring").EveryMinute().SkipIfStillRunning(),
 		facades.Schedule().Command("panel:cert-renew").Daily().SkipIfStillRunning(),
+		facades.Schedule().Command("panel:cron-reconcile").Hourly().SkipIfStillRunning(),
+		facades.Schedule().Command("panel:check-update").Daily().SkipIfStillRunning(),
+		facades.Schedule().Command("panel:website-check").EveryMinute().SkipIfStillRunning(),
+		facades.Schedule().Command("panel:website-stat").EveryFiveMinutes().SkipIfStillRunning(),
+		facades.Schedule().Command("panel:php-fpm-stat").EveryMinute().SkipIfStillRunning(),
+		facades.Schedule().Command("panel:website-expiry").Daily().SkipIfStillRunning(),
 	}
 }
 
@@ -23,5 +29,11 @@ func (kernel *Kernel) Commands() []console.Command {
 		&commands.Panel{},
 		&commands.Monitoring{},
 		&commands.CertRenew{},
+		&commands.CronReconcile{},
+		&commands.CheckUpdate{},
+		&commands.WebsiteCheck{},
+		&commands.WebsiteStat{},
+		&commands.PhpFpmStat{},
+		&commands.WebsiteExpiry{},
 	}
 }
@@ -0,0 +1,335 @@
+package controllers
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/facades"
+	"github.com/goravel/framework/support/carbon"
+	"github.com/spf13/cast"
+
+	requests "panel/app/http/requests/alert"
+	commonrequests "panel/app/http/requests/common"
+	responses "panel/app/http/responses/alert"
+	"panel/app/models"
+	"panel/app/services"
+	"panel/pkg/alert"
+)
+
+type AlertController struct {
+	// Dependent services
+}
+
+func NewAlertController() *AlertController {
+	return &AlertController{
+		// Inject services
+	}
+}
+
+// Metrics
+//
+//	@Summary		获取监控指标
+//	@Description	获取告警规则支持的监控指标
+//	@Tags			监控告警
+//	@Produce		json
+//	@Security		BearerToken
+//	@Success		200	{object}	SuccessResponse
+//	@Router			/panel/alert/metrics [get]
+func (r *AlertController) Metrics(ctx http.Context) http.Response {
+	var metrics []map[string]string
+	for metric, name := range alert.Metrics {
+		metrics = append(metrics, map[string]string{
+			"name":   name,
+			"metric": metric,
+		})
+	}
+
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i]["metric"] < metrics[j]["metric"]
+	})
+
+	return Success(ctx, metrics)
+}
+
+// RuleList
+//
+//	@Summary		获取告警规则列表
+//	@Description	获取面板的监控告警规则列表
+//	@Tags			监控告警
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			data	body		commonrequests.Paginate	true	"request"
+//	@Success		200		{object}	SuccessResponse{data=responses.RuleList}
+//	@Router			/panel/alert/rules [get]
+func (r *AlertController) RuleList(ctx http.Context) http.Response {
+	var paginateRequest commonrequests.Paginate
+	sanitize := Sanitize(ctx, &paginateRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var rules []models.AlertRule
+	var total int64
+	err := facades.Orm().Query().Paginate(paginateRequest.Page, paginateRequest.Limit, &rules, &total)
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "监控告警").With(map[string]any{
+			"error": err.Error(),
+		}).Info("获取告警规则列表失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, responses.RuleList{
+		Total: total,
+		Items: rules,
+	})
+}
+
+// RuleStore
+//
+//	@Summary		添加告警规则
+//	@Description	添加面板的监控告警规则
+//	@Tags			监控告警
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			data	body		requests.RuleStore	true	"request"
+//	@Success		200		{object}	SuccessResponse
+//	@Router			/panel/alert/rules [post]
+func (r *AlertController) RuleStore(ctx http.Context) http.Response {
+	var storeRequest requests.RuleStore
+	sanitize := Sanitize(ctx, &storeRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	rule := models.AlertRule{
+		Name:      storeRequest.Name,
+		Metric:    storeRequest.Metric,
+		Target:    storeRequest.Target,
+		Operator:  storeRequest.Operator,
+		Threshold: storeRequest.Threshold,
+		Duration:  storeRequest.Duration,
+		Enabled:   storeRequest.Enabled,
+	}
+	if err := r.validate(rule); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+	if err := facades.Orm().Query().Create(&rule); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "监控告警").With(map[string]any{
+			"error": err.Error(),
+		}).Info("添加告警规则失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, nil)
+}
+
+// RuleUpdate
+//
+//	@Summary		更新告警规则
+//	@Description	更新面板的监控告警规则，更新后规则状态会被重置
+//	@Tags			监控告警
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id		path		int					true	"规则 ID"
+//	@Param			data	body		requests.RuleUpdate	true	"request"
+//	@Success		200		{object}	SuccessResponse
+//	@Router			/panel/alert/rules/{id} [put]
+func (r *AlertController) RuleUpdate(ctx http.Context) http.Response {
+	var updateRequest requests.RuleUpdate
+	sanitize := Sanitize(ctx, &updateRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var rule models.AlertRule
+	if err := facades.Orm().Query().Where("id", updateRequest.ID).FirstOrFail(&rule); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, "告警规则不存在")
+	}
+
+	rule.Name = updateRequest.Name
+	rule.Metric = updateRequest.Metric
+	rule.Target = updateRequest.Target
+	rule.Operator = updateRequest.Operator
+	rule.Threshold = updateRequest.Threshold
+	rule.Duration = updateRequest.Duration
+	rule.Enabled = updateRequest.Enabled
+	rule.State = alert.State{}
+	if err := r.validate(rule); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+	if err := facades.Orm().Query().Save(&rule); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "监控告警").With(map[string]any{
+			"ruleID": updateRequest.ID,
+			"error":  err.Error(),
+		}).Info("更新告警规则失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, nil)
+}
+
+// RuleDestroy
+//
+//	@Summary		删除告警规则
+//	@Description	删除面板的监控告警规则
+//	@Tags			监控告警
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"规则 ID"
+//	@Success		200	{object}	SuccessResponse
+//	@Router			/panel/alert/rules/{id} [delete]
+func (r *AlertController) RuleDestroy(ctx http.Context) http.Response {
+	var destroyRequest requests.RuleDestroy
+	sanitize := Sanitize(ctx, &destroyRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	if _, err := facades.Orm().Query().Where("id", destroyRequest.ID).Delete(&models.AlertRule{}); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "监控告警").With(map[string]any{
+			"ruleID": destroyRequest.ID,
+			"error":  err.Error(),
+		}).Info("删除告警规则失败")
+		return ErrorSystem(ctx)
+	}
+	_, _ = facades.Orm().Query().Where("rule_id", destroyRequest.ID).Delete(&models.AlertSilence{})
+
+	return Success(ctx, nil)
+}
+
+// SilenceList
+//
+//	@Summary		获取静默列表
+//	@Description	获取面板的告警静默列表
+//	@Tags			监控告警
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			data	body		commonrequests.Paginate	true	"request"
+//	@Success		200		{object}	SuccessResponse{data=responses.SilenceList}
+//	@Router			/panel/alert/silences [get]
+func (r *AlertController) SilenceList(ctx http.Context) http.Response {
+	var paginateRequest commonrequests.Paginate
+	sanitize := Sanitize(ctx, &paginateRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var silences []models.AlertSilence
+	var total int64
+	err := facades.Orm().Query().Order("id desc").Paginate(paginateRequest.Page, paginateRequest.Limit, &silences, &total)
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "监控告警").With(map[string]any{
+			"error": err.Error(),
+		}).Info("获取告警静默列表失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, responses.SilenceList{
+		Total: total,
+		Items: silences,
+	})
+}
+
+// SilenceStore
+//
+//	@Summary		添加静默
+//	@Description	在指定时间段内不发送告警通知，规则 ID 为 0 时静默全部规则
+//	@Tags			监控告警
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			data	body		requests.SilenceStore	true	"request"
+//	@Success		200		{object}	SuccessResponse
+//	@Router			/panel/alert/silences [post]
+func (r *AlertController) SilenceStore(ctx http.Context) http.Response {
+	var storeRequest requests.SilenceStore
+	sanitize := Sanitize(ctx, &storeRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	silence, err := r.silence(storeRequest)
+	if err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+	if err = facades.Orm().Query().Create(&silence); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "监控告警").With(map[string]any{
+			"error": err.Error(),
+		}).Info("添加告警静默失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, nil)
+}
+
+// SilenceDestroy
+//
+//	@Summary		删除静默
+//	@Description	删除面板的告警静默
+//	@Tags			监控告警
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"静默 ID"
+//	@Success		200	{object}	SuccessResponse
+//	@Router			/panel/alert/silences/{id} [delete]
+func (r *AlertController) SilenceDestroy(ctx http.Context) http.Response {
+	var destroyRequest requests.SilenceDestroy
+	sanitize := Sanitize(ctx, &destroyRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	if _, err := facades.Orm().Query().Where("id", destroyRequest.ID).Delete(&models.AlertSilence{}); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "监控告警").With(map[string]any{
+			"silenceID": destroyRequest.ID,
+			"error":     err.Error(),
+		}).Info("删除告警静默失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, nil)
+}
+
+// silence 根据请求生成静默
+func (r *AlertController) silence(storeRequest requests.SilenceStore) (models.AlertSilence, error) {
+	if storeRequest.RuleID > 0 {
+		var rule models.AlertRule
+		if err := facades.Orm().Query().Where("id", storeRequest.RuleID).FirstOrFail(&rule); err != nil {
+			return models.AlertSilence{}, errors.New("告警规则不存在")
+		}
+	}
+
+	startAt := carbon.Now()
+	if len(storeRequest.StartAt) > 0 {
+		startAt = carbon.Parse(storeRequest.StartAt)
+	}
+	endAt := carbon.Parse(storeRequest.EndAt)
+	if startAt.Error != nil || endAt.Error != nil {
+		return models.AlertSilence{}, errors.New("时间格式错误")
+	}
+	if !endAt.Gt(startAt) {
+		return models.AlertSilence{}, errors.New("结束时间需晚于开始时间")
+	}
+
+	return models.AlertSilence{
+		RuleID:  storeRequest.RuleID,
+		Reason:  storeRequest.Reason,
+		StartAt: carbon.DateTime{Carbon: startAt},
+		EndAt:   carbon.DateTime{Carbon: endAt},
+	}, nil
+}
+
+// validate 校验告警规则，速率类指标需开启系统监控
+func (r *AlertController) validate(rule models.AlertRule) error {
+	if err := alert.Validate(rule.Rule()); err != nil {
+		return err
+	}
+	if alert.Rate(rule.Metric) && !cast.ToBool(services.NewSettingImpl().Get(models.SettingKeyMonitor)) {
+		return errors.New(alert.Metrics[rule.Metric] + " 需开启系统监控后使用")
+	}
+
+	return nil
+}
@@ -564,7 +564,7 @@ func (r *CertController) CertDestroy(ctx http.Context) http.Response {
 			"certID": showAndDestroyRequest.ID,
 			"error":  err.Error(),
 		}).Info("删除证书失败")
-		return ErrorSystem(ctx)
+		return Error(ctx, http.StatusInternalServerError, err.Error())
 	}
 
 	return Success(ctx, nil)
@@ -1,15 +1,16 @@
 package controllers
 
 import (
-	"regexp"
 	"strconv"
 
 	"github.com/goravel/framework/contracts/http"
 	"github.com/goravel/framework/facades"
 	"github.com/goravel/framework/support/carbon"
-	"github.com/spf13/cast"
+
+	requests "panel/app/http/requests/cron"
 	"panel/app/models"
 	"panel/app/services"
+	"panel/pkg/crontab"
 	"panel/pkg/tools"
 )
 
@@ -28,7 +29,13 @@ func NewCronController() *CronController {
 // List 获取计划任务列表
 func (r *CronController) List(ctx http.Context) http.Response {
 	limit := ctx.Request().QueryInt("limit", 10)
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
 	page := ctx.Request().QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
 
 	var crons []models.Cron
 	var total int64
@@ -48,66 +55,20 @@ func (r *CronController) List(ctx http.Context) http.Response {
 
 // Add 添加计划任务
 func (r *CronController) Add(ctx http.Context) http.Response {
-	validator, err := ctx.Request().Validate(map[string]string{
-		"name":        "required|min_len:1|max_len:255",
-		"time":        "required",
-		"script":      "required",
-		"type":        "required|in:shell,backup,cutoff",
-		"backup_type": "required_if:type,backup|in:website,mysql,postgresql",
-	})
-	if err != nil {
-		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
-	}
-	if validator.Fails() {
-		return Error(ctx, http.StatusUnprocessableEntity, validator.Errors().One())
+	var addRequest requests.Add
+	sanitize := Sanitize(ctx, &addRequest)
+	if sanitize != nil {
+		return sanitize
 	}
 
 	// 单独验证时间格式
-	if !regexp.MustCompile(`^((\*|\d+|\d+-\d+|\d+/\d+|\d+-\d+/\d+|\*/\d+)(,(\*|\d+|\d+-\d+|\d+/\d+|\d+-\d+/\d+|\*/\d+))*\s?){5}$`).MatchString(ctx.Request().Input("time")) {
-		return Error(ctx, http.StatusUnprocessableEntity, "时间格式错误")
-	}
-
-	shell := ctx.Request().Input("script")
-	cronType := ctx.Request().Input("type")
-	if cronType == "backup" {
-		backupType := ctx.Request().Input("backup_type")
-		backupName := ctx.Request().Input("backup_database")
-		if backupType == "website" {
-			backupName = ctx.Request().Input("website")
-		}
-		backupPath := ctx.Request().Input("backup_path")
-		if len(backupPath) == 0 {
-			backupPath = r.setting.Get(models.SettingKeyBackupPath) + "/" + backupType
-		}
-		backupSave := ctx.Request().InputInt("save", 10)
-		shell = `#!/bin/bash
-export PATH=/bin:/sbin:/usr/bin:/usr/sbin:/usr/local/bin:/usr/local/sbin:$PATH
-
-# 耗子Linux面板 - 数据备份脚本
-
-type=` + backupType + `
-path=` + backupPath + `
-name=` + backupName + `
-save=` + cast.ToString(backupSave) + `
-
-# 执行备份
-panel backup ${type} ${name} ${path} ${save} 2>&1
-`
+	if err := crontab.Validate(addRequest.Time); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
 	}
-	if cronType == "cutoff" {
-		website := ctx.Request().Input("website")
-		save := ctx.Request().InputInt("save", 180)
-		shell = `#!/bin/bash
-export PATH=/bin:/sbin:/usr/bin:/usr/sbin:/usr/local/bin:/usr/local/sbin:$PATH
 
-# 耗子Linux面板 - 日志切割脚本
-
-name=` + website + `
-save=` + cast.ToString(save) + `
-
-# 执行切割
-panel cutoff ${name} ${save} 2>&1
-`
+	shell, config, err := r.script(addRequest.Type, addRequest.Script, addRequest.Config())
+	if err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
 	}
 
 	shellDir := "/www/server/cron/"
@@ -127,12 +88,16 @@ panel cutoff ${name} ${save} 2>&1
 	}
 
 	var cron models.Cron
-	cron.Name = ctx.Request().Input("name")
-	cron.Type = ctx.Request().Input("type")
+	cron.Name = addRequest.Name
+	cron.Type = addRequest.Type
 	cron.Status = true
-	cron.Time = ctx.Request().Input("time")
+	cron.Time = addRequest.Time
 	cron.Shell = shellDir + shellFile + ".sh"
 	cron.Log = shellLogDir + shellFile + ".log"
+	cron.Config = config
+	cron.Timeout = addRequest.Timeout
+	cron.Overlap = addRequest.Overlap
+	cron.Notify = addRequest.Notify
 
 	err = facades.Orm().Query().Create(&cron)
 	if err != nil {
@@ -142,7 +107,7 @@ panel cutoff ${name} ${save} 2>&1
 		return ErrorSystem(ctx)
 	}
 
-	if err := r.cron.AddToSystem(cron); err != nil {
+	if err = r.cron.Sync(); err != nil {
 		return Error(ctx, http.StatusInternalServerError, err.Error())
 	}
 
@@ -169,25 +134,19 @@ func (r *CronController) Script(ctx http.Context) http.Response {
 
 // Update 更新计划任务
 func (r *CronController) Update(ctx http.Context) http.Response {
-	validator, err := ctx.Request().Validate(map[string]string{
-		"name":   "required|min_len:1|max_len:255",
-		"time":   "required",
-		"script": "required",
-	})
-	if err != nil {
-		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
-	}
-	if validator.Fails() {
-		return Error(ctx, http.StatusUnprocessableEntity, validator.Errors().One())
+	var updateRequest requests.Update
+	sanitize := Sanitize(ctx, &updateRequest)
+	if sanitize != nil {
+		return sanitize
 	}
 
 	// 单独验证时间格式
-	if !regexp.MustCompile(`^((\*|\d+|\d+-\d+|\d+/\d+|\d+-\d+/\d+|\*/\d+)(,(\*|\d+|\d+-\d+|\d+/\d+|\d+-\d+/\d+|\*/\d+))*\s?){5}$`).MatchString(ctx.Request().Input("time")) {
-		return Error(ctx, http.StatusUnprocessableEntity, "时间格式错误")
+	if err := crontab.Validate(updateRequest.Time); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
 	}
 
 	var cron models.Cron
-	err = facades.Orm().Query().Where("id", ctx.Request().Input("id")).FirstOrFail(&cron)
+	err := facades.Orm().Query().Where("id", updateRequest.ID).FirstOrFail(&cron)
 	if err != nil {
 		return Error(ctx, http.StatusUnprocessableEntity, "计划任务不存在")
 	}
@@ -196,8 +155,32 @@ func (r *CronController) Update(ctx http.Context) http.Response {
 		return Error(ctx, http.StatusUnprocessableEntity, "计划任务已禁用")
 	}
 
-	cron.Time = ctx.Request().Input("time")
-	cron.Name = ctx.Request().Input("name")
+	// 旧版本的表单不提交类型和配置，沿用已保存的类型和配置
+	cronType, config := updateRequest.Type, updateRequest.Config()
+	if len(cronType) == 0 {
+		cronType, config = cron.Type, cron.Config
+	}
+	var shell string
+	if cronType != crontab.TypeShell && cronType == cron.Type && config == (crontab.Config{}) {
+		// 旧版本创建的模板任务没有保存配置，只能直接修改脚本
+		if len(updateRequest.Script) == 0 {
+			return Error(ctx, http.StatusUnprocessableEntity, "该计划任务创建于旧版本，请直接修改脚本")
+		}
+		shell = updateRequest.Script
+	} else if shell, config, err = r.script(cronType, updateRequest.Script, config); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+	if len(shell) == 0 {
+		return Error(ctx, http.StatusUnprocessableEntity, "脚本不能为空")
+	}
+
+	cron.Time = updateRequest.Time
+	cron.Name = updateRequest.Name
+	cron.Type = cronType
+	cron.Config = config
+	cron.Timeout = updateRequest.Timeout
+	cron.Overlap = updateRequest.Overlap
+	cron.Notify = updateRequest.Notify
 	err = facades.Orm().Query().Save(&cron)
 	if err != nil {
 		facades.Log().Request(ctx.Request()).Tags("面板", "计划任务").With(map[string]any{
@@ -206,21 +189,16 @@ func (r *CronController) Update(ctx http.Context) http.Response {
 		return ErrorSystem(ctx)
 	}
 
-	if err = tools.Write(cron.Shell, ctx.Request().Input("script"), 0644); err != nil {
+	if err = tools.Write(cron.Shell, shell, 0700); err != nil {
 		return Error(ctx, http.StatusInternalServerError, err.Error())
 	}
 	if out, err := tools.Exec("dos2unix " + cron.Shell); err != nil {
 		return Error(ctx, http.StatusInternalServerError, out)
 	}
 
-	if err := r.cron.DeleteFromSystem(cron); err != nil {
+	if err = r.cron.Sync(); err != nil {
 		return Error(ctx, http.StatusInternalServerError, err.Error())
 	}
-	if cron.Status {
-		if err := r.cron.AddToSystem(cron); err != nil {
-			return Error(ctx, http.StatusInternalServerError, err.Error())
-		}
-	}
 
 	return Success(ctx, nil)
 }
@@ -232,19 +210,25 @@ func (r *CronController) Delete(ctx http.Context) http.Response {
 		return Error(ctx, http.StatusUnprocessableEntity, "计划任务不存在")
 	}
 
-	if err := r.cron.DeleteFromSystem(cron); err != nil {
-		return Error(ctx, http.StatusInternalServerError, err.Error())
-	}
-	if err := tools.Remove(cron.Shell); err != nil {
-		return Error(ctx, http.StatusInternalServerError, err.Error())
-	}
-
 	if _, err := facades.Orm().Query().Delete(&cron); err != nil {
 		facades.Log().Request(ctx.Request()).Tags("面板", "计划任务").With(map[string]any{
 			"error": err.Error(),
 		}).Info("删除计划任务失败")
 		return ErrorSystem(ctx)
 	}
+	if _, err := facades.Orm().Query().Where("cron_id", cron.ID).Delete(&models.CronRun{}); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "计划任务").With(map[string]any{
+			"error": err.Error(),
+		}).Info("删除计划任务运行记录失败")
+		return ErrorSystem(ctx)
+	}
+
+	if err := r.cron.Sync(); err != nil {
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+	if err := tools.Remove(cron.Shell); err != nil {
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
 
 	return Success(ctx, nil)
 }
@@ -276,33 +260,102 @@ func (r *CronController) Status(ctx http.Context) http.Response {
 		return ErrorSystem(ctx)
 	}
 
-	if err := r.cron.DeleteFromSystem(cron); err != nil {
+	if err = r.cron.Sync(); err != nil {
 		return Error(ctx, http.StatusInternalServerError, err.Error())
 	}
-	if cron.Status {
-		if err := r.cron.AddToSystem(cron); err != nil {
-			return Error(ctx, http.StatusInternalServerError, err.Error())
-		}
-	}
 
 	return Success(ctx, nil)
 }
 
-// Log 获取计划任务日志
+// Log 获取计划任务运行记录
 func (r *CronController) Log(ctx http.Context) http.Response {
 	var cron models.Cron
 	if err := facades.Orm().Query().Where("id", ctx.Request().Input("id")).FirstOrFail(&cron); err != nil {
 		return Error(ctx, http.StatusUnprocessableEntity, "计划任务不存在")
 	}
 
-	if !tools.Exists(cron.Log) {
-		return Error(ctx, http.StatusUnprocessableEntity, "日志文件不存在")
+	limit := ctx.Request().QueryInt("limit", 10)
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	page := ctx.Request().QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+
+	var runs []models.CronRun
+	var total int64
+	if err := facades.Orm().Query().Where("cron_id", cron.ID).Order("id desc").Paginate(page, limit, &runs, &total); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "计划任务").With(map[string]any{
+			"error": err.Error(),
+		}).Info("查询计划任务运行记录失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, http.Json{
+		"total": total,
+		"items": runs,
+	})
+}
+
+// Preview 预览计划任务接下来的运行时间
+func (r *CronController) Preview(ctx http.Context) http.Response {
+	count := ctx.Request().QueryInt("count", 5)
+	if count < 1 || count > 50 {
+		count = 5
+	}
+
+	runs, err := r.cron.NextRuns(ctx.Request().Query("time"), count)
+	if err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
 	}
 
-	log, err := tools.Exec("tail -n 1000 " + cron.Log)
+	return Success(ctx, runs)
+}
+
+// Drift 检测系统计划任务与面板数据的差异
+func (r *CronController) Drift(ctx http.Context) http.Response {
+	drift, err := r.cron.Drift()
 	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "计划任务").With(map[string]any{
+			"error": err.Error(),
+		}).Info("检测计划任务差异失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, http.Json{
+		"clean": drift.Clean(),
+		"drift": drift,
+	})
+}
+
+// Sync 根据面板数据重写系统计划任务
+func (r *CronController) Sync(ctx http.Context) http.Response {
+	if err := r.cron.Sync(); err != nil {
 		return Error(ctx, http.StatusInternalServerError, err.Error())
 	}
 
-	return Success(ctx, log)
+	return Success(ctx, nil)
+}
+
+// script 生成计划任务脚本，自定义脚本直接使用提交的内容
+func (r *CronController) script(cronType, script string, config crontab.Config) (string, crontab.Config, error) {
+	switch cronType {
+	case crontab.TypeShell:
+		return script, crontab.Config{}, nil
+	case crontab.TypeBackup:
+		if len(config.Path) == 0 {
+			config.Path = r.setting.Get(models.SettingKeyBackupPath) + "/" + config.BackupType
+		}
+		if config.Save == 0 {
+			config.Save = 10
+		}
+	case crontab.TypeCutoff:
+		if config.Save == 0 {
+			config.Save = 180
+		}
+	}
+
+	shell, err := crontab.Script(cronType, config)
+	return shell, config, err
 }
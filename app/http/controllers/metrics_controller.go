@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"github.com/goravel/framework/contracts/http"
+
+	"panel/app/services"
+	"panel/pkg/metrics"
+)
+
+type MetricsController struct {
+	metrics services.Metrics
+}
+
+func NewMetricsController() *MetricsController {
+	return &MetricsController{
+		metrics: services.NewMetricsImpl(),
+	}
+}
+
+// Index
+//
+//	@Summary		Prometheus 指标
+//	@Description	以 Prometheus 文本格式输出主机和面板的监控指标，需使用指标令牌鉴权
+//	@Tags			面板设置
+//	@Produce		plain
+//	@Param			token	query		string	false	"指标令牌，也可通过 Authorization: Bearer 传递"
+//	@Success		200		{string}	string
+//	@Router			/metrics [get]
+func (r *MetricsController) Index(ctx http.Context) http.Response {
+	return ctx.Response().Data(http.StatusOK, metrics.ContentType, []byte(r.metrics.Render()))
+}
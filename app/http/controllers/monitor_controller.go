@@ -2,6 +2,8 @@ package controllers
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/goravel/framework/contracts/http"
 	"github.com/goravel/framework/facades"
@@ -10,15 +12,21 @@ import (
 
 	"panel/app/models"
 	"panel/app/services"
+	"panel/pkg/alert"
+	monitorpkg "panel/pkg/monitor"
 )
 
 type MonitorController struct {
 	setting services.Setting
+	monitor services.Monitor
+	process services.Process
 }
 
 func NewMonitorController() *MonitorController {
 	return &MonitorController{
 		setting: services.NewSettingImpl(),
+		monitor: services.NewMonitorImpl(),
+		process: services.NewProcessImpl(),
 	}
 }
 
@@ -37,16 +45,37 @@ func (r *MonitorController) Switch(ctx http.Context) http.Response {
 	return Success(ctx, nil)
 }
 
-// SaveDays 保存监控天数
+// SaveDays 保存监控数据保留天数，days 为分钟粒度，days_5m 和 days_1h 为聚合数据
 func (r *MonitorController) SaveDays(ctx http.Context) http.Response {
-	days := ctx.Request().Input("days")
-	err := r.setting.Set(models.SettingKeyMonitorDays, days)
+	validator, err := ctx.Request().Validate(map[string]string{
+		"days":    "required|int|min:0",
+		"days_5m": "int|min:0",
+		"days_1h": "int|min:0",
+	})
 	if err != nil {
-		facades.Log().Request(ctx.Request()).Tags("面板", "资源监控").With(map[string]any{
-			"days":  days,
-			"error": err.Error(),
-		}).Info("更新监控开关失败")
-		return ErrorSystem(ctx)
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+	if validator.Fails() {
+		return Error(ctx, http.StatusUnprocessableEntity, validator.Errors().One())
+	}
+
+	settings := map[string]string{
+		models.SettingKeyMonitorDays:   ctx.Request().Input("days"),
+		models.SettingKeyMonitorDays5m: ctx.Request().Input("days_5m"),
+		models.SettingKeyMonitorDays1h: ctx.Request().Input("days_1h"),
+	}
+	for key, value := range settings {
+		if len(value) == 0 {
+			continue
+		}
+		if err = r.setting.Set(key, value); err != nil {
+			facades.Log().Request(ctx.Request()).Tags("面板", "资源监控").With(map[string]any{
+				"key":   key,
+				"value": value,
+				"error": err.Error(),
+			}).Info("更新监控保留天数失败")
+			return ErrorSystem(ctx)
+		}
 	}
 
 	return Success(ctx, nil)
@@ -55,18 +84,18 @@ func (r *MonitorController) SaveDays(ctx http.Context) http.Response {
 // SwitchAndDays 监控开关和监控天数
 func (r *MonitorController) SwitchAndDays(ctx http.Context) http.Response {
 	monitor := r.setting.Get(models.SettingKeyMonitor)
-	monitorDays := r.setting.Get(models.SettingKeyMonitorDays)
 
 	return Success(ctx, http.Json{
-		"switch": cast.ToBool(monitor),
-		"days":   cast.ToInt(monitorDays),
+		"switch":  cast.ToBool(monitor),
+		"days":    r.monitor.Retention(monitorpkg.ResolutionMinute),
+		"days_5m": r.monitor.Retention(monitorpkg.ResolutionFiveMinutes),
+		"days_1h": r.monitor.Retention(monitorpkg.ResolutionHour),
 	})
 }
 
 // Clear 清空监控数据
 func (r *MonitorController) Clear(ctx http.Context) http.Response {
-	_, err := facades.Orm().Query().Where("1 = 1").Delete(&models.Monitor{})
-	if err != nil {
+	if err := r.monitor.Clear(); err != nil {
 		facades.Log().Request(ctx.Request()).Tags("面板", "资源监控").With(map[string]any{
 			"error": err.Error(),
 		}).Info("清空监控数据失败")
@@ -76,6 +105,119 @@ func (r *MonitorController) Clear(ctx http.Context) http.Response {
 	return Success(ctx, nil)
 }
 
+// Query 查询时间范围内指标的最小值、平均值和最大值
+func (r *MonitorController) Query(ctx http.Context) http.Response {
+	start := carbon.FromTimestampMilli(ctx.Request().QueryInt64("start")).Timestamp()
+	end := carbon.FromTimestampMilli(ctx.Request().QueryInt64("end")).Timestamp()
+	if end <= start {
+		return Error(ctx, http.StatusUnprocessableEntity, "结束时间需晚于开始时间")
+	}
+
+	var metrics []string
+	for _, metric := range strings.Split(ctx.Request().Query("metrics"), ",") {
+		if metric = strings.TrimSpace(metric); len(metric) > 0 {
+			metrics = append(metrics, metric)
+		}
+	}
+
+	series, step, err := r.monitor.Query(metrics, start, end, ctx.Request().QueryInt64("step"))
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "资源监控").With(map[string]any{
+			"start": start,
+			"end":   end,
+			"error": err.Error(),
+		}).Info("查询监控数据失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, http.Json{
+		"step":   step,
+		"series": series,
+	})
+}
+
+// Processes 资源占用最高的进程，time 为空时返回最近一次采样，未开启监控时实时采样
+func (r *MonitorController) Processes(ctx http.Context) http.Response {
+	at := int64(0)
+	if ms := ctx.Request().QueryInt64("time"); ms > 0 {
+		at = carbon.FromTimestampMilli(ms).Timestamp()
+	}
+
+	processes, err := r.monitor.Processes(at)
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "资源监控").With(map[string]any{
+			"error": err.Error(),
+		}).Info("获取进程数据失败")
+		return ErrorSystem(ctx)
+	}
+	if len(processes) > 0 || at > 0 {
+		return Success(ctx, processes)
+	}
+
+	report, err := r.process.Collect(time.Second)
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "资源监控").With(map[string]any{
+			"error": err.Error(),
+		}).Info("采样进程资源失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, report.Top)
+}
+
+// Services 已安装插件的服务和 Supervisor 程序的实时资源占用
+func (r *MonitorController) Services(ctx http.Context) http.Response {
+	report, err := r.process.Collect(time.Second)
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "资源监控").With(map[string]any{
+			"error": err.Error(),
+		}).Info("采样进程资源失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, http.Json{
+		"services": report.Services,
+		"plugins":  report.Plugins,
+	})
+}
+
+// Service 单个服务在时间范围内的资源占用历史
+func (r *MonitorController) Service(ctx http.Context) http.Response {
+	name := ctx.Request().Query("name")
+	start := carbon.FromTimestampMilli(ctx.Request().QueryInt64("start")).Timestamp()
+	end := carbon.FromTimestampMilli(ctx.Request().QueryInt64("end")).Timestamp()
+	if len(name) == 0 {
+		return Error(ctx, http.StatusUnprocessableEntity, "服务名不能为空")
+	}
+	if end <= start {
+		return Error(ctx, http.StatusUnprocessableEntity, "结束时间需晚于开始时间")
+	}
+
+	var metrics []string
+	for _, metric := range monitorpkg.ServiceMetrics {
+		metrics = append(metrics, alert.Key(metric, name))
+	}
+	series, step, err := r.monitor.Query(metrics, start, end, ctx.Request().QueryInt64("step"))
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "资源监控").With(map[string]any{
+			"name":  name,
+			"error": err.Error(),
+		}).Info("查询服务监控数据失败")
+		return ErrorSystem(ctx)
+	}
+
+	// 返回时去掉指标中的服务名
+	result := make(map[string][]monitorpkg.Point)
+	for _, metric := range monitorpkg.ServiceMetrics {
+		result[metric] = series[alert.Key(metric, name)]
+	}
+
+	return Success(ctx, http.Json{
+		"step":   step,
+		"series": result,
+	})
+}
+
 // List 监控数据列表
 func (r *MonitorController) List(ctx http.Context) http.Response {
 	start := ctx.Request().InputInt64("start")
@@ -83,8 +225,12 @@ func (r *MonitorController) List(ctx http.Context) http.Response {
 	startTime := carbon.FromTimestampMilli(start)
 	endTime := carbon.FromTimestampMilli(end)
 
-	var monitors []models.Monitor
-	err := facades.Orm().Query().Where("created_at >= ?", startTime.ToDateTimeString()).Where("created_at <= ?", endTime.ToDateTimeString()).Get(&monitors)
+	series, _, err := r.monitor.Query([]string{
+		alert.MetricCPU, alert.MetricLoad1, alert.MetricLoad5, alert.MetricLoad15,
+		monitorpkg.MetricMemTotal, monitorpkg.MetricMemAvailable, monitorpkg.MetricMemUsed,
+		monitorpkg.MetricSwapTotal, monitorpkg.MetricSwapUsed, monitorpkg.MetricSwapFree,
+		monitorpkg.MetricNetSent, monitorpkg.MetricNetRecv, alert.MetricNetTx, alert.MetricNetRx,
+	}, startTime.Timestamp(), endTime.Timestamp(), 0)
 	if err != nil {
 		facades.Log().Request(ctx.Request()).Tags("面板", "资源监控").With(map[string]any{
 			"start": startTime.ToDateTimeString(),
@@ -94,7 +240,7 @@ func (r *MonitorController) List(ctx http.Context) http.Response {
 		return ErrorSystem(ctx)
 	}
 
-	if len(monitors) == 0 {
+	if len(series[alert.MetricCPU]) == 0 {
 		return Error(ctx, http.StatusNotFound, "监控数据为空")
 	}
 
@@ -131,53 +277,36 @@ func (r *MonitorController) List(ctx http.Context) http.Response {
 		Net   network  `json:"net"`
 	}
 
-	var data monitorData
-	var bytesSent uint64
-	var bytesRecv uint64
-	var bytesSent2 uint64
-	var bytesRecv2 uint64
-	for _, net := range monitors[0].Info.Net {
-		if net.Name == "lo" {
-			continue
-		}
-		bytesSent += net.BytesSent
-		bytesRecv += net.BytesRecv
-	}
-	for i, monitor := range monitors {
-		// 跳过第一条数据，因为第一条数据的流量为 0
-		if i == 0 {
-			// MB
-			data.Mem.Total = fmt.Sprintf("%.2f", float64(monitor.Info.Mem.Total)/1024/1024)
-			data.Swap.Total = fmt.Sprintf("%.2f", float64(monitor.Info.Swap.Total)/1024/1024)
-			continue
+	// 按时间索引各指标的平均值，缺失的数据记为 0
+	values := make(map[string]map[int64]float64)
+	for metric, points := range series {
+		values[metric] = make(map[int64]float64)
+		for _, point := range points {
+			values[metric][point.Time] = point.Avg
 		}
-		for _, net := range monitor.Info.Net {
-			if net.Name == "lo" {
-				continue
-			}
-			bytesSent2 += net.BytesSent
-			bytesRecv2 += net.BytesRecv
-		}
-		data.Times = append(data.Times, monitor.CreatedAt.ToDateTimeString())
-		data.Load.Load1 = append(data.Load.Load1, monitor.Info.Load.Load1)
-		data.Load.Load5 = append(data.Load.Load5, monitor.Info.Load.Load5)
-		data.Load.Load15 = append(data.Load.Load15, monitor.Info.Load.Load15)
-		data.Cpu.Percent = append(data.Cpu.Percent, fmt.Sprintf("%.2f", monitor.Info.Percent[0]))
-		data.Mem.Available = append(data.Mem.Available, fmt.Sprintf("%.2f", float64(monitor.Info.Mem.Available)/1024/1024))
-		data.Mem.Used = append(data.Mem.Used, fmt.Sprintf("%.2f", float64(monitor.Info.Mem.Used)/1024/1024))
-		data.Swap.Used = append(data.Swap.Used, fmt.Sprintf("%.2f", float64(monitor.Info.Swap.Used)/1024/1024))
-		data.Swap.Free = append(data.Swap.Free, fmt.Sprintf("%.2f", float64(monitor.Info.Swap.Free)/1024/1024))
-		data.Net.Sent = append(data.Net.Sent, fmt.Sprintf("%.2f", float64(bytesSent2/1024/1024)))
-		data.Net.Recv = append(data.Net.Recv, fmt.Sprintf("%.2f", float64(bytesRecv2/1024/1024)))
-
-		// 监控频率为 1 分钟，所以这里除以 60 即可得到每秒的流量
-		data.Net.Tx = append(data.Net.Tx, fmt.Sprintf("%.2f", float64(bytesSent2-bytesSent)/60/1024/1024))
-		data.Net.Rx = append(data.Net.Rx, fmt.Sprintf("%.2f", float64(bytesRecv2-bytesRecv)/60/1024/1024))
-
-		bytesSent = bytesSent2
-		bytesRecv = bytesRecv2
-		bytesSent2 = 0
-		bytesRecv2 = 0
+	}
+	mb := func(metric string, time int64) string {
+		return fmt.Sprintf("%.2f", values[metric][time]/1024/1024)
+	}
+
+	var data monitorData
+	last := series[alert.MetricCPU][len(series[alert.MetricCPU])-1].Time
+	data.Mem.Total = mb(monitorpkg.MetricMemTotal, last)
+	data.Swap.Total = mb(monitorpkg.MetricSwapTotal, last)
+	for _, point := range series[alert.MetricCPU] {
+		data.Times = append(data.Times, carbon.FromTimestamp(point.Time).ToDateTimeString())
+		data.Load.Load1 = append(data.Load.Load1, values[alert.MetricLoad1][point.Time])
+		data.Load.Load5 = append(data.Load.Load5, values[alert.MetricLoad5][point.Time])
+		data.Load.Load15 = append(data.Load.Load15, values[alert.MetricLoad15][point.Time])
+		data.Cpu.Percent = append(data.Cpu.Percent, fmt.Sprintf("%.2f", point.Avg))
+		data.Mem.Available = append(data.Mem.Available, mb(monitorpkg.MetricMemAvailable, point.Time))
+		data.Mem.Used = append(data.Mem.Used, mb(monitorpkg.MetricMemUsed, point.Time))
+		data.Swap.Used = append(data.Swap.Used, mb(monitorpkg.MetricSwapUsed, point.Time))
+		data.Swap.Free = append(data.Swap.Free, mb(monitorpkg.MetricSwapFree, point.Time))
+		data.Net.Sent = append(data.Net.Sent, mb(monitorpkg.MetricNetSent, point.Time))
+		data.Net.Recv = append(data.Net.Recv, mb(monitorpkg.MetricNetRecv, point.Time))
+		data.Net.Tx = append(data.Net.Tx, fmt.Sprintf("%.2f", values[alert.MetricNetTx][point.Time]))
+		data.Net.Rx = append(data.Net.Rx, fmt.Sprintf("%.2f", values[alert.MetricNetRx][point.Time]))
 	}
 
 	return Success(ctx, data)
@@ -0,0 +1,415 @@
+package controllers
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/facades"
+	"github.com/spf13/cast"
+
+	commonrequests "panel/app/http/requests/common"
+	requests "panel/app/http/requests/notification"
+	responses "panel/app/http/responses/notification"
+	"panel/app/models"
+	"panel/app/services"
+	"panel/pkg/notify"
+)
+
+type NotificationController struct {
+	notification services.Notification
+	setting      services.Setting
+}
+
+func NewNotificationController() *NotificationController {
+	return &NotificationController{
+		notification: services.NewNotificationImpl(),
+		setting:      services.NewSettingImpl(),
+	}
+}
+
+// Events
+//
+//	@Summary		获取通知事件
+//	@Description	获取可订阅的通知事件列表
+//	@Tags			消息通知
+//	@Produce		json
+//	@Security		BearerToken
+//	@Success		200	{object}	SuccessResponse
+//	@Router			/panel/notification/events [get]
+func (r *NotificationController) Events(ctx http.Context) http.Response {
+	var events []map[string]string
+	for event, name := range models.NotificationEvents {
+		events = append(events, map[string]string{
+			"name":  name,
+			"event": event,
+		})
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i]["event"] < events[j]["event"]
+	})
+
+	return Success(ctx, events)
+}
+
+// Types
+//
+//	@Summary		获取渠道类型
+//	@Description	获取面板支持的通知渠道类型
+//	@Tags			消息通知
+//	@Produce		json
+//	@Security		BearerToken
+//	@Success		200	{object}	SuccessResponse
+//	@Router			/panel/notification/types [get]
+func (r *NotificationController) Types(ctx http.Context) http.Response {
+	return Success(ctx, []map[string]string{
+		{
+			"name": "邮件",
+			"type": notify.TypeEmail,
+		},
+		{
+			"name": "Webhook",
+			"type": notify.TypeWebhook,
+		},
+		{
+			"name": "Telegram",
+			"type": notify.TypeTelegram,
+		},
+		{
+			"name": "钉钉",
+			"type": notify.TypeDingTalk,
+		},
+		{
+			"name": "企业微信",
+			"type": notify.TypeWeCom,
+		},
+	})
+}
+
+// ChannelList
+//
+//	@Summary		获取通知渠道列表
+//	@Description	获取面板的通知渠道列表
+//	@Tags			消息通知
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			data	body		commonrequests.Paginate	true	"request"
+//	@Success		200		{object}	SuccessResponse{data=responses.ChannelList}
+//	@Router			/panel/notification/channels [get]
+func (r *NotificationController) ChannelList(ctx http.Context) http.Response {
+	var paginateRequest commonrequests.Paginate
+	sanitize := Sanitize(ctx, &paginateRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var channels []models.NotificationChannel
+	var total int64
+	err := facades.Orm().Query().Paginate(paginateRequest.Page, paginateRequest.Limit, &channels, &total)
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "消息通知").With(map[string]any{
+			"error": err.Error(),
+		}).Info("获取通知渠道列表失败")
+		return ErrorSystem(ctx)
+	}
+
+	for i := range channels {
+		channels[i].Config = channels[i].Config.Mask()
+	}
+
+	return Success(ctx, responses.ChannelList{
+		Total: total,
+		Items: channels,
+	})
+}
+
+// ChannelStore
+//
+//	@Summary		添加通知渠道
+//	@Description	添加面板的通知渠道
+//	@Tags			消息通知
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			data	body		requests.ChannelStore	true	"request"
+//	@Success		200		{object}	SuccessResponse
+//	@Router			/panel/notification/channels [post]
+func (r *NotificationController) ChannelStore(ctx http.Context) http.Response {
+	var storeRequest requests.ChannelStore
+	sanitize := Sanitize(ctx, &storeRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+	if err := r.check(storeRequest.Type, storeRequest.Config, storeRequest.Events); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	channel := models.NotificationChannel{
+		Name:    storeRequest.Name,
+		Type:    storeRequest.Type,
+		Config:  storeRequest.Config,
+		Events:  storeRequest.Events,
+		Enabled: storeRequest.Enabled,
+	}
+	if err := facades.Orm().Query().Create(&channel); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "消息通知").With(map[string]any{
+			"error": err.Error(),
+		}).Info("添加通知渠道失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, nil)
+}
+
+// ChannelUpdate
+//
+//	@Summary		更新通知渠道
+//	@Description	更新面板的通知渠道
+//	@Tags			消息通知
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id		path		int						true	"渠道 ID"
+//	@Param			data	body		requests.ChannelUpdate	true	"request"
+//	@Success		200		{object}	SuccessResponse
+//	@Router			/panel/notification/channels/{id} [put]
+func (r *NotificationController) ChannelUpdate(ctx http.Context) http.Response {
+	var updateRequest requests.ChannelUpdate
+	sanitize := Sanitize(ctx, &updateRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var channel models.NotificationChannel
+	if err := facades.Orm().Query().Where("id", updateRequest.ID).FirstOrFail(&channel); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, "通知渠道不存在")
+	}
+	var exists int64
+	if err := facades.Orm().Query().Model(&models.NotificationChannel{}).Where("name", updateRequest.Name).Where("id != ?", updateRequest.ID).Count(&exists); err != nil {
+		return ErrorSystem(ctx)
+	}
+	if exists > 0 {
+		return Error(ctx, http.StatusUnprocessableEntity, "渠道名称已存在")
+	}
+
+	// 列表和详情返回的敏感字段为占位值，未修改时保持原值
+	config := updateRequest.Config.Restore(channel.Config)
+	if err := r.check(updateRequest.Type, config, updateRequest.Events); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	channel.Name = updateRequest.Name
+	channel.Type = updateRequest.Type
+	channel.Config = config
+	channel.Events = updateRequest.Events
+	channel.Enabled = updateRequest.Enabled
+	if err := facades.Orm().Query().Save(&channel); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "消息通知").With(map[string]any{
+			"channelID": updateRequest.ID,
+			"error":     err.Error(),
+		}).Info("更新通知渠道失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, nil)
+}
+
+// ChannelShow
+//
+//	@Summary		获取通知渠道
+//	@Description	获取面板的通知渠道
+//	@Tags			消息通知
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"渠道 ID"
+//	@Success		200	{object}	SuccessResponse{data=models.NotificationChannel}
+//	@Router			/panel/notification/channels/{id} [get]
+func (r *NotificationController) ChannelShow(ctx http.Context) http.Response {
+	var showAndDestroyRequest requests.ChannelShowAndDestroy
+	sanitize := Sanitize(ctx, &showAndDestroyRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var channel models.NotificationChannel
+	if err := facades.Orm().Query().Where("id", showAndDestroyRequest.ID).FirstOrFail(&channel); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, "通知渠道不存在")
+	}
+	channel.Config = channel.Config.Mask()
+
+	return Success(ctx, channel)
+}
+
+// ChannelDestroy
+//
+//	@Summary		删除通知渠道
+//	@Description	删除面板的通知渠道
+//	@Tags			消息通知
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"渠道 ID"
+//	@Success		200	{object}	SuccessResponse
+//	@Router			/panel/notification/channels/{id} [delete]
+func (r *NotificationController) ChannelDestroy(ctx http.Context) http.Response {
+	var showAndDestroyRequest requests.ChannelShowAndDestroy
+	sanitize := Sanitize(ctx, &showAndDestroyRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	if _, err := facades.Orm().Query().Where("id", showAndDestroyRequest.ID).Delete(&models.NotificationChannel{}); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "消息通知").With(map[string]any{
+			"channelID": showAndDestroyRequest.ID,
+			"error":     err.Error(),
+		}).Info("删除通知渠道失败")
+		return ErrorSystem(ctx)
+	}
+	_, _ = facades.Orm().Query().Where("channel_id", showAndDestroyRequest.ID).Delete(&models.NotificationLog{})
+
+	return Success(ctx, nil)
+}
+
+// ChannelTest
+//
+//	@Summary		测试通知渠道
+//	@Description	通过指定渠道发送一条测试通知
+//	@Tags			消息通知
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"渠道 ID"
+//	@Success		200	{object}	SuccessResponse
+//	@Router			/panel/notification/channels/{id}/test [post]
+func (r *NotificationController) ChannelTest(ctx http.Context) http.Response {
+	var showAndDestroyRequest requests.ChannelShowAndDestroy
+	sanitize := Sanitize(ctx, &showAndDestroyRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var channel models.NotificationChannel
+	if err := facades.Orm().Query().Where("id", showAndDestroyRequest.ID).FirstOrFail(&channel); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, "通知渠道不存在")
+	}
+
+	name := facades.Config().GetString("panel.name")
+	if err := r.notification.Send(channel, models.NotificationEventTest, name+"测试通知", "这是一条来自"+name+"的测试通知，收到即表示通知渠道配置正确。"); err != nil {
+		return Error(ctx, http.StatusInternalServerError, "发送失败: "+err.Error())
+	}
+
+	return Success(ctx, nil)
+}
+
+// LogList
+//
+//	@Summary		获取通知记录
+//	@Description	获取面板的通知发送记录
+//	@Tags			消息通知
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			data	body		commonrequests.Paginate	true	"request"
+//	@Success		200		{object}	SuccessResponse{data=responses.LogList}
+//	@Router			/panel/notification/logs [get]
+func (r *NotificationController) LogList(ctx http.Context) http.Response {
+	var paginateRequest commonrequests.Paginate
+	sanitize := Sanitize(ctx, &paginateRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var logs []models.NotificationLog
+	var total int64
+	err := facades.Orm().Query().Order("id desc").Paginate(paginateRequest.Page, paginateRequest.Limit, &logs, &total)
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "消息通知").With(map[string]any{
+			"error": err.Error(),
+		}).Info("获取通知记录失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, responses.LogList{
+		Total: total,
+		Items: logs,
+	})
+}
+
+// Settings
+//
+//	@Summary		获取通知设置
+//	@Description	获取通知频率限制、告警阈值和网站到期提醒天数设置
+//	@Tags			消息通知
+//	@Produce		json
+//	@Security		BearerToken
+//	@Success		200	{object}	SuccessResponse
+//	@Router			/panel/notification/settings [get]
+func (r *NotificationController) Settings(ctx http.Context) http.Response {
+	return Success(ctx, http.Json{
+		"interval":      cast.ToInt(r.setting.Get(models.SettingKeyNotificationInterval, "60")),
+		"hourly_limit":  cast.ToInt(r.setting.Get(models.SettingKeyNotificationHourlyLimit, "20")),
+		"disk_usage":    cast.ToInt(r.setting.Get(models.SettingKeyNotificationDiskUsage, "90")),
+		"expiry_remind": r.setting.Get(models.SettingKeyWebsiteExpiryRemind, "7,3,1"),
+	})
+}
+
+// UpdateSettings
+//
+//	@Summary		更新通知设置
+//	@Description	更新通知频率限制、告警阈值和网站到期提醒天数设置
+//	@Tags			消息通知
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Success		200	{object}	SuccessResponse
+//	@Router			/panel/notification/settings [post]
+func (r *NotificationController) UpdateSettings(ctx http.Context) http.Response {
+	validator, err := ctx.Request().Validate(map[string]string{
+		"interval":     "required|int|min:0",
+		"hourly_limit": "required|int|min:0",
+		"disk_usage":   "required|int|min:0|max:100",
+	})
+	if err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+	if validator.Fails() {
+		return Error(ctx, http.StatusUnprocessableEntity, validator.Errors().One())
+	}
+	remind := strings.ReplaceAll(ctx.Request().Input("expiry_remind", "7,3,1"), " ", "")
+	for _, day := range strings.Split(remind, ",") {
+		if cast.ToInt(day) <= 0 {
+			return Error(ctx, http.StatusUnprocessableEntity, "网站到期提醒天数需为逗号分隔的正整数")
+		}
+	}
+
+	settings := map[string]string{
+		models.SettingKeyWebsiteExpiryRemind:     remind,
+		models.SettingKeyNotificationInterval:    ctx.Request().Input("interval"),
+		models.SettingKeyNotificationHourlyLimit: ctx.Request().Input("hourly_limit"),
+		models.SettingKeyNotificationDiskUsage:   ctx.Request().Input("disk_usage"),
+	}
+	for key, value := range settings {
+		if err = r.setting.Set(key, value); err != nil {
+			facades.Log().Request(ctx.Request()).Tags("面板", "消息通知").With(map[string]any{
+				"error": err.Error(),
+			}).Info("更新通知设置失败")
+			return ErrorSystem(ctx)
+		}
+	}
+
+	return Success(ctx, nil)
+}
+
+// check 检查渠道配置和订阅事件
+func (r *NotificationController) check(kind string, config notify.Config, events []string) error {
+	if _, err := notify.New(kind, config); err != nil {
+		return err
+	}
+	for _, event := range events {
+		if _, ok := models.NotificationEvents[event]; !ok {
+			return errors.New("不支持的通知事件: " + event)
+		}
+	}
+
+	return nil
+}
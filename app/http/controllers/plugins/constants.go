@@ -1,12 +1,5 @@
 package plugins
 
-type PHPExtension struct {
-	Name        string `json:"name"`
-	Slug        string `json:"slug"`
-	Description string `json:"description"`
-	Installed   bool   `json:"installed"`
-}
-
 type LoadInfo struct {
 	Name  string `json:"name"`
 	Value string `json:"value"`
@@ -2,6 +2,7 @@ package plugins
 
 import (
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/goravel/framework/contracts/http"
@@ -9,16 +10,18 @@ import (
 	"github.com/spf13/cast"
 
 	"panel/app/http/controllers"
+	"panel/app/services"
+	"panel/pkg/procstat"
 	"panel/pkg/tools"
 )
 
 type OpenRestyController struct {
-	// Dependent services
+	waf services.WebsiteWaf
 }
 
 func NewOpenrestyController() *OpenRestyController {
 	return &OpenRestyController{
-		// Inject services
+		waf: services.NewWebsiteWafImpl(),
 	}
 }
 
@@ -115,6 +118,27 @@ func (r *OpenRestyController) ClearErrorLog(ctx http.Context) http.Response {
 	return controllers.Success(ctx, nil)
 }
 
+// WafRules 获取 WAF 全局规则
+func (r *OpenRestyController) WafRules(ctx http.Context) http.Response {
+	rules, err := r.waf.GlobalRules()
+	if err != nil {
+		return controllers.Error(ctx, http.StatusInternalServerError, "获取WAF规则失败")
+	}
+
+	return controllers.Success(ctx, rules)
+}
+
+// SaveWafRule 保存 WAF 全局规则文件
+func (r *OpenRestyController) SaveWafRule(ctx http.Context) http.Response {
+	name := ctx.Request().Input("name")
+	content := ctx.Request().Input("content")
+	if err := r.waf.SaveGlobalRule(name, content); err != nil {
+		return controllers.Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	return controllers.Success(ctx, nil)
+}
+
 // Load 获取负载
 func (r *OpenRestyController) Load(ctx http.Context) http.Response {
 	client := req.C().SetTimeout(10 * time.Second)
@@ -130,23 +154,24 @@ func (r *OpenRestyController) Load(ctx http.Context) http.Response {
 	}
 	var data []nginxStatus
 
-	workers, err := tools.Exec("ps aux | grep nginx | grep 'worker process' | wc -l")
+	processes, err := procstat.Snapshot()
 	if err != nil {
 		return controllers.Error(ctx, http.StatusInternalServerError, "获取OpenResty负载失败")
 	}
+	workers, memory := 0, uint64(0)
+	for _, p := range processes {
+		if p.Unit == "openresty.service" && strings.Contains(p.Cmdline, "worker process") {
+			workers++
+			memory += p.RSS
+		}
+	}
 	data = append(data, nginxStatus{
 		Name:  "工作进程",
-		Value: workers,
+		Value: cast.ToString(workers),
 	})
-
-	out, err := tools.Exec("ps aux | grep nginx | grep 'worker process' | awk '{memsum+=$6};END {print memsum}'")
-	if err != nil {
-		return controllers.Error(ctx, http.StatusInternalServerError, "获取OpenResty负载失败")
-	}
-	mem := tools.FormatBytes(cast.ToFloat64(out))
 	data = append(data, nginxStatus{
 		Name:  "内存占用",
-		Value: mem,
+		Value: tools.FormatBytes(float64(memory)),
 	})
 
 	match := regexp.MustCompile(`Active connections:\s+(\d+)`).FindStringSubmatch(raw)
@@ -1,32 +1,31 @@
 package plugins
 
 import (
-	"fmt"
-	"regexp"
-	"strings"
-	"time"
-
 	"github.com/goravel/framework/contracts/http"
 	"github.com/goravel/framework/facades"
-	"github.com/imroc/req/v3"
+	"github.com/goravel/framework/support/carbon"
+	"github.com/spf13/cast"
 
 	"panel/app/http/controllers"
-	"panel/app/models"
+	requests "panel/app/http/requests/plugins/php"
 	"panel/app/services"
+	"panel/pkg/phpext"
 	"panel/pkg/tools"
 )
 
 type Php83Controller struct {
-	setting services.Setting
-	task    services.Task
-	version string
+	setting   services.Setting
+	extension services.PhpExtension
+	fpm       services.PhpFpm
+	version   string
 }
 
 func NewPhp83Controller() *Php83Controller {
 	return &Php83Controller{
-		setting: services.NewSettingImpl(),
-		task:    services.NewTaskImpl(),
-		version: "83",
+		setting:   services.NewSettingImpl(),
+		extension: services.NewPhpExtensionImpl(),
+		fpm:       services.NewPhpFpmImpl(),
+		version:   "83",
 	}
 }
 
@@ -89,34 +88,74 @@ func (r *Php83Controller) SaveConfig(ctx http.Context) http.Response {
 }
 
 func (r *Php83Controller) Load(ctx http.Context) http.Response {
-	client := req.C().SetTimeout(10 * time.Second)
-	resp, err := client.R().Get("http://127.0.0.1/phpfpm_status/" + r.version)
-	if err != nil || !resp.IsSuccessState() {
-		facades.Log().Info("获取PHP-" + r.version + "运行状态失败")
+	status, err := r.fpm.Status(cast.ToInt(r.version), "")
+	if err != nil {
+		facades.Log().Info("获取PHP-" + r.version + "运行状态失败：" + err.Error())
 		return controllers.Error(ctx, http.StatusInternalServerError, "[PHP-"+r.version+"] 获取运行状态失败")
 	}
 
-	raw := resp.String()
-	dataKeys := []string{"应用池", "工作模式", "启动时间", "接受连接", "监听队列", "最大监听队列", "监听队列长度", "空闲进程数量", "活动进程数量", "总进程数量", "最大活跃进程数量", "达到进程上限次数", "慢请求"}
-	regexKeys := []string{"pool", "process manager", "start time", "accepted conn", "listen queue", "max listen queue", "listen queue len", "idle processes", "active processes", "total processes", "max active processes", "max children reached", "slow requests"}
+	return controllers.Success(ctx, []LoadInfo{
+		{Name: "应用池", Value: status.Pool},
+		{Name: "工作模式", Value: status.ProcessManager},
+		{Name: "启动时间", Value: carbon.FromStdTime(status.StartTime).ToDateTimeString()},
+		{Name: "接受连接", Value: cast.ToString(status.AcceptedConn)},
+		{Name: "监听队列", Value: cast.ToString(status.ListenQueue)},
+		{Name: "最大监听队列", Value: cast.ToString(status.MaxListenQueue)},
+		{Name: "监听队列长度", Value: cast.ToString(status.ListenQueueLen)},
+		{Name: "空闲进程数量", Value: cast.ToString(status.IdleProcesses)},
+		{Name: "活动进程数量", Value: cast.ToString(status.ActiveProcesses)},
+		{Name: "总进程数量", Value: cast.ToString(status.TotalProcesses)},
+		{Name: "最大活跃进程数量", Value: cast.ToString(status.MaxActiveProcesses)},
+		{Name: "达到进程上限次数", Value: cast.ToString(status.MaxChildrenReached)},
+		{Name: "慢请求", Value: cast.ToString(status.SlowRequests)},
+	})
+}
 
-	type Data struct {
-		Name  string `json:"name"`
-		Value string `json:"value"`
+func (r *Php83Controller) FpmStatus(ctx http.Context) http.Response {
+	status, err := r.fpm.Status(cast.ToInt(r.version), ctx.Request().Query("pool"))
+	if err != nil {
+		return controllers.Error(ctx, http.StatusInternalServerError, err.Error())
 	}
-	data := make([]Data, len(dataKeys))
-	for i := range dataKeys {
-		data[i].Name = dataKeys[i]
 
-		r := regexp.MustCompile(fmt.Sprintf("%s:\\s+(.*)", regexKeys[i]))
-		match := r.FindStringSubmatch(raw)
+	return controllers.Success(ctx, status)
+}
+
+func (r *Php83Controller) SlowRequests(ctx http.Context) http.Response {
+	entries, err := r.fpm.SlowLog(cast.ToInt(r.version), ctx.Request().Query("pool"))
+	if err != nil {
+		return controllers.Error(ctx, http.StatusInternalServerError, err.Error())
+	}
 
-		if len(match) > 1 {
-			data[i].Value = strings.TrimSpace(match[1])
-		}
+	// 最新的记录在前
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	if len(entries) > 100 {
+		entries = entries[:100]
 	}
 
-	return controllers.Success(ctx, data)
+	return controllers.Success(ctx, entries)
+}
+
+func (r *Php83Controller) FpmHistory(ctx http.Context) http.Response {
+	end := carbon.Now()
+	if input := ctx.Request().QueryInt64("end"); input > 0 {
+		end = carbon.FromTimestampMilli(input)
+	}
+	start := end.SubDay()
+	if input := ctx.Request().QueryInt64("start"); input > 0 {
+		start = carbon.FromTimestampMilli(input)
+	}
+	if start.Gt(end) {
+		return controllers.Error(ctx, http.StatusUnprocessableEntity, "开始时间不能晚于结束时间")
+	}
+
+	history, err := r.fpm.History(cast.ToInt(r.version), ctx.Request().Query("pool"), start, end)
+	if err != nil {
+		return controllers.Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	return controllers.Success(ctx, history)
 }
 
 func (r *Php83Controller) ErrorLog(ctx http.Context) http.Response {
@@ -152,8 +191,21 @@ func (r *Php83Controller) ClearSlowLog(ctx http.Context) http.Response {
 	return controllers.Success(ctx, nil)
 }
 
+func (r *Php83Controller) Pools(ctx http.Context) http.Response {
+	pools, err := services.NewWebsitePoolImpl().List(cast.ToInt(r.version))
+	if err != nil {
+		return controllers.ErrorSystem(ctx)
+	}
+
+	return controllers.Success(ctx, pools)
+}
+
 func (r *Php83Controller) GetExtensionList(ctx http.Context) http.Response {
-	extensions := r.GetExtensions()
+	extensions, err := r.extension.List(cast.ToInt(r.version))
+	if err != nil {
+		return controllers.Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+
 	return controllers.Success(ctx, extensions)
 }
 
@@ -163,30 +215,34 @@ func (r *Php83Controller) InstallExtension(ctx http.Context) http.Response {
 		return controllers.Error(ctx, http.StatusUnprocessableEntity, "参数错误")
 	}
 
-	extensions := r.GetExtensions()
-	for _, item := range extensions {
-		if item.Slug == slug {
-			if item.Installed {
-				return controllers.Error(ctx, http.StatusUnprocessableEntity, "扩展已安装")
-			}
-
-			var task models.Task
-			task.Name = "安装PHP-" + r.version + "扩展-" + item.Name
-			task.Status = models.TaskStatusWaiting
-			task.Shell = `bash '/www/panel/scripts/php_extensions/` + item.Slug + `.sh' install ` + r.version + ` >> /tmp/` + item.Slug + `.log 2>&1`
-			task.Log = "/tmp/" + item.Slug + ".log"
-			if err := facades.Orm().Query().Create(&task); err != nil {
-				facades.Log().Info("[PHP-" + r.version + "] 创建安装拓展任务失败：" + err.Error())
-				return controllers.ErrorSystem(ctx)
-			}
-
-			r.task.Process(task.ID)
-
-			return controllers.Success(ctx, true)
-		}
+	task, err := r.extension.Install(cast.ToInt(r.version), slug)
+	if err != nil {
+		return controllers.Error(ctx, http.StatusUnprocessableEntity, err.Error())
 	}
 
-	return controllers.Error(ctx, http.StatusUnprocessableEntity, "扩展不存在")
+	return controllers.Success(ctx, task)
+}
+
+func (r *Php83Controller) InstallPecl(ctx http.Context) http.Response {
+	var peclRequest requests.Pecl
+	sanitize := controllers.Sanitize(ctx, &peclRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	task, err := r.extension.InstallPecl(cast.ToInt(r.version), phpext.Extension{
+		Name:      peclRequest.Package,
+		Package:   peclRequest.Package,
+		Version:   peclRequest.Version,
+		Ini:       peclRequest.Name,
+		Zend:      peclRequest.Zend,
+		Configure: peclRequest.Configure,
+	})
+	if err != nil {
+		return controllers.Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	return controllers.Success(ctx, task)
 }
 
 func (r *Php83Controller) UninstallExtension(ctx http.Context) http.Response {
@@ -195,80 +251,24 @@ func (r *Php83Controller) UninstallExtension(ctx http.Context) http.Response {
 		return controllers.Error(ctx, http.StatusUnprocessableEntity, "参数错误")
 	}
 
-	extensions := r.GetExtensions()
-	for _, item := range extensions {
-		if item.Slug == slug {
-			if !item.Installed {
-				return controllers.Error(ctx, http.StatusUnprocessableEntity, "扩展未安装")
-			}
-
-			var task models.Task
-			task.Name = "卸载PHP-" + r.version + "扩展-" + item.Name
-			task.Status = models.TaskStatusWaiting
-			task.Shell = `bash '/www/panel/scripts/php_extensions/` + item.Slug + `.sh' uninstall ` + r.version + ` >> /tmp/` + item.Slug + `.log 2>&1`
-			task.Log = "/tmp/" + item.Slug + ".log"
-			if err := facades.Orm().Query().Create(&task); err != nil {
-				facades.Log().Info("[PHP-" + r.version + "] 创建卸载拓展任务失败：" + err.Error())
-				return controllers.ErrorSystem(ctx)
-			}
-
-			r.task.Process(task.ID)
-
-			return controllers.Success(ctx, true)
-		}
+	task, err := r.extension.Uninstall(cast.ToInt(r.version), slug)
+	if err != nil {
+		return controllers.Error(ctx, http.StatusUnprocessableEntity, err.Error())
 	}
 
-	return controllers.Error(ctx, http.StatusUnprocessableEntity, "扩展不存在")
+	return controllers.Success(ctx, task)
 }
 
-func (r *Php83Controller) GetExtensions() []PHPExtension {
-	var extensions []PHPExtension
-	extensions = append(extensions, PHPExtension{
-		Name:        "OPcache",
-		Slug:        "Zend OPcache",
-		Description: "OPcache 通过将 PHP 脚本预编译的字节码存储到共享内存中来提升 PHP 的性能，存储预编译字节码可以省去每次加载和解析 PHP 脚本的开销。",
-		Installed:   false,
-	})
-	extensions = append(extensions, PHPExtension{
-		Name:        "PhpRedis",
-		Slug:        "redis",
-		Description: "PhpRedis 是一个用C语言编写的PHP模块，用来连接并操作 Redis 数据库上的数据。",
-		Installed:   false,
-	})
-	extensions = append(extensions, PHPExtension{
-		Name:        "ImageMagick",
-		Slug:        "imagick",
-		Description: "ImageMagick 是一个免费的创建、编辑、合成图片的软件。",
-		Installed:   false,
-	})
-	extensions = append(extensions, PHPExtension{
-		Name:        "Exif",
-		Slug:        "exif",
-		Description: "通过 exif 扩展，你可以操作图像元数据。",
-		Installed:   false,
-	})
-	extensions = append(extensions, PHPExtension{
-		Name:        "pdo_pgsql",
-		Slug:        "pdo_pgsql",
-		Description: "（需先安装PostgreSQL）pdo_pgsql 是一个驱动程序，它实现了 PHP 数据对象（PDO）接口以启用从 PHP 到 PostgreSQL 数据库的访问。",
-		Installed:   false,
-	})
-
-	raw, err := tools.Exec("/www/server/php/" + r.version + "/bin/php -m")
-	if err != nil {
-		return extensions
+func (r *Php83Controller) ExtensionStatus(ctx http.Context) http.Response {
+	var statusRequest requests.ExtensionStatus
+	sanitize := controllers.Sanitize(ctx, &statusRequest)
+	if sanitize != nil {
+		return sanitize
 	}
 
-	rawExtensionList := strings.Split(raw, "\n")
-	for _, item := range rawExtensionList {
-		if !strings.Contains(item, "[") && item != "" {
-			for i := range extensions {
-				if extensions[i].Slug == item {
-					extensions[i].Installed = true
-				}
-			}
-		}
+	if err := r.extension.Toggle(cast.ToInt(r.version), statusRequest.Slug, statusRequest.Enabled); err != nil {
+		return controllers.Error(ctx, http.StatusUnprocessableEntity, err.Error())
 	}
 
-	return extensions
+	return controllers.Success(ctx, nil)
 }
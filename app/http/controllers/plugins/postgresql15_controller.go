@@ -1,6 +1,7 @@
 package plugins
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/goravel/framework/contracts/http"
@@ -9,6 +10,7 @@ import (
 	"panel/app/http/controllers"
 	"panel/app/models"
 	"panel/app/services"
+	"panel/pkg/procstat"
 	"panel/pkg/tools"
 )
 
@@ -138,10 +140,17 @@ func (r *Postgresql15Controller) Load(ctx http.Context) http.Response {
 	if err != nil {
 		return controllers.Error(ctx, http.StatusInternalServerError, "获取PostgreSQL进程PID失败")
 	}
-	process, err := tools.Exec(`ps aux | grep postgres | grep -v grep | wc -l`)
+	processes, err := procstat.Snapshot()
 	if err != nil {
 		return controllers.Error(ctx, http.StatusInternalServerError, "获取PostgreSQL进程数失败")
 	}
+	count := 0
+	for _, p := range processes {
+		if p.Unit == "postgresql.service" {
+			count++
+		}
+	}
+	process := strconv.Itoa(count)
 	connections, err := tools.Exec(`echo "SELECT count(*) FROM pg_stat_activity WHERE NOT pid=pg_backend_pid();" | su - postgres -c "psql" | sed -n 3p`)
 	if err != nil {
 		return controllers.Error(ctx, http.StatusInternalServerError, "获取PostgreSQL连接数失败")
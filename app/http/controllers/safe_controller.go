@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"path/filepath"
 	"regexp"
 	"strings"
 
@@ -8,16 +9,20 @@ import (
 	"github.com/spf13/cast"
 	commonrequests "panel/app/http/requests/common"
 
+	"panel/app/models"
+	"panel/app/services"
 	"panel/pkg/tools"
 )
 
 type SafeController struct {
-	// Dependent services
+	geoip   services.GeoIP
+	country services.FirewallCountry
 }
 
 func NewSafeController() *SafeController {
 	return &SafeController{
-		// Inject services
+		geoip:   services.NewGeoIPImpl(),
+		country: services.NewFirewallCountryImpl(),
 	}
 }
 
@@ -388,3 +393,93 @@ func (r *SafeController) SetPingStatus(ctx http.Context) http.Response {
 
 	return Success(ctx, nil)
 }
+
+// GetGeoIP 获取 GeoIP 数据库信息
+func (r *SafeController) GetGeoIP(ctx http.Context) http.Response {
+	info, err := r.geoip.Info()
+	if err != nil {
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+
+	return Success(ctx, info)
+}
+
+// UploadGeoIP 上传 GeoIP 数据库，支持 mmdb 文件和压缩包
+func (r *SafeController) UploadGeoIP(ctx http.Context) http.Response {
+	file, err := ctx.Request().File("file")
+	if err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, "上传文件失败")
+	}
+	dir, err := tools.TempDir("geoip-upload")
+	if err != nil {
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+	defer tools.Remove(dir)
+
+	name := filepath.Base(file.GetClientOriginalName())
+	if _, err = file.StoreAs(dir, name); err != nil {
+		return Error(ctx, http.StatusInternalServerError, "保存文件失败")
+	}
+	if err = r.geoip.Install(filepath.Join(dir, name)); err != nil {
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+
+	return Success(ctx, nil)
+}
+
+// DownloadGeoIP 从地址下载并更新 GeoIP 数据库
+func (r *SafeController) DownloadGeoIP(ctx http.Context) http.Response {
+	url := ctx.Request().Input("url")
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return Error(ctx, http.StatusUnprocessableEntity, "下载地址不合法")
+	}
+
+	if err := r.geoip.Download(url); err != nil {
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+
+	return Success(ctx, nil)
+}
+
+// GetCountryRules 获取防火墙国家规则
+func (r *SafeController) GetCountryRules(ctx http.Context) http.Response {
+	rules, err := r.country.List()
+	if err != nil {
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+
+	return Success(ctx, rules)
+}
+
+// AddCountryRule 添加防火墙国家规则
+func (r *SafeController) AddCountryRule(ctx http.Context) http.Response {
+	if !r.firewallStatus() {
+		return Error(ctx, http.StatusUnprocessableEntity, "防火墙未启动")
+	}
+
+	rule := models.FirewallCountry{
+		Country:  strings.ToUpper(ctx.Request().Input("country")),
+		Action:   ctx.Request().Input("action"),
+		Port:     ctx.Request().Input("port"),
+		Protocol: ctx.Request().Input("protocol"),
+	}
+	if err := r.country.Add(rule); err != nil {
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+
+	return Success(ctx, nil)
+}
+
+// DeleteCountryRule 删除防火墙国家规则
+func (r *SafeController) DeleteCountryRule(ctx http.Context) http.Response {
+	id := ctx.Request().InputInt("id", 0)
+	if id == 0 {
+		return Error(ctx, http.StatusUnprocessableEntity, "参数错误")
+	}
+
+	if err := r.country.Delete(uint(id)); err != nil {
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+
+	return Success(ctx, nil)
+}
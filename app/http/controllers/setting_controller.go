@@ -201,3 +201,58 @@ func (r *SettingController) Update(ctx http.Context) http.Response {
 
 	return Success(ctx, nil)
 }
+
+// MetricsToken
+//
+//	@Summary		获取指标令牌
+//	@Description	获取 Prometheus 指标接口的访问令牌，令牌为空时接口关闭
+//	@Tags			面板设置
+//	@Produce		json
+//	@Security		BearerToken
+//	@Success		200	{object}	SuccessResponse
+//	@Router			/panel/setting/metricsToken [get]
+func (r *SettingController) MetricsToken(ctx http.Context) http.Response {
+	token := r.setting.Get(models.SettingKeyMetricsToken)
+	return Success(ctx, http.Json{
+		"enabled": len(token) > 0,
+		"token":   token,
+	})
+}
+
+// UpdateMetricsToken
+//
+//	@Summary		重置指标令牌
+//	@Description	启用时重新生成 Prometheus 指标接口的访问令牌，关闭时清空令牌
+//	@Tags			面板设置
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Success		200	{object}	SuccessResponse
+//	@Router			/panel/setting/metricsToken [post]
+func (r *SettingController) UpdateMetricsToken(ctx http.Context) http.Response {
+	validator, err := ctx.Request().Validate(map[string]string{
+		"enabled": "bool",
+	})
+	if err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+	if validator.Fails() {
+		return Error(ctx, http.StatusUnprocessableEntity, validator.Errors().One())
+	}
+
+	token := ""
+	if ctx.Request().InputBool("enabled") {
+		token = tools.RandomString(32)
+	}
+	if err = r.setting.Set(models.SettingKeyMetricsToken, token); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "面板设置").With(map[string]any{
+			"error": err.Error(),
+		}).Info("保存指标令牌失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, http.Json{
+		"enabled": len(token) > 0,
+		"token":   token,
+	})
+}
@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/facades"
+
+	commonrequests "panel/app/http/requests/common"
+	requests "panel/app/http/requests/stream"
+	responses "panel/app/http/responses/stream"
+	"panel/app/models"
+	"panel/app/services"
+)
+
+type StreamController struct {
+	proxy services.StreamProxy
+}
+
+func NewStreamController() *StreamController {
+	return &StreamController{
+		proxy: services.NewStreamProxyImpl(),
+	}
+}
+
+// ProxyList
+//
+//	@Summary		获取 TCP/UDP 代理列表
+//	@Description	获取 OpenResty stream 模块的 TCP/UDP 代理列表
+//	@Tags			TCP/UDP 代理
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			data	body		commonrequests.Paginate	true	"request"
+//	@Success		200		{object}	SuccessResponse{data=responses.ProxyList}
+//	@Router			/panel/stream/proxies [get]
+func (r *StreamController) ProxyList(ctx http.Context) http.Response {
+	var paginateRequest commonrequests.Paginate
+	sanitize := Sanitize(ctx, &paginateRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	total, proxies, err := r.proxy.List(paginateRequest.Page, paginateRequest.Limit)
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "TCP/UDP 代理").With(map[string]any{
+			"error": err.Error(),
+		}).Info("获取代理列表失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, responses.ProxyList{
+		Total: total,
+		Items: proxies,
+	})
+}
+
+// ProxyStore
+//
+//	@Summary		添加 TCP/UDP 代理
+//	@Description	添加 TCP/UDP 代理，支持多后端负载均衡、PROXY 协议、超时和使用面板证书终止 TLS，防火墙运行时自动放行监听端口
+//	@Tags			TCP/UDP 代理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			data	body		requests.ProxyStore	true	"request"
+//	@Success		200		{object}	SuccessResponse{data=models.StreamProxy}
+//	@Router			/panel/stream/proxies [post]
+func (r *StreamController) ProxyStore(ctx http.Context) http.Response {
+	var storeRequest requests.ProxyStore
+	sanitize := Sanitize(ctx, &storeRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	proxy, err := r.proxy.Save(models.StreamProxy{
+		Name:                storeRequest.Name,
+		Port:                storeRequest.Port,
+		Protocol:            storeRequest.Protocol,
+		Backends:            storeRequest.Backends,
+		Balance:             storeRequest.Balance,
+		ProxyProtocol:       storeRequest.ProxyProtocol,
+		AcceptProxyProtocol: storeRequest.AcceptProxyProtocol,
+		ConnectTimeout:      storeRequest.ConnectTimeout,
+		Timeout:             storeRequest.Timeout,
+		SSL:                 storeRequest.SSL,
+		CertID:              storeRequest.CertID,
+	})
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "TCP/UDP 代理").With(map[string]any{
+			"port":  storeRequest.Port,
+			"error": err.Error(),
+		}).Info("添加代理失败")
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+
+	return Success(ctx, proxy)
+}
+
+// ProxyUpdate
+//
+//	@Summary		更新 TCP/UDP 代理
+//	@Description	更新 TCP/UDP 代理，OpenResty 配置检查未通过时恢复原配置
+//	@Tags			TCP/UDP 代理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id		path		int						true	"代理 ID"
+//	@Param			data	body		requests.ProxyUpdate	true	"request"
+//	@Success		200		{object}	SuccessResponse{data=models.StreamProxy}
+//	@Router			/panel/stream/proxies/{id} [put]
+func (r *StreamController) ProxyUpdate(ctx http.Context) http.Response {
+	var updateRequest requests.ProxyUpdate
+	sanitize := Sanitize(ctx, &updateRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	proxy, err := r.proxy.Save(models.StreamProxy{
+		ID:                  updateRequest.ID,
+		Name:                updateRequest.Name,
+		Port:                updateRequest.Port,
+		Protocol:            updateRequest.Protocol,
+		Backends:            updateRequest.Backends,
+		Balance:             updateRequest.Balance,
+		ProxyProtocol:       updateRequest.ProxyProtocol,
+		AcceptProxyProtocol: updateRequest.AcceptProxyProtocol,
+		ConnectTimeout:      updateRequest.ConnectTimeout,
+		Timeout:             updateRequest.Timeout,
+		SSL:                 updateRequest.SSL,
+		CertID:              updateRequest.CertID,
+	})
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "TCP/UDP 代理").With(map[string]any{
+			"id":    updateRequest.ID,
+			"error": err.Error(),
+		}).Info("更新代理失败")
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+
+	return Success(ctx, proxy)
+}
+
+// ProxyShow
+//
+//	@Summary		获取 TCP/UDP 代理
+//	@Description	获取 TCP/UDP 代理
+//	@Tags			TCP/UDP 代理
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"代理 ID"
+//	@Success		200	{object}	SuccessResponse{data=models.StreamProxy}
+//	@Router			/panel/stream/proxies/{id} [get]
+func (r *StreamController) ProxyShow(ctx http.Context) http.Response {
+	var showAndDestroyRequest requests.ProxyShowAndDestroy
+	sanitize := Sanitize(ctx, &showAndDestroyRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	proxy, err := r.proxy.Get(showAndDestroyRequest.ID)
+	if err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	return Success(ctx, proxy)
+}
+
+// ProxyDestroy
+//
+//	@Summary		删除 TCP/UDP 代理
+//	@Description	删除 TCP/UDP 代理，防火墙运行时自动关闭监听端口
+//	@Tags			TCP/UDP 代理
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"代理 ID"
+//	@Success		200	{object}	SuccessResponse
+//	@Router			/panel/stream/proxies/{id} [delete]
+func (r *StreamController) ProxyDestroy(ctx http.Context) http.Response {
+	var showAndDestroyRequest requests.ProxyShowAndDestroy
+	sanitize := Sanitize(ctx, &showAndDestroyRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	if err := r.proxy.Delete(showAndDestroyRequest.ID); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "TCP/UDP 代理").With(map[string]any{
+			"id":    showAndDestroyRequest.ID,
+			"error": err.Error(),
+		}).Info("删除代理失败")
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+
+	return Success(ctx, nil)
+}
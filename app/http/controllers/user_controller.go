@@ -7,15 +7,16 @@ import (
 	"panel/app/http/requests/user"
 	responses "panel/app/http/responses/user"
 	"panel/app/models"
+	"panel/app/services"
 )
 
 type UserController struct {
-	// Dependent services
+	user services.User
 }
 
 func NewUserController() *UserController {
 	return &UserController{
-		// Inject services
+		user: services.NewUserImpl(),
 	}
 }
 
@@ -69,6 +70,15 @@ func (r *UserController) Login(ctx http.Context) http.Response {
 		return ErrorSystem(ctx)
 	}
 
+	ip := ctx.Request().Ip()
+	go func() {
+		if err := r.user.RecordLogin(user, ip); err != nil {
+			facades.Log().Tags("面板", "用户").With(map[string]any{
+				"error": err.Error(),
+			}).Info("记录登录 IP 失败")
+		}
+	}()
+
 	return Success(ctx, http.Json{
 		"access_token": token,
 	})
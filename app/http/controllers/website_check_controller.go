@@ -0,0 +1,298 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/facades"
+	"github.com/goravel/framework/support/carbon"
+
+	commonrequests "panel/app/http/requests/common"
+	requests "panel/app/http/requests/website_check"
+	responses "panel/app/http/responses/website_check"
+	"panel/app/models"
+	"panel/app/services"
+	"panel/pkg/healthcheck"
+)
+
+type WebsiteCheckController struct {
+	check services.WebsiteCheck
+}
+
+func NewWebsiteCheckController() *WebsiteCheckController {
+	return &WebsiteCheckController{
+		check: services.NewWebsiteCheckImpl(),
+	}
+}
+
+// List
+//
+//	@Summary		获取网站检测列表
+//	@Description	获取网站可用性检测列表及最近 1、7、30 天的可用率
+//	@Tags			网站检测
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			website_id	query		int						false	"网站 ID"
+//	@Param			data		body		commonrequests.Paginate	true	"request"
+//	@Success		200			{object}	SuccessResponse{data=responses.List}
+//	@Router			/panel/website_checks [get]
+func (r *WebsiteCheckController) List(ctx http.Context) http.Response {
+	var paginateRequest commonrequests.Paginate
+	sanitize := Sanitize(ctx, &paginateRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	query := facades.Orm().Query().With("Website")
+	if websiteID := ctx.Request().QueryInt("website_id"); websiteID > 0 {
+		query = query.Where("website_id", websiteID)
+	}
+
+	var checks []models.WebsiteCheck
+	var total int64
+	if err := query.Paginate(paginateRequest.Page, paginateRequest.Limit, &checks, &total); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站检测").With(map[string]any{
+			"error": err.Error(),
+		}).Info("获取网站检测列表失败")
+		return ErrorSystem(ctx)
+	}
+
+	items := make([]responses.Check, 0, len(checks))
+	for _, check := range checks {
+		item := responses.Check{WebsiteCheck: check, Uptime: make(map[string]*float64)}
+		for _, days := range []int{1, 7, 30} {
+			item.Uptime[uptimeKey(days)] = nil
+			if uptime, ok := r.check.Uptime(check.ID, days); ok {
+				item.Uptime[uptimeKey(days)] = &uptime
+			}
+		}
+		items = append(items, item)
+	}
+
+	return Success(ctx, responses.List{
+		Total: total,
+		Items: items,
+	})
+}
+
+// Store
+//
+//	@Summary		添加网站检测
+//	@Description	添加网站可用性检测
+//	@Tags			网站检测
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			data	body		requests.Store	true	"request"
+//	@Success		200		{object}	SuccessResponse
+//	@Router			/panel/website_checks [post]
+func (r *WebsiteCheckController) Store(ctx http.Context) http.Response {
+	var storeRequest requests.Store
+	sanitize := Sanitize(ctx, &storeRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	check := models.WebsiteCheck{
+		WebsiteID:    storeRequest.WebsiteID,
+		URL:          storeRequest.URL,
+		Method:       storeRequest.Method,
+		ExpectStatus: storeRequest.ExpectStatus,
+		Keyword:      storeRequest.Keyword,
+		Interval:     storeRequest.Interval,
+		Timeout:      storeRequest.Timeout,
+		TLSWarnDays:  storeRequest.TLSWarnDays,
+		Insecure:     storeRequest.Insecure,
+		Enabled:      storeRequest.Enabled,
+		Status:       models.WebsiteCheckStatusUnknown,
+	}
+	if err := r.validate(check); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+	if err := facades.Orm().Query().Create(&check); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站检测").With(map[string]any{
+			"error": err.Error(),
+		}).Info("添加网站检测失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, check)
+}
+
+// Update
+//
+//	@Summary		更新网站检测
+//	@Description	更新网站可用性检测，更新后检测状态会被重置
+//	@Tags			网站检测
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id		path		int				true	"检测 ID"
+//	@Param			data	body		requests.Update	true	"request"
+//	@Success		200		{object}	SuccessResponse
+//	@Router			/panel/website_checks/{id} [put]
+func (r *WebsiteCheckController) Update(ctx http.Context) http.Response {
+	var updateRequest requests.Update
+	sanitize := Sanitize(ctx, &updateRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var check models.WebsiteCheck
+	if err := facades.Orm().Query().Where("id", updateRequest.ID).FirstOrFail(&check); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, "网站检测不存在")
+	}
+
+	check.WebsiteID = updateRequest.WebsiteID
+	check.URL = updateRequest.URL
+	check.Method = updateRequest.Method
+	check.ExpectStatus = updateRequest.ExpectStatus
+	check.Keyword = updateRequest.Keyword
+	check.Interval = updateRequest.Interval
+	check.Timeout = updateRequest.Timeout
+	check.TLSWarnDays = updateRequest.TLSWarnDays
+	check.Insecure = updateRequest.Insecure
+	check.Enabled = updateRequest.Enabled
+	check.Status = models.WebsiteCheckStatusUnknown
+	check.Failures = 0
+	check.CheckedAt = nil
+	if err := r.validate(check); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+	if err := facades.Orm().Query().Save(&check); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站检测").With(map[string]any{
+			"checkID": updateRequest.ID,
+			"error":   err.Error(),
+		}).Info("更新网站检测失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, nil)
+}
+
+// Destroy
+//
+//	@Summary		删除网站检测
+//	@Description	删除网站可用性检测及其检测记录
+//	@Tags			网站检测
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"检测 ID"
+//	@Success		200	{object}	SuccessResponse
+//	@Router			/panel/website_checks/{id} [delete]
+func (r *WebsiteCheckController) Destroy(ctx http.Context) http.Response {
+	var destroyRequest requests.ShowAndDestroy
+	sanitize := Sanitize(ctx, &destroyRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	if _, err := facades.Orm().Query().Where("id", destroyRequest.ID).Delete(&models.WebsiteCheck{}); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站检测").With(map[string]any{
+			"checkID": destroyRequest.ID,
+			"error":   err.Error(),
+		}).Info("删除网站检测失败")
+		return ErrorSystem(ctx)
+	}
+	_, _ = facades.Orm().Query().Where("check_id", destroyRequest.ID).Delete(&models.WebsiteCheckResult{})
+
+	return Success(ctx, nil)
+}
+
+// Results
+//
+//	@Summary		获取检测记录
+//	@Description	获取网站检测的响应时间和结果记录，可按时间范围筛选
+//	@Tags			网站检测
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id		path		int						true	"检测 ID"
+//	@Param			start	query		int						false	"开始时间（毫秒时间戳）"
+//	@Param			end		query		int						false	"结束时间（毫秒时间戳）"
+//	@Param			data	body		commonrequests.Paginate	true	"request"
+//	@Success		200		{object}	SuccessResponse{data=responses.ResultList}
+//	@Router			/panel/website_checks/{id}/results [get]
+func (r *WebsiteCheckController) Results(ctx http.Context) http.Response {
+	var showRequest requests.ShowAndDestroy
+	sanitize := Sanitize(ctx, &showRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+	var paginateRequest commonrequests.Paginate
+	sanitize = Sanitize(ctx, &paginateRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	query := facades.Orm().Query().Where("check_id", showRequest.ID)
+	if start := ctx.Request().QueryInt64("start"); start > 0 {
+		query = query.Where("created_at >= ?", carbon.FromTimestampMilli(start).ToDateTimeString())
+	}
+	if end := ctx.Request().QueryInt64("end"); end > 0 {
+		query = query.Where("created_at <= ?", carbon.FromTimestampMilli(end).ToDateTimeString())
+	}
+
+	var results []models.WebsiteCheckResult
+	var total int64
+	if err := query.Order("id desc").Paginate(paginateRequest.Page, paginateRequest.Limit, &results, &total); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站检测").With(map[string]any{
+			"checkID": showRequest.ID,
+			"error":   err.Error(),
+		}).Info("获取检测记录失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, responses.ResultList{
+		Total: total,
+		Items: results,
+	})
+}
+
+// Run
+//
+//	@Summary		立即检测
+//	@Description	立即执行一次网站检测并保存结果
+//	@Tags			网站检测
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"检测 ID"
+//	@Success		200	{object}	SuccessResponse
+//	@Router			/panel/website_checks/{id}/run [post]
+func (r *WebsiteCheckController) Run(ctx http.Context) http.Response {
+	var runRequest requests.ShowAndDestroy
+	sanitize := Sanitize(ctx, &runRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var check models.WebsiteCheck
+	if err := facades.Orm().Query().Where("id", runRequest.ID).FirstOrFail(&check); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, "网站检测不存在")
+	}
+
+	result, err := r.check.Run(check)
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站检测").With(map[string]any{
+			"checkID": runRequest.ID,
+			"error":   err.Error(),
+		}).Info("保存检测结果失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, result)
+}
+
+// validate 校验检测配置
+func (r *WebsiteCheckController) validate(check models.WebsiteCheck) error {
+	return healthcheck.Validate(healthcheck.Check{
+		URL:          check.URL,
+		Method:       check.Method,
+		ExpectStatus: check.ExpectStatus,
+		Keyword:      check.Keyword,
+	})
+}
+
+// uptimeKey 可用率的键名
+func uptimeKey(days int) string {
+	return strconv.Itoa(days) + "d"
+}
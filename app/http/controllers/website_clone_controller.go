@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/facades"
+
+	requests "panel/app/http/requests/website"
+	"panel/app/models"
+	"panel/app/services"
+)
+
+type WebsiteCloneController struct {
+	clone services.WebsiteClone
+}
+
+func NewWebsiteCloneController() *WebsiteCloneController {
+	return &WebsiteCloneController{
+		clone: services.NewWebsiteCloneImpl(),
+	}
+}
+
+// List
+//
+//	@Summary		获取克隆记录
+//	@Description	获取由网站克隆出的预发布环境以及网站自身的克隆来源
+//	@Tags			网站克隆
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"网站 ID"
+//	@Success		200	{object}	SuccessResponse{data=[]models.WebsiteClone}
+//	@Router			/panel/websites/{id}/clones [get]
+func (r *WebsiteCloneController) List(ctx http.Context) http.Response {
+	var idRequest requests.ID
+	sanitize := Sanitize(ctx, &idRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	website, response := r.website(ctx, idRequest.ID)
+	if response != nil {
+		return response
+	}
+	clones, err := r.clone.List(website)
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站克隆").With(map[string]any{
+			"id":    website.ID,
+			"error": err.Error(),
+		}).Info("获取克隆记录失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, clones)
+}
+
+// Clone
+//
+//	@Summary		克隆网站
+//	@Description	以新域名克隆网站的配置、网站文件和数据库作为预发布环境，可选替换数据库中的域名，复制在后台任务中进行
+//	@Tags			网站克隆
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id		path		int				true	"网站 ID"
+//	@Param			data	body		requests.Clone	true	"request"
+//	@Success		200		{object}	SuccessResponse{data=models.WebsiteClone}
+//	@Router			/panel/websites/{id}/clone [post]
+func (r *WebsiteCloneController) Clone(ctx http.Context) http.Response {
+	var cloneRequest requests.Clone
+	sanitize := Sanitize(ctx, &cloneRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	source, response := r.website(ctx, cloneRequest.ID)
+	if response != nil {
+		return response
+	}
+	target := services.WebsiteCloneTarget{
+		Name:    cloneRequest.Name,
+		Domains: cloneRequest.Domains,
+		Path:    cloneRequest.Path,
+		Php:     cloneRequest.Php,
+		Replace: cloneRequest.Replace,
+	}
+	for _, db := range cloneRequest.Databases {
+		target.Databases = append(target.Databases, services.WebsiteCloneDatabase{
+			Type:      db.Type,
+			Source:    db.Source,
+			Target:    db.Target,
+			User:      db.User,
+			Password:  db.Password,
+			AppConfig: db.AppConfig,
+		})
+	}
+
+	clone, err := r.clone.Clone(source, target)
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站克隆").With(map[string]any{
+			"id":    source.ID,
+			"name":  cloneRequest.Name,
+			"error": err.Error(),
+		}).Info("克隆网站失败")
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	return Success(ctx, clone)
+}
+
+// Promote
+//
+//	@Summary		上线预发布环境
+//	@Description	备份生产环境后将预发布环境的网站文件和数据库替换到生产环境，生产环境的数据库配置文件保持不变，上线在后台任务中进行
+//	@Tags			网站克隆
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"预发布环境网站 ID"
+//	@Success		200	{object}	SuccessResponse{data=models.WebsiteClone}
+//	@Router			/panel/websites/{id}/promote [post]
+func (r *WebsiteCloneController) Promote(ctx http.Context) http.Response {
+	var idRequest requests.ID
+	sanitize := Sanitize(ctx, &idRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	website, response := r.website(ctx, idRequest.ID)
+	if response != nil {
+		return response
+	}
+	clone, err := r.clone.Promote(website)
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站克隆").With(map[string]any{
+			"id":    website.ID,
+			"error": err.Error(),
+		}).Info("上线预发布环境失败")
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	return Success(ctx, clone)
+}
+
+func (r *WebsiteCloneController) website(ctx http.Context, id uint) (models.Website, http.Response) {
+	var website models.Website
+	if err := facades.Orm().Query().Where("id", id).FirstOrFail(&website); err != nil {
+		return models.Website{}, Error(ctx, http.StatusUnprocessableEntity, "网站不存在")
+	}
+
+	return website, nil
+}
@@ -2,31 +2,55 @@ package controllers
 
 import (
 	"fmt"
-	"regexp"
+	"path/filepath"
 	"strings"
 
 	"github.com/goravel/framework/contracts/http"
 	"github.com/goravel/framework/facades"
+	"github.com/goravel/framework/support/carbon"
 
 	commonrequests "panel/app/http/requests/common"
 	requests "panel/app/http/requests/website"
 	responses "panel/app/http/responses/website"
 	"panel/app/models"
 	"panel/app/services"
+	"panel/pkg/ratelimit"
+	"panel/pkg/sitebundle"
+	"panel/pkg/sitestatus"
+	"panel/pkg/tlsprofile"
 	"panel/pkg/tools"
+	"panel/pkg/waf"
 )
 
 type WebsiteController struct {
-	website services.Website
-	setting services.Setting
-	backup  services.Backup
+	website    services.Website
+	setting    services.Setting
+	backup     services.Backup
+	stat       services.WebsiteStat
+	pool       services.WebsitePool
+	php        services.WebsitePhp
+	app        services.WebsiteApp
+	bundle     services.WebsiteBundle
+	limit      services.WebsiteLimit
+	waf        services.WebsiteWaf
+	country    services.WebsiteCountry
+	suspension services.WebsiteSuspension
 }
 
 func NewWebsiteController() *WebsiteController {
 	return &WebsiteController{
-		website: services.NewWebsiteImpl(),
-		setting: services.NewSettingImpl(),
-		backup:  services.NewBackupImpl(),
+		website:    services.NewWebsiteImpl(),
+		setting:    services.NewSettingImpl(),
+		backup:     services.NewBackupImpl(),
+		stat:       services.NewWebsiteStatImpl(),
+		pool:       services.NewWebsitePoolImpl(),
+		php:        services.NewWebsitePhpImpl(),
+		app:        services.NewWebsiteAppImpl(),
+		bundle:     services.NewWebsiteBundleImpl(),
+		limit:      services.NewWebsiteLimitImpl(),
+		waf:        services.NewWebsiteWafImpl(),
+		country:    services.NewWebsiteCountryImpl(),
+		suspension: services.NewWebsiteSuspensionImpl(),
 	}
 }
 
@@ -55,9 +79,29 @@ func (r *WebsiteController) List(ctx http.Context) http.Response {
 		return ErrorSystem(ctx)
 	}
 
+	ids := make([]uint, 0, len(websites))
+	for _, website := range websites {
+		ids = append(ids, website.ID)
+	}
+	stats, err := r.stat.Summary(ids)
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"error": err.Error(),
+		}).Info("获取网站统计失败")
+		return ErrorSystem(ctx)
+	}
+
+	items := make([]responses.Website, 0, len(websites))
+	for _, website := range websites {
+		items = append(items, responses.Website{
+			Website: website,
+			Stat:    stats[website.ID],
+		})
+	}
+
 	return Success(ctx, responses.List{
 		Total: total,
-		Items: websites,
+		Items: items,
 	})
 }
 
@@ -142,7 +186,7 @@ func (r *WebsiteController) Delete(ctx http.Context) http.Response {
 // GetDefaultConfig
 //
 //	@Summary		获取默认配置
-//	@Description	获取默认首页和停止页配置
+//	@Description	获取默认首页、停止页和暂停页配置
 //	@Tags			网站管理
 //	@Produce		json
 //	@Security		BearerToken
@@ -157,17 +201,24 @@ func (r *WebsiteController) GetDefaultConfig(ctx http.Context) http.Response {
 	if err != nil {
 		return Error(ctx, http.StatusInternalServerError, err.Error())
 	}
+	suspended := sitestatus.DefaultSuspendedPage
+	if tools.Exists(sitestatus.Root + "/" + sitestatus.SuspendedPage) {
+		if suspended, err = tools.Read(sitestatus.Root + "/" + sitestatus.SuspendedPage); err != nil {
+			return Error(ctx, http.StatusInternalServerError, err.Error())
+		}
+	}
 
 	return Success(ctx, http.Json{
-		"index": index,
-		"stop":  stop,
+		"index":     index,
+		"stop":      stop,
+		"suspended": suspended,
 	})
 }
 
 // SaveDefaultConfig
 //
 //	@Summary		保存默认配置
-//	@Description	保存默认首页和停止页配置
+//	@Description	保存默认首页、停止页和暂停页配置
 //	@Tags			网站管理
 //	@Accept			json
 //	@Produce		json
@@ -193,6 +244,15 @@ func (r *WebsiteController) SaveDefaultConfig(ctx http.Context) http.Response {
 		return ErrorSystem(ctx)
 	}
 
+	if suspended := ctx.Request().Input("suspended"); len(suspended) > 0 {
+		if err := tools.Write(sitestatus.Root+"/"+sitestatus.SuspendedPage, suspended, 0644); err != nil {
+			facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+				"error": err.Error(),
+			}).Info("保存默认暂停页配置失败")
+			return ErrorSystem(ctx)
+		}
+	}
+
 	return Success(ctx, nil)
 }
 
@@ -253,6 +313,67 @@ func (r *WebsiteController) SaveConfig(ctx http.Context) http.Response {
 	return Success(ctx, nil)
 }
 
+// SaveAuthUser
+//
+//	@Summary		保存密码访问用户
+//	@Description	添加或修改网站密码访问的用户，用户对网站所有的密码访问路径生效
+//	@Tags			网站管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id		path		int					true	"网站 ID"
+//	@Param			data	body		requests.AuthUser	true	"request"
+//	@Success		200		{object}	SuccessResponse
+//	@Router			/panel/websites/{id}/auth_users [post]
+func (r *WebsiteController) SaveAuthUser(ctx http.Context) http.Response {
+	var authUserRequest requests.AuthUser
+	sanitize := Sanitize(ctx, &authUserRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	if err := r.website.SaveAuthUser(authUserRequest.ID, authUserRequest.Name, authUserRequest.Password); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"id":    authUserRequest.ID,
+			"name":  authUserRequest.Name,
+			"error": err.Error(),
+		}).Info("保存密码访问用户失败")
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	return Success(ctx, nil)
+}
+
+// DeleteAuthUser
+//
+//	@Summary		删除密码访问用户
+//	@Description	删除网站密码访问的用户
+//	@Tags			网站管理
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id		path		int		true	"网站 ID"
+//	@Param			name	path		string	true	"用户名"
+//	@Success		200		{object}	SuccessResponse
+//	@Router			/panel/websites/{id}/auth_users/{name} [delete]
+func (r *WebsiteController) DeleteAuthUser(ctx http.Context) http.Response {
+	var deleteAuthUserRequest requests.DeleteAuthUser
+	sanitize := Sanitize(ctx, &deleteAuthUserRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	if err := r.website.DeleteAuthUser(deleteAuthUserRequest.ID, deleteAuthUserRequest.Name); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"id":    deleteAuthUserRequest.ID,
+			"name":  deleteAuthUserRequest.Name,
+			"error": err.Error(),
+		}).Info("删除密码访问用户失败")
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	return Success(ctx, nil)
+}
+
 // ClearLog
 //
 //	@Summary		清空日志
@@ -562,6 +683,17 @@ server
     # ssl标记位开始
     # ssl标记位结束
 
+    # redirect标记位开始
+    # redirect标记位结束
+    # auth标记位开始
+    # auth标记位结束
+    # error_page标记位开始
+    # error_page标记位结束
+    # hotlink标记位开始
+    # hotlink标记位结束
+    # deny标记位开始
+    # deny标记位结束
+
     # php标记位开始
     include enable-php-%d.conf;
     # php标记位结束
@@ -573,6 +705,10 @@ server
     waf_cc_deny rate=1000r/m duration=60m;
     waf_cache capacity=50;
     # waf标记位结束
+    # limit标记位开始
+    # limit标记位结束
+    # geo标记位开始
+    # geo标记位结束
 
     # 错误页配置，可自行设置
     #error_page 404 /404.html;
@@ -608,6 +744,18 @@ server
 	if exec, err := tools.Exec("systemctl reload openresty"); err != nil {
 		return Error(ctx, http.StatusInternalServerError, exec)
 	}
+	if err := r.php.Apply(website); err != nil {
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+	if err := r.limit.Apply(website); err != nil {
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+	if err := r.waf.Apply(website); err != nil {
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+	if err := r.country.Apply(website); err != nil {
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
 
 	return Success(ctx, nil)
 }
@@ -631,50 +779,1120 @@ func (r *WebsiteController) Status(ctx http.Context) http.Response {
 	}
 
 	website := models.Website{}
-	if err := facades.Orm().Query().Where("id", idRequest.ID).Get(&website); err != nil {
+	if err := facades.Orm().Query().Where("id", idRequest.ID).FirstOrFail(&website); err != nil {
 		return ErrorSystem(ctx)
 	}
 
-	website.Status = ctx.Request().InputBool("status")
-	if err := facades.Orm().Query().Save(&website); err != nil {
+	if err := r.suspension.Status(website, ctx.Request().InputBool("status")); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"id":    idRequest.ID,
+			"error": err.Error(),
+		}).Info("修改网站状态失败")
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+
+	return Success(ctx, nil)
+}
+
+// SaveExpiry
+//
+//	@Summary		设置到期时间
+//	@Description	设置网站的到期时间，到期当天结束后自动暂停，为空时永不到期，已暂停的网站设置为未到期后自动恢复
+//	@Tags			网站管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id		path		int				true	"网站 ID"
+//	@Param			data	body		requests.Expiry	true	"request"
+//	@Success		200		{object}	SuccessResponse
+//	@Router			/panel/websites/{id}/expiry [post]
+func (r *WebsiteController) SaveExpiry(ctx http.Context) http.Response {
+	var expiryRequest requests.Expiry
+	sanitize := Sanitize(ctx, &expiryRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	website := models.Website{}
+	if err := facades.Orm().Query().Where("id", expiryRequest.ID).FirstOrFail(&website); err != nil {
 		return ErrorSystem(ctx)
 	}
 
-	raw, err := tools.Read("/www/server/vhost/" + website.Name + ".conf")
-	if err != nil {
+	var expiresAt *carbon.DateTime
+	if len(expiryRequest.ExpiresAt) > 0 {
+		expiresAt = &carbon.DateTime{Carbon: carbon.Parse(expiryRequest.ExpiresAt)}
+	}
+	if err := r.suspension.SetExpiry(website, expiresAt); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"id":    expiryRequest.ID,
+			"error": err.Error(),
+		}).Info("设置网站到期时间失败")
 		return Error(ctx, http.StatusInternalServerError, err.Error())
 	}
 
-	// 运行目录
-	rootConfig := tools.Cut(raw, "# root标记位开始\n", "# root标记位结束")
-	match := regexp.MustCompile(`root\s+(.+);`).FindStringSubmatch(rootConfig)
-	if len(match) == 2 {
-		if website.Status {
-			root := regexp.MustCompile(`# root\s+(.+);`).FindStringSubmatch(rootConfig)
-			raw = strings.ReplaceAll(raw, rootConfig, "    root "+root[1]+";\n    ")
-		} else {
-			raw = strings.ReplaceAll(raw, rootConfig, "    root /www/server/openresty/html;\n    # root "+match[1]+";\n    ")
-		}
+	return Success(ctx, nil)
+}
+
+// Extend
+//
+//	@Summary		批量续期
+//	@Description	将网站的到期时间延长指定天数，已到期或未设置到期时间的网站从今天起计算，因到期暂停的网站自动恢复
+//	@Tags			网站管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			data	body		requests.Extend	true	"request"
+//	@Success		200		{object}	SuccessResponse
+//	@Router			/panel/websites/extend [post]
+func (r *WebsiteController) Extend(ctx http.Context) http.Response {
+	var extendRequest requests.Extend
+	sanitize := Sanitize(ctx, &extendRequest)
+	if sanitize != nil {
+		return sanitize
 	}
 
-	// 默认文件
-	indexConfig := tools.Cut(raw, "# index标记位开始\n", "# index标记位结束")
-	match = regexp.MustCompile(`index\s+(.+);`).FindStringSubmatch(indexConfig)
-	if len(match) == 2 {
-		if website.Status {
-			index := regexp.MustCompile(`# index\s+(.+);`).FindStringSubmatch(indexConfig)
-			raw = strings.ReplaceAll(raw, indexConfig, "    index "+index[1]+";\n    ")
-		} else {
-			raw = strings.ReplaceAll(raw, indexConfig, "    index stop.html;\n    # index "+match[1]+";\n    ")
+	return r.bulk(ctx, extendRequest.IDs, "续期", func(website models.Website) error {
+		return r.suspension.Extend(website, extendRequest.Days)
+	})
+}
+
+// Suspend
+//
+//	@Summary		批量暂停
+//	@Description	暂停网站，访问时显示暂停页
+//	@Tags			网站管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			data	body		requests.Bulk	true	"request"
+//	@Success		200		{object}	SuccessResponse
+//	@Router			/panel/websites/suspend [post]
+func (r *WebsiteController) Suspend(ctx http.Context) http.Response {
+	var bulkRequest requests.Bulk
+	sanitize := Sanitize(ctx, &bulkRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	return r.bulk(ctx, bulkRequest.IDs, "暂停", r.suspension.Suspend)
+}
+
+// Resume
+//
+//	@Summary		批量恢复
+//	@Description	恢复暂停的网站，已到期的网站需先续期
+//	@Tags			网站管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			data	body		requests.Bulk	true	"request"
+//	@Success		200		{object}	SuccessResponse
+//	@Router			/panel/websites/resume [post]
+func (r *WebsiteController) Resume(ctx http.Context) http.Response {
+	var bulkRequest requests.Bulk
+	sanitize := Sanitize(ctx, &bulkRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	return r.bulk(ctx, bulkRequest.IDs, "恢复", r.suspension.Resume)
+}
+
+// bulk 对多个网站执行操作，单个网站失败时继续处理其余网站并汇总错误
+func (r *WebsiteController) bulk(ctx http.Context, ids []uint, action string, handle func(website models.Website) error) http.Response {
+	var websites []models.Website
+	if err := facades.Orm().Query().Where("id IN ?", ids).Find(&websites); err != nil {
+		return ErrorSystem(ctx)
+	}
+
+	var failed []string
+	for _, website := range websites {
+		if err := handle(website); err != nil {
+			facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+				"id":    website.ID,
+				"error": err.Error(),
+			}).Info(action + "网站失败")
+			failed = append(failed, website.Name+": "+err.Error())
 		}
 	}
+	if len(failed) > 0 {
+		return Error(ctx, http.StatusInternalServerError, action+"以下网站失败: "+strings.Join(failed, "; "))
+	}
 
-	if err := tools.Write("/www/server/vhost/"+website.Name+".conf", raw, 0644); err != nil {
+	return Success(ctx, nil)
+}
+
+// Stats
+//
+//	@Summary		获取网站统计
+//	@Description	获取网站的请求数、流量、PHP-FPM 进程池和目录占用的每日统计，默认返回最近 30 天
+//	@Tags			网站管理
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id		path		int		true	"网站 ID"
+//	@Param			start	query		string	false	"开始日期，如 2023-12-01"
+//	@Param			end		query		string	false	"结束日期，如 2023-12-31"
+//	@Success		200		{object}	SuccessResponse{data=responses.Stats}
+//	@Router			/panel/websites/{id}/stats [get]
+func (r *WebsiteController) Stats(ctx http.Context) http.Response {
+	var idRequest requests.ID
+	sanitize := Sanitize(ctx, &idRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	end := carbon.Now()
+	if input := ctx.Request().Query("end"); len(input) > 0 {
+		end = carbon.ParseByLayout(input, carbon.DateLayout)
+	}
+	start := end.SubDays(29)
+	if input := ctx.Request().Query("start"); len(input) > 0 {
+		start = carbon.ParseByLayout(input, carbon.DateLayout)
+	}
+	if start.Error != nil || end.Error != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, "日期格式错误")
+	}
+	if start.Gt(end) {
+		return Error(ctx, http.StatusUnprocessableEntity, "开始日期不能晚于结束日期")
+	}
+
+	daily, err := r.stat.Daily(idRequest.ID, start.ToDateString(), end.ToDateString())
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"id":    idRequest.ID,
+			"error": err.Error(),
+		}).Info("获取网站统计失败")
 		return ErrorSystem(ctx)
 	}
-	if exec, err := tools.Exec("systemctl reload openresty"); err != nil {
-		return Error(ctx, http.StatusInternalServerError, exec)
+	summary, err := r.stat.Summary([]uint{idRequest.ID})
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"id":    idRequest.ID,
+			"error": err.Error(),
+		}).Info("获取网站统计失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, responses.Stats{
+		Summary: summary[idRequest.ID],
+		Daily:   daily,
+	})
+}
+
+// GetPool
+//
+//	@Summary		获取独立进程池
+//	@Description	获取网站的独立 PHP-FPM 进程池配置，未开启时返回默认配置
+//	@Tags			网站管理
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"网站 ID"
+//	@Success		200	{object}	SuccessResponse{data=responses.Pool}
+//	@Router			/panel/websites/{id}/pool [get]
+func (r *WebsiteController) GetPool(ctx http.Context) http.Response {
+	var idRequest requests.ID
+	sanitize := Sanitize(ctx, &idRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	pool, err := r.pool.Get(idRequest.ID)
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"id":    idRequest.ID,
+			"error": err.Error(),
+		}).Info("获取独立进程池失败")
+		return ErrorSystem(ctx)
+	}
+	if pool.ID == 0 {
+		pool = models.WebsitePool{
+			WebsiteID:       idRequest.ID,
+			PM:              "dynamic",
+			MaxChildren:     10,
+			StartServers:    2,
+			MinSpareServers: 1,
+			MaxSpareServers: 3,
+			MaxRequests:     500,
+			IdleTimeout:     10,
+			OpenBasedir:     true,
+			AdminValues:     make(map[string]string),
+		}
+	}
+
+	return Success(ctx, responses.Pool{
+		Enabled:     pool.ID > 0,
+		WebsitePool: pool,
+	})
+}
+
+// SavePool
+//
+//	@Summary		保存独立进程池
+//	@Description	开启或更新网站的独立 PHP-FPM 进程池，网站目录将归属于进程池的运行用户
+//	@Tags			网站管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id		path		int				true	"网站 ID"
+//	@Param			data	body		requests.Pool	true	"request"
+//	@Success		200		{object}	SuccessResponse
+//	@Router			/panel/websites/{id}/pool [post]
+func (r *WebsiteController) SavePool(ctx http.Context) http.Response {
+	var poolRequest requests.Pool
+	sanitize := Sanitize(ctx, &poolRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var website models.Website
+	if err := facades.Orm().Query().Where("id", poolRequest.ID).FirstOrFail(&website); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, "网站不存在")
+	}
+
+	if err := r.pool.Save(website, models.WebsitePool{
+		User:            poolRequest.User,
+		PM:              poolRequest.PM,
+		MaxChildren:     poolRequest.MaxChildren,
+		StartServers:    poolRequest.StartServers,
+		MinSpareServers: poolRequest.MinSpareServers,
+		MaxSpareServers: poolRequest.MaxSpareServers,
+		MaxRequests:     poolRequest.MaxRequests,
+		IdleTimeout:     poolRequest.IdleTimeout,
+		OpenBasedir:     poolRequest.OpenBasedir,
+		AdminValues:     poolRequest.AdminValues,
+	}); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"id":    poolRequest.ID,
+			"error": err.Error(),
+		}).Info("保存独立进程池失败")
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+	if err := r.php.UserIni(website); err != nil {
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+
+	return Success(ctx, nil)
+}
+
+// DeletePool
+//
+//	@Summary		关闭独立进程池
+//	@Description	关闭网站的独立 PHP-FPM 进程池，恢复使用 PHP 的默认进程池并删除运行用户
+//	@Tags			网站管理
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"网站 ID"
+//	@Success		200	{object}	SuccessResponse
+//	@Router			/panel/websites/{id}/pool [delete]
+func (r *WebsiteController) DeletePool(ctx http.Context) http.Response {
+	var idRequest requests.ID
+	sanitize := Sanitize(ctx, &idRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var website models.Website
+	if err := facades.Orm().Query().Where("id", idRequest.ID).FirstOrFail(&website); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, "网站不存在")
+	}
+
+	if err := r.pool.Delete(website); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"id":    idRequest.ID,
+			"error": err.Error(),
+		}).Info("关闭独立进程池失败")
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+	if err := r.php.UserIni(website); err != nil {
+		return Error(ctx, http.StatusInternalServerError, err.Error())
 	}
 
 	return Success(ctx, nil)
 }
+
+// GetLimit
+//
+//	@Summary		获取限制
+//	@Description	获取网站的请求频率、并发连接和带宽限制，未开启时返回默认配置
+//	@Tags			网站管理
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"网站 ID"
+//	@Success		200	{object}	SuccessResponse{data=responses.Limit}
+//	@Router			/panel/websites/{id}/limit [get]
+func (r *WebsiteController) GetLimit(ctx http.Context) http.Response {
+	var idRequest requests.ID
+	sanitize := Sanitize(ctx, &idRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	limit, err := r.limit.Get(idRequest.ID)
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"id":    idRequest.ID,
+			"error": err.Error(),
+		}).Info("获取限制失败")
+		return ErrorSystem(ctx)
+	}
+	if limit.ID == 0 {
+		limit = models.WebsiteLimit{
+			WebsiteID: idRequest.ID,
+			Rules:     make([]ratelimit.Rule, 0),
+			Status:    503,
+		}
+	}
+
+	return Success(ctx, responses.Limit{
+		Enabled:      limit.ID > 0,
+		WebsiteLimit: limit,
+	})
+}
+
+// SaveLimit
+//
+//	@Summary		保存限制
+//	@Description	开启或更新网站的请求频率、并发连接和带宽限制，频率限制按客户端 IP 和路径前缀计数
+//	@Tags			网站管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id		path		int				true	"网站 ID"
+//	@Param			data	body		requests.Limit	true	"request"
+//	@Success		200		{object}	SuccessResponse
+//	@Router			/panel/websites/{id}/limit [post]
+func (r *WebsiteController) SaveLimit(ctx http.Context) http.Response {
+	var limitRequest requests.Limit
+	sanitize := Sanitize(ctx, &limitRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var website models.Website
+	if err := facades.Orm().Query().Where("id", limitRequest.ID).FirstOrFail(&website); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, "网站不存在")
+	}
+
+	if err := r.limit.Save(website, models.WebsiteLimit{
+		Rules:     limitRequest.RateRules,
+		Status:    limitRequest.Status,
+		PerIP:     limitRequest.PerIP,
+		PerServer: limitRequest.PerServer,
+		Rate:      limitRequest.Rate,
+		RateAfter: limitRequest.RateAfter,
+	}); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"id":    limitRequest.ID,
+			"error": err.Error(),
+		}).Info("保存限制失败")
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+
+	return Success(ctx, nil)
+}
+
+// DeleteLimit
+//
+//	@Summary		关闭限制
+//	@Description	关闭网站的请求频率、并发连接和带宽限制
+//	@Tags			网站管理
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"网站 ID"
+//	@Success		200	{object}	SuccessResponse
+//	@Router			/panel/websites/{id}/limit [delete]
+func (r *WebsiteController) DeleteLimit(ctx http.Context) http.Response {
+	var idRequest requests.ID
+	sanitize := Sanitize(ctx, &idRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var website models.Website
+	if err := facades.Orm().Query().Where("id", idRequest.ID).FirstOrFail(&website); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, "网站不存在")
+	}
+
+	if err := r.limit.Delete(website); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"id":    idRequest.ID,
+			"error": err.Error(),
+		}).Info("关闭限制失败")
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+
+	return Success(ctx, nil)
+}
+
+// GetWaf
+//
+//	@Summary		获取 WAF 规则
+//	@Description	获取网站在全局规则之外追加的 IP 黑白名单和 URL、参数、UA、Referer、Cookie、POST 正则规则
+//	@Tags			网站管理
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"网站 ID"
+//	@Success		200	{object}	SuccessResponse{data=responses.Waf}
+//	@Router			/panel/websites/{id}/waf [get]
+func (r *WebsiteController) GetWaf(ctx http.Context) http.Response {
+	var idRequest requests.ID
+	sanitize := Sanitize(ctx, &idRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	rules, err := r.waf.Get(idRequest.ID)
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"id":    idRequest.ID,
+			"error": err.Error(),
+		}).Info("获取 WAF 规则失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, responses.Waf{
+		Enabled: rules.ID > 0 && !rules.Rules.Empty(),
+		Rules:   rules.Rules,
+	})
+}
+
+// SaveWaf
+//
+//	@Summary		保存 WAF 规则
+//	@Description	保存网站的 WAF 规则，规则追加到全局规则之后，OpenResty 配置检查未通过时恢复原规则，规则为空时使用全局规则
+//	@Tags			网站管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id		path		int				true	"网站 ID"
+//	@Param			data	body		requests.Waf	true	"request"
+//	@Success		200		{object}	SuccessResponse
+//	@Router			/panel/websites/{id}/waf [post]
+func (r *WebsiteController) SaveWaf(ctx http.Context) http.Response {
+	var wafRequest requests.Waf
+	sanitize := Sanitize(ctx, &wafRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var website models.Website
+	if err := facades.Orm().Query().Where("id", wafRequest.ID).FirstOrFail(&website); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, "网站不存在")
+	}
+
+	if err := r.waf.Save(website, waf.Rules{
+		AllowIPs:  wafRequest.AllowIPs,
+		DenyIPs:   wafRequest.DenyIPs,
+		AllowURLs: wafRequest.AllowURLs,
+		URL:       wafRequest.URL,
+		Args:      wafRequest.Args,
+		UserAgent: wafRequest.UserAgent,
+		Referer:   wafRequest.Referer,
+		Cookie:    wafRequest.Cookie,
+		Post:      wafRequest.Post,
+	}); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"id":    wafRequest.ID,
+			"error": err.Error(),
+		}).Info("保存 WAF 规则失败")
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	return Success(ctx, nil)
+}
+
+// WafLog
+//
+//	@Summary		获取 WAF 拦截日志
+//	@Description	统计网站被 WAF 拦截的请求，按规则和 IP 汇总拦截次数，并返回最近 100 条拦截
+//	@Tags			网站管理
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"网站 ID"
+//	@Success		200	{object}	SuccessResponse{data=waf.Summary}
+//	@Router			/panel/websites/{id}/waf/log [get]
+func (r *WebsiteController) WafLog(ctx http.Context) http.Response {
+	var idRequest requests.ID
+	sanitize := Sanitize(ctx, &idRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var website models.Website
+	if err := facades.Orm().Query().Where("id", idRequest.ID).FirstOrFail(&website); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, "网站不存在")
+	}
+
+	summary, err := r.waf.Log(website)
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"id":    idRequest.ID,
+			"error": err.Error(),
+		}).Info("获取 WAF 拦截日志失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, summary)
+}
+
+// ClearWafLog
+//
+//	@Summary		清空 WAF 拦截日志
+//	@Description	清空网站的 WAF 拦截日志
+//	@Tags			网站管理
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"网站 ID"
+//	@Success		200	{object}	SuccessResponse
+//	@Router			/panel/websites/{id}/waf/log [delete]
+func (r *WebsiteController) ClearWafLog(ctx http.Context) http.Response {
+	var idRequest requests.ID
+	sanitize := Sanitize(ctx, &idRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var website models.Website
+	if err := facades.Orm().Query().Where("id", idRequest.ID).FirstOrFail(&website); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, "网站不存在")
+	}
+
+	if err := r.waf.ClearLog(website); err != nil {
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+
+	return Success(ctx, nil)
+}
+
+// TlsProfiles
+//
+//	@Summary		获取 TLS 预设
+//	@Description	获取网站可选的 TLS 预设及其协议和加密套件
+//	@Tags			网站管理
+//	@Produce		json
+//	@Security		BearerToken
+//	@Success		200	{object}	SuccessResponse{data=[]tlsprofile.Profile}
+//	@Router			/panel/websites/tlsProfiles [get]
+func (r *WebsiteController) TlsProfiles(ctx http.Context) http.Response {
+	return Success(ctx, tlsprofile.Profiles)
+}
+
+// TlsReport
+//
+//	@Summary		获取 TLS 报告
+//	@Description	获取网站的 TLS 配置报告，包括协议、加密套件、HTTP/3、OCSP 装订以及证书的有效期、域名覆盖、私钥匹配和证书链检查
+//	@Tags			网站管理
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"网站 ID"
+//	@Success		200	{object}	SuccessResponse{data=tlsprofile.Report}
+//	@Router			/panel/websites/{id}/tls [get]
+func (r *WebsiteController) TlsReport(ctx http.Context) http.Response {
+	var idRequest requests.ID
+	sanitize := Sanitize(ctx, &idRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	report, err := r.website.TlsReport(idRequest.ID)
+	if err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	return Success(ctx, report)
+}
+
+// GetCountry
+//
+//	@Summary		获取国家规则
+//	@Description	获取网站按国家的访问控制规则，未开启时返回默认配置
+//	@Tags			网站管理
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"网站 ID"
+//	@Success		200	{object}	SuccessResponse{data=responses.Country}
+//	@Router			/panel/websites/{id}/country [get]
+func (r *WebsiteController) GetCountry(ctx http.Context) http.Response {
+	var idRequest requests.ID
+	sanitize := Sanitize(ctx, &idRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	country, err := r.country.Get(idRequest.ID)
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"id":    idRequest.ID,
+			"error": err.Error(),
+		}).Info("获取国家规则失败")
+		return ErrorSystem(ctx)
+	}
+	if country.ID == 0 {
+		country = models.WebsiteCountry{
+			WebsiteID: idRequest.ID,
+			Mode:      "deny",
+			Countries: make([]string, 0),
+			Status:    403,
+		}
+	}
+
+	return Success(ctx, responses.Country{
+		Enabled:        country.ID > 0,
+		WebsiteCountry: country,
+	})
+}
+
+// SaveCountry
+//
+//	@Summary		保存国家规则
+//	@Description	开启或更新网站按国家的访问控制，allow 仅允许所列国家访问，deny 禁止所列国家访问，需先安装 GeoIP 数据库
+//	@Tags			网站管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id		path		int					true	"网站 ID"
+//	@Param			data	body		requests.Country	true	"request"
+//	@Success		200		{object}	SuccessResponse
+//	@Router			/panel/websites/{id}/country [post]
+func (r *WebsiteController) SaveCountry(ctx http.Context) http.Response {
+	var countryRequest requests.Country
+	sanitize := Sanitize(ctx, &countryRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var website models.Website
+	if err := facades.Orm().Query().Where("id", countryRequest.ID).FirstOrFail(&website); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, "网站不存在")
+	}
+
+	if err := r.country.Save(website, models.WebsiteCountry{
+		Mode:      countryRequest.Mode,
+		Countries: countryRequest.Countries,
+		Status:    countryRequest.Status,
+	}); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"id":    countryRequest.ID,
+			"error": err.Error(),
+		}).Info("保存国家规则失败")
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+
+	return Success(ctx, nil)
+}
+
+// DeleteCountry
+//
+//	@Summary		关闭国家规则
+//	@Description	关闭网站按国家的访问控制
+//	@Tags			网站管理
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"网站 ID"
+//	@Success		200	{object}	SuccessResponse
+//	@Router			/panel/websites/{id}/country [delete]
+func (r *WebsiteController) DeleteCountry(ctx http.Context) http.Response {
+	var idRequest requests.ID
+	sanitize := Sanitize(ctx, &idRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var website models.Website
+	if err := facades.Orm().Query().Where("id", idRequest.ID).FirstOrFail(&website); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, "网站不存在")
+	}
+
+	if err := r.country.Delete(website); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"id":    idRequest.ID,
+			"error": err.Error(),
+		}).Info("关闭国家规则失败")
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+
+	return Success(ctx, nil)
+}
+
+// GetPhpSettings
+//
+//	@Summary		获取 PHP 配置
+//	@Description	获取网站的 PHP 配置，开启独立进程池时写入进程池，否则写入运行目录的 .user.ini
+//	@Tags			网站管理
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"网站 ID"
+//	@Success		200	{object}	SuccessResponse{data=responses.PhpSettings}
+//	@Router			/panel/websites/{id}/php [get]
+func (r *WebsiteController) GetPhpSettings(ctx http.Context) http.Response {
+	var idRequest requests.ID
+	sanitize := Sanitize(ctx, &idRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var website models.Website
+	if err := facades.Orm().Query().Where("id", idRequest.ID).FirstOrFail(&website); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, "网站不存在")
+	}
+	pool, err := r.pool.Get(website.ID)
+	if err != nil {
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, responses.PhpSettings{
+		Php:      website.Php,
+		Pool:     pool.ID > 0,
+		Settings: website.PhpSettings,
+	})
+}
+
+// SavePhpSettings
+//
+//	@Summary		保存 PHP 配置
+//	@Description	保存并应用网站的 PHP 配置，为空的项使用 php.ini 中的配置
+//	@Tags			网站管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id		path		int						true	"网站 ID"
+//	@Param			data	body		requests.PhpSettings	true	"request"
+//	@Success		200		{object}	SuccessResponse
+//	@Router			/panel/websites/{id}/php [post]
+func (r *WebsiteController) SavePhpSettings(ctx http.Context) http.Response {
+	var settingsRequest requests.PhpSettings
+	sanitize := Sanitize(ctx, &settingsRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var website models.Website
+	if err := facades.Orm().Query().Where("id", settingsRequest.ID).FirstOrFail(&website); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, "网站不存在")
+	}
+
+	if err := r.php.Save(website, models.WebsitePhpSettings{
+		UploadMaxFilesize: settingsRequest.UploadMaxFilesize,
+		PostMaxSize:       settingsRequest.PostMaxSize,
+		MaxExecutionTime:  settingsRequest.MaxExecutionTime,
+		MemoryLimit:       settingsRequest.MemoryLimit,
+		DisableFunctions:  settingsRequest.DisableFunctions,
+		DisplayErrors:     settingsRequest.DisplayErrors,
+		Extensions:        settingsRequest.Extensions,
+	}); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"id":    settingsRequest.ID,
+			"error": err.Error(),
+		}).Info("保存 PHP 配置失败")
+		return Error(ctx, http.StatusInternalServerError, err.Error())
+	}
+
+	return Success(ctx, nil)
+}
+
+// CheckPhp
+//
+//	@Summary		检查 PHP 版本兼容性
+//	@Description	检查网站依赖的扩展在目标 PHP 版本中是否已安装
+//	@Tags			网站管理
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"网站 ID"
+//	@Param			php	query		int	true	"目标 PHP 版本，如 82"
+//	@Success		200	{object}	SuccessResponse{data=responses.PhpCheck}
+//	@Router			/panel/websites/{id}/php/check [get]
+func (r *WebsiteController) CheckPhp(ctx http.Context) http.Response {
+	var idRequest requests.ID
+	sanitize := Sanitize(ctx, &idRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var website models.Website
+	if err := facades.Orm().Query().Where("id", idRequest.ID).FirstOrFail(&website); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, "网站不存在")
+	}
+
+	php := ctx.Request().QueryInt("php")
+	if php <= 0 {
+		return Error(ctx, http.StatusUnprocessableEntity, "PHP 版本错误")
+	}
+	modules, err := r.php.Modules(php)
+	if err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+	missing, err := r.php.Check(website, php)
+	if err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	return Success(ctx, responses.PhpCheck{
+		Php:     php,
+		Modules: modules,
+		Missing: missing,
+	})
+}
+
+// Apps
+//
+//	@Summary		获取可部署的应用
+//	@Description	获取可一键部署到网站的 PHP 应用
+//	@Tags			网站管理
+//	@Produce		json
+//	@Security		BearerToken
+//	@Success		200	{object}	SuccessResponse
+//	@Router			/panel/websites/apps [get]
+func (r *WebsiteController) Apps(ctx http.Context) http.Response {
+	return Success(ctx, r.app.Apps())
+}
+
+// InstallApp
+//
+//	@Summary		部署应用
+//	@Description	部署应用到网站，自动设置运行目录、伪静态、数据库和目录权限，部署在后台任务中进行
+//	@Tags			网站管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id		path		int					true	"网站 ID"
+//	@Param			data	body		requests.InstallApp	true	"request"
+//	@Success		200		{object}	SuccessResponse{data=models.Task}
+//	@Router			/panel/websites/{id}/app [post]
+func (r *WebsiteController) InstallApp(ctx http.Context) http.Response {
+	var installRequest requests.InstallApp
+	sanitize := Sanitize(ctx, &installRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var website models.Website
+	if err := facades.Orm().Query().Where("id", installRequest.ID).FirstOrFail(&website); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, "网站不存在")
+	}
+
+	task, err := r.app.Install(website, services.WebsiteAppInstall{
+		App:        installRequest.App,
+		Package:    installRequest.Package,
+		Version:    installRequest.Version,
+		Root:       installRequest.Root,
+		Writable:   installRequest.Writable,
+		Db:         installRequest.Db,
+		DbType:     installRequest.DbType,
+		DbName:     installRequest.DbName,
+		DbUser:     installRequest.DbUser,
+		DbPassword: installRequest.DbPassword,
+	})
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"id":    installRequest.ID,
+			"app":   installRequest.App,
+			"error": err.Error(),
+		}).Info("部署网站应用失败")
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	return Success(ctx, task)
+}
+
+// Export
+//
+//	@Summary		导出迁移包
+//	@Description	将网站的配置、证书、数据库和网站文件导出为迁移包，导出在后台任务中进行
+//	@Tags			网站管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id		path		int				true	"网站 ID"
+//	@Param			data	body		requests.Export	true	"request"
+//	@Success		200		{object}	SuccessResponse{data=models.Task}
+//	@Router			/panel/websites/{id}/export [post]
+func (r *WebsiteController) Export(ctx http.Context) http.Response {
+	var exportRequest requests.Export
+	sanitize := Sanitize(ctx, &exportRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	var website models.Website
+	if err := facades.Orm().Query().Where("id", exportRequest.ID).FirstOrFail(&website); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, "网站不存在")
+	}
+	var databases []sitebundle.Database
+	for _, item := range exportRequest.Databases {
+		db, err := sitebundle.NewDatabase(item.Type, item.Name)
+		if err != nil {
+			return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+		}
+		databases = append(databases, db)
+	}
+
+	task, err := r.bundle.Export(website, databases)
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"id":    exportRequest.ID,
+			"error": err.Error(),
+		}).Info("导出迁移包失败")
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	return Success(ctx, task)
+}
+
+// BundleList
+//
+//	@Summary		获取迁移包列表
+//	@Description	获取已导出和已上传的迁移包
+//	@Tags			网站管理
+//	@Produce		json
+//	@Security		BearerToken
+//	@Success		200	{object}	SuccessResponse{data=[]services.BackupFile}
+//	@Router			/panel/website/bundleList [get]
+func (r *WebsiteController) BundleList(ctx http.Context) http.Response {
+	list, err := r.bundle.List()
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"error": err.Error(),
+		}).Info("获取迁移包列表失败")
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	return Success(ctx, list)
+}
+
+// UploadBundle
+//
+//	@Summary		上传迁移包
+//	@Description	上传其他面板导出的迁移包
+//	@Tags			网站管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			file	formData	file	true	"迁移包"
+//	@Success		200		{object}	SuccessResponse
+//	@Router			/panel/website/uploadBundle [put]
+func (r *WebsiteController) UploadBundle(ctx http.Context) http.Response {
+	file, err := ctx.Request().File("file")
+	if err != nil {
+		return Error(ctx, http.StatusInternalServerError, "上传文件失败")
+	}
+	path, err := r.bundle.Path()
+	if err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	name := filepath.Base(file.GetClientOriginalName())
+	if !sitebundle.BundlePattern.MatchString(name) {
+		return Error(ctx, http.StatusUnprocessableEntity, "迁移包文件名只能包含字母、数字和 _.- 符号，且以 .tar 结尾")
+	}
+
+	if _, err = file.StoreAs(path, name); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"error": err.Error(),
+		}).Info("上传迁移包失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, nil)
+}
+
+// DeleteBundle
+//
+//	@Summary		删除迁移包
+//	@Description	删除迁移包
+//	@Tags			网站管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			data	body		requests.DeleteBackup	true	"request"
+//	@Success		200		{object}	SuccessResponse
+//	@Router			/panel/website/deleteBundle [delete]
+func (r *WebsiteController) DeleteBundle(ctx http.Context) http.Response {
+	var deleteRequest requests.DeleteBackup
+	sanitize := Sanitize(ctx, &deleteRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	if err := r.bundle.Delete(deleteRequest.Name); err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	return Success(ctx, nil)
+}
+
+// InspectBundle
+//
+//	@Summary		检查迁移包
+//	@Description	读取迁移包中的网站信息，检查按指定的名称、目录、域名和数据库导入时的冲突
+//	@Tags			网站管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			data	body		requests.ImportBundle	true	"request"
+//	@Success		200		{object}	SuccessResponse{data=services.WebsiteBundleInspect}
+//	@Router			/panel/website/inspectBundle [post]
+func (r *WebsiteController) InspectBundle(ctx http.Context) http.Response {
+	var importRequest requests.ImportBundle
+	sanitize := Sanitize(ctx, &importRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	inspect, err := r.bundle.Inspect(r.bundleImport(importRequest))
+	if err != nil {
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	return Success(ctx, inspect)
+}
+
+// ImportBundle
+//
+//	@Summary		导入迁移包
+//	@Description	按指定的名称、目录、域名和数据库创建网站，网站文件和数据库在后台任务中导入
+//	@Tags			网站管理
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			data	body		requests.ImportBundle	true	"request"
+//	@Success		200		{object}	SuccessResponse{data=models.Task}
+//	@Router			/panel/website/importBundle [post]
+func (r *WebsiteController) ImportBundle(ctx http.Context) http.Response {
+	var importRequest requests.ImportBundle
+	sanitize := Sanitize(ctx, &importRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	task, err := r.bundle.Import(r.bundleImport(importRequest))
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站管理").With(map[string]any{
+			"file":  importRequest.File,
+			"error": err.Error(),
+		}).Info("导入迁移包失败")
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	return Success(ctx, task)
+}
+
+// bundleImport 转换导入参数
+func (r *WebsiteController) bundleImport(request requests.ImportBundle) services.WebsiteBundleImport {
+	target := services.WebsiteBundleImport{
+		File:    request.File,
+		Name:    request.Name,
+		Domains: request.Domains,
+		Path:    request.Path,
+		Php:     request.Php,
+	}
+	for _, db := range request.Databases {
+		target.Databases = append(target.Databases, services.WebsiteBundleDatabase{
+			Name:     db.Name,
+			Target:   db.Target,
+			User:     db.User,
+			Password: db.Password,
+		})
+	}
+
+	return target
+}
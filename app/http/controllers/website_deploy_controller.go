@@ -0,0 +1,295 @@
+package controllers
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/facades"
+
+	requests "panel/app/http/requests/website"
+	responses "panel/app/http/responses/website"
+	"panel/app/models"
+	"panel/app/services"
+)
+
+type WebsiteDeployController struct {
+	deploy services.WebsiteDeploy
+}
+
+func NewWebsiteDeployController() *WebsiteDeployController {
+	return &WebsiteDeployController{
+		deploy: services.NewWebsiteDeployImpl(),
+	}
+}
+
+// Show
+//
+//	@Summary		获取发布配置
+//	@Description	获取网站的 Git 发布配置、部署公钥和 Webhook 地址
+//	@Tags			网站发布
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"网站 ID"
+//	@Success		200	{object}	SuccessResponse{data=responses.Deploy}
+//	@Router			/panel/websites/{id}/deploy [get]
+func (r *WebsiteDeployController) Show(ctx http.Context) http.Response {
+	var idRequest requests.ID
+	sanitize := Sanitize(ctx, &idRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	website, response := r.website(ctx, idRequest.ID)
+	if response != nil {
+		return response
+	}
+	config, err := r.deploy.Get(website.ID)
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站发布").With(map[string]any{
+			"id":    website.ID,
+			"error": err.Error(),
+		}).Info("获取发布配置失败")
+		return ErrorSystem(ctx)
+	}
+	if config.ID == 0 {
+		config = models.WebsiteDeploy{
+			WebsiteID:   website.ID,
+			Branch:      "main",
+			SharedDirs:  []string{},
+			SharedFiles: []string{},
+			Keep:        5,
+		}
+	}
+	publicKey, err := r.deploy.PublicKey(website)
+	if err != nil {
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, responses.Deploy{
+		Enabled:       config.ID > 0,
+		PublicKey:     publicKey,
+		Webhook:       "/api/panel/webhooks/deploy/" + strconv.Itoa(int(website.ID)),
+		WebsiteDeploy: config,
+	})
+}
+
+// Save
+//
+//	@Summary		保存发布配置
+//	@Description	保存网站的 Git 发布配置，首次保存时生成部署密钥并将网站运行目录指向 current
+//	@Tags			网站发布
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id		path		int				true	"网站 ID"
+//	@Param			data	body		requests.Deploy	true	"request"
+//	@Success		200		{object}	SuccessResponse{data=models.WebsiteDeploy}
+//	@Router			/panel/websites/{id}/deploy [post]
+func (r *WebsiteDeployController) Save(ctx http.Context) http.Response {
+	var deployRequest requests.Deploy
+	sanitize := Sanitize(ctx, &deployRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	website, response := r.website(ctx, deployRequest.ID)
+	if response != nil {
+		return response
+	}
+	config, err := r.deploy.Save(website, models.WebsiteDeploy{
+		Repository:  deployRequest.Repository,
+		Branch:      deployRequest.Branch,
+		Root:        deployRequest.Root,
+		SharedDirs:  deployRequest.SharedDirs,
+		SharedFiles: deployRequest.SharedFiles,
+		Build:       deployRequest.Build,
+		PostDeploy:  deployRequest.PostDeploy,
+		Keep:        deployRequest.Keep,
+		AutoDeploy:  deployRequest.AutoDeploy,
+		Secret:      deployRequest.Secret,
+	})
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站发布").With(map[string]any{
+			"id":    website.ID,
+			"error": err.Error(),
+		}).Info("保存发布配置失败")
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	return Success(ctx, config)
+}
+
+// Delete
+//
+//	@Summary		删除发布配置
+//	@Description	删除网站的 Git 发布配置、发布记录和部署密钥，网站文件和运行目录保持不变
+//	@Tags			网站发布
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"网站 ID"
+//	@Success		200	{object}	SuccessResponse
+//	@Router			/panel/websites/{id}/deploy [delete]
+func (r *WebsiteDeployController) Delete(ctx http.Context) http.Response {
+	var idRequest requests.ID
+	sanitize := Sanitize(ctx, &idRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	website, response := r.website(ctx, idRequest.ID)
+	if response != nil {
+		return response
+	}
+	if err := r.deploy.Delete(website); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站发布").With(map[string]any{
+			"id":    website.ID,
+			"error": err.Error(),
+		}).Info("删除发布配置失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, nil)
+}
+
+// Deploy
+//
+//	@Summary		发布
+//	@Description	拉取仓库最新代码发布新版本，发布在后台任务中进行
+//	@Tags			网站发布
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"网站 ID"
+//	@Success		200	{object}	SuccessResponse{data=models.WebsiteRelease}
+//	@Router			/panel/websites/{id}/deploy/run [post]
+func (r *WebsiteDeployController) Deploy(ctx http.Context) http.Response {
+	var idRequest requests.ID
+	sanitize := Sanitize(ctx, &idRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	website, response := r.website(ctx, idRequest.ID)
+	if response != nil {
+		return response
+	}
+	release, err := r.deploy.Deploy(website, models.WebsiteReleaseTriggerManual)
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站发布").With(map[string]any{
+			"id":    website.ID,
+			"error": err.Error(),
+		}).Info("创建发布任务失败")
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	return Success(ctx, release)
+}
+
+// Releases
+//
+//	@Summary		获取发布记录
+//	@Description	获取网站最近 100 条发布和回滚记录
+//	@Tags			网站发布
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id	path		int	true	"网站 ID"
+//	@Success		200	{object}	SuccessResponse{data=[]services.WebsiteReleaseInfo}
+//	@Router			/panel/websites/{id}/releases [get]
+func (r *WebsiteDeployController) Releases(ctx http.Context) http.Response {
+	var idRequest requests.ID
+	sanitize := Sanitize(ctx, &idRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	website, response := r.website(ctx, idRequest.ID)
+	if response != nil {
+		return response
+	}
+	releases, err := r.deploy.Releases(website)
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站发布").With(map[string]any{
+			"id":    website.ID,
+			"error": err.Error(),
+		}).Info("获取发布记录失败")
+		return ErrorSystem(ctx)
+	}
+
+	return Success(ctx, releases)
+}
+
+// Rollback
+//
+//	@Summary		回滚
+//	@Description	将网站切换到之前发布成功的版本
+//	@Tags			网站发布
+//	@Produce		json
+//	@Security		BearerToken
+//	@Param			id			path		int	true	"网站 ID"
+//	@Param			release_id	path		int	true	"发布记录 ID"
+//	@Success		200			{object}	SuccessResponse
+//	@Router			/panel/websites/{id}/releases/{release_id}/rollback [post]
+func (r *WebsiteDeployController) Rollback(ctx http.Context) http.Response {
+	var rollbackRequest requests.Rollback
+	sanitize := Sanitize(ctx, &rollbackRequest)
+	if sanitize != nil {
+		return sanitize
+	}
+
+	website, response := r.website(ctx, rollbackRequest.ID)
+	if response != nil {
+		return response
+	}
+	if err := r.deploy.Rollback(website, rollbackRequest.ReleaseID); err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站发布").With(map[string]any{
+			"id":      website.ID,
+			"release": rollbackRequest.ReleaseID,
+			"error":   err.Error(),
+		}).Info("回滚版本失败")
+		return Error(ctx, http.StatusUnprocessableEntity, err.Error())
+	}
+
+	return Success(ctx, nil)
+}
+
+// Webhook
+//
+//	@Summary		发布 Webhook
+//	@Description	供 GitHub、Gitea、GitLab、Gitee 推送事件调用，校验签名或令牌后发布配置的分支
+//	@Tags			网站发布
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int	true	"网站 ID"
+//	@Success		200	{object}	SuccessResponse
+//	@Router			/panel/webhooks/deploy/{id} [post]
+func (r *WebsiteDeployController) Webhook(ctx http.Context) http.Response {
+	id := ctx.Request().RouteInt("id")
+	body, err := io.ReadAll(io.LimitReader(ctx.Request().Origin().Body, 10<<20))
+	if err != nil || id <= 0 {
+		return Error(ctx, http.StatusBadRequest, "请求不合法")
+	}
+
+	release, err := r.deploy.Webhook(uint(id), ctx.Request().Headers(), body)
+	if err != nil {
+		facades.Log().Request(ctx.Request()).Tags("面板", "网站发布").With(map[string]any{
+			"id":    id,
+			"error": err.Error(),
+		}).Info("Webhook 发布失败")
+		return Error(ctx, http.StatusForbidden, err.Error())
+	}
+	if release.ID == 0 {
+		return Success(ctx, "忽略非发布分支的事件")
+	}
+
+	return Success(ctx, release)
+}
+
+// website 获取网站
+func (r *WebsiteDeployController) website(ctx http.Context, id uint) (models.Website, http.Response) {
+	var website models.Website
+	if err := facades.Orm().Query().Where("id", id).FirstOrFail(&website); err != nil {
+		return models.Website{}, Error(ctx, http.StatusUnprocessableEntity, "网站不存在")
+	}
+
+	return website, nil
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strings"
+	"time"
+
+	"github.com/goravel/fiber"
+	"github.com/goravel/framework/contracts/http"
+
+	"panel/app/models"
+	"panel/app/services"
+)
+
+// Metrics 记录面板 API 的请求耗时
+func Metrics() http.Middleware {
+	metrics := services.NewMetricsImpl()
+	return func(ctx http.Context) {
+		path := ctx.Request().Path()
+		if !strings.HasPrefix(path, "/api/") {
+			ctx.Request().Next()
+			return
+		}
+
+		start := time.Now()
+		ctx.Request().Next()
+		metrics.ObserveRequest(ctx.Request().Method(), route(ctx), ctx.Response().Origin().Status(), time.Since(start))
+	}
+}
+
+// route 请求匹配到的路由模板，未匹配到 API 路由时统一为 unmatched，避免标签基数过大
+func route(ctx http.Context) string {
+	if fiberCtx, ok := ctx.(*fiber.Context); ok {
+		if template := fiberCtx.Instance().Route().Path; strings.HasPrefix(template, "/api/") {
+			return template
+		}
+	}
+
+	return "unmatched"
+}
+
+// MetricsToken 确保通过指标令牌鉴权，未设置令牌时指标接口关闭
+func MetricsToken() http.Middleware {
+	return func(ctx http.Context) {
+		token := services.NewSettingImpl().Get(models.SettingKeyMetricsToken)
+		if len(token) == 0 {
+			ctx.Request().AbortWithStatus(http.StatusNotFound)
+			return
+		}
+
+		provided := strings.TrimPrefix(ctx.Request().Header("Authorization"), "Bearer ")
+		if len(provided) == 0 {
+			provided = ctx.Request().Query("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			ctx.Request().AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		ctx.Request().Next()
+	}
+}
@@ -0,0 +1,32 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type RuleDestroy struct {
+	ID uint `form:"id" json:"id"`
+}
+
+func (r *RuleDestroy) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *RuleDestroy) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"id": "required|uint|min:1|exists:alert_rules,id",
+	}
+}
+
+func (r *RuleDestroy) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *RuleDestroy) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *RuleDestroy) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
@@ -0,0 +1,44 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type RuleStore struct {
+	Name      string  `form:"name" json:"name"`
+	Metric    string  `form:"metric" json:"metric"`
+	Target    string  `form:"target" json:"target"`
+	Operator  string  `form:"operator" json:"operator"`
+	Threshold float64 `form:"threshold" json:"threshold"`
+	Duration  int     `form:"duration" json:"duration"`
+	Enabled   bool    `form:"enabled" json:"enabled"`
+}
+
+func (r *RuleStore) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *RuleStore) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"name":      "required|max_len:255|not_exists:alert_rules,name",
+		"metric":    "required|in:cpu,load1,load5,load15,mem,swap,disk,disk_read,disk_write,net_rx,net_tx",
+		"target":    "required_if:metric,disk|string",
+		"operator":  "required|in:>,>=,<,<=",
+		"threshold": "required|number",
+		"duration":  "int|min:0|max:1440",
+		"enabled":   "bool",
+	}
+}
+
+func (r *RuleStore) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *RuleStore) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *RuleStore) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
@@ -0,0 +1,46 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type RuleUpdate struct {
+	ID        uint    `form:"id" json:"id"`
+	Name      string  `form:"name" json:"name"`
+	Metric    string  `form:"metric" json:"metric"`
+	Target    string  `form:"target" json:"target"`
+	Operator  string  `form:"operator" json:"operator"`
+	Threshold float64 `form:"threshold" json:"threshold"`
+	Duration  int     `form:"duration" json:"duration"`
+	Enabled   bool    `form:"enabled" json:"enabled"`
+}
+
+func (r *RuleUpdate) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *RuleUpdate) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"id":        "required|uint|min:1|exists:alert_rules,id",
+		"name":      "required|max_len:255",
+		"metric":    "required|in:cpu,load1,load5,load15,mem,swap,disk,disk_read,disk_write,net_rx,net_tx",
+		"target":    "required_if:metric,disk|string",
+		"operator":  "required|in:>,>=,<,<=",
+		"threshold": "required|number",
+		"duration":  "int|min:0|max:1440",
+		"enabled":   "bool",
+	}
+}
+
+func (r *RuleUpdate) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *RuleUpdate) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *RuleUpdate) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
@@ -0,0 +1,32 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type SilenceDestroy struct {
+	ID uint `form:"id" json:"id"`
+}
+
+func (r *SilenceDestroy) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *SilenceDestroy) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"id": "required|uint|min:1|exists:alert_silences,id",
+	}
+}
+
+func (r *SilenceDestroy) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *SilenceDestroy) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *SilenceDestroy) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
@@ -0,0 +1,38 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type SilenceStore struct {
+	RuleID  uint   `form:"rule_id" json:"rule_id"`
+	Reason  string `form:"reason" json:"reason"`
+	StartAt string `form:"start_at" json:"start_at"`
+	EndAt   string `form:"end_at" json:"end_at"`
+}
+
+func (r *SilenceStore) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *SilenceStore) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"rule_id":  "uint",
+		"reason":   "max_len:255",
+		"start_at": "date",
+		"end_at":   "required|date",
+	}
+}
+
+func (r *SilenceStore) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *SilenceStore) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *SilenceStore) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
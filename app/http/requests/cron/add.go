@@ -0,0 +1,94 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+
+	"panel/pkg/crontab"
+)
+
+type Add struct {
+	Name           string `form:"name" json:"name"`
+	Time           string `form:"time" json:"time"`
+	Type           string `form:"type" json:"type"`
+	Script         string `form:"script" json:"script"`
+	BackupType     string `form:"backup_type" json:"backup_type"`
+	Target         string `form:"target" json:"target"`
+	Path           string `form:"path" json:"path"`
+	Save           int    `form:"save" json:"save"`
+	URL            string `form:"url" json:"url"`
+	Method         string `form:"method" json:"method"`
+	ExpectStatus   int    `form:"expect_status" json:"expect_status"`
+	Keyword        string `form:"keyword" json:"keyword"`
+	RequestTimeout int    `form:"request_timeout" json:"request_timeout"`
+	Destination    string `form:"destination" json:"destination"`
+	Port           int    `form:"port" json:"port"`
+	Delete         bool   `form:"delete" json:"delete"`
+	Timeout        int    `form:"timeout" json:"timeout"`
+	Overlap        bool   `form:"overlap" json:"overlap"`
+	Notify         bool   `form:"notify" json:"notify"`
+}
+
+func (r *Add) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *Add) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"name":            "required|min_len:1|max_len:255",
+		"time":            "required",
+		"type":            "required|in:shell,backup,cutoff,ping,sync",
+		"script":          "required_if:type,shell",
+		"backup_type":     "required_if:type,backup|in:website,mysql,postgresql",
+		"target":          "required_if:type,backup,cutoff|regex:^[a-zA-Z0-9_-]+(\\.[a-zA-Z0-9_-]+)*$",
+		"path":            "required_if:type,sync",
+		"save":            "int|min:1",
+		"url":             "required_if:type,ping|full_url",
+		"method":          "in:GET,HEAD,POST",
+		"expect_status":   "int|min:100|max:599",
+		"keyword":         "string",
+		"request_timeout": "int|min:1|max:300",
+		"destination":     "required_if:type,sync",
+		"port":            "int|min:1|max:65535",
+		"delete":          "bool",
+		"timeout":         "int|min:0",
+		"overlap":         "bool",
+		"notify":          "bool",
+	}
+}
+
+func (r *Add) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Add) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Add) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	if _, exist := data.Get("notify"); !exist {
+		if err := data.Set("notify", true); err != nil {
+			return err
+		}
+	}
+
+	return prepare(data)
+}
+
+// Config 模板类计划任务的配置
+func (r *Add) Config() crontab.Config {
+	return crontab.Config{
+		BackupType:  r.BackupType,
+		Target:      r.Target,
+		Path:        r.Path,
+		Save:        r.Save,
+		URL:         r.URL,
+		Method:      r.Method,
+		Status:      r.ExpectStatus,
+		Keyword:     r.Keyword,
+		Timeout:     r.RequestTimeout,
+		Destination: r.Destination,
+		Port:        r.Port,
+		Delete:      r.Delete,
+	}
+}
@@ -0,0 +1,28 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/validation"
+)
+
+// prepare 兼容旧版本表单字段
+func prepare(data validation.Data) error {
+	aliases := map[string][]string{
+		"target": {"website", "backup_database"},
+		"path":   {"backup_path"},
+	}
+	for key, olds := range aliases {
+		if value, exist := data.Get(key); exist && value != nil && value != "" {
+			continue
+		}
+		for _, old := range olds {
+			if value, exist := data.Get(old); exist && value != nil && value != "" {
+				if err := data.Set(key, value); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+
+	return nil
+}
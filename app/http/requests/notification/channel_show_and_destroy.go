@@ -0,0 +1,32 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type ChannelShowAndDestroy struct {
+	ID uint `form:"id" json:"id"`
+}
+
+func (r *ChannelShowAndDestroy) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *ChannelShowAndDestroy) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"id": "required|uint|min:1|exists:notification_channels,id",
+	}
+}
+
+func (r *ChannelShowAndDestroy) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *ChannelShowAndDestroy) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *ChannelShowAndDestroy) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
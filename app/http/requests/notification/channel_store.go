@@ -0,0 +1,42 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+
+	"panel/pkg/notify"
+)
+
+type ChannelStore struct {
+	Name    string        `form:"name" json:"name"`
+	Type    string        `form:"type" json:"type"`
+	Config  notify.Config `form:"config" json:"config"`
+	Events  []string      `form:"events" json:"events"`
+	Enabled bool          `form:"enabled" json:"enabled"`
+}
+
+func (r *ChannelStore) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *ChannelStore) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"name":    "required|max_len:255|not_exists:notification_channels,name",
+		"type":    "required|in:email,webhook,telegram,dingtalk,wecom",
+		"config":  "required",
+		"events":  "slice",
+		"enabled": "bool",
+	}
+}
+
+func (r *ChannelStore) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *ChannelStore) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *ChannelStore) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
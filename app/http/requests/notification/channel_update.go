@@ -0,0 +1,44 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+
+	"panel/pkg/notify"
+)
+
+type ChannelUpdate struct {
+	ID      uint          `form:"id" json:"id"`
+	Name    string        `form:"name" json:"name"`
+	Type    string        `form:"type" json:"type"`
+	Config  notify.Config `form:"config" json:"config"`
+	Events  []string      `form:"events" json:"events"`
+	Enabled bool          `form:"enabled" json:"enabled"`
+}
+
+func (r *ChannelUpdate) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *ChannelUpdate) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"id":      "required|uint|min:1|exists:notification_channels,id",
+		"name":    "required|max_len:255",
+		"type":    "required|in:email,webhook,telegram,dingtalk,wecom",
+		"config":  "required",
+		"events":  "slice",
+		"enabled": "bool",
+	}
+}
+
+func (r *ChannelUpdate) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *ChannelUpdate) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *ChannelUpdate) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
@@ -0,0 +1,34 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type ExtensionStatus struct {
+	Slug    string `form:"slug" json:"slug"`
+	Enabled bool   `form:"enabled" json:"enabled"`
+}
+
+func (r *ExtensionStatus) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *ExtensionStatus) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"slug":    "required|string",
+		"enabled": "bool",
+	}
+}
+
+func (r *ExtensionStatus) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *ExtensionStatus) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *ExtensionStatus) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
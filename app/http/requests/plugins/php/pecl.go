@@ -0,0 +1,40 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type Pecl struct {
+	Package   string   `form:"package" json:"package"`
+	Version   string   `form:"version" json:"version"`
+	Name      string   `form:"name" json:"name"`
+	Zend      bool     `form:"zend" json:"zend"`
+	Configure []string `form:"configure" json:"configure"`
+}
+
+func (r *Pecl) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *Pecl) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"package":   "required|regex:^[a-zA-Z][a-zA-Z0-9_]*$",
+		"version":   "regex:^[0-9]+\\.[0-9]+(\\.[0-9]+)?([a-zA-Z]+[0-9]*)?$",
+		"name":      "regex:^[a-zA-Z][a-zA-Z0-9_]*$",
+		"zend":      "bool",
+		"configure": "slice",
+	}
+}
+
+func (r *Pecl) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Pecl) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Pecl) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
@@ -0,0 +1,32 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type ProxyShowAndDestroy struct {
+	ID uint `form:"id" json:"id"`
+}
+
+func (r *ProxyShowAndDestroy) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *ProxyShowAndDestroy) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"id": "required|uint|min:1|exists:stream_proxies,id",
+	}
+}
+
+func (r *ProxyShowAndDestroy) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *ProxyShowAndDestroy) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *ProxyShowAndDestroy) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
@@ -0,0 +1,63 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+	"github.com/spf13/cast"
+
+	"panel/pkg/streamproxy"
+)
+
+type ProxyStore struct {
+	Name                string                `form:"name" json:"name"`
+	Port                int                   `form:"port" json:"port"`
+	Protocol            string                `form:"protocol" json:"protocol"`
+	Backends            []streamproxy.Backend `form:"backends" json:"backends"`
+	Balance             string                `form:"balance" json:"balance"`
+	ProxyProtocol       bool                  `form:"proxy_protocol" json:"proxy_protocol"`
+	AcceptProxyProtocol bool                  `form:"accept_proxy_protocol" json:"accept_proxy_protocol"`
+	ConnectTimeout      int                   `form:"connect_timeout" json:"connect_timeout"`
+	Timeout             int                   `form:"timeout" json:"timeout"`
+	SSL                 bool                  `form:"ssl" json:"ssl"`
+	CertID              *uint                 `form:"cert_id" json:"cert_id"`
+}
+
+func (r *ProxyStore) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *ProxyStore) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"name":                  "required|max_len:255",
+		"port":                  "required|int|min:1|max:65535",
+		"protocol":              "required|in:tcp,udp",
+		"backends":              "required|slice",
+		"balance":               "in:least_conn,hash,random",
+		"proxy_protocol":        "bool",
+		"accept_proxy_protocol": "bool",
+		"connect_timeout":       "int|min:0",
+		"timeout":               "int|min:0",
+		"ssl":                   "bool",
+		"cert_id":               "uint|exists:certs,id",
+	}
+}
+
+func (r *ProxyStore) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *ProxyStore) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *ProxyStore) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	// TODO 由于验证器 filter 标签的问题，暂时这里这样处理
+	certID, exist := data.Get("cert_id")
+	if exist {
+		if err := data.Set("cert_id", cast.ToUint(certID)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
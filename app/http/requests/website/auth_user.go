@@ -0,0 +1,36 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type AuthUser struct {
+	ID       uint   `form:"id" json:"id" filter:"uint"`
+	Name     string `form:"name" json:"name"`
+	Password string `form:"password" json:"password"`
+}
+
+func (r *AuthUser) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *AuthUser) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"id":       "required|exists:websites,id",
+		"name":     "required|regex:^[a-zA-Z0-9_.-]{1,32}$",
+		"password": "required|min_len:6|max_len:128",
+	}
+}
+
+func (r *AuthUser) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *AuthUser) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *AuthUser) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
@@ -0,0 +1,33 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type Bulk struct {
+	IDs []uint `form:"ids" json:"ids"`
+}
+
+func (r *Bulk) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *Bulk) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"ids":   "required|slice",
+		"ids.*": "uint|exists:websites,id",
+	}
+}
+
+func (r *Bulk) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Bulk) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Bulk) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
@@ -0,0 +1,59 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type CloneDatabase struct {
+	Type      string `form:"type" json:"type"`
+	Source    string `form:"source" json:"source"`
+	Target    string `form:"target" json:"target"`
+	User      string `form:"user" json:"user"`
+	Password  string `form:"password" json:"password"`
+	AppConfig bool   `form:"app_config" json:"app_config"`
+}
+
+type Clone struct {
+	ID        uint            `form:"id" json:"id" filter:"uint"`
+	Name      string          `form:"name" json:"name"`
+	Domains   []string        `form:"domains" json:"domains"`
+	Path      string          `form:"path" json:"path"`
+	Php       int             `form:"php" json:"php"`
+	Replace   bool            `form:"replace" json:"replace"`
+	Databases []CloneDatabase `form:"databases" json:"databases"`
+}
+
+func (r *Clone) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *Clone) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"id":                     "required|exists:websites,id",
+		"name":                   "required|regex:^[a-zA-Z0-9_-]+(\\.[a-zA-Z0-9_-]+)*$|not_exists:websites,name|not_in:phpmyadmin,mysql,panel,ssh",
+		"domains":                "required|slice",
+		"path":                   "regex:^/[a-zA-Z0-9_.-]+(\\/[a-zA-Z0-9_.-]+)*$",
+		"php":                    "int",
+		"replace":                "bool",
+		"databases":              "slice",
+		"databases.*.type":       "required|in:mysql,postgresql",
+		"databases.*.source":     "required|regex:^[a-zA-Z0-9_-]+$",
+		"databases.*.target":     "required|regex:^[a-zA-Z0-9_-]+$",
+		"databases.*.user":       "required|regex:^[a-zA-Z0-9_-]+$",
+		"databases.*.password":   "required|min_len:8",
+		"databases.*.app_config": "bool",
+	}
+}
+
+func (r *Clone) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Clone) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Clone) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
@@ -0,0 +1,39 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type Country struct {
+	ID        uint     `form:"id" json:"id" filter:"uint"`
+	Mode      string   `form:"mode" json:"mode"`
+	Countries []string `form:"countries" json:"countries"`
+	Status    int      `form:"status" json:"status"`
+}
+
+func (r *Country) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *Country) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"id":          "required|exists:websites,id",
+		"mode":        "required|in:allow,deny",
+		"countries":   "required|slice",
+		"countries.*": "regex:^[A-Z]{2}$",
+		"status":      "required|int|min:400|max:599",
+	}
+}
+
+func (r *Country) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Country) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Country) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
@@ -0,0 +1,34 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type DeleteAuthUser struct {
+	ID   uint   `form:"id" json:"id" filter:"uint"`
+	Name string `form:"name" json:"name"`
+}
+
+func (r *DeleteAuthUser) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *DeleteAuthUser) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"id":   "required|exists:websites,id",
+		"name": "required|regex:^[a-zA-Z0-9_.-]{1,32}$",
+	}
+}
+
+func (r *DeleteAuthUser) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *DeleteAuthUser) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *DeleteAuthUser) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
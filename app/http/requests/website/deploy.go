@@ -0,0 +1,52 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type Deploy struct {
+	ID          uint     `form:"id" json:"id" filter:"uint"`
+	Repository  string   `form:"repository" json:"repository"`
+	Branch      string   `form:"branch" json:"branch"`
+	Root        string   `form:"root" json:"root"`
+	SharedDirs  []string `form:"shared_dirs" json:"shared_dirs"`
+	SharedFiles []string `form:"shared_files" json:"shared_files"`
+	Build       string   `form:"build" json:"build"`
+	PostDeploy  string   `form:"post_deploy" json:"post_deploy"`
+	Keep        int      `form:"keep" json:"keep"`
+	AutoDeploy  bool     `form:"auto_deploy" json:"auto_deploy"`
+	Secret      string   `form:"secret" json:"secret"`
+}
+
+func (r *Deploy) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *Deploy) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"id":           "required|exists:websites,id",
+		"repository":   "required|string|max_len:255",
+		"branch":       "required|string|max_len:255",
+		"root":         "regex:^[a-zA-Z0-9_.-]+(\\/[a-zA-Z0-9_.-]+)*$",
+		"shared_dirs":  "slice",
+		"shared_files": "slice",
+		"build":        "string",
+		"post_deploy":  "string",
+		"keep":         "required|int|min:1|max:50",
+		"auto_deploy":  "bool",
+		"secret":       "regex:^[a-zA-Z0-9_-]{16,64}$",
+	}
+}
+
+func (r *Deploy) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Deploy) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Deploy) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
@@ -0,0 +1,34 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type Expiry struct {
+	ID        uint   `form:"id" json:"id" filter:"uint"`
+	ExpiresAt string `form:"expires_at" json:"expires_at"` // 为空时永不到期
+}
+
+func (r *Expiry) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *Expiry) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"id":         "required|exists:websites,id",
+		"expires_at": "date",
+	}
+}
+
+func (r *Expiry) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Expiry) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Expiry) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
@@ -0,0 +1,41 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type ExportDatabase struct {
+	Type string `form:"type" json:"type"`
+	Name string `form:"name" json:"name"`
+}
+
+type Export struct {
+	ID        uint             `form:"id" json:"id" filter:"uint"`
+	Databases []ExportDatabase `form:"databases" json:"databases"`
+}
+
+func (r *Export) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *Export) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"id":               "required|exists:websites,id",
+		"databases":        "slice",
+		"databases.*.type": "required|in:mysql,postgresql",
+		"databases.*.name": "required|regex:^[a-zA-Z0-9_-]+$",
+	}
+}
+
+func (r *Export) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Export) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Export) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
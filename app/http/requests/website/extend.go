@@ -0,0 +1,35 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type Extend struct {
+	IDs  []uint `form:"ids" json:"ids"`
+	Days int    `form:"days" json:"days"`
+}
+
+func (r *Extend) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *Extend) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"ids":   "required|slice",
+		"ids.*": "uint|exists:websites,id",
+		"days":  "required|int|min:1|max:3650",
+	}
+}
+
+func (r *Extend) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Extend) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Extend) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
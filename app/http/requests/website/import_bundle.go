@@ -0,0 +1,53 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type BundleDatabase struct {
+	Name     string `form:"name" json:"name"`
+	Target   string `form:"target" json:"target"`
+	User     string `form:"user" json:"user"`
+	Password string `form:"password" json:"password"`
+}
+
+type ImportBundle struct {
+	File      string           `form:"file" json:"file"`
+	Name      string           `form:"name" json:"name"`
+	Domains   []string         `form:"domains" json:"domains"`
+	Path      string           `form:"path" json:"path"`
+	Php       int              `form:"php" json:"php"`
+	Databases []BundleDatabase `form:"databases" json:"databases"`
+}
+
+func (r *ImportBundle) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *ImportBundle) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"file":                 "required|regex:^[a-zA-Z0-9_-]+(\\.[a-zA-Z0-9_-]+)*\\.tar$",
+		"name":                 "regex:^[a-zA-Z0-9_-]+(\\.[a-zA-Z0-9_-]+)*$|not_in:phpmyadmin,mysql,panel,ssh",
+		"domains":              "slice",
+		"path":                 "regex:^/[a-zA-Z0-9_.-]+(\\/[a-zA-Z0-9_.-]+)*$",
+		"php":                  "int",
+		"databases":            "slice",
+		"databases.*.name":     "required|string",
+		"databases.*.target":   "regex:^[a-zA-Z0-9_-]+$",
+		"databases.*.user":     "required_with:databases.*.target|regex:^[a-zA-Z0-9_-]+$",
+		"databases.*.password": "required_with:databases.*.target|min_len:8",
+	}
+}
+
+func (r *ImportBundle) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *ImportBundle) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *ImportBundle) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
@@ -0,0 +1,52 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type InstallApp struct {
+	ID         uint     `form:"id" json:"id" filter:"uint"`
+	App        string   `form:"app" json:"app"`
+	Package    string   `form:"package" json:"package"`
+	Version    string   `form:"version" json:"version"`
+	Root       string   `form:"root" json:"root"`
+	Writable   []string `form:"writable" json:"writable"`
+	Db         bool     `form:"db" json:"db"`
+	DbType     string   `form:"db_type" json:"db_type"`
+	DbName     string   `form:"db_name" json:"db_name"`
+	DbUser     string   `form:"db_user" json:"db_user"`
+	DbPassword string   `form:"db_password" json:"db_password"`
+}
+
+func (r *InstallApp) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *InstallApp) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"id":          "required|exists:websites,id",
+		"app":         "required|string",
+		"package":     "required_if:app,composer|string",
+		"version":     "string",
+		"root":        "regex:^[a-zA-Z0-9_-]+(\\/[a-zA-Z0-9_-]+)*$",
+		"writable":    "slice",
+		"db":          "bool",
+		"db_type":     "required_if:db,true|in:mysql,postgresql",
+		"db_name":     "required_if:db,true|regex:^[a-zA-Z0-9_-]+$",
+		"db_user":     "required_if:db,true|regex:^[a-zA-Z0-9_-]+$",
+		"db_password": "required_if:db,true|min_len:8",
+	}
+}
+
+func (r *InstallApp) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *InstallApp) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *InstallApp) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
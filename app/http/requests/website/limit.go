@@ -0,0 +1,46 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+
+	"panel/pkg/ratelimit"
+)
+
+type Limit struct {
+	ID        uint             `form:"id" json:"id" filter:"uint"`
+	RateRules []ratelimit.Rule `form:"rules" json:"rules"`
+	Status    int              `form:"status" json:"status"`
+	PerIP     int              `form:"per_ip" json:"per_ip"`
+	PerServer int              `form:"per_server" json:"per_server"`
+	Rate      int              `form:"rate" json:"rate"`
+	RateAfter int              `form:"rate_after" json:"rate_after"`
+}
+
+func (r *Limit) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *Limit) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"id":         "required|exists:websites,id",
+		"rules":      "slice",
+		"status":     "required|int|min:400|max:599",
+		"per_ip":     "int|min:0",
+		"per_server": "int|min:0",
+		"rate":       "int|min:0",
+		"rate_after": "int|min:0",
+	}
+}
+
+func (r *Limit) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Limit) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Limit) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
@@ -0,0 +1,46 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type PhpSettings struct {
+	ID                uint     `form:"id" json:"id" filter:"uint"`
+	UploadMaxFilesize string   `form:"upload_max_filesize" json:"upload_max_filesize"`
+	PostMaxSize       string   `form:"post_max_size" json:"post_max_size"`
+	MaxExecutionTime  int      `form:"max_execution_time" json:"max_execution_time"`
+	MemoryLimit       string   `form:"memory_limit" json:"memory_limit"`
+	DisableFunctions  string   `form:"disable_functions" json:"disable_functions"`
+	DisplayErrors     string   `form:"display_errors" json:"display_errors"`
+	Extensions        []string `form:"extensions" json:"extensions"`
+}
+
+func (r *PhpSettings) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *PhpSettings) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"id":                  "required|exists:websites,id",
+		"upload_max_filesize": "regex:^\\d+[KMG]?$",
+		"post_max_size":       "regex:^\\d+[KMG]?$",
+		"max_execution_time":  "int|min:0|max:86400",
+		"memory_limit":        "regex:^(-1|\\d+[KMG]?)$",
+		"disable_functions":   "string",
+		"display_errors":      "in:On,Off",
+		"extensions":          "slice",
+	}
+}
+
+func (r *PhpSettings) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *PhpSettings) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *PhpSettings) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
@@ -0,0 +1,52 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type Pool struct {
+	ID              uint              `form:"id" json:"id" filter:"uint"`
+	User            string            `form:"user" json:"user"`
+	PM              string            `form:"pm" json:"pm"`
+	MaxChildren     int               `form:"max_children" json:"max_children"`
+	StartServers    int               `form:"start_servers" json:"start_servers"`
+	MinSpareServers int               `form:"min_spare_servers" json:"min_spare_servers"`
+	MaxSpareServers int               `form:"max_spare_servers" json:"max_spare_servers"`
+	MaxRequests     int               `form:"max_requests" json:"max_requests"`
+	IdleTimeout     int               `form:"idle_timeout" json:"idle_timeout"`
+	OpenBasedir     bool              `form:"open_basedir" json:"open_basedir"`
+	AdminValues     map[string]string `form:"admin_values" json:"admin_values"`
+}
+
+func (r *Pool) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *Pool) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"id":                "required|exists:websites,id",
+		"user":              "regex:^[a-z_][a-z0-9_-]{0,31}$|not_in:root,www,mysql,postgres,redis",
+		"pm":                "required|in:static,dynamic,ondemand",
+		"max_children":      "required|int|min:1|max:1000",
+		"start_servers":     "int|min:0|max:1000",
+		"min_spare_servers": "int|min:0|max:1000",
+		"max_spare_servers": "int|min:0|max:1000",
+		"max_requests":      "int|min:0",
+		"idle_timeout":      "int|min:0|max:86400",
+		"open_basedir":      "bool",
+		"admin_values":      "map",
+	}
+}
+
+func (r *Pool) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Pool) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Pool) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
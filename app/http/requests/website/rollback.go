@@ -0,0 +1,34 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type Rollback struct {
+	ID        uint `form:"id" json:"id" filter:"uint"`
+	ReleaseID uint `form:"release_id" json:"release_id" filter:"uint"`
+}
+
+func (r *Rollback) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *Rollback) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"id":         "required|exists:websites,id",
+		"release_id": "required|exists:website_releases,id",
+	}
+}
+
+func (r *Rollback) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Rollback) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Rollback) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
@@ -3,28 +3,42 @@ package requests
 import (
 	"github.com/goravel/framework/contracts/http"
 	"github.com/goravel/framework/contracts/validation"
+
+	"panel/pkg/siterule"
 )
 
 type SaveConfig struct {
-	ID                uint     `form:"id" json:"id" filter:"uint"`
-	Domains           []string `form:"domains" json:"domains"`
-	Ports             []uint   `form:"ports" json:"ports"`
-	Hsts              bool     `form:"hsts" json:"hsts"`
-	Ssl               bool     `form:"ssl" json:"ssl"`
-	HttpRedirect      bool     `form:"http_redirect" json:"http_redirect"`
-	OpenBasedir       bool     `form:"open_basedir" json:"open_basedir"`
-	Waf               bool     `form:"waf" json:"waf"`
-	WafCache          string   `form:"waf_cache" json:"waf_cache"`
-	WafMode           string   `form:"waf_mode" json:"waf_mode"`
-	WafCcDeny         string   `form:"waf_cc_deny" json:"waf_cc_deny"`
-	Index             string   `form:"index" json:"index"`
-	Path              string   `form:"path" json:"path"`
-	Root              string   `form:"root" json:"root"`
-	Raw               string   `form:"raw" json:"raw"`
-	Rewrite           string   `form:"rewrite" json:"rewrite"`
-	Php               int      `form:"php" json:"php" filter:"int"`
-	SslCertificate    string   `form:"ssl_certificate" json:"ssl_certificate"`
-	SslCertificateKey string   `form:"ssl_certificate_key" json:"ssl_certificate_key"`
+	ID                    uint     `form:"id" json:"id" filter:"uint"`
+	Domains               []string `form:"domains" json:"domains"`
+	Ports                 []uint   `form:"ports" json:"ports"`
+	Hsts                  bool     `form:"hsts" json:"hsts"`
+	Ssl                   bool     `form:"ssl" json:"ssl"`
+	HttpRedirect          bool     `form:"http_redirect" json:"http_redirect"`
+	OpenBasedir           bool     `form:"open_basedir" json:"open_basedir"`
+	Waf                   bool     `form:"waf" json:"waf"`
+	WafCache              string   `form:"waf_cache" json:"waf_cache"`
+	WafMode               string   `form:"waf_mode" json:"waf_mode"`
+	WafCcDeny             string   `form:"waf_cc_deny" json:"waf_cc_deny"`
+	Index                 string   `form:"index" json:"index"`
+	Path                  string   `form:"path" json:"path"`
+	Root                  string   `form:"root" json:"root"`
+	Raw                   string   `form:"raw" json:"raw"`
+	Rewrite               string   `form:"rewrite" json:"rewrite"`
+	Php                   int      `form:"php" json:"php" filter:"int"`
+	SslCertificate        string   `form:"ssl_certificate" json:"ssl_certificate"`
+	SslCertificateKey     string   `form:"ssl_certificate_key" json:"ssl_certificate_key"`
+	SslDualCertificate    string   `form:"ssl_dual_certificate" json:"ssl_dual_certificate"`
+	SslDualCertificateKey string   `form:"ssl_dual_certificate_key" json:"ssl_dual_certificate_key"`
+	TlsProfile            string   `form:"tls_profile" json:"tls_profile"`
+	Http3                 bool     `form:"http3" json:"http3"`
+	Ocsp                  bool     `form:"ocsp" json:"ocsp"`
+	OcspResolver          string   `form:"ocsp_resolver" json:"ocsp_resolver"`
+
+	Redirects  []siterule.Redirect `form:"redirects" json:"redirects"`
+	AuthPaths  []string            `form:"auth_paths" json:"auth_paths"`
+	ErrorPages siterule.ErrorPages `form:"error_pages" json:"error_pages"`
+	Hotlink    siterule.Hotlink    `form:"hotlink" json:"hotlink"`
+	Denies     []siterule.Deny     `form:"denies" json:"denies"`
 }
 
 func (r *SaveConfig) Authorize(ctx http.Context) error {
@@ -33,25 +47,33 @@ func (r *SaveConfig) Authorize(ctx http.Context) error {
 
 func (r *SaveConfig) Rules(ctx http.Context) map[string]string {
 	return map[string]string{
-		"id":                  "required|exists:websites,id",
-		"domains":             "required|slice",
-		"ports":               "required|slice",
-		"hsts":                "bool",
-		"ssl":                 "bool",
-		"http_redirect":       "bool",
-		"open_basedir":        "bool",
-		"waf":                 "bool",
-		"waf_cache":           "required|string",
-		"waf_mode":            "required|string",
-		"waf_cc_deny":         "required|string",
-		"index":               "required|string",
-		"path":                "required|string",
-		"root":                "required|string",
-		"raw":                 "required|string",
-		"rewrite":             "string",
-		"php":                 "int",
-		"ssl_certificate":     "required_if:ssl,true",
-		"ssl_certificate_key": "required_if:ssl,true",
+		"id":                       "required|exists:websites,id",
+		"domains":                  "required|slice",
+		"ports":                    "required|slice",
+		"hsts":                     "bool",
+		"ssl":                      "bool",
+		"http_redirect":            "bool",
+		"open_basedir":             "bool",
+		"waf":                      "bool",
+		"waf_cache":                "required|string",
+		"waf_mode":                 "required|string",
+		"waf_cc_deny":              "required|string",
+		"index":                    "required|string",
+		"path":                     "required|string",
+		"root":                     "required|string",
+		"raw":                      "required|string",
+		"rewrite":                  "string",
+		"php":                      "int",
+		"ssl_certificate":          "required_if:ssl,true",
+		"ssl_certificate_key":      "required_if:ssl,true",
+		"ssl_dual_certificate_key": "required_with:ssl_dual_certificate",
+		"tls_profile":              "required|in:modern,intermediate,legacy",
+		"http3":                    "bool",
+		"ocsp":                     "bool",
+		"ocsp_resolver":            "string",
+		"redirects":                "slice",
+		"auth_paths":               "slice",
+		"denies":                   "slice",
 	}
 }
 
@@ -82,6 +104,12 @@ func (r *SaveConfig) PrepareForValidation(ctx http.Context, data validation.Data
 			return err
 		}
 	}
+	_, exist = data.Get("tls_profile")
+	if !exist {
+		if err := data.Set("tls_profile", "intermediate"); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -0,0 +1,50 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type Waf struct {
+	ID        uint     `form:"id" json:"id" filter:"uint"`
+	AllowIPs  []string `form:"allow_ips" json:"allow_ips"`
+	DenyIPs   []string `form:"deny_ips" json:"deny_ips"`
+	AllowURLs []string `form:"allow_urls" json:"allow_urls"`
+	URL       []string `form:"url" json:"url"`
+	Args      []string `form:"args" json:"args"`
+	UserAgent []string `form:"user_agent" json:"user_agent"`
+	Referer   []string `form:"referer" json:"referer"`
+	Cookie    []string `form:"cookie" json:"cookie"`
+	Post      []string `form:"post" json:"post"`
+}
+
+func (r *Waf) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *Waf) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"id":         "required|exists:websites,id",
+		"allow_ips":  "slice",
+		"deny_ips":   "slice",
+		"allow_urls": "slice",
+		"url":        "slice",
+		"args":       "slice",
+		"user_agent": "slice",
+		"referer":    "slice",
+		"cookie":     "slice",
+		"post":       "slice",
+	}
+}
+
+func (r *Waf) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Waf) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Waf) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
@@ -0,0 +1,32 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type ShowAndDestroy struct {
+	ID uint `form:"id" json:"id"`
+}
+
+func (r *ShowAndDestroy) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *ShowAndDestroy) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"id": "required|uint|min:1|exists:website_checks,id",
+	}
+}
+
+func (r *ShowAndDestroy) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *ShowAndDestroy) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *ShowAndDestroy) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
@@ -0,0 +1,50 @@
+package requests
+
+import (
+	"github.com/goravel/framework/contracts/http"
+	"github.com/goravel/framework/contracts/validation"
+)
+
+type Store struct {
+	WebsiteID    uint   `form:"website_id" json:"website_id"`
+	URL          string `form:"url" json:"url"`
+	Method       string `form:"method" json:"method"`
+	ExpectStatus int    `form:"expect_status" json:"expect_status"`
+	Keyword      string `form:"keyword" json:"keyword"`
+	Interval     int    `form:"interval" json:"interval"`
+	Timeout      int    `form:"timeout" json:"timeout"`
+	TLSWarnDays  int    `form:"tls_warn_days" json:"tls_warn_days"`
+	Insecure     bool   `form:"insecure" json:"insecure"`
+	Enabled      bool   `form:"enabled" json:"enabled"`
+}
+
+func (r *Store) Authorize(ctx http.Context) error {
+	return nil
+}
+
+func (r *Store) Rules(ctx http.Context) map[string]string {
+	return map[string]string{
+		"website_id":    "required|uint|min:1|exists:websites,id",
+		"url":           "required|full_url|max_len:255",
+		"method":        "required|in:GET,HEAD",
+		"expect_status": "int|min:0|max:599",
+		"keyword":       "string|max_len:255",
+		"interval":      "required|int|min:30|max:86400",
+		"timeout":       "required|int|min:1|max:60",
+		"tls_warn_days": "int|min:0|max:365",
+		"insecure":      "bool",
+		"enabled":       "bool",
+	}
+}
+
+func (r *Store) Messages(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Store) Attributes(ctx http.Context) map[string]string {
+	return map[string]string{}
+}
+
+func (r *Store) PrepareForValidation(ctx http.Context, data validation.Data) error {
+	return nil
+}
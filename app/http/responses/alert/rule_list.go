@@ -0,0 +1,8 @@
+package responses
+
+import "panel/app/models"
+
+type RuleList struct {
+	Total int64              `json:"total"`
+	Items []models.AlertRule `json:"items"`
+}
@@ -0,0 +1,8 @@
+package responses
+
+import "panel/app/models"
+
+type SilenceList struct {
+	Total int64                 `json:"total"`
+	Items []models.AlertSilence `json:"items"`
+}
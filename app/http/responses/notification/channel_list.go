@@ -0,0 +1,8 @@
+package responses
+
+import "panel/app/models"
+
+type ChannelList struct {
+	Total int64                        `json:"total"`
+	Items []models.NotificationChannel `json:"items"`
+}
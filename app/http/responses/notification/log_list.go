@@ -0,0 +1,8 @@
+package responses
+
+import "panel/app/models"
+
+type LogList struct {
+	Total int64                    `json:"total"`
+	Items []models.NotificationLog `json:"items"`
+}
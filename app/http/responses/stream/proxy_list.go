@@ -0,0 +1,8 @@
+package responses
+
+import "panel/app/models"
+
+type ProxyList struct {
+	Total int64                `json:"total"`
+	Items []models.StreamProxy `json:"items"`
+}
@@ -0,0 +1,10 @@
+package responses
+
+import "panel/app/models"
+
+type Deploy struct {
+	Enabled   bool   `json:"enabled"`
+	PublicKey string `json:"public_key"` // 部署公钥，需添加到仓库的部署密钥中
+	Webhook   string `json:"webhook"`    // Webhook 地址的路径部分
+	models.WebsiteDeploy
+}
@@ -0,0 +1,8 @@
+package responses
+
+import "panel/app/models"
+
+type Limit struct {
+	Enabled bool `json:"enabled"`
+	models.WebsiteLimit
+}
@@ -1,8 +1,16 @@
 package responses
 
-import "panel/app/models"
+import (
+	"panel/app/models"
+	"panel/app/services"
+)
+
+type Website struct {
+	models.Website
+	Stat services.WebsiteStatSummary `json:"stat"`
+}
 
 type List struct {
-	Total int64            `json:"total"`
-	Items []models.Website `json:"items"`
+	Total int64     `json:"total"`
+	Items []Website `json:"items"`
 }
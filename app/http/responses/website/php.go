@@ -0,0 +1,15 @@
+package responses
+
+import "panel/app/models"
+
+type PhpCheck struct {
+	Php     int      `json:"php"`
+	Modules []string `json:"modules"` // 目标版本已安装的扩展
+	Missing []string `json:"missing"` // 网站依赖但目标版本未安装的扩展
+}
+
+type PhpSettings struct {
+	Php      int                       `json:"php"`
+	Pool     bool                      `json:"pool"` // 是否开启独立进程池，未开启时禁用函数不可用
+	Settings models.WebsitePhpSettings `json:"settings"`
+}
@@ -0,0 +1,11 @@
+package responses
+
+import (
+	"panel/app/models"
+	"panel/app/services"
+)
+
+type Stats struct {
+	Summary services.WebsiteStatSummary `json:"summary"`
+	Daily   []models.WebsiteStat        `json:"daily"`
+}
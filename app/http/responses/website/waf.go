@@ -0,0 +1,8 @@
+package responses
+
+import "panel/pkg/waf"
+
+type Waf struct {
+	Enabled bool `json:"enabled"` // 是否使用网站规则
+	waf.Rules
+}
@@ -0,0 +1,18 @@
+package responses
+
+import "panel/app/models"
+
+type Check struct {
+	models.WebsiteCheck
+	Uptime map[string]*float64 `json:"uptime"` // 最近 1、7、30 天的可用率（%），无数据时为 null
+}
+
+type List struct {
+	Total int64   `json:"total"`
+	Items []Check `json:"items"`
+}
+
+type ResultList struct {
+	Total int64                       `json:"total"`
+	Items []models.WebsiteCheckResult `json:"items"`
+}
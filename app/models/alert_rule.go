@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/goravel/framework/support/carbon"
+
+	"panel/pkg/alert"
+)
+
+type AlertRule struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	Name      string          `gorm:"not null;unique" json:"name"`
+	Metric    string          `gorm:"not null" json:"metric"`             // 监控指标
+	Target    string          `gorm:"not null;default:''" json:"target"`  // 指标对象，如磁盘挂载点
+	Operator  string          `gorm:"not null" json:"operator"`           // 比较运算符
+	Threshold float64         `gorm:"not null" json:"threshold"`          // 阈值
+	Duration  int             `gorm:"not null;default:0" json:"duration"` // 持续时间（分钟）
+	Enabled   bool            `gorm:"not null" json:"enabled"`
+	State     alert.State     `gorm:"type:json;serializer:json" json:"state"` // 运行状态
+	CreatedAt carbon.DateTime `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+	UpdatedAt carbon.DateTime `gorm:"autoUpdateTime;column:updated_at" json:"updated_at"`
+}
+
+// Rule 转换为告警计算使用的规则
+func (r AlertRule) Rule() alert.Rule {
+	return alert.Rule{
+		Metric:    r.Metric,
+		Target:    r.Target,
+		Operator:  r.Operator,
+		Threshold: r.Threshold,
+		Duration:  time.Duration(r.Duration) * time.Minute,
+	}
+}
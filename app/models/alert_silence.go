@@ -0,0 +1,15 @@
+package models
+
+import (
+	"github.com/goravel/framework/support/carbon"
+)
+
+type AlertSilence struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	RuleID    uint            `gorm:"not null;default:0" json:"rule_id"` // 静默的规则 ID，0 为全部规则
+	Reason    string          `gorm:"not null;default:''" json:"reason"`
+	StartAt   carbon.DateTime `gorm:"not null" json:"start_at"`
+	EndAt     carbon.DateTime `gorm:"not null" json:"end_at"`
+	CreatedAt carbon.DateTime `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+	UpdatedAt carbon.DateTime `gorm:"autoUpdateTime;column:updated_at" json:"updated_at"`
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"github.com/goravel/framework/support/carbon"
+
+	"panel/pkg/crontab"
 )
 
 type Cron struct {
@@ -12,6 +14,10 @@ type Cron struct {
 	Time      string          `gorm:"not null" json:"time"`
 	Shell     string          `gorm:"default:''" json:"shell"`
 	Log       string          `gorm:"default:''" json:"log"`
+	Config    crontab.Config  `gorm:"type:json;serializer:json" json:"config"` // 模板类任务的配置
+	Timeout   int             `gorm:"not null;default:0" json:"timeout"`       // 超时时间（秒），0 为不限制
+	Overlap   bool            `gorm:"not null;default:false" json:"overlap"`   // 是否允许重叠运行
+	Notify    bool            `gorm:"not null" json:"notify"`                  // 失败时是否通知
 	CreatedAt carbon.DateTime `gorm:"autoCreateTime;column:created_at" json:"created_at"`
 	UpdatedAt carbon.DateTime `gorm:"autoUpdateTime;column:updated_at" json:"updated_at"`
 }
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"github.com/goravel/framework/support/carbon"
+)
+
+const (
+	CronRunStatusRunning = "running"
+	CronRunStatusSuccess = "success"
+	CronRunStatusFailed  = "failed"
+	CronRunStatusTimeout = "timeout"
+	CronRunStatusSkipped = "skipped"
+)
+
+type CronRun struct {
+	ID        uint             `gorm:"primaryKey" json:"id"`
+	CronID    uint             `gorm:"not null" json:"cron_id"`
+	Status    string           `gorm:"not null" json:"status"`
+	ExitCode  *int             `gorm:"default:null" json:"exit_code"`      // 退出码，未结束或被跳过时为空
+	Pid       int              `gorm:"not null;default:0" json:"pid"`      // 脚本进程 ID
+	Output    string           `gorm:"not null;default:''" json:"output"`  // 输出内容（仅保留末尾部分）
+	Duration  int64            `gorm:"not null;default:0" json:"duration"` // 运行时长（毫秒）
+	StartedAt carbon.DateTime  `gorm:"not null" json:"started_at"`         // 开始时间
+	EndedAt   *carbon.DateTime `gorm:"default:null" json:"ended_at"`       // 结束时间
+	CreatedAt carbon.DateTime  `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+	UpdatedAt carbon.DateTime  `gorm:"autoUpdateTime;column:updated_at" json:"updated_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"github.com/goravel/framework/support/carbon"
+)
+
+// FirewallCountry 防火墙按国家的访问控制
+type FirewallCountry struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	Country   string          `gorm:"not null" json:"country"`
+	Action    string          `gorm:"not null" json:"action"`   // accept 或 drop
+	Port      string          `gorm:"not null" json:"port"`     // 端口或端口范围，为空时匹配所有端口
+	Protocol  string          `gorm:"not null" json:"protocol"` // tcp 或 udp，端口为空时忽略
+	CreatedAt carbon.DateTime `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+	UpdatedAt carbon.DateTime `gorm:"autoUpdateTime;column:updated_at" json:"updated_at"`
+}
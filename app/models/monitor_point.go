@@ -0,0 +1,13 @@
+package models
+
+// MonitorPoint 监控指标在一个时间桶内的聚合值
+type MonitorPoint struct {
+	ID         uint    `gorm:"primaryKey" json:"id"`
+	Resolution int64   `gorm:"not null" json:"resolution"` // 存储粒度（秒）
+	Metric     string  `gorm:"not null" json:"metric"`
+	Time       int64   `gorm:"not null" json:"time"` // 时间桶起点（Unix 时间戳）
+	Min        float64 `gorm:"not null" json:"min"`
+	Avg        float64 `gorm:"not null" json:"avg"`
+	Max        float64 `gorm:"not null" json:"max"`
+	Count      int     `gorm:"not null" json:"count"` // 聚合的原始样本数
+}
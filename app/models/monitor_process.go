@@ -0,0 +1,16 @@
+package models
+
+// MonitorProcess 一次采样中资源占用最高的进程
+type MonitorProcess struct {
+	ID        uint    `gorm:"primaryKey" json:"id"`
+	Time      int64   `gorm:"not null" json:"time"` // 采样时间所在分钟（Unix 时间戳）
+	PID       int32   `gorm:"column:pid;not null" json:"pid"`
+	Name      string  `gorm:"not null" json:"name"`
+	Cmdline   string  `gorm:"not null" json:"cmdline"`
+	CPU       float64 `gorm:"column:cpu;not null" json:"cpu"` // CPU 使用率（%）
+	RSS       uint64  `gorm:"column:rss;not null" json:"rss"`
+	OpenFiles int32   `gorm:"not null" json:"open_files"`
+	Threads   int32   `gorm:"not null" json:"threads"`
+	ReadRate  float64 `gorm:"not null" json:"read_rate"`  // 磁盘读取速度（字节/秒）
+	WriteRate float64 `gorm:"not null" json:"write_rate"` // 磁盘写入速度（字节/秒）
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"github.com/goravel/framework/support/carbon"
+
+	"panel/pkg/notify"
+)
+
+const (
+	NotificationEventCertExpiring    = "cert_expiring"
+	NotificationEventCertRenewFailed = "cert_renew_failed"
+	NotificationEventBackupFailed    = "backup_failed"
+	NotificationEventDiskFull        = "disk_full"
+	NotificationEventServiceDown     = "service_down"
+	NotificationEventLoginNewIP      = "login_new_ip"
+	NotificationEventPanelUpdate     = "panel_update"
+	NotificationEventCronFailed      = "cron_failed"
+	NotificationEventMonitorAlert    = "monitor_alert"
+	NotificationEventWebsiteDown     = "website_down"
+	NotificationEventWebsiteExpiring = "website_expiring"
+	NotificationEventWebsiteExpired  = "website_expired"
+	NotificationEventTest            = "test"
+)
+
+// NotificationEvents 可订阅的通知事件
+var NotificationEvents = map[string]string{
+	NotificationEventCertExpiring:    "证书即将过期",
+	NotificationEventCertRenewFailed: "证书续签失败",
+	NotificationEventBackupFailed:    "备份失败",
+	NotificationEventDiskFull:        "磁盘空间不足",
+	NotificationEventServiceDown:     "服务停止运行",
+	NotificationEventLoginNewIP:      "新 IP 登录面板",
+	NotificationEventPanelUpdate:     "面板有新版本",
+	NotificationEventCronFailed:      "计划任务运行失败",
+	NotificationEventMonitorAlert:    "监控告警",
+	NotificationEventWebsiteDown:     "网站无法访问",
+	NotificationEventWebsiteExpiring: "网站即将到期",
+	NotificationEventWebsiteExpired:  "网站到期暂停",
+}
+
+type NotificationChannel struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	Name      string          `gorm:"not null;unique" json:"name"`
+	Type      string          `gorm:"not null" json:"type"` // 渠道类型 (email, webhook, telegram, dingtalk, wecom)
+	Config    notify.Config   `gorm:"type:json;serializer:json" json:"config"`
+	Events    []string        `gorm:"type:json;serializer:json" json:"events"` // 订阅的事件
+	Enabled   bool            `gorm:"not null" json:"enabled"`
+	CreatedAt carbon.DateTime `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+	UpdatedAt carbon.DateTime `gorm:"autoUpdateTime;column:updated_at" json:"updated_at"`
+}
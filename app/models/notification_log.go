@@ -0,0 +1,24 @@
+package models
+
+import (
+	"github.com/goravel/framework/support/carbon"
+)
+
+const (
+	NotificationLogStatusSuccess = "success"
+	NotificationLogStatusFailed  = "failed"
+	NotificationLogStatusLimited = "limited" // 被频率限制跳过
+)
+
+type NotificationLog struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	ChannelID uint            `gorm:"not null" json:"channel_id"`
+	Event     string          `gorm:"not null" json:"event"`
+	Subject   string          `gorm:"not null;default:''" json:"subject"` // 通知涉及的对象，用于频率限制去重
+	Title     string          `gorm:"not null" json:"title"`
+	Content   string          `gorm:"not null;default:''" json:"content"`
+	Status    string          `gorm:"not null" json:"status"`
+	Error     string          `gorm:"not null;default:''" json:"error"`
+	CreatedAt carbon.DateTime `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+	UpdatedAt carbon.DateTime `gorm:"autoUpdateTime;column:updated_at" json:"updated_at"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"github.com/goravel/framework/support/carbon"
+)
+
+// PhpFpmStat PHP-FPM 进程池状态采样
+type PhpFpmStat struct {
+	ID                 uint            `gorm:"primaryKey" json:"id"`
+	Php                int             `gorm:"not null" json:"php"`
+	Pool               string          `gorm:"not null" json:"pool"`
+	ActiveProcesses    int             `gorm:"not null" json:"active_processes"`
+	IdleProcesses      int             `gorm:"not null" json:"idle_processes"`
+	TotalProcesses     int             `gorm:"not null" json:"total_processes"`
+	ListenQueue        int             `gorm:"not null" json:"listen_queue"`
+	AcceptedConn       uint64          `gorm:"not null" json:"accepted_conn"`        // 采样间隔内的请求数
+	MaxChildrenReached uint64          `gorm:"not null" json:"max_children_reached"` // 采样间隔内达到进程上限的次数
+	SlowRequests       uint64          `gorm:"not null" json:"slow_requests"`        // 采样间隔内的慢请求数
+	StartTime          int64           `gorm:"not null" json:"-"`                    // 进程池启动时间，用于判断计数器是否因重启清零
+	TotalAccepted      uint64          `gorm:"not null" json:"-"`
+	TotalReached       uint64          `gorm:"not null" json:"-"`
+	TotalSlow          uint64          `gorm:"not null" json:"-"`
+	CreatedAt          carbon.DateTime `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+	UpdatedAt          carbon.DateTime `gorm:"autoUpdateTime;column:updated_at" json:"updated_at"`
+}
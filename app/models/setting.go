@@ -7,6 +7,8 @@ const (
 	SettingKeyVersion           = "version"
 	SettingKeyMonitor           = "monitor"
 	SettingKeyMonitorDays       = "monitor_days"
+	SettingKeyMonitorDays5m     = "monitor_days_5m"
+	SettingKeyMonitorDays1h     = "monitor_days_1h"
 	SettingKeyBackupPath        = "backup_path"
 	SettingKeyWebsitePath       = "website_path"
 	SettingKeyMysqlRootPassword = "mysql_root_password"
@@ -14,6 +16,15 @@ const (
 	SettingKeySshPort           = "ssh_port"
 	SettingKeySshUser           = "ssh_user"
 	SettingKeySshPassword       = "ssh_password"
+
+	SettingKeyNotificationInterval    = "notification_interval"     // 相同通知的最小间隔（分钟）
+	SettingKeyNotificationHourlyLimit = "notification_hourly_limit" // 每个渠道每小时最多发送的通知数
+	SettingKeyNotificationDiskUsage   = "notification_disk_usage"   // 磁盘使用率告警阈值（%）
+	SettingKeyLoginIPs                = "login_ips"                 // 曾经登录过面板的 IP
+	SettingKeyNotifiedVersion         = "notified_version"          // 已通知过的面板新版本
+	SettingKeyMetricsToken            = "metrics_token"             // Prometheus 指标接口令牌，为空时关闭接口
+	SettingKeyGeoIPURL                = "geoip_url"                 // GeoIP 国家数据库的更新地址
+	SettingKeyWebsiteExpiryRemind     = "website_expiry_remind"     // 网站到期前提醒的天数，逗号分隔
 )
 
 type Setting struct {
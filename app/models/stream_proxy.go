@@ -0,0 +1,28 @@
+package models
+
+import (
+	"github.com/goravel/framework/support/carbon"
+
+	"panel/pkg/streamproxy"
+)
+
+// StreamProxy OpenResty 的 TCP/UDP 代理
+type StreamProxy struct {
+	ID                  uint                  `gorm:"primaryKey" json:"id"`
+	Name                string                `gorm:"not null" json:"name"`
+	Port                int                   `gorm:"not null" json:"port"`
+	Protocol            string                `gorm:"not null" json:"protocol"` // tcp 或 udp
+	Backends            []streamproxy.Backend `gorm:"type:json;serializer:json" json:"backends"`
+	Balance             string                `gorm:"not null" json:"balance"` // 为空时轮询，可选 least_conn、hash、random
+	ProxyProtocol       bool                  `gorm:"not null" json:"proxy_protocol"`
+	AcceptProxyProtocol bool                  `gorm:"not null" json:"accept_proxy_protocol"`
+	ConnectTimeout      int                   `gorm:"not null" json:"connect_timeout"`
+	Timeout             int                   `gorm:"not null" json:"timeout"`
+	SSL                 bool                  `gorm:"column:ssl;not null" json:"ssl"`
+	CertID              *uint                 `gorm:"default:null" json:"cert_id"` // 开启 TLS 时使用的面板证书
+	PortOpened          bool                  `gorm:"not null" json:"port_opened"` // 防火墙端口是否由面板放行，删除代理时只关闭面板放行的端口
+	CreatedAt           carbon.DateTime       `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+	UpdatedAt           carbon.DateTime       `gorm:"autoUpdateTime;column:updated_at" json:"updated_at"`
+
+	Cert *Cert `gorm:"foreignKey:CertID" json:"cert"`
+}
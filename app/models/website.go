@@ -5,15 +5,29 @@ import (
 )
 
 type Website struct {
-	ID        uint            `gorm:"primaryKey" json:"id"`
-	Name      string          `gorm:"unique;not null" json:"name"`
-	Status    bool            `gorm:"default:true;not null;index" json:"status"`
-	Path      string          `gorm:"not null" json:"path"`
-	Php       int             `gorm:"default:0;not null;index" json:"php"`
-	Ssl       bool            `gorm:"default:false;not null;index" json:"ssl"`
-	Remark    string          `gorm:"default:''" json:"remark"`
-	CreatedAt carbon.DateTime `gorm:"autoCreateTime;column:created_at" json:"created_at"`
-	UpdatedAt carbon.DateTime `gorm:"autoUpdateTime;column:updated_at" json:"updated_at"`
+	ID          uint               `gorm:"primaryKey" json:"id"`
+	Name        string             `gorm:"unique;not null" json:"name"`
+	Status      bool               `gorm:"default:true;not null;index" json:"status"`
+	Path        string             `gorm:"not null" json:"path"`
+	Php         int                `gorm:"default:0;not null;index" json:"php"`
+	Ssl         bool               `gorm:"default:false;not null;index" json:"ssl"`
+	Remark      string             `gorm:"default:''" json:"remark"`
+	PhpSettings WebsitePhpSettings `gorm:"type:json;serializer:json" json:"php_settings"`
+	ExpiresAt   *carbon.DateTime   `gorm:"default:null" json:"expires_at"`          // 到期时间，为空时永不到期
+	Suspended   bool               `gorm:"default:false;not null" json:"suspended"` // 是否因到期或批量操作暂停
+	CreatedAt   carbon.DateTime    `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+	UpdatedAt   carbon.DateTime    `gorm:"autoUpdateTime;column:updated_at" json:"updated_at"`
 
 	Cert *Cert `gorm:"foreignKey:WebsiteID" json:"cert"`
 }
+
+// WebsitePhpSettings 网站的 PHP 配置，为空的项使用 php.ini 中的配置
+type WebsitePhpSettings struct {
+	UploadMaxFilesize string   `json:"upload_max_filesize"`
+	PostMaxSize       string   `json:"post_max_size"`
+	MaxExecutionTime  int      `json:"max_execution_time"`
+	MemoryLimit       string   `json:"memory_limit"`
+	DisableFunctions  string   `json:"disable_functions"` // 需开启独立进程池
+	DisplayErrors     string   `json:"display_errors"`    // On、Off 或为空
+	Extensions        []string `json:"extensions"`        // 网站依赖的扩展，切换 PHP 版本时检查
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"github.com/goravel/framework/support/carbon"
+)
+
+const (
+	WebsiteCheckStatusUnknown = "unknown"
+	WebsiteCheckStatusUp      = "up"
+	WebsiteCheckStatusDown    = "down"
+)
+
+// WebsiteCheck 网站可用性检测
+type WebsiteCheck struct {
+	ID            uint             `gorm:"primaryKey" json:"id"`
+	WebsiteID     uint             `gorm:"not null" json:"website_id"`
+	URL           string           `gorm:"column:url;not null" json:"url"`
+	Method        string           `gorm:"not null" json:"method"`
+	ExpectStatus  int              `gorm:"not null" json:"expect_status"` // 0 表示 2xx 和 3xx 均视为正常
+	Keyword       string           `gorm:"not null" json:"keyword"`
+	Interval      int              `gorm:"not null" json:"interval"`                           // 检测间隔（秒）
+	Timeout       int              `gorm:"not null" json:"timeout"`                            // 超时时间（秒）
+	TLSWarnDays   int              `gorm:"column:tls_warn_days;not null" json:"tls_warn_days"` // 证书剩余天数少于该值时通知，0 为不检测
+	Insecure      bool             `gorm:"not null" json:"insecure"`                           // 跳过证书校验
+	Enabled       bool             `gorm:"not null" json:"enabled"`
+	Status        string           `gorm:"not null" json:"status"`
+	Failures      int              `gorm:"not null" json:"failures"`      // 连续失败次数
+	ResponseTime  int64            `gorm:"not null" json:"response_time"` // 最近一次响应时间（毫秒）
+	LastError     string           `gorm:"not null" json:"last_error"`
+	CertExpiresAt *carbon.DateTime `gorm:"default:null" json:"cert_expires_at"`
+	CheckedAt     *carbon.DateTime `gorm:"default:null" json:"checked_at"`
+	ChangedAt     *carbon.DateTime `gorm:"default:null" json:"changed_at"` // 状态最近一次变化的时间
+	CreatedAt     carbon.DateTime  `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+	UpdatedAt     carbon.DateTime  `gorm:"autoUpdateTime;column:updated_at" json:"updated_at"`
+
+	Website *Website `gorm:"foreignKey:WebsiteID" json:"website"`
+}
+
+// WebsiteCheckResult 网站可用性检测记录
+type WebsiteCheckResult struct {
+	ID           uint            `gorm:"primaryKey" json:"id"`
+	CheckID      uint            `gorm:"not null" json:"check_id"`
+	Up           bool            `gorm:"not null" json:"up"`
+	StatusCode   int             `gorm:"not null" json:"status_code"`
+	ResponseTime int64           `gorm:"not null" json:"response_time"` // 响应时间（毫秒）
+	Error        string          `gorm:"not null" json:"error"`
+	CreatedAt    carbon.DateTime `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+	UpdatedAt    carbon.DateTime `gorm:"autoUpdateTime;column:updated_at" json:"updated_at"`
+}
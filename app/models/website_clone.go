@@ -0,0 +1,32 @@
+package models
+
+import (
+	"github.com/goravel/framework/support/carbon"
+)
+
+// WebsiteCloneDatabase 预发布环境数据库与生产环境数据库的对应关系
+type WebsiteCloneDatabase struct {
+	Type   string `json:"type"`
+	Source string `json:"source"` // 生产环境数据库
+	Target string `json:"target"` // 预发布环境数据库
+	User   string `json:"user"`   // 预发布环境数据库用户
+}
+
+// WebsiteClone 克隆出的预发布环境网站
+type WebsiteClone struct {
+	ID         uint                   `gorm:"primaryKey" json:"id"`
+	WebsiteID  uint                   `gorm:"not null" json:"website_id"`  // 预发布环境网站
+	SourceID   uint                   `gorm:"not null" json:"source_id"`   // 生产环境网站
+	FromDomain string                 `gorm:"not null" json:"from_domain"` // 生产环境主域名
+	ToDomain   string                 `gorm:"not null" json:"to_domain"`   // 预发布环境主域名
+	ReplaceDB  bool                   `gorm:"not null" json:"replace_db"`  // 是否替换数据库中的域名
+	Databases  []WebsiteCloneDatabase `gorm:"type:json;serializer:json" json:"databases"`
+	TaskID     uint                   `gorm:"not null" json:"task_id"` // 最近一次克隆或上线的任务
+	PromotedAt *carbon.DateTime       `gorm:"default:null" json:"promoted_at"`
+	CreatedAt  carbon.DateTime        `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+	UpdatedAt  carbon.DateTime        `gorm:"autoUpdateTime;column:updated_at" json:"updated_at"`
+
+	Website *Website `gorm:"foreignKey:WebsiteID" json:"website"`
+	Source  *Website `gorm:"foreignKey:SourceID" json:"source"`
+	Task    *Task    `gorm:"foreignKey:TaskID" json:"task"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"github.com/goravel/framework/support/carbon"
+)
+
+// WebsiteCountry 网站按国家的访问控制
+type WebsiteCountry struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	WebsiteID uint            `gorm:"not null" json:"website_id"`
+	Mode      string          `gorm:"not null" json:"mode"` // allow 仅允许所列国家访问，deny 禁止所列国家访问
+	Countries []string        `gorm:"type:json;serializer:json" json:"countries"`
+	Status    int             `gorm:"not null" json:"status"` // 拦截时返回的状态码
+	CreatedAt carbon.DateTime `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+	UpdatedAt carbon.DateTime `gorm:"autoUpdateTime;column:updated_at" json:"updated_at"`
+
+	Website *Website `gorm:"foreignKey:WebsiteID" json:"website"`
+}
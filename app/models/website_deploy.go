@@ -0,0 +1,25 @@
+package models
+
+import (
+	"github.com/goravel/framework/support/carbon"
+)
+
+// WebsiteDeploy 网站的 Git 发布配置
+type WebsiteDeploy struct {
+	ID          uint            `gorm:"primaryKey" json:"id"`
+	WebsiteID   uint            `gorm:"not null" json:"website_id"`
+	Repository  string          `gorm:"not null" json:"repository"`
+	Branch      string          `gorm:"not null" json:"branch"`
+	Root        string          `gorm:"not null" json:"root"` // 相对版本目录的运行目录
+	SharedDirs  []string        `gorm:"type:json;serializer:json" json:"shared_dirs"`
+	SharedFiles []string        `gorm:"type:json;serializer:json" json:"shared_files"`
+	Build       string          `gorm:"not null" json:"build"`
+	PostDeploy  string          `gorm:"not null" json:"post_deploy"`
+	Keep        int             `gorm:"not null" json:"keep"`        // 保留的版本数
+	AutoDeploy  bool            `gorm:"not null" json:"auto_deploy"` // 是否允许通过 Webhook 发布
+	Secret      string          `gorm:"not null" json:"secret"`      // Webhook 密钥
+	CreatedAt   carbon.DateTime `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+	UpdatedAt   carbon.DateTime `gorm:"autoUpdateTime;column:updated_at" json:"updated_at"`
+
+	Website *Website `gorm:"foreignKey:WebsiteID" json:"website"`
+}
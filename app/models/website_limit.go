@@ -0,0 +1,23 @@
+package models
+
+import (
+	"github.com/goravel/framework/support/carbon"
+
+	"panel/pkg/ratelimit"
+)
+
+// WebsiteLimit 网站的请求频率、并发连接和带宽限制
+type WebsiteLimit struct {
+	ID        uint             `gorm:"primaryKey" json:"id"`
+	WebsiteID uint             `gorm:"not null" json:"website_id"`
+	Rules     []ratelimit.Rule `gorm:"type:json;serializer:json" json:"rules"`
+	Status    int              `gorm:"not null" json:"status"`     // 超出限制时的状态码
+	PerIP     int              `gorm:"not null" json:"per_ip"`     // 单 IP 并发连接数
+	PerServer int              `gorm:"not null" json:"per_server"` // 网站总并发连接数
+	Rate      int              `gorm:"not null" json:"rate"`       // 单个连接的带宽，单位 KB/s
+	RateAfter int              `gorm:"not null" json:"rate_after"` // 单个连接传输多少 MB 后开始限速
+	CreatedAt carbon.DateTime  `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+	UpdatedAt carbon.DateTime  `gorm:"autoUpdateTime;column:updated_at" json:"updated_at"`
+
+	Website *Website `gorm:"foreignKey:WebsiteID" json:"website"`
+}
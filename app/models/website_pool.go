@@ -0,0 +1,25 @@
+package models
+
+import (
+	"github.com/goravel/framework/support/carbon"
+)
+
+// WebsitePool 网站独立的 PHP-FPM 进程池
+type WebsitePool struct {
+	ID              uint              `gorm:"primaryKey" json:"id"`
+	WebsiteID       uint              `gorm:"not null" json:"website_id"`
+	User            string            `gorm:"not null" json:"user"` // 进程池的运行用户，同时为网站目录的所有者
+	PM              string            `gorm:"column:pm;not null" json:"pm"`
+	MaxChildren     int               `gorm:"not null" json:"max_children"`
+	StartServers    int               `gorm:"not null" json:"start_servers"`
+	MinSpareServers int               `gorm:"not null" json:"min_spare_servers"`
+	MaxSpareServers int               `gorm:"not null" json:"max_spare_servers"`
+	MaxRequests     int               `gorm:"not null" json:"max_requests"`
+	IdleTimeout     int               `gorm:"not null" json:"idle_timeout"`
+	OpenBasedir     bool              `gorm:"not null" json:"open_basedir"`
+	AdminValues     map[string]string `gorm:"type:json;serializer:json" json:"admin_values"` // 其他 php_admin_value 配置
+	CreatedAt       carbon.DateTime   `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+	UpdatedAt       carbon.DateTime   `gorm:"autoUpdateTime;column:updated_at" json:"updated_at"`
+
+	Website *Website `gorm:"foreignKey:WebsiteID" json:"website"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"github.com/goravel/framework/support/carbon"
+)
+
+const (
+	WebsiteReleaseTriggerManual   = "manual"
+	WebsiteReleaseTriggerWebhook  = "webhook"
+	WebsiteReleaseTriggerRollback = "rollback"
+)
+
+// WebsiteRelease 网站的发布记录
+type WebsiteRelease struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	WebsiteID uint            `gorm:"not null" json:"website_id"`
+	Name      string          `gorm:"not null" json:"name"` // 版本目录名
+	Branch    string          `gorm:"not null" json:"branch"`
+	Commit    string          `gorm:"not null" json:"commit"`
+	Trigger   string          `gorm:"not null" json:"trigger"`
+	TaskID    uint            `gorm:"not null" json:"task_id"` // 回滚记录为 0
+	CreatedAt carbon.DateTime `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+	UpdatedAt carbon.DateTime `gorm:"autoUpdateTime;column:updated_at" json:"updated_at"`
+
+	Task *Task `gorm:"foreignKey:TaskID" json:"task"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"github.com/goravel/framework/support/carbon"
+)
+
+// WebsiteStat 网站每日资源使用统计
+type WebsiteStat struct {
+	ID           uint            `gorm:"primaryKey" json:"id"`
+	WebsiteID    uint            `gorm:"not null" json:"website_id"`
+	Date         string          `gorm:"not null" json:"date"` // 格式为 2006-01-02
+	Requests     uint64          `gorm:"not null" json:"requests"`
+	Bytes        uint64          `gorm:"not null" json:"bytes"` // 响应流量（字节）
+	Status2xx    uint64          `gorm:"column:status_2xx;not null" json:"status_2xx"`
+	Status3xx    uint64          `gorm:"column:status_3xx;not null" json:"status_3xx"`
+	Status4xx    uint64          `gorm:"column:status_4xx;not null" json:"status_4xx"`
+	Status5xx    uint64          `gorm:"column:status_5xx;not null" json:"status_5xx"`
+	PhpProcesses int             `gorm:"not null" json:"php_processes"`          // PHP-FPM 进程池的最大进程数
+	PhpCPU       float64         `gorm:"column:php_cpu;not null" json:"php_cpu"` // PHP-FPM 进程池的平均 CPU 使用率（%）
+	PhpRSS       uint64          `gorm:"column:php_rss;not null" json:"php_rss"` // PHP-FPM 进程池的最大内存占用（字节）
+	PhpSamples   int             `gorm:"not null" json:"-"`
+	DiskUsage    uint64          `gorm:"not null" json:"disk_usage"` // 网站目录占用（字节）
+	CreatedAt    carbon.DateTime `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+	UpdatedAt    carbon.DateTime `gorm:"autoUpdateTime;column:updated_at" json:"updated_at"`
+}
+
+// WebsiteStatCursor 网站访问日志的读取位置
+type WebsiteStatCursor struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	WebsiteID uint            `gorm:"not null" json:"website_id"`
+	Inode     uint64          `gorm:"not null" json:"inode"`
+	Position  int64           `gorm:"not null" json:"position"` // 已读取的字节数
+	CreatedAt carbon.DateTime `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+	UpdatedAt carbon.DateTime `gorm:"autoUpdateTime;column:updated_at" json:"updated_at"`
+}
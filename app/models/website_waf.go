@@ -0,0 +1,18 @@
+package models
+
+import (
+	"github.com/goravel/framework/support/carbon"
+
+	"panel/pkg/waf"
+)
+
+// WebsiteWaf 网站独立的 WAF 规则
+type WebsiteWaf struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	WebsiteID uint            `gorm:"not null" json:"website_id"`
+	Rules     waf.Rules       `gorm:"type:json;serializer:json" json:"rules"`
+	CreatedAt carbon.DateTime `gorm:"autoCreateTime;column:created_at" json:"created_at"`
+	UpdatedAt carbon.DateTime `gorm:"autoUpdateTime;column:updated_at" json:"updated_at"`
+
+	Website *Website `gorm:"foreignKey:WebsiteID" json:"website"`
+}
@@ -0,0 +1,93 @@
+// Package services 监控告警服务
+package services
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/goravel/framework/facades"
+	"github.com/goravel/framework/support/carbon"
+
+	"panel/app/models"
+	"panel/pkg/alert"
+)
+
+type Alert interface {
+	Evaluate(sample alert.Sample) error
+	Silenced(ruleID uint) bool
+}
+
+type AlertImpl struct {
+	notification Notification
+}
+
+func NewAlertImpl() *AlertImpl {
+	return &AlertImpl{
+		notification: NewNotificationImpl(),
+	}
+}
+
+// Evaluate 使用本次采样计算所有启用的告警规则
+func (r *AlertImpl) Evaluate(sample alert.Sample) error {
+	var rules []models.AlertRule
+	if err := facades.Orm().Query().Where("enabled", true).Find(&rules); err != nil {
+		return err
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	now := carbon.Now()
+	for _, rule := range rules {
+		state, transition := alert.Evaluate(rule.Rule(), sample, rule.State, now.ToStdTime())
+		rule.State = state
+		if err := facades.Orm().Query().Save(&rule); err != nil {
+			return err
+		}
+		if transition == alert.TransitionNone || r.Silenced(rule.ID) {
+			continue
+		}
+
+		title, content := r.message(rule, transition)
+		if err := r.notification.Notify(models.NotificationEventMonitorAlert, strconv.FormatUint(uint64(rule.ID), 10), title, content); err != nil {
+			facades.Log().Tags("面板", "监控告警").With(map[string]any{
+				"rule_id": rule.ID,
+				"error":   err.Error(),
+			}).Info("发送告警通知失败")
+		}
+	}
+
+	return nil
+}
+
+// Silenced 规则当前是否处于静默期
+func (r *AlertImpl) Silenced(ruleID uint) bool {
+	now := carbon.Now().ToDateTimeString()
+	var count int64
+	if err := facades.Orm().Query().Model(&models.AlertSilence{}).
+		Where("rule_id = ? OR rule_id = 0", ruleID).
+		Where("start_at <= ?", now).Where("end_at >= ?", now).Count(&count); err != nil {
+		return false
+	}
+
+	return count > 0
+}
+
+// message 生成告警通知内容
+func (r *AlertImpl) message(rule models.AlertRule, transition alert.Transition) (string, string) {
+	metric := alert.Metrics[rule.Metric]
+	if len(rule.Target) > 0 {
+		metric += " " + rule.Target
+	}
+
+	if transition == alert.TransitionRecovered {
+		return "[恢复] " + rule.Name, fmt.Sprintf("告警规则 %s 已恢复\n指标: %s\n当前值: %.2f", rule.Name, metric, rule.State.Value)
+	}
+
+	content := fmt.Sprintf("告警规则 %s 已触发\n指标: %s\n当前值: %.2f\n条件: %s %.2f", rule.Name, metric, rule.State.Value, rule.Operator, rule.Threshold)
+	if rule.Duration > 0 {
+		content += fmt.Sprintf("，持续 %d 分钟", rule.Duration)
+	}
+
+	return "[告警] " + rule.Name, content
+}
@@ -252,6 +252,14 @@ func (s *CertImpl) CertDestroy(ID uint) error {
 		return err
 	}
 
+	var proxies int64
+	if err = facades.Orm().Query().Model(&models.StreamProxy{}).Where("cert_id", ID).Count(&proxies); err != nil {
+		return err
+	}
+	if proxies > 0 {
+		return errors.New("证书正在被 TCP/UDP 代理使用，无法删除")
+	}
+
 	_, err = facades.Orm().Query().Delete(&models.Cert{}, ID)
 	return err
 }
@@ -320,6 +328,9 @@ func (s *CertImpl) ObtainAuto(ID uint) (certificate.Resource, error) {
 			return certificate.Resource{}, err
 		}
 	}
+	if err := NewStreamProxyImpl().RefreshCert(cert.ID); err != nil {
+		return certificate.Resource{}, err
+	}
 
 	return ssl, nil
 }
@@ -380,6 +391,9 @@ func (s *CertImpl) ObtainManual(ID uint) (certificate.Resource, error) {
 			return certificate.Resource{}, err
 		}
 	}
+	if err := NewStreamProxyImpl().RefreshCert(cert.ID); err != nil {
+		return certificate.Resource{}, err
+	}
 
 	return ssl, nil
 }
@@ -487,6 +501,9 @@ func (s *CertImpl) Renew(ID uint) (certificate.Resource, error) {
 			return certificate.Resource{}, err
 		}
 	}
+	if err := NewStreamProxyImpl().RefreshCert(cert.ID); err != nil {
+		return certificate.Resource{}, err
+	}
 
 	return ssl, nil
 }
@@ -1,15 +1,39 @@
+// Package services 计划任务服务
 package services
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
 	"strings"
+	"syscall"
+	"time"
+
+	"github.com/goravel/framework/facades"
+	"github.com/goravel/framework/support/carbon"
 
 	"panel/app/models"
+	"panel/pkg/crontab"
 	"panel/pkg/tools"
 )
 
+const (
+	// cronLegacyMarker 旧版本面板直接写入 crontab 的条目均包含该路径
+	cronLegacyMarker = "/www/server/cron/"
+	// cronOutputLimit 运行记录中保留的输出长度
+	cronOutputLimit = 64 * 1024
+	// cronRunsKeep 每个计划任务保留的运行记录数
+	cronRunsKeep = 200
+)
+
 type Cron interface {
-	AddToSystem(cron models.Cron) error
-	DeleteFromSystem(cron models.Cron) error
+	Sync() error
+	Drift() (crontab.Drift, error)
+	NextRuns(spec string, count int) ([]string, error)
+	Run(id uint) (models.CronRun, error)
 }
 
 type CronImpl struct {
@@ -19,46 +43,263 @@ func NewCronImpl() *CronImpl {
 	return &CronImpl{}
 }
 
-// AddToSystem 添加到系统
-func (r *CronImpl) AddToSystem(cron models.Cron) error {
-	if tools.IsRHEL() {
-		if _, err := tools.Exec("echo \"" + cron.Time + " " + cron.Shell + " >> " + cron.Log + " 2>&1\" >> /var/spool/cron/root"); err != nil {
-			return err
-		}
-		if _, err := tools.Exec("systemctl restart crond"); err != nil {
-			return err
-		}
+// Sync 根据数据库重写系统 crontab 中的面板托管区块
+func (r *CronImpl) Sync() error {
+	entries, err := r.entries()
+	if err != nil {
+		return err
+	}
+
+	raw := r.read()
+	content := crontab.RemoveOutside(raw, cronLegacyMarker)
+	content = crontab.Replace(content, crontab.Render(entries))
+	if content == raw {
+		return nil
+	}
+
+	if err = crontab.WriteFile(r.path(), content); err != nil {
+		return err
+	}
+
+	return tools.ServiceRestart(r.service())
+}
+
+// Drift 检测系统 crontab 与数据库的差异
+func (r *CronImpl) Drift() (crontab.Drift, error) {
+	entries, err := r.entries()
+	if err != nil {
+		return crontab.Drift{}, err
+	}
+
+	return crontab.Diff(r.read(), entries, cronLegacyMarker), nil
+}
+
+// NextRuns 预览接下来的运行时间
+func (r *CronImpl) NextRuns(spec string, count int) ([]string, error) {
+	times, err := crontab.Next(spec, carbon.Now().ToStdTime(), count)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]string, 0, len(times))
+	for _, t := range times {
+		runs = append(runs, carbon.FromStdTime(t).ToDateTimeString())
+	}
+
+	return runs, nil
+}
+
+// Run 执行计划任务并记录运行结果
+func (r *CronImpl) Run(id uint) (models.CronRun, error) {
+	var cron models.Cron
+	if err := facades.Orm().Query().Where("id", id).FirstOrFail(&cron); err != nil {
+		return models.CronRun{}, errors.New("计划任务不存在")
+	}
+
+	// 先写入运行记录占位，进程 ID 在脚本启动前为当前进程，再检查是否有更早的运行，
+	// 同时触发的多次运行中只有记录 ID 最小的一次会继续执行
+	run := models.CronRun{
+		CronID:    cron.ID,
+		Status:    models.CronRunStatusRunning,
+		Pid:       os.Getpid(),
+		StartedAt: carbon.DateTime{Carbon: carbon.Now()},
+	}
+	if err := facades.Orm().Query().Create(&run); err != nil {
+		return run, err
+	}
+	if !cron.Overlap && r.running(cron.ID, run.ID) {
+		run.Status = models.CronRunStatusSkipped
+		run.Pid = 0
+		run.Output = "上一次运行尚未结束，已跳过本次运行"
+		run.EndedAt = &carbon.DateTime{Carbon: carbon.Now()}
+		return run, facades.Orm().Query().Save(&run)
+	}
+
+	log, err := os.OpenFile(cron.Log, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		log = nil
 	} else {
-		if _, err := tools.Exec("echo \"" + cron.Time + " " + cron.Shell + " >> " + cron.Log + " 2>&1\" >> /var/spool/cron/crontabs/root"); err != nil {
-			return err
+		defer log.Close()
+		_, _ = fmt.Fprintf(log, "[%s] 开始运行\n", run.StartedAt.ToDateTimeString())
+	}
+
+	ctx := context.Background()
+	if cron.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(cron.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	output := &cronOutput{limit: cronOutputLimit}
+	var writer io.Writer = output
+	if log != nil {
+		writer = io.MultiWriter(output, log)
+	}
+
+	cmd := exec.CommandContext(ctx, "bash", cron.Shell)
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	// 在独立进程组中运行，超时时连同子进程一起结束
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = 5 * time.Second
+
+	start := time.Now()
+	if err = cmd.Start(); err == nil {
+		run.Pid = cmd.Process.Pid
+		_, _ = facades.Orm().Query().Model(&run).Where("id", run.ID).Update("pid", run.Pid)
+		err = cmd.Wait()
+	}
+
+	exitCode := 0
+	run.Status = models.CronRunStatusSuccess
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		exitCode = -1
+		run.Status = models.CronRunStatusTimeout
+		_, _ = fmt.Fprintf(writer, "运行超时（%d 秒），已终止\n", cron.Timeout)
+	case errors.As(err, &exitErr):
+		exitCode = exitErr.ExitCode()
+		run.Status = models.CronRunStatusFailed
+	case err != nil:
+		exitCode = -1
+		run.Status = models.CronRunStatusFailed
+		_, _ = fmt.Fprintln(writer, err.Error())
+	}
+
+	run.ExitCode = &exitCode
+	run.Output = output.String()
+	run.Duration = time.Since(start).Milliseconds()
+	run.EndedAt = &carbon.DateTime{Carbon: carbon.Now()}
+	if log != nil {
+		_, _ = fmt.Fprintf(log, "[%s] 运行结束，状态: %s，退出码: %d\n", run.EndedAt.ToDateTimeString(), run.Status, exitCode)
+	}
+	if err = facades.Orm().Query().Save(&run); err != nil {
+		return run, err
+	}
+
+	if run.Status != models.CronRunStatusSuccess && cron.Notify {
+		r.notifyFailure(cron, run)
+	}
+	r.prune(cron.ID)
+
+	return run, nil
+}
+
+// running 检查计划任务是否有早于 before 且仍存活的运行，同时修正进程已不存在的运行记录
+func (r *CronImpl) running(id, before uint) bool {
+	var runs []models.CronRun
+	if err := facades.Orm().Query().Where("cron_id", id).Where("status", models.CronRunStatusRunning).Where("id < ?", before).Find(&runs); err != nil {
+		return false
+	}
+
+	alive := false
+	for _, run := range runs {
+		if run.Pid > 0 && tools.Exists(fmt.Sprintf("/proc/%d", run.Pid)) {
+			alive = true
+			continue
 		}
-		if _, err := tools.Exec("systemctl restart cron"); err != nil {
-			return err
+
+		run.Status = models.CronRunStatusFailed
+		run.Output += "\n运行进程已不存在，可能被意外终止"
+		run.EndedAt = &carbon.DateTime{Carbon: carbon.Now()}
+		_ = facades.Orm().Query().Save(&run)
+	}
+
+	return alive
+}
+
+// notifyFailure 计划任务运行失败通知
+func (r *CronImpl) notifyFailure(cron models.Cron, run models.CronRun) {
+	content := fmt.Sprintf("计划任务 %s 运行失败，状态: %s，退出码: %d，耗时: %d 毫秒", cron.Name, run.Status, *run.ExitCode, run.Duration)
+	if len(run.Output) > 0 {
+		output := []rune(run.Output)
+		if len(output) > 500 {
+			output = output[len(output)-500:]
 		}
+		content += "\n\n输出:\n" + string(output)
+	}
+
+	if err := NewNotificationImpl().Notify(models.NotificationEventCronFailed, cron.Name, "计划任务 "+cron.Name+" 运行失败", content); err != nil {
+		facades.Log().Tags("面板", "计划任务").With(map[string]any{
+			"id":    cron.ID,
+			"error": err.Error(),
+		}).Info("发送计划任务失败通知失败")
+	}
+}
+
+// prune 清理过多的运行记录
+func (r *CronImpl) prune(id uint) {
+	var last models.CronRun
+	if err := facades.Orm().Query().Where("cron_id", id).Order("id desc").Offset(cronRunsKeep - 1).First(&last); err != nil || last.ID == 0 {
+		return
 	}
 
-	return nil
+	_, _ = facades.Orm().Query().Where("cron_id", id).Where("id < ?", last.ID).Delete(&models.CronRun{})
 }
 
-// DeleteFromSystem 从系统中删除
-func (r *CronImpl) DeleteFromSystem(cron models.Cron) error {
-	// 需要转义Shell路径的/为\/
-	cron.Shell = strings.ReplaceAll(cron.Shell, "/", "\\/")
+// entries 获取所有启用的计划任务条目
+func (r *CronImpl) entries() ([]crontab.Entry, error) {
+	var crons []models.Cron
+	if err := facades.Orm().Query().Where("status", true).Order("id asc").Find(&crons); err != nil {
+		return nil, err
+	}
+
+	entries := make([]crontab.Entry, 0, len(crons))
+	for _, cron := range crons {
+		entries = append(entries, crontab.Entry{
+			ID:      cron.ID,
+			Name:    cron.Name,
+			Time:    cron.Time,
+			Command: fmt.Sprintf("panel cronRun %d > /dev/null 2>&1", cron.ID),
+		})
+	}
+
+	return entries, nil
+}
+
+// read 读取系统 crontab，文件不存在时视为空
+func (r *CronImpl) read() string {
+	content, _ := tools.Read(r.path())
+	return content
+}
+
+// path 系统 crontab 路径
+func (r *CronImpl) path() string {
 	if tools.IsRHEL() {
-		if _, err := tools.Exec("sed -i '/" + cron.Shell + "/d' /var/spool/cron/root"); err != nil {
-			return err
-		}
-		if _, err := tools.Exec("systemctl restart crond"); err != nil {
-			return err
-		}
-	} else {
-		if _, err := tools.Exec("sed -i '/" + cron.Shell + "/d' /var/spool/cron/crontabs/root"); err != nil {
-			return err
-		}
-		if _, err := tools.Exec("systemctl restart cron"); err != nil {
-			return err
-		}
+		return "/var/spool/cron/root"
 	}
 
-	return nil
+	return "/var/spool/cron/crontabs/root"
+}
+
+// service 系统 cron 服务名
+func (r *CronImpl) service() string {
+	if tools.IsRHEL() {
+		return "crond"
+	}
+
+	return "cron"
+}
+
+// cronOutput 仅保留末尾 limit 字节的输出
+type cronOutput struct {
+	limit int
+	data  []byte
+}
+
+func (o *cronOutput) Write(p []byte) (int, error) {
+	o.data = append(o.data, p...)
+	if len(o.data) > o.limit {
+		o.data = o.data[len(o.data)-o.limit:]
+	}
+
+	return len(p), nil
+}
+
+func (o *cronOutput) String() string {
+	return strings.ToValidUTF8(string(o.data), "")
 }
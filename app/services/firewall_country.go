@@ -0,0 +1,295 @@
+// Package services 防火墙按国家的访问控制服务
+package services
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/goravel/framework/facades"
+
+	"panel/app/models"
+	"panel/pkg/geoip"
+	"panel/pkg/tools"
+)
+
+const (
+	// firewallCountryIpset ufw 下开机恢复国家集合使用的 ipset 文件
+	firewallCountryIpset = "/www/server/geoip/ipset.conf"
+	// firewallCountryUnit ufw 下开机恢复国家集合的服务，需在 ufw 之前启动
+	firewallCountryUnit = "/etc/systemd/system/panel-geoip.service"
+)
+
+type FirewallCountry interface {
+	List() ([]models.FirewallCountry, error)
+	Add(rule models.FirewallCountry) error
+	Delete(id uint) error
+	Apply() error
+}
+
+type FirewallCountryImpl struct {
+	geoip GeoIP
+}
+
+func NewFirewallCountryImpl() *FirewallCountryImpl {
+	return &FirewallCountryImpl{
+		geoip: NewGeoIPImpl(),
+	}
+}
+
+// List 获取防火墙国家规则
+func (r *FirewallCountryImpl) List() ([]models.FirewallCountry, error) {
+	var rules []models.FirewallCountry
+	err := facades.Orm().Query().Order("id asc").Find(&rules)
+
+	return rules, err
+}
+
+// Add 添加防火墙国家规则，应用失败时回滚
+func (r *FirewallCountryImpl) Add(rule models.FirewallCountry) error {
+	if len(rule.Port) == 0 {
+		rule.Protocol = ""
+	}
+	if err := r.convert(rule).Validate(); err != nil {
+		return err
+	}
+	if !tools.Exists(geoipDatabase) {
+		return errors.New("GeoIP 数据库不存在，请先上传或下载")
+	}
+
+	var exists int64
+	if err := facades.Orm().Query().Model(&models.FirewallCountry{}).
+		Where("country", rule.Country).Where("port", rule.Port).Where("protocol", rule.Protocol).
+		Count(&exists); err != nil {
+		return err
+	}
+	if exists > 0 {
+		return errors.New("该国家和端口的规则已存在")
+	}
+
+	if err := facades.Orm().Query().Create(&rule); err != nil {
+		return err
+	}
+	if err := r.Apply(); err != nil {
+		_, _ = facades.Orm().Query().Delete(&rule)
+		_ = r.Apply()
+		return err
+	}
+
+	return nil
+}
+
+// Delete 删除防火墙国家规则
+func (r *FirewallCountryImpl) Delete(id uint) error {
+	var rule models.FirewallCountry
+	if err := facades.Orm().Query().Where("id", id).FirstOrFail(&rule); err != nil {
+		return errors.New("规则不存在")
+	}
+	if _, err := facades.Orm().Query().Delete(&rule); err != nil {
+		return err
+	}
+
+	return r.Apply()
+}
+
+// Apply 按当前规则重建国家集合和防火墙规则，更新数据库后需调用
+func (r *FirewallCountryImpl) Apply() error {
+	rules, err := r.List()
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 && !r.applied() {
+		return nil
+	}
+
+	// 每个国家按 IP 版本生成两个集合
+	sets := make(map[string][]string)
+	var countries []string
+	for _, rule := range rules {
+		if _, ok := sets[rule.Country]; ok {
+			continue
+		}
+		networks, err := r.geoip.Networks([]string{rule.Country})
+		if err != nil {
+			return err
+		}
+		ipv4, ipv6 := geoip.Split(networks)
+		sets[rule.Country] = []string{
+			geoip.Ipset(geoip.SetName(rule.Country, "inet"), "inet", ipv4),
+			geoip.Ipset(geoip.SetName(rule.Country, "inet6"), "inet6", ipv6),
+		}
+		if tools.IsRHEL() {
+			sets[rule.Country] = []string{geoip.FirewalldIpset("inet", ipv4), geoip.FirewalldIpset("inet6", ipv6)}
+		}
+		countries = append(countries, rule.Country)
+	}
+	sort.Strings(countries)
+
+	if tools.IsRHEL() {
+		return r.applyFirewalld(rules, countries, sets)
+	}
+
+	return r.applyUfw(rules, countries, sets)
+}
+
+// applyFirewalld 通过 firewalld 的 ipset 和富规则应用
+func (r *FirewallCountryImpl) applyFirewalld(rules []models.FirewallCountry, countries []string, sets map[string][]string) error {
+	// 先移除旧的富规则，否则无法删除被引用的集合
+	out, err := tools.Exec("firewall-cmd --permanent --list-rich-rules")
+	if err != nil {
+		return errors.New("获取防火墙规则失败: " + err.Error())
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if strings.Contains(line, `ipset="`+geoip.SetPrefix) {
+			if _, err = tools.Exec("firewall-cmd --permanent --remove-rich-rule='" + line + "'"); err != nil {
+				return err
+			}
+		}
+	}
+
+	old, _ := filepath.Glob("/etc/firewalld/ipsets/" + geoip.SetPrefix + "*.xml")
+	for _, file := range old {
+		if err = tools.Remove(file); err != nil {
+			return err
+		}
+	}
+	for _, country := range countries {
+		if err = tools.Write("/etc/firewalld/ipsets/"+geoip.SetName(country, "inet")+".xml", sets[country][0], 0644); err != nil {
+			return err
+		}
+		if err = tools.Write("/etc/firewalld/ipsets/"+geoip.SetName(country, "inet6")+".xml", sets[country][1], 0644); err != nil {
+			return err
+		}
+	}
+	// 重载后 firewalld 才能识别新集合
+	if _, err = tools.Exec("firewall-cmd --reload"); err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		for _, family := range []string{"inet", "inet6"} {
+			if _, err = tools.Exec("firewall-cmd --permanent --add-rich-rule='" + geoip.RichRule(r.convert(rule), family) + "'"); err != nil {
+				return err
+			}
+		}
+	}
+	_, err = tools.Exec("firewall-cmd --reload")
+
+	return err
+}
+
+// applyUfw 通过 ipset 和 ufw 的 before.rules 应用
+func (r *FirewallCountryImpl) applyUfw(rules []models.FirewallCountry, countries []string, sets map[string][]string) error {
+	if len(rules) > 0 {
+		if _, err := tools.Exec("command -v ipset"); err != nil {
+			return errors.New("未安装 ipset，请先执行 apt-get install -y ipset")
+		}
+	}
+
+	var restore strings.Builder
+	for _, country := range countries {
+		restore.WriteString(sets[country][0])
+		restore.WriteString(sets[country][1])
+	}
+	if err := tools.Write(firewallCountryIpset, restore.String(), 0644); err != nil {
+		return err
+	}
+	if len(rules) > 0 {
+		if _, err := tools.Exec("ipset restore -exist -file " + firewallCountryIpset); err != nil {
+			return errors.New("创建国家集合失败: " + err.Error())
+		}
+		if err := r.enableUnit(); err != nil {
+			return err
+		}
+	}
+
+	for file, family := range map[string]string{"/etc/ufw/before.rules": "inet", "/etc/ufw/before6.rules": "inet6"} {
+		var lines []string
+		for _, rule := range rules {
+			lines = append(lines, geoip.IptablesRule(r.convert(rule), family))
+		}
+		raw, err := tools.Read(file)
+		if err != nil {
+			return err
+		}
+		if raw, err = geoip.WriteUfwRules(raw, lines); err != nil {
+			return err
+		}
+		if err = tools.Write(file, raw, 0640); err != nil {
+			return err
+		}
+	}
+	if _, err := tools.Exec("ufw reload"); err != nil {
+		return err
+	}
+
+	// 规则重载后再销毁不再使用的集合
+	out, _ := tools.Exec("ipset list -n")
+	for _, name := range strings.Split(out, "\n") {
+		if !strings.HasPrefix(name, geoip.SetPrefix) {
+			continue
+		}
+		used := false
+		for _, country := range countries {
+			if name == geoip.SetName(country, "inet") || name == geoip.SetName(country, "inet6") {
+				used = true
+			}
+		}
+		if !used {
+			_, _ = tools.Exec("ipset destroy " + name)
+		}
+	}
+	if len(rules) == 0 {
+		_, _ = tools.Exec("systemctl disable panel-geoip")
+		return tools.Remove(firewallCountryUnit)
+	}
+
+	return nil
+}
+
+// enableUnit 写入开机恢复国家集合的服务
+func (r *FirewallCountryImpl) enableUnit() error {
+	if tools.Exists(firewallCountryUnit) {
+		return nil
+	}
+
+	unit := `[Unit]
+Description=Panel GeoIP ipsets
+Before=ufw.service network-pre.target
+Wants=network-pre.target
+
+[Service]
+Type=oneshot
+ExecStart=/usr/sbin/ipset restore -exist -file ` + firewallCountryIpset + `
+RemainAfterExit=yes
+
+[Install]
+WantedBy=multi-user.target
+`
+	if err := tools.Write(firewallCountryUnit, unit, 0644); err != nil {
+		return err
+	}
+	_, err := tools.Exec("systemctl daemon-reload && systemctl enable panel-geoip")
+
+	return err
+}
+
+// applied 系统中是否存在面板创建的国家规则
+func (r *FirewallCountryImpl) applied() bool {
+	if tools.IsRHEL() {
+		old, _ := filepath.Glob("/etc/firewalld/ipsets/" + geoip.SetPrefix + "*.xml")
+		return len(old) > 0
+	}
+
+	return tools.Exists(firewallCountryUnit)
+}
+
+func (r *FirewallCountryImpl) convert(rule models.FirewallCountry) geoip.FirewallRule {
+	return geoip.FirewallRule{
+		Country:  rule.Country,
+		Action:   rule.Action,
+		Port:     rule.Port,
+		Protocol: rule.Protocol,
+	}
+}
@@ -0,0 +1,183 @@
+// Package services GeoIP 国家数据库服务
+package services
+
+import (
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/imroc/req/v3"
+
+	"panel/app/models"
+	"panel/pkg/geoip"
+	"panel/pkg/tools"
+)
+
+// geoipDatabase 本地 GeoIP 国家数据库
+const geoipDatabase = "/www/server/geoip/GeoLite2-Country.mmdb"
+
+// GeoIPInfo 数据库信息
+type GeoIPInfo struct {
+	Exists    bool           `json:"exists"`
+	Size      int64          `json:"size"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	URL       string         `json:"url"` // 更新地址
+	Metadata  geoip.Metadata `json:"metadata"`
+}
+
+type GeoIP interface {
+	Info() (GeoIPInfo, error)
+	Install(file string) error
+	Download(url string) error
+	Networks(countries []string) ([]*net.IPNet, error)
+}
+
+type GeoIPImpl struct {
+	setting Setting
+}
+
+func NewGeoIPImpl() *GeoIPImpl {
+	return &GeoIPImpl{
+		setting: NewSettingImpl(),
+	}
+}
+
+// Info 获取数据库信息
+func (r *GeoIPImpl) Info() (GeoIPInfo, error) {
+	info := GeoIPInfo{URL: r.setting.Get(models.SettingKeyGeoIPURL)}
+	stat, err := os.Stat(geoipDatabase)
+	if os.IsNotExist(err) {
+		return info, nil
+	}
+	if err != nil {
+		return info, err
+	}
+
+	reader, err := geoip.Open(geoipDatabase)
+	if err != nil {
+		return info, err
+	}
+	info.Exists = true
+	info.Size = stat.Size()
+	info.UpdatedAt = stat.ModTime()
+	info.Metadata = reader.Metadata
+
+	return info, nil
+}
+
+// Install 安装数据库文件，支持 mmdb 文件和包含 mmdb 文件的压缩包，安装后按新数据库重新生成网站和防火墙的国家规则
+func (r *GeoIPImpl) Install(file string) error {
+	dir, err := tools.TempDir("geoip")
+	if err != nil {
+		return err
+	}
+	defer tools.Remove(dir)
+
+	database := file
+	if !strings.HasSuffix(file, ".mmdb") {
+		if err = tools.UnArchive(file, dir); err != nil {
+			return errors.New("解压数据库失败: " + err.Error())
+		}
+		database = ""
+		_ = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err == nil && !entry.IsDir() && strings.HasSuffix(path, "-Country.mmdb") {
+				database = path
+			}
+			return nil
+		})
+		if len(database) == 0 {
+			return errors.New("压缩包中未找到国家数据库")
+		}
+	}
+
+	reader, err := geoip.Open(database)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(reader.Metadata.DatabaseType, "Country") {
+		return errors.New("数据库类型 " + reader.Metadata.DatabaseType + " 不是国家数据库")
+	}
+
+	if err = tools.Mkdir(filepath.Dir(geoipDatabase), 0755); err != nil {
+		return err
+	}
+	if err = tools.Cp(database, geoipDatabase+".tmp"); err != nil {
+		return err
+	}
+	if err = os.Rename(geoipDatabase+".tmp", geoipDatabase); err != nil {
+		return err
+	}
+
+	if err = NewWebsiteCountryImpl().ApplyAll(); err != nil {
+		return err
+	}
+
+	return NewFirewallCountryImpl().Apply()
+}
+
+// Download 从地址下载并安装数据库，地址可为 MaxMind 带授权码的下载地址
+func (r *GeoIPImpl) Download(url string) error {
+	dir, err := tools.TempDir("geoip-download")
+	if err != nil {
+		return err
+	}
+	defer tools.Remove(dir)
+
+	file := filepath.Join(dir, "database")
+	client := req.C().SetTimeout(10 * time.Minute)
+	resp, err := client.R().SetOutputFile(file).Get(url)
+	if err != nil {
+		return err
+	}
+	if !resp.IsSuccessState() {
+		return errors.New("下载数据库失败: " + resp.Status)
+	}
+
+	// 根据文件头判断格式，下载地址通常不带扩展名
+	head := make([]byte, 2)
+	if f, err := os.Open(file); err == nil {
+		_, _ = f.Read(head)
+		_ = f.Close()
+	}
+	name := file + ".mmdb"
+	if head[0] == 0x1f && head[1] == 0x8b {
+		name = file + ".tar.gz"
+	} else if head[0] == 'P' && head[1] == 'K' {
+		name = file + ".zip"
+	}
+	if err = os.Rename(file, name); err != nil {
+		return err
+	}
+
+	if err = r.Install(name); err != nil {
+		return err
+	}
+
+	return r.setting.Set(models.SettingKeyGeoIPURL, url)
+}
+
+// Networks 获取国家的全部网段
+func (r *GeoIPImpl) Networks(countries []string) ([]*net.IPNet, error) {
+	if !tools.Exists(geoipDatabase) {
+		return nil, errors.New("GeoIP 数据库不存在，请先上传或下载")
+	}
+	reader, err := geoip.Open(geoipDatabase)
+	if err != nil {
+		return nil, err
+	}
+
+	var networks []*net.IPNet
+	for _, country := range countries {
+		items, err := reader.Networks(country)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, items...)
+	}
+
+	return networks, nil
+}
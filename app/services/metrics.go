@@ -0,0 +1,213 @@
+// Package services Prometheus 指标服务
+package services
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goravel/framework/facades"
+	"github.com/goravel/framework/support/carbon"
+
+	"panel/app/models"
+	"panel/pkg/metrics"
+	"panel/pkg/tools"
+)
+
+// requestDuration 面板 API 请求耗时，进程内累计
+var requestDuration = metrics.NewHistogram("panel_http_request_duration_seconds", "面板 API 请求耗时（秒）", metrics.DefaultBuckets)
+
+type Metrics interface {
+	Render() string
+	ObserveRequest(method, path string, status int, duration time.Duration)
+}
+
+type MetricsImpl struct {
+	setting Setting
+	plugin  Plugin
+}
+
+func NewMetricsImpl() *MetricsImpl {
+	return &MetricsImpl{
+		setting: NewSettingImpl(),
+		plugin:  NewPluginImpl(),
+	}
+}
+
+// Render 生成 Prometheus 文本格式的全部指标
+func (r *MetricsImpl) Render() string {
+	w := metrics.NewWriter()
+	r.host(w)
+	r.websites(w)
+	r.tasks(w)
+	r.certs(w)
+	r.backups(w)
+	r.services(w)
+	requestDuration.WriteTo(w)
+
+	return w.String()
+}
+
+// ObserveRequest 记录一次 API 请求耗时，path 为匹配到的路由模板
+func (r *MetricsImpl) ObserveRequest(method, path string, status int, duration time.Duration) {
+	requestDuration.Observe(metrics.Labels{
+		"method": method,
+		"path":   path,
+		"status": strconv.Itoa(status),
+	}, duration.Seconds())
+}
+
+// host 主机指标
+func (r *MetricsImpl) host(w *metrics.Writer) {
+	info := tools.GetMonitoringInfo()
+
+	if len(info.Percent) > 0 {
+		w.Gauge("panel_host_cpu_usage_percent", "CPU 使用率（%）", info.Percent[0], nil)
+	}
+	if info.Load != nil {
+		w.Gauge("panel_host_load1", "1 分钟负载", info.Load.Load1, nil)
+		w.Gauge("panel_host_load5", "5 分钟负载", info.Load.Load5, nil)
+		w.Gauge("panel_host_load15", "15 分钟负载", info.Load.Load15, nil)
+	}
+	if info.Host != nil {
+		w.Gauge("panel_host_uptime_seconds", "系统运行时间（秒）", float64(info.Host.Uptime), nil)
+	}
+	if info.Mem != nil {
+		w.Gauge("panel_host_memory_total_bytes", "内存总量（字节）", float64(info.Mem.Total), nil)
+		w.Gauge("panel_host_memory_used_bytes", "已用内存（字节）", float64(info.Mem.Used), nil)
+		w.Gauge("panel_host_memory_available_bytes", "可用内存（字节）", float64(info.Mem.Available), nil)
+	}
+	if info.Swap != nil {
+		w.Gauge("panel_host_swap_total_bytes", "Swap 总量（字节）", float64(info.Swap.Total), nil)
+		w.Gauge("panel_host_swap_used_bytes", "已用 Swap（字节）", float64(info.Swap.Used), nil)
+	}
+	for mount, usage := range info.DiskUsage {
+		if usage == nil {
+			continue
+		}
+		w.Gauge("panel_host_disk_total_bytes", "磁盘总量（字节）", float64(usage.Total), metrics.Labels{"mount": mount})
+		w.Gauge("panel_host_disk_used_bytes", "已用磁盘（字节）", float64(usage.Used), metrics.Labels{"mount": mount})
+	}
+	for _, io := range info.Net {
+		w.Counter("panel_host_network_receive_bytes_total", "网卡接收字节数", float64(io.BytesRecv), metrics.Labels{"device": io.Name})
+		w.Counter("panel_host_network_transmit_bytes_total", "网卡发送字节数", float64(io.BytesSent), metrics.Labels{"device": io.Name})
+	}
+	for device, io := range info.DiskIO {
+		w.Counter("panel_host_disk_read_bytes_total", "磁盘读取字节数", float64(io.ReadBytes), metrics.Labels{"device": device})
+		w.Counter("panel_host_disk_written_bytes_total", "磁盘写入字节数", float64(io.WriteBytes), metrics.Labels{"device": device})
+	}
+}
+
+// websites 网站数量
+func (r *MetricsImpl) websites(w *metrics.Writer) {
+	for _, status := range []bool{true, false} {
+		var count int64
+		if err := facades.Orm().Query().Model(&models.Website{}).Where("status", status).Count(&count); err != nil {
+			continue
+		}
+
+		label := "running"
+		if !status {
+			label = "stopped"
+		}
+		w.Gauge("panel_websites", "网站数量", float64(count), metrics.Labels{"status": label})
+	}
+}
+
+// tasks 任务队列
+func (r *MetricsImpl) tasks(w *metrics.Writer) {
+	for _, status := range []string{models.TaskStatusWaiting, models.TaskStatusRunning, models.TaskStatusSuccess, models.TaskStatusFailed} {
+		var count int64
+		if err := facades.Orm().Query().Model(&models.Task{}).Where("status", status).Count(&count); err != nil {
+			continue
+		}
+		w.Gauge("panel_tasks", "各状态的任务数量", float64(count), metrics.Labels{"status": status})
+	}
+}
+
+// certs 证书剩余有效天数
+func (r *MetricsImpl) certs(w *metrics.Writer) {
+	var certs []models.Cert
+	if err := facades.Orm().Query().Find(&certs); err != nil {
+		return
+	}
+
+	now := carbon.Now().ToStdTime()
+	for _, cert := range certs {
+		block, _ := pem.Decode([]byte(cert.Cert))
+		if block == nil {
+			continue
+		}
+		data, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+
+		w.Gauge("panel_cert_expiry_days", "证书剩余有效天数", data.NotAfter.Sub(now).Hours()/24, metrics.Labels{
+			"id":      strconv.Itoa(int(cert.ID)),
+			"domains": strings.Join(cert.Domains, ","),
+		})
+	}
+}
+
+// backups 各类备份最近一次成功的时间
+func (r *MetricsImpl) backups(w *metrics.Writer) {
+	backupPath := r.setting.Get(models.SettingKeyBackupPath)
+	if len(backupPath) == 0 {
+		return
+	}
+
+	for _, kind := range []string{"website", "mysql", "postgresql"} {
+		entries, err := os.ReadDir(filepath.Join(backupPath, kind))
+		if err != nil {
+			continue
+		}
+
+		var latest time.Time
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			if info.ModTime().After(latest) {
+				latest = info.ModTime()
+			}
+		}
+		if latest.IsZero() {
+			continue
+		}
+
+		w.Gauge("panel_backup_last_success_timestamp_seconds", "最近一次备份成功的时间戳", float64(latest.Unix()), metrics.Labels{"type": kind})
+	}
+}
+
+// services 已安装插件的服务状态
+func (r *MetricsImpl) services(w *metrics.Writer) {
+	plugins, err := r.plugin.AllInstalled()
+	if err != nil {
+		return
+	}
+
+	for _, installed := range plugins {
+		service := r.plugin.ServiceName(installed.Slug)
+		if len(service) == 0 {
+			continue
+		}
+
+		up := 0.0
+		if status, err := tools.ServiceStatus(service); err == nil && status {
+			up = 1
+		}
+		w.Gauge("panel_service_up", "插件服务是否运行", up, metrics.Labels{
+			"plugin":  installed.Slug,
+			"service": service,
+		})
+	}
+}
@@ -0,0 +1,313 @@
+// Package services 资源监控服务
+package services
+
+import (
+	"time"
+
+	"github.com/goravel/framework/facades"
+	"github.com/goravel/framework/support/carbon"
+	"github.com/spf13/cast"
+
+	"panel/app/models"
+	"panel/pkg/alert"
+	"panel/pkg/monitor"
+	"panel/pkg/tools"
+)
+
+// monitorCounters 用于计算速率的累计计数指标
+var monitorCounters = []string{monitor.MetricNetSent, monitor.MetricNetRecv, monitor.MetricDiskReadAll, monitor.MetricDiskWriteAll}
+
+type Monitor interface {
+	Record(info tools.MonitoringInfo, save bool) (alert.Sample, error)
+	Rollup() error
+	Prune() error
+	Clear() error
+	Query(metrics []string, start, end, step int64) (map[string][]monitor.Point, int64, error)
+	Processes(at int64) ([]models.MonitorProcess, error)
+	Retention(resolution int64) int
+}
+
+type MonitorImpl struct {
+	setting Setting
+	process Process
+}
+
+func NewMonitorImpl() *MonitorImpl {
+	return &MonitorImpl{
+		setting: NewSettingImpl(),
+		process: NewProcessImpl(),
+	}
+}
+
+// Record 生成本次采样，save 为 true 时同时采样服务进程并保存为分钟粒度数据
+func (r *MonitorImpl) Record(info tools.MonitoringInfo, save bool) (alert.Sample, error) {
+	now := carbon.Now().Timestamp()
+	bucket := monitor.Bucket(now, monitor.ResolutionMinute)
+
+	// 使用上一分钟的累计计数计算速率
+	var prev []models.MonitorPoint
+	if err := facades.Orm().Query().Where("resolution", monitor.ResolutionMinute).
+		Where("time", bucket-monitor.ResolutionMinute).Where("metric IN ?", monitorCounters).Find(&prev); err != nil {
+		return nil, err
+	}
+	var prevSample alert.Sample
+	if len(prev) > 0 {
+		prevSample = alert.Sample{}
+		for _, point := range prev {
+			prevSample[point.Metric] = point.Avg
+		}
+	}
+
+	sample := monitor.Collect(info, prevSample, monitor.ResolutionMinute)
+	if !save {
+		return sample, nil
+	}
+
+	report, err := r.process.Collect(time.Second)
+	if err != nil {
+		facades.Log().Tags("面板", "资源监控").With(map[string]any{
+			"error": err.Error(),
+		}).Info("采样进程资源失败")
+	}
+	monitor.AddServices(sample, report.Services)
+
+	// 同一分钟内重复采样时覆盖旧数据
+	if _, err = facades.Orm().Query().Where("resolution", monitor.ResolutionMinute).Where("time", bucket).Delete(&models.MonitorPoint{}); err != nil {
+		return sample, err
+	}
+	if err = r.save(monitor.Points(sample, now, monitor.ResolutionMinute), monitor.ResolutionMinute); err != nil {
+		return sample, err
+	}
+
+	if _, err = facades.Orm().Query().Where("time", bucket).Delete(&models.MonitorProcess{}); err != nil {
+		return sample, err
+	}
+	var processes []models.MonitorProcess
+	for _, usage := range report.Top {
+		processes = append(processes, models.MonitorProcess{
+			Time:      bucket,
+			PID:       usage.PID,
+			Name:      usage.Name,
+			Cmdline:   usage.Cmdline,
+			CPU:       usage.CPU,
+			RSS:       usage.RSS,
+			OpenFiles: usage.OpenFiles,
+			Threads:   usage.Threads,
+			ReadRate:  usage.ReadRate,
+			WriteRate: usage.WriteRate,
+		})
+	}
+	if len(processes) > 0 {
+		if err = facades.Orm().Query().Create(&processes); err != nil {
+			return sample, err
+		}
+	}
+
+	return sample, nil
+}
+
+// Rollup 将已结束的时间桶聚合到更粗的粒度
+func (r *MonitorImpl) Rollup() error {
+	now := carbon.Now().Timestamp()
+	for i := 1; i < len(monitor.Resolutions); i++ {
+		source, target := monitor.Resolutions[i-1], monitor.Resolutions[i]
+
+		// 从上一次聚合的下一个时间桶开始
+		var from int64
+		var last models.MonitorPoint
+		if err := facades.Orm().Query().Where("resolution", target).Order("time desc").First(&last); err == nil && last.ID > 0 {
+			from = last.Time + target
+		} else {
+			var first models.MonitorPoint
+			if err = facades.Orm().Query().Where("resolution", source).Order("time asc").First(&first); err != nil || first.ID == 0 {
+				continue
+			}
+			from = monitor.Bucket(first.Time, target)
+		}
+		to := monitor.Bucket(now, target)
+		if from >= to {
+			continue
+		}
+
+		var sources []models.MonitorPoint
+		if err := facades.Orm().Query().Where("resolution", source).
+			Where("time >= ?", from).Where("time < ?", to).Find(&sources); err != nil {
+			return err
+		}
+		if err := r.save(monitor.Rollup(r.points(sources), target), target); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Prune 按各粒度的保留天数删除过期数据
+func (r *MonitorImpl) Prune() error {
+	now := carbon.Now().Timestamp()
+	for _, resolution := range monitor.Resolutions {
+		days := r.Retention(resolution)
+		if days <= 0 {
+			continue
+		}
+		if _, err := facades.Orm().Query().Where("resolution", resolution).
+			Where("time < ?", now-int64(days)*86400).Delete(&models.MonitorPoint{}); err != nil {
+			return err
+		}
+		// 进程数据与分钟粒度数据保留相同天数
+		if resolution == monitor.ResolutionMinute {
+			if _, err := facades.Orm().Query().Where("time < ?", now-int64(days)*86400).Delete(&models.MonitorProcess{}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Clear 清空监控数据
+func (r *MonitorImpl) Clear() error {
+	if _, err := facades.Orm().Query().Where("1 = 1").Delete(&models.MonitorPoint{}); err != nil {
+		return err
+	}
+
+	_, err := facades.Orm().Query().Where("1 = 1").Delete(&models.MonitorProcess{})
+	return err
+}
+
+// Query 查询时间范围内的指标，step 为 0 时自动选择步长，返回数据和实际步长
+func (r *MonitorImpl) Query(metrics []string, start, end, step int64) (map[string][]monitor.Point, int64, error) {
+	if step <= 0 {
+		step = monitor.Step(start, end, 720)
+	}
+	resolution := r.resolution(start, step)
+	if step < resolution {
+		step = resolution
+	}
+
+	query := facades.Orm().Query().Where("resolution", resolution).
+		Where("time >= ?", monitor.Bucket(start, resolution)).Where("time <= ?", end)
+	if len(metrics) > 0 {
+		query = query.Where("metric IN ?", metrics)
+	}
+	var points []models.MonitorPoint
+	if err := query.Order("time asc").Find(&points); err != nil {
+		return nil, step, err
+	}
+
+	result := make(map[string][]monitor.Point)
+	for _, point := range monitor.Rollup(r.points(points), step) {
+		result[point.Metric] = append(result[point.Metric], point)
+	}
+
+	return result, step, nil
+}
+
+// Processes 获取不晚于指定时间的最近一次进程采样，at 为 0 时获取最新一次
+func (r *MonitorImpl) Processes(at int64) ([]models.MonitorProcess, error) {
+	query := facades.Orm().Query()
+	if at > 0 {
+		query = query.Where("time <= ?", at)
+	}
+
+	var latest models.MonitorProcess
+	if err := query.Order("time desc").First(&latest); err != nil {
+		return nil, err
+	}
+	if latest.ID == 0 {
+		return []models.MonitorProcess{}, nil
+	}
+
+	var processes []models.MonitorProcess
+	if err := facades.Orm().Query().Where("time", latest.Time).Order("cpu desc").Find(&processes); err != nil {
+		return nil, err
+	}
+
+	return processes, nil
+}
+
+// Retention 获取粒度的保留天数，0 为永久保留
+func (r *MonitorImpl) Retention(resolution int64) int {
+	switch resolution {
+	case monitor.ResolutionMinute:
+		return cast.ToInt(r.setting.Get(models.SettingKeyMonitorDays, "7"))
+	case monitor.ResolutionFiveMinutes:
+		return cast.ToInt(r.setting.Get(models.SettingKeyMonitorDays5m, "30"))
+	case monitor.ResolutionHour:
+		return cast.ToInt(r.setting.Get(models.SettingKeyMonitorDays1h, "365"))
+	}
+
+	return 0
+}
+
+// resolution 选择不大于步长且保留期覆盖开始时间的最细粒度，均不覆盖时使用保留期最长的粒度
+func (r *MonitorImpl) resolution(start, step int64) int64 {
+	now := carbon.Now().Timestamp()
+	fallback := int64(monitor.ResolutionMinute)
+	longest := -1
+	for _, resolution := range monitor.Resolutions {
+		if resolution > step && resolution != monitor.ResolutionMinute {
+			break
+		}
+
+		days := r.Retention(resolution)
+		if days <= 0 || start >= now-int64(days)*86400 {
+			return resolution
+		}
+		if days > longest {
+			longest = days
+			fallback = resolution
+		}
+	}
+
+	return fallback
+}
+
+func (r *MonitorImpl) save(points []monitor.Point, resolution int64) error {
+	if len(points) == 0 {
+		return nil
+	}
+
+	rows := make([]models.MonitorPoint, 0, len(points))
+	for _, point := range points {
+		rows = append(rows, models.MonitorPoint{
+			Resolution: resolution,
+			Metric:     point.Metric,
+			Time:       point.Time,
+			Min:        point.Min,
+			Avg:        point.Avg,
+			Max:        point.Max,
+			Count:      point.Count,
+		})
+	}
+
+	// 分批写入，避免超出 SQLite 的参数数量限制
+	for i := 0; i < len(rows); i += 500 {
+		end := i + 500
+		if end > len(rows) {
+			end = len(rows)
+		}
+		batch := rows[i:end]
+		if err := facades.Orm().Query().Create(&batch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *MonitorImpl) points(rows []models.MonitorPoint) []monitor.Point {
+	points := make([]monitor.Point, 0, len(rows))
+	for _, row := range rows {
+		points = append(points, monitor.Point{
+			Metric: row.Metric,
+			Time:   row.Time,
+			Min:    row.Min,
+			Avg:    row.Avg,
+			Max:    row.Max,
+			Count:  row.Count,
+		})
+	}
+
+	return points
+}
@@ -0,0 +1,147 @@
+// Package services 消息通知服务
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/goravel/framework/facades"
+	"github.com/goravel/framework/support/carbon"
+	"github.com/spf13/cast"
+
+	"panel/app/models"
+	"panel/pkg/notify"
+)
+
+// notificationLogDays 通知记录保留天数
+const notificationLogDays = 30
+
+type Notification interface {
+	Notify(event, subject, title, content string) error
+	Send(channel models.NotificationChannel, event, title, content string) error
+}
+
+type NotificationImpl struct {
+	setting Setting
+}
+
+func NewNotificationImpl() *NotificationImpl {
+	return &NotificationImpl{
+		setting: NewSettingImpl(),
+	}
+}
+
+// Notify 向订阅了该事件的所有渠道发送通知，subject 为通知涉及的对象（如网站名、域名、IP），
+// 频率限制按事件、对象和标题去重，不同对象的同类通知互不影响
+func (r *NotificationImpl) Notify(event, subject, title, content string) error {
+	var channels []models.NotificationChannel
+	if err := facades.Orm().Query().Where("enabled", true).Find(&channels); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, channel := range channels {
+		if !r.subscribed(channel, event) {
+			continue
+		}
+		if reason := r.limited(channel.ID, event, subject, title); len(reason) > 0 {
+			r.log(channel.ID, event, subject, title, content, models.NotificationLogStatusLimited, reason)
+			continue
+		}
+		if err := r.send(channel, event, subject, title, content); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", channel.Name, err))
+		}
+	}
+
+	_, _ = facades.Orm().Query().Where("created_at < ?", carbon.Now().SubDays(notificationLogDays).ToDateTimeString()).Delete(&models.NotificationLog{})
+
+	return errors.Join(errs...)
+}
+
+// Send 通过指定渠道发送通知并记录结果
+func (r *NotificationImpl) Send(channel models.NotificationChannel, event, title, content string) error {
+	return r.send(channel, event, "", title, content)
+}
+
+// send 通过指定渠道发送通知并记录结果
+func (r *NotificationImpl) send(channel models.NotificationChannel, event, subject, title, content string) error {
+	sender, err := notify.New(channel.Type, channel.Config)
+	if err == nil {
+		err = sender.Send(notify.Message{
+			Event:   event,
+			Title:   title,
+			Content: content,
+			Time:    carbon.Now().ToStdTime(),
+		})
+	}
+
+	if err != nil {
+		r.log(channel.ID, event, subject, title, content, models.NotificationLogStatusFailed, err.Error())
+		facades.Log().Tags("面板", "消息通知").With(map[string]any{
+			"channel_id": channel.ID,
+			"event":      event,
+			"error":      err.Error(),
+		}).Info("发送通知失败")
+		return err
+	}
+
+	r.log(channel.ID, event, subject, title, content, models.NotificationLogStatusSuccess, "")
+	return nil
+}
+
+// subscribed 渠道是否订阅了该事件
+func (r *NotificationImpl) subscribed(channel models.NotificationChannel, event string) bool {
+	for _, item := range channel.Events {
+		if item == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// limited 检查是否触发频率限制，返回限制原因
+func (r *NotificationImpl) limited(channelID uint, event, subject, title string) string {
+	interval := cast.ToInt(r.setting.Get(models.SettingKeyNotificationInterval, "60"))
+	if interval > 0 {
+		var count int64
+		_ = facades.Orm().Query().Model(&models.NotificationLog{}).
+			Where("channel_id", channelID).Where("event", event).Where("subject", subject).Where("title", title).
+			Where("status", models.NotificationLogStatusSuccess).
+			Where("created_at > ?", carbon.Now().SubMinutes(interval).ToDateTimeString()).Count(&count)
+		if count > 0 {
+			return fmt.Sprintf("%d 分钟内已发送过相同通知", interval)
+		}
+	}
+
+	limit := cast.ToInt(r.setting.Get(models.SettingKeyNotificationHourlyLimit, "20"))
+	if limit > 0 {
+		var count int64
+		_ = facades.Orm().Query().Model(&models.NotificationLog{}).
+			Where("channel_id", channelID).
+			Where("status", models.NotificationLogStatusSuccess).
+			Where("created_at > ?", carbon.Now().SubHour().ToDateTimeString()).Count(&count)
+		if count >= int64(limit) {
+			return fmt.Sprintf("超过每小时 %d 条的发送限制", limit)
+		}
+	}
+
+	return ""
+}
+
+// log 记录通知发送结果
+func (r *NotificationImpl) log(channelID uint, event, subject, title, content, status, reason string) {
+	if err := facades.Orm().Query().Create(&models.NotificationLog{
+		ChannelID: channelID,
+		Event:     event,
+		Subject:   subject,
+		Title:     title,
+		Content:   content,
+		Status:    status,
+		Error:     reason,
+	}); err != nil {
+		facades.Log().Tags("面板", "消息通知").With(map[string]any{
+			"error": err.Error(),
+		}).Info("保存通知记录失败")
+	}
+}
@@ -0,0 +1,241 @@
+// Package services PHP 扩展管理服务
+package services
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"panel/app/models"
+	"panel/pkg/phpext"
+	"panel/pkg/tools"
+)
+
+// PhpExtensionInfo PHP 扩展及其状态
+type PhpExtensionInfo struct {
+	phpext.Extension
+	Installed bool `json:"installed"` // 已写入 php.ini 或已编译进 PHP
+	Enabled   bool `json:"enabled"`   // php.ini 中未被注释
+	Loaded    bool `json:"loaded"`    // php -m 中已加载
+}
+
+type PhpExtension interface {
+	List(php int) ([]PhpExtensionInfo, error)
+	Install(php int, slug string) (models.Task, error)
+	InstallPecl(php int, extension phpext.Extension) (models.Task, error)
+	Uninstall(php int, slug string) (models.Task, error)
+	Toggle(php int, slug string, enabled bool) error
+}
+
+type PhpExtensionImpl struct {
+	php  WebsitePhp
+	task Task
+}
+
+func NewPhpExtensionImpl() *PhpExtensionImpl {
+	return &PhpExtensionImpl{
+		php:  NewWebsitePhpImpl(),
+		task: NewTaskImpl(),
+	}
+}
+
+// List 获取 PHP 扩展目录及 php.ini 中通过 PECL 安装的其他扩展
+func (r *PhpExtensionImpl) List(php int) ([]PhpExtensionInfo, error) {
+	modules, err := r.php.Modules(php)
+	if err != nil {
+		return nil, err
+	}
+	ini, err := r.ini(php)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := make(map[string]bool, len(modules))
+	for _, module := range modules {
+		loaded[module] = true
+	}
+	entries := make(map[string]phpext.IniExtension)
+	for _, entry := range phpext.Extensions(ini) {
+		entries[entry.Name] = entry
+	}
+
+	var extensions []PhpExtensionInfo
+	for _, extension := range phpext.Catalog(php) {
+		entry, ok := entries[extension.Ini]
+		delete(entries, extension.Ini)
+		extensions = append(extensions, PhpExtensionInfo{
+			Extension: extension,
+			Installed: ok || loaded[extension.Module],
+			Enabled:   entry.Enabled || !ok && loaded[extension.Module],
+			Loaded:    loaded[extension.Module],
+		})
+	}
+	for _, entry := range phpext.Extensions(ini) {
+		if _, ok := entries[entry.Name]; !ok {
+			continue
+		}
+		extensions = append(extensions, PhpExtensionInfo{
+			Extension: phpext.Extension{
+				Name:    entry.Name,
+				Slug:    entry.Name,
+				Module:  strings.ToLower(entry.Name),
+				Ini:     entry.Name,
+				Zend:    entry.Zend,
+				Package: entry.Name,
+			},
+			Installed: true,
+			Enabled:   entry.Enabled,
+			Loaded:    loaded[strings.ToLower(entry.Name)],
+		})
+	}
+
+	return extensions, nil
+}
+
+// Install 安装扩展目录中的扩展
+func (r *PhpExtensionImpl) Install(php int, slug string) (models.Task, error) {
+	extension, ok := phpext.Find(php, slug)
+	if !ok {
+		return models.Task{}, errors.New("扩展不存在")
+	}
+	if len(extension.Script) == 0 {
+		return r.InstallPecl(php, extension)
+	}
+
+	info, err := r.find(php, slug)
+	if err != nil {
+		return models.Task{}, err
+	}
+	if info.Installed {
+		return models.Task{}, errors.New("扩展已安装")
+	}
+
+	version := strconv.Itoa(php)
+	log := r.log(php, extension.Ini)
+	return r.task.Dispatch("安装PHP-"+version+"扩展-"+extension.Name,
+		`bash '/www/panel/scripts/php_extensions/`+extension.Script+`.sh' install `+version+` >> `+log+` 2>&1`, log)
+}
+
+// InstallPecl 通过 PECL 源码编译安装扩展
+func (r *PhpExtensionImpl) InstallPecl(php int, extension phpext.Extension) (models.Task, error) {
+	if len(extension.Ini) == 0 {
+		extension.Ini = strings.ToLower(extension.Package)
+	}
+	if len(extension.Name) == 0 {
+		extension.Name = extension.Package
+	}
+	if err := extension.Validate(); err != nil {
+		return models.Task{}, err
+	}
+
+	ini, err := r.ini(php)
+	if err != nil {
+		return models.Task{}, err
+	}
+	for _, entry := range phpext.Extensions(ini) {
+		if entry.Name == extension.Ini {
+			return models.Task{}, errors.New("扩展已安装")
+		}
+	}
+
+	version := strconv.Itoa(php)
+	zend := "0"
+	if extension.Zend {
+		zend = "1"
+	}
+	log := r.log(php, extension.Ini)
+	shell := `bash '/www/panel/scripts/php_extensions/pecl.sh' install ` + version + ` '` + extension.Package + `' '` +
+		extension.Version + `' '` + extension.Ini + `' ` + zend
+	for _, option := range extension.Configure {
+		shell += ` '` + option + `'`
+	}
+
+	return r.task.Dispatch("安装PHP-"+version+"扩展-"+extension.Name, shell+` >> `+log+` 2>&1`, log)
+}
+
+// Uninstall 卸载扩展，slug 为扩展目录中的标识或 php.ini 中的扩展名
+func (r *PhpExtensionImpl) Uninstall(php int, slug string) (models.Task, error) {
+	info, err := r.find(php, slug)
+	if err != nil {
+		return models.Task{}, err
+	}
+	if !info.Installed {
+		return models.Task{}, errors.New("扩展未安装")
+	}
+	ini, err := r.ini(php)
+	if err != nil {
+		return models.Task{}, err
+	}
+	managed := false
+	for _, entry := range phpext.Extensions(ini) {
+		if entry.Name == info.Ini {
+			managed = true
+		}
+	}
+	if !managed {
+		return models.Task{}, errors.New("扩展为 PHP 内置扩展，无法卸载")
+	}
+
+	version := strconv.Itoa(php)
+	log := r.log(php, info.Ini)
+	shell := `bash '/www/panel/scripts/php_extensions/pecl.sh' uninstall ` + version + ` '` + info.Ini + `'`
+	if len(info.Script) > 0 {
+		shell = `bash '/www/panel/scripts/php_extensions/` + info.Script + `.sh' uninstall ` + version
+	}
+
+	return r.task.Dispatch("卸载PHP-"+version+"扩展-"+info.Name, shell+` >> `+log+` 2>&1`, log)
+}
+
+// Toggle 启用或停用扩展，不删除扩展文件
+func (r *PhpExtensionImpl) Toggle(php int, slug string, enabled bool) error {
+	info, err := r.find(php, slug)
+	if err != nil {
+		return err
+	}
+
+	version := strconv.Itoa(php)
+	ini, err := r.ini(php)
+	if err != nil {
+		return err
+	}
+	ini, found := phpext.Toggle(ini, info.Ini, enabled)
+	if !found {
+		return errors.New("扩展未安装或未由面板管理")
+	}
+	if err = tools.Write("/www/server/php/"+version+"/etc/php.ini", ini, 0644); err != nil {
+		return err
+	}
+
+	return tools.ServiceReload("php-fpm-" + version)
+}
+
+// find 按标识查找扩展及其状态
+func (r *PhpExtensionImpl) find(php int, slug string) (PhpExtensionInfo, error) {
+	extensions, err := r.List(php)
+	if err != nil {
+		return PhpExtensionInfo{}, err
+	}
+	for _, extension := range extensions {
+		if extension.Slug == slug {
+			return extension, nil
+		}
+	}
+
+	return PhpExtensionInfo{}, errors.New("扩展不存在")
+}
+
+// ini 读取 PHP 的 php.ini
+func (r *PhpExtensionImpl) ini(php int) (string, error) {
+	version := strconv.Itoa(php)
+	if !tools.Exists("/www/server/php/" + version + "/etc/php.ini") {
+		return "", errors.New("PHP-" + version + " 未安装")
+	}
+
+	return tools.Read("/www/server/php/" + version + "/etc/php.ini")
+}
+
+// log 扩展编译日志，每个任务独立一份以便在任务中查看
+func (r *PhpExtensionImpl) log(php int, name string) string {
+	return "/tmp/php" + strconv.Itoa(php) + "-" + name + "-" + strconv.FormatInt(time.Now().Unix(), 10) + ".log"
+}
@@ -0,0 +1,304 @@
+// Package services PHP-FPM 进程池状态服务
+package services
+
+import (
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/goravel/framework/facades"
+	"github.com/goravel/framework/support/carbon"
+	"github.com/imroc/req/v3"
+
+	"panel/app/models"
+	"panel/pkg/phpfpm"
+	"panel/pkg/tools"
+)
+
+const (
+	// phpFpmMainPool PHP 默认进程池的名称
+	phpFpmMainPool = "www"
+	// phpFpmStatDays 进程池状态采样保留天数
+	phpFpmStatDays = 7
+	// phpFpmSlowLogLimit 读取慢日志末尾的字节数
+	phpFpmSlowLogLimit = 512 << 10
+)
+
+// phpFpmVersions 面板支持的 PHP 版本
+var phpFpmVersions = []int{74, 80, 81, 82, 83}
+
+// PhpFpmHistory 进程池状态历史
+type PhpFpmHistory struct {
+	MaxChildren int                 `json:"max_children"` // 当前配置的 pm.max_children
+	Stats       []models.PhpFpmStat `json:"stats"`
+}
+
+type PhpFpm interface {
+	Status(php int, pool string) (phpfpm.Status, error)
+	SlowLog(php int, pool string) ([]phpfpm.SlowEntry, error)
+	Collect() error
+	History(php int, pool string, start, end carbon.Carbon) (PhpFpmHistory, error)
+	Prune() error
+}
+
+type PhpFpmImpl struct {
+	pool WebsitePool
+}
+
+func NewPhpFpmImpl() *PhpFpmImpl {
+	return &PhpFpmImpl{
+		pool: NewWebsitePoolImpl(),
+	}
+}
+
+// Status 获取进程池状态及各进程正在处理的请求，pool 为空时为默认进程池
+func (r *PhpFpmImpl) Status(php int, pool string) (phpfpm.Status, error) {
+	now := time.Now()
+	status, err := r.fetch(php, pool)
+	if err != nil {
+		return phpfpm.Status{}, err
+	}
+
+	// 慢日志读取失败不影响状态
+	if entries, err := r.SlowLog(php, pool); err == nil {
+		status.Correlate(entries, now)
+	}
+
+	return status, nil
+}
+
+// SlowLog 获取进程池最近的慢请求记录
+func (r *PhpFpmImpl) SlowLog(php int, pool string) ([]phpfpm.SlowEntry, error) {
+	if _, err := r.statusURL(php, pool); err != nil {
+		return nil, err
+	}
+
+	file := "/www/server/php/" + strconv.Itoa(php) + "/var/log/slow.log"
+	if len(pool) > 0 && pool != phpFpmMainPool {
+		file = "/www/server/php/" + strconv.Itoa(php) + "/var/log/slow-" + pool + ".log"
+	}
+	raw, err := r.tail(file, phpFpmSlowLogLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return phpfpm.ParseSlowLog(raw, time.Local), nil
+}
+
+// Collect 采样所有 PHP 版本的默认进程池和网站独立进程池的状态
+func (r *PhpFpmImpl) Collect() error {
+	for _, php := range phpFpmVersions {
+		if !tools.Exists("/www/server/php/" + strconv.Itoa(php) + "/bin/php") {
+			continue
+		}
+
+		pools := []string{phpFpmMainPool}
+		websitePools, err := r.pool.List(php)
+		if err != nil {
+			return err
+		}
+		for _, pool := range websitePools {
+			pools = append(pools, pool.Website.Name)
+		}
+
+		for _, pool := range pools {
+			status, err := r.fetch(php, pool)
+			if err != nil {
+				facades.Log().Tags("面板", "PHP-FPM").With(map[string]any{
+					"php":   php,
+					"pool":  pool,
+					"error": err.Error(),
+				}).Info("获取进程池状态失败")
+				continue
+			}
+			if err = r.save(php, pool, status); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// History 获取进程池在时间范围内的状态采样
+func (r *PhpFpmImpl) History(php int, pool string, start, end carbon.Carbon) (PhpFpmHistory, error) {
+	if len(pool) == 0 {
+		pool = phpFpmMainPool
+	}
+	if _, err := r.statusURL(php, pool); err != nil {
+		return PhpFpmHistory{}, err
+	}
+
+	var history PhpFpmHistory
+	if err := facades.Orm().Query().Where("php", php).Where("pool", pool).
+		Where("created_at >= ?", start.ToDateTimeString()).Where("created_at <= ?", end.ToDateTimeString()).
+		Order("id asc").Find(&history.Stats); err != nil {
+		return PhpFpmHistory{}, err
+	}
+	history.MaxChildren = r.maxChildren(php, pool)
+
+	return history, nil
+}
+
+// Prune 删除过期的状态采样
+func (r *PhpFpmImpl) Prune() error {
+	_, err := facades.Orm().Query().Where("created_at < ?", carbon.Now().SubDays(phpFpmStatDays).ToDateTimeString()).Delete(&models.PhpFpmStat{})
+	return err
+}
+
+// fetch 请求进程池状态页
+func (r *PhpFpmImpl) fetch(php int, pool string) (phpfpm.Status, error) {
+	url, err := r.statusURL(php, pool)
+	if err != nil {
+		return phpfpm.Status{}, err
+	}
+	if pool != phpFpmMainPool {
+		if err = phpFpmStatusLocation(); err != nil {
+			return phpfpm.Status{}, err
+		}
+	}
+
+	client := req.C().SetTimeout(10 * time.Second)
+	resp, err := client.R().Get(url + "?json&full")
+	if err != nil {
+		return phpfpm.Status{}, err
+	}
+	if !resp.IsSuccessState() {
+		return phpfpm.Status{}, errors.New("PHP-" + strconv.Itoa(php) + " 状态页返回 " + resp.Status)
+	}
+
+	return phpfpm.ParseStatus(resp.Bytes())
+}
+
+// save 保存一次采样，累计计数器转换为与上次采样的差值
+func (r *PhpFpmImpl) save(php int, pool string, status phpfpm.Status) error {
+	var last models.PhpFpmStat
+	if err := facades.Orm().Query().Where("php", php).Where("pool", pool).Order("id desc").First(&last); err != nil {
+		return err
+	}
+
+	stat := models.PhpFpmStat{
+		Php:                php,
+		Pool:               pool,
+		ActiveProcesses:    status.ActiveProcesses,
+		IdleProcesses:      status.IdleProcesses,
+		TotalProcesses:     status.TotalProcesses,
+		ListenQueue:        status.ListenQueue,
+		AcceptedConn:       status.AcceptedConn,
+		MaxChildrenReached: status.MaxChildrenReached,
+		SlowRequests:       status.SlowRequests,
+		StartTime:          status.StartTime.Unix(),
+		TotalAccepted:      status.AcceptedConn,
+		TotalReached:       status.MaxChildrenReached,
+		TotalSlow:          status.SlowRequests,
+	}
+	// 进程池重启后计数器清零，此时直接使用当前值
+	if last.ID > 0 && last.StartTime == stat.StartTime {
+		stat.AcceptedConn = r.delta(status.AcceptedConn, last.TotalAccepted)
+		stat.MaxChildrenReached = r.delta(status.MaxChildrenReached, last.TotalReached)
+		stat.SlowRequests = r.delta(status.SlowRequests, last.TotalSlow)
+	}
+
+	return facades.Orm().Query().Create(&stat)
+}
+
+// statusURL 进程池状态页地址，进程池需为默认进程池或该 PHP 版本下的网站独立进程池
+func (r *PhpFpmImpl) statusURL(php int, pool string) (string, error) {
+	version := strconv.Itoa(php)
+	if !tools.Exists("/www/server/php/" + version + "/bin/php") {
+		return "", errors.New("PHP-" + version + " 未安装")
+	}
+	if len(pool) == 0 || pool == phpFpmMainPool {
+		return "http://127.0.0.1/phpfpm_status/" + version, nil
+	}
+
+	pools, err := r.pool.List(php)
+	if err != nil {
+		return "", err
+	}
+	for _, item := range pools {
+		if item.Website.Name == pool {
+			return "http://127.0.0.1/phpfpm_status/" + version + "/" + pool, nil
+		}
+	}
+
+	return "", errors.New("进程池 " + pool + " 不存在")
+}
+
+// maxChildren 进程池当前配置的最大进程数
+func (r *PhpFpmImpl) maxChildren(php int, pool string) int {
+	file := "/www/server/php/" + strconv.Itoa(php) + "/etc/php-fpm.conf"
+	if pool != phpFpmMainPool {
+		file = "/www/server/php/" + strconv.Itoa(php) + "/etc/php-fpm.d/" + pool + ".conf"
+	}
+	raw, err := tools.Read(file)
+	if err != nil {
+		return 0
+	}
+
+	match := regexp.MustCompile(`(?m)^\s*pm\.max_children\s*=\s*(\d+)`).FindStringSubmatch(raw)
+	if len(match) != 2 {
+		return 0
+	}
+	value, _ := strconv.Atoi(match[1])
+
+	return value
+}
+
+// delta 计数器的增量
+func (r *PhpFpmImpl) delta(current, last uint64) uint64 {
+	if current < last {
+		return current
+	}
+
+	return current - last
+}
+
+// tail 读取文件末尾最多 limit 字节
+func (r *PhpFpmImpl) tail(file string, limit int64) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if info.Size() > limit {
+		if _, err = f.Seek(info.Size()-limit, io.SeekStart); err != nil {
+			return "", err
+		}
+	}
+	raw, err := io.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+
+	return string(raw), nil
+}
+
+// phpFpmStatusLocation 确保 OpenResty 主配置文件中有网站独立进程池的状态页，旧版本安装的 OpenResty 缺少该配置
+func phpFpmStatusLocation() error {
+	conf := "/www/server/openresty/conf/nginx.conf"
+	raw, err := tools.Read(conf)
+	if err != nil {
+		return err
+	}
+	raw, changed, err := phpfpm.StatusLocation(raw)
+	if err != nil || !changed {
+		return err
+	}
+	if err = tools.Write(conf, raw, 0644); err != nil {
+		return err
+	}
+
+	return tools.ServiceReload("openresty")
+}
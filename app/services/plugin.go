@@ -2,6 +2,8 @@
 package services
 
 import (
+	"strings"
+
 	"github.com/goravel/framework/facades"
 
 	"panel/app/models"
@@ -23,6 +25,7 @@ import (
 	"panel/app/plugins/s3fs"
 	"panel/app/plugins/supervisor"
 	"panel/app/plugins/toolbox"
+	"panel/pkg/tools"
 )
 
 // PanelPlugin 插件元数据结构
@@ -43,6 +46,7 @@ type Plugin interface {
 	All() []PanelPlugin
 	GetBySlug(slug string) PanelPlugin
 	GetInstalledBySlug(slug string) models.Plugin
+	ServiceName(slug string) string
 }
 
 type PluginImpl struct {
@@ -288,3 +292,32 @@ func (r *PluginImpl) GetInstalledBySlug(slug string) models.Plugin {
 
 	return plugin
 }
+
+// ServiceName 获取插件对应的系统服务名，无常驻服务的插件返回空
+func (r *PluginImpl) ServiceName(slug string) string {
+	switch slug {
+	case openresty.Slug:
+		return "openresty"
+	case mysql57.Slug, mysql80.Slug:
+		return "mysqld"
+	case php74.Slug, php80.Slug, php81.Slug, php82.Slug, php83.Slug:
+		return "php-fpm-" + strings.TrimPrefix(slug, "php")
+	case postgresql15.Slug, postgresql16.Slug:
+		return "postgresql"
+	case pureftpd.Slug:
+		return "pure-ftpd"
+	case redis.Slug:
+		return "redis"
+	case rsync.Slug:
+		return "rsyncd"
+	case fail2ban.Slug:
+		return "fail2ban"
+	case supervisor.Slug:
+		if tools.IsRHEL() {
+			return "supervisord"
+		}
+		return "supervisor"
+	}
+
+	return ""
+}
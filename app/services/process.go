@@ -0,0 +1,85 @@
+// Package services 进程监控服务
+package services
+
+import (
+	"time"
+
+	"panel/app/plugins/supervisor"
+	"panel/pkg/procstat"
+	"panel/pkg/tools"
+)
+
+// processTopN 每次采样保存的 CPU 和内存占用最高的进程数
+const processTopN = 10
+
+// ProcessReport 一次进程采样的结果
+type ProcessReport struct {
+	Services map[string]procstat.Usage `json:"services"` // 键为服务名，Supervisor 程序为 supervisor:<程序名>
+	Plugins  map[string]string         `json:"plugins"`  // 服务名对应的插件标识
+	Top      []procstat.Usage          `json:"top"`
+}
+
+type Process interface {
+	Collect(interval time.Duration) (ProcessReport, error)
+}
+
+type ProcessImpl struct {
+	plugin Plugin
+}
+
+func NewProcessImpl() *ProcessImpl {
+	return &ProcessImpl{
+		plugin: NewPluginImpl(),
+	}
+}
+
+// Collect 采样已安装插件的服务、Supervisor 程序以及资源占用最高的进程
+func (r *ProcessImpl) Collect(interval time.Duration) (ProcessReport, error) {
+	processes, usages, err := procstat.Sample(interval)
+	if err != nil {
+		return ProcessReport{}, err
+	}
+
+	report := ProcessReport{
+		Services: make(map[string]procstat.Usage),
+		Plugins:  make(map[string]string),
+	}
+	plugins, err := r.plugin.AllInstalled()
+	if err != nil {
+		return report, err
+	}
+	for _, installed := range plugins {
+		service := r.plugin.ServiceName(installed.Slug)
+		if len(service) == 0 {
+			continue
+		}
+
+		report.Services[service] = procstat.Aggregate(service, procstat.Unit(processes, usages, service))
+		report.Plugins[service] = installed.Slug
+
+		if installed.Slug != supervisor.Slug {
+			continue
+		}
+		// 命令在有程序未运行时返回非 0，忽略退出码
+		out, _ := tools.Exec("supervisorctl status || true")
+		for program, pid := range procstat.ParseSupervisor(out) {
+			name := "supervisor:" + program
+			report.Services[name] = procstat.Aggregate(name, procstat.Tree(processes, usages, pid))
+			report.Plugins[name] = installed.Slug
+		}
+	}
+
+	// 合并 CPU 和内存占用最高的进程
+	seen := make(map[int32]bool)
+	for _, by := range []string{"cpu", "rss"} {
+		for _, usage := range procstat.Top(usages, by, processTopN) {
+			if seen[usage.PID] {
+				continue
+			}
+			seen[usage.PID] = true
+			report.Top = append(report.Top, usage)
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,314 @@
+// Package services TCP/UDP 代理服务
+package services
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/goravel/framework/facades"
+
+	"panel/app/models"
+	"panel/pkg/streamproxy"
+	"panel/pkg/tools"
+)
+
+type StreamProxy interface {
+	List(page, limit int) (int64, []models.StreamProxy, error)
+	Get(id uint) (models.StreamProxy, error)
+	Save(proxy models.StreamProxy) (models.StreamProxy, error)
+	Delete(id uint) error
+	RefreshCert(certID uint) error
+}
+
+type StreamProxyImpl struct {
+}
+
+func NewStreamProxyImpl() *StreamProxyImpl {
+	return &StreamProxyImpl{}
+}
+
+// List 获取代理列表
+func (r *StreamProxyImpl) List(page, limit int) (int64, []models.StreamProxy, error) {
+	var proxies []models.StreamProxy
+	var total int64
+	err := facades.Orm().Query().Order("port asc").Paginate(page, limit, &proxies, &total)
+
+	return total, proxies, err
+}
+
+// Get 获取代理
+func (r *StreamProxyImpl) Get(id uint) (models.StreamProxy, error) {
+	var proxy models.StreamProxy
+	if err := facades.Orm().Query().Where("id", id).FirstOrFail(&proxy); err != nil {
+		return proxy, errors.New("代理不存在")
+	}
+
+	return proxy, nil
+}
+
+// Save 添加或更新代理，ID 为 0 时添加，配置检查未通过时回滚
+func (r *StreamProxyImpl) Save(proxy models.StreamProxy) (models.StreamProxy, error) {
+	if err := r.convert(proxy).Validate(); err != nil {
+		return proxy, err
+	}
+	if proxy.SSL {
+		if proxy.CertID == nil {
+			return proxy, errors.New("开启 TLS 时需选择证书")
+		}
+		var cert models.Cert
+		if err := facades.Orm().Query().Where("id", *proxy.CertID).FirstOrFail(&cert); err != nil {
+			return proxy, errors.New("证书不存在")
+		}
+		if len(cert.Cert) == 0 || len(cert.Key) == 0 {
+			return proxy, errors.New("证书尚未签发")
+		}
+	} else {
+		proxy.CertID = nil
+	}
+
+	var old models.StreamProxy
+	if proxy.ID > 0 {
+		var err error
+		if old, err = r.Get(proxy.ID); err != nil {
+			return proxy, err
+		}
+		proxy.CreatedAt = old.CreatedAt
+	}
+	proxy.PortOpened = old.PortOpened && old.Port == proxy.Port && old.Protocol == proxy.Protocol
+
+	var exists int64
+	if err := facades.Orm().Query().Model(&models.StreamProxy{}).Where("port", proxy.Port).
+		Where("protocol", proxy.Protocol).Where("id != ?", proxy.ID).Count(&exists); err != nil {
+		return proxy, err
+	}
+	if exists > 0 {
+		return proxy, errors.New("已存在监听 " + strconv.Itoa(proxy.Port) + "/" + proxy.Protocol + " 的代理")
+	}
+	// 端口未变化时由 OpenResty 自身占用
+	if old.Port != proxy.Port || old.Protocol != proxy.Protocol {
+		if r.listening(proxy.Port, proxy.Protocol) {
+			return proxy, errors.New("端口 " + strconv.Itoa(proxy.Port) + "/" + proxy.Protocol + " 已被占用")
+		}
+	}
+
+	if err := facades.Orm().Query().Save(&proxy); err != nil {
+		return proxy, err
+	}
+	if err := r.write(proxy); err != nil {
+		// 回滚到原配置
+		if old.ID > 0 {
+			_ = facades.Orm().Query().Save(&old)
+			_ = r.write(old)
+		} else {
+			_, _ = facades.Orm().Query().Delete(&proxy)
+			r.remove(proxy)
+		}
+		return proxy, err
+	}
+	if err := tools.ServiceReload("openresty"); err != nil {
+		return proxy, err
+	}
+
+	if old.PortOpened && (old.Port != proxy.Port || old.Protocol != proxy.Protocol) {
+		r.closePort(old.Port, old.Protocol)
+	}
+	if proxy.PortOpened {
+		return proxy, nil
+	}
+
+	opened, err := r.openPort(proxy.Port, proxy.Protocol)
+	if err != nil || !opened {
+		return proxy, err
+	}
+	proxy.PortOpened = true
+
+	return proxy, facades.Orm().Query().Save(&proxy)
+}
+
+// Delete 删除代理并关闭防火墙端口
+func (r *StreamProxyImpl) Delete(id uint) error {
+	proxy, err := r.Get(id)
+	if err != nil {
+		return err
+	}
+
+	r.remove(proxy)
+	if err = tools.ServiceReload("openresty"); err != nil {
+		return err
+	}
+	if _, err = facades.Orm().Query().Delete(&proxy); err != nil {
+		return err
+	}
+	if proxy.PortOpened {
+		r.closePort(proxy.Port, proxy.Protocol)
+	}
+
+	return nil
+}
+
+// RefreshCert 证书签发或续签后更新使用该证书的代理
+func (r *StreamProxyImpl) RefreshCert(certID uint) error {
+	var proxies []models.StreamProxy
+	if err := facades.Orm().Query().Where("cert_id", certID).Where("ssl", true).Find(&proxies); err != nil {
+		return err
+	}
+	if len(proxies) == 0 {
+		return nil
+	}
+
+	for _, proxy := range proxies {
+		if err := r.writeCert(proxy); err != nil {
+			return err
+		}
+	}
+
+	return tools.ServiceReload("openresty")
+}
+
+// write 写入代理配置并检查，检查未通过时恢复原配置文件
+func (r *StreamProxyImpl) write(proxy models.StreamProxy) error {
+	conf := "/www/server/openresty/conf/nginx.conf"
+	raw, err := tools.Read(conf)
+	if err != nil {
+		return err
+	}
+	raw, changed, err := streamproxy.Include(raw)
+	if err != nil {
+		return err
+	}
+	if changed {
+		if err = tools.Write(conf, raw, 0644); err != nil {
+			return err
+		}
+	}
+
+	if proxy.SSL {
+		if err = r.writeCert(proxy); err != nil {
+			return err
+		}
+	}
+
+	file := r.file(proxy)
+	backup := ""
+	if tools.Exists(file) {
+		if backup, err = tools.Read(file); err != nil {
+			return err
+		}
+	}
+	cert, key := r.certFiles(proxy)
+	content := "# " + strings.ReplaceAll(proxy.Name, "\n", " ") + "\n" + streamproxy.Render("stream_"+strconv.Itoa(int(proxy.ID)), r.convert(proxy), cert, key)
+	if err = tools.Write(file, content, 0644); err != nil {
+		return err
+	}
+
+	if _, err = tools.Exec("openresty -t"); err != nil {
+		if len(backup) > 0 {
+			_ = tools.Write(file, backup, 0644)
+		} else {
+			_ = tools.Remove(file)
+		}
+		return errors.New("OpenResty 配置检查未通过: " + err.Error())
+	}
+
+	return nil
+}
+
+// writeCert 写入代理使用的证书
+func (r *StreamProxyImpl) writeCert(proxy models.StreamProxy) error {
+	var cert models.Cert
+	if err := facades.Orm().Query().Where("id", proxy.CertID).FirstOrFail(&cert); err != nil {
+		return errors.New("证书不存在")
+	}
+
+	certFile, keyFile := r.certFiles(proxy)
+	if err := tools.Write(certFile, cert.Cert, 0644); err != nil {
+		return err
+	}
+
+	return tools.Write(keyFile, cert.Key, 0600)
+}
+
+// remove 删除代理的配置和证书文件
+func (r *StreamProxyImpl) remove(proxy models.StreamProxy) {
+	certFile, keyFile := r.certFiles(proxy)
+	_ = tools.Remove(r.file(proxy))
+	_ = tools.Remove(certFile)
+	_ = tools.Remove(keyFile)
+}
+
+// listening 端口是否已被监听
+func (r *StreamProxyImpl) listening(port int, protocol string) bool {
+	flag := "-Hlnt"
+	if protocol == "udp" {
+		flag = "-Hlnu"
+	}
+	out, err := tools.Exec("ss " + flag + " 'sport = :" + strconv.Itoa(port) + "'")
+
+	return err == nil && len(out) > 0
+}
+
+// openPort 防火墙运行时放行端口，返回是否由面板放行，防火墙未运行或端口已放行时为 false
+func (r *StreamProxyImpl) openPort(port int, protocol string) (bool, error) {
+	rule := strconv.Itoa(port) + "/" + protocol
+	if tools.IsRHEL() {
+		if _, err := tools.Exec("firewall-cmd --state"); err != nil {
+			return false, nil
+		}
+		if _, err := tools.Exec("firewall-cmd --query-port=" + rule + " --permanent"); err == nil {
+			return false, nil
+		}
+		if _, err := tools.Exec("firewall-cmd --add-port=" + rule + " --permanent && firewall-cmd --reload"); err != nil {
+			return false, errors.New("防火墙放行端口失败: " + err.Error())
+		}
+		return true, nil
+	}
+
+	if out, err := tools.Exec("ufw status | grep -q 'Status: active' && echo active"); err != nil || out != "active" {
+		return false, nil
+	}
+	if _, err := tools.Exec("ufw status | grep -qE '^" + rule + "\\s+ALLOW'"); err == nil {
+		return false, nil
+	}
+	if _, err := tools.Exec("ufw allow " + rule + " && ufw reload"); err != nil {
+		return false, errors.New("防火墙放行端口失败: " + err.Error())
+	}
+
+	return true, nil
+}
+
+// closePort 防火墙运行时关闭面板放行的端口
+func (r *StreamProxyImpl) closePort(port int, protocol string) {
+	rule := strconv.Itoa(port) + "/" + protocol
+	if tools.IsRHEL() {
+		_, _ = tools.Exec("firewall-cmd --state && firewall-cmd --remove-port=" + rule + " --permanent && firewall-cmd --reload")
+		return
+	}
+
+	_, _ = tools.Exec("ufw status | grep -q 'Status: active' && ufw delete allow " + rule + " && ufw reload")
+}
+
+// file 代理的配置文件
+func (r *StreamProxyImpl) file(proxy models.StreamProxy) string {
+	return streamproxy.Dir + "/" + strconv.Itoa(int(proxy.ID)) + ".conf"
+}
+
+// certFiles 代理的证书和私钥文件
+func (r *StreamProxyImpl) certFiles(proxy models.StreamProxy) (string, string) {
+	prefix := streamproxy.Dir + "/ssl/" + strconv.Itoa(int(proxy.ID))
+	return prefix + ".pem", prefix + ".key"
+}
+
+func (r *StreamProxyImpl) convert(proxy models.StreamProxy) streamproxy.Proxy {
+	return streamproxy.Proxy{
+		Port:                proxy.Port,
+		Protocol:            proxy.Protocol,
+		Backends:            proxy.Backends,
+		Balance:             proxy.Balance,
+		ProxyProtocol:       proxy.ProxyProtocol,
+		AcceptProxyProtocol: proxy.AcceptProxyProtocol,
+		ConnectTimeout:      proxy.ConnectTimeout,
+		Timeout:             proxy.Timeout,
+		SSL:                 proxy.SSL,
+	}
+}
@@ -1,14 +1,18 @@
 package services
 
 import (
+	"errors"
+
 	"github.com/goravel/framework/contracts/queue"
 	"github.com/goravel/framework/facades"
 
 	"panel/app/jobs"
+	"panel/app/models"
 )
 
 type Task interface {
 	Process(taskID uint)
+	Dispatch(name, shell, log string) (models.Task, error)
 }
 
 type TaskImpl struct {
@@ -29,3 +33,28 @@ func (r *TaskImpl) Process(taskID uint) {
 		}
 	}()
 }
+
+// Dispatch 创建并运行任务，同名任务同时只允许一个
+func (r *TaskImpl) Dispatch(name, shell, log string) (models.Task, error) {
+	var count int64
+	if err := facades.Orm().Query().Model(&models.Task{}).Where("name", name).
+		Where("status IN ?", []string{models.TaskStatusWaiting, models.TaskStatusRunning}).Count(&count); err != nil {
+		return models.Task{}, err
+	}
+	if count > 0 {
+		return models.Task{}, errors.New("已有相同的任务正在运行")
+	}
+
+	task := models.Task{
+		Name:   name,
+		Status: models.TaskStatusWaiting,
+		Shell:  shell,
+		Log:    log,
+	}
+	if err := facades.Orm().Query().Create(&task); err != nil {
+		return models.Task{}, err
+	}
+	r.Process(task.ID)
+
+	return task, nil
+}
@@ -2,7 +2,11 @@
 package services
 
 import (
+	"encoding/json"
+	"fmt"
+
 	"github.com/goravel/framework/facades"
+	"github.com/goravel/framework/support/carbon"
 
 	"panel/app/models"
 )
@@ -10,6 +14,7 @@ import (
 type User interface {
 	Create(name, password string) (models.User, error)
 	Update(user models.User) (models.User, error)
+	RecordLogin(user models.User, ip string) error
 }
 
 type UserImpl struct {
@@ -38,3 +43,47 @@ func (r *UserImpl) Update(user models.User) (models.User, error) {
 
 	return user, nil
 }
+
+// loginIPsKeep 记录的登录 IP 数量
+const loginIPsKeep = 50
+
+// RecordLogin 记录登录 IP，从未出现过的 IP 登录时发送通知
+func (r *UserImpl) RecordLogin(user models.User, ip string) error {
+	setting := NewSettingImpl()
+
+	var ips []string
+	_ = json.Unmarshal([]byte(setting.Get(models.SettingKeyLoginIPs, "[]")), &ips)
+	for i, item := range ips {
+		if item == ip {
+			// 移动到末尾，保证最近使用的 IP 不会被淘汰
+			ips = append(append(ips[:i:i], ips[i+1:]...), ip)
+			return r.saveLoginIPs(ips)
+		}
+	}
+
+	// 首次记录时不通知
+	if len(ips) > 0 {
+		if err := NewNotificationImpl().Notify(models.NotificationEventLoginNewIP, ip, "面板新 IP 登录",
+			fmt.Sprintf("用户 %s 于 %s 从新 IP %s 登录面板，如非本人操作请立即修改密码", user.Username, carbon.Now().ToDateTimeString(), ip)); err != nil {
+			facades.Log().Tags("面板", "用户").With(map[string]any{
+				"error": err.Error(),
+			}).Info("发送新 IP 登录通知失败")
+		}
+	}
+
+	ips = append(ips, ip)
+	if len(ips) > loginIPsKeep {
+		ips = ips[len(ips)-loginIPsKeep:]
+	}
+
+	return r.saveLoginIPs(ips)
+}
+
+func (r *UserImpl) saveLoginIPs(ips []string) error {
+	data, err := json.Marshal(ips)
+	if err != nil {
+		return err
+	}
+
+	return NewSettingImpl().Set(models.SettingKeyLoginIPs, string(data))
+}
@@ -6,15 +6,23 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/goravel/framework/facades"
 	"github.com/spf13/cast"
 	requests "panel/app/http/requests/website"
 
 	"panel/app/models"
+	"panel/pkg/geoip"
+	"panel/pkg/phpfpm"
+	"panel/pkg/ratelimit"
+	"panel/pkg/sitebundle"
+	"panel/pkg/siterule"
+	"panel/pkg/tlsprofile"
 	"panel/pkg/tools"
 )
 
@@ -25,6 +33,9 @@ type Website interface {
 	Delete(id uint) error
 	GetConfig(id uint) (WebsiteSetting, error)
 	GetConfigByName(name string) (WebsiteSetting, error)
+	SaveAuthUser(id uint, name, password string) error
+	DeleteAuthUser(id uint, name string) error
+	TlsReport(id uint) (tlsprofile.Report, error)
 }
 
 type PanelWebsite struct {
@@ -45,40 +56,58 @@ type PanelWebsite struct {
 
 // WebsiteSetting 网站设置
 type WebsiteSetting struct {
-	Name              string   `json:"name"`
-	Domains           []string `json:"domains"`
-	Ports             []uint   `json:"ports"`
-	Root              string   `json:"root"`
-	Path              string   `json:"path"`
-	Index             string   `json:"index"`
-	Php               int      `json:"php"`
-	OpenBasedir       bool     `json:"open_basedir"`
-	Ssl               bool     `json:"ssl"`
-	SslCertificate    string   `json:"ssl_certificate"`
-	SslCertificateKey string   `json:"ssl_certificate_key"`
-	SslNotBefore      string   `json:"ssl_not_before"`
-	SslNotAfter       string   `json:"ssl_not_after"`
-	SSlDNSNames       []string `json:"ssl_dns_names"`
-	SslIssuer         string   `json:"ssl_issuer"`
-	SslOCSPServer     []string `json:"ssl_ocsp_server"`
-	HttpRedirect      bool     `json:"http_redirect"`
-	Hsts              bool     `json:"hsts"`
-	Waf               bool     `json:"waf"`
-	WafMode           string   `json:"waf_mode"`
-	WafCcDeny         string   `json:"waf_cc_deny"`
-	WafCache          string   `json:"waf_cache"`
-	Rewrite           string   `json:"rewrite"`
-	Raw               string   `json:"raw"`
-	Log               string   `json:"log"`
+	Name                  string   `json:"name"`
+	Domains               []string `json:"domains"`
+	Ports                 []uint   `json:"ports"`
+	Root                  string   `json:"root"`
+	Path                  string   `json:"path"`
+	Index                 string   `json:"index"`
+	Php                   int      `json:"php"`
+	OpenBasedir           bool     `json:"open_basedir"`
+	Ssl                   bool     `json:"ssl"`
+	SslCertificate        string   `json:"ssl_certificate"`
+	SslCertificateKey     string   `json:"ssl_certificate_key"`
+	SslNotBefore          string   `json:"ssl_not_before"`
+	SslNotAfter           string   `json:"ssl_not_after"`
+	SSlDNSNames           []string `json:"ssl_dns_names"`
+	SslIssuer             string   `json:"ssl_issuer"`
+	SslOCSPServer         []string `json:"ssl_ocsp_server"`
+	SslDualCertificate    string   `json:"ssl_dual_certificate"` // 第二张证书，与主证书分别为 ECDSA 和 RSA
+	SslDualCertificateKey string   `json:"ssl_dual_certificate_key"`
+	TlsProfile            string   `json:"tls_profile"`
+	Http3                 bool     `json:"http3"`
+	Ocsp                  bool     `json:"ocsp"`
+	OcspResolver          string   `json:"ocsp_resolver"`
+	HttpRedirect          bool     `json:"http_redirect"`
+	Hsts                  bool     `json:"hsts"`
+	Waf                   bool     `json:"waf"`
+	WafMode               string   `json:"waf_mode"`
+	WafCcDeny             string   `json:"waf_cc_deny"`
+	WafCache              string   `json:"waf_cache"`
+	Rewrite               string   `json:"rewrite"`
+	Raw                   string   `json:"raw"`
+	Log                   string   `json:"log"`
+	AuthUsers             []string `json:"auth_users"` // 密码访问的用户
+	siterule.Rules
 }
 
 type WebsiteImpl struct {
 	setting Setting
+	pool    WebsitePool
+	php     WebsitePhp
+	limit   WebsiteLimit
+	waf     WebsiteWaf
+	country WebsiteCountry
 }
 
 func NewWebsiteImpl() *WebsiteImpl {
 	return &WebsiteImpl{
 		setting: NewSettingImpl(),
+		pool:    NewWebsitePoolImpl(),
+		php:     NewWebsitePhpImpl(),
+		limit:   NewWebsiteLimitImpl(),
+		waf:     NewWebsiteWafImpl(),
+		country: NewWebsiteCountryImpl(),
 	}
 }
 
@@ -211,6 +240,17 @@ server
     # ssl标记位开始
     # ssl标记位结束
 
+    # redirect标记位开始
+    # redirect标记位结束
+    # auth标记位开始
+    # auth标记位结束
+    # error_page标记位开始
+    # error_page标记位结束
+    # hotlink标记位开始
+    # hotlink标记位结束
+    # deny标记位开始
+    # deny标记位结束
+
     # php标记位开始
     include enable-php-%d.conf;
     # php标记位结束
@@ -222,6 +262,10 @@ server
     waf_cc_deny rate=1000r/m duration=60m;
     waf_cache capacity=50;
     # waf标记位结束
+    # limit标记位开始
+    # limit标记位结束
+    # geo标记位开始
+    # geo标记位结束
 
     # 错误页配置，可自行设置
     #error_page 404 /404.html;
@@ -247,6 +291,10 @@ server
     error_log /www/wwwlogs/%s.log;
 }
 `, portList, domainList, website.Path, website.Php, website.Name, website.Name, website.Name)
+	nginxConf, err := r.waf.Write(w, nginxConf)
+	if err != nil {
+		return models.Website{}, err
+	}
 
 	if err := tools.Write("/www/server/vhost/"+website.Name+".conf", nginxConf, 0644); err != nil {
 		return models.Website{}, err
@@ -278,21 +326,8 @@ server
 		return models.Website{}, err
 	}
 
-	rootPassword := r.setting.Get(models.SettingKeyMysqlRootPassword)
-	if website.Db && website.DbType == "mysql" {
-		_, _ = tools.Exec(`/www/server/mysql/bin/mysql -uroot -p` + rootPassword + ` -e "CREATE DATABASE IF NOT EXISTS ` + website.DbName + ` DEFAULT CHARSET utf8mb4 COLLATE utf8mb4_general_ci;"`)
-		_, _ = tools.Exec(`/www/server/mysql/bin/mysql -uroot -p` + rootPassword + ` -e "CREATE USER '` + website.DbUser + `'@'localhost' IDENTIFIED BY '` + website.DbPassword + `';"`)
-		_, _ = tools.Exec(`/www/server/mysql/bin/mysql -uroot -p` + rootPassword + ` -e "GRANT ALL PRIVILEGES ON ` + website.DbName + `.* TO '` + website.DbUser + `'@'localhost';"`)
-		_, _ = tools.Exec(`/www/server/mysql/bin/mysql -uroot -p` + rootPassword + ` -e "FLUSH PRIVILEGES;"`)
-	}
-	if website.Db && website.DbType == "postgresql" {
-		_, _ = tools.Exec(`echo "CREATE DATABASE ` + website.DbName + `;" | su - postgres -c "psql"`)
-		_, _ = tools.Exec(`echo "CREATE USER ` + website.DbUser + ` WITH PASSWORD '` + website.DbPassword + `';" | su - postgres -c "psql"`)
-		_, _ = tools.Exec(`echo "ALTER DATABASE ` + website.DbName + ` OWNER TO ` + website.DbUser + `;" | su - postgres -c "psql"`)
-		_, _ = tools.Exec(`echo "GRANT ALL PRIVILEGES ON DATABASE ` + website.DbName + ` TO ` + website.DbUser + `;" | su - postgres -c "psql"`)
-		userConfig := "host    " + website.DbName + "    " + website.DbUser + "    127.0.0.1/32    scram-sha-256"
-		_, _ = tools.Exec(`echo "` + userConfig + `" >> /www/server/postgresql/data/pg_hba.conf`)
-		_ = tools.ServiceReload("postgresql")
+	if website.Db {
+		createDatabase(r.setting, website.DbType, website.DbName, website.DbUser, website.DbPassword)
 	}
 
 	return w, nil
@@ -309,6 +344,17 @@ func (r *WebsiteImpl) SaveConfig(config requests.SaveConfig) error {
 		return errors.New("网站已停用，请先启用")
 	}
 
+	// 切换 PHP 版本前检查网站依赖的扩展
+	if website.Php != config.Php {
+		missing, err := r.php.Check(website, config.Php)
+		if err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("PHP-%d 缺少网站依赖的扩展：%s", config.Php, strings.Join(missing, ", "))
+		}
+	}
+
 	// 原文
 	raw, err := tools.Read("/www/server/vhost/" + website.Name + ".conf")
 	if err != nil {
@@ -325,6 +371,30 @@ func (r *WebsiteImpl) SaveConfig(config requests.SaveConfig) error {
 		return nil
 	}
 
+	rules := siterule.Rules{
+		Redirects:  config.Redirects,
+		AuthPaths:  config.AuthPaths,
+		ErrorPages: config.ErrorPages,
+		Hotlink:    config.Hotlink,
+		Denies:     config.Denies,
+	}
+	if err = rules.Validate(); err != nil {
+		return err
+	}
+	tls := tlsprofile.Options{
+		Profile:      config.TlsProfile,
+		HTTP3:        config.Http3,
+		OCSP:         config.Ocsp,
+		Resolver:     config.OcspResolver,
+		HttpRedirect: config.HttpRedirect,
+		Hsts:         config.Hsts,
+	}
+	if config.Ssl {
+		if err = r.checkTls(tls, config); err != nil {
+			return err
+		}
+	}
+
 	// 目录
 	path := config.Path
 	if !tools.Exists(path) {
@@ -352,14 +422,14 @@ func (r *WebsiteImpl) SaveConfig(config requests.SaveConfig) error {
 	var port strings.Builder
 	ports := config.Ports
 	for i, v := range ports {
-		vStr := cast.ToString(v)
-		if v == 443 && config.Ssl {
-			vStr = "443 ssl http2"
+		for j, vStr := range tlsprofile.Listen(v, config.Ssl, config.Http3) {
+			if j > 0 {
+				port.WriteString("\n")
+			}
+			port.WriteString("    listen " + vStr + ";")
 		}
 		if i != len(ports)-1 {
-			port.WriteString("    listen " + vStr + ";\n")
-		} else {
-			port.WriteString("    listen " + vStr + ";")
+			port.WriteString("\n")
 		}
 	}
 	portConfigOld := tools.Cut(raw, "# port标记位开始", "# port标记位结束")
@@ -397,15 +467,19 @@ func (r *WebsiteImpl) SaveConfig(config requests.SaveConfig) error {
 	if !strings.HasSuffix(root, "/") {
 		root += "/"
 	}
+	// .user.ini 中可能还有网站的 PHP 配置，仅修改 open_basedir
+	userIni, _ := tools.Read(root + ".user.ini")
+	openBasedir := ""
 	if config.OpenBasedir {
-		if err := tools.Write(root+".user.ini", "open_basedir="+path+":/tmp/", 0644); err != nil {
+		openBasedir = path + ":/tmp/"
+	}
+	if userIni = phpfpm.SetIni(userIni, "open_basedir", openBasedir); len(userIni) > 0 {
+		if err := tools.Write(root+".user.ini", userIni, 0644); err != nil {
 			return err
 		}
-	} else {
-		if tools.Exists(root + ".user.ini") {
-			if err := tools.Remove(root + ".user.ini"); err != nil {
-				return err
-			}
+	} else if tools.Exists(root + ".user.ini") {
+		if err := tools.Remove(root + ".user.ini"); err != nil {
+			return err
 		}
 	}
 
@@ -430,6 +504,9 @@ func (r *WebsiteImpl) SaveConfig(config requests.SaveConfig) error {
 		raw = strings.Replace(raw, wafConfigOld, "", -1)
 	}
 	raw = strings.Replace(raw, "# waf标记位开始", wafConfig, -1)
+	if raw, err = r.waf.Write(website, raw); err != nil {
+		return err
+	}
 
 	// SSL
 	ssl := config.Ssl
@@ -440,32 +517,21 @@ func (r *WebsiteImpl) SaveConfig(config requests.SaveConfig) error {
 	if err := tools.Write("/www/server/vhost/ssl/"+website.Name+".key", config.SslCertificateKey, 0644); err != nil {
 		return err
 	}
-	if ssl {
-		sslConfig := `# ssl标记位开始
-    ssl_certificate /www/server/vhost/ssl/` + website.Name + `.pem;
-    ssl_certificate_key /www/server/vhost/ssl/` + website.Name + `.key;
-    ssl_session_timeout 1d;
-    ssl_session_cache shared:SSL:10m;
-    ssl_session_tickets off;
-    ssl_protocols TLSv1.2 TLSv1.3;
-    ssl_ciphers ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256:ECDHE-ECDSA-AES256-GCM-SHA384:ECDHE-RSA-AES256-GCM-SHA384:ECDHE-ECDSA-CHACHA20-POLY1305:ECDHE-RSA-CHACHA20-POLY1305:DHE-RSA-AES128-GCM-SHA256:DHE-RSA-AES256-GCM-SHA384;
-    ssl_prefer_server_ciphers off;
-    `
-		if config.HttpRedirect {
-			sslConfig += `# http重定向标记位开始
-    if ($server_port !~ 443){
-        return 301 https://$host$request_uri;
-    }
-    error_page 497  https://$host$request_uri;
-    # http重定向标记位结束
-    `
+	files := sslFiles(website, len(strings.TrimSpace(config.SslDualCertificate)) > 0)
+	if len(files.DualCert) > 0 {
+		if err := tools.Write(files.DualCert, config.SslDualCertificate, 0644); err != nil {
+			return err
 		}
-		if config.Hsts {
-			sslConfig += `# hsts标记位开始
-    add_header Strict-Transport-Security "max-age=63072000" always;
-    # hsts标记位结束
-    `
+		if err := tools.Write(files.DualKey, config.SslDualCertificateKey, 0644); err != nil {
+			return err
 		}
+	} else {
+		dual := sslFiles(website, true)
+		_ = tools.Remove(dual.DualCert)
+		_ = tools.Remove(dual.DualKey)
+	}
+	if ssl {
+		sslConfig := tlsprofile.Block(tls, files)
 		sslConfigOld := tools.Cut(raw, "# ssl标记位开始", "# ssl标记位结束")
 		if len(strings.TrimSpace(sslConfigOld)) != 0 {
 			raw = strings.Replace(raw, sslConfigOld, "", -1)
@@ -481,14 +547,29 @@ func (r *WebsiteImpl) SaveConfig(config requests.SaveConfig) error {
 	if website.Php != config.Php {
 		website.Php = config.Php
 		phpConfigOld := tools.Cut(raw, "# php标记位开始", "# php标记位结束")
-		phpConfig := `
-    include enable-php-` + strconv.Itoa(website.Php) + `.conf;
-    `
+		phpConfig := r.pool.Block(website)
 		if len(strings.TrimSpace(phpConfigOld)) != 0 {
 			raw = strings.Replace(raw, phpConfigOld, phpConfig, -1)
 		}
 	}
 
+	// 跳转、密码访问、错误页、防盗链和禁止访问规则，密码访问的规则中嵌套了 PHP 配置，需在切换 PHP 版本后写入
+	if len(rules.AuthPaths) > 0 && !tools.Exists(htpasswdFile(website)) {
+		if err = writeHtpasswd(website, ""); err != nil {
+			return err
+		}
+	}
+	if raw, err = siterule.Apply(raw, rules, htpasswdFile(website)); err != nil {
+		return err
+	}
+	// 限制由面板维护，忽略原始配置中对限制的修改
+	if limited, err := ratelimit.Write(raw, r.limit.Block(website)); err == nil {
+		raw = limited
+	}
+	if located, err := geoip.Write(raw, r.country.Block(website)); err == nil {
+		raw = located
+	}
+
 	if err := facades.Orm().Query().Save(&website); err != nil {
 		return err
 	}
@@ -500,8 +581,12 @@ func (r *WebsiteImpl) SaveConfig(config requests.SaveConfig) error {
 		return err
 	}
 
-	_, err = tools.Exec("systemctl reload openresty")
-	return err
+	if _, err = tools.Exec("systemctl reload openresty"); err != nil {
+		return err
+	}
+
+	// 切换 PHP 版本或目录后同步独立进程池和 PHP 配置
+	return r.php.Apply(website)
 }
 
 // Delete 删除网站
@@ -519,6 +604,40 @@ func (r *WebsiteImpl) Delete(id uint) error {
 		return err
 	}
 
+	var checks []models.WebsiteCheck
+	if err := facades.Orm().Query().Where("website_id", website.ID).Find(&checks); err != nil {
+		return err
+	}
+	for _, check := range checks {
+		if _, err := facades.Orm().Query().Where("check_id", check.ID).Delete(&models.WebsiteCheckResult{}); err != nil {
+			return err
+		}
+	}
+	if _, err := facades.Orm().Query().Where("website_id", website.ID).Delete(&models.WebsiteCheck{}); err != nil {
+		return err
+	}
+	if err := NewWebsiteStatImpl().Delete(website.ID); err != nil {
+		return err
+	}
+	if err := r.pool.Delete(website); err != nil {
+		return err
+	}
+	if err := r.limit.Delete(website); err != nil {
+		return err
+	}
+	if err := r.waf.Delete(website); err != nil {
+		return err
+	}
+	if err := r.country.Delete(website); err != nil {
+		return err
+	}
+	if err := NewWebsiteDeployImpl().Delete(website); err != nil {
+		return err
+	}
+	if err := NewWebsiteCloneImpl().Delete(website); err != nil {
+		return err
+	}
+
 	if err := tools.Remove("/www/server/vhost/" + website.Name + ".conf"); err != nil {
 		return err
 	}
@@ -531,6 +650,16 @@ func (r *WebsiteImpl) Delete(id uint) error {
 	if err := tools.Remove("/www/server/vhost/ssl/" + website.Name + ".key"); err != nil {
 		return err
 	}
+	dual := sslFiles(website, true)
+	if err := tools.Remove(dual.DualCert); err != nil {
+		return err
+	}
+	if err := tools.Remove(dual.DualKey); err != nil {
+		return err
+	}
+	if err := tools.Remove(htpasswdFile(website)); err != nil {
+		return err
+	}
 	if err := tools.Remove(website.Path); err != nil {
 		return err
 	}
@@ -565,8 +694,15 @@ func (r *WebsiteImpl) GetConfig(id uint) (WebsiteSetting, error) {
 			continue
 		}
 
-		port := strings.Fields(match[1])[0]
-		setting.Ports = append(setting.Ports, cast.ToUint(port))
+		// 开启 HTTP/3 时 443 端口有两条 listen
+		port := cast.ToUint(strings.Fields(match[1])[0])
+		exists := false
+		for _, p := range setting.Ports {
+			exists = exists || p == port
+		}
+		if !exists {
+			setting.Ports = append(setting.Ports, port)
+		}
 	}
 	serverName := tools.Cut(config, "# server_name标记位开始", "# server_name标记位结束")
 	match := regexp.MustCompile(`server_name\s+(.*);`).FindStringSubmatch(serverName)
@@ -599,10 +735,18 @@ func (r *WebsiteImpl) GetConfig(id uint) (WebsiteSetting, error) {
 	setting.SslCertificate = cert
 	key, _ := tools.Read("/www/server/vhost/ssl/" + website.Name + ".key")
 	setting.SslCertificateKey = key
+	dual := sslFiles(website, true)
+	setting.SslDualCertificate, _ = tools.Read(dual.DualCert)
+	setting.SslDualCertificateKey, _ = tools.Read(dual.DualKey)
+	setting.TlsProfile = "intermediate"
 	if setting.Ssl {
-		ssl := tools.Cut(config, "# ssl标记位开始", "# ssl标记位结束")
-		setting.HttpRedirect = strings.Contains(ssl, "# http重定向标记位")
-		setting.Hsts = strings.Contains(ssl, "# hsts标记位")
+		tls := tlsprofile.Parse(tools.Cut(config, "# ssl标记位开始", "# ssl标记位结束"), ports)
+		setting.TlsProfile = tls.Profile
+		setting.Http3 = tls.HTTP3
+		setting.Ocsp = tls.OCSP
+		setting.OcspResolver = tls.Resolver
+		setting.HttpRedirect = tls.HttpRedirect
+		setting.Hsts = tls.Hsts
 
 		block, _ := pem.Decode([]byte(cert))
 		if block != nil {
@@ -635,6 +779,10 @@ func (r *WebsiteImpl) GetConfig(id uint) (WebsiteSetting, error) {
 		setting.WafCache = match[1]
 	}
 
+	setting.Rules = siterule.Parse(config)
+	htpasswd, _ := tools.Read(htpasswdFile(website))
+	setting.AuthUsers = siterule.Users(htpasswd)
+
 	rewrite, _ := tools.Read("/www/server/vhost/rewrite/" + website.Name + ".conf")
 	setting.Rewrite = rewrite
 	log, _ := tools.Exec(`tail -n 100 '/www/wwwlogs/` + website.Name + `.log'`)
@@ -652,3 +800,282 @@ func (r *WebsiteImpl) GetConfigByName(name string) (WebsiteSetting, error) {
 
 	return r.GetConfig(website.ID)
 }
+
+// SaveAuthUser 添加或修改网站密码访问的用户
+func (r *WebsiteImpl) SaveAuthUser(id uint, name, password string) error {
+	var website models.Website
+	if err := facades.Orm().Query().Where("id", id).FirstOrFail(&website); err != nil {
+		return err
+	}
+
+	htpasswd, _ := tools.Read(htpasswdFile(website))
+	htpasswd, err := siterule.SetUser(htpasswd, name, password)
+	if err != nil {
+		return err
+	}
+
+	return writeHtpasswd(website, htpasswd)
+}
+
+// DeleteAuthUser 删除网站密码访问的用户
+func (r *WebsiteImpl) DeleteAuthUser(id uint, name string) error {
+	var website models.Website
+	if err := facades.Orm().Query().Where("id", id).FirstOrFail(&website); err != nil {
+		return err
+	}
+
+	htpasswd, err := tools.Read(htpasswdFile(website))
+	if err != nil {
+		return errors.New("用户不存在")
+	}
+
+	return writeHtpasswd(website, siterule.DeleteUser(htpasswd, name))
+}
+
+// createDatabase 创建网站使用的数据库及用户
+func createDatabase(setting Setting, dbType, name, user, password string) {
+	rootPassword := setting.Get(models.SettingKeyMysqlRootPassword)
+	if dbType == "mysql" {
+		_, _ = tools.Exec(`/www/server/mysql/bin/mysql -uroot -p` + rootPassword + ` -e "CREATE DATABASE IF NOT EXISTS ` + name + ` DEFAULT CHARSET utf8mb4 COLLATE utf8mb4_general_ci;"`)
+		_, _ = tools.Exec(`/www/server/mysql/bin/mysql -uroot -p` + rootPassword + ` -e "CREATE USER '` + user + `'@'localhost' IDENTIFIED BY '` + password + `';"`)
+		_, _ = tools.Exec(`/www/server/mysql/bin/mysql -uroot -p` + rootPassword + ` -e "GRANT ALL PRIVILEGES ON ` + name + `.* TO '` + user + `'@'localhost';"`)
+		_, _ = tools.Exec(`/www/server/mysql/bin/mysql -uroot -p` + rootPassword + ` -e "FLUSH PRIVILEGES;"`)
+	}
+	if dbType == "postgresql" {
+		_, _ = tools.Exec(`echo "CREATE DATABASE ` + name + `;" | su - postgres -c "psql"`)
+		_, _ = tools.Exec(`echo "CREATE USER ` + user + ` WITH PASSWORD '` + password + `';" | su - postgres -c "psql"`)
+		_, _ = tools.Exec(`echo "ALTER DATABASE ` + name + ` OWNER TO ` + user + `;" | su - postgres -c "psql"`)
+		_, _ = tools.Exec(`echo "GRANT ALL PRIVILEGES ON DATABASE ` + name + ` TO ` + user + `;" | su - postgres -c "psql"`)
+		userConfig := "host    " + name + "    " + user + "    127.0.0.1/32    scram-sha-256"
+		_, _ = tools.Exec(`echo "` + userConfig + `" >> /www/server/postgresql/data/pg_hba.conf`)
+		_ = tools.ServiceReload("postgresql")
+	}
+}
+
+// usedDomains 获取本机网站使用的域名及所属网站
+func usedDomains() (map[string]string, error) {
+	used := make(map[string]string)
+	files, err := filepath.Glob("/www/server/vhost/*.conf")
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		raw, err := tools.Read(file)
+		if err != nil {
+			continue
+		}
+		for _, domain := range sitebundle.Domains(raw) {
+			used[domain] = strings.TrimSuffix(filepath.Base(file), ".conf")
+		}
+	}
+
+	return used, nil
+}
+
+// checkDatabaseServer 检查数据库服务是否已安装
+func checkDatabaseServer(dbType string) error {
+	switch dbType {
+	case "mysql":
+		if !tools.Exists("/www/server/mysql") {
+			return errors.New("MySQL 未安装")
+		}
+	case "postgresql":
+		if !tools.Exists("/www/server/postgresql") {
+			return errors.New("PostgreSQL 未安装")
+		}
+	default:
+		return errors.New("不支持的数据库类型")
+	}
+
+	return nil
+}
+
+// databaseExists 检查数据库是否已存在
+func databaseExists(setting Setting, dbType, name string) bool {
+	var out string
+	switch dbType {
+	case "mysql":
+		out, _ = tools.Exec(`/www/server/mysql/bin/mysql -uroot -p` + setting.Get(models.SettingKeyMysqlRootPassword) + ` -N -e "SHOW DATABASES LIKE '` + name + `';"`)
+	case "postgresql":
+		out, _ = tools.Exec(`su - postgres -c "psql -tAc \"SELECT 1 FROM pg_database WHERE datname = '` + name + `'\""`)
+	}
+
+	return len(strings.TrimSpace(out)) > 0
+}
+
+// dispatchScript 将脚本写入临时文件并创建任务，脚本中含有数据库密码，执行后删除
+func dispatchScript(task Task, name, prefix, script string) (models.Task, error) {
+	file := prefix + ".sh"
+	if err := tools.Write(file, script, 0600); err != nil {
+		return models.Task{}, err
+	}
+	log := prefix + "-" + strconv.FormatInt(time.Now().Unix(), 10) + ".log"
+	shell := `bash '` + file + `' >> ` + log + ` 2>&1; code=$?; rm -f '` + file + `'; exit $code`
+
+	dispatched, err := task.Dispatch(name, shell, log)
+	if err != nil {
+		_ = tools.Remove(file)
+		return models.Task{}, err
+	}
+
+	return dispatched, nil
+}
+
+// TlsReport 生成网站的 TLS 配置报告，检查证书有效期、域名覆盖、私钥匹配和证书链
+func (r *WebsiteImpl) TlsReport(id uint) (tlsprofile.Report, error) {
+	setting, err := r.GetConfig(id)
+	if err != nil {
+		return tlsprofile.Report{}, err
+	}
+	if !setting.Ssl {
+		return tlsprofile.Report{}, errors.New("网站未开启 SSL")
+	}
+
+	now := time.Now()
+	pairs := [][2]string{{setting.SslCertificate, setting.SslCertificateKey}}
+	if len(strings.TrimSpace(setting.SslDualCertificate)) > 0 {
+		pairs = append(pairs, [2]string{setting.SslDualCertificate, setting.SslDualCertificateKey})
+	}
+	var certificates []tlsprofile.Certificate
+	for _, pair := range pairs {
+		certificate, err := tlsprofile.Inspect(pair[0], pair[1], now)
+		if err != nil {
+			return tlsprofile.Report{}, err
+		}
+		certificates = append(certificates, certificate)
+	}
+	var domains []string
+	for _, domain := range setting.Domains {
+		if len(domain) > 0 {
+			domains = append(domains, domain)
+		}
+	}
+
+	// 协议版本由默认网站统一配置
+	protocols := tlsprofile.DefaultProtocols
+	if conf, err := tools.Read("/www/server/openresty/conf/default.conf"); err == nil {
+		protocols = tlsprofile.Protocols(conf)
+	}
+
+	return tlsprofile.BuildReport(tlsprofile.Options{
+		Profile:      setting.TlsProfile,
+		HTTP3:        setting.Http3,
+		OCSP:         setting.Ocsp,
+		Resolver:     setting.OcspResolver,
+		HttpRedirect: setting.HttpRedirect,
+		Hsts:         setting.Hsts,
+	}, protocols, certificates, domains), nil
+}
+
+// checkTls 校验 TLS 配置、OpenResty 对 HTTP/3 的支持和双证书
+func (r *WebsiteImpl) checkTls(options tlsprofile.Options, config requests.SaveConfig) error {
+	if err := options.Validate(); err != nil {
+		return err
+	}
+	if options.HTTP3 {
+		https := false
+		for _, port := range config.Ports {
+			https = https || port == 443
+		}
+		if !https {
+			return errors.New("开启 HTTP/3 需监听 443 端口")
+		}
+		if out, err := tools.Exec("openresty -V 2>&1"); err != nil || !strings.Contains(out, "http_v3_module") {
+			return errors.New("当前 OpenResty 未编译 HTTP/3 模块，无法开启 HTTP/3")
+		}
+	}
+	if len(strings.TrimSpace(config.SslDualCertificate)) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	first, err := tlsprofile.Inspect(config.SslCertificate, config.SslCertificateKey, now)
+	if err != nil {
+		return err
+	}
+	second, err := tlsprofile.Inspect(config.SslDualCertificate, config.SslDualCertificateKey, now)
+	if err != nil {
+		return errors.New("第二张证书" + err.Error())
+	}
+	if !second.KeyMatch {
+		return errors.New("第二张证书与私钥不匹配")
+	}
+
+	return tlsprofile.ValidateDual(first, second)
+}
+
+// sslFiles 网站的证书文件，dual 为 true 时包含第二张证书
+func sslFiles(website models.Website, dual bool) tlsprofile.Files {
+	files := tlsprofile.Files{
+		Cert: "/www/server/vhost/ssl/" + website.Name + ".pem",
+		Key:  "/www/server/vhost/ssl/" + website.Name + ".key",
+	}
+	if dual {
+		files.DualCert = "/www/server/vhost/ssl/" + website.Name + ".dual.pem"
+		files.DualKey = "/www/server/vhost/ssl/" + website.Name + ".dual.key"
+	}
+
+	return files
+}
+
+// htpasswdFile 网站密码访问的用户文件
+func htpasswdFile(website models.Website) string {
+	return "/www/server/vhost/htpasswd/" + website.Name + ".htpasswd"
+}
+
+// writeHtpasswd 写入网站密码访问的用户文件，tools.Write 创建的目录没有执行权限，需先创建目录并修正旧版本创建的目录权限，
+// 用户文件仅允许 root 和 OpenResty 所在的 www 组读取
+func writeHtpasswd(website models.Website, content string) error {
+	file := htpasswdFile(website)
+	dir := filepath.Dir(file)
+	if err := tools.Mkdir(dir, 0755); err != nil {
+		return err
+	}
+	if err := tools.Chmod(dir, 0755); err != nil {
+		return err
+	}
+	if err := tools.Write(file, content, 0640); err != nil {
+		return err
+	}
+	if err := tools.Chmod(file, 0640); err != nil {
+		return err
+	}
+
+	return tools.Chown(file, "root", "www")
+}
+
+// remapVhost 将其他网站的配置文件映射到新网站，from 为原网站，
+// 独立进程池、限制、国家规则和网站规则不随配置复制，新网站使用默认进程池和全局规则
+func remapVhost(pool WebsitePool, waf WebsiteWaf, raw string, from sitebundle.Mapping, website models.Website, domains []string) (string, error) {
+	vhost := sitebundle.RemapVhost(raw, from,
+		sitebundle.Mapping{Name: website.Name, Path: strings.TrimSuffix(website.Path, "/"), Domains: domains},
+	)
+	old := tools.Cut(vhost, "# php标记位开始", "# php标记位结束")
+	vhost = strings.Replace(vhost, "# php标记位开始"+old+"# php标记位结束", "# php标记位开始"+pool.Block(website)+"# php标记位结束", 1)
+	if limited, err := ratelimit.Write(vhost, ""); err == nil {
+		vhost = limited
+	}
+	if located, err := geoip.Write(vhost, ""); err == nil {
+		vhost = located
+	}
+
+	return waf.Write(website, vhost)
+}
+
+// setWebsiteRoot 修改网站配置文件中的运行目录
+func setWebsiteRoot(website models.Website, root string) error {
+	file := "/www/server/vhost/" + website.Name + ".conf"
+	raw, err := tools.Read(file)
+	if err != nil {
+		return err
+	}
+
+	block := tools.Cut(raw, "# root标记位开始", "# root标记位结束")
+	match := regexp.MustCompile(`root\s+(.+);`).FindStringSubmatch(block)
+	if len(match) != 2 {
+		return errors.New("配置文件中root标记位格式错误")
+	}
+	raw = strings.Replace(raw, block, strings.Replace(block, match[1], root, 1), 1)
+
+	return tools.Write(file, raw, 0644)
+}
@@ -0,0 +1,148 @@
+// Package services 网站应用部署服务
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/goravel/framework/facades"
+
+	"panel/app/models"
+	"panel/pkg/phpfpm"
+	"panel/pkg/webapp"
+)
+
+// websiteAppDefaultFiles 网站目录中可被应用覆盖的默认文件
+var websiteAppDefaultFiles = map[string]bool{"index.html": true, ".user.ini": true, ".htaccess": true, "404.html": true}
+
+// WebsiteAppInstall 应用部署参数
+type WebsiteAppInstall struct {
+	App        string   `json:"app"` // 应用标识，composer 为自定义 composer 包
+	Package    string   `json:"package"`
+	Version    string   `json:"version"`
+	Root       string   `json:"root"`
+	Writable   []string `json:"writable"`
+	Db         bool     `json:"db"`
+	DbType     string   `json:"db_type"`
+	DbName     string   `json:"db_name"`
+	DbUser     string   `json:"db_user"`
+	DbPassword string   `json:"db_password"`
+}
+
+type WebsiteApp interface {
+	Apps() []webapp.App
+	Install(website models.Website, install WebsiteAppInstall) (models.Task, error)
+}
+
+type WebsiteAppImpl struct {
+	setting Setting
+	pool    WebsitePool
+	php     WebsitePhp
+	task    Task
+}
+
+func NewWebsiteAppImpl() *WebsiteAppImpl {
+	return &WebsiteAppImpl{
+		setting: NewSettingImpl(),
+		pool:    NewWebsitePoolImpl(),
+		php:     NewWebsitePhpImpl(),
+		task:    NewTaskImpl(),
+	}
+}
+
+// Apps 获取可部署的应用
+func (r *WebsiteAppImpl) Apps() []webapp.App {
+	return webapp.Apps()
+}
+
+// Install 创建部署任务，应用下载成功后再创建数据库并设置运行目录和伪静态
+func (r *WebsiteAppImpl) Install(website models.Website, install WebsiteAppInstall) (models.Task, error) {
+	app, ok := webapp.Find(install.App)
+	if install.App == "composer" {
+		app, ok = webapp.Composer(install.Package, install.Version, install.Root, install.Writable), true
+	}
+	if !ok {
+		return models.Task{}, errors.New("应用不存在")
+	}
+	if err := app.Validate(); err != nil {
+		return models.Task{}, err
+	}
+
+	if !website.Status {
+		return models.Task{}, errors.New("网站已停用，请先启用")
+	}
+	if website.Php == 0 {
+		return models.Task{}, errors.New("请先为网站选择 PHP 版本")
+	}
+	if website.Php < app.MinPhp {
+		return models.Task{}, fmt.Errorf("%s 需要 PHP %d.%d 及以上版本", app.Name, app.MinPhp/10, app.MinPhp%10)
+	}
+	modules, err := r.php.Modules(website.Php)
+	if err != nil {
+		return models.Task{}, err
+	}
+	if missing := phpfpm.Missing(modules, app.Extensions); len(missing) > 0 {
+		return models.Task{}, fmt.Errorf("PHP-%d 缺少 %s 依赖的扩展：%s", website.Php, app.Name, strings.Join(missing, ", "))
+	}
+	if err = r.checkEmpty(website.Path); err != nil {
+		return models.Task{}, err
+	}
+
+	pool, err := r.pool.Get(website.ID)
+	if err != nil {
+		return models.Task{}, err
+	}
+	opts := webapp.Options{
+		Path:          website.Path,
+		Php:           website.Php,
+		Owner:         "www",
+		Vhost:         "/www/server/vhost/" + website.Name + ".conf",
+		Rewrite:       "/www/server/vhost/rewrite/" + website.Name + ".conf",
+		MysqlPassword: r.setting.Get(models.SettingKeyMysqlRootPassword),
+	}
+	if pool.ID > 0 {
+		opts.Owner = pool.User
+	}
+	if install.Db {
+		opts.Database = webapp.Database{
+			Type:     install.DbType,
+			Name:     install.DbName,
+			User:     install.DbUser,
+			Password: install.DbPassword,
+		}
+	}
+	script, err := webapp.Script(app, opts)
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	task, err := dispatchScript(r.task, "部署网站应用-"+website.Name+"-"+app.Name, "/tmp/panel-app-"+website.Name, script)
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	facades.Log().Tags("面板", "网站应用").With(map[string]any{
+		"website": website.Name,
+		"app":     app.Name,
+		"task":    task.ID,
+	}).Info("创建应用部署任务")
+
+	return task, nil
+}
+
+// checkEmpty 检查网站目录中除默认文件外是否为空
+func (r *WebsiteAppImpl) checkEmpty(path string) error {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !websiteAppDefaultFiles[entry.Name()] {
+			return errors.New("网站目录不为空，请先清空网站目录")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,460 @@
+// Package services 网站迁移服务
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goravel/framework/facades"
+	"github.com/goravel/framework/support/carbon"
+
+	"panel/app/models"
+	"panel/pkg/shell"
+	"panel/pkg/sitebundle"
+	"panel/pkg/tools"
+)
+
+// websiteBundleVersion 迁移包格式版本，导入时拒绝更高版本的迁移包
+const websiteBundleVersion = 1
+
+// WebsiteBundleManifest 迁移包中的网站信息
+type WebsiteBundleManifest struct {
+	Version   int                   `json:"version"`
+	Panel     string                `json:"panel"` // 导出时的面板版本
+	CreatedAt string                `json:"created_at"`
+	Website   models.Website        `json:"website"`
+	Domains   []string              `json:"domains"`
+	Ports     []uint                `json:"ports"`
+	Databases []sitebundle.Database `json:"databases"`
+}
+
+// WebsiteBundleDatabase 导入时数据库的映射，Target 为空时不导入该数据库
+type WebsiteBundleDatabase struct {
+	Name     string `json:"name"` // 迁移包中的数据库名
+	Target   string `json:"target"`
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+// WebsiteBundleImport 导入参数，名称、目录、域名和 PHP 版本为空时使用迁移包中的
+type WebsiteBundleImport struct {
+	File      string                  `json:"file"`
+	Name      string                  `json:"name"`
+	Domains   []string                `json:"domains"`
+	Path      string                  `json:"path"`
+	Php       int                     `json:"php"`
+	Databases []WebsiteBundleDatabase `json:"databases"`
+}
+
+// WebsiteBundleInspect 迁移包检查结果，存在冲突时无法导入
+type WebsiteBundleInspect struct {
+	Manifest  WebsiteBundleManifest `json:"manifest"`
+	Target    WebsiteBundleImport   `json:"target"`
+	Conflicts []string              `json:"conflicts"`
+	Warnings  []string              `json:"warnings"`
+}
+
+type WebsiteBundle interface {
+	Path() (string, error)
+	List() ([]BackupFile, error)
+	Export(website models.Website, databases []sitebundle.Database) (models.Task, error)
+	Inspect(target WebsiteBundleImport) (WebsiteBundleInspect, error)
+	Import(target WebsiteBundleImport) (models.Task, error)
+	Delete(file string) error
+}
+
+type WebsiteBundleImpl struct {
+	setting Setting
+	website Website
+	pool    WebsitePool
+	waf     WebsiteWaf
+	task    Task
+}
+
+func NewWebsiteBundleImpl() *WebsiteBundleImpl {
+	return &WebsiteBundleImpl{
+		setting: NewSettingImpl(),
+		website: NewWebsiteImpl(),
+		pool:    NewWebsitePoolImpl(),
+		waf:     NewWebsiteWafImpl(),
+		task:    NewTaskImpl(),
+	}
+}
+
+// Path 获取迁移包目录
+func (r *WebsiteBundleImpl) Path() (string, error) {
+	backupPath := r.setting.Get(models.SettingKeyBackupPath)
+	if len(backupPath) == 0 {
+		return "", errors.New("未正确配置备份路径")
+	}
+
+	backupPath += "/migration"
+	if !tools.Exists(backupPath) {
+		if err := tools.Mkdir(backupPath, 0700); err != nil {
+			return "", err
+		}
+	}
+
+	return backupPath, nil
+}
+
+// List 迁移包列表
+func (r *WebsiteBundleImpl) List() ([]BackupFile, error) {
+	path, err := r.Path()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]BackupFile, 0, len(files))
+	for _, file := range files {
+		info, err := file.Info()
+		if err != nil || file.IsDir() {
+			continue
+		}
+		list = append(list, BackupFile{
+			Name: file.Name(),
+			Size: tools.FormatBytes(float64(info.Size())),
+		})
+	}
+
+	return list, nil
+}
+
+// Export 导出网站的配置、证书、数据库和网站文件到迁移包
+func (r *WebsiteBundleImpl) Export(website models.Website, databases []sitebundle.Database) (models.Task, error) {
+	for _, db := range databases {
+		if err := checkDatabaseServer(db.Type); err != nil {
+			return models.Task{}, err
+		}
+	}
+	path, err := r.Path()
+	if err != nil {
+		return models.Task{}, err
+	}
+	config, err := r.website.GetConfig(website.ID)
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	manifest := WebsiteBundleManifest{
+		Version:   websiteBundleVersion,
+		Panel:     r.setting.Get(models.SettingKeyVersion),
+		CreatedAt: carbon.Now().ToDateTimeString(),
+		Website:   website,
+		Domains:   config.Domains,
+		Ports:     config.Ports,
+		Databases: databases,
+	}
+	raw, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	staging := fmt.Sprintf("/tmp/panel-export-%s-%d", website.Name, time.Now().Unix())
+	files := map[string]string{
+		sitebundle.ManifestFile:                string(raw),
+		sitebundle.ConfigDir + "/vhost.conf":   config.Raw,
+		sitebundle.ConfigDir + "/rewrite.conf": config.Rewrite,
+		sitebundle.ConfigDir + "/ssl.pem":      config.SslCertificate,
+		sitebundle.ConfigDir + "/ssl.key":      config.SslCertificateKey,
+	}
+	if len(config.SslDualCertificate) > 0 {
+		files[sitebundle.ConfigDir+"/ssl.dual.pem"] = config.SslDualCertificate
+		files[sitebundle.ConfigDir+"/ssl.dual.key"] = config.SslDualCertificateKey
+	}
+	if htpasswd, err := tools.Read(htpasswdFile(website)); err == nil {
+		files[sitebundle.ConfigDir+"/htpasswd"] = htpasswd
+	}
+	for name, content := range files {
+		if err = tools.Write(staging+"/"+name, content, 0600); err != nil {
+			_ = tools.Remove(staging)
+			return models.Task{}, err
+		}
+	}
+
+	output := path + "/" + website.Name + "_" + carbon.Now().ToShortDateTimeString() + ".tar"
+	script, err := sitebundle.ExportScript(staging, strings.TrimSuffix(website.Path, "/"), output, r.setting.Get(models.SettingKeyMysqlRootPassword), databases)
+	if err != nil {
+		_ = tools.Remove(staging)
+		return models.Task{}, err
+	}
+
+	return dispatchScript(r.task, "导出网站-"+website.Name, "/tmp/panel-export-"+website.Name, script)
+}
+
+// Inspect 读取迁移包并检查导入到本机时的冲突
+func (r *WebsiteBundleImpl) Inspect(target WebsiteBundleImport) (WebsiteBundleInspect, error) {
+	manifest, err := r.manifest(target.File)
+	if err != nil {
+		return WebsiteBundleInspect{}, err
+	}
+
+	if len(target.Name) == 0 {
+		target.Name = manifest.Website.Name
+	}
+	if len(target.Path) == 0 {
+		target.Path = r.setting.Get(models.SettingKeyWebsitePath) + "/" + target.Name
+	}
+	target.Path = strings.TrimSuffix(target.Path, "/")
+	if len(target.Domains) == 0 {
+		target.Domains = manifest.Domains
+	}
+	if target.Php == 0 {
+		target.Php = manifest.Website.Php
+	}
+	if target.Databases == nil {
+		target.Databases = []WebsiteBundleDatabase{}
+	}
+
+	inspect := WebsiteBundleInspect{
+		Manifest:  manifest,
+		Target:    target,
+		Conflicts: []string{},
+		Warnings:  []string{},
+	}
+
+	var count int64
+	if err = facades.Orm().Query().Model(&models.Website{}).Where("name", target.Name).Count(&count); err != nil {
+		return WebsiteBundleInspect{}, err
+	}
+	if count > 0 || tools.Exists("/www/server/vhost/"+target.Name+".conf") {
+		inspect.Conflicts = append(inspect.Conflicts, "网站 "+target.Name+" 已存在")
+	}
+	if tools.Exists(target.Path) && !tools.Empty(target.Path) {
+		inspect.Conflicts = append(inspect.Conflicts, "网站目录 "+target.Path+" 不为空")
+	}
+	if target.Php > 0 && !tools.Exists("/www/server/php/"+strconv.Itoa(target.Php)) {
+		inspect.Conflicts = append(inspect.Conflicts, "PHP-"+strconv.Itoa(target.Php)+" 未安装")
+	}
+	used, err := usedDomains()
+	if err != nil {
+		return WebsiteBundleInspect{}, err
+	}
+	for _, domain := range target.Domains {
+		if name, ok := used[domain]; ok {
+			inspect.Conflicts = append(inspect.Conflicts, "域名 "+domain+" 已被网站 "+name+" 使用")
+		}
+	}
+
+	mapped := make(map[string]WebsiteBundleDatabase)
+	for _, db := range target.Databases {
+		mapped[db.Name] = db
+	}
+	for _, db := range manifest.Databases {
+		mapping, ok := mapped[db.Name]
+		if !ok || len(mapping.Target) == 0 {
+			inspect.Warnings = append(inspect.Warnings, "数据库 "+db.Name+" 将不会导入")
+			continue
+		}
+		if !shell.NamePattern.MatchString(mapping.Target) || !shell.NamePattern.MatchString(mapping.User) {
+			inspect.Conflicts = append(inspect.Conflicts, "数据库 "+db.Name+" 的新数据库名或用户名不合法")
+			continue
+		}
+		if err = checkDatabaseServer(db.Type); err != nil {
+			inspect.Conflicts = append(inspect.Conflicts, err.Error())
+			continue
+		}
+		if databaseExists(r.setting, db.Type, mapping.Target) {
+			inspect.Conflicts = append(inspect.Conflicts, "数据库 "+mapping.Target+" 已存在")
+		}
+	}
+
+	if manifest.Website.Ssl && strings.Join(target.Domains, " ") != strings.Join(manifest.Domains, " ") {
+		inspect.Warnings = append(inspect.Warnings, "域名已变更，迁移的证书可能不包含新域名，请导入后重新签发")
+	}
+
+	return inspect, nil
+}
+
+// Import 导入迁移包，创建网站并写入配置和证书后在后台任务中导入网站文件和数据库
+func (r *WebsiteBundleImpl) Import(target WebsiteBundleImport) (models.Task, error) {
+	inspect, err := r.Inspect(target)
+	if err != nil {
+		return models.Task{}, err
+	}
+	if len(inspect.Conflicts) > 0 {
+		return models.Task{}, errors.New("存在冲突：" + strings.Join(inspect.Conflicts, "；"))
+	}
+	manifest, target := inspect.Manifest, inspect.Target
+
+	mapped := make(map[string]WebsiteBundleDatabase)
+	for _, db := range target.Databases {
+		mapped[db.Name] = db
+	}
+	var restores []sitebundle.Restore
+	for _, db := range manifest.Databases {
+		if mapping, ok := mapped[db.Name]; ok && len(mapping.Target) > 0 {
+			restores = append(restores, sitebundle.Restore{Database: db, Target: mapping.Target, User: mapping.User, Password: mapping.Password})
+		}
+	}
+
+	// 先生成脚本以校验参数，避免创建网站后才发现参数错误
+	bundle, err := r.file(target.File)
+	if err != nil {
+		return models.Task{}, err
+	}
+	script, err := sitebundle.ImportScript(bundle, target.Path, strings.TrimSuffix(manifest.Website.Path, "/"), "www", r.setting.Get(models.SettingKeyMysqlRootPassword), restores)
+	if err != nil {
+		return models.Task{}, err
+	}
+	tmp, err := tools.TempDir("panel-import")
+	if err != nil {
+		return models.Task{}, err
+	}
+	defer os.RemoveAll(tmp)
+	if out, err := tools.Exec("tar -xf " + shell.Quote(bundle) + " -C " + shell.Quote(tmp) + " " + sitebundle.ConfigDir); err != nil {
+		return models.Task{}, errors.New("读取迁移包失败: " + out)
+	}
+
+	website, err := r.website.Add(PanelWebsite{
+		Name:    target.Name,
+		Status:  manifest.Website.Status,
+		Domains: target.Domains,
+		Ports:   manifest.Ports,
+		Path:    target.Path,
+		Php:     target.Php,
+		Remark:  manifest.Website.Remark,
+	})
+	if err != nil {
+		return models.Task{}, err
+	}
+	website.Ssl = manifest.Website.Ssl
+	website.PhpSettings = manifest.Website.PhpSettings
+	if err = facades.Orm().Query().Save(&website); err != nil {
+		return models.Task{}, err
+	}
+	if err = r.writeConfig(website, manifest, tmp+"/"+sitebundle.ConfigDir); err != nil {
+		return models.Task{}, err
+	}
+	for _, restore := range restores {
+		createDatabase(r.setting, restore.Type, restore.Target, restore.User, restore.Password)
+	}
+
+	facades.Log().Tags("面板", "网站迁移").With(map[string]any{
+		"file":    target.File,
+		"website": website.Name,
+	}).Info("导入迁移包")
+
+	return dispatchScript(r.task, "导入网站-"+website.Name, "/tmp/panel-import-"+website.Name, script)
+}
+
+// Delete 删除迁移包
+func (r *WebsiteBundleImpl) Delete(file string) error {
+	path, err := r.Path()
+	if err != nil {
+		return err
+	}
+	file = path + "/" + filepath.Base(file)
+	if !tools.Exists(file) {
+		return errors.New("迁移包不存在")
+	}
+
+	return tools.Remove(file)
+}
+
+// writeConfig 将迁移包中的配置映射到新网站后写入
+func (r *WebsiteBundleImpl) writeConfig(website models.Website, manifest WebsiteBundleManifest, dir string) error {
+	vhost, err := tools.Read(dir + "/vhost.conf")
+	if err != nil {
+		return err
+	}
+	setting, err := r.website.GetConfig(website.ID)
+	if err != nil {
+		return err
+	}
+	from := sitebundle.Mapping{Name: manifest.Website.Name, Path: strings.TrimSuffix(manifest.Website.Path, "/"), Domains: manifest.Domains}
+	if vhost, err = remapVhost(r.pool, r.waf, vhost, from, website, setting.Domains); err != nil {
+		return err
+	}
+
+	rewrite, _ := tools.Read(dir + "/rewrite.conf")
+	cert, _ := tools.Read(dir + "/ssl.pem")
+	key, _ := tools.Read(dir + "/ssl.key")
+	if err = tools.Write("/www/server/vhost/"+website.Name+".conf", vhost, 0644); err != nil {
+		return err
+	}
+	if err = tools.Write("/www/server/vhost/rewrite/"+website.Name+".conf", rewrite, 0644); err != nil {
+		return err
+	}
+	if err = tools.Write("/www/server/vhost/ssl/"+website.Name+".pem", cert, 0644); err != nil {
+		return err
+	}
+	if err = tools.Write("/www/server/vhost/ssl/"+website.Name+".key", key, 0644); err != nil {
+		return err
+	}
+	if dualCert, err := tools.Read(dir + "/ssl.dual.pem"); err == nil {
+		dual := sslFiles(website, true)
+		dualKey, _ := tools.Read(dir + "/ssl.dual.key")
+		if err = tools.Write(dual.DualCert, dualCert, 0644); err != nil {
+			return err
+		}
+		if err = tools.Write(dual.DualKey, dualKey, 0644); err != nil {
+			return err
+		}
+	}
+	if htpasswd, err := tools.Read(dir + "/htpasswd"); err == nil {
+		if err = writeHtpasswd(website, htpasswd); err != nil {
+			return err
+		}
+	}
+
+	return tools.ServiceReload("openresty")
+}
+
+// file 校验迁移包文件名并返回完整路径
+func (r *WebsiteBundleImpl) file(name string) (string, error) {
+	if !sitebundle.BundlePattern.MatchString(name) {
+		return "", errors.New("迁移包文件名不合法")
+	}
+	path, err := r.Path()
+	if err != nil {
+		return "", err
+	}
+	bundle := path + "/" + name
+	if !tools.Exists(bundle) {
+		return "", errors.New("迁移包不存在")
+	}
+
+	return bundle, nil
+}
+
+// manifest 读取迁移包中的网站信息
+func (r *WebsiteBundleImpl) manifest(file string) (WebsiteBundleManifest, error) {
+	bundle, err := r.file(file)
+	if err != nil {
+		return WebsiteBundleManifest{}, err
+	}
+
+	tmp, err := tools.TempDir("panel-import")
+	if err != nil {
+		return WebsiteBundleManifest{}, err
+	}
+	defer os.RemoveAll(tmp)
+	if _, err = tools.Exec("tar -xf " + shell.Quote(bundle) + " -C " + shell.Quote(tmp) + " " + sitebundle.ManifestFile); err != nil {
+		return WebsiteBundleManifest{}, errors.New("文件不是有效的迁移包")
+	}
+	raw, err := os.ReadFile(tmp + "/" + sitebundle.ManifestFile)
+	if err != nil {
+		return WebsiteBundleManifest{}, err
+	}
+
+	var manifest WebsiteBundleManifest
+	if err = json.Unmarshal(raw, &manifest); err != nil {
+		return WebsiteBundleManifest{}, errors.New("迁移包中的网站信息格式错误")
+	}
+	if manifest.Version > websiteBundleVersion {
+		return WebsiteBundleManifest{}, errors.New("迁移包由更高版本的面板导出，请先升级面板")
+	}
+
+	return manifest, nil
+}
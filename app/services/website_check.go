@@ -0,0 +1,179 @@
+// Package services 网站可用性检测服务
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/goravel/framework/facades"
+	"github.com/goravel/framework/support/carbon"
+
+	"panel/app/models"
+	"panel/pkg/healthcheck"
+)
+
+const (
+	// websiteCheckConfirm 连续失败多少次后判定为无法访问，避免偶发失败误报
+	websiteCheckConfirm = 2
+	// websiteCheckResultDays 检测记录保留天数
+	websiteCheckResultDays = 30
+)
+
+type WebsiteCheck interface {
+	Due() ([]models.WebsiteCheck, error)
+	Probe(check models.WebsiteCheck) healthcheck.Result
+	Save(check models.WebsiteCheck, result healthcheck.Result) (models.WebsiteCheckResult, error)
+	Run(check models.WebsiteCheck) (models.WebsiteCheckResult, error)
+	Uptime(checkID uint, days int) (float64, bool)
+	Prune() error
+}
+
+type WebsiteCheckImpl struct {
+	notification Notification
+}
+
+func NewWebsiteCheckImpl() *WebsiteCheckImpl {
+	return &WebsiteCheckImpl{
+		notification: NewNotificationImpl(),
+	}
+}
+
+// Due 获取已到检测时间的检测
+func (r *WebsiteCheckImpl) Due() ([]models.WebsiteCheck, error) {
+	var checks []models.WebsiteCheck
+	if err := facades.Orm().Query().Where("enabled", true).Find(&checks); err != nil {
+		return nil, err
+	}
+
+	now := carbon.Now()
+	var due []models.WebsiteCheck
+	for _, check := range checks {
+		// 调度每分钟执行一次，预留几秒误差
+		if check.CheckedAt == nil || check.CheckedAt.AddSeconds(check.Interval-5).Lte(now) {
+			due = append(due, check)
+		}
+	}
+
+	return due, nil
+}
+
+// Run 执行一次检测并保存结果
+func (r *WebsiteCheckImpl) Run(check models.WebsiteCheck) (models.WebsiteCheckResult, error) {
+	return r.Save(check, r.Probe(check))
+}
+
+// Probe 执行一次检测，不保存结果
+func (r *WebsiteCheckImpl) Probe(check models.WebsiteCheck) healthcheck.Result {
+	return healthcheck.Run(context.Background(), healthcheck.Check{
+		URL:          check.URL,
+		Method:       check.Method,
+		ExpectStatus: check.ExpectStatus,
+		Keyword:      check.Keyword,
+		Timeout:      time.Duration(check.Timeout) * time.Second,
+		Insecure:     check.Insecure,
+	})
+}
+
+// Save 保存检测记录，更新检测状态并在状态变化时发送通知
+func (r *WebsiteCheckImpl) Save(check models.WebsiteCheck, result healthcheck.Result) (models.WebsiteCheckResult, error) {
+	record := models.WebsiteCheckResult{
+		CheckID:      check.ID,
+		Up:           result.Up,
+		StatusCode:   result.StatusCode,
+		ResponseTime: result.ResponseTime.Milliseconds(),
+		Error:        result.Error,
+	}
+	if err := facades.Orm().Query().Create(&record); err != nil {
+		return record, err
+	}
+
+	now := carbon.DateTime{Carbon: carbon.Now()}
+	lastChecked := check.CheckedAt
+	status := check.Status
+	check.CheckedAt = &now
+	check.ResponseTime = record.ResponseTime
+	check.LastError = result.Error
+	if result.Up {
+		check.Failures = 0
+		check.Status = models.WebsiteCheckStatusUp
+	} else {
+		check.Failures++
+		if check.Failures >= websiteCheckConfirm {
+			check.Status = models.WebsiteCheckStatusDown
+		}
+	}
+	if result.CertExpiry != nil {
+		check.CertExpiresAt = &carbon.DateTime{Carbon: carbon.FromStdTime(*result.CertExpiry)}
+	}
+	if check.Status != status {
+		check.ChangedAt = &now
+	}
+	if err := facades.Orm().Query().Save(&check); err != nil {
+		return record, err
+	}
+
+	r.notify(check, status, lastChecked)
+
+	return record, nil
+}
+
+// Uptime 计算最近 days 天的可用率（%），无检测记录时返回 false
+func (r *WebsiteCheckImpl) Uptime(checkID uint, days int) (float64, bool) {
+	since := carbon.Now().SubDays(days).ToDateTimeString()
+
+	var total, up int64
+	if err := facades.Orm().Query().Model(&models.WebsiteCheckResult{}).Where("check_id", checkID).
+		Where("created_at > ?", since).Count(&total); err != nil || total == 0 {
+		return 0, false
+	}
+	if err := facades.Orm().Query().Model(&models.WebsiteCheckResult{}).Where("check_id", checkID).
+		Where("created_at > ?", since).Where("up", true).Count(&up); err != nil {
+		return 0, false
+	}
+
+	return float64(up) / float64(total) * 100, true
+}
+
+// Prune 删除过期的检测记录
+func (r *WebsiteCheckImpl) Prune() error {
+	_, err := facades.Orm().Query().Where("created_at < ?", carbon.Now().SubDays(websiteCheckResultDays).ToDateTimeString()).Delete(&models.WebsiteCheckResult{})
+	return err
+}
+
+// notify 状态变化和证书即将过期时发送通知
+func (r *WebsiteCheckImpl) notify(check models.WebsiteCheck, status string, lastChecked *carbon.DateTime) {
+	var err error
+	switch {
+	case check.Status == models.WebsiteCheckStatusDown && status != models.WebsiteCheckStatusDown:
+		err = r.notification.Notify(models.NotificationEventWebsiteDown, check.URL, "[故障] "+check.URL+" 无法访问",
+			fmt.Sprintf("网站 %s 连续 %d 次检测失败\n原因: %s", check.URL, check.Failures, check.LastError))
+	case check.Status == models.WebsiteCheckStatusUp && status == models.WebsiteCheckStatusDown:
+		err = r.notification.Notify(models.NotificationEventWebsiteDown, check.URL, "[恢复] "+check.URL+" 已恢复访问",
+			fmt.Sprintf("网站 %s 已恢复访问，响应时间 %d ms", check.URL, check.ResponseTime))
+	}
+	if err != nil {
+		facades.Log().Tags("面板", "网站检测").With(map[string]any{
+			"check_id": check.ID,
+			"error":    err.Error(),
+		}).Info("发送网站检测通知失败")
+	}
+
+	// 证书即将过期时每天提醒一次
+	if check.TLSWarnDays <= 0 || check.CertExpiresAt == nil {
+		return
+	}
+	if check.CertExpiresAt.Gt(carbon.Now().AddDays(check.TLSWarnDays)) {
+		return
+	}
+	if lastChecked != nil && lastChecked.IsSameDay(carbon.Now()) {
+		return
+	}
+	if err = r.notification.Notify(models.NotificationEventCertExpiring, check.URL, "证书即将过期",
+		fmt.Sprintf("网站 %s 的证书将于 %s 过期，请及时续签", check.URL, check.CertExpiresAt.ToDateTimeString())); err != nil {
+		facades.Log().Tags("面板", "网站检测").With(map[string]any{
+			"check_id": check.ID,
+			"error":    err.Error(),
+		}).Info("发送证书过期通知失败")
+	}
+}
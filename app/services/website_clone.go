@@ -0,0 +1,333 @@
+// Package services 网站克隆及预发布环境服务
+package services
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/goravel/framework/facades"
+	"github.com/goravel/framework/support/carbon"
+
+	"panel/app/models"
+	"panel/pkg/sitebundle"
+	"panel/pkg/siteclone"
+	"panel/pkg/tools"
+)
+
+// WebsiteCloneDatabase 克隆时生产环境数据库对应的新数据库
+type WebsiteCloneDatabase struct {
+	Type      string `json:"type"`
+	Source    string `json:"source"`
+	Target    string `json:"target"`
+	User      string `json:"user"`
+	Password  string `json:"password"`
+	AppConfig bool   `json:"app_config"` // 是否写入预发布环境的应用配置文件，克隆多个数据库时需指定一个
+}
+
+// WebsiteCloneTarget 克隆参数，目录为空时使用默认网站目录，PHP 为 0 时使用源网站的版本
+type WebsiteCloneTarget struct {
+	Name      string                 `json:"name"`
+	Domains   []string               `json:"domains"`
+	Path      string                 `json:"path"`
+	Php       int                    `json:"php"`
+	Replace   bool                   `json:"replace"` // 是否将数据库中的源网站域名替换为新域名
+	Databases []WebsiteCloneDatabase `json:"databases"`
+}
+
+type WebsiteClone interface {
+	List(website models.Website) ([]models.WebsiteClone, error)
+	Clone(source models.Website, target WebsiteCloneTarget) (models.WebsiteClone, error)
+	Promote(staging models.Website) (models.WebsiteClone, error)
+	Delete(website models.Website) error
+}
+
+type WebsiteCloneImpl struct {
+	setting Setting
+	website Website
+	pool    WebsitePool
+	waf     WebsiteWaf
+	task    Task
+}
+
+func NewWebsiteCloneImpl() *WebsiteCloneImpl {
+	return &WebsiteCloneImpl{
+		setting: NewSettingImpl(),
+		website: NewWebsiteImpl(),
+		pool:    NewWebsitePoolImpl(),
+		waf:     NewWebsiteWafImpl(),
+		task:    NewTaskImpl(),
+	}
+}
+
+// List 获取网站的克隆记录，包括由该网站克隆出的预发布环境和该网站自身的克隆来源
+func (r *WebsiteCloneImpl) List(website models.Website) ([]models.WebsiteClone, error) {
+	var clones []models.WebsiteClone
+	err := facades.Orm().Query().With("Website").With("Source").With("Task").
+		Where("website_id = ? OR source_id = ?", website.ID, website.ID).Order("id desc").Find(&clones)
+
+	return clones, err
+}
+
+// Clone 以新域名创建网站并复制源网站的配置，然后在后台任务中复制网站文件和数据库
+func (r *WebsiteCloneImpl) Clone(source models.Website, target WebsiteCloneTarget) (models.WebsiteClone, error) {
+	config, err := r.website.GetConfig(source.ID)
+	if err != nil {
+		return models.WebsiteClone{}, err
+	}
+	if len(config.Domains) == 0 || len(target.Domains) == 0 {
+		return models.WebsiteClone{}, errors.New("源网站或新网站未设置域名")
+	}
+	if len(target.Path) == 0 {
+		target.Path = r.setting.Get(models.SettingKeyWebsitePath) + "/" + target.Name
+	}
+	target.Path = strings.TrimSuffix(target.Path, "/")
+	if target.Php == 0 {
+		target.Php = source.Php
+	}
+	if err = r.check(target); err != nil {
+		return models.WebsiteClone{}, err
+	}
+
+	opts := siteclone.Options{
+		Source:        strings.TrimSuffix(source.Path, "/"),
+		Target:        target.Path,
+		Owner:         "www",
+		MysqlPassword: r.setting.Get(models.SettingKeyMysqlRootPassword),
+	}
+	if target.Replace {
+		opts.From, opts.To = "//"+config.Domains[0], "//"+target.Domains[0]
+	}
+	databases := make([]models.WebsiteCloneDatabase, 0, len(target.Databases))
+	for _, db := range target.Databases {
+		opts.Databases = append(opts.Databases, siteclone.Database{Type: db.Type, Source: db.Source, Target: db.Target, User: db.User, Password: db.Password, AppConfig: db.AppConfig})
+		databases = append(databases, models.WebsiteCloneDatabase{Type: db.Type, Source: db.Source, Target: db.Target, User: db.User})
+	}
+	// 先生成脚本以校验参数，避免创建网站后才发现参数错误
+	script, err := siteclone.CloneScript(opts)
+	if err != nil {
+		return models.WebsiteClone{}, err
+	}
+
+	// 证书不包含新域名，预发布环境只监听非 443 端口
+	var ports []uint
+	for _, port := range config.Ports {
+		if port != 443 {
+			ports = append(ports, port)
+		}
+	}
+	if len(ports) == 0 {
+		ports = []uint{80}
+	}
+	website, err := r.website.Add(PanelWebsite{
+		Name:    target.Name,
+		Status:  true,
+		Domains: target.Domains,
+		Ports:   ports,
+		Path:    target.Path,
+		Php:     target.Php,
+		Remark:  "克隆自 " + source.Name,
+	})
+	if err != nil {
+		return models.WebsiteClone{}, err
+	}
+	website.PhpSettings = source.PhpSettings
+	if err = facades.Orm().Query().Save(&website); err != nil {
+		return models.WebsiteClone{}, err
+	}
+	if err = r.writeConfig(source, website, config); err != nil {
+		return models.WebsiteClone{}, err
+	}
+	for _, db := range target.Databases {
+		createDatabase(r.setting, db.Type, db.Target, db.User, db.Password)
+	}
+
+	clone := models.WebsiteClone{
+		WebsiteID:  website.ID,
+		SourceID:   source.ID,
+		FromDomain: config.Domains[0],
+		ToDomain:   target.Domains[0],
+		ReplaceDB:  target.Replace,
+		Databases:  databases,
+	}
+	task, err := dispatchScript(r.task, "克隆网站-"+website.Name, "/tmp/panel-clone-"+website.Name, script)
+	if err != nil {
+		return models.WebsiteClone{}, err
+	}
+	clone.TaskID = task.ID
+	if err = facades.Orm().Query().Create(&clone); err != nil {
+		return models.WebsiteClone{}, err
+	}
+
+	facades.Log().Tags("面板", "网站克隆").With(map[string]any{
+		"source":  source.Name,
+		"website": website.Name,
+		"task":    task.ID,
+	}).Info("克隆网站")
+
+	return clone, nil
+}
+
+// Promote 将预发布环境的网站文件和数据库上线到生产环境，生产环境的数据库配置文件保持不变
+func (r *WebsiteCloneImpl) Promote(staging models.Website) (models.WebsiteClone, error) {
+	var clone models.WebsiteClone
+	if err := facades.Orm().Query().With("Source").Where("website_id", staging.ID).First(&clone); err != nil {
+		return models.WebsiteClone{}, err
+	}
+	if clone.ID == 0 {
+		return models.WebsiteClone{}, errors.New("网站不是克隆的预发布环境")
+	}
+	if clone.Source == nil || clone.Source.ID == 0 {
+		return models.WebsiteClone{}, errors.New("生产环境网站不存在")
+	}
+	production := *clone.Source
+	deploy, err := NewWebsiteDeployImpl().Get(production.ID)
+	if err != nil {
+		return models.WebsiteClone{}, err
+	}
+	if deploy.ID > 0 {
+		return models.WebsiteClone{}, errors.New("生产环境网站已配置 Git 发布，请通过发布上线")
+	}
+	backupPath := r.setting.Get(models.SettingKeyBackupPath)
+	if len(backupPath) == 0 {
+		return models.WebsiteClone{}, errors.New("未正确配置备份路径")
+	}
+
+	// 生产环境使用独立进程池时文件需归属进程池用户
+	pool, err := r.pool.Get(production.ID)
+	if err != nil {
+		return models.WebsiteClone{}, err
+	}
+	owner := "www"
+	if pool.ID > 0 {
+		owner = pool.User
+	}
+
+	opts := siteclone.Options{
+		Source:        strings.TrimSuffix(staging.Path, "/"),
+		Target:        strings.TrimSuffix(production.Path, "/"),
+		Owner:         owner,
+		MysqlPassword: r.setting.Get(models.SettingKeyMysqlRootPassword),
+	}
+	if clone.ReplaceDB {
+		opts.From, opts.To = "//"+clone.ToDomain, "//"+clone.FromDomain
+	}
+	for _, db := range clone.Databases {
+		if err = checkDatabaseServer(db.Type); err != nil {
+			return models.WebsiteClone{}, err
+		}
+		if !databaseExists(r.setting, db.Type, db.Source) {
+			return models.WebsiteClone{}, errors.New("生产环境数据库 " + db.Source + " 不存在")
+		}
+		// 重建的 PostgreSQL 数据库需归属原所有者，生产环境的应用才能继续访问
+		user := db.User
+		if db.Type == "postgresql" {
+			out, err := tools.Exec(`su - postgres -c "psql -tAc \"SELECT pg_get_userbyid(datdba) FROM pg_database WHERE datname = '` + db.Source + `'\""`)
+			if err != nil || len(strings.TrimSpace(out)) == 0 {
+				return models.WebsiteClone{}, errors.New("获取数据库 " + db.Source + " 的所有者失败")
+			}
+			user = strings.TrimSpace(out)
+		}
+		opts.Databases = append(opts.Databases, siteclone.Database{Type: db.Type, Source: db.Target, Target: db.Source, User: user})
+	}
+
+	script, err := siteclone.PromoteScript(opts, backupPath+"/promote/"+production.Name+"_"+carbon.Now().ToShortDateTimeString())
+	if err != nil {
+		return models.WebsiteClone{}, err
+	}
+	task, err := dispatchScript(r.task, "上线预发布环境-"+production.Name, "/tmp/panel-promote-"+production.Name, script)
+	if err != nil {
+		return models.WebsiteClone{}, err
+	}
+
+	now := carbon.DateTime{Carbon: carbon.Now()}
+	clone.TaskID = task.ID
+	clone.PromotedAt = &now
+	if err = facades.Orm().Query().Save(&clone); err != nil {
+		return models.WebsiteClone{}, err
+	}
+
+	facades.Log().Tags("面板", "网站克隆").With(map[string]any{
+		"staging":    staging.Name,
+		"production": production.Name,
+		"task":       task.ID,
+	}).Info("上线预发布环境")
+
+	return clone, nil
+}
+
+// Delete 删除网站作为预发布环境或生产环境的克隆记录
+func (r *WebsiteCloneImpl) Delete(website models.Website) error {
+	if _, err := facades.Orm().Query().Where("website_id", website.ID).Delete(&models.WebsiteClone{}); err != nil {
+		return err
+	}
+	_, err := facades.Orm().Query().Where("source_id", website.ID).Delete(&models.WebsiteClone{})
+
+	return err
+}
+
+// check 检查新网站的名称、目录、域名和数据库是否冲突
+func (r *WebsiteCloneImpl) check(target WebsiteCloneTarget) error {
+	var count int64
+	if err := facades.Orm().Query().Model(&models.Website{}).Where("name", target.Name).Count(&count); err != nil {
+		return err
+	}
+	if count > 0 || tools.Exists("/www/server/vhost/"+target.Name+".conf") {
+		return errors.New("网站 " + target.Name + " 已存在")
+	}
+	if tools.Exists(target.Path) && !tools.Empty(target.Path) {
+		return errors.New("网站目录 " + target.Path + " 不为空")
+	}
+	if target.Php > 0 && !tools.Exists("/www/server/php/"+strconv.Itoa(target.Php)) {
+		return errors.New("PHP-" + strconv.Itoa(target.Php) + " 未安装")
+	}
+	used, err := usedDomains()
+	if err != nil {
+		return err
+	}
+	for _, domain := range target.Domains {
+		if name, ok := used[domain]; ok {
+			return errors.New("域名 " + domain + " 已被网站 " + name + " 使用")
+		}
+	}
+	for _, db := range target.Databases {
+		if err = checkDatabaseServer(db.Type); err != nil {
+			return err
+		}
+		if !databaseExists(r.setting, db.Type, db.Source) {
+			return errors.New("数据库 " + db.Source + " 不存在")
+		}
+		if databaseExists(r.setting, db.Type, db.Target) {
+			return errors.New("数据库 " + db.Target + " 已存在")
+		}
+	}
+
+	return nil
+}
+
+// writeConfig 将源网站的配置映射到新网站后写入，证书不随网站克隆
+func (r *WebsiteCloneImpl) writeConfig(source, website models.Website, config WebsiteSetting) error {
+	setting, err := r.website.GetConfig(website.ID)
+	if err != nil {
+		return err
+	}
+	from := sitebundle.Mapping{Name: source.Name, Path: strings.TrimSuffix(source.Path, "/"), Domains: config.Domains}
+	vhost, err := remapVhost(r.pool, r.waf, sitebundle.StripSsl(config.Raw), from, website, setting.Domains)
+	if err != nil {
+		return err
+	}
+
+	if err = tools.Write("/www/server/vhost/"+website.Name+".conf", vhost, 0644); err != nil {
+		return err
+	}
+	if err = tools.Write("/www/server/vhost/rewrite/"+website.Name+".conf", config.Rewrite, 0644); err != nil {
+		return err
+	}
+	if htpasswd, err := tools.Read(htpasswdFile(source)); err == nil {
+		if err = writeHtpasswd(website, htpasswd); err != nil {
+			return err
+		}
+	}
+
+	return tools.ServiceReload("openresty")
+}
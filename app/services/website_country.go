@@ -0,0 +1,191 @@
+// Package services 网站按国家的访问控制服务
+package services
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/goravel/framework/facades"
+
+	"panel/app/models"
+	"panel/pkg/geoip"
+	"panel/pkg/tools"
+)
+
+// websiteCountryDir 网站国家规则的 geo 配置目录，由主配置文件的 http 块引入
+const websiteCountryDir = "/www/server/vhost/geo"
+
+type WebsiteCountry interface {
+	Get(websiteID uint) (models.WebsiteCountry, error)
+	Save(website models.Website, country models.WebsiteCountry) error
+	Delete(website models.Website) error
+	Apply(website models.Website) error
+	ApplyAll() error
+	Block(website models.Website) string
+}
+
+type WebsiteCountryImpl struct {
+	geoip GeoIP
+}
+
+func NewWebsiteCountryImpl() *WebsiteCountryImpl {
+	return &WebsiteCountryImpl{
+		geoip: NewGeoIPImpl(),
+	}
+}
+
+// Get 获取网站的国家规则，未开启时 ID 为 0
+func (r *WebsiteCountryImpl) Get(websiteID uint) (models.WebsiteCountry, error) {
+	var country models.WebsiteCountry
+	err := facades.Orm().Query().Where("website_id", websiteID).First(&country)
+
+	return country, err
+}
+
+// Save 开启或更新网站的国家规则
+func (r *WebsiteCountryImpl) Save(website models.Website, country models.WebsiteCountry) error {
+	if country.Mode != "allow" && country.Mode != "deny" {
+		return errors.New("模式只能为 allow 或 deny")
+	}
+	if len(country.Countries) == 0 {
+		return errors.New("请选择国家")
+	}
+	if err := geoip.ValidateCountries(country.Countries); err != nil {
+		return err
+	}
+	if country.Status < 400 || country.Status > 599 {
+		return errors.New("状态码必须在 400 到 599 之间")
+	}
+	if !tools.Exists(geoipDatabase) {
+		return errors.New("GeoIP 数据库不存在，请先上传或下载")
+	}
+
+	old, err := r.Get(website.ID)
+	if err != nil {
+		return err
+	}
+	country.ID = old.ID
+	country.WebsiteID = website.ID
+	country.CreatedAt = old.CreatedAt
+	if err = facades.Orm().Query().Save(&country); err != nil {
+		return err
+	}
+
+	return r.Apply(website)
+}
+
+// Delete 关闭网站的国家规则
+func (r *WebsiteCountryImpl) Delete(website models.Website) error {
+	country, err := r.Get(website.ID)
+	if err != nil {
+		return err
+	}
+	if country.ID == 0 {
+		return nil
+	}
+
+	if _, err = facades.Orm().Query().Delete(&country); err != nil {
+		return err
+	}
+
+	return r.Apply(website)
+}
+
+// Apply 按当前规则生成网站的 geo 配置并写入网站配置文件的拦截指令，重置网站配置后需调用
+func (r *WebsiteCountryImpl) Apply(website models.Website) error {
+	if err := r.writeGeo(website); err != nil {
+		return err
+	}
+
+	file := "/www/server/vhost/" + website.Name + ".conf"
+	if tools.Exists(file) {
+		raw, err := tools.Read(file)
+		if err != nil {
+			return err
+		}
+		if raw, err = geoip.Write(raw, r.Block(website)); err != nil {
+			return err
+		}
+		if err = tools.Write(file, raw, 0644); err != nil {
+			return err
+		}
+	}
+
+	return tools.ServiceReload("openresty")
+}
+
+// ApplyAll 按新数据库重新生成所有网站的 geo 配置
+func (r *WebsiteCountryImpl) ApplyAll() error {
+	var countries []models.WebsiteCountry
+	if err := facades.Orm().Query().With("Website").Find(&countries); err != nil {
+		return err
+	}
+	if len(countries) == 0 {
+		return nil
+	}
+
+	for _, country := range countries {
+		if country.Website == nil {
+			continue
+		}
+		if err := r.writeGeo(*country.Website); err != nil {
+			return err
+		}
+	}
+
+	return tools.ServiceReload("openresty")
+}
+
+// Block 网站配置文件中 geo 标记位内的配置
+func (r *WebsiteCountryImpl) Block(website models.Website) string {
+	country, _ := r.Get(website.ID)
+	if country.ID == 0 {
+		return ""
+	}
+
+	return geoip.Block(r.variable(website), country.Status)
+}
+
+// writeGeo 生成网站的 geo 配置，并确保主配置文件引入
+func (r *WebsiteCountryImpl) writeGeo(website models.Website) error {
+	file := websiteCountryDir + "/" + website.Name + ".conf"
+	country, err := r.Get(website.ID)
+	if err != nil {
+		return err
+	}
+	if country.ID == 0 {
+		return tools.Remove(file)
+	}
+
+	networks, err := r.geoip.Networks(country.Countries)
+	if err != nil {
+		return err
+	}
+	if err = tools.Mkdir(websiteCountryDir, 0755); err != nil {
+		return err
+	}
+	if err = tools.Write(file, "# 网站 "+website.Name+" 的国家规则："+country.Mode+" "+strconv.Itoa(len(country.Countries))+" 个国家\n"+
+		geoip.Geo(r.variable(website), country.Mode == "allow", networks), 0644); err != nil {
+		return err
+	}
+
+	conf := "/www/server/openresty/conf/nginx.conf"
+	raw, err := tools.Read(conf)
+	if err != nil {
+		return err
+	}
+	raw, changed, err := tools.NginxInclude(raw, websiteCountryDir+"/*.conf")
+	if err != nil {
+		return err
+	}
+	if changed {
+		return tools.Write(conf, raw, 0644)
+	}
+
+	return nil
+}
+
+// variable 网站是否拦截当前请求的变量名
+func (r *WebsiteCountryImpl) variable(website models.Website) string {
+	return "website_" + strconv.Itoa(int(website.ID)) + "_country_blocked"
+}
@@ -0,0 +1,357 @@
+// Package services 网站 Git 发布服务
+package services
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goravel/framework/facades"
+
+	"panel/app/models"
+	"panel/pkg/deploy"
+	"panel/pkg/tools"
+)
+
+// websiteDeployKeyPath 部署密钥及 known_hosts 的存放目录
+const websiteDeployKeyPath = "/www/server/vhost/deploy"
+
+// WebsiteReleaseInfo 发布记录及其状态
+type WebsiteReleaseInfo struct {
+	models.WebsiteRelease
+	Status  string `json:"status"`  // 发布任务的状态
+	Current bool   `json:"current"` // 是否为当前版本
+	Exists  bool   `json:"exists"`  // 版本目录是否存在，已被清理的版本无法回滚
+}
+
+type WebsiteDeploy interface {
+	Get(websiteID uint) (models.WebsiteDeploy, error)
+	Save(website models.Website, config models.WebsiteDeploy) (models.WebsiteDeploy, error)
+	Delete(website models.Website) error
+	PublicKey(website models.Website) (string, error)
+	Deploy(website models.Website, trigger string) (models.WebsiteRelease, error)
+	Releases(website models.Website) ([]WebsiteReleaseInfo, error)
+	Rollback(website models.Website, releaseID uint) error
+	Webhook(websiteID uint, header http.Header, body []byte) (models.WebsiteRelease, error)
+}
+
+type WebsiteDeployImpl struct {
+	pool WebsitePool
+	task Task
+}
+
+func NewWebsiteDeployImpl() *WebsiteDeployImpl {
+	return &WebsiteDeployImpl{
+		pool: NewWebsitePoolImpl(),
+		task: NewTaskImpl(),
+	}
+}
+
+// Get 获取网站的发布配置，未配置时 ID 为 0
+func (r *WebsiteDeployImpl) Get(websiteID uint) (models.WebsiteDeploy, error) {
+	var config models.WebsiteDeploy
+	err := facades.Orm().Query().Where("website_id", websiteID).First(&config)
+
+	return config, err
+}
+
+// Save 保存发布配置，首次保存时生成部署密钥和 Webhook 密钥，
+// 网站运行目录在首次发布成功后由发布脚本指向 current，已有版本时修改运行目录立即生效
+func (r *WebsiteDeployImpl) Save(website models.Website, config models.WebsiteDeploy) (models.WebsiteDeploy, error) {
+	old, err := r.Get(website.ID)
+	if err != nil {
+		return models.WebsiteDeploy{}, err
+	}
+	config.ID = old.ID
+	config.WebsiteID = website.ID
+	config.CreatedAt = old.CreatedAt
+	if len(config.Secret) == 0 {
+		config.Secret = old.Secret
+	}
+	if len(config.Secret) == 0 {
+		config.Secret = tools.RandomString(32)
+	}
+
+	cfg, err := r.config(website, config)
+	if err != nil {
+		return models.WebsiteDeploy{}, err
+	}
+	if err = cfg.Validate(); err != nil {
+		return models.WebsiteDeploy{}, err
+	}
+	if err = deploy.CheckLink(website.Path); err != nil {
+		return models.WebsiteDeploy{}, err
+	}
+	if err = r.generateKey(website); err != nil {
+		return models.WebsiteDeploy{}, err
+	}
+
+	if err = facades.Orm().Query().Save(&config); err != nil {
+		return models.WebsiteDeploy{}, err
+	}
+
+	root := filepath.Join(website.Path, deploy.CurrentLink, config.Root)
+	if len(deploy.Current(website.Path)) > 0 && (old.ID == 0 || old.Root != config.Root) {
+		if err = setWebsiteRoot(website, root); err != nil {
+			return models.WebsiteDeploy{}, err
+		}
+		if err = tools.ServiceReload("openresty"); err != nil {
+			return models.WebsiteDeploy{}, err
+		}
+	}
+
+	return config, nil
+}
+
+// Delete 删除发布配置、发布记录和部署密钥，网站文件和运行目录保持不变
+func (r *WebsiteDeployImpl) Delete(website models.Website) error {
+	if _, err := facades.Orm().Query().Where("website_id", website.ID).Delete(&models.WebsiteDeploy{}); err != nil {
+		return err
+	}
+	if _, err := facades.Orm().Query().Where("website_id", website.ID).Delete(&models.WebsiteRelease{}); err != nil {
+		return err
+	}
+	if err := tools.Remove(r.keyFile(website)); err != nil {
+		return err
+	}
+
+	return tools.Remove(r.keyFile(website) + ".pub")
+}
+
+// PublicKey 获取部署公钥，需添加到仓库的部署密钥中
+func (r *WebsiteDeployImpl) PublicKey(website models.Website) (string, error) {
+	if !tools.Exists(r.keyFile(website) + ".pub") {
+		return "", nil
+	}
+
+	key, err := tools.Read(r.keyFile(website) + ".pub")
+	return strings.TrimSpace(key), err
+}
+
+// Deploy 创建发布任务
+func (r *WebsiteDeployImpl) Deploy(website models.Website, trigger string) (models.WebsiteRelease, error) {
+	config, err := r.Get(website.ID)
+	if err != nil {
+		return models.WebsiteRelease{}, err
+	}
+	if config.ID == 0 {
+		return models.WebsiteRelease{}, errors.New("网站未配置 Git 发布")
+	}
+	cfg, err := r.config(website, config)
+	if err != nil {
+		return models.WebsiteRelease{}, err
+	}
+	if err = r.checkRunning(website); err != nil {
+		return models.WebsiteRelease{}, err
+	}
+
+	name := deploy.ReleaseName(time.Now())
+	if tools.Exists(filepath.Join(website.Path, deploy.ReleasesDir, name)) {
+		return models.WebsiteRelease{}, errors.New("版本 " + name + " 已存在，请稍后再试")
+	}
+	script, err := deploy.Script(cfg, name)
+	if err != nil {
+		return models.WebsiteRelease{}, err
+	}
+
+	task, err := dispatchScript(r.task, r.taskName(website), "/tmp/panel-deploy-"+website.Name+"-"+name, script)
+	if err != nil {
+		return models.WebsiteRelease{}, err
+	}
+
+	release := models.WebsiteRelease{
+		WebsiteID: website.ID,
+		Name:      name,
+		Branch:    config.Branch,
+		Trigger:   trigger,
+		TaskID:    task.ID,
+	}
+	if err = facades.Orm().Query().Create(&release); err != nil {
+		return models.WebsiteRelease{}, err
+	}
+
+	facades.Log().Tags("面板", "网站发布").With(map[string]any{
+		"website": website.Name,
+		"release": name,
+		"trigger": trigger,
+		"task":    task.ID,
+	}).Info("创建发布任务")
+
+	return release, nil
+}
+
+// Releases 获取发布记录，按时间倒序
+func (r *WebsiteDeployImpl) Releases(website models.Website) ([]WebsiteReleaseInfo, error) {
+	var releases []models.WebsiteRelease
+	if err := facades.Orm().Query().With("Task").Where("website_id", website.ID).Order("id desc").Limit(100).Find(&releases); err != nil {
+		return nil, err
+	}
+
+	current := deploy.Current(website.Path)
+	currentFound := false
+	infos := make([]WebsiteReleaseInfo, 0, len(releases))
+	for _, release := range releases {
+		dir := filepath.Join(website.Path, deploy.ReleasesDir, release.Name)
+		// 提交由发布脚本写入版本目录，发布完成后补充到记录中
+		if len(release.Commit) == 0 && tools.Exists(dir+"/REVISION") {
+			if commit, err := tools.Read(dir + "/REVISION"); err == nil {
+				release.Commit = strings.TrimSpace(commit)
+				_ = facades.Orm().Query().Save(&release)
+			}
+		}
+
+		info := WebsiteReleaseInfo{
+			WebsiteRelease: release,
+			Status:         models.TaskStatusSuccess,
+			Exists:         tools.Exists(dir),
+		}
+		if release.Task != nil {
+			info.Status = release.Task.Status
+		} else if release.TaskID > 0 {
+			info.Status = models.TaskStatusFailed
+		}
+		// 同一版本可能有多条记录（发布、回滚），只标记最新的一条
+		if release.Name == current && !currentFound {
+			info.Current = true
+			currentFound = true
+		}
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
+// Rollback 将 current 切换到之前发布成功的版本
+func (r *WebsiteDeployImpl) Rollback(website models.Website, releaseID uint) error {
+	var release models.WebsiteRelease
+	if err := facades.Orm().Query().With("Task").Where("id", releaseID).Where("website_id", website.ID).First(&release); err != nil {
+		return err
+	}
+	if release.ID == 0 {
+		return errors.New("发布记录不存在")
+	}
+	if release.TaskID > 0 && (release.Task == nil || release.Task.Status != models.TaskStatusSuccess) {
+		return errors.New("该版本未发布成功，无法回滚")
+	}
+	if release.Name == deploy.Current(website.Path) {
+		return errors.New("该版本已是当前版本")
+	}
+
+	if err := r.checkRunning(website); err != nil {
+		return err
+	}
+	if err := deploy.Activate(website.Path, release.Name); err != nil {
+		return err
+	}
+	if website.Php > 0 {
+		if err := tools.ServiceReload("php-fpm-" + strconv.Itoa(website.Php)); err != nil {
+			return err
+		}
+	}
+
+	facades.Log().Tags("面板", "网站发布").With(map[string]any{
+		"website": website.Name,
+		"release": release.Name,
+	}).Info("回滚版本")
+
+	return facades.Orm().Query().Create(&models.WebsiteRelease{
+		WebsiteID: website.ID,
+		Name:      release.Name,
+		Branch:    release.Branch,
+		Commit:    release.Commit,
+		Trigger:   models.WebsiteReleaseTriggerRollback,
+	})
+}
+
+// Webhook 处理仓库推送事件，推送的不是配置的分支时忽略，返回的记录 ID 为 0
+func (r *WebsiteDeployImpl) Webhook(websiteID uint, header http.Header, body []byte) (models.WebsiteRelease, error) {
+	config, err := r.Get(websiteID)
+	if err != nil {
+		return models.WebsiteRelease{}, err
+	}
+	if config.ID == 0 || !config.AutoDeploy {
+		return models.WebsiteRelease{}, errors.New("网站未开启自动发布")
+	}
+	if !deploy.Verify(header, body, config.Secret) {
+		return models.WebsiteRelease{}, errors.New("签名校验失败")
+	}
+	if deploy.Branch(body) != config.Branch {
+		return models.WebsiteRelease{}, nil
+	}
+
+	var website models.Website
+	if err = facades.Orm().Query().Where("id", websiteID).FirstOrFail(&website); err != nil {
+		return models.WebsiteRelease{}, err
+	}
+
+	return r.Deploy(website, models.WebsiteReleaseTriggerWebhook)
+}
+
+// config 生成发布脚本的配置
+func (r *WebsiteDeployImpl) config(website models.Website, config models.WebsiteDeploy) (deploy.Config, error) {
+	pool, err := r.pool.Get(website.ID)
+	if err != nil {
+		return deploy.Config{}, err
+	}
+	owner := "www"
+	if pool.ID > 0 {
+		owner = pool.User
+	}
+
+	return deploy.Config{
+		Path:        strings.TrimSuffix(website.Path, "/"),
+		Repository:  config.Repository,
+		Branch:      config.Branch,
+		Key:         r.keyFile(website),
+		KnownHosts:  websiteDeployKeyPath + "/known_hosts",
+		Root:        config.Root,
+		SharedDirs:  config.SharedDirs,
+		SharedFiles: config.SharedFiles,
+		Build:       config.Build,
+		PostDeploy:  config.PostDeploy,
+		Keep:        config.Keep,
+		Php:         website.Php,
+		Owner:       owner,
+		Vhost:       "/www/server/vhost/" + website.Name + ".conf",
+	}, nil
+}
+
+// checkRunning 检查网站是否有等待或正在运行的发布任务
+func (r *WebsiteDeployImpl) checkRunning(website models.Website) error {
+	var count int64
+	if err := facades.Orm().Query().Model(&models.Task{}).Where("name", r.taskName(website)).
+		Where("status IN ?", []string{models.TaskStatusWaiting, models.TaskStatusRunning}).Count(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("发布任务正在运行，请稍后再试")
+	}
+
+	return nil
+}
+
+// generateKey 生成网站的部署密钥，已存在时不重新生成
+func (r *WebsiteDeployImpl) generateKey(website models.Website) error {
+	if tools.Exists(r.keyFile(website)) {
+		return nil
+	}
+	if err := tools.Mkdir(websiteDeployKeyPath, 0700); err != nil {
+		return err
+	}
+	if out, err := tools.Exec("ssh-keygen -q -t ed25519 -N '' -C 'panel-deploy@" + website.Name + "' -f '" + r.keyFile(website) + "'"); err != nil {
+		return errors.New("生成部署密钥失败: " + out + err.Error())
+	}
+
+	return nil
+}
+
+func (r *WebsiteDeployImpl) keyFile(website models.Website) string {
+	return websiteDeployKeyPath + "/" + website.Name
+}
+
+func (r *WebsiteDeployImpl) taskName(website models.Website) string {
+	return "发布网站-" + website.Name
+}
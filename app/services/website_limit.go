@@ -0,0 +1,158 @@
+// Package services 网站请求频率、并发连接和带宽限制服务
+package services
+
+import (
+	"strings"
+
+	"github.com/goravel/framework/facades"
+
+	"panel/app/models"
+	"panel/pkg/ratelimit"
+	"panel/pkg/tools"
+)
+
+// websiteLimitZones 所有网站频率限制的共享内存定义，由主配置文件的 http 块引入
+const websiteLimitZones = "/www/server/openresty/conf/limit_zones.conf"
+
+type WebsiteLimit interface {
+	Get(websiteID uint) (models.WebsiteLimit, error)
+	Save(website models.Website, limit models.WebsiteLimit) error
+	Delete(website models.Website) error
+	Apply(website models.Website) error
+	Block(website models.Website) string
+}
+
+type WebsiteLimitImpl struct {
+}
+
+func NewWebsiteLimitImpl() *WebsiteLimitImpl {
+	return &WebsiteLimitImpl{}
+}
+
+// Get 获取网站的限制，未开启限制时 ID 为 0
+func (r *WebsiteLimitImpl) Get(websiteID uint) (models.WebsiteLimit, error) {
+	var limit models.WebsiteLimit
+	err := facades.Orm().Query().Where("website_id", websiteID).First(&limit)
+
+	return limit, err
+}
+
+// Save 开启或更新网站的限制
+func (r *WebsiteLimitImpl) Save(website models.Website, limit models.WebsiteLimit) error {
+	if err := r.limit(limit).Validate(); err != nil {
+		return err
+	}
+
+	old, err := r.Get(website.ID)
+	if err != nil {
+		return err
+	}
+	limit.ID = old.ID
+	limit.WebsiteID = website.ID
+	limit.CreatedAt = old.CreatedAt
+	if limit.Rules == nil {
+		limit.Rules = make([]ratelimit.Rule, 0)
+	}
+	if err = facades.Orm().Query().Save(&limit); err != nil {
+		return err
+	}
+
+	return r.Apply(website)
+}
+
+// Delete 关闭网站的限制
+func (r *WebsiteLimitImpl) Delete(website models.Website) error {
+	limit, err := r.Get(website.ID)
+	if err != nil {
+		return err
+	}
+	if limit.ID == 0 {
+		return nil
+	}
+
+	if _, err = facades.Orm().Query().Delete(&limit); err != nil {
+		return err
+	}
+
+	return r.Apply(website)
+}
+
+// Apply 按当前配置重新生成共享内存定义并写入网站配置文件的限制指令，重置网站配置后需调用
+func (r *WebsiteLimitImpl) Apply(website models.Website) error {
+	if err := r.writeZones(); err != nil {
+		return err
+	}
+
+	file := "/www/server/vhost/" + website.Name + ".conf"
+	if tools.Exists(file) {
+		raw, err := tools.Read(file)
+		if err != nil {
+			return err
+		}
+		if raw, err = ratelimit.Write(raw, r.Block(website)); err != nil {
+			return err
+		}
+		if err = tools.Write(file, raw, 0644); err != nil {
+			return err
+		}
+	}
+
+	return tools.ServiceReload("openresty")
+}
+
+// Block 网站配置文件中 limit 标记位内的配置
+func (r *WebsiteLimitImpl) Block(website models.Website) string {
+	limit, _ := r.Get(website.ID)
+	if limit.ID == 0 {
+		return ""
+	}
+
+	return ratelimit.Block(website.ID, r.limit(limit))
+}
+
+// writeZones 生成所有网站的共享内存定义，并确保主配置文件引入
+func (r *WebsiteLimitImpl) writeZones() error {
+	var limits []models.WebsiteLimit
+	if err := facades.Orm().Query().With("Website").Order("website_id asc").Find(&limits); err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	for _, limit := range limits {
+		if limit.Website == nil || len(limit.Rules) == 0 {
+			continue
+		}
+		sb.WriteString("# 网站 " + limit.Website.Name + "\n")
+		sb.WriteString(ratelimit.Zones(limit.WebsiteID, limit.Rules))
+	}
+	if err := tools.Write(websiteLimitZones, sb.String(), 0644); err != nil {
+		return err
+	}
+
+	conf := "/www/server/openresty/conf/nginx.conf"
+	raw, err := tools.Read(conf)
+	if err != nil {
+		return err
+	}
+	raw, changed, err := tools.NginxInclude(raw, "limit_zones.conf")
+	if err != nil {
+		return err
+	}
+	if changed {
+		return tools.Write(conf, raw, 0644)
+	}
+
+	return nil
+}
+
+// limit 转换为配置生成使用的限制
+func (r *WebsiteLimitImpl) limit(limit models.WebsiteLimit) ratelimit.Limit {
+	return ratelimit.Limit{
+		Rules:     limit.Rules,
+		Status:    limit.Status,
+		PerIP:     limit.PerIP,
+		PerServer: limit.PerServer,
+		Rate:      limit.Rate,
+		RateAfter: limit.RateAfter,
+	}
+}
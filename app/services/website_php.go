@@ -0,0 +1,206 @@
+// Package services 网站 PHP 配置服务
+package services
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/goravel/framework/facades"
+
+	"panel/app/models"
+	"panel/pkg/phpfpm"
+	"panel/pkg/tools"
+)
+
+var (
+	websitePhpFunctionsPattern = regexp.MustCompile(`^[a-zA-Z0-9_]+(,[a-zA-Z0-9_]+)*$`)
+	websitePhpExtensionPattern = regexp.MustCompile(`^[a-zA-Z0-9_ -]+$`)
+)
+
+// websitePhpUserIniKeys 可写入 .user.ini 的配置项
+var websitePhpUserIniKeys = []string{"upload_max_filesize", "post_max_size", "max_execution_time", "memory_limit", "display_errors"}
+
+type WebsitePhp interface {
+	Modules(php int) ([]string, error)
+	Check(website models.Website, php int) ([]string, error)
+	Save(website models.Website, settings models.WebsitePhpSettings) error
+	Apply(website models.Website) error
+	UserIni(website models.Website) error
+}
+
+type WebsitePhpImpl struct {
+	pool WebsitePool
+}
+
+func NewWebsitePhpImpl() *WebsitePhpImpl {
+	return &WebsitePhpImpl{
+		pool: NewWebsitePoolImpl(),
+	}
+}
+
+// Modules 获取 PHP 已安装的扩展
+func (r *WebsitePhpImpl) Modules(php int) ([]string, error) {
+	version := strconv.Itoa(php)
+	if !tools.Exists("/www/server/php/" + version + "/bin/php") {
+		return nil, errors.New("PHP-" + version + " 未安装")
+	}
+
+	out, err := tools.Exec("/www/server/php/" + version + "/bin/php -m")
+	if err != nil {
+		return nil, err
+	}
+
+	return phpfpm.ParseModules(out), nil
+}
+
+// Check 检查网站依赖的扩展在目标 PHP 版本中是否已安装，返回缺少的扩展
+func (r *WebsitePhpImpl) Check(website models.Website, php int) ([]string, error) {
+	if php == 0 {
+		return nil, nil
+	}
+
+	modules, err := r.Modules(php)
+	if err != nil {
+		return nil, err
+	}
+
+	return phpfpm.Missing(modules, website.PhpSettings.Extensions), nil
+}
+
+// Save 保存网站的 PHP 配置并应用
+func (r *WebsitePhpImpl) Save(website models.Website, settings models.WebsitePhpSettings) error {
+	for _, size := range []string{settings.UploadMaxFilesize, settings.PostMaxSize} {
+		if len(size) > 0 && !phpfpm.ValidSize(size) {
+			return errors.New("容量格式错误，如 128M")
+		}
+	}
+	if len(settings.MemoryLimit) > 0 && settings.MemoryLimit != "-1" && !phpfpm.ValidSize(settings.MemoryLimit) {
+		return errors.New("内存限制格式错误，如 256M，-1 为不限制")
+	}
+	if settings.MaxExecutionTime < 0 {
+		return errors.New("最大执行时间不能为负数")
+	}
+	if settings.DisplayErrors != "" && settings.DisplayErrors != "On" && settings.DisplayErrors != "Off" {
+		return errors.New("显示错误仅支持 On 和 Off")
+	}
+	settings.DisableFunctions = strings.ReplaceAll(settings.DisableFunctions, " ", "")
+	if len(settings.DisableFunctions) > 0 && !websitePhpFunctionsPattern.MatchString(settings.DisableFunctions) {
+		return errors.New("禁用函数格式错误，多个函数以英文逗号分隔")
+	}
+	for _, extension := range settings.Extensions {
+		if !websitePhpExtensionPattern.MatchString(extension) {
+			return errors.New("扩展名 " + extension + " 不合法")
+		}
+	}
+
+	pool, err := r.pool.Get(website.ID)
+	if err != nil {
+		return err
+	}
+	// disable_functions 只能在 php.ini 或进程池中配置
+	if len(settings.DisableFunctions) > 0 && pool.ID == 0 {
+		return errors.New("设置禁用函数需先开启独立进程池")
+	}
+
+	website.PhpSettings = settings
+	if err = facades.Orm().Query().Save(&website); err != nil {
+		return err
+	}
+
+	return r.Apply(website)
+}
+
+// Apply 应用网站的 PHP 配置，开启独立进程池时写入进程池，否则写入网站运行目录的 .user.ini
+func (r *WebsitePhpImpl) Apply(website models.Website) error {
+	if err := r.pool.Apply(website); err != nil {
+		return err
+	}
+
+	return r.UserIni(website)
+}
+
+// UserIni 更新网站运行目录 .user.ini 中的 PHP 配置，开启独立进程池时清除
+func (r *WebsitePhpImpl) UserIni(website models.Website) error {
+	pool, err := r.pool.Get(website.ID)
+	if err != nil {
+		return err
+	}
+	root := r.root(website)
+	if len(root) == 0 {
+		return nil
+	}
+
+	file := strings.TrimSuffix(root, "/") + "/.user.ini"
+	raw := ""
+	if tools.Exists(file) {
+		if raw, err = tools.Read(file); err != nil {
+			return err
+		}
+	}
+
+	values := websitePhpValues(website.PhpSettings)
+	newRaw := raw
+	for _, key := range websitePhpUserIniKeys {
+		value := values[key]
+		if pool.ID > 0 || website.Php == 0 {
+			value = ""
+		}
+		newRaw = phpfpm.SetIni(newRaw, key, value)
+	}
+	if newRaw == raw {
+		return nil
+	}
+	if len(newRaw) == 0 {
+		return tools.Remove(file)
+	}
+
+	return tools.Write(file, newRaw, 0644)
+}
+
+// root 从网站配置文件中读取运行目录
+func (r *WebsitePhpImpl) root(website models.Website) string {
+	raw, err := tools.Read("/www/server/vhost/" + website.Name + ".conf")
+	if err != nil {
+		return ""
+	}
+
+	// 网站停用时运行目录记录在注释中
+	block := tools.Cut(raw, "# root标记位开始", "# root标记位结束")
+	if match := regexp.MustCompile(`# root\s+(.+);`).FindStringSubmatch(block); len(match) == 2 {
+		return match[1]
+	}
+	if match := regexp.MustCompile(`root\s+(.+);`).FindStringSubmatch(block); len(match) == 2 {
+		return match[1]
+	}
+
+	return ""
+}
+
+// websitePhpValues 网站 PHP 配置对应的 ini 配置项
+func websitePhpValues(settings models.WebsitePhpSettings) map[string]string {
+	values := make(map[string]string)
+	if len(settings.UploadMaxFilesize) > 0 {
+		values["upload_max_filesize"] = settings.UploadMaxFilesize
+		// post_max_size 需不小于 upload_max_filesize
+		values["post_max_size"] = settings.UploadMaxFilesize
+	}
+	if len(settings.PostMaxSize) > 0 {
+		values["post_max_size"] = settings.PostMaxSize
+	}
+	if settings.MaxExecutionTime > 0 {
+		values["max_execution_time"] = strconv.Itoa(settings.MaxExecutionTime)
+	}
+	if len(settings.MemoryLimit) > 0 {
+		values["memory_limit"] = settings.MemoryLimit
+	}
+	if len(settings.DisableFunctions) > 0 {
+		values["disable_functions"] = settings.DisableFunctions
+	}
+	if len(settings.DisplayErrors) > 0 {
+		values["display_errors"] = settings.DisplayErrors
+	}
+
+	return values
+}
@@ -0,0 +1,318 @@
+// Package services 网站独立 PHP-FPM 进程池服务
+package services
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/goravel/framework/facades"
+
+	"panel/app/models"
+	"panel/pkg/phpfpm"
+	"panel/pkg/siterule"
+	"panel/pkg/tools"
+)
+
+var websitePoolUserPattern = regexp.MustCompile(`[^a-z0-9_]+`)
+
+type WebsitePool interface {
+	Get(websiteID uint) (models.WebsitePool, error)
+	List(php int) ([]models.WebsitePool, error)
+	Save(website models.Website, pool models.WebsitePool) error
+	Delete(website models.Website) error
+	Apply(website models.Website) error
+	Block(website models.Website) string
+}
+
+type WebsitePoolImpl struct {
+}
+
+func NewWebsitePoolImpl() *WebsitePoolImpl {
+	return &WebsitePoolImpl{}
+}
+
+// Get 获取网站的进程池，未开启独立进程池时 ID 为 0
+func (r *WebsitePoolImpl) Get(websiteID uint) (models.WebsitePool, error) {
+	var pool models.WebsitePool
+	err := facades.Orm().Query().Where("website_id", websiteID).First(&pool)
+
+	return pool, err
+}
+
+// List 获取使用指定 PHP 版本的网站进程池
+func (r *WebsitePoolImpl) List(php int) ([]models.WebsitePool, error) {
+	var pools []models.WebsitePool
+	if err := facades.Orm().Query().With("Website").Find(&pools); err != nil {
+		return nil, err
+	}
+
+	var result []models.WebsitePool
+	for _, pool := range pools {
+		if pool.Website != nil && pool.Website.Php == php {
+			result = append(result, pool)
+		}
+	}
+
+	return result, nil
+}
+
+// Save 开启或更新网站的独立进程池，创建运行用户并将网站目录交给该用户
+func (r *WebsitePoolImpl) Save(website models.Website, pool models.WebsitePool) error {
+	if website.Php == 0 {
+		return errors.New("网站未使用 PHP，无需独立进程池")
+	}
+	if !tools.Exists("/www/server/php/" + strconv.Itoa(website.Php)) {
+		return errors.New("PHP-" + strconv.Itoa(website.Php) + " 未安装")
+	}
+
+	old, err := r.Get(website.ID)
+	if err != nil {
+		return err
+	}
+	if len(pool.User) == 0 {
+		pool.User = old.User
+	}
+	if len(pool.User) == 0 {
+		pool.User = r.defaultUser(website)
+	}
+	if pool.User != old.User {
+		var count int64
+		if err = facades.Orm().Query().Model(&models.WebsitePool{}).Where("user", pool.User).Count(&count); err != nil {
+			return err
+		}
+		if count > 0 || r.userExists(pool.User) {
+			return errors.New("用户 " + pool.User + " 已存在，请更换运行用户")
+		}
+	}
+
+	pool.ID = old.ID
+	pool.WebsiteID = website.ID
+	pool.CreatedAt = old.CreatedAt
+	if err = r.fpmPool(website, pool).Validate(); err != nil {
+		return err
+	}
+
+	if !r.userExists(pool.User) {
+		if out, err := tools.Exec("useradd -r -M -d '" + website.Path + "' -s /sbin/nologin " + pool.User); err != nil {
+			return errors.New("创建运行用户失败: " + out + err.Error())
+		}
+	}
+	// 网站目录归运行用户所有，OpenResty 通过 www 组读取静态文件，其他开启独立进程池的网站无法访问，
+	// 但使用默认进程池的网站同样以 www 运行，仍可读取该目录，并非完全隔离
+	if err = tools.Chown(website.Path, pool.User, "www"); err != nil {
+		return err
+	}
+	if err = tools.Chmod(website.Path, 0750); err != nil {
+		return err
+	}
+
+	if err = facades.Orm().Query().Save(&pool); err != nil {
+		return err
+	}
+	if err = r.Apply(website); err != nil {
+		return err
+	}
+
+	if len(old.User) > 0 && old.User != pool.User {
+		_, _ = tools.Exec("userdel " + old.User)
+	}
+
+	return nil
+}
+
+// Delete 关闭网站的独立进程池，网站恢复使用 PHP 的默认进程池
+func (r *WebsitePoolImpl) Delete(website models.Website) error {
+	pool, err := r.Get(website.ID)
+	if err != nil {
+		return err
+	}
+	if pool.ID == 0 {
+		return nil
+	}
+
+	if _, err = facades.Orm().Query().Delete(&pool); err != nil {
+		return err
+	}
+	if err = r.Apply(website); err != nil {
+		return err
+	}
+
+	if tools.Exists(website.Path) {
+		if err = tools.Chown(website.Path, "www", "www"); err != nil {
+			return err
+		}
+		if err = tools.Chmod(website.Path, 0755); err != nil {
+			return err
+		}
+	}
+	if out, err := tools.Exec("userdel " + pool.User); err != nil {
+		return errors.New("删除运行用户失败: " + out + err.Error())
+	}
+
+	return nil
+}
+
+// Apply 按当前配置写入网站的进程池和 PHP 配置，网站切换 PHP 版本后需调用
+func (r *WebsitePoolImpl) Apply(website models.Website) error {
+	pool, err := r.Get(website.ID)
+	if err != nil {
+		return err
+	}
+
+	// 清理其他 PHP 版本下的进程池
+	version := strconv.Itoa(website.Php)
+	files, _ := filepath.Glob("/www/server/php/*/etc/php-fpm.d/" + website.Name + ".conf")
+	for _, file := range files {
+		fileVersion := filepath.Base(filepath.Dir(filepath.Dir(filepath.Dir(file))))
+		if pool.ID > 0 && fileVersion == version {
+			continue
+		}
+		if err = tools.Remove(file); err != nil {
+			return err
+		}
+		if err = tools.ServiceReload("php-fpm-" + fileVersion); err != nil {
+			return err
+		}
+	}
+
+	if pool.ID > 0 && website.Php > 0 {
+		conf := "/www/server/php/" + version + "/etc/php-fpm.conf"
+		raw, err := tools.Read(conf)
+		if err != nil {
+			return err
+		}
+		if raw, changed := phpfpm.Include(raw, "/www/server/php/"+version+"/etc/php-fpm.d/*.conf"); changed {
+			if err = tools.Write(conf, raw, 0644); err != nil {
+				return err
+			}
+		}
+		if err = tools.Mkdir("/www/server/php/"+version+"/etc/php-fpm.d", 0755); err != nil {
+			return err
+		}
+		if err = tools.Write("/www/server/php/"+version+"/etc/php-fpm.d/"+website.Name+".conf", r.fpmPool(website, pool).String(), 0644); err != nil {
+			return err
+		}
+		if err = tools.ServiceReload("php-fpm-" + version); err != nil {
+			return err
+		}
+		if err = phpFpmStatusLocation(); err != nil {
+			return err
+		}
+	}
+
+	return r.writeBlock(website)
+}
+
+// Block 网站配置文件中 PHP 标记位内的配置
+func (r *WebsitePoolImpl) Block(website models.Website) string {
+	pool, _ := r.Get(website.ID)
+	if pool.ID == 0 || website.Php == 0 {
+		return `
+    include enable-php-` + strconv.Itoa(website.Php) + `.conf;
+    `
+	}
+
+	return `
+    location ~ \.php$ {
+        try_files $uri =404;
+        fastcgi_pass unix:` + r.socket(website) + `;
+        fastcgi_index index.php;
+        include fastcgi.conf;
+        include pathinfo.conf;
+    }
+    `
+}
+
+// writeBlock 更新网站配置文件中的 PHP 配置
+func (r *WebsitePoolImpl) writeBlock(website models.Website) error {
+	file := "/www/server/vhost/" + website.Name + ".conf"
+	raw, err := tools.Read(file)
+	if err != nil {
+		return err
+	}
+
+	old := tools.Cut(raw, "# php标记位开始", "# php标记位结束")
+	block := r.Block(website)
+	if old == block || !strings.Contains(raw, "# php标记位开始"+old+"# php标记位结束") {
+		return nil
+	}
+	raw = strings.Replace(raw, "# php标记位开始"+old+"# php标记位结束", "# php标记位开始"+block+"# php标记位结束", 1)
+	// 密码访问的规则中嵌套了 PHP 配置，需同步更新
+	if strings.Contains(raw, "# auth标记位开始") {
+		if raw, err = siterule.Apply(raw, siterule.Parse(raw), htpasswdFile(website)); err != nil {
+			return err
+		}
+	}
+	if err = tools.Write(file, raw, 0644); err != nil {
+		return err
+	}
+
+	return tools.ServiceReload("openresty")
+}
+
+// fpmPool 生成进程池配置
+func (r *WebsitePoolImpl) fpmPool(website models.Website, pool models.WebsitePool) phpfpm.Pool {
+	version := strconv.Itoa(website.Php)
+	values := make(map[string]string)
+	for key, value := range pool.AdminValues {
+		values[key] = value
+	}
+	// 网站的 PHP 配置优先
+	for key, value := range websitePhpValues(website.PhpSettings) {
+		values[key] = value
+	}
+	if pool.OpenBasedir {
+		values["open_basedir"] = website.Path + ":/tmp/"
+	}
+
+	return phpfpm.Pool{
+		Name:                    website.Name,
+		User:                    pool.User,
+		Group:                   pool.User,
+		Listen:                  r.socket(website),
+		ListenOwner:             "www",
+		ListenGroup:             "www",
+		PM:                      pool.PM,
+		MaxChildren:             pool.MaxChildren,
+		StartServers:            pool.StartServers,
+		MinSpareServers:         pool.MinSpareServers,
+		MaxSpareServers:         pool.MaxSpareServers,
+		MaxRequests:             pool.MaxRequests,
+		IdleTimeout:             pool.IdleTimeout,
+		RequestTerminateTimeout: 100,
+		RequestSlowlogTimeout:   30,
+		Slowlog:                 "var/log/slow-" + website.Name + ".log",
+		StatusPath:              "/phpfpm_status/" + version + "/" + website.Name,
+		AdminValues:             values,
+	}
+}
+
+// socket 进程池的监听地址
+func (r *WebsitePoolImpl) socket(website models.Website) string {
+	return "/tmp/php-cgi-" + strconv.Itoa(website.Php) + "-" + website.Name + ".sock"
+}
+
+// defaultUser 根据网站名生成运行用户名
+func (r *WebsitePoolImpl) defaultUser(website models.Website) string {
+	user := "site_" + strings.Trim(websitePoolUserPattern.ReplaceAllString(strings.ToLower(website.Name), "_"), "_")
+	if len(user) > 32 {
+		user = user[:32]
+	}
+
+	var count int64
+	_ = facades.Orm().Query().Model(&models.WebsitePool{}).Where("user", user).Count(&count)
+	if count > 0 || r.userExists(user) {
+		user = "site_" + strconv.Itoa(int(website.ID))
+	}
+
+	return user
+}
+
+// userExists 系统用户是否存在
+func (r *WebsitePoolImpl) userExists(user string) bool {
+	_, err := tools.Exec("id -u " + user)
+	return err == nil
+}
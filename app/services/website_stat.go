@@ -0,0 +1,264 @@
+// Package services 网站资源使用统计服务
+package services
+
+import (
+	"time"
+
+	"github.com/goravel/framework/contracts/database/orm"
+	"github.com/goravel/framework/facades"
+	"github.com/goravel/framework/support/carbon"
+
+	"panel/app/models"
+	"panel/pkg/accesslog"
+	"panel/pkg/procstat"
+	"panel/pkg/tools"
+)
+
+const (
+	// websiteStatDays 每日统计保留天数
+	websiteStatDays = 365
+	// websiteStatReadLimit 每次最多读取的访问日志字节数，避免日志过大时单次统计耗时过长
+	websiteStatReadLimit = 64 << 20
+)
+
+// WebsiteStatSummary 网站资源使用概览
+type WebsiteStatSummary struct {
+	Requests     uint64  `json:"requests"` // 今日请求数
+	Bytes        uint64  `json:"bytes"`    // 今日流量（字节）
+	Requests30d  uint64  `json:"requests_30d"`
+	Bytes30d     uint64  `json:"bytes_30d"`
+	PhpProcesses int     `json:"php_processes"`
+	PhpCPU       float64 `json:"php_cpu"`
+	PhpRSS       uint64  `json:"php_rss"`
+	DiskUsage    uint64  `json:"disk_usage"`
+}
+
+type WebsiteStat interface {
+	Collect(interval time.Duration) error
+	Summary(ids []uint) (map[uint]WebsiteStatSummary, error)
+	Daily(websiteID uint, start, end string) ([]models.WebsiteStat, error)
+	Delete(websiteID uint) error
+	Prune() error
+}
+
+type WebsiteStatImpl struct {
+}
+
+func NewWebsiteStatImpl() *WebsiteStatImpl {
+	return &WebsiteStatImpl{}
+}
+
+// Collect 统计所有网站的访问日志、PHP-FPM 进程池和目录占用，interval 为进程采样时长
+func (r *WebsiteStatImpl) Collect(interval time.Duration) error {
+	var websites []models.Website
+	if err := facades.Orm().Query().Find(&websites); err != nil {
+		return err
+	}
+	if len(websites) == 0 {
+		return nil
+	}
+
+	processes, usages, err := procstat.Sample(interval)
+	if err != nil {
+		return err
+	}
+
+	now := carbon.Now()
+	for _, website := range websites {
+		days, cursor, err := r.readLog(website)
+		if err != nil {
+			facades.Log().Tags("面板", "网站统计").With(map[string]any{
+				"website": website.Name,
+				"error":   err.Error(),
+			}).Info("读取访问日志失败")
+		}
+
+		// 目录统计耗时较长，在事务外完成，缩短事务占用数据库的时间
+		diskUsage := r.diskUsage(website, now)
+
+		// 统计和日志读取位置在同一事务中保存，避免保存失败时丢失或重复统计
+		if err = facades.Orm().Transaction(func(tx orm.Transaction) error {
+			return r.save(tx, website, days, cursor, processes, usages, diskUsage, now)
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// diskUsage 统计网站目录占用，每小时统计一次，无需统计或统计失败时返回 0
+func (r *WebsiteStatImpl) diskUsage(website models.Website, now carbon.Carbon) uint64 {
+	stat, err := r.get(facades.Orm().Query(), website.ID, now.ToDateString())
+	if err != nil {
+		return 0
+	}
+	if stat.DiskUsage > 0 && !stat.UpdatedAt.IsZero() && stat.UpdatedAt.Hour() == now.Hour() {
+		return 0
+	}
+	size, err := tools.Size(website.Path)
+	if err != nil {
+		return 0
+	}
+
+	return uint64(size)
+}
+
+// save 保存网站的访问统计、进程池和目录占用，cursor 为空时不更新日志读取位置，diskUsage 为 0 时不更新目录占用
+func (r *WebsiteStatImpl) save(tx orm.Transaction, website models.Website, days map[string]accesslog.Stats, cursor *models.WebsiteStatCursor, processes map[int32]procstat.Process, usages map[int32]procstat.Usage, diskUsage uint64, now carbon.Carbon) error {
+	today := now.ToDateString()
+	stat, err := r.get(tx, website.ID, today)
+	if err != nil {
+		return err
+	}
+	r.merge(&stat, days[today])
+
+	if website.Php > 0 {
+		pool := procstat.Aggregate(website.Name, procstat.Pool(processes, usages, website.Name))
+		stat.PhpCPU = (stat.PhpCPU*float64(stat.PhpSamples) + pool.CPU) / float64(stat.PhpSamples+1)
+		stat.PhpSamples++
+		if pool.Processes > stat.PhpProcesses {
+			stat.PhpProcesses = pool.Processes
+		}
+		if pool.RSS > stat.PhpRSS {
+			stat.PhpRSS = pool.RSS
+		}
+	}
+
+	if diskUsage > 0 {
+		stat.DiskUsage = diskUsage
+	}
+
+	for date, stats := range days {
+		if date == today {
+			continue
+		}
+		past, err := r.get(tx, website.ID, date)
+		if err != nil {
+			return err
+		}
+		r.merge(&past, stats)
+		if err = tx.Save(&past); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Save(&stat); err != nil {
+		return err
+	}
+	if cursor != nil {
+		return tx.Save(cursor)
+	}
+
+	return nil
+}
+
+// Summary 获取网站今日和最近 30 天的资源使用概览
+func (r *WebsiteStatImpl) Summary(ids []uint) (map[uint]WebsiteStatSummary, error) {
+	result := make(map[uint]WebsiteStatSummary)
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	var stats []models.WebsiteStat
+	if err := facades.Orm().Query().Where("website_id IN ?", ids).
+		Where("date > ?", carbon.Now().SubDays(30).ToDateString()).Order("date asc").Find(&stats); err != nil {
+		return nil, err
+	}
+
+	today := carbon.Now().ToDateString()
+	for _, stat := range stats {
+		summary := result[stat.WebsiteID]
+		summary.Requests30d += stat.Requests
+		summary.Bytes30d += stat.Bytes
+		// 按日期升序，目录占用取最近一次统计
+		if stat.DiskUsage > 0 {
+			summary.DiskUsage = stat.DiskUsage
+		}
+		if stat.Date == today {
+			summary.Requests = stat.Requests
+			summary.Bytes = stat.Bytes
+			summary.PhpProcesses = stat.PhpProcesses
+			summary.PhpCPU = stat.PhpCPU
+			summary.PhpRSS = stat.PhpRSS
+		}
+		result[stat.WebsiteID] = summary
+	}
+
+	return result, nil
+}
+
+// Daily 获取网站在日期范围内的每日统计，日期格式为 2006-01-02
+func (r *WebsiteStatImpl) Daily(websiteID uint, start, end string) ([]models.WebsiteStat, error) {
+	var stats []models.WebsiteStat
+	err := facades.Orm().Query().Where("website_id", websiteID).Where("date >= ?", start).
+		Where("date <= ?", end).Order("date asc").Find(&stats)
+
+	return stats, err
+}
+
+// Delete 删除网站的统计数据
+func (r *WebsiteStatImpl) Delete(websiteID uint) error {
+	if _, err := facades.Orm().Query().Where("website_id", websiteID).Delete(&models.WebsiteStat{}); err != nil {
+		return err
+	}
+
+	_, err := facades.Orm().Query().Where("website_id", websiteID).Delete(&models.WebsiteStatCursor{})
+	return err
+}
+
+// Prune 删除过期的每日统计
+func (r *WebsiteStatImpl) Prune() error {
+	_, err := facades.Orm().Query().Where("date < ?", carbon.Now().SubDays(websiteStatDays).ToDateString()).Delete(&models.WebsiteStat{})
+	return err
+}
+
+// readLog 读取网站新增的访问日志，按日期汇总，返回读取后的日志位置，由调用方与统计一起保存
+func (r *WebsiteStatImpl) readLog(website models.Website) (map[string]accesslog.Stats, *models.WebsiteStatCursor, error) {
+	var cursor models.WebsiteStatCursor
+	if err := facades.Orm().Query().Where("website_id", website.ID).First(&cursor); err != nil {
+		return nil, nil, err
+	}
+	cursor.WebsiteID = website.ID
+
+	days := make(map[string]accesslog.Stats)
+	position, err := accesslog.Read("/www/wwwlogs/"+website.Name+".log", accesslog.Cursor{
+		Inode:  cursor.Inode,
+		Offset: cursor.Position,
+	}, websiteStatReadLimit, func(entry accesslog.Entry) {
+		date := carbon.FromStdTime(entry.Time).ToDateString()
+		stats := days[date]
+		stats.Add(entry)
+		days[date] = stats
+	})
+	if err != nil {
+		// 读取失败时不更新位置，本次读到的部分也不计入，下次从原位置重新读取
+		return nil, nil, err
+	}
+
+	cursor.Inode = position.Inode
+	cursor.Position = position.Offset
+	return days, &cursor, nil
+}
+
+// get 获取网站某日的统计，不存在时返回新记录
+func (r *WebsiteStatImpl) get(query orm.Query, websiteID uint, date string) (models.WebsiteStat, error) {
+	var stat models.WebsiteStat
+	if err := query.Where("website_id", websiteID).Where("date", date).First(&stat); err != nil {
+		return stat, err
+	}
+	stat.WebsiteID = websiteID
+	stat.Date = date
+
+	return stat, nil
+}
+
+// merge 合并访问统计
+func (r *WebsiteStatImpl) merge(stat *models.WebsiteStat, stats accesslog.Stats) {
+	stat.Requests += stats.Requests
+	stat.Bytes += stats.Bytes
+	stat.Status2xx += stats.Status2xx
+	stat.Status3xx += stats.Status3xx
+	stat.Status4xx += stats.Status4xx
+	stat.Status5xx += stats.Status5xx
+}
@@ -0,0 +1,181 @@
+// Package services 网站到期和暂停服务
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/goravel/framework/facades"
+	"github.com/goravel/framework/support/carbon"
+
+	"panel/app/models"
+	"panel/pkg/sitestatus"
+	"panel/pkg/tools"
+)
+
+// defaultExpiryRemind 未设置时网站到期前提醒的天数
+const defaultExpiryRemind = "7,3,1"
+
+type WebsiteSuspension interface {
+	Status(website models.Website, status bool) error
+	Suspend(website models.Website) error
+	Resume(website models.Website) error
+	SetExpiry(website models.Website, expiresAt *carbon.DateTime) error
+	Extend(website models.Website, days int) error
+	Check() error
+}
+
+type WebsiteSuspensionImpl struct {
+	setting      Setting
+	notification Notification
+}
+
+func NewWebsiteSuspensionImpl() *WebsiteSuspensionImpl {
+	return &WebsiteSuspensionImpl{
+		setting:      NewSettingImpl(),
+		notification: NewNotificationImpl(),
+	}
+}
+
+// Status 启用或停用网站，已到期的网站需先续期才能启用
+func (r *WebsiteSuspensionImpl) Status(website models.Website, status bool) error {
+	if status && websiteExpired(website) {
+		return errors.New("网站 " + website.Name + " 已到期，请先续期")
+	}
+
+	var err error
+	if status {
+		err = r.write(website, sitestatus.Start)
+	} else {
+		err = r.write(website, func(conf string) (string, error) {
+			return sitestatus.Stop(conf, sitestatus.StopPage)
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	website.Status = status
+	website.Suspended = false
+	return facades.Orm().Query().Save(&website)
+}
+
+// Suspend 暂停网站，访问时显示暂停页
+func (r *WebsiteSuspensionImpl) Suspend(website models.Website) error {
+	page := sitestatus.Root + "/" + sitestatus.SuspendedPage
+	if !tools.Exists(page) {
+		if err := tools.Write(page, sitestatus.DefaultSuspendedPage, 0644); err != nil {
+			return err
+		}
+	}
+
+	if err := r.write(website, func(conf string) (string, error) {
+		return sitestatus.Stop(conf, sitestatus.SuspendedPage)
+	}); err != nil {
+		return err
+	}
+
+	website.Status = false
+	website.Suspended = true
+	return facades.Orm().Query().Save(&website)
+}
+
+// Resume 恢复暂停的网站
+func (r *WebsiteSuspensionImpl) Resume(website models.Website) error {
+	return r.Status(website, true)
+}
+
+// SetExpiry 设置网站的到期时间，为空时永不到期，因到期暂停的网站设置为未到期后自动恢复
+func (r *WebsiteSuspensionImpl) SetExpiry(website models.Website, expiresAt *carbon.DateTime) error {
+	if expiresAt != nil {
+		expiresAt = &carbon.DateTime{Carbon: expiresAt.EndOfDay()}
+	}
+	website.ExpiresAt = expiresAt
+	if err := facades.Orm().Query().Save(&website); err != nil {
+		return err
+	}
+	if website.Suspended && !websiteExpired(website) {
+		return r.Resume(website)
+	}
+
+	return nil
+}
+
+// Extend 将网站的到期时间延长 days 天，已到期或未设置到期时间的网站从今天起计算
+func (r *WebsiteSuspensionImpl) Extend(website models.Website, days int) error {
+	if days <= 0 {
+		return errors.New("续期天数必须大于 0")
+	}
+
+	start := carbon.Now()
+	if website.ExpiresAt != nil && website.ExpiresAt.Gt(start) {
+		start = website.ExpiresAt.Carbon
+	}
+
+	return r.SetExpiry(website, &carbon.DateTime{Carbon: start.AddDays(days)})
+}
+
+// Check 暂停已到期的网站，并在到期前的指定天数发送提醒
+func (r *WebsiteSuspensionImpl) Check() error {
+	var websites []models.Website
+	if err := facades.Orm().Query().Where("expires_at IS NOT NULL").Find(&websites); err != nil {
+		return err
+	}
+
+	remind := make(map[int64]bool)
+	for _, day := range strings.Split(r.setting.Get(models.SettingKeyWebsiteExpiryRemind, defaultExpiryRemind), ",") {
+		if value, err := strconv.ParseInt(strings.TrimSpace(day), 10, 64); err == nil && value > 0 {
+			remind[value] = true
+		}
+	}
+
+	today := carbon.Now().StartOfDay()
+	for _, website := range websites {
+		if websiteExpired(website) {
+			if website.Suspended {
+				continue
+			}
+			if err := r.Suspend(website); err != nil {
+				facades.Log().Tags("面板", "网站管理").With(map[string]any{
+					"website_id": website.ID,
+					"error":      err.Error(),
+				}).Info("暂停到期网站失败")
+				continue
+			}
+			_ = r.notification.Notify(models.NotificationEventWebsiteExpired, website.Name, "网站到期暂停", fmt.Sprintf("网站 %s 已于 %s 到期，已自动暂停", website.Name, website.ExpiresAt.ToDateString()))
+			continue
+		}
+
+		days := today.DiffInDays(website.ExpiresAt.StartOfDay())
+		if remind[days] {
+			_ = r.notification.Notify(models.NotificationEventWebsiteExpiring, website.Name, "网站即将到期", fmt.Sprintf("网站 %s 将于 %s 到期，剩余 %d 天，到期后将自动暂停", website.Name, website.ExpiresAt.ToDateString(), days))
+		}
+	}
+
+	return nil
+}
+
+// write 修改网站配置文件的运行目录和默认文件并重载 OpenResty
+func (r *WebsiteSuspensionImpl) write(website models.Website, change func(conf string) (string, error)) error {
+	file := "/www/server/vhost/" + website.Name + ".conf"
+	raw, err := tools.Read(file)
+	if err != nil {
+		return err
+	}
+	raw, err = change(raw)
+	if err != nil {
+		return err
+	}
+	if err = tools.Write(file, raw, 0644); err != nil {
+		return err
+	}
+
+	return tools.ServiceReload("openresty")
+}
+
+// websiteExpired 网站是否已到期
+func websiteExpired(website models.Website) bool {
+	return website.ExpiresAt != nil && website.ExpiresAt.Lt(carbon.Now())
+}
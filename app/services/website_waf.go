@@ -0,0 +1,290 @@
+// Package services 网站 WAF 规则与拦截日志服务
+package services
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/goravel/framework/facades"
+
+	"panel/app/models"
+	"panel/pkg/tools"
+	"panel/pkg/waf"
+)
+
+// websiteWafLogFormat 拦截日志格式的定义，由主配置文件的 http 块引入
+const websiteWafLogFormat = "/www/server/openresty/conf/waf_log.conf"
+
+type WebsiteWaf interface {
+	Get(websiteID uint) (models.WebsiteWaf, error)
+	Save(website models.Website, rules waf.Rules) error
+	Delete(website models.Website) error
+	Apply(website models.Website) error
+	Write(website models.Website, conf string) (string, error)
+	Log(website models.Website) (waf.Summary, error)
+	ClearLog(website models.Website) error
+	GlobalRules() (map[string]string, error)
+	SaveGlobalRule(name, content string) error
+}
+
+type WebsiteWafImpl struct {
+}
+
+func NewWebsiteWafImpl() *WebsiteWafImpl {
+	return &WebsiteWafImpl{}
+}
+
+// Get 获取网站的规则，未设置网站规则时 ID 为 0
+func (r *WebsiteWafImpl) Get(websiteID uint) (models.WebsiteWaf, error) {
+	var rules models.WebsiteWaf
+	err := facades.Orm().Query().Where("website_id", websiteID).First(&rules)
+
+	return rules, err
+}
+
+// Save 保存网站的规则，规则为空时网站使用全局规则
+func (r *WebsiteWafImpl) Save(website models.Website, rules waf.Rules) error {
+	if err := rules.Validate(); err != nil {
+		return err
+	}
+
+	old, err := r.Get(website.ID)
+	if err != nil {
+		return err
+	}
+	if err = r.save(website, old, rules); err != nil {
+		return err
+	}
+	if err = r.Apply(website); err != nil {
+		// 规则未通过 OpenResty 配置检查时恢复
+		if old.ID > 0 {
+			_ = r.save(website, old, old.Rules)
+		} else {
+			_, _ = facades.Orm().Query().Where("website_id", website.ID).Delete(&models.WebsiteWaf{})
+		}
+		_ = r.Apply(website)
+		return err
+	}
+
+	return nil
+}
+
+// Delete 删除网站时删除网站的规则和拦截日志
+func (r *WebsiteWafImpl) Delete(website models.Website) error {
+	if _, err := facades.Orm().Query().Where("website_id", website.ID).Delete(&models.WebsiteWaf{}); err != nil {
+		return err
+	}
+	if err := tools.Remove(r.rulePath(website)); err != nil {
+		return err
+	}
+
+	return tools.Remove(r.logFile(website))
+}
+
+// Apply 生成网站的规则目录并更新网站配置文件，检查配置通过后重载 OpenResty
+func (r *WebsiteWafImpl) Apply(website models.Website) error {
+	rules, err := r.Get(website.ID)
+	if err != nil {
+		return err
+	}
+	if rules.ID > 0 && !rules.Rules.Empty() {
+		global, err := r.GlobalRules()
+		if err != nil {
+			return err
+		}
+		if err = r.writeRules(website, waf.Merge(global, rules.Rules)); err != nil {
+			return err
+		}
+	} else if err = tools.Remove(r.rulePath(website)); err != nil {
+		return err
+	}
+
+	file := "/www/server/vhost/" + website.Name + ".conf"
+	raw, err := tools.Read(file)
+	if err != nil {
+		return err
+	}
+	if raw, err = r.Write(website, raw); err != nil {
+		return err
+	}
+	if err = tools.Write(file, raw, 0644); err != nil {
+		return err
+	}
+	if err = r.test(); err != nil {
+		return err
+	}
+
+	return tools.ServiceReload("openresty")
+}
+
+// Write 将网站的规则目录和拦截日志写入配置文件的 waf 标记位，并确保主配置文件引入了拦截日志格式
+func (r *WebsiteWafImpl) Write(website models.Website, conf string) (string, error) {
+	if err := r.writeLogFormat(); err != nil {
+		return "", err
+	}
+
+	rulePath := waf.GlobalRulePath
+	if tools.Exists(r.rulePath(website)) {
+		rulePath = r.rulePath(website)
+	}
+
+	return waf.Write(conf, rulePath, r.logFile(website))
+}
+
+// Log 统计网站的拦截日志
+func (r *WebsiteWafImpl) Log(website models.Website) (waf.Summary, error) {
+	file, err := os.Open(r.logFile(website))
+	if os.IsNotExist(err) {
+		return waf.Summarize(strings.NewReader(""), 0, 0)
+	}
+	if err != nil {
+		return waf.Summary{}, err
+	}
+	defer file.Close()
+
+	return waf.Summarize(file, 100, 20)
+}
+
+// ClearLog 清空网站的拦截日志
+func (r *WebsiteWafImpl) ClearLog(website models.Website) error {
+	if !tools.Exists(r.logFile(website)) {
+		return nil
+	}
+
+	return os.Truncate(r.logFile(website), 0)
+}
+
+// GlobalRules 获取全局规则文件
+func (r *WebsiteWafImpl) GlobalRules() (map[string]string, error) {
+	rules := make(map[string]string)
+	for _, name := range waf.Files {
+		content, err := tools.Read(waf.GlobalRulePath + name)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		rules[name] = content
+	}
+
+	return rules, nil
+}
+
+// SaveGlobalRule 保存全局规则文件，并重新生成使用网站规则的网站的规则目录，配置检查未通过时恢复
+func (r *WebsiteWafImpl) SaveGlobalRule(name, content string) error {
+	if err := waf.ValidateFile(name, content); err != nil {
+		return err
+	}
+
+	old, err := tools.Read(waf.GlobalRulePath + name)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err = tools.Write(waf.GlobalRulePath+name, content, 0644); err != nil {
+		return err
+	}
+	if err = r.applyAll(); err == nil {
+		err = r.test()
+	}
+	if err != nil {
+		_ = tools.Write(waf.GlobalRulePath+name, old, 0644)
+		_ = r.applyAll()
+		return err
+	}
+
+	return tools.ServiceReload("openresty")
+}
+
+// applyAll 重新生成所有网站的规则目录
+func (r *WebsiteWafImpl) applyAll() error {
+	var rules []models.WebsiteWaf
+	if err := facades.Orm().Query().With("Website").Find(&rules); err != nil {
+		return err
+	}
+
+	global, err := r.GlobalRules()
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		if rule.Website == nil || rule.Rules.Empty() {
+			continue
+		}
+		if err = r.writeRules(*rule.Website, waf.Merge(global, rule.Rules)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// save 写入网站规则的记录
+func (r *WebsiteWafImpl) save(website models.Website, old models.WebsiteWaf, rules waf.Rules) error {
+	record := models.WebsiteWaf{
+		ID:        old.ID,
+		WebsiteID: website.ID,
+		Rules:     rules,
+		CreatedAt: old.CreatedAt,
+	}
+
+	return facades.Orm().Query().Save(&record)
+}
+
+// writeRules 写入网站的规则目录
+func (r *WebsiteWafImpl) writeRules(website models.Website, files map[string]string) error {
+	if err := tools.Mkdir(r.rulePath(website), 0755); err != nil {
+		return err
+	}
+	for name, content := range files {
+		if err := tools.Write(r.rulePath(website)+name, content, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeLogFormat 确保拦截日志格式已定义并被主配置文件引入
+func (r *WebsiteWafImpl) writeLogFormat() error {
+	if !tools.Exists(websiteWafLogFormat) {
+		if err := tools.Write(websiteWafLogFormat, waf.LogFormat+"\n", 0644); err != nil {
+			return err
+		}
+	}
+	if err := tools.Mkdir("/www/wwwlogs/waf", 0755); err != nil {
+		return err
+	}
+
+	conf := "/www/server/openresty/conf/nginx.conf"
+	raw, err := tools.Read(conf)
+	if err != nil {
+		return err
+	}
+	raw, changed, err := tools.NginxInclude(raw, "waf_log.conf")
+	if err != nil {
+		return err
+	}
+	if changed {
+		return tools.Write(conf, raw, 0644)
+	}
+
+	return nil
+}
+
+// test 检查 OpenResty 配置
+func (r *WebsiteWafImpl) test() error {
+	if _, err := tools.Exec("openresty -t"); err != nil {
+		return errors.New("OpenResty 配置检查未通过: " + err.Error())
+	}
+
+	return nil
+}
+
+// rulePath 网站的规则目录
+func (r *WebsiteWafImpl) rulePath(website models.Website) string {
+	return "/www/server/vhost/waf/" + website.Name + "/"
+}
+
+// logFile 网站的拦截日志
+func (r *WebsiteWafImpl) logFile(website models.Website) string {
+	return "/www/wwwlogs/waf/" + website.Name + ".log"
+}
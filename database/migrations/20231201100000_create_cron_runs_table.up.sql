@@ -0,0 +1,17 @@
+CREATE TABLE cron_runs
+(
+    id          integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    cron_id     integer                           NOT NULL,
+    status      varchar(255)                      NOT NULL,
+    exit_code   integer      DEFAULT NULL,
+    pid         integer      DEFAULT 0            NOT NULL,
+    output      text         DEFAULT ''           NOT NULL,
+    duration    integer      DEFAULT 0            NOT NULL,
+    started_at  datetime                          NOT NULL,
+    ended_at    datetime     DEFAULT NULL,
+    created_at  datetime                          NOT NULL,
+    updated_at  datetime                          NOT NULL
+);
+
+CREATE INDEX cron_runs_cron_id_index ON cron_runs (cron_id);
+CREATE INDEX cron_runs_status_index ON cron_runs (status);
@@ -0,0 +1,3 @@
+ALTER TABLE crons DROP COLUMN timeout;
+ALTER TABLE crons DROP COLUMN overlap;
+ALTER TABLE crons DROP COLUMN notify;
@@ -0,0 +1,3 @@
+ALTER TABLE crons ADD COLUMN timeout integer DEFAULT 0 NOT NULL;
+ALTER TABLE crons ADD COLUMN overlap boolean DEFAULT 0 NOT NULL;
+ALTER TABLE crons ADD COLUMN notify boolean DEFAULT 1 NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE crons DROP COLUMN config;
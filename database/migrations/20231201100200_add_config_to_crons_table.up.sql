@@ -0,0 +1 @@
+ALTER TABLE crons ADD COLUMN config text DEFAULT '{}' NOT NULL;
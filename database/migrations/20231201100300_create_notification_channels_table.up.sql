@@ -0,0 +1,13 @@
+CREATE TABLE notification_channels
+(
+    id         integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name       varchar(255)                      NOT NULL,
+    type       varchar(255)                      NOT NULL,
+    config     text         DEFAULT '{}'         NOT NULL,
+    events     text         DEFAULT '[]'         NOT NULL,
+    enabled    boolean      DEFAULT 1            NOT NULL,
+    created_at datetime                          NOT NULL,
+    updated_at datetime                          NOT NULL
+);
+
+CREATE UNIQUE INDEX notification_channels_name_unique ON notification_channels (name);
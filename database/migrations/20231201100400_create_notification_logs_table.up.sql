@@ -0,0 +1,17 @@
+CREATE TABLE notification_logs
+(
+    id         integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    channel_id integer                           NOT NULL,
+    event      varchar(255)                      NOT NULL,
+    subject    varchar(255) DEFAULT ''           NOT NULL,
+    title      varchar(255)                      NOT NULL,
+    content    text         DEFAULT ''           NOT NULL,
+    status     varchar(255)                      NOT NULL,
+    error      text         DEFAULT ''           NOT NULL,
+    created_at datetime                          NOT NULL,
+    updated_at datetime                          NOT NULL
+);
+
+CREATE INDEX notification_logs_channel_id_index ON notification_logs (channel_id);
+CREATE INDEX notification_logs_event_index ON notification_logs (event);
+CREATE INDEX notification_logs_created_at_index ON notification_logs (created_at);
@@ -0,0 +1,16 @@
+CREATE TABLE alert_rules
+(
+    id         integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name       varchar(255)                      NOT NULL,
+    metric     varchar(255)                      NOT NULL,
+    target     varchar(255) DEFAULT ''           NOT NULL,
+    operator   varchar(255)                      NOT NULL,
+    threshold  real                              NOT NULL,
+    duration   integer      DEFAULT 0            NOT NULL,
+    enabled    boolean      DEFAULT 1            NOT NULL,
+    state      text         DEFAULT '{}'         NOT NULL,
+    created_at datetime                          NOT NULL,
+    updated_at datetime                          NOT NULL
+);
+
+CREATE UNIQUE INDEX alert_rules_name_unique ON alert_rules (name);
@@ -0,0 +1,12 @@
+CREATE TABLE alert_silences
+(
+    id         integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    rule_id    integer      DEFAULT 0            NOT NULL,
+    reason     varchar(255) DEFAULT ''           NOT NULL,
+    start_at   datetime                          NOT NULL,
+    end_at     datetime                          NOT NULL,
+    created_at datetime                          NOT NULL,
+    updated_at datetime                          NOT NULL
+);
+
+CREATE INDEX alert_silences_rule_id_index ON alert_silences (rule_id);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS monitor_points;
+
+CREATE TABLE monitors
+(
+    id         integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    info       text                              NOT NULL,
+    created_at datetime                          NOT NULL,
+    updated_at datetime                          NOT NULL
+);
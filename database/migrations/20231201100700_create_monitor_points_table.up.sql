@@ -0,0 +1,49 @@
+CREATE TABLE monitor_points
+(
+    id         integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    resolution integer                           NOT NULL,
+    metric     varchar(255)                      NOT NULL,
+    time       integer                           NOT NULL,
+    min        real                              NOT NULL,
+    avg        real                              NOT NULL,
+    max        real                              NOT NULL,
+    count      integer      DEFAULT 1            NOT NULL
+);
+
+CREATE UNIQUE INDEX monitor_points_resolution_metric_time_unique ON monitor_points (resolution, metric, time);
+CREATE INDEX monitor_points_resolution_time_index ON monitor_points (resolution, time);
+
+-- 将旧的分钟级监控数据迁移为数据点，累计计数类指标缺少上一次采样，无法计算速率，不迁移
+INSERT OR IGNORE INTO monitor_points (resolution, metric, time, min, avg, max, count)
+SELECT 60, metric, time - time % 60, value, value, value, 1
+FROM (SELECT 'cpu' AS metric, CAST(strftime('%s', created_at, 'utc') AS integer) AS time, json_extract(info, '$.percent[0]') AS value FROM monitors
+      UNION ALL
+      SELECT 'load1', CAST(strftime('%s', created_at, 'utc') AS integer), json_extract(info, '$.load.load1') FROM monitors
+      UNION ALL
+      SELECT 'load5', CAST(strftime('%s', created_at, 'utc') AS integer), json_extract(info, '$.load.load5') FROM monitors
+      UNION ALL
+      SELECT 'load15', CAST(strftime('%s', created_at, 'utc') AS integer), json_extract(info, '$.load.load15') FROM monitors
+      UNION ALL
+      SELECT 'mem', CAST(strftime('%s', created_at, 'utc') AS integer), json_extract(info, '$.mem.usedPercent') FROM monitors
+      UNION ALL
+      SELECT 'mem_total', CAST(strftime('%s', created_at, 'utc') AS integer), json_extract(info, '$.mem.total') FROM monitors
+      UNION ALL
+      SELECT 'mem_used', CAST(strftime('%s', created_at, 'utc') AS integer), json_extract(info, '$.mem.used') FROM monitors
+      UNION ALL
+      SELECT 'mem_available', CAST(strftime('%s', created_at, 'utc') AS integer), json_extract(info, '$.mem.available') FROM monitors
+      UNION ALL
+      SELECT 'swap', CAST(strftime('%s', created_at, 'utc') AS integer), json_extract(info, '$.swap.usedPercent') FROM monitors WHERE json_extract(info, '$.swap.total') > 0
+      UNION ALL
+      SELECT 'swap_total', CAST(strftime('%s', created_at, 'utc') AS integer), json_extract(info, '$.swap.total') FROM monitors
+      UNION ALL
+      SELECT 'swap_used', CAST(strftime('%s', created_at, 'utc') AS integer), json_extract(info, '$.swap.used') FROM monitors
+      UNION ALL
+      SELECT 'swap_free', CAST(strftime('%s', created_at, 'utc') AS integer), json_extract(info, '$.swap.free') FROM monitors
+      UNION ALL
+      SELECT 'disk:' || usage.key, CAST(strftime('%s', created_at, 'utc') AS integer), json_extract(usage.value, '$.usedPercent')
+      FROM monitors, json_each(monitors.info, '$.disk_usage') AS usage
+      WHERE json_extract(usage.value, '$.total') > 0)
+WHERE value IS NOT NULL
+  AND time IS NOT NULL;
+
+DROP TABLE IF EXISTS monitors;
@@ -0,0 +1,16 @@
+CREATE TABLE monitor_processes
+(
+    id         integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    time       integer                           NOT NULL,
+    pid        integer                           NOT NULL,
+    name       varchar(255)                      NOT NULL,
+    cmdline    text         DEFAULT ''           NOT NULL,
+    cpu        real         DEFAULT 0            NOT NULL,
+    rss        integer      DEFAULT 0            NOT NULL,
+    open_files integer      DEFAULT 0            NOT NULL,
+    threads    integer      DEFAULT 0            NOT NULL,
+    read_rate  real         DEFAULT 0            NOT NULL,
+    write_rate real         DEFAULT 0            NOT NULL
+);
+
+CREATE INDEX monitor_processes_time_index ON monitor_processes (time);
@@ -0,0 +1,25 @@
+CREATE TABLE website_checks
+(
+    id              integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    website_id      integer                           NOT NULL,
+    url             varchar(255)                      NOT NULL,
+    method          varchar(255) DEFAULT 'GET'        NOT NULL,
+    expect_status   integer      DEFAULT 0            NOT NULL,
+    keyword         varchar(255) DEFAULT ''           NOT NULL,
+    interval        integer      DEFAULT 60           NOT NULL,
+    timeout         integer      DEFAULT 10           NOT NULL,
+    tls_warn_days   integer      DEFAULT 7            NOT NULL,
+    insecure        boolean      DEFAULT 0            NOT NULL,
+    enabled         boolean      DEFAULT 1            NOT NULL,
+    status          varchar(255) DEFAULT 'unknown'    NOT NULL,
+    failures        integer      DEFAULT 0            NOT NULL,
+    response_time   integer      DEFAULT 0            NOT NULL,
+    last_error      text         DEFAULT ''           NOT NULL,
+    cert_expires_at datetime     DEFAULT NULL,
+    checked_at      datetime     DEFAULT NULL,
+    changed_at      datetime     DEFAULT NULL,
+    created_at      datetime                          NOT NULL,
+    updated_at      datetime                          NOT NULL
+);
+
+CREATE INDEX website_checks_website_id_index ON website_checks (website_id);
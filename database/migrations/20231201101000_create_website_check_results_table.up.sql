@@ -0,0 +1,13 @@
+CREATE TABLE website_check_results
+(
+    id            integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    check_id      integer                           NOT NULL,
+    up            boolean                           NOT NULL,
+    status_code   integer      DEFAULT 0            NOT NULL,
+    response_time integer      DEFAULT 0            NOT NULL,
+    error         text         DEFAULT ''           NOT NULL,
+    created_at    datetime                          NOT NULL,
+    updated_at    datetime                          NOT NULL
+);
+
+CREATE INDEX website_check_results_check_id_created_at_index ON website_check_results (check_id, created_at);
@@ -0,0 +1,21 @@
+CREATE TABLE website_stats
+(
+    id            integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    website_id    integer                           NOT NULL,
+    date          varchar(10)                       NOT NULL,
+    requests      integer      DEFAULT 0            NOT NULL,
+    bytes         integer      DEFAULT 0            NOT NULL,
+    status_2xx    integer      DEFAULT 0            NOT NULL,
+    status_3xx    integer      DEFAULT 0            NOT NULL,
+    status_4xx    integer      DEFAULT 0            NOT NULL,
+    status_5xx    integer      DEFAULT 0            NOT NULL,
+    php_processes integer      DEFAULT 0            NOT NULL,
+    php_cpu       real         DEFAULT 0            NOT NULL,
+    php_rss       integer      DEFAULT 0            NOT NULL,
+    php_samples   integer      DEFAULT 0            NOT NULL,
+    disk_usage    integer      DEFAULT 0            NOT NULL,
+    created_at    datetime                          NOT NULL,
+    updated_at    datetime                          NOT NULL
+);
+
+CREATE UNIQUE INDEX website_stats_website_id_date_unique ON website_stats (website_id, date);
@@ -0,0 +1,11 @@
+CREATE TABLE website_stat_cursors
+(
+    id         integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    website_id integer                           NOT NULL,
+    inode      integer DEFAULT 0                 NOT NULL,
+    position   integer DEFAULT 0                 NOT NULL,
+    created_at datetime                          NOT NULL,
+    updated_at datetime                          NOT NULL
+);
+
+CREATE UNIQUE INDEX website_stat_cursors_website_id_unique ON website_stat_cursors (website_id);
@@ -0,0 +1,20 @@
+CREATE TABLE website_pools
+(
+    id                  integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    website_id          integer                           NOT NULL,
+    user                varchar(32)                       NOT NULL,
+    pm                  varchar(16)  DEFAULT 'dynamic'    NOT NULL,
+    max_children        integer      DEFAULT 10           NOT NULL,
+    start_servers       integer      DEFAULT 2            NOT NULL,
+    min_spare_servers   integer      DEFAULT 1            NOT NULL,
+    max_spare_servers   integer      DEFAULT 3            NOT NULL,
+    max_requests        integer      DEFAULT 500          NOT NULL,
+    idle_timeout        integer      DEFAULT 10           NOT NULL,
+    open_basedir        boolean      DEFAULT 1            NOT NULL,
+    admin_values        text         DEFAULT '{}'         NOT NULL,
+    created_at          datetime                          NOT NULL,
+    updated_at          datetime                          NOT NULL
+);
+
+CREATE UNIQUE INDEX website_pools_website_id_unique ON website_pools (website_id);
+CREATE UNIQUE INDEX website_pools_user_unique ON website_pools (user);
@@ -0,0 +1 @@
+ALTER TABLE websites DROP COLUMN php_settings;
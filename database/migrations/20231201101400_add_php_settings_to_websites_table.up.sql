@@ -0,0 +1 @@
+ALTER TABLE websites ADD COLUMN php_settings text DEFAULT '{}' NOT NULL;
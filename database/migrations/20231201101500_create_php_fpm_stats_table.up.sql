@@ -0,0 +1,22 @@
+DROP TABLE IF EXISTS php_fpm_stats;
+CREATE TABLE php_fpm_stats
+(
+    id                   integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    php                  integer                           NOT NULL,
+    pool                 varchar(255)                      NOT NULL,
+    active_processes     integer      DEFAULT 0            NOT NULL,
+    idle_processes       integer      DEFAULT 0            NOT NULL,
+    total_processes      integer      DEFAULT 0            NOT NULL,
+    listen_queue         integer      DEFAULT 0            NOT NULL,
+    accepted_conn        integer      DEFAULT 0            NOT NULL,
+    max_children_reached integer      DEFAULT 0            NOT NULL,
+    slow_requests        integer      DEFAULT 0            NOT NULL,
+    start_time           integer      DEFAULT 0            NOT NULL,
+    total_accepted       integer      DEFAULT 0            NOT NULL,
+    total_reached        integer      DEFAULT 0            NOT NULL,
+    total_slow           integer      DEFAULT 0            NOT NULL,
+    created_at           datetime                          NOT NULL,
+    updated_at           datetime                          NOT NULL
+);
+
+CREATE INDEX php_fpm_stats_php_pool_created_at_index ON php_fpm_stats (php, pool, created_at);
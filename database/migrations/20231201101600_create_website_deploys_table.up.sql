@@ -0,0 +1,19 @@
+CREATE TABLE website_deploys
+(
+    id           integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    website_id   integer                           NOT NULL,
+    repository   varchar(255)                      NOT NULL,
+    branch       varchar(255)  DEFAULT 'main'      NOT NULL,
+    root         varchar(255)  DEFAULT ''          NOT NULL,
+    shared_dirs  text          DEFAULT '[]'        NOT NULL,
+    shared_files text          DEFAULT '[]'        NOT NULL,
+    build        text          DEFAULT ''          NOT NULL,
+    post_deploy  text          DEFAULT ''          NOT NULL,
+    keep         integer       DEFAULT 5           NOT NULL,
+    auto_deploy  boolean       DEFAULT 0           NOT NULL,
+    secret       varchar(255)  DEFAULT ''          NOT NULL,
+    created_at   datetime                          NOT NULL,
+    updated_at   datetime                          NOT NULL
+);
+
+CREATE UNIQUE INDEX website_deploys_website_id_unique ON website_deploys (website_id);
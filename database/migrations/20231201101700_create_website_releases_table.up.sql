@@ -0,0 +1,14 @@
+CREATE TABLE website_releases
+(
+    id         integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    website_id integer                           NOT NULL,
+    name       varchar(32)                       NOT NULL,
+    branch     varchar(255) DEFAULT ''           NOT NULL,
+    "commit"   varchar(64)  DEFAULT ''           NOT NULL,
+    "trigger"  varchar(16)  DEFAULT 'manual'     NOT NULL,
+    task_id    integer      DEFAULT 0            NOT NULL,
+    created_at datetime                          NOT NULL,
+    updated_at datetime                          NOT NULL
+);
+
+CREATE INDEX website_releases_website_id_index ON website_releases (website_id);
@@ -0,0 +1,17 @@
+CREATE TABLE website_clones
+(
+    id          integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    website_id  integer                           NOT NULL,
+    source_id   integer                           NOT NULL,
+    from_domain varchar(255)  DEFAULT ''          NOT NULL,
+    to_domain   varchar(255)  DEFAULT ''          NOT NULL,
+    replace_db  boolean       DEFAULT 0           NOT NULL,
+    databases   text          DEFAULT '[]'        NOT NULL,
+    task_id     integer       DEFAULT 0           NOT NULL,
+    promoted_at datetime      DEFAULT NULL,
+    created_at  datetime                          NOT NULL,
+    updated_at  datetime                          NOT NULL
+);
+
+CREATE UNIQUE INDEX website_clones_website_id_unique ON website_clones (website_id);
+CREATE INDEX website_clones_source_id_index ON website_clones (source_id);
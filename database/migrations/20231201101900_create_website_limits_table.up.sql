@@ -0,0 +1,15 @@
+CREATE TABLE website_limits
+(
+    id         integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    website_id integer                           NOT NULL,
+    rules      text    DEFAULT '[]'              NOT NULL,
+    status     integer DEFAULT 503               NOT NULL,
+    per_ip     integer DEFAULT 0                 NOT NULL,
+    per_server integer DEFAULT 0                 NOT NULL,
+    rate       integer DEFAULT 0                 NOT NULL,
+    rate_after integer DEFAULT 0                 NOT NULL,
+    created_at datetime                          NOT NULL,
+    updated_at datetime                          NOT NULL
+);
+
+CREATE UNIQUE INDEX website_limits_website_id_unique ON website_limits (website_id);
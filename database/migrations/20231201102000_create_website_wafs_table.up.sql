@@ -0,0 +1,10 @@
+CREATE TABLE website_wafs
+(
+    id         integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    website_id integer                           NOT NULL,
+    rules      text    DEFAULT '{}'              NOT NULL,
+    created_at datetime                          NOT NULL,
+    updated_at datetime                          NOT NULL
+);
+
+CREATE UNIQUE INDEX website_wafs_website_id_unique ON website_wafs (website_id);
@@ -0,0 +1,12 @@
+CREATE TABLE website_countries
+(
+    id         integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    website_id integer                           NOT NULL,
+    mode       varchar(8)  DEFAULT 'deny'        NOT NULL,
+    countries  text        DEFAULT '[]'          NOT NULL,
+    status     integer     DEFAULT 403           NOT NULL,
+    created_at datetime                          NOT NULL,
+    updated_at datetime                          NOT NULL
+);
+
+CREATE UNIQUE INDEX website_countries_website_id_unique ON website_countries (website_id);
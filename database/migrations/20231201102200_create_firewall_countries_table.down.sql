@@ -0,0 +1 @@
+DROP TABLE IF EXISTS firewall_countries;
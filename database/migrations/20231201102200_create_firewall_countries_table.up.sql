@@ -0,0 +1,10 @@
+CREATE TABLE firewall_countries
+(
+    id         integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    country    varchar(2)                        NOT NULL,
+    action     varchar(8)  DEFAULT 'drop'        NOT NULL,
+    port       varchar(16) DEFAULT ''            NOT NULL,
+    protocol   varchar(8)  DEFAULT 'tcp'         NOT NULL,
+    created_at datetime                          NOT NULL,
+    updated_at datetime                          NOT NULL
+);
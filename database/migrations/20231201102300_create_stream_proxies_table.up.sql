@@ -0,0 +1,19 @@
+CREATE TABLE stream_proxies
+(
+    id                    integer PRIMARY KEY AUTOINCREMENT NOT NULL,
+    name                  varchar(255)                      NOT NULL,
+    port                  integer                           NOT NULL,
+    protocol              varchar(8)   DEFAULT 'tcp'        NOT NULL,
+    backends              text         DEFAULT '[]'         NOT NULL,
+    balance               varchar(16)  DEFAULT ''           NOT NULL,
+    proxy_protocol        boolean      DEFAULT false        NOT NULL,
+    accept_proxy_protocol boolean      DEFAULT false        NOT NULL,
+    connect_timeout       integer      DEFAULT 0            NOT NULL,
+    timeout               integer      DEFAULT 0            NOT NULL,
+    ssl                   boolean      DEFAULT false        NOT NULL,
+    cert_id               integer      DEFAULT NULL,
+    created_at            datetime                          NOT NULL,
+    updated_at            datetime                          NOT NULL
+);
+
+CREATE UNIQUE INDEX stream_proxies_port_protocol_unique ON stream_proxies (port, protocol);
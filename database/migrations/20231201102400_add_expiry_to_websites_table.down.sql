@@ -0,0 +1,2 @@
+ALTER TABLE websites DROP COLUMN suspended;
+ALTER TABLE websites DROP COLUMN expires_at;
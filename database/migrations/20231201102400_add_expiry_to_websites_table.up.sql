@@ -0,0 +1,2 @@
+ALTER TABLE websites ADD COLUMN expires_at datetime DEFAULT NULL;
+ALTER TABLE websites ADD COLUMN suspended boolean DEFAULT false NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE stream_proxies DROP COLUMN port_opened;
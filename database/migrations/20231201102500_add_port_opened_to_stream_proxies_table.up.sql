@@ -0,0 +1 @@
+ALTER TABLE stream_proxies ADD COLUMN port_opened boolean DEFAULT false NOT NULL;
@@ -0,0 +1,158 @@
+// Package accesslog OpenResty 访问日志解析与增量读取
+package accesslog
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxLine 单行日志的最大长度，超出的行会被跳过
+const maxLine = 64 << 10
+
+// combinedPattern 匹配 combined 格式的访问日志
+var combinedPattern = regexp.MustCompile(`^(\S+) \S+ \S+ \[([^\]]+)\] "([^"]*)" (\d{3}) (\d+|-)(?: "([^"]*)" "([^"]*)")?`)
+
+// Entry 一条访问日志
+type Entry struct {
+	IP        string
+	Time      time.Time
+	Method    string
+	Path      string
+	Status    int
+	Bytes     uint64
+	Referer   string
+	UserAgent string
+}
+
+// Stats 访问统计
+type Stats struct {
+	Requests  uint64 `json:"requests"`
+	Bytes     uint64 `json:"bytes"`
+	Status2xx uint64 `json:"status_2xx"`
+	Status3xx uint64 `json:"status_3xx"`
+	Status4xx uint64 `json:"status_4xx"`
+	Status5xx uint64 `json:"status_5xx"`
+}
+
+// Cursor 日志的读取位置，日志被轮转或清空后会从头读取
+type Cursor struct {
+	Inode  uint64
+	Offset int64
+}
+
+// Parse 解析一行 combined 格式的访问日志
+func Parse(line string) (Entry, error) {
+	matches := combinedPattern.FindStringSubmatch(line)
+	if matches == nil {
+		return Entry{}, errors.New("不是有效的访问日志")
+	}
+
+	t, err := time.Parse("02/Jan/2006:15:04:05 -0700", matches[2])
+	if err != nil {
+		return Entry{}, err
+	}
+	status, _ := strconv.Atoi(matches[4])
+	entry := Entry{
+		IP:        matches[1],
+		Time:      t,
+		Status:    status,
+		Referer:   matches[6],
+		UserAgent: matches[7],
+	}
+	if matches[5] != "-" {
+		entry.Bytes, _ = strconv.ParseUint(matches[5], 10, 64)
+	}
+	if request := strings.SplitN(matches[3], " ", 3); len(request) >= 2 {
+		entry.Method = request[0]
+		entry.Path = request[1]
+	}
+
+	return entry, nil
+}
+
+// Add 累加一条访问日志
+func (s *Stats) Add(entry Entry) {
+	s.Requests++
+	s.Bytes += entry.Bytes
+	switch entry.Status / 100 {
+	case 2:
+		s.Status2xx++
+	case 3:
+		s.Status3xx++
+	case 4:
+		s.Status4xx++
+	case 5:
+		s.Status5xx++
+	}
+}
+
+// Merge 合并另一份统计
+func (s *Stats) Merge(other Stats) {
+	s.Requests += other.Requests
+	s.Bytes += other.Bytes
+	s.Status2xx += other.Status2xx
+	s.Status3xx += other.Status3xx
+	s.Status4xx += other.Status4xx
+	s.Status5xx += other.Status5xx
+}
+
+// Read 从 cursor 处读取新增的日志，最多读取 limit 字节（0 为不限制），返回新的读取位置
+// 无法解析的行（如同一文件中的错误日志）会被忽略，未写完的最后一行留到下次读取
+func Read(path string, cursor Cursor, limit int64, fn func(Entry)) (Cursor, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Cursor{}, nil
+		}
+		return cursor, err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return cursor, err
+	}
+	var inode uint64
+	if sys, ok := stat.Sys().(*syscall.Stat_t); ok {
+		inode = sys.Ino
+	}
+	if inode != cursor.Inode || stat.Size() < cursor.Offset {
+		cursor = Cursor{Inode: inode}
+	}
+	if _, err = file.Seek(cursor.Offset, io.SeekStart); err != nil {
+		return cursor, err
+	}
+
+	var reader io.Reader = file
+	if limit > 0 {
+		reader = io.LimitReader(file, limit)
+	}
+	buffered := bufio.NewReaderSize(reader, 64<<10)
+	for {
+		line, err := buffered.ReadString('\n')
+		if err != nil {
+			// 不完整的行不计入读取位置
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return cursor, err
+		}
+
+		cursor.Offset += int64(len(line))
+		if len(line) > maxLine {
+			continue
+		}
+		if entry, err := Parse(strings.TrimRight(line, "\r\n")); err == nil {
+			fn(entry)
+		}
+	}
+
+	return cursor, nil
+}
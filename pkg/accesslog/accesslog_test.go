@@ -0,0 +1,98 @@
+package accesslog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type AccessLogTestSuite struct {
+	suite.Suite
+}
+
+func TestAccessLogTestSuite(t *testing.T) {
+	suite.Run(t, &AccessLogTestSuite{})
+}
+
+func (s *AccessLogTestSuite) TestParse() {
+	entry, err := Parse(`1.2.3.4 - - [01/Dec/2023:10:20:30 +0800] "GET /index.php?a=1 HTTP/1.1" 200 1024 "https://example.com/" "Mozilla/5.0"`)
+	s.NoError(err)
+	s.Equal("1.2.3.4", entry.IP)
+	s.Equal("GET", entry.Method)
+	s.Equal("/index.php?a=1", entry.Path)
+	s.Equal(200, entry.Status)
+	s.Equal(uint64(1024), entry.Bytes)
+	s.Equal("https://example.com/", entry.Referer)
+	s.Equal("Mozilla/5.0", entry.UserAgent)
+	s.Equal(time.Date(2023, 12, 1, 2, 20, 30, 0, time.UTC), entry.Time.UTC())
+
+	// common 格式
+	entry, err = Parse(`::1 - admin [01/Dec/2023:10:20:30 +0000] "POST /api HTTP/2.0" 502 -`)
+	s.NoError(err)
+	s.Equal(502, entry.Status)
+	s.Equal(uint64(0), entry.Bytes)
+
+	// 错误日志
+	_, err = Parse(`2023/12/01 10:20:30 [error] 123#123: *1 open() "/www/wwwroot/favicon.ico" failed`)
+	s.Error(err)
+}
+
+func (s *AccessLogTestSuite) TestStats() {
+	var stats Stats
+	for _, status := range []int{200, 204, 301, 404, 500, 101} {
+		stats.Add(Entry{Status: status, Bytes: 10})
+	}
+	s.Equal(Stats{Requests: 6, Bytes: 60, Status2xx: 2, Status3xx: 1, Status4xx: 1, Status5xx: 1}, stats)
+
+	stats.Merge(Stats{Requests: 1, Bytes: 5, Status2xx: 1})
+	s.Equal(uint64(7), stats.Requests)
+	s.Equal(uint64(65), stats.Bytes)
+	s.Equal(uint64(3), stats.Status2xx)
+}
+
+func (s *AccessLogTestSuite) TestRead() {
+	path := filepath.Join(s.T().TempDir(), "site.log")
+	line := `1.2.3.4 - - [01/Dec/2023:10:20:30 +0800] "GET / HTTP/1.1" 200 100 "-" "curl"` + "\n"
+	s.NoError(os.WriteFile(path, []byte(line+"2023/12/01 10:20:30 [error] oops\n"+line+`1.2.3.4 - - [01/Dec`), 0644))
+
+	var stats Stats
+	cursor, err := Read(path, Cursor{}, 0, stats.Add)
+	s.NoError(err)
+	s.Equal(uint64(2), stats.Requests)
+	s.NotZero(cursor.Inode)
+
+	// 不完整的行留到下次读取
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	s.NoError(err)
+	_, err = file.WriteString(`/2023:10:20:31 +0800] "GET / HTTP/1.1" 404 0 "-" "curl"` + "\n")
+	s.NoError(err)
+	s.NoError(file.Close())
+	cursor, err = Read(path, cursor, 0, stats.Add)
+	s.NoError(err)
+	s.Equal(uint64(3), stats.Requests)
+	s.Equal(uint64(1), stats.Status4xx)
+
+	// 日志被清空后从头读取
+	s.NoError(os.WriteFile(path, []byte(line), 0644))
+	cursor, err = Read(path, cursor, 0, stats.Add)
+	s.NoError(err)
+	s.Equal(uint64(4), stats.Requests)
+	s.Equal(int64(len(line)), cursor.Offset)
+
+	// 限制单次读取量
+	stats = Stats{}
+	s.NoError(os.WriteFile(path, []byte(line+line+line), 0644))
+	cursor, err = Read(path, Cursor{}, int64(len(line)+10), stats.Add)
+	s.NoError(err)
+	s.Equal(uint64(1), stats.Requests)
+	_, err = Read(path, cursor, 0, stats.Add)
+	s.NoError(err)
+	s.Equal(uint64(3), stats.Requests)
+
+	cursor, err = Read(filepath.Join(s.T().TempDir(), "missing.log"), cursor, 0, stats.Add)
+	s.NoError(err)
+	s.Equal(Cursor{}, cursor)
+}
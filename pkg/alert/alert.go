@@ -0,0 +1,236 @@
+// Package alert 监控告警规则计算
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"panel/pkg/tools"
+)
+
+const (
+	MetricCPU       = "cpu"        // CPU 使用率 (%)
+	MetricLoad1     = "load1"      // 1 分钟负载
+	MetricLoad5     = "load5"      // 5 分钟负载
+	MetricLoad15    = "load15"     // 15 分钟负载
+	MetricMem       = "mem"        // 内存使用率 (%)
+	MetricSwap      = "swap"       // 交换分区使用率 (%)
+	MetricDisk      = "disk"       // 磁盘使用率 (%)，需指定挂载点
+	MetricDiskRead  = "disk_read"  // 磁盘读取速度 (MB/s)
+	MetricDiskWrite = "disk_write" // 磁盘写入速度 (MB/s)
+	MetricNetRx     = "net_rx"     // 网络下行速度 (MB/s)
+	MetricNetTx     = "net_tx"     // 网络上行速度 (MB/s)
+)
+
+// Metrics 支持的监控指标及其名称
+var Metrics = map[string]string{
+	MetricCPU:       "CPU 使用率 (%)",
+	MetricLoad1:     "1 分钟负载",
+	MetricLoad5:     "5 分钟负载",
+	MetricLoad15:    "15 分钟负载",
+	MetricMem:       "内存使用率 (%)",
+	MetricSwap:      "Swap 使用率 (%)",
+	MetricDisk:      "磁盘使用率 (%)",
+	MetricDiskRead:  "磁盘读取速度 (MB/s)",
+	MetricDiskWrite: "磁盘写入速度 (MB/s)",
+	MetricNetRx:     "网络下行速度 (MB/s)",
+	MetricNetTx:     "网络上行速度 (MB/s)",
+}
+
+// virtualDisks 虚拟块设备的名称前缀，其读写已计入所在的物理磁盘
+var virtualDisks = []string{"loop", "ram", "zram", "dm-", "md", "nbd", "sr"}
+
+// partitionSuffix 分区名相对所在磁盘名的后缀，如 sda1、nvme0n1p1
+var partitionSuffix = regexp.MustCompile(`^p?[0-9]+$`)
+
+// Operators 支持的比较运算符
+var Operators = []string{">", ">=", "<", "<="}
+
+// Transition 规则状态变化
+type Transition int
+
+const (
+	TransitionNone      Transition = iota // 无变化
+	TransitionFired                       // 开始告警
+	TransitionRecovered                   // 告警恢复
+)
+
+// Rule 告警规则
+type Rule struct {
+	Metric    string
+	Target    string // 磁盘挂载点等指标对象
+	Operator  string
+	Threshold float64
+	Duration  time.Duration // 持续超过阈值多久后告警
+}
+
+// State 规则的运行状态
+type State struct {
+	Firing       bool       `json:"firing"`
+	PendingSince *time.Time `json:"pending_since"` // 开始超过阈值的时间
+	FiredAt      *time.Time `json:"fired_at"`
+	Value        float64    `json:"value"` // 最近一次的指标值
+}
+
+// Sample 一次采集得到的指标值
+type Sample map[string]float64
+
+// Key 生成指标在 Sample 中的键
+func Key(metric, target string) string {
+	if len(target) == 0 {
+		return metric
+	}
+
+	return metric + ":" + target
+}
+
+// Rate 是否为速率类指标，速率需根据上一次保存的监控数据计算，仅在开启系统监控时可用
+func Rate(metric string) bool {
+	switch metric {
+	case MetricDiskRead, MetricDiskWrite, MetricNetRx, MetricNetTx:
+		return true
+	}
+
+	return false
+}
+
+// Validate 校验规则
+func Validate(rule Rule) error {
+	if _, ok := Metrics[rule.Metric]; !ok {
+		return fmt.Errorf("不支持的监控指标: %s", rule.Metric)
+	}
+	if rule.Metric == MetricDisk && len(rule.Target) == 0 {
+		return errors.New("磁盘使用率需指定挂载点")
+	}
+	if _, err := compare(rule.Operator, 0, 0); err != nil {
+		return err
+	}
+	if rule.Duration < 0 {
+		return errors.New("持续时间不能为负数")
+	}
+
+	return nil
+}
+
+// Collect 从监控数据中计算使用率和负载类指标，速率类指标需由调用方根据上一次采样计算
+func Collect(info tools.MonitoringInfo) Sample {
+	sample := Sample{}
+	if len(info.Percent) > 0 {
+		sample[MetricCPU] = info.Percent[0]
+	}
+	if info.Load != nil {
+		sample[MetricLoad1] = info.Load.Load1
+		sample[MetricLoad5] = info.Load.Load5
+		sample[MetricLoad15] = info.Load.Load15
+	}
+	if info.Mem != nil {
+		sample[MetricMem] = info.Mem.UsedPercent
+	}
+	if info.Swap != nil && info.Swap.Total > 0 {
+		sample[MetricSwap] = info.Swap.UsedPercent
+	}
+	for mount, usage := range info.DiskUsage {
+		if usage != nil && usage.Total > 0 {
+			sample[Key(MetricDisk, mount)] = usage.UsedPercent
+		}
+	}
+
+	return sample
+}
+
+// Evaluate 根据指标值计算规则的新状态
+func Evaluate(rule Rule, sample Sample, state State, now time.Time) (State, Transition) {
+	value, ok := sample[Key(rule.Metric, rule.Target)]
+	if !ok {
+		return state, TransitionNone
+	}
+	state.Value = value
+
+	breach, err := compare(rule.Operator, value, rule.Threshold)
+	if err != nil {
+		return state, TransitionNone
+	}
+
+	if !breach {
+		state.PendingSince = nil
+		if state.Firing {
+			state.Firing = false
+			state.FiredAt = nil
+			return state, TransitionRecovered
+		}
+		return state, TransitionNone
+	}
+
+	if state.PendingSince == nil {
+		since := now
+		state.PendingSince = &since
+	}
+	if !state.Firing && now.Sub(*state.PendingSince) >= rule.Duration {
+		state.Firing = true
+		firedAt := now
+		state.FiredAt = &firedAt
+		return state, TransitionFired
+	}
+
+	return state, TransitionNone
+}
+
+func compare(operator string, value, threshold float64) (bool, error) {
+	switch operator {
+	case ">":
+		return value > threshold, nil
+	case ">=":
+		return value >= threshold, nil
+	case "<":
+		return value < threshold, nil
+	case "<=":
+		return value <= threshold, nil
+	}
+
+	return false, fmt.Errorf("不支持的比较运算符: %s", operator)
+}
+
+// DiskBytes 所有物理磁盘累计读写的字节数，分区和虚拟设备的读写已计入所在磁盘，不重复计算
+func DiskBytes(info tools.MonitoringInfo) (read, write uint64) {
+	for name, io := range info.DiskIO {
+		if !wholeDisk(name, info) {
+			continue
+		}
+		read += io.ReadBytes
+		write += io.WriteBytes
+	}
+
+	return read, write
+}
+
+// wholeDisk 是否为物理磁盘
+func wholeDisk(name string, info tools.MonitoringInfo) bool {
+	for _, prefix := range virtualDisks {
+		if strings.HasPrefix(name, prefix) {
+			return false
+		}
+	}
+	for other := range info.DiskIO {
+		if other != name && strings.HasPrefix(name, other) && partitionSuffix.MatchString(strings.TrimPrefix(name, other)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NetBytes 除回环网卡外累计收发的字节数
+func NetBytes(info tools.MonitoringInfo) (rx, tx uint64) {
+	for _, io := range info.Net {
+		if io.Name == "lo" {
+			continue
+		}
+		rx += io.BytesRecv
+		tx += io.BytesSent
+	}
+
+	return rx, tx
+}
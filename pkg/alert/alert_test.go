@@ -0,0 +1,112 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/disk"
+	"github.com/shirou/gopsutil/load"
+	"github.com/shirou/gopsutil/mem"
+	"github.com/shirou/gopsutil/net"
+	"github.com/stretchr/testify/suite"
+
+	"panel/pkg/tools"
+)
+
+type AlertTestSuite struct {
+	suite.Suite
+}
+
+func TestAlertTestSuite(t *testing.T) {
+	suite.Run(t, &AlertTestSuite{})
+}
+
+func (s *AlertTestSuite) TestValidate() {
+	s.NoError(Validate(Rule{Metric: MetricCPU, Operator: ">", Threshold: 90, Duration: 5 * time.Minute}))
+	s.NoError(Validate(Rule{Metric: MetricDisk, Target: "/", Operator: ">=", Threshold: 90}))
+	s.Error(Validate(Rule{Metric: MetricDisk, Operator: ">", Threshold: 90}))
+	s.True(Rate(MetricNetRx))
+	s.False(Rate(MetricCPU))
+	s.Error(Validate(Rule{Metric: "gpu", Operator: ">", Threshold: 90}))
+	s.Error(Validate(Rule{Metric: MetricCPU, Operator: "!=", Threshold: 90}))
+	s.Error(Validate(Rule{Metric: MetricCPU, Operator: ">", Duration: -time.Minute}))
+}
+
+func (s *AlertTestSuite) TestCollect() {
+	info := tools.MonitoringInfo{
+		Percent: []float64{42.5},
+		Load:    &load.AvgStat{Load1: 1, Load5: 2, Load15: 3},
+		Mem:     &mem.VirtualMemoryStat{UsedPercent: 80},
+		Swap:    &mem.SwapMemoryStat{Total: 0},
+		Net:     []net.IOCountersStat{{Name: "lo", BytesRecv: 2 << 30}, {Name: "eth0", BytesRecv: 120 << 20, BytesSent: 0}},
+		DiskIO: map[string]disk.IOCountersStat{
+			"sda":       {ReadBytes: 60 << 20, WriteBytes: 30 << 20},
+			"sda1":      {ReadBytes: 50 << 20, WriteBytes: 20 << 20},
+			"nvme0n1":   {ReadBytes: 40 << 20},
+			"nvme0n1p1": {ReadBytes: 40 << 20},
+			"dm-0":      {ReadBytes: 40 << 20},
+			"loop0":     {ReadBytes: 10 << 20},
+		},
+		DiskUsage: map[string]*disk.UsageStat{"/": {Total: 100, UsedPercent: 91}, "/data": nil},
+	}
+
+	sample := Collect(info)
+	s.Equal(42.5, sample[MetricCPU])
+	s.Equal(2.0, sample[MetricLoad5])
+	s.Equal(80.0, sample[MetricMem])
+	s.NotContains(sample, MetricSwap)
+	s.Equal(91.0, sample["disk:/"])
+	s.NotContains(sample, "disk:/data")
+	s.NotContains(sample, MetricNetRx)
+	s.NotContains(sample, MetricDiskRead)
+
+	rx, tx := NetBytes(info)
+	s.Equal(uint64(120<<20), rx)
+	s.Equal(uint64(0), tx)
+	read, write := DiskBytes(info)
+	s.Equal(uint64(100<<20), read)
+	s.Equal(uint64(30<<20), write)
+}
+
+func (s *AlertTestSuite) TestEvaluate() {
+	rule := Rule{Metric: MetricCPU, Operator: ">", Threshold: 90, Duration: 5 * time.Minute}
+	now := time.Date(2023, 12, 1, 10, 0, 0, 0, time.UTC)
+
+	state, transition := Evaluate(rule, Sample{MetricCPU: 95}, State{}, now)
+	s.Equal(TransitionNone, transition)
+	s.NotNil(state.PendingSince)
+	s.False(state.Firing)
+
+	// 持续时间不足
+	state, transition = Evaluate(rule, Sample{MetricCPU: 96}, state, now.Add(4*time.Minute))
+	s.Equal(TransitionNone, transition)
+
+	state, transition = Evaluate(rule, Sample{MetricCPU: 97}, state, now.Add(5*time.Minute))
+	s.Equal(TransitionFired, transition)
+	s.True(state.Firing)
+	s.Equal(97.0, state.Value)
+
+	// 持续告警不重复触发
+	state, transition = Evaluate(rule, Sample{MetricCPU: 99}, state, now.Add(6*time.Minute))
+	s.Equal(TransitionNone, transition)
+
+	// 无数据时保持状态
+	state, transition = Evaluate(rule, Sample{}, state, now.Add(7*time.Minute))
+	s.Equal(TransitionNone, transition)
+	s.True(state.Firing)
+
+	state, transition = Evaluate(rule, Sample{MetricCPU: 10}, state, now.Add(8*time.Minute))
+	s.Equal(TransitionRecovered, transition)
+	s.False(state.Firing)
+	s.Nil(state.PendingSince)
+
+	// 中途回落会重新计时
+	state, _ = Evaluate(rule, Sample{MetricCPU: 95}, State{}, now)
+	state, _ = Evaluate(rule, Sample{MetricCPU: 50}, state, now.Add(3*time.Minute))
+	state, transition = Evaluate(rule, Sample{MetricCPU: 95}, state, now.Add(6*time.Minute))
+	s.Equal(TransitionNone, transition)
+
+	// 无持续时间要求时立即告警
+	_, transition = Evaluate(Rule{Metric: MetricDisk, Target: "/", Operator: ">=", Threshold: 90}, Sample{"disk:/": 90}, State{}, now)
+	s.Equal(TransitionFired, transition)
+}
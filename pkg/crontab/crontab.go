@@ -0,0 +1,247 @@
+// Package crontab 系统计划任务文件管理
+package crontab
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	BlockBegin = "# 耗子Linux面板计划任务开始，该区块由面板自动管理，请勿手动修改"
+	BlockEnd   = "# 耗子Linux面板计划任务结束"
+)
+
+// parser 仅支持标准的 5 段式表达式，与系统 cron 保持一致
+var parser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+
+// Entry 计划任务条目
+type Entry struct {
+	ID      uint
+	Name    string
+	Time    string
+	Command string
+}
+
+// Line 生成条目对应的 crontab 行
+func (e Entry) Line() string {
+	return strings.Join(strings.Fields(e.Time), " ") + " " + e.Command
+}
+
+// Drift 系统 crontab 与期望状态的差异
+type Drift struct {
+	Missing    []string `json:"missing"`    // 期望存在但区块中缺失的行
+	Unexpected []string `json:"unexpected"` // 区块中存在但不应存在的行
+	Legacy     []string `json:"legacy"`     // 区块外残留的面板旧版本条目
+	NoBlock    bool     `json:"no_block"`   // 文件中不存在托管区块
+}
+
+// Clean 是否无差异
+func (d Drift) Clean() bool {
+	return len(d.Missing) == 0 && len(d.Unexpected) == 0 && len(d.Legacy) == 0 && !d.NoBlock
+}
+
+// Validate 校验时间表达式
+func Validate(spec string) error {
+	if strings.Contains(spec, "\n") || strings.Contains(spec, "TZ=") {
+		return errors.New("时间格式错误")
+	}
+	if len(strings.Fields(spec)) != 5 {
+		return errors.New("时间格式错误，需为 5 段式表达式")
+	}
+	if _, err := parser.Parse(spec); err != nil {
+		return fmt.Errorf("时间格式错误: %s", err.Error())
+	}
+
+	return nil
+}
+
+// Next 获取从 from 开始接下来 n 次运行时间
+func Next(spec string, from time.Time, n int) ([]time.Time, error) {
+	if err := Validate(spec); err != nil {
+		return nil, err
+	}
+
+	schedule, _ := parser.Parse(spec)
+	times := make([]time.Time, 0, n)
+	next := from
+	for i := 0; i < n; i++ {
+		next = schedule.Next(next)
+		if next.IsZero() {
+			break
+		}
+		times = append(times, next)
+	}
+
+	return times, nil
+}
+
+// Render 渲染托管区块
+func Render(entries []Entry) string {
+	var block strings.Builder
+	block.WriteString(BlockBegin + "\n")
+	for _, entry := range entries {
+		name := strings.ReplaceAll(strings.ReplaceAll(entry.Name, "\r", " "), "\n", " ")
+		block.WriteString(fmt.Sprintf("# [%d] %s\n", entry.ID, name))
+		block.WriteString(entry.Line() + "\n")
+	}
+	block.WriteString(BlockEnd + "\n")
+
+	return block.String()
+}
+
+// Replace 使用新区块替换内容中的托管区块，不存在时追加到末尾
+func Replace(content, block string) string {
+	begin := strings.Index(content, BlockBegin)
+	end := strings.Index(content, BlockEnd)
+	if begin == -1 || end == -1 || begin > end {
+		if len(content) > 0 && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		return content + block
+	}
+
+	end += len(BlockEnd)
+	if end < len(content) && content[end] == '\n' {
+		end++
+	}
+
+	return content[:begin] + block + content[end:]
+}
+
+// Extract 提取托管区块内的任务行（忽略注释和空行）
+func Extract(content string) ([]string, bool) {
+	begin := strings.Index(content, BlockBegin)
+	end := strings.Index(content, BlockEnd)
+	if begin == -1 || end == -1 || begin > end {
+		return nil, false
+	}
+
+	var lines []string
+	for _, line := range strings.Split(content[begin+len(BlockBegin):end], "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, true
+}
+
+// Outside 获取托管区块外包含 marker 的行
+func Outside(content, marker string) []string {
+	begin := strings.Index(content, BlockBegin)
+	end := strings.Index(content, BlockEnd)
+	if begin != -1 && end != -1 && begin < end {
+		content = content[:begin] + content[end+len(BlockEnd):]
+	}
+
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.Contains(line, marker) {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// RemoveOutside 删除托管区块外包含 marker 的行
+func RemoveOutside(content, marker string) string {
+	legacy := Outside(content, marker)
+	if len(legacy) == 0 {
+		return content
+	}
+
+	remove := make(map[string]bool, len(legacy))
+	for _, line := range legacy {
+		remove[line] = true
+	}
+
+	var kept []string
+	for _, line := range strings.Split(content, "\n") {
+		if remove[strings.TrimSpace(line)] {
+			continue
+		}
+		kept = append(kept, line)
+	}
+
+	return strings.Join(kept, "\n")
+}
+
+// Diff 比较 crontab 内容与期望条目
+func Diff(content string, entries []Entry, marker string) Drift {
+	var drift Drift
+	drift.Legacy = Outside(content, marker)
+
+	actual, ok := Extract(content)
+	if !ok {
+		drift.NoBlock = true
+	}
+
+	actualSet := make(map[string]bool, len(actual))
+	for _, line := range actual {
+		actualSet[normalize(line)] = true
+	}
+	expectedSet := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		line := entry.Line()
+		expectedSet[normalize(line)] = true
+		if !actualSet[normalize(line)] {
+			drift.Missing = append(drift.Missing, line)
+		}
+	}
+	for _, line := range actual {
+		if !expectedSet[normalize(line)] {
+			drift.Unexpected = append(drift.Unexpected, line)
+		}
+	}
+
+	return drift
+}
+
+// normalize 统一行内空白，避免空格差异被误判为漂移
+func normalize(line string) string {
+	return strings.Join(strings.Fields(line), " ")
+}
+
+// WriteFile 原子写入 crontab 文件
+func WriteFile(path, content string) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".panel-crontab-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.WriteString(content); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
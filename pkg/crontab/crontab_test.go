@@ -0,0 +1,148 @@
+package crontab
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type CrontabTestSuite struct {
+	suite.Suite
+}
+
+func TestCrontabTestSuite(t *testing.T) {
+	suite.Run(t, &CrontabTestSuite{})
+}
+
+func (s *CrontabTestSuite) TestValidate() {
+	s.NoError(Validate("* * * * *"))
+	s.NoError(Validate("*/5 1-3 * * 1,3,5"))
+	s.NoError(Validate("0 0 1 jan mon"))
+	s.Error(Validate("* * * *"))
+	s.Error(Validate("60 * * * *"))
+	s.Error(Validate("@daily"))
+	s.Error(Validate("TZ=UTC * * * * *"))
+	s.Error(Validate("* * * * *\n* * * * * rm -rf /"))
+}
+
+func (s *CrontabTestSuite) TestNext() {
+	from := time.Date(2023, 12, 1, 10, 2, 0, 0, time.UTC)
+	times, err := Next("*/15 * * * *", from, 3)
+	s.NoError(err)
+	s.Equal([]time.Time{
+		time.Date(2023, 12, 1, 10, 15, 0, 0, time.UTC),
+		time.Date(2023, 12, 1, 10, 30, 0, 0, time.UTC),
+		time.Date(2023, 12, 1, 10, 45, 0, 0, time.UTC),
+	}, times)
+
+	_, err = Next("bad", from, 3)
+	s.Error(err)
+}
+
+func (s *CrontabTestSuite) TestReplaceAndExtract() {
+	entries := []Entry{
+		{ID: 1, Name: "备份", Time: "0 2 * * *", Command: "panel cronRun 1"},
+		{ID: 2, Name: "切割\n日志", Time: "0  3 * * *", Command: "panel cronRun 2"},
+	}
+	block := Render(entries)
+	s.Contains(block, "# [2] 切割 日志\n")
+
+	content := "MAILTO=\"\"\n0 1 * * * /root/other.sh"
+	content = Replace(content, block)
+	s.Contains(content, "0 1 * * * /root/other.sh\n"+BlockBegin)
+
+	lines, ok := Extract(content)
+	s.True(ok)
+	s.Equal([]string{"0 2 * * * panel cronRun 1", "0 3 * * * panel cronRun 2"}, lines)
+
+	content = Replace(content, Render(entries[:1]))
+	lines, ok = Extract(content)
+	s.True(ok)
+	s.Equal([]string{"0 2 * * * panel cronRun 1"}, lines)
+	s.Contains(content, "/root/other.sh")
+
+	_, ok = Extract("0 1 * * * /root/other.sh")
+	s.False(ok)
+}
+
+func (s *CrontabTestSuite) TestDiff() {
+	entries := []Entry{
+		{ID: 1, Name: "a", Time: "0 2 * * *", Command: "panel cronRun 1"},
+		{ID: 2, Name: "b", Time: "0 3 * * *", Command: "panel cronRun 2"},
+	}
+
+	drift := Diff("", entries, "/www/server/cron/")
+	s.True(drift.NoBlock)
+	s.Len(drift.Missing, 2)
+
+	content := "* * * * * /www/server/cron/old.sh >> /www/server/cron/logs/old.log 2>&1\n" + Render(entries)
+	drift = Diff(content, entries, "/www/server/cron/")
+	s.False(drift.Clean())
+	s.Equal([]string{"* * * * * /www/server/cron/old.sh >> /www/server/cron/logs/old.log 2>&1"}, drift.Legacy)
+
+	content = RemoveOutside(content, "/www/server/cron/")
+	s.True(Diff(content, entries, "/www/server/cron/").Clean())
+
+	drift = Diff(content, entries[1:], "/www/server/cron/")
+	s.Equal([]string{"0 2 * * * panel cronRun 1"}, drift.Unexpected)
+	s.Empty(drift.Missing)
+}
+
+func (s *CrontabTestSuite) TestWriteFile() {
+	dir := s.T().TempDir()
+	path := filepath.Join(dir, "root")
+	s.NoError(WriteFile(path, "content"))
+
+	data, err := os.ReadFile(path)
+	s.NoError(err)
+	s.Equal("content", string(data))
+
+	info, err := os.Stat(path)
+	s.NoError(err)
+	s.Equal(os.FileMode(0600), info.Mode().Perm())
+
+	entries, err := os.ReadDir(dir)
+	s.NoError(err)
+	s.Len(entries, 1)
+}
+
+func (s *CrontabTestSuite) TestScript() {
+	script, err := Script(TypeBackup, Config{BackupType: "mysql", Target: "db_1", Path: "/www/backup/mysql", Save: 10})
+	s.NoError(err)
+	s.Contains(script, "name='db_1'\n")
+	s.Contains(script, `panel backup "${type}" "${name}" "${path}" "${save}"`)
+
+	_, err = Script(TypeBackup, Config{BackupType: "mysql", Target: "db;rm -rf /", Path: "/www/backup", Save: 10})
+	s.Error(err)
+	_, err = Script(TypeBackup, Config{BackupType: "redis", Target: "db", Path: "/www/backup", Save: 10})
+	s.Error(err)
+
+	script, err = Script(TypeCutoff, Config{Target: "example.com", Save: 180})
+	s.NoError(err)
+	s.Contains(script, `panel cutoff "${name}" "${save}"`)
+
+	script, err = Script(TypePing, Config{URL: "https://example.com/health?a=1&b='2'", Keyword: "ok"})
+	s.NoError(err)
+	s.Contains(script, `url='https://example.com/health?a=1&b='\''2'\'''`)
+	s.Contains(script, "status=200\n")
+	s.Contains(script, "method=GET\n")
+	_, err = Script(TypePing, Config{URL: "ftp://example.com"})
+	s.Error(err)
+	_, err = Script(TypePing, Config{URL: "https://example.com", Method: "DELETE"})
+	s.Error(err)
+
+	script, err = Script(TypeSync, Config{Path: "/www/wwwroot/", Destination: "root@10.0.0.2:/backup", Port: 2222, Delete: true})
+	s.NoError(err)
+	s.Contains(script, `rsync -az --stats --delete -e "ssh -p 2222`)
+	script, err = Script(TypeSync, Config{Path: "/www/wwwroot/", Destination: "rsync://10.0.0.2/backup"})
+	s.NoError(err)
+	s.NotContains(script, "ssh")
+	_, err = Script(TypeSync, Config{Path: "/www/wwwroot/", Destination: "--rsh=evil"})
+	s.Error(err)
+
+	_, err = Script(TypeShell, Config{})
+	s.Error(err)
+}
@@ -0,0 +1,191 @@
+package crontab
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"panel/pkg/shell"
+)
+
+const (
+	TypeShell  = "shell"  // 自定义脚本
+	TypeBackup = "backup" // 网站 / 数据库备份
+	TypeCutoff = "cutoff" // 网站日志切割
+	TypePing   = "ping"   // URL 检测
+	TypeSync   = "sync"   // 同步到远程
+)
+
+// Types 支持的计划任务类型
+var Types = []string{TypeShell, TypeBackup, TypeCutoff, TypePing, TypeSync}
+
+var (
+	namePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+(\.[a-zA-Z0-9_-]+)*$`)
+	pathPattern = regexp.MustCompile(`^/[^\x00-\x1f]*$`)
+)
+
+// Config 模板类计划任务的配置
+type Config struct {
+	BackupType  string `json:"backup_type,omitempty"` // 备份类型 (website, mysql, postgresql)
+	Target      string `json:"target,omitempty"`      // 网站名或数据库名
+	Path        string `json:"path,omitempty"`        // 备份目录 / 同步源目录
+	Save        int    `json:"save,omitempty"`        // 保留份数
+	URL         string `json:"url,omitempty"`         // 检测地址
+	Method      string `json:"method,omitempty"`      // 请求方法
+	Status      int    `json:"status,omitempty"`      // 期望状态码
+	Keyword     string `json:"keyword,omitempty"`     // 期望响应内容包含的关键字
+	Timeout     int    `json:"timeout,omitempty"`     // 请求超时（秒）
+	Destination string `json:"destination,omitempty"` // 同步目标，如 user@host:/path 或 rsync://host/module
+	Port        int    `json:"port,omitempty"`        // SSH 端口
+	Delete      bool   `json:"delete,omitempty"`      // 同步时删除目标端多余文件
+}
+
+// Script 根据类型和配置生成计划任务脚本
+func Script(kind string, config Config) (string, error) {
+	switch kind {
+	case TypeBackup:
+		return backupScript(config)
+	case TypeCutoff:
+		return cutoffScript(config)
+	case TypePing:
+		return pingScript(config)
+	case TypeSync:
+		return syncScript(config)
+	}
+
+	return "", fmt.Errorf("不支持生成 %s 类型的脚本", kind)
+}
+
+func backupScript(config Config) (string, error) {
+	if config.BackupType != "website" && config.BackupType != "mysql" && config.BackupType != "postgresql" {
+		return "", errors.New("备份类型错误")
+	}
+	if !namePattern.MatchString(config.Target) {
+		return "", errors.New("备份目标名称错误")
+	}
+	if !pathPattern.MatchString(config.Path) {
+		return "", errors.New("备份目录需为绝对路径")
+	}
+	if config.Save < 1 {
+		return "", errors.New("保留份数需大于 0")
+	}
+
+	return header("数据备份脚本") + `type=` + shell.Quote(config.BackupType) + `
+path=` + shell.Quote(config.Path) + `
+name=` + shell.Quote(config.Target) + `
+save=` + fmt.Sprint(config.Save) + `
+
+# 执行备份
+panel backup "${type}" "${name}" "${path}" "${save}" 2>&1
+`, nil
+}
+
+func cutoffScript(config Config) (string, error) {
+	if !namePattern.MatchString(config.Target) {
+		return "", errors.New("网站名称错误")
+	}
+	if config.Save < 1 {
+		return "", errors.New("保留份数需大于 0")
+	}
+
+	return header("日志切割脚本") + `name=` + shell.Quote(config.Target) + `
+save=` + fmt.Sprint(config.Save) + `
+
+# 执行切割
+panel cutoff "${name}" "${save}" 2>&1
+`, nil
+}
+
+func pingScript(config Config) (string, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 || strings.ContainsAny(config.URL, "\r\n") {
+		return "", errors.New("检测地址需为 http / https URL")
+	}
+	method := strings.ToUpper(config.Method)
+	if len(method) == 0 {
+		method = "GET"
+	}
+	if method != "GET" && method != "HEAD" && method != "POST" {
+		return "", errors.New("请求方法错误")
+	}
+	status := config.Status
+	if status == 0 {
+		status = 200
+	}
+	if status < 100 || status > 599 {
+		return "", errors.New("期望状态码错误")
+	}
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 10
+	}
+	if strings.ContainsAny(config.Keyword, "\r\n") {
+		return "", errors.New("关键字不能包含换行")
+	}
+
+	return header("URL 检测脚本") + `url=` + shell.Quote(config.URL) + `
+method=` + method + `
+status=` + fmt.Sprint(status) + `
+keyword=` + shell.Quote(config.Keyword) + `
+timeout=` + fmt.Sprint(timeout) + `
+
+# 执行检测
+response=$(curl -sS -L -X "${method}" --max-time "${timeout}" -w '\n%{http_code}' "${url}" 2>&1)
+code=$(echo "${response}" | tail -n 1)
+body=$(echo "${response}" | sed '$d')
+if [ "${code}" != "${status}" ]; then
+    echo "检测失败: ${url} 返回状态码 ${code}，期望 ${status}"
+    echo "${body}" | head -c 1024
+    exit 1
+fi
+if [ -n "${keyword}" ] && ! echo "${body}" | grep -qF -- "${keyword}"; then
+    echo "检测失败: ${url} 响应内容不包含 ${keyword}"
+    exit 1
+fi
+echo "检测成功: ${url} 返回状态码 ${code}"
+`, nil
+}
+
+func syncScript(config Config) (string, error) {
+	if !pathPattern.MatchString(config.Path) {
+		return "", errors.New("同步源目录需为绝对路径")
+	}
+	if len(config.Destination) == 0 || strings.ContainsAny(config.Destination, "\r\n") || strings.HasPrefix(config.Destination, "-") {
+		return "", errors.New("同步目标错误")
+	}
+	if config.Port < 0 || config.Port > 65535 {
+		return "", errors.New("SSH 端口错误")
+	}
+
+	options := "-az --stats"
+	if config.Delete {
+		options += " --delete"
+	}
+	// rsync 守护进程地址不经过 SSH
+	if !strings.HasPrefix(config.Destination, "rsync://") && !strings.Contains(config.Destination, "::") {
+		port := config.Port
+		if port == 0 {
+			port = 22
+		}
+		options += fmt.Sprintf(` -e "ssh -p %d -o BatchMode=yes -o StrictHostKeyChecking=accept-new"`, port)
+	}
+
+	return header("远程同步脚本") + `source=` + shell.Quote(config.Path) + `
+destination=` + shell.Quote(config.Destination) + `
+
+# 执行同步
+rsync ` + options + ` "${source}" "${destination}" 2>&1
+`, nil
+}
+
+// header 脚本公共头部
+func header(title string) string {
+	return `#!/bin/bash
+export PATH=/bin:/sbin:/usr/bin:/usr/sbin:/usr/local/bin:/usr/local/sbin:$PATH
+
+# 耗子Linux面板 - ` + title + `
+
+`
+}
@@ -0,0 +1,143 @@
+// Package deploy 基于 Git 的网站发布，每次发布克隆到独立的版本目录，通过切换 current 软链接上线
+package deploy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"panel/pkg/shell"
+)
+
+const (
+	ReleasesDir = "releases" // 版本目录
+	SharedDir   = "shared"   // 各版本共享的目录和文件
+	CurrentLink = "current"  // 指向当前版本的软链接
+
+	releaseLayout = "20060102150405"
+)
+
+var (
+	repositoryPattern = regexp.MustCompile(`^(https://|ssh://|git@)[^\s'"\\]+$`)
+	branchPattern     = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_./-]*$`)
+	sharedPattern     = regexp.MustCompile(`^[a-zA-Z0-9_.-]+(/[a-zA-Z0-9_.-]+)*$`)
+	releasePattern    = regexp.MustCompile(`^[0-9]{14}$`)
+)
+
+// Config 发布配置
+type Config struct {
+	Path        string   // 网站目录
+	Repository  string   // 仓库地址
+	Branch      string   // 分支
+	Key         string   // 部署私钥文件
+	KnownHosts  string   // known_hosts 文件
+	Root        string   // 相对版本目录的运行目录，为空时为版本目录
+	SharedDirs  []string // 共享目录，如 storage
+	SharedFiles []string // 共享文件，如 .env
+	Build       string   // 构建命令，在切换版本前于版本目录中执行
+	PostDeploy  string   // 部署后命令，在切换版本后于 current 目录中执行
+	Keep        int      // 保留的版本数
+	Php         int      // 网站使用的 PHP 版本，构建命令优先使用该版本，切换版本后重载
+	Owner       string   // 网站文件所有者，用户组固定为 www，构建和部署后命令以该用户执行
+	Vhost       string   // 网站配置文件，切换版本后将其中的运行目录指向 current，为空时不修改
+}
+
+// Validate 校验发布配置
+func (c Config) Validate() error {
+	if !shell.ValidPath(c.Path) {
+		return errors.New("网站目录不合法")
+	}
+	if !repositoryPattern.MatchString(c.Repository) {
+		return errors.New("仓库地址不合法，仅支持 https:// 、ssh:// 和 git@ 开头的地址")
+	}
+	if !branchPattern.MatchString(c.Branch) || strings.Contains(c.Branch, "..") {
+		return errors.New("分支名不合法")
+	}
+	if len(c.Root) > 0 && !validPath(c.Root) {
+		return errors.New("运行目录不合法")
+	}
+	for _, item := range append(append([]string{}, c.SharedDirs...), c.SharedFiles...) {
+		if !validPath(item) {
+			return errors.New("共享路径 " + item + " 不合法")
+		}
+	}
+	if c.Keep < 1 || c.Keep > 50 {
+		return errors.New("保留版本数需在 1 到 50 之间")
+	}
+	if !shell.NamePattern.MatchString(c.Owner) {
+		return errors.New("网站文件所有者不合法")
+	}
+	if len(c.Vhost) > 0 && !shell.ValidPath(c.Vhost) {
+		return errors.New("网站配置文件路径不合法")
+	}
+
+	return nil
+}
+
+// ReleaseName 生成版本名
+func ReleaseName(t time.Time) string {
+	return t.Format(releaseLayout)
+}
+
+// Current 获取网站当前的版本名，未发布过时返回空
+func Current(path string) string {
+	target, err := os.Readlink(filepath.Join(path, CurrentLink))
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Base(target)
+}
+
+// Activate 将 current 软链接原子地切换到指定版本
+func Activate(path, release string) error {
+	if !releasePattern.MatchString(release) {
+		return errors.New("版本名不合法")
+	}
+	if info, err := os.Stat(filepath.Join(path, ReleasesDir, release)); err != nil || !info.IsDir() {
+		return errors.New("版本 " + release + " 不存在")
+	}
+	if err := CheckLink(path); err != nil {
+		return err
+	}
+
+	// 先创建临时软链接再重命名覆盖，rename 是原子操作，切换过程中不会出现 current 不存在的情况
+	tmp := filepath.Join(path, "."+CurrentLink+".tmp")
+	_ = os.Remove(tmp)
+	if err := os.Symlink(filepath.Join(ReleasesDir, release), tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(path, CurrentLink)); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	return nil
+}
+
+// CheckLink 检查 current 不是普通文件或目录，否则无法切换版本
+func CheckLink(path string) error {
+	info, err := os.Lstat(filepath.Join(path, CurrentLink))
+	if err != nil || info.Mode()&os.ModeSymlink != 0 {
+		return nil
+	}
+
+	return errors.New("网站目录中已存在 " + CurrentLink + "，请先移除")
+}
+
+// validPath 校验相对路径，不允许跳出版本目录或覆盖 .git
+func validPath(path string) bool {
+	if !sharedPattern.MatchString(path) {
+		return false
+	}
+	for _, part := range strings.Split(path, "/") {
+		if part == "." || part == ".." || part == ".git" {
+			return false
+		}
+	}
+
+	return true
+}
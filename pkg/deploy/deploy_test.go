@@ -0,0 +1,147 @@
+package deploy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type DeployTestSuite struct {
+	suite.Suite
+}
+
+func TestDeployTestSuite(t *testing.T) {
+	suite.Run(t, &DeployTestSuite{})
+}
+
+func (s *DeployTestSuite) config() Config {
+	return Config{
+		Path:        "/www/wwwroot/example.com",
+		Repository:  "git@github.com:example/app.git",
+		Branch:      "main",
+		Key:         "/www/server/vhost/deploy/example.com",
+		KnownHosts:  "/www/server/vhost/deploy/known_hosts",
+		Root:        "public",
+		SharedDirs:  []string{"storage"},
+		SharedFiles: []string{".env"},
+		Build:       "composer install --no-dev",
+		PostDeploy:  "php artisan migrate --force",
+		Keep:        5,
+		Php:         82,
+		Owner:       "www",
+		Vhost:       "/www/server/vhost/example.com.conf",
+	}
+}
+
+func (s *DeployTestSuite) TestValidate() {
+	s.NoError(s.config().Validate())
+
+	cfg := s.config()
+	cfg.Repository = "file:///etc"
+	s.Error(cfg.Validate())
+	cfg = s.config()
+	cfg.Repository = "https://example.com/app.git' ; id"
+	s.Error(cfg.Validate())
+	cfg = s.config()
+	cfg.Branch = "--upload-pack=id"
+	s.Error(cfg.Validate())
+	cfg = s.config()
+	cfg.SharedDirs = []string{"../etc"}
+	s.Error(cfg.Validate())
+	cfg = s.config()
+	cfg.SharedFiles = []string{".git/config"}
+	s.Error(cfg.Validate())
+	cfg = s.config()
+	cfg.Keep = 0
+	s.Error(cfg.Validate())
+	cfg = s.config()
+	cfg.Owner = "www -- id"
+	s.Error(cfg.Validate())
+}
+
+func (s *DeployTestSuite) TestScript() {
+	release := ReleaseName(time.Date(2023, 12, 1, 10, 30, 0, 0, time.UTC))
+	s.Equal("20231201103000", release)
+
+	script, err := Script(s.config(), release)
+	s.NoError(err)
+	s.Contains(script, "release=20231201103000\n")
+	s.Contains(script, `git clone --depth 1 --single-branch --branch 'main' 'git@github.com:example/app.git' "${releasePath}"`)
+	s.Contains(script, `-i '\''/www/server/vhost/deploy/example.com'\''`)
+	s.Contains(script, `ln -s "${sharedPath}/storage" 'storage'`)
+	s.Contains(script, `touch "${sharedPath}/.env"`)
+	s.Contains(script, `cp "${sitePath}/.user.ini" "${releasePath}/public"/.user.ini`)
+	s.Contains(script, `runuser -u www -- env PATH="/www/server/php/82/bin:${PATH}" COMPOSER_HOME=/tmp/composer-www bash -e -c 'composer install --no-dev'`+"\n")
+	s.Contains(script, `runuser -u www -- env PATH="/www/server/php/82/bin:${PATH}" COMPOSER_HOME=/tmp/composer-www bash -e -c 'php artisan migrate --force' || {`)
+	s.Less(strings.Index(script, `chown -R www:www`), strings.Index(script, "composer install"))
+	s.Contains(script, `mv -Tf "${sitePath}/.current.tmp" "${sitePath}/current"`)
+	s.Contains(script, "systemctl reload php-fpm-82")
+	s.Contains(script, "tail -n +6")
+	s.Less(strings.Index(script, "composer install"), strings.Index(script, "mv -Tf"))
+	s.Less(strings.Index(script, "mv -Tf"), strings.Index(script, "php artisan migrate"))
+	s.Contains(script, `s|root .*;|root /www/wwwroot/example.com/current/public;|' '/www/server/vhost/example.com.conf' && systemctl reload openresty`)
+	s.Less(strings.Index(script, "mv -Tf"), strings.Index(script, "# root标记位开始"))
+
+	cfg := s.config()
+	cfg.Build, cfg.PostDeploy, cfg.Php, cfg.Vhost = "", "", 0, ""
+	script, err = Script(cfg, release)
+	s.NoError(err)
+	s.NotContains(script, "runuser")
+	s.NotContains(script, "php-fpm")
+	s.NotContains(script, "openresty")
+
+	_, err = Script(s.config(), "../../etc")
+	s.Error(err)
+}
+
+func (s *DeployTestSuite) TestActivate() {
+	path := s.T().TempDir()
+	s.Equal("", Current(path))
+	s.Error(Activate(path, "20231201103000"))
+
+	for _, release := range []string{"20231201103000", "20231201104000"} {
+		s.NoError(os.MkdirAll(filepath.Join(path, ReleasesDir, release), 0755))
+	}
+	s.NoError(Activate(path, "20231201103000"))
+	s.Equal("20231201103000", Current(path))
+	s.NoError(Activate(path, "20231201104000"))
+	s.Equal("20231201104000", Current(path))
+	s.NoFileExists(filepath.Join(path, ".current.tmp"))
+	s.Error(Activate(path, "../releases"))
+
+	// current 为普通目录时拒绝切换
+	other := s.T().TempDir()
+	s.NoError(os.MkdirAll(filepath.Join(other, ReleasesDir, "20231201103000"), 0755))
+	s.NoError(os.Mkdir(filepath.Join(other, CurrentLink), 0755))
+	s.Error(Activate(other, "20231201103000"))
+}
+
+func (s *DeployTestSuite) TestVerify() {
+	body := []byte(`{"ref":"refs/heads/main"}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	s.True(Verify(http.Header{"X-Hub-Signature-256": {"sha256=" + signature}}, body, "secret"))
+	s.False(Verify(http.Header{"X-Hub-Signature-256": {"sha256=" + signature}}, body, "other"))
+	s.False(Verify(http.Header{"X-Hub-Signature-256": {signature}}, body, "secret"))
+	s.True(Verify(http.Header{"X-Gitea-Signature": {signature}}, body, "secret"))
+	s.False(Verify(http.Header{"X-Gitea-Signature": {signature}}, []byte(`{}`), "secret"))
+	s.True(Verify(http.Header{"X-Gitlab-Token": {"secret"}}, body, "secret"))
+	s.False(Verify(http.Header{"X-Gitlab-Token": {"secre"}}, body, "secret"))
+	s.False(Verify(http.Header{}, body, "secret"))
+	s.False(Verify(http.Header{"X-Gitlab-Token": {""}}, body, ""))
+
+	s.Equal("main", Branch(body))
+	s.Equal("feature/a", Branch([]byte(`{"ref":"refs/heads/feature/a"}`)))
+	s.Equal("", Branch([]byte(`{"ref":"refs/tags/v1.0.0"}`)))
+	s.Equal("", Branch([]byte(`{"zen":"ping"}`)))
+}
@@ -0,0 +1,148 @@
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"panel/pkg/shell"
+)
+
+// Script 生成发布指定版本的 bash 脚本
+func Script(cfg Config, release string) (string, error) {
+	if err := cfg.Validate(); err != nil {
+		return "", err
+	}
+	if !releasePattern.MatchString(release) {
+		return "", errors.New("版本名不合法")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(shell.Header)
+	sb.WriteString("sitePath=" + shell.Quote(cfg.Path) + "\n")
+	sb.WriteString("release=" + release + "\n")
+	sb.WriteString(`releasePath="${sitePath}/` + ReleasesDir + `/${release}"` + "\n")
+	sb.WriteString(`sharedPath="${sitePath}/` + SharedDir + `"` + "\n")
+	sb.WriteString(`
+set -e
+trap 'echo -e $HR; echo "版本 ${release} 发布失败"; rm -rf "${releasePath}"' ERR
+
+echo "开始发布版本 ${release}"
+mkdir -p "${sitePath}/` + ReleasesDir + `" "${sharedPath}"
+`)
+
+	sb.WriteString(`export GIT_TERMINAL_PROMPT=0` + "\n")
+	sb.WriteString(`export GIT_SSH_COMMAND=` + shell.Quote("ssh -i "+shell.Quote(cfg.Key)+" -o IdentitiesOnly=yes -o StrictHostKeyChecking=accept-new -o UserKnownHostsFile="+shell.Quote(cfg.KnownHosts)) + "\n")
+	sb.WriteString(`git clone --depth 1 --single-branch --branch ` + shell.Quote(cfg.Branch) + ` ` + shell.Quote(cfg.Repository) + ` "${releasePath}"` + "\n")
+	sb.WriteString(`cd "${releasePath}"
+git rev-parse HEAD > REVISION
+echo "提交: $(git log -1 --pretty='%h %s')"
+rm -rf .git
+`)
+
+	if len(cfg.SharedDirs) > 0 || len(cfg.SharedFiles) > 0 {
+		sb.WriteString("\n# 链接共享目录和文件，首次发布时以仓库中的内容初始化\n")
+	}
+	for _, dir := range cfg.SharedDirs {
+		sb.WriteString(`if [ ! -d "${sharedPath}/` + dir + `" ]; then
+    mkdir -p "${sharedPath}/` + dir + `"
+    if [ -d ` + shell.Quote(dir) + ` ]; then cp -a ` + shell.Quote(dir+"/.") + ` "${sharedPath}/` + dir + `/"; fi
+fi
+`)
+		writeLink(&sb, dir)
+	}
+	for _, file := range cfg.SharedFiles {
+		sb.WriteString(`if [ ! -f "${sharedPath}/` + file + `" ]; then
+    mkdir -p "$(dirname "${sharedPath}/` + file + `")"
+    if [ -f ` + shell.Quote(file) + ` ]; then cp -a ` + shell.Quote(file) + ` "${sharedPath}/` + file + `"; else touch "${sharedPath}/` + file + `"; fi
+fi
+`)
+		writeLink(&sb, file)
+	}
+
+	root := `"${releasePath}"`
+	if len(cfg.Root) > 0 {
+		root = `"${releasePath}/` + cfg.Root + `"`
+	}
+	sb.WriteString(`
+# 防跨站配置随运行目录迁移
+if [ -f "${sitePath}/.user.ini" ] && [ -d ` + root + ` ]; then
+    cp "${sitePath}/.user.ini" ` + root + `/.user.ini
+fi
+`)
+
+	sb.WriteString(`chown -R ` + cfg.Owner + `:www "${releasePath}" "${sharedPath}"` + "\n")
+	if len(cfg.Build) > 0 {
+		sb.WriteString("\necho -e $HR\necho \"执行构建命令\"\n")
+		sb.WriteString(runAsOwner(cfg, cfg.Build) + "\n")
+	}
+
+	sb.WriteString(`
+# 原子切换 current 软链接
+ln -sfn "` + ReleasesDir + `/${release}" "${sitePath}/.` + CurrentLink + `.tmp"
+mv -Tf "${sitePath}/.` + CurrentLink + `.tmp" "${sitePath}/` + CurrentLink + `"
+echo "已切换到版本 ${release}"
+`)
+	if len(cfg.Vhost) > 0 {
+		writeVhost(&sb, cfg)
+	}
+	if cfg.Php > 0 {
+		// 重载以清除 OPcache 和 realpath 缓存，否则可能继续执行旧版本的文件
+		sb.WriteString(fmt.Sprintf("systemctl reload php-fpm-%d || true\n", cfg.Php))
+	}
+
+	if len(cfg.PostDeploy) > 0 {
+		// 版本已上线，部署后命令失败时不能再删除版本目录
+		sb.WriteString(`
+trap - ERR
+echo -e $HR
+echo "执行部署后命令"
+cd "${sitePath}/` + CurrentLink + `"
+`)
+		sb.WriteString(runAsOwner(cfg, cfg.PostDeploy) + ` || { echo "部署后命令执行失败"; exit 1; }` + "\n")
+	}
+
+	sb.WriteString(fmt.Sprintf(`
+# 清理旧版本，保留最近 %d 个
+trap - ERR
+cd "${sitePath}/`+ReleasesDir+`"
+current=$(basename "$(readlink "${sitePath}/`+CurrentLink+`")")
+ls -1 | grep -E '^[0-9]{14}$' | sort -r | tail -n +%d | while read -r old; do
+    if [ "${old}" != "${current}" ]; then rm -rf "${old}"; echo "已清理版本 ${old}"; fi
+done
+
+echo -e $HR
+echo "版本 ${release} 发布成功"
+`, cfg.Keep, cfg.Keep+1))
+
+	return sb.String(), nil
+}
+
+// runAsOwner 以网站文件所有者的身份执行仓库中配置的命令，不以 root 运行仓库中的代码
+func runAsOwner(cfg Config, command string) string {
+	path := "${PATH}"
+	if cfg.Php > 0 {
+		path = fmt.Sprintf("/www/server/php/%d/bin:${PATH}", cfg.Php)
+	}
+
+	return `runuser -u ` + cfg.Owner + ` -- env PATH="` + path + `" COMPOSER_HOME=/tmp/composer-` + cfg.Owner + ` bash -e -c ` + shell.Quote(command)
+}
+
+// writeVhost 首次切换版本成功后再将网站运行目录指向 current，此前网站保持原样
+func writeVhost(sb *strings.Builder, cfg Config) {
+	root := filepath.Join(cfg.Path, CurrentLink, cfg.Root)
+	sb.WriteString(`if [ -f ` + shell.Quote(cfg.Vhost) + ` ] && ! grep -qF ` + shell.Quote("root "+root+";") + ` ` + shell.Quote(cfg.Vhost) + `; then
+    sed -i '/# root标记位开始/,/# root标记位结束/s|root .*;|root ` + root + `;|' ` + shell.Quote(cfg.Vhost) + ` && systemctl reload openresty || echo "切换网站运行目录失败，请手动修改为 ` + root + `"
+fi
+`)
+}
+
+// writeLink 将版本中的路径替换为指向共享目录的软链接
+func writeLink(sb *strings.Builder, path string) {
+	sb.WriteString(`rm -rf ` + shell.Quote(path) + "\n")
+	if dir := filepath.Dir(path); dir != "." {
+		sb.WriteString(`mkdir -p ` + shell.Quote(dir) + "\n")
+	}
+	sb.WriteString(`ln -s "${sharedPath}/` + path + `" ` + shell.Quote(path) + "\n")
+}
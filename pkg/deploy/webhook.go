@@ -0,0 +1,60 @@
+package deploy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Verify 校验 Webhook 请求，支持 GitHub、Gitea 的 HMAC 签名及 GitLab、Gitee 的令牌
+func Verify(header http.Header, body []byte, secret string) bool {
+	if len(secret) == 0 {
+		return false
+	}
+
+	if signature := header.Get("X-Hub-Signature-256"); len(signature) > 0 {
+		signature, ok := strings.CutPrefix(signature, "sha256=")
+		return ok && checkSignature(body, secret, signature)
+	}
+	if signature := header.Get("X-Gitea-Signature"); len(signature) > 0 {
+		return checkSignature(body, secret, signature)
+	}
+	for _, key := range []string{"X-Gitlab-Token", "X-Gitee-Token"} {
+		if token := header.Get(key); len(token) > 0 {
+			return subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+		}
+	}
+
+	return false
+}
+
+// Branch 获取推送事件的分支，非分支推送（如 ping、标签推送）返回空
+func Branch(body []byte) string {
+	var payload struct {
+		Ref string `json:"ref"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	branch, ok := strings.CutPrefix(payload.Ref, "refs/heads/")
+	if !ok {
+		return ""
+	}
+
+	return branch
+}
+
+func checkSignature(body []byte, secret, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return hmac.Equal(mac.Sum(nil), expected)
+}
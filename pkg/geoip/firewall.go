@@ -0,0 +1,119 @@
+package geoip
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// SetPrefix 面板创建的国家 IP 集合名前缀
+const SetPrefix = "panel_geo_"
+
+// ufw before.rules 中面板规则的标记
+const (
+	ufwBegin = "# 面板国家规则开始"
+	ufwEnd   = "# 面板国家规则结束"
+)
+
+// FirewallRule 防火墙国家规则
+type FirewallRule struct {
+	Country  string
+	Action   string // accept 或 drop
+	Port     string // 端口或 a-b 形式的端口范围，为空时匹配所有端口
+	Protocol string // tcp 或 udp
+}
+
+// Validate 校验防火墙国家规则
+func (r FirewallRule) Validate() error {
+	if err := ValidateCountries([]string{r.Country}); err != nil {
+		return err
+	}
+	if r.Action != "accept" && r.Action != "drop" {
+		return errors.New("动作只能为 accept 或 drop")
+	}
+	if len(r.Port) == 0 {
+		return nil
+	}
+	if r.Protocol != "tcp" && r.Protocol != "udp" {
+		return errors.New("协议只能为 tcp 或 udp")
+	}
+
+	start, end, ok := strings.Cut(r.Port, "-")
+	if !ok {
+		end = start
+	}
+	startPort, err1 := strconv.Atoi(start)
+	endPort, err2 := strconv.Atoi(end)
+	if err1 != nil || err2 != nil || startPort < 1 || endPort > 65535 || startPort > endPort {
+		return errors.New("端口 " + r.Port + " 不合法")
+	}
+
+	return nil
+}
+
+// SetName 国家 IP 集合名，family 为 inet 或 inet6
+func SetName(country, family string) string {
+	if family == "inet6" {
+		return SetPrefix + strings.ToLower(country) + "_6"
+	}
+
+	return SetPrefix + strings.ToLower(country) + "_4"
+}
+
+// RichRule 生成 firewalld 的富规则，family 为 inet 或 inet6
+func RichRule(rule FirewallRule, family string) string {
+	ipv := "ipv4"
+	if family == "inet6" {
+		ipv = "ipv6"
+	}
+
+	parts := []string{"rule", `family="` + ipv + `"`, `source ipset="` + SetName(rule.Country, family) + `"`}
+	if len(rule.Port) > 0 {
+		parts = append(parts, `port port="`+rule.Port+`" protocol="`+rule.Protocol+`"`)
+	}
+	parts = append(parts, rule.Action)
+
+	return strings.Join(parts, " ")
+}
+
+// IptablesRule 生成 ufw before.rules 中的 iptables 规则，family 为 inet 或 inet6
+func IptablesRule(rule FirewallRule, family string) string {
+	parts := []string{"-A ufw-before-input -m set --match-set " + SetName(rule.Country, family) + " src"}
+	if family == "inet6" {
+		parts[0] = strings.Replace(parts[0], "ufw-before-input", "ufw6-before-input", 1)
+	}
+	if len(rule.Port) > 0 {
+		parts = append(parts, "-p "+rule.Protocol+" --dport "+strings.Replace(rule.Port, "-", ":", 1))
+	}
+	parts = append(parts, "-j "+strings.ToUpper(rule.Action))
+
+	return strings.Join(parts, " ")
+}
+
+// WriteUfwRules 将规则写入 ufw 的 before.rules 或 before6.rules，插入到 filter 表的 COMMIT 之前
+func WriteUfwRules(conf string, rules []string) (string, error) {
+	// 清除旧规则
+	if begin := strings.Index(conf, ufwBegin); begin != -1 {
+		end := strings.Index(conf, ufwEnd)
+		if end == -1 || end < begin {
+			return "", errors.New("ufw 规则文件中的面板规则标记不完整")
+		}
+		conf = conf[:begin] + strings.TrimPrefix(conf[end+len(ufwEnd):], "\n")
+	}
+	if len(rules) == 0 {
+		return conf, nil
+	}
+
+	filter := strings.Index(conf, "*filter")
+	if filter == -1 {
+		return "", errors.New("ufw 规则文件中缺少 filter 表")
+	}
+	commit := strings.Index(conf[filter:], "\nCOMMIT")
+	if commit == -1 {
+		return "", errors.New("ufw 规则文件中缺少 COMMIT")
+	}
+	commit += filter + 1
+
+	block := ufwBegin + "\n" + strings.Join(rules, "\n") + "\n" + ufwEnd + "\n"
+	return conf[:commit] + block + conf[commit:], nil
+}
@@ -0,0 +1,304 @@
+package geoip
+
+import (
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type GeoIPTestSuite struct {
+	suite.Suite
+}
+
+func TestGeoIPTestSuite(t *testing.T) {
+	suite.Run(t, &GeoIPTestSuite{})
+}
+
+var testNetworks = map[string]string{
+	"1.0.0.0/8":     "CN",
+	"2.0.0.0/7":     "US",
+	"4.4.4.0/24":    "CN",
+	"2001:db8::/32": "CN",
+}
+
+func (s *GeoIPTestSuite) TestIPv4() {
+	for _, recordSize := range []int{24, 28, 32} {
+		reader, err := FromBytes(buildDatabase(4, recordSize, testNetworks))
+		s.Require().NoError(err)
+		s.Equal("Test-Country", reader.Metadata.DatabaseType)
+		s.Equal(uint64(1700000000), reader.Metadata.BuildEpoch)
+
+		country, err := reader.Country(net.ParseIP("1.2.3.4"))
+		s.NoError(err)
+		s.Equal("CN", country)
+		country, err = reader.Country(net.ParseIP("3.255.0.1"))
+		s.NoError(err)
+		s.Equal("US", country)
+		country, err = reader.Country(net.ParseIP("8.8.8.8"))
+		s.NoError(err)
+		s.Equal("", country)
+		_, err = reader.Country(net.ParseIP("2001:db8::1"))
+		s.Error(err)
+
+		networks, err := reader.Networks("cn")
+		s.NoError(err)
+		s.Equal([]string{"1.0.0.0/8", "4.4.4.0/24"}, strings.Fields(join(networks)))
+	}
+}
+
+func (s *GeoIPTestSuite) TestIPv6() {
+	reader, err := FromBytes(buildDatabase(6, 28, testNetworks))
+	s.Require().NoError(err)
+
+	country, err := reader.Country(net.ParseIP("4.4.4.4"))
+	s.NoError(err)
+	s.Equal("CN", country)
+	country, err = reader.Country(net.ParseIP("2001:db8:1::1"))
+	s.NoError(err)
+	s.Equal("CN", country)
+
+	networks, err := reader.Networks("CN")
+	s.NoError(err)
+	s.Equal([]string{"1.0.0.0/8", "4.4.4.0/24", "2001:db8::/32"}, strings.Fields(join(networks)))
+
+	ipv4, ipv6 := Split(networks)
+	s.Len(ipv4, 2)
+	s.Len(ipv6, 1)
+}
+
+func (s *GeoIPTestSuite) TestInvalid() {
+	_, err := FromBytes([]byte("not a database"))
+	s.Error(err)
+
+	buffer := buildDatabase(4, 24, testNetworks)
+	_, err = FromBytes(buffer[len(buffer)-100:])
+	s.Error(err)
+
+	// 指向自身的指针
+	_, _, err = (&decoder{buffer: []byte{0x20, 0x00}}).decode(0, 0)
+	s.Error(err)
+	// 指向指针的指针
+	_, _, err = (&decoder{buffer: []byte{0x20, 0x02, 0x20, 0x00}}).decode(0, 0)
+	s.Error(err)
+	// 嵌套过深的 array
+	nested := make([]byte, 0, 2*(maxDepth+2))
+	for i := 0; i < maxDepth+2; i++ {
+		nested = append(nested, 0x01, 0x04)
+	}
+	_, _, err = (&decoder{buffer: nested}).decode(0, 0)
+	s.Error(err)
+	value, _, err := (&decoder{buffer: []byte{0x20, 0x02, 0x42, 'C', 'N'}}).decode(0, 0)
+	s.NoError(err)
+	s.Equal("CN", value)
+}
+
+func (s *GeoIPTestSuite) TestValidateCountries() {
+	s.NoError(ValidateCountries([]string{"CN", "US"}))
+	s.Error(ValidateCountries([]string{"cn"}))
+	s.Error(ValidateCountries([]string{"CHN"}))
+}
+
+func (s *GeoIPTestSuite) TestGeo() {
+	_, network, _ := net.ParseCIDR("1.0.0.0/8")
+	deny := Geo("website_1_country_blocked", false, []*net.IPNet{network})
+	s.Contains(deny, "geo $website_1_country_blocked_country {\n    default 0;\n    1.0.0.0/8 1;\n}\n")
+	s.Contains(deny, "map \"$website_1_country_blocked_country:$uri\" $website_1_country_blocked {\n")
+	s.Contains(deny, "    \"~^1:/\\.well-known/acme-challenge/\" 0;\n    \"~^1:\" 1;\n")
+
+	allow := Geo("website_1_country_blocked", true, []*net.IPNet{network})
+	s.Contains(allow, "    default 1;\n    127.0.0.0/8 0;\n")
+	s.Contains(allow, "    1.0.0.0/8 0;\n}\n")
+}
+
+func (s *GeoIPTestSuite) TestWrite() {
+	conf := "server\n{\n    # waf标记位开始\n    # waf标记位结束\n    # limit标记位开始\n    # limit标记位结束\n}\n"
+	conf, err := Write(conf, Block("website_1_country_blocked", 403))
+	s.NoError(err)
+	s.Contains(conf, "    # limit标记位结束\n    # geo标记位开始\n    if ($website_1_country_blocked) {\n        return 403;\n    }\n    # geo标记位结束\n}")
+
+	conf, err = Write(conf, "")
+	s.NoError(err)
+	s.Contains(conf, "    # geo标记位开始\n    # geo标记位结束\n")
+	s.NotContains(conf, "return 403")
+
+	conf, err = Write("server\n{\n    # waf标记位开始\n    # waf标记位结束\n}\n", "")
+	s.NoError(err)
+	s.Contains(conf, "    # waf标记位结束\n    # geo标记位开始\n    # geo标记位结束\n}")
+
+	_, err = Write("server {}", "")
+	s.Error(err)
+}
+
+func (s *GeoIPTestSuite) TestIpset() {
+	_, network, _ := net.ParseCIDR("1.0.0.0/8")
+	s.Equal("create panel_cn_4 hash:net family inet maxelem 65536 -exist\nflush panel_cn_4\nadd panel_cn_4 1.0.0.0/8\n",
+		Ipset("panel_cn_4", "inet", []*net.IPNet{network}))
+
+	xml := FirewalldIpset("inet", []*net.IPNet{network})
+	s.Contains(xml, "<ipset type=\"hash:net\">")
+	s.Contains(xml, "<option name=\"family\" value=\"inet\"/>")
+	s.Contains(xml, "<entry>1.0.0.0/8</entry>")
+}
+
+func (s *GeoIPTestSuite) TestFirewallRule() {
+	s.NoError(FirewallRule{Country: "CN", Action: "drop"}.Validate())
+	s.NoError(FirewallRule{Country: "CN", Action: "accept", Port: "22", Protocol: "tcp"}.Validate())
+	s.NoError(FirewallRule{Country: "CN", Action: "drop", Port: "8000-9000", Protocol: "udp"}.Validate())
+	s.Error(FirewallRule{Country: "CN", Action: "reject"}.Validate())
+	s.Error(FirewallRule{Country: "CN", Action: "drop", Port: "22", Protocol: "icmp"}.Validate())
+	s.Error(FirewallRule{Country: "CN", Action: "drop", Port: "9000-8000", Protocol: "tcp"}.Validate())
+	s.Error(FirewallRule{Country: "CN", Action: "drop", Port: "70000", Protocol: "tcp"}.Validate())
+
+	rule := FirewallRule{Country: "CN", Action: "drop", Port: "8000-9000", Protocol: "tcp"}
+	s.Equal(`rule family="ipv4" source ipset="panel_geo_cn_4" port port="8000-9000" protocol="tcp" drop`, RichRule(rule, "inet"))
+	s.Equal(`rule family="ipv6" source ipset="panel_geo_cn_6" accept`, RichRule(FirewallRule{Country: "CN", Action: "accept"}, "inet6"))
+	s.Equal("-A ufw-before-input -m set --match-set panel_geo_cn_4 src -p tcp --dport 8000:9000 -j DROP", IptablesRule(rule, "inet"))
+	s.Equal("-A ufw6-before-input -m set --match-set panel_geo_cn_6 src -j ACCEPT", IptablesRule(FirewallRule{Country: "CN", Action: "accept"}, "inet6"))
+}
+
+func (s *GeoIPTestSuite) TestWriteUfwRules() {
+	conf := "*filter\n:ufw-before-input - [0:0]\n-A ufw-before-input -i lo -j ACCEPT\n\n# don't delete the 'COMMIT' line\nCOMMIT\n"
+	written, err := WriteUfwRules(conf, []string{"-A ufw-before-input -m set --match-set panel_geo_cn_4 src -j DROP"})
+	s.NoError(err)
+	s.Equal("*filter\n:ufw-before-input - [0:0]\n-A ufw-before-input -i lo -j ACCEPT\n\n# don't delete the 'COMMIT' line\n# 面板国家规则开始\n-A ufw-before-input -m set --match-set panel_geo_cn_4 src -j DROP\n# 面板国家规则结束\nCOMMIT\n", written)
+
+	again, err := WriteUfwRules(written, []string{"-A ufw-before-input -m set --match-set panel_geo_us_4 src -j DROP"})
+	s.NoError(err)
+	s.Equal(1, strings.Count(again, "# 面板国家规则开始"))
+	s.NotContains(again, "panel_geo_cn_4")
+
+	cleared, err := WriteUfwRules(written, nil)
+	s.NoError(err)
+	s.Equal(conf, cleared)
+
+	_, err = WriteUfwRules("COMMIT\n", []string{"-A x"})
+	s.Error(err)
+}
+
+func join(networks []*net.IPNet) string {
+	var items []string
+	for _, network := range networks {
+		items = append(items, network.String())
+	}
+
+	return strings.Join(items, " ")
+}
+
+// buildDatabase 生成测试用的 mmdb 数据库
+func buildDatabase(ipVersion, recordSize int, networks map[string]string) []byte {
+	type node struct {
+		children [2]int // 0 为空，正数为节点编号 + 1，负数为数据编号 - 1
+	}
+	nodes := []node{{}}
+
+	// 数据段，第二条及之后的数据通过指针复用第一条数据中的 country 键
+	var data []byte
+	offsets := make(map[string]int)
+	record := func(country string) int {
+		if offset, ok := offsets[country]; ok {
+			return offset
+		}
+		offset := len(data)
+		data = append(data, 7<<5|1)
+		if offset == 0 {
+			data = append(data, encodeString("country")...)
+		} else {
+			data = append(data, 1<<5, 1)
+		}
+		data = append(data, 7<<5|1)
+		data = append(data, encodeString("iso_code")...)
+		data = append(data, encodeString(country)...)
+		offsets[country] = offset
+		return offset
+	}
+	// 按固定顺序插入，保证数据段稳定
+	for _, cidr := range []string{"1.0.0.0/8", "2.0.0.0/7", "4.4.4.0/24", "2001:db8::/32"} {
+		country, ok := networks[cidr]
+		if !ok {
+			continue
+		}
+		_, network, _ := net.ParseCIDR(cidr)
+		ones, _ := network.Mask.Size()
+		ip := []byte(network.IP.To16())
+		if network.IP.To4() != nil {
+			if ipVersion == 4 {
+				ip = network.IP.To4()
+			} else {
+				ip = append(make([]byte, 12), network.IP.To4()...)
+				ones += 96
+			}
+		} else if ipVersion == 4 {
+			continue
+		}
+
+		offset := record(country)
+		current := 0
+		for i := 0; i < ones; i++ {
+			bit := int(ip[i/8]>>(7-i%8)) & 1
+			if i == ones-1 {
+				nodes[current].children[bit] = -(offset + 1)
+				break
+			}
+			if nodes[current].children[bit] <= 0 {
+				nodes = append(nodes, node{})
+				nodes[current].children[bit] = len(nodes)
+			}
+			current = nodes[current].children[bit] - 1
+		}
+	}
+
+	nodeCount := len(nodes)
+	value := func(child int) uint64 {
+		switch {
+		case child == 0:
+			return uint64(nodeCount)
+		case child > 0:
+			return uint64(child - 1)
+		default:
+			return uint64(nodeCount + 16 + (-child - 1))
+		}
+	}
+
+	var buffer []byte
+	for _, n := range nodes {
+		left, right := value(n.children[0]), value(n.children[1])
+		switch recordSize {
+		case 24:
+			buffer = append(buffer, byte(left>>16), byte(left>>8), byte(left), byte(right>>16), byte(right>>8), byte(right))
+		case 28:
+			buffer = append(buffer, byte(left>>16), byte(left>>8), byte(left), byte(left>>24)<<4|byte(right>>24), byte(right>>16), byte(right>>8), byte(right))
+		default:
+			b := make([]byte, 8)
+			binary.BigEndian.PutUint32(b, uint32(left))
+			binary.BigEndian.PutUint32(b[4:], uint32(right))
+			buffer = append(buffer, b...)
+		}
+	}
+	buffer = append(buffer, make([]byte, 16)...)
+	buffer = append(buffer, data...)
+	buffer = append(buffer, metadataMarker...)
+
+	buffer = append(buffer, 7<<5|5)
+	buffer = append(buffer, encodeString("node_count")...)
+	buffer = append(buffer, 6<<5|4, byte(nodeCount>>24), byte(nodeCount>>16), byte(nodeCount>>8), byte(nodeCount))
+	buffer = append(buffer, encodeString("record_size")...)
+	buffer = append(buffer, 5<<5|2, byte(recordSize>>8), byte(recordSize))
+	buffer = append(buffer, encodeString("ip_version")...)
+	buffer = append(buffer, 5<<5|1, byte(ipVersion))
+	buffer = append(buffer, encodeString("database_type")...)
+	buffer = append(buffer, encodeString("Test-Country")...)
+	buffer = append(buffer, encodeString("build_epoch")...)
+	epoch := make([]byte, 8)
+	binary.BigEndian.PutUint64(epoch, 1700000000)
+	buffer = append(buffer, 8, 2)
+	buffer = append(buffer, epoch...)
+
+	return buffer
+}
+
+func encodeString(s string) []byte {
+	return append([]byte{byte(2<<5 | len(s))}, s...)
+}
@@ -0,0 +1,402 @@
+// Package geoip 读取 MaxMind 格式（mmdb）的 GeoIP 国家数据库，将国家展开为网段用于 OpenResty 和防火墙
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"net"
+	"os"
+	"strings"
+)
+
+// metadataMarker 元数据段的起始标记
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// Metadata 数据库元数据
+type Metadata struct {
+	DatabaseType string `json:"database_type"`
+	BuildEpoch   uint64 `json:"build_epoch"`
+	IPVersion    uint64 `json:"ip_version"`
+	NodeCount    uint64 `json:"node_count"`
+	RecordSize   uint64 `json:"record_size"`
+}
+
+// Reader mmdb 数据库
+type Reader struct {
+	Metadata Metadata
+
+	buffer   []byte
+	data     []byte // 数据段
+	ipv4Node uint64 // IPv6 数据库中 ::/96 对应的节点
+	cache    map[uint64]string
+}
+
+// Open 打开数据库文件
+func Open(file string) (*Reader, error) {
+	buffer, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return FromBytes(buffer)
+}
+
+// FromBytes 从内存中读取数据库
+func FromBytes(buffer []byte) (*Reader, error) {
+	start := bytes.LastIndex(buffer, metadataMarker)
+	if start == -1 {
+		return nil, errors.New("不是有效的 MaxMind 数据库")
+	}
+	start += len(metadataMarker)
+
+	raw, _, err := (&decoder{buffer: buffer[start:]}).decode(0, 0)
+	if err != nil {
+		return nil, err
+	}
+	values, ok := raw.(map[string]any)
+	if !ok {
+		return nil, errors.New("数据库元数据不合法")
+	}
+
+	metadata := Metadata{}
+	metadata.DatabaseType, _ = values["database_type"].(string)
+	metadata.BuildEpoch, _ = values["build_epoch"].(uint64)
+	metadata.IPVersion, _ = values["ip_version"].(uint64)
+	metadata.NodeCount, _ = values["node_count"].(uint64)
+	metadata.RecordSize, _ = values["record_size"].(uint64)
+	if metadata.RecordSize != 24 && metadata.RecordSize != 28 && metadata.RecordSize != 32 {
+		return nil, errors.New("不支持的数据库记录长度")
+	}
+	if metadata.IPVersion != 4 && metadata.IPVersion != 6 {
+		return nil, errors.New("不支持的数据库 IP 版本")
+	}
+
+	treeSize := metadata.NodeCount * metadata.RecordSize / 4
+	if treeSize+16 > uint64(start-len(metadataMarker)) {
+		return nil, errors.New("数据库已损坏")
+	}
+
+	reader := &Reader{
+		Metadata: metadata,
+		buffer:   buffer,
+		data:     buffer[treeSize+16 : start-len(metadataMarker)],
+		cache:    make(map[uint64]string),
+	}
+	if metadata.IPVersion == 6 {
+		for i := 0; i < 96 && reader.ipv4Node < metadata.NodeCount; i++ {
+			reader.ipv4Node, _ = reader.records(reader.ipv4Node)
+		}
+	}
+
+	return reader, nil
+}
+
+// Country 查询 IP 所属国家的 ISO 代码，未收录时返回空字符串
+func (r *Reader) Country(ip net.IP) (string, error) {
+	bits := ip.To4()
+	node := uint64(0)
+	if bits == nil {
+		if r.Metadata.IPVersion == 4 {
+			return "", errors.New("IPv4 数据库不支持查询 IPv6")
+		}
+		bits = ip.To16()
+	} else if r.Metadata.IPVersion == 6 {
+		node = r.ipv4Node
+	}
+	if bits == nil {
+		return "", errors.New("IP 不合法")
+	}
+
+	for i := 0; i < len(bits)*8 && node < r.Metadata.NodeCount; i++ {
+		left, right := r.records(node)
+		if bits[i/8]&(0x80>>(i%8)) == 0 {
+			node = left
+		} else {
+			node = right
+		}
+	}
+	if node <= r.Metadata.NodeCount {
+		return "", nil
+	}
+
+	return r.country(node)
+}
+
+// Networks 获取国家的全部网段，country 为 ISO 代码
+func (r *Reader) Networks(country string) ([]*net.IPNet, error) {
+	country = strings.ToUpper(country)
+	var networks []*net.IPNet
+
+	type item struct {
+		node  uint64
+		ip    []byte
+		depth int
+	}
+	size := 16
+	if r.Metadata.IPVersion == 4 {
+		size = 4
+	}
+	stack := []item{{node: 0, ip: make([]byte, size), depth: 0}}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if current.node > r.Metadata.NodeCount {
+			code, err := r.country(current.node)
+			if err != nil {
+				return nil, err
+			}
+			if code == country {
+				networks = append(networks, network(current.ip, current.depth))
+			}
+			continue
+		}
+		if current.node == r.Metadata.NodeCount || current.depth >= size*8 {
+			continue
+		}
+		// IPv6 数据库中 IPv4 网段通过 ::ffff:0:0/96 等别名重复出现，只在 ::/96 下遍历一次
+		if r.Metadata.IPVersion == 6 && current.node == r.ipv4Node {
+			if current.depth != 96 || !isZero(current.ip[:12]) {
+				continue
+			}
+			current.ip = current.ip[12:]
+			current.depth = 0
+		}
+
+		left, right := r.records(current.node)
+		rightIP := append([]byte(nil), current.ip...)
+		rightIP[current.depth/8] |= 0x80 >> (current.depth % 8)
+		stack = append(stack,
+			item{node: right, ip: rightIP, depth: current.depth + 1},
+			item{node: left, ip: append([]byte(nil), current.ip...), depth: current.depth + 1},
+		)
+	}
+
+	return networks, nil
+}
+
+// records 读取节点的左右记录
+func (r *Reader) records(node uint64) (uint64, uint64) {
+	switch r.Metadata.RecordSize {
+	case 24:
+		b := r.buffer[node*6:]
+		return uint64(b[0])<<16 | uint64(b[1])<<8 | uint64(b[2]),
+			uint64(b[3])<<16 | uint64(b[4])<<8 | uint64(b[5])
+	case 28:
+		b := r.buffer[node*7:]
+		return uint64(b[3]&0xf0)<<20 | uint64(b[0])<<16 | uint64(b[1])<<8 | uint64(b[2]),
+			uint64(b[3]&0x0f)<<24 | uint64(b[4])<<16 | uint64(b[5])<<8 | uint64(b[6])
+	default:
+		b := r.buffer[node*8:]
+		return uint64(binary.BigEndian.Uint32(b[:4])), uint64(binary.BigEndian.Uint32(b[4:8]))
+	}
+}
+
+// country 读取记录指向的数据中的国家代码，优先使用 country，其次为 registered_country
+func (r *Reader) country(record uint64) (string, error) {
+	offset := record - r.Metadata.NodeCount - 16
+	if code, ok := r.cache[offset]; ok {
+		return code, nil
+	}
+
+	raw, _, err := (&decoder{buffer: r.data}).decode(offset, 0)
+	if err != nil {
+		return "", err
+	}
+	code := ""
+	if values, ok := raw.(map[string]any); ok {
+		for _, key := range []string{"country", "registered_country"} {
+			if country, ok := values[key].(map[string]any); ok {
+				if code, _ = country["iso_code"].(string); len(code) > 0 {
+					break
+				}
+			}
+		}
+	}
+	r.cache[offset] = code
+
+	return code, nil
+}
+
+func network(ip []byte, depth int) *net.IPNet {
+	return &net.IPNet{IP: net.IP(ip), Mask: net.CIDRMask(depth, len(ip)*8)}
+}
+
+func isZero(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// decoder mmdb 数据段解码
+type decoder struct {
+	buffer []byte
+}
+
+var errInvalidData = errors.New("数据库数据段已损坏")
+
+// maxDepth map 和 array 的最大嵌套深度，防止损坏的数据库造成无限递归
+const maxDepth = 32
+
+// decode 解码 offset 处的数据，返回数据及下一个数据的位置，depth 为当前的嵌套深度
+func (d *decoder) decode(offset uint64, depth int) (any, uint64, error) {
+	if depth > maxDepth || offset >= uint64(len(d.buffer)) {
+		return nil, 0, errInvalidData
+	}
+	ctrl := d.buffer[offset]
+	offset++
+	kind := int(ctrl >> 5)
+
+	if kind == 1 {
+		pointer, next, err := d.pointer(ctrl, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		// 指针不能指向另一个指针
+		if pointer >= uint64(len(d.buffer)) || d.buffer[pointer]>>5 == 1 {
+			return nil, 0, errInvalidData
+		}
+		value, _, err := d.decode(pointer, depth)
+		return value, next, err
+	}
+
+	if kind == 0 {
+		if offset >= uint64(len(d.buffer)) {
+			return nil, 0, errInvalidData
+		}
+		kind = 7 + int(d.buffer[offset])
+		offset++
+	}
+	size, offset, err := d.size(ctrl, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch kind {
+	case 7: // map
+		values := make(map[string]any)
+		for i := uint64(0); i < size; i++ {
+			var key, value any
+			if key, offset, err = d.decode(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			if value, offset, err = d.decode(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, 0, errInvalidData
+			}
+			values[name] = value
+		}
+		return values, offset, nil
+	case 11: // array
+		values := make([]any, 0)
+		for i := uint64(0); i < size; i++ {
+			var value any
+			if value, offset, err = d.decode(offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			values = append(values, value)
+		}
+		return values, offset, nil
+	case 14: // bool
+		return size != 0, offset, nil
+	}
+
+	if offset+size > uint64(len(d.buffer)) {
+		return nil, 0, errInvalidData
+	}
+	payload := d.buffer[offset : offset+size]
+	next := offset + size
+	switch kind {
+	case 2: // utf8 string
+		return string(payload), next, nil
+	case 3: // double
+		if size != 8 {
+			return nil, 0, errInvalidData
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(payload)), next, nil
+	case 15: // float
+		if size != 4 {
+			return nil, 0, errInvalidData
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(payload))), next, nil
+	case 5, 6, 9: // uint16、uint32、uint64
+		value := uint64(0)
+		for _, b := range payload {
+			value = value<<8 | uint64(b)
+		}
+		return value, next, nil
+	case 8: // int32
+		value := uint32(0)
+		for _, b := range payload {
+			value = value<<8 | uint32(b)
+		}
+		if size == 4 {
+			return int64(int32(value)), next, nil
+		}
+		return int64(value), next, nil
+	case 4, 10: // bytes、uint128
+		return append([]byte(nil), payload...), next, nil
+	}
+
+	return nil, 0, errInvalidData
+}
+
+// size 解析数据长度
+func (d *decoder) size(ctrl byte, offset uint64) (uint64, uint64, error) {
+	size := uint64(ctrl & 0x1f)
+	if size < 29 {
+		return size, offset, nil
+	}
+
+	extra := size - 28
+	if offset+extra > uint64(len(d.buffer)) {
+		return 0, 0, errInvalidData
+	}
+	value := uint64(0)
+	for _, b := range d.buffer[offset : offset+extra] {
+		value = value<<8 | uint64(b)
+	}
+	switch size {
+	case 29:
+		size = 29 + value
+	case 30:
+		size = 285 + value
+	default:
+		size = 65821 + value
+	}
+
+	return size, offset + extra, nil
+}
+
+// pointer 解析指针，返回指向的位置及下一个数据的位置
+func (d *decoder) pointer(ctrl byte, offset uint64) (uint64, uint64, error) {
+	length := uint64(ctrl>>3&0x3) + 1
+	if offset+length > uint64(len(d.buffer)) {
+		return 0, 0, errInvalidData
+	}
+	b := d.buffer[offset : offset+length]
+	prefix := uint64(ctrl & 0x7)
+
+	var pointer uint64
+	switch length {
+	case 1:
+		pointer = prefix<<8 | uint64(b[0])
+	case 2:
+		pointer = 2048 + (prefix<<16 | uint64(b[0])<<8 | uint64(b[1]))
+	case 3:
+		pointer = 526336 + (prefix<<24 | uint64(b[0])<<16 | uint64(b[1])<<8 | uint64(b[2]))
+	default:
+		pointer = uint64(binary.BigEndian.Uint32(b))
+	}
+
+	return pointer, offset + length, nil
+}
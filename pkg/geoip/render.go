@@ -0,0 +1,130 @@
+package geoip
+
+import (
+	"errors"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"panel/pkg/tools"
+)
+
+var countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// privateNetworks 内网和本机地址，仅允许部分国家访问时不拦截
+var privateNetworks = []string{
+	"127.0.0.0/8", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "::1/128", "fc00::/7",
+}
+
+// ValidateCountries 校验国家的 ISO 代码
+func ValidateCountries(countries []string) error {
+	for _, country := range countries {
+		if !countryPattern.MatchString(country) {
+			return errors.New("国家代码 " + country + " 不合法，需为两位大写字母")
+		}
+	}
+
+	return nil
+}
+
+// Geo 生成网站按国家拦截的 geo 和 map 配置，需写入 http 块，网站通过 $<variable> 判断是否拦截，ACME 验证请求不拦截
+// allow 为 true 时仅允许 networks 访问，否则禁止 networks 访问
+func Geo(variable string, allow bool, networks []*net.IPNet) string {
+	blocked, listed := "1", "0"
+	if !allow {
+		blocked, listed = "0", "1"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("geo $" + variable + "_country {\n")
+	sb.WriteString("    default " + blocked + ";\n")
+	if allow {
+		for _, network := range privateNetworks {
+			sb.WriteString("    " + network + " 0;\n")
+		}
+	}
+	for _, network := range networks {
+		sb.WriteString("    " + network.String() + " " + listed + ";\n")
+	}
+	sb.WriteString("}\n")
+	sb.WriteString("map \"$" + variable + "_country:$uri\" $" + variable + " {\n")
+	sb.WriteString("    default 0;\n")
+	sb.WriteString("    \"~^1:/\\.well-known/acme-challenge/\" 0;\n")
+	sb.WriteString("    \"~^1:\" 1;\n")
+	sb.WriteString("}\n")
+
+	return sb.String()
+}
+
+// Block 生成网站配置文件 server 块中的拦截指令
+func Block(variable string, status int) string {
+	return "    if ($" + variable + ") {\n        return " + strconv.Itoa(status) + ";\n    }\n"
+}
+
+// Write 将拦截指令写入网站配置文件的 geo 标记位，缺少标记位时插入到 limit 或 waf 标记位之后
+func Write(conf, block string) (string, error) {
+	begin, end := "# geo标记位开始", "# geo标记位结束"
+	if !strings.Contains(conf, begin) {
+		after := "# limit标记位结束"
+		if !strings.Contains(conf, after) {
+			after = "# waf标记位结束"
+		}
+		if !strings.Contains(conf, after) {
+			return "", errors.New("配置文件中缺少waf标记位")
+		}
+		conf = strings.Replace(conf, after, after+"\n    "+begin+"\n    "+end, 1)
+	}
+
+	old := tools.Cut(conf, begin, end)
+	return strings.Replace(conf, begin+old+end, begin+"\n"+block+"    "+end, 1), nil
+}
+
+// Split 按 IP 版本拆分网段
+func Split(networks []*net.IPNet) (ipv4 []*net.IPNet, ipv6 []*net.IPNet) {
+	for _, network := range networks {
+		if network.IP.To4() != nil {
+			ipv4 = append(ipv4, network)
+		} else {
+			ipv6 = append(ipv6, network)
+		}
+	}
+
+	return ipv4, ipv6
+}
+
+// Ipset 生成 ipset restore 使用的集合定义，family 为 inet 或 inet6
+func Ipset(name, family string, networks []*net.IPNet) string {
+	var sb strings.Builder
+	sb.WriteString("create " + name + " hash:net family " + family + " maxelem " + strconv.Itoa(maxElem(len(networks))) + " -exist\n")
+	sb.WriteString("flush " + name + "\n")
+	for _, network := range networks {
+		sb.WriteString("add " + name + " " + network.String() + "\n")
+	}
+
+	return sb.String()
+}
+
+// FirewalldIpset 生成 firewalld 的 ipset 定义文件，family 为 inet 或 inet6
+func FirewalldIpset(family string, networks []*net.IPNet) string {
+	var sb strings.Builder
+	sb.WriteString("<?xml version=\"1.0\" encoding=\"utf-8\"?>\n")
+	sb.WriteString("<ipset type=\"hash:net\">\n")
+	sb.WriteString("  <option name=\"family\" value=\"" + family + "\"/>\n")
+	sb.WriteString("  <option name=\"maxelem\" value=\"" + strconv.Itoa(maxElem(len(networks))) + "\"/>\n")
+	for _, network := range networks {
+		sb.WriteString("  <entry>" + network.String() + "</entry>\n")
+	}
+	sb.WriteString("</ipset>\n")
+
+	return sb.String()
+}
+
+// maxElem 集合的容量，至少为 ipset 的默认值
+func maxElem(count int) int {
+	if count < 65536 {
+		return 65536
+	}
+
+	return count * 2
+}
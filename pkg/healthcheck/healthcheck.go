@@ -0,0 +1,128 @@
+// Package healthcheck 网站 HTTP 可用性检测
+package healthcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxBody 关键字检测读取的最大响应体大小
+const maxBody = 1 << 20
+
+// Check 检测配置
+type Check struct {
+	URL          string
+	Method       string        // 默认 GET
+	ExpectStatus int           // 期望的状态码，0 表示 2xx 和 3xx 均视为正常
+	Keyword      string        // 响应体需包含的关键字
+	Timeout      time.Duration // 默认 10 秒
+	Insecure     bool          // 跳过证书校验
+}
+
+// Result 检测结果
+type Result struct {
+	Up           bool          `json:"up"`
+	StatusCode   int           `json:"status_code"`
+	ResponseTime time.Duration `json:"response_time"`
+	Error        string        `json:"error"`
+	CertExpiry   *time.Time    `json:"cert_expiry"` // HTTPS 站点证书的过期时间
+}
+
+// Validate 校验检测配置
+func Validate(check Check) error {
+	u, err := url.Parse(check.URL)
+	if err != nil || len(u.Host) == 0 {
+		return errors.New("URL 格式错误")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("仅支持 HTTP 和 HTTPS 协议")
+	}
+	if check.ExpectStatus != 0 && (check.ExpectStatus < 100 || check.ExpectStatus > 599) {
+		return errors.New("期望状态码需在 100 - 599 之间")
+	}
+	if check.Timeout < 0 {
+		return errors.New("超时时间不能为负数")
+	}
+
+	return nil
+}
+
+// Run 执行一次检测
+func Run(ctx context.Context, check Check) Result {
+	if err := Validate(check); err != nil {
+		return Result{Error: err.Error()}
+	}
+
+	method := check.Method
+	if len(method) == 0 {
+		method = http.MethodGet
+	}
+	timeout := check.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, method, check.URL, nil)
+	if err != nil {
+		return Result{Error: err.Error()}
+	}
+	request.Header.Set("User-Agent", "Panel-HealthCheck/1.0")
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: check.Insecure},
+			DisableKeepAlives: true,
+		},
+		// 不跟随跳转，按原始状态码判断
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	start := time.Now()
+	response, err := client.Do(request)
+	if err != nil {
+		return Result{ResponseTime: time.Since(start), Error: err.Error()}
+	}
+	defer response.Body.Close()
+
+	result := Result{StatusCode: response.StatusCode}
+	if response.TLS != nil && len(response.TLS.PeerCertificates) > 0 {
+		expiry := response.TLS.PeerCertificates[0].NotAfter
+		result.CertExpiry = &expiry
+	}
+
+	var body []byte
+	if len(check.Keyword) > 0 {
+		body, err = io.ReadAll(io.LimitReader(response.Body, maxBody))
+	} else {
+		_, err = io.Copy(io.Discard, io.LimitReader(response.Body, maxBody))
+	}
+	result.ResponseTime = time.Since(start)
+	if err != nil {
+		result.Error = "读取响应失败: " + err.Error()
+		return result
+	}
+
+	switch {
+	case check.ExpectStatus != 0 && response.StatusCode != check.ExpectStatus:
+		result.Error = fmt.Sprintf("状态码 %d 与期望的 %d 不符", response.StatusCode, check.ExpectStatus)
+	case check.ExpectStatus == 0 && response.StatusCode >= 400:
+		result.Error = fmt.Sprintf("状态码 %d 异常", response.StatusCode)
+	case len(check.Keyword) > 0 && !strings.Contains(string(body), check.Keyword):
+		result.Error = "响应中未找到关键字 " + check.Keyword
+	default:
+		result.Up = true
+	}
+
+	return result
+}
@@ -0,0 +1,106 @@
+package healthcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type HealthCheckTestSuite struct {
+	suite.Suite
+	server *httptest.Server
+}
+
+func TestHealthCheckTestSuite(t *testing.T) {
+	suite.Run(t, &HealthCheckTestSuite{})
+}
+
+func (s *HealthCheckTestSuite) SetupSuite() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html><body>Hello Panel</body></html>"))
+	})
+	mux.HandleFunc("/error", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/error", http.StatusFound)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	})
+	s.server = httptest.NewServer(mux)
+}
+
+func (s *HealthCheckTestSuite) TearDownSuite() {
+	s.server.Close()
+}
+
+func (s *HealthCheckTestSuite) TestValidate() {
+	s.NoError(Validate(Check{URL: "https://example.com/health"}))
+	s.Error(Validate(Check{URL: "ftp://example.com"}))
+	s.Error(Validate(Check{URL: "example.com"}))
+	s.Error(Validate(Check{URL: "http://example.com", ExpectStatus: 999}))
+	s.Error(Validate(Check{URL: "http://example.com", Timeout: -time.Second}))
+}
+
+func (s *HealthCheckTestSuite) TestUp() {
+	result := Run(context.Background(), Check{URL: s.server.URL, Keyword: "Hello Panel"})
+	s.True(result.Up)
+	s.Equal(http.StatusOK, result.StatusCode)
+	s.Empty(result.Error)
+	s.Positive(result.ResponseTime)
+	s.Nil(result.CertExpiry)
+}
+
+func (s *HealthCheckTestSuite) TestKeyword() {
+	result := Run(context.Background(), Check{URL: s.server.URL, Keyword: "Goodbye"})
+	s.False(result.Up)
+	s.Contains(result.Error, "Goodbye")
+}
+
+func (s *HealthCheckTestSuite) TestStatus() {
+	result := Run(context.Background(), Check{URL: s.server.URL + "/error"})
+	s.False(result.Up)
+	s.Equal(http.StatusBadGateway, result.StatusCode)
+
+	result = Run(context.Background(), Check{URL: s.server.URL + "/error", ExpectStatus: http.StatusBadGateway})
+	s.True(result.Up)
+
+	// 不跟随跳转
+	result = Run(context.Background(), Check{URL: s.server.URL + "/redirect"})
+	s.True(result.Up)
+	s.Equal(http.StatusFound, result.StatusCode)
+
+	result = Run(context.Background(), Check{URL: s.server.URL + "/redirect", ExpectStatus: http.StatusOK})
+	s.False(result.Up)
+}
+
+func (s *HealthCheckTestSuite) TestTimeout() {
+	result := Run(context.Background(), Check{URL: s.server.URL + "/slow", Timeout: 100 * time.Millisecond})
+	s.False(result.Up)
+	s.NotEmpty(result.Error)
+}
+
+func (s *HealthCheckTestSuite) TestTLS() {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	// 自签名证书校验失败
+	result := Run(context.Background(), Check{URL: server.URL})
+	s.False(result.Up)
+
+	result = Run(context.Background(), Check{URL: server.URL, Insecure: true})
+	s.True(result.Up)
+	s.NotNil(result.CertExpiry)
+	s.Equal(server.Certificate().NotAfter, *result.CertExpiry)
+}
@@ -0,0 +1,201 @@
+// Package metrics 生成 Prometheus 文本格式的监控指标
+package metrics
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType Prometheus 文本格式的 Content-Type
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets 默认的直方图分桶（秒）
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Labels 指标标签
+type Labels map[string]string
+
+// Writer 按 Prometheus 文本格式输出指标
+type Writer struct {
+	builder strings.Builder
+	seen    map[string]bool
+}
+
+func NewWriter() *Writer {
+	return &Writer{
+		seen: make(map[string]bool),
+	}
+}
+
+// Gauge 输出一个 gauge 指标
+func (w *Writer) Gauge(name, help string, value float64, labels Labels) {
+	w.header(name, help, "gauge")
+	w.sample(name, labels, value)
+}
+
+// Counter 输出一个 counter 指标
+func (w *Writer) Counter(name, help string, value float64, labels Labels) {
+	w.header(name, help, "counter")
+	w.sample(name, labels, value)
+}
+
+// String 返回输出的文本
+func (w *Writer) String() string {
+	return w.builder.String()
+}
+
+// header 输出指标的 HELP 和 TYPE，同名指标只输出一次
+func (w *Writer) header(name, help, kind string) {
+	if w.seen[name] {
+		return
+	}
+	w.seen[name] = true
+
+	if len(help) > 0 {
+		w.builder.WriteString("# HELP " + name + " " + escapeHelp(help) + "\n")
+	}
+	w.builder.WriteString("# TYPE " + name + " " + kind + "\n")
+}
+
+func (w *Writer) sample(name string, labels Labels, value float64) {
+	w.builder.WriteString(name)
+	w.builder.WriteString(formatLabels(labels))
+	w.builder.WriteString(" ")
+	w.builder.WriteString(formatValue(value))
+	w.builder.WriteString("\n")
+}
+
+// Histogram 可并发使用的直方图
+type Histogram struct {
+	mu      sync.Mutex
+	name    string
+	help    string
+	buckets []float64
+	series  map[string]*series
+}
+
+type series struct {
+	labels Labels
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+
+	return &Histogram{
+		name:    name,
+		help:    help,
+		buckets: sorted,
+		series:  make(map[string]*series),
+	}
+}
+
+// Observe 记录一次观测值
+func (h *Histogram) Observe(labels Labels, value float64) {
+	key := formatLabels(labels)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[key]
+	if !ok {
+		copied := make(Labels, len(labels))
+		for k, v := range labels {
+			copied[k] = v
+		}
+		s = &series{labels: copied, counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+
+	for i, bound := range h.buckets {
+		if value <= bound {
+			s.counts[i]++
+		}
+	}
+	s.sum += value
+	s.count++
+}
+
+// WriteTo 将直方图输出到 Writer
+func (h *Histogram) WriteTo(w *Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	w.header(h.name, h.help, "histogram")
+
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := h.series[key]
+		for i, bound := range h.buckets {
+			w.sample(h.name+"_bucket", withLabel(s.labels, "le", formatValue(bound)), float64(s.counts[i]))
+		}
+		w.sample(h.name+"_bucket", withLabel(s.labels, "le", "+Inf"), float64(s.count))
+		w.sample(h.name+"_sum", s.labels, s.sum)
+		w.sample(h.name+"_count", s.labels, float64(s.count))
+	}
+}
+
+func withLabel(labels Labels, key, value string) Labels {
+	result := make(Labels, len(labels)+1)
+	for k, v := range labels {
+		result[k] = v
+	}
+	result[key] = value
+
+	return result
+}
+
+// formatLabels 按键名排序输出标签
+func formatLabels(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+`="`+escapeLabel(labels[key])+`"`)
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+var helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelReplacer.Replace(value)
+}
+
+func escapeHelp(value string) string {
+	return helpReplacer.Replace(value)
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type MetricsTestSuite struct {
+	suite.Suite
+}
+
+func TestMetricsTestSuite(t *testing.T) {
+	suite.Run(t, &MetricsTestSuite{})
+}
+
+func (s *MetricsTestSuite) TestWriter() {
+	w := NewWriter()
+	w.Gauge("panel_websites", "网站数量", 3, Labels{"status": "running"})
+	w.Gauge("panel_websites", "网站数量", 1, Labels{"status": "stopped"})
+	w.Counter("panel_net_bytes_total", "", 1024, Labels{"device": "eth0", "direction": "rx"})
+	w.Gauge("panel_up", "line1\nline2", 1, nil)
+	w.Gauge("panel_nan", "", math.NaN(), nil)
+
+	s.Equal(`# HELP panel_websites 网站数量
+# TYPE panel_websites gauge
+panel_websites{status="running"} 3
+panel_websites{status="stopped"} 1
+# TYPE panel_net_bytes_total counter
+panel_net_bytes_total{device="eth0",direction="rx"} 1024
+# HELP panel_up line1\nline2
+# TYPE panel_up gauge
+panel_up 1
+# TYPE panel_nan gauge
+panel_nan NaN
+`, w.String())
+}
+
+func (s *MetricsTestSuite) TestEscape() {
+	w := NewWriter()
+	w.Gauge("panel_cert_expiry_days", "", 1.5, Labels{"domains": `a"b\c` + "\n"})
+
+	s.Equal("# TYPE panel_cert_expiry_days gauge\npanel_cert_expiry_days{domains=\"a\\\"b\\\\c\\n\"} 1.5\n", w.String())
+}
+
+func (s *MetricsTestSuite) TestHistogram() {
+	h := NewHistogram("panel_http_request_duration_seconds", "请求耗时", []float64{1, 0.1})
+	h.Observe(Labels{"method": "GET"}, 0.05)
+	h.Observe(Labels{"method": "GET"}, 0.5)
+	h.Observe(Labels{"method": "GET"}, 2)
+	h.Observe(Labels{"method": "POST"}, 0.1)
+
+	w := NewWriter()
+	h.WriteTo(w)
+
+	s.Equal(`# HELP panel_http_request_duration_seconds 请求耗时
+# TYPE panel_http_request_duration_seconds histogram
+panel_http_request_duration_seconds_bucket{le="0.1",method="GET"} 1
+panel_http_request_duration_seconds_bucket{le="1",method="GET"} 2
+panel_http_request_duration_seconds_bucket{le="+Inf",method="GET"} 3
+panel_http_request_duration_seconds_sum{method="GET"} 2.55
+panel_http_request_duration_seconds_count{method="GET"} 3
+panel_http_request_duration_seconds_bucket{le="0.1",method="POST"} 1
+panel_http_request_duration_seconds_bucket{le="1",method="POST"} 1
+panel_http_request_duration_seconds_bucket{le="+Inf",method="POST"} 1
+panel_http_request_duration_seconds_sum{method="POST"} 0.1
+panel_http_request_duration_seconds_count{method="POST"} 1
+`, w.String())
+}
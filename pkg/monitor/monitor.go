@@ -0,0 +1,196 @@
+// Package monitor 监控数据的采样与降采样聚合
+package monitor
+
+import (
+	"math"
+	"sort"
+
+	"panel/pkg/alert"
+	"panel/pkg/procstat"
+	"panel/pkg/tools"
+)
+
+// 存储粒度（秒）
+const (
+	ResolutionMinute      = 60
+	ResolutionFiveMinutes = 300
+	ResolutionHour        = 3600
+)
+
+// Resolutions 由细到粗的存储粒度
+var Resolutions = []int64{ResolutionMinute, ResolutionFiveMinutes, ResolutionHour}
+
+// 除告警指标外额外记录的指标
+const (
+	MetricMemTotal     = "mem_total"      // 内存总量 (字节)
+	MetricMemUsed      = "mem_used"       // 已用内存 (字节)
+	MetricMemAvailable = "mem_available"  // 可用内存 (字节)
+	MetricSwapTotal    = "swap_total"     // Swap 总量 (字节)
+	MetricSwapUsed     = "swap_used"      // 已用 Swap (字节)
+	MetricSwapFree     = "swap_free"      // 空闲 Swap (字节)
+	MetricNetSent      = "net_sent"       // 累计发送 (字节)
+	MetricNetRecv      = "net_recv"       // 累计接收 (字节)
+	MetricDiskReadAll  = "disk_read_all"  // 累计读取 (字节)
+	MetricDiskWriteAll = "disk_write_all" // 累计写入 (字节)
+)
+
+// 服务资源指标，目标为服务名
+const (
+	MetricServiceCPU       = "service_cpu"       // CPU 使用率 (%)
+	MetricServiceRSS       = "service_rss"       // 内存占用 (字节)
+	MetricServiceFiles     = "service_files"     // 打开的文件数
+	MetricServiceThreads   = "service_threads"   // 线程数
+	MetricServiceProcesses = "service_processes" // 进程数
+	MetricServiceRead      = "service_read"      // 磁盘读取速度 (字节/秒)
+	MetricServiceWrite     = "service_write"     // 磁盘写入速度 (字节/秒)
+)
+
+// ServiceMetrics 所有服务资源指标
+var ServiceMetrics = []string{MetricServiceCPU, MetricServiceRSS, MetricServiceFiles, MetricServiceThreads, MetricServiceProcesses, MetricServiceRead, MetricServiceWrite}
+
+// steps 自动选择查询步长时可用的步长（秒）
+var steps = []int64{60, 300, 900, 1800, 3600, 3 * 3600, 6 * 3600, 12 * 3600, 86400}
+
+// Point 一个时间桶内某个指标的聚合值
+type Point struct {
+	Metric string  `json:"-"`
+	Time   int64   `json:"time"` // 时间桶起点 (Unix 时间戳)
+	Min    float64 `json:"min"`
+	Avg    float64 `json:"avg"`
+	Max    float64 `json:"max"`
+	Count  int     `json:"-"`
+}
+
+// Collect 从监控数据生成一次采样，prev 为上一次采样，用于根据累计计数计算速率
+func Collect(info tools.MonitoringInfo, prev alert.Sample, seconds float64) alert.Sample {
+	sample := alert.Collect(info)
+	if info.Mem != nil {
+		sample[MetricMemTotal] = float64(info.Mem.Total)
+		sample[MetricMemUsed] = float64(info.Mem.Used)
+		sample[MetricMemAvailable] = float64(info.Mem.Available)
+	}
+	if info.Swap != nil {
+		sample[MetricSwapTotal] = float64(info.Swap.Total)
+		sample[MetricSwapUsed] = float64(info.Swap.Used)
+		sample[MetricSwapFree] = float64(info.Swap.Free)
+	}
+
+	rx, tx := alert.NetBytes(info)
+	read, write := alert.DiskBytes(info)
+	sample[MetricNetRecv] = float64(rx)
+	sample[MetricNetSent] = float64(tx)
+	sample[MetricDiskReadAll] = float64(read)
+	sample[MetricDiskWriteAll] = float64(write)
+
+	if prev == nil || seconds <= 0 {
+		return sample
+	}
+
+	counters := map[string]string{
+		alert.MetricNetRx:     MetricNetRecv,
+		alert.MetricNetTx:     MetricNetSent,
+		alert.MetricDiskRead:  MetricDiskReadAll,
+		alert.MetricDiskWrite: MetricDiskWriteAll,
+	}
+	for metric, counter := range counters {
+		before, ok := prev[counter]
+		if !ok {
+			continue
+		}
+		// 计数器重置时速率记为 0
+		sample[metric] = math.Max(sample[counter]-before, 0) / seconds / 1024 / 1024
+	}
+
+	return sample
+}
+
+// AddServices 将各服务的资源占用加入采样
+func AddServices(sample alert.Sample, services map[string]procstat.Usage) {
+	for name, usage := range services {
+		sample[alert.Key(MetricServiceCPU, name)] = usage.CPU
+		sample[alert.Key(MetricServiceRSS, name)] = float64(usage.RSS)
+		sample[alert.Key(MetricServiceFiles, name)] = float64(usage.OpenFiles)
+		sample[alert.Key(MetricServiceThreads, name)] = float64(usage.Threads)
+		sample[alert.Key(MetricServiceProcesses, name)] = float64(usage.Processes)
+		sample[alert.Key(MetricServiceRead, name)] = usage.ReadRate
+		sample[alert.Key(MetricServiceWrite, name)] = usage.WriteRate
+	}
+}
+
+// Points 将一次采样转换为指定时间桶的数据点
+func Points(sample alert.Sample, time int64, resolution int64) []Point {
+	bucket := Bucket(time, resolution)
+	points := make([]Point, 0, len(sample))
+	for metric, value := range sample {
+		points = append(points, Point{Metric: metric, Time: bucket, Min: value, Avg: value, Max: value, Count: 1})
+	}
+	sortPoints(points)
+
+	return points
+}
+
+// Bucket 时间所在时间桶的起点
+func Bucket(time int64, step int64) int64 {
+	return time - time%step
+}
+
+// Rollup 将数据点按 step 秒重新聚合，平均值按样本数加权
+func Rollup(points []Point, step int64) []Point {
+	type key struct {
+		metric string
+		time   int64
+	}
+
+	buckets := make(map[key]*Point)
+	sums := make(map[key]float64)
+	for _, point := range points {
+		count := point.Count
+		if count <= 0 {
+			count = 1
+		}
+
+		k := key{metric: point.Metric, time: Bucket(point.Time, step)}
+		bucket, ok := buckets[k]
+		if !ok {
+			bucket = &Point{Metric: point.Metric, Time: k.time, Min: point.Min, Max: point.Max}
+			buckets[k] = bucket
+		}
+		bucket.Min = math.Min(bucket.Min, point.Min)
+		bucket.Max = math.Max(bucket.Max, point.Max)
+		bucket.Count += count
+		sums[k] += point.Avg * float64(count)
+	}
+
+	result := make([]Point, 0, len(buckets))
+	for k, bucket := range buckets {
+		bucket.Avg = sums[k] / float64(bucket.Count)
+		result = append(result, *bucket)
+	}
+	sortPoints(result)
+
+	return result
+}
+
+// Step 根据时间范围自动选择步长，使数据点不超过 maxPoints 个
+func Step(start, end int64, maxPoints int64) int64 {
+	if maxPoints <= 0 || end <= start {
+		return steps[0]
+	}
+
+	for _, step := range steps {
+		if (end-start)/step <= maxPoints {
+			return step
+		}
+	}
+
+	return steps[len(steps)-1]
+}
+
+func sortPoints(points []Point) {
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].Metric != points[j].Metric {
+			return points[i].Metric < points[j].Metric
+		}
+		return points[i].Time < points[j].Time
+	})
+}
@@ -0,0 +1,96 @@
+package monitor
+
+import (
+	"testing"
+
+	"github.com/shirou/gopsutil/disk"
+	"github.com/shirou/gopsutil/mem"
+	"github.com/shirou/gopsutil/net"
+	"github.com/stretchr/testify/suite"
+
+	"panel/pkg/alert"
+	"panel/pkg/procstat"
+	"panel/pkg/tools"
+)
+
+type MonitorTestSuite struct {
+	suite.Suite
+}
+
+func TestMonitorTestSuite(t *testing.T) {
+	suite.Run(t, &MonitorTestSuite{})
+}
+
+func (s *MonitorTestSuite) TestCollect() {
+	info := tools.MonitoringInfo{
+		Percent: []float64{12},
+		Mem:     &mem.VirtualMemoryStat{Total: 1024, Used: 512, Available: 256, UsedPercent: 50},
+		Net:     []net.IOCountersStat{{Name: "lo", BytesRecv: 1 << 30}, {Name: "eth0", BytesRecv: 120 << 20, BytesSent: 60 << 20}},
+		DiskIO:  map[string]disk.IOCountersStat{"sda": {ReadBytes: 60 << 20, WriteBytes: 0}},
+	}
+
+	sample := Collect(info, nil, 0)
+	s.Equal(12.0, sample[alert.MetricCPU])
+	s.Equal(512.0, sample[MetricMemUsed])
+	s.Equal(float64(120<<20), sample[MetricNetRecv])
+	s.NotContains(sample, alert.MetricNetRx)
+
+	prev := alert.Sample{MetricNetRecv: 0, MetricNetSent: float64(120 << 20), MetricDiskReadAll: 0, MetricDiskWriteAll: 0}
+	sample = Collect(info, prev, 60)
+	s.Equal(2.0, sample[alert.MetricNetRx])
+	s.Equal(0.0, sample[alert.MetricNetTx])
+	s.Equal(1.0, sample[alert.MetricDiskRead])
+	s.Equal(0.0, sample[alert.MetricDiskWrite])
+}
+
+func (s *MonitorTestSuite) TestAddServices() {
+	sample := alert.Sample{}
+	AddServices(sample, map[string]procstat.Usage{
+		"openresty":        {Processes: 3, CPU: 1.5, RSS: 2048},
+		"supervisor:queue": {Processes: 1, Threads: 4},
+	})
+
+	s.Equal(1.5, sample["service_cpu:openresty"])
+	s.Equal(2048.0, sample["service_rss:openresty"])
+	s.Equal(3.0, sample["service_processes:openresty"])
+	s.Equal(4.0, sample["service_threads:supervisor:queue"])
+	s.Len(sample, 2*len(ServiceMetrics))
+}
+
+func (s *MonitorTestSuite) TestPoints() {
+	points := Points(alert.Sample{"cpu": 10, "mem": 20}, 1701424865, ResolutionMinute)
+	s.Len(points, 2)
+	s.Equal("cpu", points[0].Metric)
+	s.Equal(int64(1701424860), points[0].Time)
+	s.Equal(Point{Metric: "mem", Time: 1701424860, Min: 20, Avg: 20, Max: 20, Count: 1}, points[1])
+}
+
+func (s *MonitorTestSuite) TestRollup() {
+	points := []Point{
+		{Metric: "cpu", Time: 0, Min: 10, Avg: 10, Max: 10, Count: 1},
+		{Metric: "cpu", Time: 60, Min: 20, Avg: 20, Max: 20, Count: 1},
+		{Metric: "cpu", Time: 120, Min: 5, Avg: 60, Max: 90, Count: 2},
+		{Metric: "cpu", Time: 300, Min: 40, Avg: 40, Max: 40, Count: 1},
+		{Metric: "load1", Time: 240, Min: 1, Avg: 1, Max: 1},
+	}
+
+	result := Rollup(points, ResolutionFiveMinutes)
+	s.Equal([]Point{
+		{Metric: "cpu", Time: 0, Min: 5, Avg: 37.5, Max: 90, Count: 4},
+		{Metric: "cpu", Time: 300, Min: 40, Avg: 40, Max: 40, Count: 1},
+		{Metric: "load1", Time: 0, Min: 1, Avg: 1, Max: 1, Count: 1},
+	}, result)
+
+	// 聚合结果可继续聚合
+	result = Rollup(result, ResolutionHour)
+	s.Equal(Point{Metric: "cpu", Time: 0, Min: 5, Avg: 38, Max: 90, Count: 5}, result[0])
+}
+
+func (s *MonitorTestSuite) TestStep() {
+	s.Equal(int64(60), Step(0, 3600, 720))
+	s.Equal(int64(300), Step(0, 86400, 720))
+	s.Equal(int64(900), Step(0, 7*86400, 720))
+	s.Equal(int64(3600), Step(0, 30*86400, 720))
+	s.Equal(int64(86400), Step(0, 3650*86400, 720))
+	s.Equal(int64(60), Step(100, 0, 720))
+}
@@ -0,0 +1,141 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Telegram Telegram 机器人通知
+type Telegram struct {
+	config Config
+}
+
+func NewTelegram(config Config) (*Telegram, error) {
+	if len(config.Token) == 0 || len(config.ChatID) == 0 {
+		return nil, errors.New("Bot Token 和 Chat ID 不能为空")
+	}
+	if len(config.API) == 0 {
+		config.API = "https://api.telegram.org"
+	}
+	if err := checkURL(config.API); err != nil {
+		return nil, err
+	}
+
+	return &Telegram{config: config}, nil
+}
+
+// Send 发送通知
+func (r *Telegram) Send(message Message) error {
+	data, err := postJSON(strings.TrimSuffix(r.config.API, "/")+"/bot"+r.config.Token+"/sendMessage", map[string]any{
+		"chat_id": r.config.ChatID,
+		"text":    message.Title + "\n\n" + message.Content + "\n\n" + message.Time.Format(time.DateTime),
+	})
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err = json.Unmarshal(data, &resp); err != nil {
+		return errors.New("解析响应失败: " + string(data))
+	}
+	if !resp.OK {
+		return errors.New("发送失败: " + resp.Description)
+	}
+
+	return nil
+}
+
+// DingTalk 钉钉群机器人通知
+type DingTalk struct {
+	config Config
+}
+
+func NewDingTalk(config Config) (*DingTalk, error) {
+	if err := checkURL(config.URL); err != nil {
+		return nil, err
+	}
+
+	return &DingTalk{config: config}, nil
+}
+
+// Send 发送通知
+func (r *DingTalk) Send(message Message) error {
+	target, err := r.SignedURL(time.Now())
+	if err != nil {
+		return err
+	}
+
+	data, err := postJSON(target, map[string]any{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"title": message.Title,
+			"text":  markdown(message),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return checkErrCode(data)
+}
+
+// SignedURL 生成加签后的请求地址，未配置密钥时原样返回
+func (r *DingTalk) SignedURL(now time.Time) (string, error) {
+	if len(r.config.Secret) == 0 {
+		return r.config.URL, nil
+	}
+
+	u, err := url.Parse(r.config.URL)
+	if err != nil {
+		return "", err
+	}
+
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(r.config.Secret))
+	mac.Write([]byte(timestamp + "\n" + r.config.Secret))
+
+	query := u.Query()
+	query.Set("timestamp", timestamp)
+	query.Set("sign", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// WeCom 企业微信群机器人通知
+type WeCom struct {
+	config Config
+}
+
+func NewWeCom(config Config) (*WeCom, error) {
+	if err := checkURL(config.URL); err != nil {
+		return nil, err
+	}
+
+	return &WeCom{config: config}, nil
+}
+
+// Send 发送通知
+func (r *WeCom) Send(message Message) error {
+	data, err := postJSON(r.config.URL, map[string]any{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"content": markdown(message),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return checkErrCode(data)
+}
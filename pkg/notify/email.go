@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Email SMTP 邮件通知
+type Email struct {
+	config Config
+}
+
+func NewEmail(config Config) (*Email, error) {
+	if len(config.Host) == 0 || config.Port <= 0 {
+		return nil, errors.New("SMTP 服务器地址或端口错误")
+	}
+	if _, err := mail.ParseAddress(config.From); err != nil {
+		return nil, errors.New("发件人地址错误")
+	}
+	if len(config.To) == 0 {
+		return nil, errors.New("收件人不能为空")
+	}
+	for _, to := range config.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return nil, fmt.Errorf("收件人地址错误: %s", to)
+		}
+	}
+
+	return &Email{config: config}, nil
+}
+
+// Send 发送邮件
+func (r *Email) Send(message Message) error {
+	addr := net.JoinHostPort(r.config.Host, strconv.Itoa(r.config.Port))
+	tlsConfig := &tls.Config{ServerName: r.config.Host}
+
+	var conn net.Conn
+	var err error
+	if r.config.Encryption == "ssl" {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, 10*time.Second)
+	}
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	c, err := smtp.NewClient(conn, r.config.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if r.config.Encryption == "starttls" {
+		if err = c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if len(r.config.Username) > 0 {
+		if err = c.Auth(smtp.PlainAuth("", r.config.Username, r.config.Password, r.config.Host)); err != nil {
+			return err
+		}
+	}
+
+	from, _ := mail.ParseAddress(r.config.From)
+	if err = c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, to := range r.config.To {
+		address, _ := mail.ParseAddress(to)
+		if err = c.Rcpt(address.Address); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(r.build(message)); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// build 构建邮件内容
+func (r *Email) build(message Message) []byte {
+	var builder strings.Builder
+	builder.WriteString("From: " + r.config.From + "\r\n")
+	builder.WriteString("To: " + strings.Join(r.config.To, ", ") + "\r\n")
+	builder.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", message.Title) + "\r\n")
+	builder.WriteString("Date: " + message.Time.Format(time.RFC1123Z) + "\r\n")
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	builder.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	builder.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+
+	body := base64.StdEncoding.EncodeToString([]byte(message.Content + "\r\n\r\n" + message.Time.Format(time.DateTime)))
+	for len(body) > 76 {
+		builder.WriteString(body[:76] + "\r\n")
+		body = body[76:]
+	}
+	builder.WriteString(body + "\r\n")
+
+	return []byte(builder.String())
+}
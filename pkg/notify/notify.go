@@ -0,0 +1,212 @@
+// Package notify 消息通知渠道
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	TypeEmail    = "email"
+	TypeWebhook  = "webhook"
+	TypeTelegram = "telegram"
+	TypeDingTalk = "dingtalk"
+	TypeWeCom    = "wecom"
+)
+
+// Types 支持的通知渠道类型
+var Types = []string{TypeEmail, TypeWebhook, TypeTelegram, TypeDingTalk, TypeWeCom}
+
+// Message 通知消息
+type Message struct {
+	Event   string    `json:"event"`
+	Title   string    `json:"title"`
+	Content string    `json:"content"`
+	Time    time.Time `json:"time"`
+}
+
+// Config 通知渠道配置，不同类型使用其中的部分字段
+type Config struct {
+	// 邮件
+	Host       string   `json:"host,omitempty"`
+	Port       int      `json:"port,omitempty"`
+	Username   string   `json:"username,omitempty"`
+	Password   string   `json:"password,omitempty"`
+	From       string   `json:"from,omitempty"`
+	To         []string `json:"to,omitempty"`
+	Encryption string   `json:"encryption,omitempty"` // none, ssl, starttls
+
+	// Webhook / 钉钉 / 企业微信
+	URL      string            `json:"url,omitempty"`
+	Method   string            `json:"method,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Template string            `json:"template,omitempty"` // 请求体模板
+	Secret   string            `json:"secret,omitempty"`   // 钉钉加签密钥
+
+	// Telegram
+	Token  string `json:"token,omitempty"`
+	ChatID string `json:"chat_id,omitempty"`
+	API    string `json:"api,omitempty"` // 自定义 API 地址，用于反代
+}
+
+// Masked 接口返回的配置中敏感字段的占位值，更新时提交该值表示保持原值
+const Masked = "******"
+
+// Mask 返回隐藏了密码、密钥、令牌、请求头和地址查询参数的配置
+func (c Config) Mask() Config {
+	masked := c
+	masked.Password = mask(c.Password)
+	masked.Secret = mask(c.Secret)
+	masked.Token = mask(c.Token)
+	masked.URL = maskURL(c.URL)
+	if len(c.Headers) > 0 {
+		masked.Headers = make(map[string]string, len(c.Headers))
+		for key, value := range c.Headers {
+			masked.Headers[key] = mask(value)
+		}
+	}
+
+	return masked
+}
+
+// Restore 将提交的配置中仍为占位值的敏感字段恢复为原配置中的值
+func (c Config) Restore(old Config) Config {
+	restored := c
+	if c.Password == Masked {
+		restored.Password = old.Password
+	}
+	if c.Secret == Masked {
+		restored.Secret = old.Secret
+	}
+	if c.Token == Masked {
+		restored.Token = old.Token
+	}
+	if len(c.URL) > 0 && c.URL == maskURL(old.URL) {
+		restored.URL = old.URL
+	}
+	if len(c.Headers) > 0 {
+		restored.Headers = make(map[string]string, len(c.Headers))
+		for key, value := range c.Headers {
+			if original, ok := old.Headers[key]; ok && value == Masked {
+				value = original
+			}
+			restored.Headers[key] = value
+		}
+	}
+
+	return restored
+}
+
+func mask(value string) string {
+	if len(value) == 0 {
+		return ""
+	}
+
+	return Masked
+}
+
+// maskURL 隐藏地址中的查询参数值，钉钉和企业微信的机器人令牌位于查询参数中
+func maskURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil || len(parsed.RawQuery) == 0 {
+		return raw
+	}
+
+	query := parsed.Query()
+	for key := range query {
+		query.Set(key, Masked)
+	}
+	parsed.RawQuery = query.Encode()
+
+	return parsed.String()
+}
+
+// Sender 通知发送器
+type Sender interface {
+	Send(message Message) error
+}
+
+// New 根据类型创建发送器
+func New(kind string, config Config) (Sender, error) {
+	switch kind {
+	case TypeEmail:
+		return NewEmail(config)
+	case TypeWebhook:
+		return NewWebhook(config)
+	case TypeTelegram:
+		return NewTelegram(config)
+	case TypeDingTalk:
+		return NewDingTalk(config)
+	case TypeWeCom:
+		return NewWeCom(config)
+	}
+
+	return nil, fmt.Errorf("不支持的通知渠道类型: %s", kind)
+}
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// request 发送 HTTP 请求，返回响应体
+func request(method, url string, body []byte, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return data, fmt.Errorf("请求失败，状态码: %d，响应: %s", resp.StatusCode, string(data))
+	}
+
+	return data, nil
+}
+
+// postJSON 以 JSON 格式发送数据
+func postJSON(url string, payload any) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return request(http.MethodPost, url, body, nil)
+}
+
+// checkErrCode 检查钉钉 / 企业微信的响应结果
+func checkErrCode(data []byte) error {
+	var resp struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return errors.New("解析响应失败: " + string(data))
+	}
+	if resp.ErrCode != 0 {
+		return fmt.Errorf("发送失败: %d %s", resp.ErrCode, resp.ErrMsg)
+	}
+
+	return nil
+}
+
+// markdown 生成 Markdown 格式的消息内容
+func markdown(message Message) string {
+	return "### " + message.Title + "\n\n" + message.Content + "\n\n> " + message.Time.Format(time.DateTime)
+}
@@ -0,0 +1,213 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type NotifyTestSuite struct {
+	suite.Suite
+	message Message
+}
+
+func TestNotifyTestSuite(t *testing.T) {
+	suite.Run(t, &NotifyTestSuite{
+		message: Message{
+			Event:   "cert_expiring",
+			Title:   "证书即将过期",
+			Content: "证书 \"example.com\" 将在 3 天后过期",
+			Time:    time.Date(2023, 12, 1, 10, 0, 0, 0, time.Local),
+		},
+	})
+}
+
+func (s *NotifyTestSuite) TestNew() {
+	_, err := New("sms", Config{})
+	s.Error(err)
+	_, err = New(TypeWebhook, Config{URL: "ftp://example.com"})
+	s.Error(err)
+	_, err = New(TypeTelegram, Config{Token: "token"})
+	s.Error(err)
+	_, err = New(TypeEmail, Config{Host: "smtp.example.com", Port: 465, From: "panel@example.com"})
+	s.Error(err)
+	_, err = New(TypeEmail, Config{Host: "smtp.example.com", Port: 465, From: "panel@example.com", To: []string{"admin@example.com"}})
+	s.NoError(err)
+}
+
+func (s *NotifyTestSuite) TestMask() {
+	config := Config{
+		Password: "password",
+		Secret:   "SEC123",
+		URL:      "https://oapi.dingtalk.com/robot/send?access_token=abc",
+		Headers:  map[string]string{"X-Token": "secret"},
+	}
+	masked := config.Mask()
+	s.Equal(Masked, masked.Password)
+	s.Equal(Masked, masked.Secret)
+	s.Empty(masked.Token)
+	s.Equal("https://oapi.dingtalk.com/robot/send?access_token=%2A%2A%2A%2A%2A%2A", masked.URL)
+	s.Equal(map[string]string{"X-Token": Masked}, masked.Headers)
+	s.Equal("secret", config.Headers["X-Token"])
+
+	s.Equal(config, masked.Restore(config))
+
+	masked.Secret = "SEC456"
+	masked.URL = "https://oapi.dingtalk.com/robot/send?access_token=def"
+	masked.Headers["X-Other"] = "other"
+	restored := masked.Restore(config)
+	s.Equal("password", restored.Password)
+	s.Equal("SEC456", restored.Secret)
+	s.Equal("https://oapi.dingtalk.com/robot/send?access_token=def", restored.URL)
+	s.Equal(map[string]string{"X-Token": "secret", "X-Other": "other"}, restored.Headers)
+}
+
+func (s *NotifyTestSuite) TestWebhookRender() {
+	webhook, err := NewWebhook(Config{URL: "https://example.com/hook"})
+	s.NoError(err)
+	body, err := webhook.Render(s.message)
+	s.NoError(err)
+
+	var data map[string]string
+	s.NoError(json.Unmarshal(body, &data))
+	s.Equal("cert_expiring", data["event"])
+	s.Equal(s.message.Content, data["content"])
+	s.Equal("2023-12-01 10:00:00", data["time"])
+
+	webhook, err = NewWebhook(Config{URL: "https://example.com/hook", Template: `{"text": "{{.Content}}"}`})
+	s.NoError(err)
+	_, err = webhook.Render(s.message)
+	s.Error(err)
+
+	webhook, err = NewWebhook(Config{URL: "https://example.com/hook", Template: `{{.Title}}`, Headers: map[string]string{"Content-Type": "text/plain"}})
+	s.NoError(err)
+	body, err = webhook.Render(s.message)
+	s.NoError(err)
+	s.Equal(s.message.Title, string(body))
+
+	_, err = NewWebhook(Config{URL: "https://example.com/hook", Template: `{{.Title`})
+	s.Error(err)
+}
+
+func (s *NotifyTestSuite) TestWebhookSend() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.Equal(http.MethodPut, r.Method)
+		s.Equal("secret", r.Header.Get("X-Token"))
+		body, _ := io.ReadAll(r.Body)
+		s.Contains(string(body), `"title": "证书即将过期"`)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	webhook, err := NewWebhook(Config{URL: server.URL, Method: "put", Headers: map[string]string{"X-Token": "secret"}})
+	s.NoError(err)
+	s.NoError(webhook.Send(s.message))
+
+	failed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failed.Close()
+
+	webhook, err = NewWebhook(Config{URL: failed.URL})
+	s.NoError(err)
+	s.Error(webhook.Send(s.message))
+}
+
+func (s *NotifyTestSuite) TestTelegram() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.Equal("/bottoken/sendMessage", r.URL.Path)
+		var data map[string]string
+		s.NoError(json.NewDecoder(r.Body).Decode(&data))
+		if data["chat_id"] != "42" {
+			_, _ = w.Write([]byte(`{"ok": false, "description": "chat not found"}`))
+			return
+		}
+		s.True(strings.HasPrefix(data["text"], s.message.Title))
+		_, _ = w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	telegram, err := NewTelegram(Config{Token: "token", ChatID: "42", API: server.URL})
+	s.NoError(err)
+	s.NoError(telegram.Send(s.message))
+
+	telegram, err = NewTelegram(Config{Token: "token", ChatID: "0", API: server.URL})
+	s.NoError(err)
+	s.EqualError(telegram.Send(s.message), "发送失败: chat not found")
+}
+
+func (s *NotifyTestSuite) TestDingTalkSign() {
+	dingtalk, err := NewDingTalk(Config{URL: "https://oapi.dingtalk.com/robot/send?access_token=abc", Secret: "SEC123"})
+	s.NoError(err)
+
+	now := time.UnixMilli(1700000000000)
+	signed, err := dingtalk.SignedURL(now)
+	s.NoError(err)
+
+	u, err := url.Parse(signed)
+	s.NoError(err)
+	s.Equal("abc", u.Query().Get("access_token"))
+	s.Equal("1700000000000", u.Query().Get("timestamp"))
+
+	mac := hmac.New(sha256.New, []byte("SEC123"))
+	mac.Write([]byte("1700000000000\nSEC123"))
+	s.Equal(base64.StdEncoding.EncodeToString(mac.Sum(nil)), u.Query().Get("sign"))
+
+	dingtalk, err = NewDingTalk(Config{URL: "https://oapi.dingtalk.com/robot/send?access_token=abc"})
+	s.NoError(err)
+	signed, err = dingtalk.SignedURL(now)
+	s.NoError(err)
+	s.Equal("https://oapi.dingtalk.com/robot/send?access_token=abc", signed)
+}
+
+func (s *NotifyTestSuite) TestChatSend() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data struct {
+			MsgType  string            `json:"msgtype"`
+			Markdown map[string]string `json:"markdown"`
+		}
+		s.NoError(json.NewDecoder(r.Body).Decode(&data))
+		s.Equal("markdown", data.MsgType)
+		if r.URL.Path == "/fail" {
+			_, _ = w.Write([]byte(`{"errcode": 310000, "errmsg": "sign not match"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"errcode": 0, "errmsg": "ok"}`))
+	}))
+	defer server.Close()
+
+	dingtalk, err := NewDingTalk(Config{URL: server.URL + "/ok", Secret: "SEC"})
+	s.NoError(err)
+	s.NoError(dingtalk.Send(s.message))
+
+	wecom, err := NewWeCom(Config{URL: server.URL + "/ok"})
+	s.NoError(err)
+	s.NoError(wecom.Send(s.message))
+
+	wecom, err = NewWeCom(Config{URL: server.URL + "/fail"})
+	s.NoError(err)
+	s.EqualError(wecom.Send(s.message), "发送失败: 310000 sign not match")
+}
+
+func (s *NotifyTestSuite) TestEmailBuild() {
+	email, err := NewEmail(Config{Host: "smtp.example.com", Port: 587, From: "面板 <panel@example.com>", To: []string{"admin@example.com"}})
+	s.NoError(err)
+
+	data := string(email.build(s.message))
+	s.Contains(data, "Subject: =?UTF-8?b?")
+	s.Contains(data, "To: admin@example.com\r\n")
+	body := data[strings.Index(data, "\r\n\r\n")+4:]
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(body, "\r\n", ""))
+	s.NoError(err)
+	s.Contains(string(decoded), s.message.Content)
+}
@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultWebhookTemplate 默认的 Webhook 请求体模板
+const DefaultWebhookTemplate = `{"event": {{json .Event}}, "title": {{json .Title}}, "content": {{json .Content}}, "time": {{json .Time}}}`
+
+// Webhook 通用 Webhook 通知，请求体由模板渲染
+type Webhook struct {
+	config   Config
+	template *template.Template
+}
+
+func NewWebhook(config Config) (*Webhook, error) {
+	if err := checkURL(config.URL); err != nil {
+		return nil, err
+	}
+	config.Method = strings.ToUpper(config.Method)
+	if len(config.Method) == 0 {
+		config.Method = http.MethodPost
+	}
+	if config.Method != http.MethodPost && config.Method != http.MethodPut {
+		return nil, errors.New("请求方法仅支持 POST / PUT")
+	}
+	if len(config.Template) == 0 {
+		config.Template = DefaultWebhookTemplate
+	}
+
+	tpl, err := template.New("webhook").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			if t, ok := v.(time.Time); ok {
+				v = t.Format(time.DateTime)
+			}
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}).Parse(config.Template)
+	if err != nil {
+		return nil, errors.New("模板格式错误: " + err.Error())
+	}
+
+	return &Webhook{config: config, template: tpl}, nil
+}
+
+// Render 渲染请求体
+func (r *Webhook) Render(message Message) ([]byte, error) {
+	var body bytes.Buffer
+	if err := r.template.Execute(&body, message); err != nil {
+		return nil, err
+	}
+
+	contentType := r.config.Headers["Content-Type"]
+	if (len(contentType) == 0 || strings.Contains(contentType, "json")) && !json.Valid(body.Bytes()) {
+		return nil, errors.New("模板渲染结果不是合法的 JSON")
+	}
+
+	return body.Bytes(), nil
+}
+
+// Send 发送通知
+func (r *Webhook) Send(message Message) error {
+	body, err := r.Render(message)
+	if err != nil {
+		return err
+	}
+
+	_, err = request(r.config.Method, r.config.URL, body, r.config.Headers)
+	return err
+}
+
+// checkURL 检查 Webhook 地址
+func checkURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return errors.New("Webhook 地址需为 http / https URL")
+	}
+
+	return nil
+}
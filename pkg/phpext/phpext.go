@@ -0,0 +1,155 @@
+// Package phpext PHP 扩展目录及 php.ini 中扩展的管理
+package phpext
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Marker php.ini 中面板管理的扩展均位于此标记之后
+const Marker = ";haozi"
+
+var (
+	//go:embed catalog.json
+	catalogJSON []byte
+	catalog     []Extension
+
+	packagePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
+	versionPattern = regexp.MustCompile(`^[0-9]+\.[0-9]+(\.[0-9]+)?([a-zA-Z]+[0-9]*)?$`)
+	optionPattern  = regexp.MustCompile(`^--[a-zA-Z0-9][a-zA-Z0-9_-]*(=[a-zA-Z0-9_./:+,@-]*)?$`)
+	iniLine        = regexp.MustCompile(`^\s*(;)?\s*(zend_extension|extension)\s*=\s*"?([^"\s;]+)"?\s*$`)
+)
+
+func init() {
+	if err := json.Unmarshal(catalogJSON, &catalog); err != nil {
+		panic("phpext: 扩展目录解析失败: " + err.Error())
+	}
+}
+
+// Extension 扩展目录中的扩展
+type Extension struct {
+	Name        string   `json:"name"`
+	Slug        string   `json:"slug"`
+	Description string   `json:"description"`
+	Module      string   `json:"module"`              // php -m 中的小写模块名
+	Ini         string   `json:"ini"`                 // php.ini 中的扩展名，{php} 会被替换为 PHP 版本
+	Zend        bool     `json:"zend"`                // 是否以 zend_extension 加载
+	Script      string   `json:"script,omitempty"`    // 面板自带的安装脚本
+	Package     string   `json:"package,omitempty"`   // PECL 包名
+	Version     string   `json:"version,omitempty"`   // PECL 包版本，为空时安装最新版
+	Configure   []string `json:"configure,omitempty"` // 编译选项
+	Min         int      `json:"min,omitempty"`       // 支持的最低 PHP 版本，如 80
+	Max         int      `json:"max,omitempty"`       // 支持的最高 PHP 版本
+}
+
+// IniExtension php.ini 中的扩展
+type IniExtension struct {
+	Name    string `json:"name"`
+	Zend    bool   `json:"zend"`
+	Enabled bool   `json:"enabled"`
+}
+
+// Catalog 获取指定 PHP 版本可用的扩展
+func Catalog(php int) []Extension {
+	var extensions []Extension
+	for _, extension := range catalog {
+		if extension.Min > 0 && php < extension.Min || extension.Max > 0 && php > extension.Max {
+			continue
+		}
+		extension.Ini = strings.ReplaceAll(extension.Ini, "{php}", strconv.Itoa(php))
+		extensions = append(extensions, extension)
+	}
+
+	return extensions
+}
+
+// Find 在指定 PHP 版本的扩展目录中查找扩展
+func Find(php int, slug string) (Extension, bool) {
+	for _, extension := range Catalog(php) {
+		if extension.Slug == slug {
+			return extension, true
+		}
+	}
+
+	return Extension{}, false
+}
+
+// Validate 校验 PECL 扩展的包名、版本和编译选项
+func (e Extension) Validate() error {
+	if !packagePattern.MatchString(e.Package) {
+		return errors.New("PECL 包名不合法")
+	}
+	if !packagePattern.MatchString(e.Ini) {
+		return errors.New("扩展名不合法")
+	}
+	if len(e.Version) > 0 && !versionPattern.MatchString(e.Version) {
+		return errors.New("版本号不合法")
+	}
+	for _, option := range e.Configure {
+		if !optionPattern.MatchString(option) {
+			return errors.New("编译选项 " + option + " 不合法")
+		}
+	}
+
+	return nil
+}
+
+// Extensions 解析 php.ini 中标记之后的扩展，注释掉的扩展视为已停用
+func Extensions(ini string) []IniExtension {
+	var extensions []IniExtension
+	lines := strings.Split(ini, "\n")
+	for _, line := range lines[start(lines):] {
+		match := iniLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		extensions = append(extensions, IniExtension{
+			Name:    Name(match[3]),
+			Zend:    match[2] == "zend_extension",
+			Enabled: match[1] == "",
+		})
+	}
+
+	return extensions
+}
+
+// Name 将 php.ini 中的扩展值转换为扩展名，如 /usr/local/ioncube/ioncube_loader_lin_74.so 转换为 ioncube_loader_lin_74
+func Name(value string) string {
+	return strings.TrimSuffix(path.Base(value), ".so")
+}
+
+// Toggle 启用或停用 php.ini 中的扩展，返回新的配置及是否找到该扩展
+func Toggle(ini, name string, enabled bool) (string, bool) {
+	found := false
+	lines := strings.Split(ini, "\n")
+	for i := start(lines); i < len(lines); i++ {
+		match := iniLine.FindStringSubmatch(lines[i])
+		if match == nil || Name(match[3]) != name {
+			continue
+		}
+		found = true
+		line := match[2] + "=" + match[3]
+		if !enabled {
+			line = ";" + line
+		}
+		lines[i] = line
+	}
+
+	return strings.Join(lines, "\n"), found
+}
+
+// start 标记之后的第一行，php.ini 默认的 Dynamic Extensions 段中有大量注释掉的扩展，需跳过
+func start(lines []string) int {
+	for i, line := range lines {
+		if strings.TrimSpace(line) == Marker {
+			return i + 1
+		}
+	}
+
+	return len(lines)
+}
@@ -0,0 +1,71 @@
+package phpext
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PhpExtTestSuite struct {
+	suite.Suite
+}
+
+func TestPhpExtTestSuite(t *testing.T) {
+	suite.Run(t, &PhpExtTestSuite{})
+}
+
+func (s *PhpExtTestSuite) TestCatalog() {
+	ioncube, ok := Find(74, "ionCube Loader")
+	s.True(ok)
+	s.Equal("ioncube_loader_lin_74", ioncube.Ini)
+	s.True(ioncube.Zend)
+
+	_, ok = Find(82, "ionCube Loader")
+	s.False(ok)
+
+	swoole, ok := Find(74, "swoole")
+	s.True(ok)
+	s.Equal("4.8.13", swoole.Version)
+	swoole, ok = Find(83, "swoole")
+	s.True(ok)
+	s.Equal("5.1.1", swoole.Version)
+
+	slugs := make(map[string]bool)
+	for _, extension := range Catalog(82) {
+		s.False(slugs[extension.Slug], extension.Slug)
+		slugs[extension.Slug] = true
+		if len(extension.Package) > 0 {
+			s.NoError(extension.Validate(), extension.Slug)
+		}
+	}
+}
+
+func (s *PhpExtTestSuite) TestValidate() {
+	s.NoError(Extension{Package: "yaml", Ini: "yaml", Version: "2.2.3", Configure: []string{"--with-yaml=/usr", "--enable-foo"}}.Validate())
+	s.NoError(Extension{Package: "xdebug", Ini: "xdebug", Version: "3.3.0alpha3"}.Validate())
+	s.Error(Extension{Package: "yaml; rm -rf /", Ini: "yaml"}.Validate())
+	s.Error(Extension{Package: "yaml", Ini: "../yaml"}.Validate())
+	s.Error(Extension{Package: "yaml", Ini: "yaml", Version: "latest'"}.Validate())
+	s.Error(Extension{Package: "yaml", Ini: "yaml", Configure: []string{"--with-yaml=$(id)"}}.Validate())
+	s.Error(Extension{Package: "yaml", Ini: "yaml", Configure: []string{"CFLAGS=-O0"}}.Validate())
+}
+
+func (s *PhpExtTestSuite) TestIni() {
+	ini := "[PHP]\n;extension=bz2\nextension=curl\n;haozi\nzend_extension=/usr/local/ioncube/ioncube_loader_lin_74.so\nextension = \"redis.so\"\n;extension=imagick\n"
+	s.Equal([]IniExtension{
+		{Name: "ioncube_loader_lin_74", Zend: true, Enabled: true},
+		{Name: "redis", Enabled: true},
+		{Name: "imagick", Enabled: false},
+	}, Extensions(ini))
+
+	ini, found := Toggle(ini, "redis", false)
+	s.True(found)
+	s.Contains(ini, "\n;extension=redis.so\n")
+	ini, found = Toggle(ini, "imagick", true)
+	s.True(found)
+	s.Contains(ini, "\nextension=imagick\n")
+	_, found = Toggle(ini, "bz2", true)
+	s.False(found)
+
+	s.Equal("[PHP]\n;extension=bz2\nextension=curl\n;haozi\nzend_extension=/usr/local/ioncube/ioncube_loader_lin_74.so\n;extension=redis.so\nextension=imagick\n", ini)
+}
@@ -0,0 +1,70 @@
+package phpfpm
+
+import (
+	"sort"
+	"strings"
+)
+
+// SetIni 设置 ini 内容中的配置项，value 为空时删除该配置项，其余内容保持不变
+func SetIni(raw, key, value string) string {
+	var lines []string
+	for _, line := range strings.Split(raw, "\n") {
+		name, _, found := strings.Cut(line, "=")
+		if found && strings.TrimSpace(name) == key {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	// 去除末尾空行
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(value) > 0 {
+		lines = append(lines, key+"="+value)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// ParseModules 解析 php -m 的输出，返回小写的扩展名列表
+func ParseModules(out string) []string {
+	seen := make(map[string]bool)
+	var modules []string
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "[") {
+			continue
+		}
+		name := strings.ToLower(line)
+		if name == "zend opcache" {
+			name = "opcache"
+		}
+		if !seen[name] {
+			seen[name] = true
+			modules = append(modules, name)
+		}
+	}
+	sort.Strings(modules)
+
+	return modules
+}
+
+// Missing 返回 required 中未在 modules 内的扩展，扩展名不区分大小写
+func Missing(modules, required []string) []string {
+	installed := make(map[string]bool, len(modules))
+	for _, module := range modules {
+		installed[strings.ToLower(module)] = true
+	}
+
+	var missing []string
+	for _, name := range required {
+		if !installed[strings.ToLower(name)] {
+			missing = append(missing, name)
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,38 @@
+package phpfpm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type IniTestSuite struct {
+	suite.Suite
+}
+
+func TestIniTestSuite(t *testing.T) {
+	suite.Run(t, &IniTestSuite{})
+}
+
+func (s *IniTestSuite) TestSetIni() {
+	raw := SetIni("", "open_basedir", "/www/wwwroot/example:/tmp/")
+	s.Equal("open_basedir=/www/wwwroot/example:/tmp/\n", raw)
+
+	raw = SetIni(raw, "memory_limit", "256M")
+	s.Equal("open_basedir=/www/wwwroot/example:/tmp/\nmemory_limit=256M\n", raw)
+
+	raw = SetIni("; comment\nmemory_limit = 128M\n\n", "memory_limit", "512M")
+	s.Equal("; comment\nmemory_limit=512M\n", raw)
+
+	raw = SetIni("open_basedir=/www:/tmp/\nmemory_limit=256M\n", "open_basedir", "")
+	s.Equal("memory_limit=256M\n", raw)
+	s.Equal("", SetIni(raw, "memory_limit", ""))
+}
+
+func (s *IniTestSuite) TestModules() {
+	modules := ParseModules("[PHP Modules]\nCore\ncurl\nredis\nZend OPcache\n\n[Zend Modules]\nZend OPcache\n")
+	s.Equal([]string{"core", "curl", "opcache", "redis"}, modules)
+
+	s.Empty(Missing(modules, []string{"Redis", "opcache"}))
+	s.Equal([]string{"imagick"}, Missing(modules, []string{"curl", "imagick"}))
+}
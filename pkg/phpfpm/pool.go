@@ -0,0 +1,181 @@
+// Package phpfpm PHP-FPM 进程池配置
+package phpfpm
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	namePattern  = regexp.MustCompile(`^[a-zA-Z0-9_-]+(\.[a-zA-Z0-9_-]+)*$`)
+	userPattern  = regexp.MustCompile(`^[a-z_][a-z0-9_-]{0,31}$`)
+	keyPattern   = regexp.MustCompile(`^[a-z0-9_.]+$`)
+	sizePattern  = regexp.MustCompile(`^\d+[KMG]?$`)
+	includeLine  = regexp.MustCompile(`(?m)^\s*include\s*=.*$`)
+	globalHeader = regexp.MustCompile(`(?m)^\[global\][ \t]*$`)
+)
+
+// Pool 进程池配置
+type Pool struct {
+	Name                    string
+	User                    string
+	Group                   string
+	Listen                  string
+	ListenOwner             string
+	ListenGroup             string
+	PM                      string // dynamic、static 或 ondemand
+	MaxChildren             int
+	StartServers            int
+	MinSpareServers         int
+	MaxSpareServers         int
+	MaxRequests             int // 0 为不限制
+	IdleTimeout             int // ondemand 模式下空闲进程的存活时间（秒）
+	RequestTerminateTimeout int
+	RequestSlowlogTimeout   int
+	Slowlog                 string
+	StatusPath              string
+	AdminValues             map[string]string // php_admin_value，不可被程序内 ini_set 覆盖
+}
+
+// ValidUser 系统用户名是否合法
+func ValidUser(user string) bool {
+	return userPattern.MatchString(user)
+}
+
+// ValidSize 是否为 PHP 配置中的容量写法，如 128M
+func ValidSize(size string) bool {
+	return sizePattern.MatchString(size)
+}
+
+// Validate 校验进程池配置
+func (p Pool) Validate() error {
+	if !namePattern.MatchString(p.Name) || p.Name == "global" {
+		return errors.New("进程池名称不合法")
+	}
+	if !ValidUser(p.User) || p.User == "root" {
+		return errors.New("运行用户不合法")
+	}
+	if len(p.Group) > 0 && (!ValidUser(p.Group) || p.Group == "root") {
+		return errors.New("运行用户组不合法")
+	}
+	if len(p.Listen) == 0 || strings.ContainsAny(p.Listen, " \n") {
+		return errors.New("监听地址不合法")
+	}
+	if p.MaxChildren < 1 {
+		return errors.New("最大进程数需大于 0")
+	}
+
+	switch p.PM {
+	case "static", "ondemand":
+	case "dynamic":
+		if p.MinSpareServers < 1 || p.MaxSpareServers < p.MinSpareServers {
+			return errors.New("最小空闲进程数需大于 0 且不大于最大空闲进程数")
+		}
+		if p.MaxSpareServers > p.MaxChildren {
+			return errors.New("最大空闲进程数不能大于最大进程数")
+		}
+		if p.StartServers < p.MinSpareServers || p.StartServers > p.MaxSpareServers {
+			return errors.New("启动进程数需在最小和最大空闲进程数之间")
+		}
+	default:
+		return errors.New("进程管理方式仅支持 static、dynamic 和 ondemand")
+	}
+	if p.MaxRequests < 0 || p.IdleTimeout < 0 || p.RequestTerminateTimeout < 0 || p.RequestSlowlogTimeout < 0 {
+		return errors.New("参数不能为负数")
+	}
+
+	for key, value := range p.AdminValues {
+		if !keyPattern.MatchString(key) {
+			return fmt.Errorf("PHP 配置项 %s 不合法", key)
+		}
+		if strings.ContainsAny(value, "\r\n\";") {
+			return fmt.Errorf("PHP 配置项 %s 的值不合法", key)
+		}
+	}
+
+	return nil
+}
+
+// String 生成进程池配置文件内容
+func (p Pool) String() string {
+	group := p.Group
+	if len(group) == 0 {
+		group = p.User
+	}
+
+	var sb strings.Builder
+	sb.WriteString("; 此文件由面板生成，手动修改将被覆盖\n")
+	sb.WriteString("[" + p.Name + "]\n")
+	writeValue(&sb, "user", p.User)
+	writeValue(&sb, "group", group)
+	writeValue(&sb, "listen", p.Listen)
+	writeValue(&sb, "listen.owner", p.ListenOwner)
+	writeValue(&sb, "listen.group", p.ListenGroup)
+	if len(p.ListenOwner) > 0 || len(p.ListenGroup) > 0 {
+		writeValue(&sb, "listen.mode", "0660")
+	}
+	writeValue(&sb, "pm", p.PM)
+	writeValue(&sb, "pm.max_children", fmt.Sprint(p.MaxChildren))
+	switch p.PM {
+	case "dynamic":
+		writeValue(&sb, "pm.start_servers", fmt.Sprint(p.StartServers))
+		writeValue(&sb, "pm.min_spare_servers", fmt.Sprint(p.MinSpareServers))
+		writeValue(&sb, "pm.max_spare_servers", fmt.Sprint(p.MaxSpareServers))
+	case "ondemand":
+		if p.IdleTimeout > 0 {
+			writeValue(&sb, "pm.process_idle_timeout", fmt.Sprintf("%ds", p.IdleTimeout))
+		}
+	}
+	writeValue(&sb, "pm.max_requests", fmt.Sprint(p.MaxRequests))
+	writeValue(&sb, "pm.status_path", p.StatusPath)
+	if p.RequestTerminateTimeout > 0 {
+		writeValue(&sb, "request_terminate_timeout", fmt.Sprint(p.RequestTerminateTimeout))
+	}
+	if p.RequestSlowlogTimeout > 0 && len(p.Slowlog) > 0 {
+		writeValue(&sb, "request_slowlog_timeout", fmt.Sprint(p.RequestSlowlogTimeout))
+		writeValue(&sb, "slowlog", p.Slowlog)
+	}
+
+	keys := make([]string, 0, len(p.AdminValues))
+	for key := range p.AdminValues {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writeValue(&sb, "php_admin_value["+key+"]", p.AdminValues[key])
+	}
+
+	return sb.String()
+}
+
+// Include 确保主配置文件的末尾引入了 pattern 匹配的进程池配置，返回新的配置及是否有修改
+//
+// PHP-FPM 会在 include 处就地解析引入的文件，include 之后的全局配置会被当作最后一个引入的进程池的配置，
+// 因此 include 必须位于所有全局配置和 [www] 进程池之后，位置不正确的 include 会被移动到末尾
+func Include(conf, pattern string) (string, bool) {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(conf, "\n") {
+		if includeLine.MatchString(line) && strings.ReplaceAll(strings.TrimSpace(line), " ", "") == "include="+pattern {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	result := strings.TrimRight(strings.Join(lines, "\n"), "\n")
+	if !globalHeader.MatchString(result) {
+		result = "[global]\n" + result
+	}
+	result += "\n\ninclude = " + pattern + "\n"
+
+	return result, result != conf
+}
+
+func writeValue(sb *strings.Builder, key, value string) {
+	if len(value) == 0 {
+		return
+	}
+	sb.WriteString(key + " = " + value + "\n")
+}
@@ -0,0 +1,124 @@
+package phpfpm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PoolTestSuite struct {
+	suite.Suite
+}
+
+func TestPoolTestSuite(t *testing.T) {
+	suite.Run(t, &PoolTestSuite{})
+}
+
+func (s *PoolTestSuite) pool() Pool {
+	return Pool{
+		Name:            "example.com",
+		User:            "site_example",
+		Listen:          "/tmp/php-cgi-82-example.com.sock",
+		ListenOwner:     "www",
+		ListenGroup:     "www",
+		PM:              "dynamic",
+		MaxChildren:     10,
+		StartServers:    2,
+		MinSpareServers: 1,
+		MaxSpareServers: 3,
+		StatusPath:      "/phpfpm_status/82/example.com",
+		AdminValues: map[string]string{
+			"upload_max_filesize": "64M",
+			"memory_limit":        "256M",
+		},
+	}
+}
+
+func (s *PoolTestSuite) TestValidate() {
+	s.NoError(s.pool().Validate())
+
+	pool := s.pool()
+	pool.User = "root"
+	s.Error(pool.Validate())
+
+	pool = s.pool()
+	pool.Name = "../www"
+	s.Error(pool.Validate())
+
+	pool = s.pool()
+	pool.StartServers = 5
+	s.Error(pool.Validate())
+
+	pool = s.pool()
+	pool.PM = "static"
+	pool.StartServers = 0
+	s.NoError(pool.Validate())
+
+	pool = s.pool()
+	pool.AdminValues["memory_limit"] = "256M\nuser = root"
+	s.Error(pool.Validate())
+
+	pool = s.pool()
+	pool.AdminValues["bad key"] = "1"
+	s.Error(pool.Validate())
+
+	s.True(ValidSize("128M"))
+	s.True(ValidSize("1024"))
+	s.False(ValidSize("1T"))
+	s.False(ValidSize("-1"))
+}
+
+func (s *PoolTestSuite) TestString() {
+	s.Equal(`; 此文件由面板生成，手动修改将被覆盖
+[example.com]
+user = site_example
+group = site_example
+listen = /tmp/php-cgi-82-example.com.sock
+listen.owner = www
+listen.group = www
+listen.mode = 0660
+pm = dynamic
+pm.max_children = 10
+pm.start_servers = 2
+pm.min_spare_servers = 1
+pm.max_spare_servers = 3
+pm.max_requests = 0
+pm.status_path = /phpfpm_status/82/example.com
+php_admin_value[memory_limit] = 256M
+php_admin_value[upload_max_filesize] = 64M
+`, s.pool().String())
+
+	pool := s.pool()
+	pool.PM = "ondemand"
+	pool.IdleTimeout = 10
+	pool.RequestSlowlogTimeout = 30
+	pool.Slowlog = "var/log/slow-example.com.log"
+	conf := pool.String()
+	s.Contains(conf, "pm.process_idle_timeout = 10s\n")
+	s.Contains(conf, "request_slowlog_timeout = 30\nslowlog = var/log/slow-example.com.log\n")
+	s.NotContains(conf, "pm.start_servers")
+}
+
+func (s *PoolTestSuite) TestInclude() {
+	conf := "[global]\npid = /www/server/php/82/var/run/php-fpm.pid\nerror_log = /www/server/php/82/var/log/php-fpm.log\n\n[www]\nuser = www\n"
+	result, changed := Include(conf, "/www/server/php/82/etc/php-fpm.d/*.conf")
+	s.True(changed)
+	s.Equal("[global]\npid = /www/server/php/82/var/run/php-fpm.pid\nerror_log = /www/server/php/82/var/log/php-fpm.log\n\n[www]\nuser = www\n\ninclude = /www/server/php/82/etc/php-fpm.d/*.conf\n", result)
+	// include 必须位于全局配置和 [www] 进程池之后
+	s.Greater(strings.Index(result, "include ="), strings.Index(result, "error_log ="))
+	s.Greater(strings.Index(result, "include ="), strings.Index(result, "[www]"))
+
+	result, changed = Include(result, "/www/server/php/82/etc/php-fpm.d/*.conf")
+	s.False(changed)
+	s.Equal(1, len(includeLine.FindAllString(result, -1)))
+
+	// 旧版本写在 [global] 段首的 include 移动到末尾
+	result, changed = Include("[global]\ninclude = /www/server/php/82/etc/php-fpm.d/*.conf\npid = /www/server/php/82/var/run/php-fpm.pid\n\n[www]\nuser = www\n", "/www/server/php/82/etc/php-fpm.d/*.conf")
+	s.True(changed)
+	s.Equal("[global]\npid = /www/server/php/82/var/run/php-fpm.pid\n\n[www]\nuser = www\n\ninclude = /www/server/php/82/etc/php-fpm.d/*.conf\n", result)
+
+	result, changed = Include("[www]\nuser = www\n", "/etc/php-fpm.d/*.conf")
+	s.True(changed)
+	s.Equal("[global]\n[www]\nuser = www\n\ninclude = /etc/php-fpm.d/*.conf\n", result)
+}
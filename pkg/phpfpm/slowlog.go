@@ -0,0 +1,59 @@
+package phpfpm
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var slowHeader = regexp.MustCompile(`^\[(\d{2}-[A-Za-z]{3}-\d{4} \d{2}:\d{2}:\d{2})\]\s+\[pool ([^\]]+)\] pid (\d+)$`)
+
+// SlowEntry 慢日志中的一条记录
+type SlowEntry struct {
+	Time   time.Time `json:"time"`
+	Pool   string    `json:"pool"`
+	PID    int       `json:"pid"`
+	Script string    `json:"script"`
+	Trace  []string  `json:"trace"` // 调用栈，由内到外
+}
+
+// ParseSlowLog 解析慢日志，日志中的时间没有时区，按 loc 解析
+func ParseSlowLog(raw string, loc *time.Location) []SlowEntry {
+	var entries []SlowEntry
+	var current *SlowEntry
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		if match := slowHeader.FindStringSubmatch(line); match != nil {
+			t, err := time.ParseInLocation("02-Jan-2006 15:04:05", match[1], loc)
+			if err != nil {
+				current = nil
+				continue
+			}
+			pid, _ := strconv.Atoi(match[3])
+			entries = append(entries, SlowEntry{Time: t, Pool: match[2], PID: pid})
+			current = &entries[len(entries)-1]
+			continue
+		}
+		// 读取日志末尾时第一条记录可能不完整
+		if current == nil {
+			continue
+		}
+
+		if script, ok := strings.CutPrefix(line, "script_filename = "); ok {
+			current.Script = script
+			continue
+		}
+		if strings.HasPrefix(line, "[0x") {
+			if _, frame, found := strings.Cut(line, "] "); found {
+				current.Trace = append(current.Trace, frame)
+			}
+		}
+	}
+
+	return entries
+}
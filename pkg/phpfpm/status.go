@@ -0,0 +1,169 @@
+package phpfpm
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	// statusLocation 默认进程池状态页的 location，由 OpenResty 安装脚本写入主配置文件
+	statusLocation = "location ~ ^/phpfpm_status/(?<version>\\d+)$ {"
+	// poolStatusLocation 网站独立进程池状态页的 location
+	poolStatusLocation = "location ~ ^/phpfpm_status/(?<version>\\d+)/(?<pool>[a-zA-Z0-9._-]+)$ {"
+)
+
+// Status 进程池状态，对应 pm.status_path 的 ?json&full 输出
+type Status struct {
+	Pool               string    `json:"pool"`
+	ProcessManager     string    `json:"process_manager"`
+	StartTime          time.Time `json:"start_time"`
+	AcceptedConn       uint64    `json:"accepted_conn"`
+	ListenQueue        int       `json:"listen_queue"`
+	MaxListenQueue     int       `json:"max_listen_queue"`
+	ListenQueueLen     int       `json:"listen_queue_len"`
+	IdleProcesses      int       `json:"idle_processes"`
+	ActiveProcesses    int       `json:"active_processes"`
+	TotalProcesses     int       `json:"total_processes"`
+	MaxActiveProcesses int       `json:"max_active_processes"`
+	MaxChildrenReached uint64    `json:"max_children_reached"`
+	SlowRequests       uint64    `json:"slow_requests"`
+	Processes          []Process `json:"processes"`
+}
+
+// Process 进程池中的进程
+type Process struct {
+	PID               int           `json:"pid"`
+	State             string        `json:"state"` // Idle、Running 等
+	StartTime         time.Time     `json:"start_time"`
+	Requests          uint64        `json:"requests"`
+	RequestDuration   time.Duration `json:"request_duration"` // 当前或上一个请求的耗时（纳秒）
+	RequestMethod     string        `json:"request_method"`
+	RequestURI        string        `json:"request_uri"`
+	ContentLength     uint64        `json:"content_length"`
+	User              string        `json:"user"`
+	Script            string        `json:"script"`
+	LastRequestCPU    float64       `json:"last_request_cpu"`    // 上一个请求的 CPU 使用率（%），请求进行中时为 0
+	LastRequestMemory uint64        `json:"last_request_memory"` // 上一个请求的内存峰值（字节），请求进行中时为 0
+	Slow              *SlowEntry    `json:"slow"`                // 慢日志中与当前请求对应的记录
+}
+
+type rawStatus struct {
+	Pool               string       `json:"pool"`
+	ProcessManager     string       `json:"process manager"`
+	StartTime          int64        `json:"start time"`
+	AcceptedConn       uint64       `json:"accepted conn"`
+	ListenQueue        int          `json:"listen queue"`
+	MaxListenQueue     int          `json:"max listen queue"`
+	ListenQueueLen     int          `json:"listen queue len"`
+	IdleProcesses      int          `json:"idle processes"`
+	ActiveProcesses    int          `json:"active processes"`
+	TotalProcesses     int          `json:"total processes"`
+	MaxActiveProcesses int          `json:"max active processes"`
+	MaxChildrenReached uint64       `json:"max children reached"`
+	SlowRequests       uint64       `json:"slow requests"`
+	Processes          []rawProcess `json:"processes"`
+}
+
+type rawProcess struct {
+	PID               int     `json:"pid"`
+	State             string  `json:"state"`
+	StartTime         int64   `json:"start time"`
+	Requests          uint64  `json:"requests"`
+	RequestDuration   int64   `json:"request duration"` // 微秒
+	RequestMethod     string  `json:"request method"`
+	RequestURI        string  `json:"request uri"`
+	ContentLength     uint64  `json:"content length"`
+	User              string  `json:"user"`
+	Script            string  `json:"script"`
+	LastRequestCPU    float64 `json:"last request cpu"`
+	LastRequestMemory uint64  `json:"last request memory"`
+}
+
+// ParseStatus 解析状态页的 JSON 输出
+func ParseStatus(raw []byte) (Status, error) {
+	var data rawStatus
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return Status{}, err
+	}
+
+	status := Status{
+		Pool:               data.Pool,
+		ProcessManager:     data.ProcessManager,
+		StartTime:          time.Unix(data.StartTime, 0),
+		AcceptedConn:       data.AcceptedConn,
+		ListenQueue:        data.ListenQueue,
+		MaxListenQueue:     data.MaxListenQueue,
+		ListenQueueLen:     data.ListenQueueLen,
+		IdleProcesses:      data.IdleProcesses,
+		ActiveProcesses:    data.ActiveProcesses,
+		TotalProcesses:     data.TotalProcesses,
+		MaxActiveProcesses: data.MaxActiveProcesses,
+		MaxChildrenReached: data.MaxChildrenReached,
+		SlowRequests:       data.SlowRequests,
+	}
+	for _, item := range data.Processes {
+		status.Processes = append(status.Processes, Process{
+			PID:               item.PID,
+			State:             item.State,
+			StartTime:         time.Unix(item.StartTime, 0),
+			Requests:          item.Requests,
+			RequestDuration:   time.Duration(item.RequestDuration) * time.Microsecond,
+			RequestMethod:     item.RequestMethod,
+			RequestURI:        item.RequestURI,
+			ContentLength:     item.ContentLength,
+			User:              item.User,
+			Script:            item.Script,
+			LastRequestCPU:    item.LastRequestCPU,
+			LastRequestMemory: item.LastRequestMemory,
+		})
+	}
+
+	return status, nil
+}
+
+// Correlate 将慢日志记录关联到正在处理请求的进程，now 为获取状态的时间
+func (s *Status) Correlate(entries []SlowEntry, now time.Time) {
+	for i := range s.Processes {
+		process := &s.Processes[i]
+		if process.State != "Running" {
+			continue
+		}
+		// 慢日志在请求开始后超过 request_slowlog_timeout 时写入
+		start := now.Add(-process.RequestDuration).Truncate(time.Second)
+		for j := range entries {
+			entry := entries[j]
+			if entry.PID == process.PID && !entry.Time.Before(start) {
+				process.Slow = &entry
+			}
+		}
+	}
+}
+
+// StatusLocation 确保 OpenResty 主配置文件中有网站独立进程池状态页的 location，插入到默认进程池状态页之后，
+// 返回新的配置及是否有修改
+func StatusLocation(conf string) (string, bool, error) {
+	if strings.Contains(conf, poolStatusLocation) {
+		return conf, false, nil
+	}
+
+	start := strings.Index(conf, statusLocation)
+	if start == -1 {
+		return "", false, errors.New("配置文件中缺少 PHP-FPM 状态页配置")
+	}
+	end := strings.Index(conf[start:], "}")
+	if end == -1 {
+		return "", false, errors.New("配置文件中 PHP-FPM 状态页配置格式错误")
+	}
+	end += start + 1
+
+	indent := conf[strings.LastIndex(conf[:start], "\n")+1 : start]
+	location := "\n" + indent + poolStatusLocation + "\n" +
+		indent + "    fastcgi_pass unix:/tmp/php-cgi-$version-$pool.sock;\n" +
+		indent + "    include fastcgi_params;\n" +
+		indent + "    fastcgi_param SCRIPT_FILENAME $fastcgi_script_name;\n" +
+		indent + "}"
+
+	return conf[:end] + location + conf[end:], true, nil
+}
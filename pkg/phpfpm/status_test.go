@@ -0,0 +1,111 @@
+package phpfpm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type StatusTestSuite struct {
+	suite.Suite
+}
+
+func TestStatusTestSuite(t *testing.T) {
+	suite.Run(t, &StatusTestSuite{})
+}
+
+func (s *StatusTestSuite) TestParseStatus() {
+	status, err := ParseStatus([]byte(`{"pool":"www","process manager":"dynamic","start time":1701396000,"start since":600,"accepted conn":120,"listen queue":0,"max listen queue":3,"listen queue len":511,"idle processes":1,"active processes":1,"total processes":2,"max active processes":2,"max children reached":4,"slow requests":1,"processes":[{"pid":101,"state":"Running","start time":1701396000,"start since":600,"requests":60,"request duration":35000000,"request method":"GET","request uri":"/index.php?a=1","content length":0,"user":"-","script":"/www/wwwroot/a/index.php","last request cpu":0.00,"last request memory":0},{"pid":102,"state":"Idle","start time":1701396000,"start since":600,"requests":60,"request duration":1500,"request method":"POST","request uri":"/api","content length":12,"user":"-","script":"/www/wwwroot/a/api.php","last request cpu":66.67,"last request memory":2097152}]}`))
+	s.NoError(err)
+	s.Equal("www", status.Pool)
+	s.Equal("dynamic", status.ProcessManager)
+	s.Equal(int64(1701396000), status.StartTime.Unix())
+	s.Equal(uint64(120), status.AcceptedConn)
+	s.Equal(uint64(4), status.MaxChildrenReached)
+	s.Len(status.Processes, 2)
+	s.Equal(35*time.Second, status.Processes[0].RequestDuration)
+	s.Equal("/index.php?a=1", status.Processes[0].RequestURI)
+	s.Equal(66.67, status.Processes[1].LastRequestCPU)
+	s.Equal(uint64(2097152), status.Processes[1].LastRequestMemory)
+
+	_, err = ParseStatus([]byte("pool: www\n"))
+	s.Error(err)
+}
+
+func (s *StatusTestSuite) TestSlowLog() {
+	raw := `_filename = /www/wwwroot/a/old.php
+[0x00007f0e4e213e80] usleep() /www/wwwroot/a/old.php:3
+
+[01-Dec-2023 10:20:00]  [pool www] pid 101
+script_filename = /www/wwwroot/a/index.php
+[0x00007f0e4e213e80] sleep() /www/wwwroot/a/index.php:3
+[0x00007f0e4e213df0] main() /www/wwwroot/a/index.php:0
+
+[01-Dec-2023 10:20:30]  [pool www] pid 101
+script_filename = /www/wwwroot/a/index.php
+[0x00007f0e4e213e80] curl_exec() /www/wwwroot/a/index.php:8
+`
+	entries := ParseSlowLog(raw, time.UTC)
+	s.Len(entries, 2)
+	s.Equal(time.Date(2023, 12, 1, 10, 20, 0, 0, time.UTC), entries[0].Time)
+	s.Equal("www", entries[0].Pool)
+	s.Equal(101, entries[0].PID)
+	s.Equal("/www/wwwroot/a/index.php", entries[0].Script)
+	s.Equal([]string{"sleep() /www/wwwroot/a/index.php:3", "main() /www/wwwroot/a/index.php:0"}, entries[0].Trace)
+
+	// 当前请求开始于 10:20:00，对应第二条慢日志
+	status := Status{Processes: []Process{
+		{PID: 101, State: "Running", RequestDuration: 35 * time.Second},
+		{PID: 102, State: "Idle", RequestDuration: 40 * time.Second},
+	}}
+	status.Correlate(entries, time.Date(2023, 12, 1, 10, 20, 35, 0, time.UTC))
+	s.NotNil(status.Processes[0].Slow)
+	s.Equal([]string{"curl_exec() /www/wwwroot/a/index.php:8"}, status.Processes[0].Slow.Trace)
+	s.Nil(status.Processes[1].Slow)
+
+	// 慢日志早于当前请求开始时间
+	status.Processes[0].Slow = nil
+	status.Processes[0].RequestDuration = time.Second
+	status.Correlate(entries, time.Date(2023, 12, 1, 10, 25, 0, 0, time.UTC))
+	s.Nil(status.Processes[0].Slow)
+}
+
+func (s *StatusTestSuite) TestStatusLocation() {
+	conf := `    server {
+        listen 80;
+        server_name 127.0.0.1;
+        location ~ ^/phpfpm_status/(?<version>\d+)$ {
+            fastcgi_pass unix:/tmp/php-cgi-$version.sock;
+            include fastcgi_params;
+            fastcgi_param SCRIPT_FILENAME $fastcgi_script_name;
+        }
+    }
+`
+	result, changed, err := StatusLocation(conf)
+	s.NoError(err)
+	s.True(changed)
+	s.Equal(`    server {
+        listen 80;
+        server_name 127.0.0.1;
+        location ~ ^/phpfpm_status/(?<version>\d+)$ {
+            fastcgi_pass unix:/tmp/php-cgi-$version.sock;
+            include fastcgi_params;
+            fastcgi_param SCRIPT_FILENAME $fastcgi_script_name;
+        }
+        location ~ ^/phpfpm_status/(?<version>\d+)/(?<pool>[a-zA-Z0-9._-]+)$ {
+            fastcgi_pass unix:/tmp/php-cgi-$version-$pool.sock;
+            include fastcgi_params;
+            fastcgi_param SCRIPT_FILENAME $fastcgi_script_name;
+        }
+    }
+`, result)
+
+	again, changed, err := StatusLocation(result)
+	s.NoError(err)
+	s.False(changed)
+	s.Equal(result, again)
+
+	_, _, err = StatusLocation("http {\n}\n")
+	s.Error(err)
+}
@@ -0,0 +1,273 @@
+// Package procstat 进程与服务的资源占用统计
+package procstat
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/process"
+)
+
+// Process 进程在某一时刻的累计资源数据
+type Process struct {
+	PID        int32
+	PPID       int32
+	Name       string
+	Cmdline    string
+	Unit       string  // 所属的 systemd 服务
+	CPUTime    float64 // 累计 CPU 时间（秒）
+	RSS        uint64
+	OpenFiles  int32
+	Threads    int32
+	ReadBytes  uint64
+	WriteBytes uint64
+}
+
+// Usage 一段时间内的资源占用
+type Usage struct {
+	Name      string  `json:"name"`
+	PID       int32   `json:"pid,omitempty"`
+	Cmdline   string  `json:"cmdline,omitempty"`
+	Processes int     `json:"processes"`
+	CPU       float64 `json:"cpu"` // CPU 使用率（%），多核可超过 100
+	RSS       uint64  `json:"rss"`
+	OpenFiles int32   `json:"open_files"`
+	Threads   int32   `json:"threads"`
+	ReadRate  float64 `json:"read_rate"`  // 磁盘读取速度（字节/秒）
+	WriteRate float64 `json:"write_rate"` // 磁盘写入速度（字节/秒）
+}
+
+var supervisorPattern = regexp.MustCompile(`^(\S+)\s+RUNNING\s+pid\s+(\d+),`)
+
+// Snapshot 读取当前所有进程的资源数据
+func Snapshot() (map[int32]Process, error) {
+	processes, err := process.Processes()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[int32]Process, len(processes))
+	for _, p := range processes {
+		name, err := p.Name()
+		if err != nil {
+			// 进程已退出
+			continue
+		}
+
+		item := Process{PID: p.Pid, Name: name}
+		item.PPID, _ = p.Ppid()
+		item.Cmdline, _ = p.Cmdline()
+		if times, err := p.Times(); err == nil {
+			item.CPUTime = times.User + times.System
+		}
+		if memory, err := p.MemoryInfo(); err == nil {
+			item.RSS = memory.RSS
+		}
+		item.OpenFiles, _ = p.NumFDs()
+		item.Threads, _ = p.NumThreads()
+		if io, err := p.IOCounters(); err == nil {
+			item.ReadBytes = io.ReadBytes
+			item.WriteBytes = io.WriteBytes
+		}
+		if cgroup, err := os.ReadFile("/proc/" + strconv.Itoa(int(p.Pid)) + "/cgroup"); err == nil {
+			item.Unit = ParseUnit(string(cgroup))
+		}
+
+		result[p.Pid] = item
+	}
+
+	return result, nil
+}
+
+// Sample 间隔 interval 读取两次进程数据，返回各进程在此期间的资源占用
+func Sample(interval time.Duration) (map[int32]Process, map[int32]Usage, error) {
+	prev, err := Snapshot()
+	if err != nil {
+		return nil, nil, err
+	}
+	time.Sleep(interval)
+	cur, err := Snapshot()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cur, Usages(prev, cur, interval.Seconds()), nil
+}
+
+// Usages 根据两次快照计算各进程的资源占用，新出现的进程从 0 开始计算
+func Usages(prev, cur map[int32]Process, seconds float64) map[int32]Usage {
+	result := make(map[int32]Usage, len(cur))
+	for pid, p := range cur {
+		before, ok := prev[pid]
+		if !ok || before.Name != p.Name {
+			before = Process{}
+		}
+
+		usage := Usage{
+			Name:      p.Name,
+			PID:       pid,
+			Cmdline:   p.Cmdline,
+			Processes: 1,
+			RSS:       p.RSS,
+			OpenFiles: p.OpenFiles,
+			Threads:   p.Threads,
+		}
+		if seconds > 0 {
+			usage.CPU = delta(p.CPUTime, before.CPUTime) / seconds * 100
+			usage.ReadRate = delta(float64(p.ReadBytes), float64(before.ReadBytes)) / seconds
+			usage.WriteRate = delta(float64(p.WriteBytes), float64(before.WriteBytes)) / seconds
+		}
+		result[pid] = usage
+	}
+
+	return result
+}
+
+// Aggregate 汇总多个进程的资源占用
+func Aggregate(name string, usages []Usage) Usage {
+	result := Usage{Name: name}
+	for _, usage := range usages {
+		result.Processes += usage.Processes
+		result.CPU += usage.CPU
+		result.RSS += usage.RSS
+		result.OpenFiles += usage.OpenFiles
+		result.Threads += usage.Threads
+		result.ReadRate += usage.ReadRate
+		result.WriteRate += usage.WriteRate
+	}
+
+	return result
+}
+
+// Unit 属于 systemd 服务 unit 的所有进程
+func Unit(processes map[int32]Process, usages map[int32]Usage, unit string) []Usage {
+	if !strings.Contains(unit, ".") {
+		unit += ".service"
+	}
+
+	var result []Usage
+	for pid, p := range processes {
+		if p.Unit == unit {
+			result = append(result, usages[pid])
+		}
+	}
+
+	return result
+}
+
+// Pool 属于 PHP-FPM 进程池的所有工作进程，工作进程的命令行为 "php-fpm: pool <池名>"
+func Pool(processes map[int32]Process, usages map[int32]Usage, pool string) []Usage {
+	var result []Usage
+	for pid, p := range processes {
+		if name, ok := strings.CutPrefix(p.Cmdline, "php-fpm: pool "); ok && strings.TrimSpace(name) == pool {
+			result = append(result, usages[pid])
+		}
+	}
+
+	return result
+}
+
+// Tree 进程及其所有子进程
+func Tree(processes map[int32]Process, usages map[int32]Usage, root int32) []Usage {
+	children := make(map[int32][]int32)
+	for pid, p := range processes {
+		children[p.PPID] = append(children[p.PPID], pid)
+	}
+
+	var result []Usage
+	queue := []int32{root}
+	seen := make(map[int32]bool)
+	for len(queue) > 0 {
+		pid := queue[0]
+		queue = queue[1:]
+		if seen[pid] {
+			continue
+		}
+		seen[pid] = true
+
+		if _, ok := processes[pid]; ok {
+			result = append(result, usages[pid])
+		}
+		queue = append(queue, children[pid]...)
+	}
+
+	return result
+}
+
+// Top 按 CPU 或内存占用排序取前 n 个进程，by 为 cpu 或 rss
+func Top(usages map[int32]Usage, by string, n int) []Usage {
+	result := make([]Usage, 0, len(usages))
+	for _, usage := range usages {
+		result = append(result, usage)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if by == "rss" && result[i].RSS != result[j].RSS {
+			return result[i].RSS > result[j].RSS
+		}
+		if result[i].CPU != result[j].CPU {
+			return result[i].CPU > result[j].CPU
+		}
+		return result[i].PID < result[j].PID
+	})
+
+	if n > 0 && len(result) > n {
+		result = result[:n]
+	}
+
+	return result
+}
+
+// ParseUnit 从 /proc/<pid>/cgroup 中解析所属的 systemd unit
+func ParseUnit(cgroup string) string {
+	for _, line := range strings.Split(cgroup, "\n") {
+		// 格式: hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(strings.TrimSpace(line), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[1] != "" && parts[1] != "name=systemd" {
+			continue
+		}
+
+		segments := strings.Split(parts[2], "/")
+		for i := len(segments) - 1; i >= 0; i-- {
+			if strings.HasSuffix(segments[i], ".service") || strings.HasSuffix(segments[i], ".scope") {
+				return segments[i]
+			}
+		}
+	}
+
+	return ""
+}
+
+// ParseSupervisor 从 supervisorctl status 的输出中解析运行中程序的 PID
+func ParseSupervisor(output string) map[string]int32 {
+	result := make(map[string]int32)
+	for _, line := range strings.Split(output, "\n") {
+		match := supervisorPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if len(match) != 3 {
+			continue
+		}
+		pid, err := strconv.Atoi(match[2])
+		if err != nil {
+			continue
+		}
+		result[match[1]] = int32(pid)
+	}
+
+	return result
+}
+
+// delta 计算累计值的增量，计数器重置时返回 0
+func delta(cur, prev float64) float64 {
+	if cur < prev {
+		return 0
+	}
+
+	return cur - prev
+}
@@ -0,0 +1,99 @@
+package procstat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ProcstatTestSuite struct {
+	suite.Suite
+}
+
+func TestProcstatTestSuite(t *testing.T) {
+	suite.Run(t, &ProcstatTestSuite{})
+}
+
+func (s *ProcstatTestSuite) TestParseUnit() {
+	s.Equal("openresty.service", ParseUnit("0::/system.slice/openresty.service\n"))
+	s.Equal("php-fpm-81.service", ParseUnit("12:pids:/system.slice/php-fpm-81.service\n1:name=systemd:/system.slice/php-fpm-81.service\n0::/system.slice/php-fpm-81.service"))
+	s.Equal("session-1.scope", ParseUnit("0::/user.slice/user-0.slice/session-1.scope"))
+	s.Equal("", ParseUnit("0::/"))
+	s.Equal("", ParseUnit(""))
+}
+
+func (s *ProcstatTestSuite) TestParseSupervisor() {
+	output := `queue                            RUNNING   pid 1234, uptime 1 day, 2:03:04
+worker:worker_00                 RUNNING   pid 1240, uptime 0:00:10
+stopped                          STOPPED   Dec 01 10:00 AM
+broken                           FATAL     Exited too quickly (process log may have details)`
+
+	s.Equal(map[string]int32{"queue": 1234, "worker:worker_00": 1240}, ParseSupervisor(output))
+}
+
+func (s *ProcstatTestSuite) TestUsages() {
+	prev := map[int32]Process{
+		1: {PID: 1, Name: "nginx", CPUTime: 10, ReadBytes: 100},
+		2: {PID: 2, Name: "old", CPUTime: 50},
+	}
+	cur := map[int32]Process{
+		1: {PID: 1, Name: "nginx", CPUTime: 10.5, RSS: 1024, ReadBytes: 300, Threads: 1, OpenFiles: 8},
+		2: {PID: 2, Name: "new", CPUTime: 1},
+	}
+
+	usages := Usages(prev, cur, 2)
+	s.Equal(25.0, usages[1].CPU)
+	s.Equal(100.0, usages[1].ReadRate)
+	s.Equal(uint64(1024), usages[1].RSS)
+	// PID 被复用时从 0 开始计算
+	s.Equal(50.0, usages[2].CPU)
+}
+
+func (s *ProcstatTestSuite) TestGroups() {
+	processes := map[int32]Process{
+		1:  {PID: 1, Name: "systemd"},
+		10: {PID: 10, PPID: 1, Name: "nginx", Unit: "openresty.service"},
+		11: {PID: 11, PPID: 10, Name: "nginx", Unit: "openresty.service"},
+		20: {PID: 20, PPID: 1, Name: "supervisord", Unit: "supervisor.service"},
+		21: {PID: 21, PPID: 20, Name: "php", Unit: "supervisor.service"},
+		22: {PID: 22, PPID: 21, Name: "php", Unit: "supervisor.service"},
+		23: {PID: 23, PPID: 20, Name: "node", Unit: "supervisor.service"},
+	}
+	usages := map[int32]Usage{
+		1:  {Name: "systemd", PID: 1, Processes: 1, CPU: 1},
+		10: {Name: "nginx", PID: 10, Processes: 1, CPU: 2, RSS: 100},
+		11: {Name: "nginx", PID: 11, Processes: 1, CPU: 3, RSS: 200},
+		20: {Name: "supervisord", PID: 20, Processes: 1},
+		21: {Name: "php", PID: 21, Processes: 1, CPU: 4, RSS: 300},
+		22: {Name: "php", PID: 22, Processes: 1, CPU: 5, RSS: 400},
+		23: {Name: "node", PID: 23, Processes: 1, CPU: 6, RSS: 50},
+	}
+
+	openresty := Aggregate("openresty", Unit(processes, usages, "openresty"))
+	s.Equal(2, openresty.Processes)
+	s.Equal(5.0, openresty.CPU)
+	s.Equal(uint64(300), openresty.RSS)
+
+	program := Aggregate("queue", Tree(processes, usages, 21))
+	s.Equal(2, program.Processes)
+	s.Equal(9.0, program.CPU)
+
+	s.Empty(Tree(processes, usages, 99))
+
+	processes[30] = Process{PID: 30, PPID: 1, Name: "php-fpm", Cmdline: "php-fpm: master process (/www/server/php/82/etc/php-fpm.conf)"}
+	processes[31] = Process{PID: 31, PPID: 30, Name: "php-fpm", Cmdline: "php-fpm: pool example.com"}
+	processes[32] = Process{PID: 32, PPID: 30, Name: "php-fpm", Cmdline: "php-fpm: pool example.com   "}
+	processes[33] = Process{PID: 33, PPID: 30, Name: "php-fpm", Cmdline: "php-fpm: pool www"}
+	usages[31] = Usage{PID: 31, Processes: 1, CPU: 1, RSS: 10}
+	usages[32] = Usage{PID: 32, Processes: 1, CPU: 2, RSS: 20}
+	usages[33] = Usage{PID: 33, Processes: 1, CPU: 4, RSS: 40}
+	pool := Aggregate("example.com", Pool(processes, usages, "example.com"))
+	s.Equal(2, pool.Processes)
+	s.Equal(3.0, pool.CPU)
+	s.Empty(Pool(processes, usages, "example"))
+
+	top := Top(usages, "cpu", 2)
+	s.Equal([]int32{23, 22}, []int32{top[0].PID, top[1].PID})
+	top = Top(usages, "rss", 1)
+	s.Equal(int32(22), top[0].PID)
+}
@@ -0,0 +1,133 @@
+// Package ratelimit 网站请求频率、并发连接和带宽限制，使用 OpenResty 的 limit_req、limit_conn 和 limit_rate
+package ratelimit
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"panel/pkg/tools"
+)
+
+// ZoneSize 每条频率限制规则的共享内存大小，1m 约可保存 1.6 万个 IP 的状态
+const ZoneSize = "2m"
+
+// Rule 请求频率限制规则，按客户端 IP 计数
+type Rule struct {
+	Path    string `json:"path"` // 路径前缀，/ 表示整个网站
+	Rate    int    `json:"rate"` // 每个时间单位允许的请求数
+	Unit    string `json:"unit"` // s 或 m
+	Burst   int    `json:"burst"`
+	NoDelay bool   `json:"nodelay"` // 突发请求不排队，立即处理
+}
+
+// Limit 网站的全部限制，值为 0 时不限制
+type Limit struct {
+	Rules     []Rule
+	Status    int // 超出限制时的状态码
+	PerIP     int // 单 IP 并发连接数
+	PerServer int // 网站总并发连接数
+	Rate      int // 单个连接的带宽，单位 KB/s
+	RateAfter int // 单个连接传输多少 MB 后开始限速
+}
+
+var pathPattern = regexp.MustCompile(`^/[a-zA-Z0-9_./-]*$`)
+
+// Validate 校验限制
+func (l Limit) Validate() error {
+	for _, rule := range l.Rules {
+		if !pathPattern.MatchString(rule.Path) || strings.Contains(rule.Path, "..") {
+			return errors.New("限制路径 " + rule.Path + " 不合法")
+		}
+		if rule.Rate <= 0 || (rule.Unit != "s" && rule.Unit != "m") {
+			return errors.New("路径 " + rule.Path + " 的请求频率不合法")
+		}
+		if rule.Burst < 0 {
+			return errors.New("路径 " + rule.Path + " 的突发请求数不合法")
+		}
+	}
+	if l.Status < 400 || l.Status > 599 {
+		return errors.New("状态码必须在 400 到 599 之间")
+	}
+	if l.PerIP < 0 || l.PerServer < 0 || l.Rate < 0 || l.RateAfter < 0 {
+		return errors.New("限制不能为负数")
+	}
+
+	return nil
+}
+
+// Zones 生成网站频率限制规则的共享内存定义，需写入 http 块，非整站规则通过 map 使不匹配路径的请求键为空而不计数
+func Zones(id uint, rules []Rule) string {
+	var sb strings.Builder
+	for i, rule := range rules {
+		zone := zoneName(id, i)
+		key := "$binary_remote_addr"
+		if rule.Path != "/" {
+			key = "$" + zone + "_key"
+			sb.WriteString("map $uri " + key + " {\n")
+			sb.WriteString("    default \"\";\n")
+			sb.WriteString("    ~^" + regexp.QuoteMeta(rule.Path) + " $binary_remote_addr;\n")
+			sb.WriteString("}\n")
+		}
+		sb.WriteString("limit_req_zone " + key + " zone=" + zone + ":" + ZoneSize + " rate=" + strconv.Itoa(rule.Rate) + "r/" + rule.Unit + ";\n")
+	}
+
+	return sb.String()
+}
+
+// Block 生成网站配置文件 server 块中的限制指令
+func Block(id uint, limit Limit) string {
+	var sb strings.Builder
+	for i, rule := range limit.Rules {
+		sb.WriteString("    limit_req zone=" + zoneName(id, i))
+		if rule.Burst > 0 {
+			sb.WriteString(" burst=" + strconv.Itoa(rule.Burst))
+		}
+		if rule.NoDelay {
+			sb.WriteString(" nodelay")
+		}
+		sb.WriteString(";\n")
+	}
+	if len(limit.Rules) > 0 {
+		sb.WriteString("    limit_req_status " + strconv.Itoa(limit.Status) + ";\n")
+	}
+
+	// perip 和 perserver 为主配置文件中定义的共享内存
+	if limit.PerIP > 0 {
+		sb.WriteString("    limit_conn perip " + strconv.Itoa(limit.PerIP) + ";\n")
+	}
+	if limit.PerServer > 0 {
+		sb.WriteString("    limit_conn perserver " + strconv.Itoa(limit.PerServer) + ";\n")
+	}
+	if limit.PerIP > 0 || limit.PerServer > 0 {
+		sb.WriteString("    limit_conn_status " + strconv.Itoa(limit.Status) + ";\n")
+	}
+
+	if limit.Rate > 0 {
+		sb.WriteString("    limit_rate " + strconv.Itoa(limit.Rate) + "k;\n")
+		if limit.RateAfter > 0 {
+			sb.WriteString("    limit_rate_after " + strconv.Itoa(limit.RateAfter) + "m;\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// Write 将限制指令写入网站配置文件的 limit 标记位，缺少标记位时插入到 waf 标记位之后
+func Write(conf, block string) (string, error) {
+	begin, end := "# limit标记位开始", "# limit标记位结束"
+	if !strings.Contains(conf, begin) {
+		if !strings.Contains(conf, "# waf标记位结束") {
+			return "", errors.New("配置文件中缺少waf标记位")
+		}
+		conf = strings.Replace(conf, "# waf标记位结束", "# waf标记位结束\n    "+begin+"\n    "+end, 1)
+	}
+
+	old := tools.Cut(conf, begin, end)
+	return strings.Replace(conf, begin+old+end, begin+"\n"+block+"    "+end, 1), nil
+}
+
+func zoneName(id uint, index int) string {
+	return "website_" + strconv.Itoa(int(id)) + "_" + strconv.Itoa(index)
+}
@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type RateLimitTestSuite struct {
+	suite.Suite
+}
+
+func TestRateLimitTestSuite(t *testing.T) {
+	suite.Run(t, &RateLimitTestSuite{})
+}
+
+func (s *RateLimitTestSuite) limit() Limit {
+	return Limit{
+		Rules: []Rule{
+			{Path: "/", Rate: 10, Unit: "s", Burst: 20, NoDelay: true},
+			{Path: "/wp-login.php", Rate: 5, Unit: "m"},
+		},
+		Status:    429,
+		PerIP:     10,
+		PerServer: 200,
+		Rate:      512,
+		RateAfter: 10,
+	}
+}
+
+func (s *RateLimitTestSuite) TestZones() {
+	zones := Zones(3, s.limit().Rules)
+	s.Contains(zones, "limit_req_zone $binary_remote_addr zone=website_3_0:2m rate=10r/s;\n")
+	s.Contains(zones, "map $uri $website_3_1_key {\n    default \"\";\n    ~^/wp-login\\.php $binary_remote_addr;\n}\n")
+	s.Contains(zones, "limit_req_zone $website_3_1_key zone=website_3_1:2m rate=5r/m;\n")
+	s.Empty(Zones(3, nil))
+}
+
+func (s *RateLimitTestSuite) TestBlock() {
+	s.Equal(`    limit_req zone=website_3_0 burst=20 nodelay;
+    limit_req zone=website_3_1;
+    limit_req_status 429;
+    limit_conn perip 10;
+    limit_conn perserver 200;
+    limit_conn_status 429;
+    limit_rate 512k;
+    limit_rate_after 10m;
+`, Block(3, s.limit()))
+	s.Empty(Block(3, Limit{Status: 429}))
+	s.Equal("    limit_conn perip 5;\n    limit_conn_status 503;\n", Block(3, Limit{Status: 503, PerIP: 5}))
+}
+
+func (s *RateLimitTestSuite) TestWrite() {
+	conf := "server\n{\n    # waf标记位开始\n    waf on;\n    # waf标记位结束\n}\n"
+	conf, err := Write(conf, Block(3, s.limit()))
+	s.NoError(err)
+	s.Contains(conf, "    # waf标记位结束\n    # limit标记位开始\n    limit_req zone=website_3_0")
+	s.Contains(conf, "    limit_rate_after 10m;\n    # limit标记位结束\n}")
+
+	conf, err = Write(conf, "")
+	s.NoError(err)
+	s.Equal(1, strings.Count(conf, "# limit标记位开始"))
+	s.NotContains(conf, "limit_req")
+
+	_, err = Write("server {}", "")
+	s.Error(err)
+}
+
+func (s *RateLimitTestSuite) TestValidate() {
+	s.NoError(s.limit().Validate())
+	s.NoError(Limit{Status: 503}.Validate())
+
+	invalid := []Limit{
+		{Status: 200},
+		{Status: 429, PerIP: -1},
+		{Status: 429, Rules: []Rule{{Path: "api", Rate: 1, Unit: "s"}}},
+		{Status: 429, Rules: []Rule{{Path: "/a b", Rate: 1, Unit: "s"}}},
+		{Status: 429, Rules: []Rule{{Path: "/", Rate: 0, Unit: "s"}}},
+		{Status: 429, Rules: []Rule{{Path: "/", Rate: 1, Unit: "h"}}},
+		{Status: 429, Rules: []Rule{{Path: "/", Rate: 1, Unit: "s", Burst: -1}}},
+	}
+	for _, limit := range invalid {
+		s.Error(limit.Validate())
+	}
+}
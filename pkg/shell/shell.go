@@ -0,0 +1,30 @@
+// Package shell 生成 bash 脚本的公共方法
+package shell
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Header 脚本公共头部，设置 PATH 并定义分隔线 HR
+const Header = `#!/bin/bash
+export PATH=/bin:/sbin:/usr/bin:/usr/sbin:/usr/local/bin:/usr/local/sbin:$PATH
+
+HR="+----------------------------------------------------"
+`
+
+var (
+	NamePattern     = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)                      // 数据库名、用户名等
+	PasswordPattern = regexp.MustCompile(`^[a-zA-Z0-9_@#%+=.,:~!^*()-]+$`)        // 可直接写入 SQL 和配置文件的密码
+	pathPattern     = regexp.MustCompile(`^/[a-zA-Z0-9_.-]+(/[a-zA-Z0-9_.-]+)*$`) // 绝对路径
+)
+
+// Quote 转义为 shell 单引号字符串
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ValidPath 是否为不含 .. 的绝对路径
+func ValidPath(path string) bool {
+	return pathPattern.MatchString(path) && !strings.Contains(path, "..")
+}
@@ -0,0 +1,36 @@
+package shell
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ShellTestSuite struct {
+	suite.Suite
+}
+
+func TestShellTestSuite(t *testing.T) {
+	suite.Run(t, &ShellTestSuite{})
+}
+
+func (s *ShellTestSuite) TestQuote() {
+	s.Equal(`'example'`, Quote("example"))
+	s.Equal(`'it'\''s'`, Quote("it's"))
+	s.Equal(`'$(id)'`, Quote("$(id)"))
+}
+
+func (s *ShellTestSuite) TestValidPath() {
+	s.True(ValidPath("/www/wwwroot/example.com"))
+	s.False(ValidPath("www/wwwroot"))
+	s.False(ValidPath("/www/wwwroot/../etc"))
+	s.False(ValidPath("/www/wwwroot/example.com'; id"))
+}
+
+func (s *ShellTestSuite) TestPatterns() {
+	s.True(NamePattern.MatchString("example_db"))
+	s.False(NamePattern.MatchString("example db"))
+	s.True(PasswordPattern.MatchString("Passw0rd!"))
+	s.False(PasswordPattern.MatchString("pa$$word"))
+	s.False(PasswordPattern.MatchString("pass'word"))
+}
@@ -0,0 +1,90 @@
+// Package sitebundle 网站迁移包，包含网站配置、证书、数据库和网站文件，用于在面板之间迁移网站
+package sitebundle
+
+import (
+	"regexp"
+	"strings"
+
+	"panel/pkg/tools"
+)
+
+// Mapping 网站的名称、目录和域名
+type Mapping struct {
+	Name    string
+	Path    string
+	Domains []string
+}
+
+var serverNamePattern = regexp.MustCompile(`server_name\s+(.*);`)
+
+// Domains 获取配置文件 server_name 标记位中的域名
+func Domains(conf string) []string {
+	block := tools.Cut(conf, "# server_name标记位开始", "# server_name标记位结束")
+	match := serverNamePattern.FindStringSubmatch(block)
+	if len(match) != 2 {
+		return nil
+	}
+
+	return strings.Fields(match[1])
+}
+
+// RemapVhost 将配置文件中原网站的名称、目录和域名替换为新网站的
+func RemapVhost(conf string, from, to Mapping) string {
+	if from.Path != to.Path {
+		// 只替换完整的目录，避免 /www/wwwroot/a 误替换 /www/wwwroot/ab
+		pattern := regexp.MustCompile(regexp.QuoteMeta(from.Path) + `(/|;|\s|$)`)
+		conf = pattern.ReplaceAllStringFunc(conf, func(s string) string {
+			return to.Path + s[len(from.Path):]
+		})
+	}
+
+	if from.Name != to.Name {
+		for _, format := range []string{
+			"/www/server/vhost/rewrite/%s.conf",
+			"/www/server/vhost/ssl/%s.pem",
+			"/www/server/vhost/ssl/%s.key",
+			"/www/server/vhost/ssl/%s.dual.pem",
+			"/www/server/vhost/ssl/%s.dual.key",
+			"/www/server/vhost/htpasswd/%s.htpasswd",
+			"/www/server/vhost/waf/%s/",
+			"/www/wwwlogs/%s.log",
+			"/www/wwwlogs/waf/%s.log",
+		} {
+			conf = strings.ReplaceAll(conf, strings.Replace(format, "%s", from.Name, 1), strings.Replace(format, "%s", to.Name, 1))
+		}
+	}
+
+	if len(to.Domains) > 0 {
+		block := tools.Cut(conf, "# server_name标记位开始", "# server_name标记位结束")
+		if serverNamePattern.MatchString(block) {
+			conf = strings.Replace(conf, block, serverNamePattern.ReplaceAllLiteralString(block, "server_name "+strings.Join(to.Domains, " ")+";"), 1)
+		}
+	}
+
+	return conf
+}
+
+// StripSsl 清除配置文件中的 SSL 配置和 443 端口，用于证书不包含新域名的场景
+func StripSsl(conf string) string {
+	if ssl := tools.Cut(conf, "# ssl标记位开始", "# ssl标记位结束"); len(strings.TrimSpace(ssl)) > 0 {
+		conf = strings.Replace(conf, "# ssl标记位开始"+ssl+"# ssl标记位结束", "# ssl标记位开始\n    # ssl标记位结束", 1)
+	}
+
+	ports := tools.Cut(conf, "# port标记位开始", "# port标记位结束")
+	if len(ports) == 0 {
+		return conf
+	}
+	var listens []string
+	for _, line := range strings.Split(ports, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "listen") || strings.Contains(line, "443") || strings.Contains(line, "ssl") || strings.Contains(line, "quic") {
+			continue
+		}
+		listens = append(listens, "    "+line)
+	}
+	if len(listens) == 0 {
+		listens = append(listens, "    listen 80;")
+	}
+
+	return strings.Replace(conf, "# port标记位开始"+ports+"# port标记位结束", "# port标记位开始\n"+strings.Join(listens, "\n")+"\n    # port标记位结束", 1)
+}
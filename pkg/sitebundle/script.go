@@ -0,0 +1,163 @@
+package sitebundle
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"panel/pkg/shell"
+)
+
+const (
+	ManifestFile = "manifest.json" // 网站信息
+	ConfigDir    = "config"        // 网站配置文件、伪静态和证书
+	DatabaseDir  = "databases"     // 数据库备份
+	FilesArchive = "files.tar.gz"  // 网站文件
+)
+
+var (
+	filePattern   = regexp.MustCompile(`^(mysql|postgresql)-[a-zA-Z0-9_-]+\.sql\.gz$`)
+	BundlePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+(\.[a-zA-Z0-9_-]+)*\.tar$`) // 迁移包文件名
+)
+
+// Database 迁移包中的数据库
+type Database struct {
+	Type string `json:"type"` // mysql 或 postgresql
+	Name string `json:"name"`
+	File string `json:"file"` // 迁移包中的备份文件
+}
+
+// Restore 导入时恢复的数据库
+type Restore struct {
+	Database
+	Target   string // 新数据库名
+	User     string // 新数据库用户，PostgreSQL 以该用户导入以便对象归属该用户
+	Password string
+}
+
+// NewDatabase 生成迁移包中的数据库
+func NewDatabase(dbType, name string) (Database, error) {
+	if dbType != "mysql" && dbType != "postgresql" {
+		return Database{}, errors.New("不支持的数据库类型")
+	}
+	if !shell.NamePattern.MatchString(name) {
+		return Database{}, errors.New("数据库名 " + name + " 不合法")
+	}
+
+	return Database{Type: dbType, Name: name, File: dbType + "-" + name + ".sql.gz"}, nil
+}
+
+// ExportScript 生成导出迁移包的 bash 脚本，staging 中需已写入网站信息和配置文件
+func ExportScript(staging, sitePath, output, mysqlPassword string, databases []Database) (string, error) {
+	for _, path := range []string{staging, sitePath, output} {
+		if !shell.ValidPath(path) {
+			return "", errors.New("路径 " + path + " 不合法")
+		}
+	}
+	for _, db := range databases {
+		if !shell.NamePattern.MatchString(db.Name) || !filePattern.MatchString(db.File) {
+			return "", errors.New("数据库 " + db.Name + " 不合法")
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(shell.Header)
+	sb.WriteString("staging=" + shell.Quote(staging) + "\n")
+	sb.WriteString("sitePath=" + shell.Quote(sitePath) + "\n")
+	sb.WriteString("output=" + shell.Quote(output) + "\n")
+	sb.WriteString(`
+set -e
+set -o pipefail
+trap 'echo -e $HR; echo "导出失败"; rm -f "${output}"' ERR
+trap 'rm -rf "${staging}"' EXIT
+
+mkdir -p "${staging}/` + DatabaseDir + `"
+`)
+
+	for _, db := range databases {
+		sb.WriteString(`echo "导出数据库 ` + db.Name + `"` + "\n")
+		switch db.Type {
+		case "mysql":
+			sb.WriteString(`MYSQL_PWD=` + shell.Quote(mysqlPassword) + ` /www/server/mysql/bin/mysqldump -uroot --single-transaction --routines --triggers --events ` + shell.Quote(db.Name) + ` | gzip > "${staging}/` + DatabaseDir + `/` + db.File + `"` + "\n")
+		case "postgresql":
+			sb.WriteString(`su - postgres -c "pg_dump --no-owner --no-privileges ` + db.Name + `" | gzip > "${staging}/` + DatabaseDir + `/` + db.File + `"` + "\n")
+		}
+	}
+
+	sb.WriteString(`
+echo "打包网站文件"
+tar -czf "${staging}/` + FilesArchive + `" -C "${sitePath}" .
+mkdir -p "$(dirname "${output}")"
+tar -cf "${output}" -C "${staging}" ` + ManifestFile + ` ` + ConfigDir + ` ` + DatabaseDir + ` ` + FilesArchive + `
+
+echo -e $HR
+echo "导出成功: ${output}"
+`)
+
+	return sb.String(), nil
+}
+
+// ImportScript 生成导入迁移包中网站文件和数据库的 bash 脚本，网站配置由面板写入
+func ImportScript(bundle, sitePath, oldPath, owner, mysqlPassword string, restores []Restore) (string, error) {
+	for _, path := range []string{bundle, sitePath, oldPath} {
+		if !shell.ValidPath(path) {
+			return "", errors.New("路径 " + path + " 不合法")
+		}
+	}
+	if !shell.NamePattern.MatchString(owner) {
+		return "", errors.New("网站文件所有者不合法")
+	}
+	for _, restore := range restores {
+		if !filePattern.MatchString(restore.File) || !shell.NamePattern.MatchString(restore.Target) || !shell.NamePattern.MatchString(restore.User) {
+			return "", errors.New("数据库 " + restore.Target + " 不合法")
+		}
+		if !shell.PasswordPattern.MatchString(restore.Password) {
+			return "", errors.New("数据库密码只能包含字母、数字和 _@#%+=.,:~!^*()- 符号")
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(shell.Header)
+	sb.WriteString("bundle=" + shell.Quote(bundle) + "\n")
+	sb.WriteString("sitePath=" + shell.Quote(sitePath) + "\n")
+	sb.WriteString(`tmpPath=$(mktemp -d /tmp/panel-import-XXXXXX)
+
+set -e
+set -o pipefail
+trap 'echo -e $HR; echo "导入失败"' ERR
+trap 'rm -rf "${tmpPath}"' EXIT
+
+tar -xf "${bundle}" -C "${tmpPath}" ` + FilesArchive + ` ` + DatabaseDir + `
+
+echo "导入网站文件"
+mkdir -p "${sitePath}"
+rm -f "${sitePath}/index.html"
+tar -xzf "${tmpPath}/` + FilesArchive + `" -C "${sitePath}"
+`)
+	if oldPath != sitePath {
+		sb.WriteString(`# 防跨站配置中的网站目录随之变更
+find "${sitePath}" -maxdepth 3 -name .user.ini -type f -exec sed -i 's|` + oldPath + `|` + sitePath + `|g' {} +
+`)
+	}
+
+	for _, restore := range restores {
+		sb.WriteString(`echo "导入数据库 ` + restore.Target + `"` + "\n")
+		file := `"${tmpPath}/` + DatabaseDir + `/` + restore.File + `"`
+		switch restore.Type {
+		case "mysql":
+			sb.WriteString(`gunzip -c ` + file + ` | MYSQL_PWD=` + shell.Quote(mysqlPassword) + ` /www/server/mysql/bin/mysql -uroot ` + shell.Quote(restore.Target) + "\n")
+		case "postgresql":
+			sb.WriteString(`gunzip -c ` + file + ` | PGPASSWORD=` + shell.Quote(restore.Password) + ` /www/server/postgresql/bin/psql -h 127.0.0.1 -U ` + shell.Quote(restore.User) + ` -d ` + shell.Quote(restore.Target) + ` -v ON_ERROR_STOP=1 -q` + "\n")
+		}
+	}
+
+	sb.WriteString(`
+chown -R ` + owner + `:www "${sitePath}"
+chmod 755 "${sitePath}"
+
+echo -e $HR
+echo "导入成功"
+`)
+
+	return sb.String(), nil
+}
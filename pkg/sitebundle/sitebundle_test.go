@@ -0,0 +1,142 @@
+package sitebundle
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SiteBundleTestSuite struct {
+	suite.Suite
+}
+
+func TestSiteBundleTestSuite(t *testing.T) {
+	suite.Run(t, &SiteBundleTestSuite{})
+}
+
+const vhost = `server
+{
+    # port标记位开始
+    listen 80;
+    # port标记位结束
+    # server_name标记位开始
+    server_name a.com www.a.com;
+    # server_name标记位结束
+    # root标记位开始
+    root /www/wwwroot/a.com/public;
+    # root标记位结束
+    # ssl标记位开始
+    ssl_certificate /www/server/vhost/ssl/a.com.pem;
+    ssl_certificate_key /www/server/vhost/ssl/a.com.key;
+    ssl_certificate /www/server/vhost/ssl/a.com.dual.pem;
+    ssl_certificate_key /www/server/vhost/ssl/a.com.dual.key;
+    # ssl标记位结束
+    include /www/server/vhost/rewrite/a.com.conf;
+    auth_basic_user_file /www/server/vhost/htpasswd/a.com.htpasswd;
+    waf_rule_path /www/server/vhost/waf/a.com/;
+    access_log /www/wwwlogs/waf/a.com.log waf_block if=$waf_blocking_log;
+    location /static { alias /www/wwwroot/a.com.static; }
+    access_log /www/wwwlogs/a.com.log;
+    error_log /www/wwwlogs/a.com.log;
+}
+`
+
+func (s *SiteBundleTestSuite) TestDomains() {
+	s.Equal([]string{"a.com", "www.a.com"}, Domains(vhost))
+	s.Nil(Domains("server {}"))
+}
+
+func (s *SiteBundleTestSuite) TestRemapVhost() {
+	conf := RemapVhost(vhost,
+		Mapping{Name: "a.com", Path: "/www/wwwroot/a.com", Domains: []string{"a.com", "www.a.com"}},
+		Mapping{Name: "b.com", Path: "/data/b.com", Domains: []string{"b.com"}},
+	)
+	s.Contains(conf, "server_name b.com;")
+	s.Contains(conf, "root /data/b.com/public;")
+	s.Contains(conf, "ssl_certificate /www/server/vhost/ssl/b.com.pem;")
+	s.Contains(conf, "ssl_certificate_key /www/server/vhost/ssl/b.com.key;")
+	s.Contains(conf, "ssl_certificate /www/server/vhost/ssl/b.com.dual.pem;")
+	s.Contains(conf, "ssl_certificate_key /www/server/vhost/ssl/b.com.dual.key;")
+	s.Contains(conf, "include /www/server/vhost/rewrite/b.com.conf;")
+	s.Contains(conf, "auth_basic_user_file /www/server/vhost/htpasswd/b.com.htpasswd;")
+	s.Contains(conf, "access_log /www/wwwlogs/b.com.log;")
+	s.Contains(conf, "waf_rule_path /www/server/vhost/waf/b.com/;")
+	s.Contains(conf, "access_log /www/wwwlogs/waf/b.com.log waf_block if=$waf_blocking_log;")
+	// 不是同一目录的路径保持不变
+	s.Contains(conf, "alias /www/wwwroot/a.com.static;")
+
+	// 未指定新域名时保留原域名
+	conf = RemapVhost(vhost, Mapping{Name: "a.com", Path: "/www/wwwroot/a.com"}, Mapping{Name: "a.com", Path: "/www/wwwroot/a.com"})
+	s.Equal(vhost, conf)
+}
+
+func (s *SiteBundleTestSuite) TestStripSsl() {
+	conf := StripSsl(strings.Replace(vhost, "    listen 80;\n", "    listen 80;\n    listen 443 ssl http2;\n", 1))
+	s.Contains(conf, "    # port标记位开始\n    listen 80;\n    # port标记位结束")
+	s.Contains(conf, "    # ssl标记位开始\n    # ssl标记位结束")
+	s.NotContains(conf, "ssl_certificate")
+	s.NotContains(conf, "443")
+
+	// 只监听 443 时改为 80
+	conf = StripSsl(strings.Replace(vhost, "listen 80;", "listen 443 ssl;", 1))
+	s.Contains(conf, "    # port标记位开始\n    listen 80;\n    # port标记位结束")
+}
+
+func (s *SiteBundleTestSuite) TestExportScript() {
+	mysql, err := NewDatabase("mysql", "a_db")
+	s.NoError(err)
+	s.Equal("mysql-a_db.sql.gz", mysql.File)
+	postgresql, err := NewDatabase("postgresql", "a_pg")
+	s.NoError(err)
+	_, err = NewDatabase("mysql", "a; drop")
+	s.Error(err)
+	_, err = NewDatabase("sqlite", "a")
+	s.Error(err)
+
+	script, err := ExportScript("/tmp/panel-export-a", "/www/wwwroot/a.com", "/www/backup/migration/a.com.tar", "root'pass", []Database{mysql, postgresql})
+	s.NoError(err)
+	s.Contains(script, `MYSQL_PWD='root'\''pass' /www/server/mysql/bin/mysqldump -uroot --single-transaction --routines --triggers --events 'a_db' | gzip > "${staging}/databases/mysql-a_db.sql.gz"`)
+	s.Contains(script, `su - postgres -c "pg_dump --no-owner --no-privileges a_pg" | gzip > "${staging}/databases/postgresql-a_pg.sql.gz"`)
+	s.Contains(script, `tar -cf "${output}" -C "${staging}" manifest.json config databases files.tar.gz`)
+
+	_, err = ExportScript("/tmp/panel-export-a", "/www/wwwroot/../etc", "/www/backup/a.tar", "", nil)
+	s.Error(err)
+}
+
+func (s *SiteBundleTestSuite) TestBundlePattern() {
+	s.True(BundlePattern.MatchString("a.com_20231201103000.tar"))
+	s.False(BundlePattern.MatchString("a.com.tar.gz"))
+	s.False(BundlePattern.MatchString("a'.tar"))
+	s.False(BundlePattern.MatchString("../a.tar"))
+	s.False(BundlePattern.MatchString(".tar"))
+}
+
+func (s *SiteBundleTestSuite) TestImportScript() {
+	mysql, _ := NewDatabase("mysql", "a_db")
+	postgresql, _ := NewDatabase("postgresql", "a_pg")
+	restores := []Restore{
+		{Database: mysql, Target: "b_db", User: "b_user", Password: "Passw0rd!"},
+		{Database: postgresql, Target: "b_pg", User: "b_pg", Password: "Passw0rd!"},
+	}
+
+	script, err := ImportScript("/www/backup/migration/a.com.tar", "/data/b.com", "/www/wwwroot/a.com", "www", "root", restores)
+	s.NoError(err)
+	s.Contains(script, `tar -xzf "${tmpPath}/files.tar.gz" -C "${sitePath}"`)
+	s.Contains(script, `sed -i 's|/www/wwwroot/a.com|/data/b.com|g'`)
+	s.Contains(script, `gunzip -c "${tmpPath}/databases/mysql-a_db.sql.gz" | MYSQL_PWD='root' /www/server/mysql/bin/mysql -uroot 'b_db'`)
+	s.Contains(script, `PGPASSWORD='Passw0rd!' /www/server/postgresql/bin/psql -h 127.0.0.1 -U 'b_pg' -d 'b_pg'`)
+	s.Contains(script, `chown -R www:www "${sitePath}"`)
+
+	script, err = ImportScript("/www/backup/migration/a.com.tar", "/www/wwwroot/a.com", "/www/wwwroot/a.com", "www", "", nil)
+	s.NoError(err)
+	s.NotContains(script, ".user.ini")
+
+	restores[0].Password = "pa$$word"
+	_, err = ImportScript("/www/backup/migration/a.com.tar", "/data/b.com", "/www/wwwroot/a.com", "www", "root", restores)
+	s.Error(err)
+	restores[0].Password = "Passw0rd!"
+	restores[0].File = "../../etc/passwd"
+	_, err = ImportScript("/www/backup/migration/a.com.tar", "/data/b.com", "/www/wwwroot/a.com", "www", "root", restores)
+	s.Error(err)
+}
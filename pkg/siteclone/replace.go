@@ -0,0 +1,90 @@
+// Package siteclone 网站克隆及预发布环境上线
+package siteclone
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// serializedPattern PHP 序列化字符串的开头，mysqldump 导出的双引号带反斜杠转义
+var serializedPattern = regexp.MustCompile(`s:(\d+):(\\?)"`)
+
+// ReplaceLine 替换一行数据库备份中的字符串，同时修正 PHP 序列化字符串的长度，否则 WordPress 等应用无法反序列化
+func ReplaceLine(line, from, to string) string {
+	if len(from) == 0 || !strings.Contains(line, from) {
+		return line
+	}
+
+	var sb strings.Builder
+	offset := 0
+	for _, match := range serializedPattern.FindAllStringSubmatchIndex(line, -1) {
+		if match[0] < offset {
+			continue
+		}
+		length, err := strconv.Atoi(line[match[2]:match[3]])
+		if err != nil {
+			continue
+		}
+		escaped := match[5] > match[4]
+		start := match[1]
+
+		// 按反转义后的字节数找到字符串的结尾
+		end, count := start, 0
+		for count < length && end < len(line) {
+			if line[end] == '\\' {
+				end++
+			}
+			end++
+			count++
+		}
+		terminator := `";`
+		if escaped {
+			terminator = `\";`
+		}
+		if count != length || end > len(line) || !strings.HasPrefix(line[end:], terminator) {
+			continue
+		}
+
+		content := line[start:end]
+		replaced := strings.ReplaceAll(content, from, to)
+		length += strings.Count(content, from) * (len(to) - len(from))
+
+		sb.WriteString(strings.ReplaceAll(line[offset:match[0]], from, to))
+		sb.WriteString("s:" + strconv.Itoa(length) + ":" + line[match[4]:match[5]] + `"` + replaced + terminator)
+		offset = end + len(terminator)
+	}
+	sb.WriteString(strings.ReplaceAll(line[offset:], from, to))
+
+	return sb.String()
+}
+
+// Replace 逐行替换数据库备份中的字符串
+func Replace(r io.Reader, w io.Writer, from, to string) error {
+	if len(from) == 0 {
+		return errors.New("被替换的字符串不能为空")
+	}
+
+	reader := bufio.NewReaderSize(r, 1<<20)
+	writer := bufio.NewWriterSize(w, 1<<20)
+	for {
+		// mysqldump 的一行可能很长，不使用 bufio.Scanner 以免超出缓冲区
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			if _, werr := writer.WriteString(ReplaceLine(line, from, to)); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
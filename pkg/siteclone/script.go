@@ -0,0 +1,273 @@
+package siteclone
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"panel/pkg/shell"
+)
+
+var domainPattern = regexp.MustCompile(`^[a-zA-Z0-9*._:-]+$`)
+
+// configFiles 保存数据库连接信息的应用配置文件，克隆时改为新数据库，上线时保留生产环境的
+var configFiles = []string{".env", "wp-config.php"}
+
+// Database 克隆或上线的数据库
+type Database struct {
+	Type      string // mysql 或 postgresql
+	Source    string
+	Target    string
+	User      string // 目标数据库用户，克隆时写入应用配置文件，PostgreSQL 导入时以该角色创建对象
+	Password  string
+	AppConfig bool // 克隆时是否将该数据库写入应用配置文件，仅克隆一个数据库时可省略
+}
+
+// Options 克隆或上线参数
+type Options struct {
+	Source        string // 源网站目录
+	Target        string // 目标网站目录
+	Owner         string // 目标网站文件所有者，用户组固定为 www
+	From          string // 数据库中需替换的字符串，如 //example.com，为空时不替换
+	To            string
+	Databases     []Database
+	MysqlPassword string
+}
+
+// Validate 校验参数
+func (o Options) Validate() error {
+	for _, path := range []string{o.Source, o.Target} {
+		if !shell.ValidPath(path) {
+			return errors.New("网站目录 " + path + " 不合法")
+		}
+	}
+	if o.Source == o.Target {
+		return errors.New("源网站目录与目标网站目录不能相同")
+	}
+	if !shell.NamePattern.MatchString(o.Owner) {
+		return errors.New("网站文件所有者不合法")
+	}
+	if len(o.From) > 0 && (!domainPattern.MatchString(strings.TrimPrefix(o.From, "//")) || !domainPattern.MatchString(strings.TrimPrefix(o.To, "//"))) {
+		return errors.New("替换的域名不合法")
+	}
+	for _, db := range o.Databases {
+		if db.Type != "mysql" && db.Type != "postgresql" {
+			return errors.New("不支持的数据库类型")
+		}
+		if !shell.NamePattern.MatchString(db.Source) || !shell.NamePattern.MatchString(db.Target) || !shell.NamePattern.MatchString(db.User) {
+			return errors.New("数据库名或用户名不合法")
+		}
+		if db.Source == db.Target {
+			return errors.New("源数据库与目标数据库不能相同")
+		}
+		if len(db.Password) > 0 && !shell.PasswordPattern.MatchString(db.Password) {
+			return errors.New("数据库密码包含不支持的字符")
+		}
+	}
+
+	return nil
+}
+
+// CloneScript 生成克隆网站文件和数据库的 bash 脚本，目标数据库和用户需已创建
+func CloneScript(opts Options) (string, error) {
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+	config, err := appConfigDatabase(opts.Databases)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	writeHeader(&sb, opts, "克隆")
+	sb.WriteString(`
+echo "复制网站文件"
+rm -f "${targetPath}/index.html"
+cp -a "${sourcePath}/." "${targetPath}/"
+`)
+	writeUserIni(&sb, `"${targetPath}"`)
+
+	for _, db := range opts.Databases {
+		writeCopyDatabase(&sb, opts, db, false)
+	}
+	writeAppConfig(&sb, config)
+
+	sb.WriteString(`
+chown -R ` + opts.Owner + `:www "${targetPath}"
+
+echo -e $HR
+echo "克隆成功"
+`)
+
+	return sb.String(), nil
+}
+
+// PromoteScript 生成将预发布环境上线到生产环境的 bash 脚本，上线前生产环境的文件和数据库备份到 backup 目录
+func PromoteScript(opts Options, backup string) (string, error) {
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+	if !shell.ValidPath(backup) {
+		return "", errors.New("备份目录不合法")
+	}
+
+	var sb strings.Builder
+	writeHeader(&sb, opts, "上线")
+	sb.WriteString("backupPath=" + shell.Quote(backup) + "\n")
+	sb.WriteString(`newPath="${targetPath}.promote"
+oldPath="${targetPath}.old"
+
+echo "备份生产环境到 ${backupPath}"
+mkdir -p "${backupPath}"
+tar -czf "${backupPath}/files.tar.gz" -C "${targetPath}" .
+`)
+	for _, db := range opts.Databases {
+		sb.WriteString(`echo "备份数据库 ` + db.Target + `"` + "\n")
+		writeDump(&sb, opts, db.Type, db.Target, `| gzip > "${backupPath}/`+db.Type+`-`+db.Target+`.sql.gz"`)
+	}
+
+	sb.WriteString(`
+echo "复制预发布环境文件"
+rm -rf "${newPath}" "${oldPath}"
+mkdir -p "${newPath}"
+cp -a "${sourcePath}/." "${newPath}/"
+`)
+	sb.WriteString("# 保留生产环境的数据库配置\n")
+	for _, file := range configFiles {
+		sb.WriteString(`if [ -f "${targetPath}/` + file + `" ]; then cp -a "${targetPath}/` + file + `" "${newPath}/` + file + `"; fi` + "\n")
+	}
+	writeUserIni(&sb, `"${newPath}"`)
+	sb.WriteString(`chown -R ` + opts.Owner + `:www "${newPath}"
+chmod 755 "${newPath}"
+`)
+
+	// 文件准备好后再上线数据库，失败时从备份恢复数据库，生产环境的文件保持不变
+	sb.WriteString("\nrestoreDatabases() {\n    echo \"上线数据库失败，从备份恢复生产环境数据库\"\n")
+	for _, db := range opts.Databases {
+		sb.WriteString("    ")
+		writeRecreate(&sb, opts, db)
+		sb.WriteString("    ")
+		writeImport(&sb, opts, db, `<(gunzip -c "${backupPath}/`+db.Type+`-`+db.Target+`.sql.gz")`)
+	}
+	sb.WriteString("}\ntrap 'echo -e $HR; restoreDatabases || true; rm -rf \"${newPath}\"; echo \"上线失败\"' ERR\n")
+	for _, db := range opts.Databases {
+		writeCopyDatabase(&sb, opts, db, true)
+	}
+	sb.WriteString(`trap 'echo -e $HR; echo "上线失败"' ERR
+`)
+
+	sb.WriteString(`
+echo "切换网站文件"
+mv "${targetPath}" "${oldPath}"
+mv "${newPath}" "${targetPath}" || { mv "${oldPath}" "${targetPath}"; false; }
+rm -rf "${oldPath}"
+
+echo -e $HR
+echo "上线成功，上线前的生产环境已备份到 ${backupPath}"
+`)
+
+	return sb.String(), nil
+}
+
+func writeHeader(sb *strings.Builder, opts Options, action string) {
+	sb.WriteString(shell.Header)
+	sb.WriteString("sourcePath=" + shell.Quote(opts.Source) + "\n")
+	sb.WriteString("targetPath=" + shell.Quote(opts.Target) + "\n")
+	sb.WriteString(`tmpPath=$(mktemp -d /tmp/panel-clone-XXXXXX)
+
+set -e
+set -o pipefail
+trap 'echo -e $HR; echo "` + action + `失败"' ERR
+trap 'rm -rf "${tmpPath}"' EXIT
+`)
+}
+
+// writeUserIni 将防跨站配置中的源网站目录改为目标网站目录
+func writeUserIni(sb *strings.Builder, path string) {
+	sb.WriteString(`find ` + path + ` -maxdepth 3 -name .user.ini -type f -exec sed -i "s|${sourcePath}|${targetPath}|g" {} +` + "\n")
+}
+
+// writeDump 导出数据库，suffix 为输出重定向
+func writeDump(sb *strings.Builder, opts Options, dbType, name, suffix string) {
+	switch dbType {
+	case "mysql":
+		sb.WriteString(`MYSQL_PWD=` + shell.Quote(opts.MysqlPassword) + ` /www/server/mysql/bin/mysqldump -uroot --single-transaction --routines --triggers --events ` + shell.Quote(name) + ` ` + suffix + "\n")
+	case "postgresql":
+		sb.WriteString(`su - postgres -c "pg_dump --no-owner --no-privileges ` + name + `" ` + suffix + "\n")
+	}
+}
+
+// writeCopyDatabase 复制数据库并替换其中的域名，recreate 时先清空目标数据库
+func writeCopyDatabase(sb *strings.Builder, opts Options, db Database, recreate bool) {
+	file := `"${tmpPath}/` + db.Source + `.sql"`
+	sb.WriteString("\n" + `echo "复制数据库 ` + db.Source + ` 到 ` + db.Target + `"` + "\n")
+	writeDump(sb, opts, db.Type, db.Source, "> "+file)
+	if len(opts.From) > 0 {
+		sb.WriteString(`panel replaceDomain ` + shell.Quote(opts.From) + ` ` + shell.Quote(opts.To) + ` ` + file + "\n")
+	}
+
+	if recreate {
+		writeRecreate(sb, opts, db)
+	}
+	writeImport(sb, opts, db, file)
+	sb.WriteString(`rm -f ` + file + "\n")
+}
+
+// appConfigDatabase 获取写入应用配置文件的数据库，未克隆数据库时返回空数据库
+func appConfigDatabase(databases []Database) (Database, error) {
+	if len(databases) == 0 {
+		return Database{}, nil
+	}
+	if len(databases) == 1 {
+		return databases[0], nil
+	}
+
+	var config []Database
+	for _, db := range databases {
+		if db.AppConfig {
+			config = append(config, db)
+		}
+	}
+	if len(config) != 1 {
+		return Database{}, errors.New("克隆多个数据库时需指定一个应用配置文件使用的数据库")
+	}
+
+	return config[0], nil
+}
+
+// writeRecreate 清空目标数据库，重建数据库不影响 MySQL 数据库级别的授权，PostgreSQL 先断开生产环境应用的连接
+func writeRecreate(sb *strings.Builder, opts Options, db Database) {
+	switch db.Type {
+	case "mysql":
+		sb.WriteString(`MYSQL_PWD=` + shell.Quote(opts.MysqlPassword) + ` /www/server/mysql/bin/mysql -uroot -e "DROP DATABASE IF EXISTS ` + db.Target + `; CREATE DATABASE ` + db.Target + ` DEFAULT CHARSET utf8mb4 COLLATE utf8mb4_general_ci;"` + "\n")
+	case "postgresql":
+		sb.WriteString(`su - postgres -c "dropdb --if-exists --force ` + db.Target + ` && createdb -O ` + db.User + ` ` + db.Target + `"` + "\n")
+	}
+}
+
+// writeImport 将 SQL 文件导入目标数据库
+func writeImport(sb *strings.Builder, opts Options, db Database, file string) {
+	switch db.Type {
+	case "mysql":
+		sb.WriteString(`MYSQL_PWD=` + shell.Quote(opts.MysqlPassword) + ` /www/server/mysql/bin/mysql -uroot ` + shell.Quote(db.Target) + ` < ` + file + "\n")
+	case "postgresql":
+		// 以目标用户的角色导入，使导入的对象归属于该用户
+		sb.WriteString(`{ echo 'SET ROLE "` + db.User + `";'; cat ` + file + `; } | su - postgres -c "psql -v ON_ERROR_STOP=1 -q -d ` + db.Target + `"` + "\n")
+	}
+}
+
+// writeAppConfig 将 Laravel、WordPress 等应用配置中的数据库改为克隆的数据库，未克隆数据库时清空连接信息，避免预发布环境连接生产数据库
+func writeAppConfig(sb *strings.Builder, db Database) {
+	if len(db.Target) == 0 {
+		sb.WriteString("\necho \"未克隆数据库，已清空应用配置文件中的数据库连接信息\"")
+	}
+	sb.WriteString(`
+cd "${targetPath}"
+if [ -f .env ]; then
+    sed -i -E 's|^DB_DATABASE=.*|DB_DATABASE=` + db.Target + `|; s|^DB_USERNAME=.*|DB_USERNAME=` + db.User + `|; s|^DB_PASSWORD=.*|DB_PASSWORD=` + db.Password + `|' .env
+fi
+if [ -f wp-config.php ]; then
+    sed -i -E "s|define\( *'DB_NAME', *'[^']*' *\);|define( 'DB_NAME', '` + db.Target + `' );|; s|define\( *'DB_USER', *'[^']*' *\);|define( 'DB_USER', '` + db.User + `' );|; s|define\( *'DB_PASSWORD', *'[^']*' *\);|define( 'DB_PASSWORD', '` + db.Password + `' );|" wp-config.php
+fi
+`)
+}
@@ -0,0 +1,138 @@
+package siteclone
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SiteCloneTestSuite struct {
+	suite.Suite
+}
+
+func TestSiteCloneTestSuite(t *testing.T) {
+	suite.Run(t, &SiteCloneTestSuite{})
+}
+
+func (s *SiteCloneTestSuite) TestReplaceLine() {
+	// 普通字符串
+	s.Equal("INSERT INTO `wp_options` VALUES (1,'siteurl','https://staging.a.com');",
+		ReplaceLine("INSERT INTO `wp_options` VALUES (1,'siteurl','https://a.com');", "//a.com", "//staging.a.com"))
+
+	// mysqldump 导出的序列化字符串
+	s.Equal(`(2,'widget','a:1:{s:3:\"url\";s:23:\"https://staging.a.com/x\";}')`,
+		ReplaceLine(`(2,'widget','a:1:{s:3:\"url\";s:15:\"https://a.com/x\";}')`, "//a.com", "//staging.a.com"))
+
+	// pg_dump 导出的序列化字符串，包含转义字符
+	s.Equal(`a:2:{i:0;s:33:"https://bb.com/"q"/https://bb.com";i:1;s:4:"test";}`,
+		ReplaceLine(`a:2:{i:0;s:31:"https://a.com/"q"/https://a.com";i:1;s:4:"test";}`, "//a.com", "//bb.com"))
+	s.Equal(`s:21:\"https://staging.a.com\";s:10:\"it\'s a.com\";`,
+		ReplaceLine(`s:13:\"https://a.com\";s:10:\"it\'s a.com\";`, "//a.com", "//staging.a.com"))
+
+	// 长度不匹配的不当作序列化字符串处理
+	s.Equal(`s:99:"https://b.com";`, ReplaceLine(`s:99:"https://a.com";`, "//a.com", "//b.com"))
+	s.Equal("no match", ReplaceLine("no match", "//a.com", "//b.com"))
+}
+
+func (s *SiteCloneTestSuite) TestReplace() {
+	var out bytes.Buffer
+	s.NoError(Replace(strings.NewReader("https://a.com\n"+`s:13:"https://a.com";`), &out, "//a.com", "//b.com"))
+	s.Equal("https://b.com\n"+`s:13:"https://b.com";`, out.String())
+
+	s.Error(Replace(strings.NewReader(""), &out, "", "b"))
+}
+
+func (s *SiteCloneTestSuite) TestCloneScript() {
+	opts := Options{
+		Source:        "/www/wwwroot/a.com",
+		Target:        "/www/wwwroot/staging.a.com",
+		Owner:         "www",
+		From:          "//a.com",
+		To:            "//staging.a.com",
+		MysqlPassword: "root",
+		Databases: []Database{
+			{Type: "mysql", Source: "a_db", Target: "staging_db", User: "staging_user", Password: "Passw0rd!"},
+		},
+	}
+	script, err := CloneScript(opts)
+	s.NoError(err)
+	s.Contains(script, `cp -a "${sourcePath}/." "${targetPath}/"`)
+	s.Contains(script, `MYSQL_PWD='root' /www/server/mysql/bin/mysqldump -uroot --single-transaction --routines --triggers --events 'a_db' > "${tmpPath}/a_db.sql"`)
+	s.Contains(script, `panel replaceDomain '//a.com' '//staging.a.com' "${tmpPath}/a_db.sql"`)
+	s.Contains(script, `MYSQL_PWD='root' /www/server/mysql/bin/mysql -uroot 'staging_db' < "${tmpPath}/a_db.sql"`)
+	s.Contains(script, `DB_DATABASE=staging_db`)
+	s.Contains(script, `define( 'DB_PASSWORD', 'Passw0rd!' );`)
+	s.Contains(script, `chown -R www:www "${targetPath}"`)
+	s.NotContains(script, "DROP DATABASE")
+
+	opts.From = ""
+	opts.Databases = []Database{{Type: "postgresql", Source: "a_pg", Target: "staging_pg", User: "staging_pg", Password: "Passw0rd!"}}
+	script, err = CloneScript(opts)
+	s.NoError(err)
+	s.NotContains(script, "replaceDomain")
+	s.Contains(script, `{ echo 'SET ROLE "staging_pg";'; cat "${tmpPath}/a_pg.sql"; } | su - postgres -c "psql -v ON_ERROR_STOP=1 -q -d staging_pg"`)
+
+	// 克隆多个数据库时需指定应用配置文件使用的数据库
+	opts.Databases = append(opts.Databases, Database{Type: "mysql", Source: "a_db", Target: "staging_db", User: "staging_user", Password: "Passw0rd!"})
+	_, err = CloneScript(opts)
+	s.Error(err)
+	opts.Databases[1].AppConfig = true
+	script, err = CloneScript(opts)
+	s.NoError(err)
+	s.Contains(script, `DB_DATABASE=staging_db`)
+	s.NotContains(script, `DB_DATABASE=staging_pg`)
+	opts.Databases = opts.Databases[:1]
+
+	// 未克隆数据库时清空应用配置文件中的连接信息
+	script, err = CloneScript(Options{Source: opts.Source, Target: opts.Target, Owner: "www"})
+	s.NoError(err)
+	s.Contains(script, `s|^DB_PASSWORD=.*|DB_PASSWORD=|`)
+	s.Contains(script, `define( 'DB_PASSWORD', '' );`)
+
+	opts.Databases[0].Password = "pa$$word"
+	_, err = CloneScript(opts)
+	s.Error(err)
+	opts.Databases[0].Password = "Passw0rd!"
+	opts.Target = opts.Source
+	_, err = CloneScript(opts)
+	s.Error(err)
+	opts.Target = "/www/wwwroot/../etc"
+	_, err = CloneScript(opts)
+	s.Error(err)
+}
+
+func (s *SiteCloneTestSuite) TestPromoteScript() {
+	opts := Options{
+		Source:        "/www/wwwroot/staging.a.com",
+		Target:        "/www/wwwroot/a.com",
+		Owner:         "www",
+		From:          "//staging.a.com",
+		To:            "//a.com",
+		MysqlPassword: "root",
+		Databases: []Database{
+			{Type: "mysql", Source: "staging_db", Target: "a_db", User: "a_user"},
+			{Type: "postgresql", Source: "staging_pg", Target: "a_pg", User: "a_pg"},
+		},
+	}
+	script, err := PromoteScript(opts, "/www/backup/promote/a.com_20231201000000")
+	s.NoError(err)
+	s.Contains(script, `tar -czf "${backupPath}/files.tar.gz" -C "${targetPath}" .`)
+	s.Contains(script, `'a_db' | gzip > "${backupPath}/mysql-a_db.sql.gz"`)
+	s.Contains(script, `su - postgres -c "pg_dump --no-owner --no-privileges a_pg" | gzip > "${backupPath}/postgresql-a_pg.sql.gz"`)
+	s.Contains(script, `if [ -f "${targetPath}/.env" ]; then cp -a "${targetPath}/.env" "${newPath}/.env"; fi`)
+	s.Contains(script, `mv "${newPath}" "${targetPath}"`)
+	s.Contains(script, `DROP DATABASE IF EXISTS a_db; CREATE DATABASE a_db`)
+	s.Contains(script, `su - postgres -c "dropdb --if-exists --force a_pg && createdb -O a_pg a_pg"`)
+	s.Contains(script, `MYSQL_PWD='root' /www/server/mysql/bin/mysql -uroot 'a_db' < <(gunzip -c "${backupPath}/mysql-a_db.sql.gz")`)
+	// 数据库上线成功后才切换网站文件
+	s.Less(strings.Index(script, `cp -a "${sourcePath}/." "${newPath}/"`), strings.Index(script, "复制数据库 staging_db"))
+	s.Less(strings.Index(script, "复制数据库 staging_pg"), strings.Index(script, `mv "${targetPath}" "${oldPath}"`))
+	s.Less(strings.Index(script, `mv "${newPath}" "${targetPath}"`), strings.Index(script, `rm -rf "${oldPath}"`+"\n"))
+	s.Contains(script, `panel replaceDomain '//staging.a.com' '//a.com' "${tmpPath}/staging_pg.sql"`)
+	s.NotContains(script, "DB_DATABASE=")
+
+	_, err = PromoteScript(opts, "/www/backup/../promote")
+	s.Error(err)
+}
@@ -0,0 +1,59 @@
+package siterule
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var userPattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{1,32}$`)
+
+// Users 获取 htpasswd 文件中的用户名
+func Users(htpasswd string) []string {
+	users := make([]string, 0)
+	for _, line := range strings.Split(htpasswd, "\n") {
+		if name, _, ok := strings.Cut(strings.TrimSpace(line), ":"); ok && len(name) > 0 && !strings.HasPrefix(name, "#") {
+			users = append(users, name)
+		}
+	}
+	sort.Strings(users)
+
+	return users
+}
+
+// SetUser 添加或修改 htpasswd 文件中的用户，密码使用 OpenResty 支持的加盐 SHA-1 格式
+func SetUser(htpasswd, name, password string) (string, error) {
+	if !userPattern.MatchString(name) {
+		return "", errors.New("用户名只能包含字母、数字、下划线、点和短横线")
+	}
+	if len(password) == 0 {
+		return "", errors.New("密码不能为空")
+	}
+
+	salt := make([]byte, 8)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	sum := sha1.Sum(append([]byte(password), salt...))
+	hash := "{SSHA}" + base64.StdEncoding.EncodeToString(append(sum[:], salt...))
+
+	return DeleteUser(htpasswd, name) + name + ":" + hash + "\n", nil
+}
+
+// DeleteUser 删除 htpasswd 文件中的用户
+func DeleteUser(htpasswd, name string) string {
+	var sb strings.Builder
+	for _, line := range strings.Split(htpasswd, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, name+":") {
+			continue
+		}
+		sb.WriteString(line + "\n")
+	}
+
+	return sb.String()
+}
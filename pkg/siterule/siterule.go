@@ -0,0 +1,378 @@
+// Package siterule 网站跳转、密码访问、错误页、防盗链和禁止访问规则，渲染到网站配置文件的标记位中
+package siterule
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"panel/pkg/tools"
+)
+
+const (
+	RedirectDomain = "domain" // 按域名跳转
+	RedirectPath   = "path"   // 按路径跳转
+
+	DenyAll = "all" // 禁止访问目录
+	DenyPhp = "php" // 禁止执行目录中的 PHP 文件
+)
+
+// Redirect 跳转规则
+type Redirect struct {
+	Type     string `json:"type"`
+	From     string `json:"from"` // 域名或以 / 开头的路径
+	To       string `json:"to"`   // 域名、URL 或以 / 开头的路径，域名不带协议时使用请求的协议
+	Code     int    `json:"code"` // 301 或 302
+	KeepPath bool   `json:"keep_path"`
+}
+
+// ErrorPages 自定义错误页，值为网站内的页面路径，为空时使用默认错误页
+type ErrorPages struct {
+	NotFound    string `json:"not_found"`
+	ServerError string `json:"server_error"`
+}
+
+// Hotlink 防盗链，扩展名为空时不启用
+type Hotlink struct {
+	Extensions []string `json:"extensions"`
+	Referers   []string `json:"referers"`    // 除网站域名外允许的来源域名
+	AllowEmpty bool     `json:"allow_empty"` // 是否允许空来源
+}
+
+// Deny 禁止访问规则
+type Deny struct {
+	Path  string `json:"path"`
+	Scope string `json:"scope"`
+}
+
+// Rules 网站的全部规则
+type Rules struct {
+	Redirects  []Redirect `json:"redirects"`
+	AuthPaths  []string   `json:"auth_paths"` // 需要密码访问的路径，/ 表示整个网站
+	ErrorPages ErrorPages `json:"error_pages"`
+	Hotlink    Hotlink    `json:"hotlink"`
+	Denies     []Deny     `json:"denies"`
+}
+
+// markers 规则标记位，按顺序插入到 php 标记位之前，使其中的正则 location 先于 PHP 匹配
+var markers = []string{"redirect", "auth", "error_page", "hotlink", "deny"}
+
+var (
+	domainPattern    = regexp.MustCompile(`^[a-zA-Z0-9.-]+$`)
+	refererPattern   = regexp.MustCompile(`^[a-zA-Z0-9*.-]+$`)
+	pathPattern      = regexp.MustCompile(`^/[a-zA-Z0-9_./-]*$`)
+	targetPattern    = regexp.MustCompile(`^((https?://)?[a-zA-Z0-9.-]+(:\d+)?)?(/[a-zA-Z0-9_./%-]*)?$`)
+	extensionPattern = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+
+	domainRedirectPattern = regexp.MustCompile(`if \(\$host = '([^']+)'\) \{\s*return (301|302) (\S+?)(\$request_uri)?;`)
+	pathRedirectPattern   = regexp.MustCompile(`rewrite \^(\S*)\(/\.\*\)\?\$ (\S+?)(\$1|\?) (permanent|redirect);`)
+	authPattern           = regexp.MustCompile(`location \^~ (\S+) \{\s*auth_basic "`)
+	siteAuthPattern       = regexp.MustCompile(`(?m)^    auth_basic "`)
+	errorPagePattern      = regexp.MustCompile(`error_page ([\d ]+) (\S+);`)
+	hotlinkPattern        = regexp.MustCompile(`location ~ \.\*\\\.\(([a-zA-Z0-9|]+)\)\$ \{\s*valid_referers ([^;]+);`)
+	denyAllPattern        = regexp.MustCompile(`location \^~ (\S+) \{\s*deny all;`)
+	denyPhpPattern        = regexp.MustCompile(`location ~ \^(\S*)\.\*\\\.php\$ \{\s*deny all;`)
+)
+
+// Validate 校验规则
+func (r Rules) Validate() error {
+	for _, redirect := range r.Redirects {
+		if redirect.Code != 301 && redirect.Code != 302 {
+			return errors.New("跳转状态码只能为 301 或 302")
+		}
+		if len(redirect.To) == 0 || !targetPattern.MatchString(redirect.To) {
+			return errors.New("跳转目标 " + redirect.To + " 不合法")
+		}
+		switch redirect.Type {
+		case RedirectDomain:
+			if !domainPattern.MatchString(redirect.From) || strings.HasPrefix(redirect.To, "/") {
+				return errors.New("域名跳转 " + redirect.From + " 不合法")
+			}
+		case RedirectPath:
+			if !validPath(redirect.From) {
+				return errors.New("路径跳转 " + redirect.From + " 不合法")
+			}
+		default:
+			return errors.New("不支持的跳转类型")
+		}
+	}
+
+	paths := make(map[string]bool)
+	for _, path := range r.AuthPaths {
+		if !validPath(path) {
+			return errors.New("密码访问路径 " + path + " 不合法")
+		}
+		paths[location(path)] = true
+	}
+	for _, deny := range r.Denies {
+		if !validPath(deny.Path) || (deny.Scope != DenyAll && deny.Scope != DenyPhp) {
+			return errors.New("禁止访问规则 " + deny.Path + " 不合法")
+		}
+		if deny.Scope == DenyAll && paths[location(deny.Path)] {
+			return errors.New("路径 " + deny.Path + " 不能同时设置密码访问和禁止访问")
+		}
+		if deny.Scope == DenyAll && deny.Path == "/" {
+			return errors.New("不能禁止访问整个网站，请停用网站")
+		}
+	}
+
+	for _, page := range []string{r.ErrorPages.NotFound, r.ErrorPages.ServerError} {
+		if len(page) > 0 && (!validPath(page) || strings.HasSuffix(page, "/")) {
+			return errors.New("错误页 " + page + " 不合法")
+		}
+	}
+
+	for _, extension := range r.Hotlink.Extensions {
+		if !extensionPattern.MatchString(extension) || strings.HasPrefix(strings.ToLower(extension), "php") {
+			return errors.New("防盗链扩展名 " + extension + " 不合法")
+		}
+	}
+	for _, referer := range r.Hotlink.Referers {
+		if !refererPattern.MatchString(referer) {
+			return errors.New("防盗链来源 " + referer + " 不合法")
+		}
+	}
+
+	return nil
+}
+
+// Parse 从网站配置文件中读取规则
+func Parse(conf string) Rules {
+	rules := Rules{
+		Redirects: []Redirect{},
+		AuthPaths: []string{},
+		Hotlink:   Hotlink{Extensions: []string{}, Referers: []string{}},
+		Denies:    []Deny{},
+	}
+
+	block := cut(conf, "redirect")
+	for _, match := range domainRedirectPattern.FindAllStringSubmatch(block, -1) {
+		code, _ := strconv.Atoi(match[2])
+		rules.Redirects = append(rules.Redirects, Redirect{
+			Type:     RedirectDomain,
+			From:     match[1],
+			To:       strings.TrimPrefix(match[3], "$scheme://"),
+			Code:     code,
+			KeepPath: len(match[4]) > 0,
+		})
+	}
+	for _, match := range pathRedirectPattern.FindAllStringSubmatch(block, -1) {
+		code := 301
+		if match[4] == "redirect" {
+			code = 302
+		}
+		from := strings.ReplaceAll(match[1], `\`, "")
+		if len(from) == 0 {
+			from = "/"
+		}
+		rules.Redirects = append(rules.Redirects, Redirect{
+			Type:     RedirectPath,
+			From:     from,
+			To:       strings.TrimPrefix(match[2], "$scheme://"),
+			Code:     code,
+			KeepPath: match[3] == "$1",
+		})
+	}
+
+	block = cut(conf, "auth")
+	if siteAuthPattern.MatchString(block) {
+		rules.AuthPaths = append(rules.AuthPaths, "/")
+	}
+	for _, match := range authPattern.FindAllStringSubmatch(block, -1) {
+		rules.AuthPaths = append(rules.AuthPaths, match[1])
+	}
+
+	for _, match := range errorPagePattern.FindAllStringSubmatch(cut(conf, "error_page"), -1) {
+		if match[1] == "404" {
+			rules.ErrorPages.NotFound = match[2]
+		} else {
+			rules.ErrorPages.ServerError = match[2]
+		}
+	}
+
+	if match := hotlinkPattern.FindStringSubmatch(cut(conf, "hotlink")); len(match) == 3 {
+		rules.Hotlink.Extensions = strings.Split(match[1], "|")
+		for _, referer := range strings.Fields(match[2]) {
+			switch referer {
+			case "none":
+				rules.Hotlink.AllowEmpty = true
+			case "blocked", "server_names":
+			default:
+				rules.Hotlink.Referers = append(rules.Hotlink.Referers, referer)
+			}
+		}
+	}
+
+	block = cut(conf, "deny")
+	for _, match := range denyAllPattern.FindAllStringSubmatch(block, -1) {
+		rules.Denies = append(rules.Denies, Deny{Path: match[1], Scope: DenyAll})
+	}
+	for _, match := range denyPhpPattern.FindAllStringSubmatch(block, -1) {
+		rules.Denies = append(rules.Denies, Deny{Path: strings.ReplaceAll(match[1], `\`, ""), Scope: DenyPhp})
+	}
+
+	return rules
+}
+
+// Apply 将规则写入网站配置文件，缺少标记位时插入到 php 标记位之前，htpasswd 为密码访问的用户文件
+func Apply(conf string, rules Rules, htpasswd string) (string, error) {
+	if err := rules.Validate(); err != nil {
+		return "", err
+	}
+	if !strings.Contains(conf, "# php标记位开始") {
+		return "", errors.New("配置文件中缺少php标记位")
+	}
+
+	var missing strings.Builder
+	for _, marker := range markers {
+		if !strings.Contains(conf, "# "+marker+"标记位开始") {
+			missing.WriteString("# " + marker + "标记位开始\n    # " + marker + "标记位结束\n    ")
+		}
+	}
+	if missing.Len() > 0 {
+		conf = strings.Replace(conf, "# php标记位开始", missing.String()+"\n    # php标记位开始", 1)
+	}
+
+	php := tools.Cut(conf, "# php标记位开始", "# php标记位结束")
+	blocks := map[string]string{
+		"redirect":   renderRedirects(rules.Redirects),
+		"auth":       renderAuth(rules.AuthPaths, htpasswd, php),
+		"error_page": renderErrorPages(rules.ErrorPages),
+		"hotlink":    renderHotlink(rules.Hotlink),
+		"deny":       renderDenies(rules.Denies),
+	}
+	for _, marker := range markers {
+		begin, end := "# "+marker+"标记位开始", "# "+marker+"标记位结束"
+		old := tools.Cut(conf, begin, end)
+		conf = strings.Replace(conf, begin+old+end, begin+"\n"+blocks[marker]+"    "+end, 1)
+	}
+
+	return conf, nil
+}
+
+func renderRedirects(redirects []Redirect) string {
+	var sb strings.Builder
+	for _, redirect := range redirects {
+		to := redirect.To
+		if !strings.HasPrefix(to, "/") && !strings.Contains(to, "://") {
+			to = "$scheme://" + to
+		}
+		switch redirect.Type {
+		case RedirectDomain:
+			if redirect.KeepPath {
+				to = strings.TrimSuffix(to, "/") + "$request_uri"
+			}
+			sb.WriteString("    if ($host = '" + redirect.From + "') {\n")
+			sb.WriteString("        return " + strconv.Itoa(redirect.Code) + " " + to + ";\n")
+			sb.WriteString("    }\n")
+		case RedirectPath:
+			// 匹配路径本身及其子路径，不保留路径时丢弃请求参数
+			suffix := "?"
+			if redirect.KeepPath {
+				to, suffix = strings.TrimSuffix(to, "/"), "$1"
+			}
+			flag := "permanent"
+			if redirect.Code == 302 {
+				flag = "redirect"
+			}
+			sb.WriteString("    rewrite ^" + regexp.QuoteMeta(strings.TrimSuffix(redirect.From, "/")) + "(/.*)?$ " + to + suffix + " " + flag + ";\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// renderAuth 整个网站在 server 中开启认证，其他路径使用 ^~ location 以优先于 PHP 的正则 location，因此需在其中嵌套 PHP 配置，
+// 不带结尾 / 的路径不匹配 ^~ location，需单独用 = location 开启认证
+func renderAuth(paths []string, htpasswd, php string) string {
+	var sb strings.Builder
+	for _, path := range paths {
+		if path == "/" {
+			sb.WriteString(`    auth_basic "Restricted";` + "\n")
+			sb.WriteString("    auth_basic_user_file " + htpasswd + ";\n")
+			// 证书验证文件无需认证
+			sb.WriteString("    location ^~ /.well-known/acme-challenge/ {\n        auth_basic off;\n    }\n")
+		}
+	}
+	for _, path := range paths {
+		if path == "/" {
+			continue
+		}
+		sb.WriteString("    location = " + strings.TrimSuffix(path, "/") + " {\n")
+		sb.WriteString(`        auth_basic "Restricted";` + "\n")
+		sb.WriteString("        auth_basic_user_file " + htpasswd + ";\n")
+		sb.WriteString("    }\n")
+		sb.WriteString("    location ^~ " + location(path) + " {\n")
+		sb.WriteString(`        auth_basic "Restricted";` + "\n")
+		sb.WriteString("        auth_basic_user_file " + htpasswd + ";\n")
+		for _, line := range strings.Split(strings.TrimSpace(php), "\n") {
+			if len(strings.TrimSpace(line)) > 0 {
+				sb.WriteString("        " + strings.TrimPrefix(line, "    ") + "\n")
+			}
+		}
+		sb.WriteString("    }\n")
+	}
+
+	return sb.String()
+}
+
+func renderErrorPages(pages ErrorPages) string {
+	var sb strings.Builder
+	if len(pages.NotFound) > 0 {
+		sb.WriteString("    error_page 404 " + pages.NotFound + ";\n")
+	}
+	if len(pages.ServerError) > 0 {
+		sb.WriteString("    error_page 500 502 503 504 " + pages.ServerError + ";\n")
+	}
+
+	return sb.String()
+}
+
+func renderHotlink(hotlink Hotlink) string {
+	if len(hotlink.Extensions) == 0 {
+		return ""
+	}
+
+	referers := "server_names"
+	if hotlink.AllowEmpty {
+		referers = "none blocked " + referers
+	}
+	if len(hotlink.Referers) > 0 {
+		referers += " " + strings.Join(hotlink.Referers, " ")
+	}
+
+	return "    location ~ .*\\.(" + strings.Join(hotlink.Extensions, "|") + ")$ {\n" +
+		"        valid_referers " + referers + ";\n" +
+		"        if ($invalid_referer) {\n" +
+		"            return 403;\n" +
+		"        }\n" +
+		"    }\n"
+}
+
+func renderDenies(denies []Deny) string {
+	var sb strings.Builder
+	for _, deny := range denies {
+		switch deny.Scope {
+		case DenyAll:
+			sb.WriteString("    location ^~ " + location(deny.Path) + " {\n        deny all;\n    }\n")
+		case DenyPhp:
+			sb.WriteString("    location ~ ^" + regexp.QuoteMeta(location(deny.Path)) + ".*\\.php$ {\n        deny all;\n    }\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// location 目录路径统一以 / 结尾，避免 /admin 匹配到 /administrator
+func location(path string) string {
+	return strings.TrimSuffix(path, "/") + "/"
+}
+
+func validPath(path string) bool {
+	return pathPattern.MatchString(path) && !strings.Contains(path, "..") && !strings.Contains(path, "//")
+}
+
+func cut(conf, marker string) string {
+	return tools.Cut(conf, "# "+marker+"标记位开始", "# "+marker+"标记位结束")
+}
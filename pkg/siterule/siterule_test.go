@@ -0,0 +1,149 @@
+package siterule
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SiteRuleTestSuite struct {
+	suite.Suite
+}
+
+func TestSiteRuleTestSuite(t *testing.T) {
+	suite.Run(t, &SiteRuleTestSuite{})
+}
+
+const vhost = `server
+{
+    # root标记位开始
+    root /www/wwwroot/a.com;
+    # root标记位结束
+
+    # php标记位开始
+    include enable-php-82.conf;
+    # php标记位结束
+
+    include /www/server/vhost/rewrite/a.com.conf;
+}
+`
+
+func (s *SiteRuleTestSuite) rules() Rules {
+	return Rules{
+		Redirects: []Redirect{
+			{Type: RedirectDomain, From: "www.a.com", To: "a.com", Code: 301, KeepPath: true},
+			{Type: RedirectDomain, From: "old.com", To: "https://a.com/landing", Code: 302},
+			{Type: RedirectPath, From: "/old-blog", To: "/blog", Code: 301, KeepPath: true},
+			{Type: RedirectPath, From: "/promo.html", To: "https://b.com/sale", Code: 302},
+		},
+		AuthPaths:  []string{"/", "/admin/"},
+		ErrorPages: ErrorPages{NotFound: "/404.html", ServerError: "/50x.html"},
+		Hotlink:    Hotlink{Extensions: []string{"jpg", "png"}, Referers: []string{"*.a.com", "b.com"}, AllowEmpty: true},
+		Denies: []Deny{
+			{Path: "/private/", Scope: DenyAll},
+			{Path: "/uploads/", Scope: DenyPhp},
+		},
+	}
+}
+
+func (s *SiteRuleTestSuite) TestApply() {
+	conf, err := Apply(vhost, s.rules(), "/www/server/vhost/htpasswd/a.com.htpasswd")
+	s.NoError(err)
+	s.Less(strings.Index(conf, "# deny标记位结束"), strings.Index(conf, "# php标记位开始"))
+	s.Contains(conf, "    if ($host = 'www.a.com') {\n        return 301 $scheme://a.com$request_uri;\n    }\n")
+	s.Contains(conf, "        return 302 https://a.com/landing;\n")
+	s.Contains(conf, "    rewrite ^/old-blog(/.*)?$ /blog$1 permanent;\n")
+	s.Contains(conf, "    rewrite ^/promo\\.html(/.*)?$ https://b.com/sale? redirect;\n")
+	s.Contains(conf, "    auth_basic \"Restricted\";\n    auth_basic_user_file /www/server/vhost/htpasswd/a.com.htpasswd;\n")
+	s.Contains(conf, "    location = /admin {\n        auth_basic \"Restricted\";\n        auth_basic_user_file /www/server/vhost/htpasswd/a.com.htpasswd;\n    }\n")
+	s.Contains(conf, "    location ^~ /admin/ {\n        auth_basic \"Restricted\";\n        auth_basic_user_file /www/server/vhost/htpasswd/a.com.htpasswd;\n        include enable-php-82.conf;\n    }\n")
+	s.Contains(conf, "    error_page 500 502 503 504 /50x.html;\n")
+	s.Contains(conf, "    location ~ .*\\.(jpg|png)$ {\n        valid_referers none blocked server_names *.a.com b.com;\n")
+	s.Contains(conf, "    location ^~ /private/ {\n        deny all;\n    }\n")
+	s.Contains(conf, "    location ~ ^/uploads/.*\\.php$ {\n        deny all;\n    }\n")
+
+	// 再次写入时替换原有规则
+	again, err := Apply(conf, Rules{}, "/www/server/vhost/htpasswd/a.com.htpasswd")
+	s.NoError(err)
+	s.NotContains(again, "auth_basic")
+	s.NotContains(again, "rewrite ^")
+	s.Equal(1, strings.Count(again, "# auth标记位开始"))
+	s.Contains(again, "    # redirect标记位开始\n    # redirect标记位结束\n")
+
+	_, err = Apply("server {}", s.rules(), "/tmp/htpasswd")
+	s.Error(err)
+}
+
+func (s *SiteRuleTestSuite) TestParse() {
+	rules := s.rules()
+	conf, err := Apply(vhost, rules, "/www/server/vhost/htpasswd/a.com.htpasswd")
+	s.NoError(err)
+	s.Equal(rules, Parse(conf))
+
+	empty := Parse(vhost)
+	s.Empty(empty.Redirects)
+	s.Empty(empty.AuthPaths)
+	s.Empty(empty.Hotlink.Extensions)
+	s.Equal(ErrorPages{}, empty.ErrorPages)
+
+	// 路径统一以 / 结尾
+	conf, err = Apply(vhost, Rules{AuthPaths: []string{"/admin"}, Redirects: []Redirect{{Type: RedirectPath, From: "/", To: "https://b.com/", Code: 301, KeepPath: true}}}, "/tmp/htpasswd")
+	s.NoError(err)
+	s.Contains(conf, "location ^~ /admin/ {")
+	s.Contains(conf, "rewrite ^(/.*)?$ https://b.com$1 permanent;")
+	rules = Parse(conf)
+	s.Equal([]string{"/admin/"}, rules.AuthPaths)
+	s.Equal(Redirect{Type: RedirectPath, From: "/", To: "https://b.com", Code: 301, KeepPath: true}, rules.Redirects[0])
+}
+
+func (s *SiteRuleTestSuite) TestValidate() {
+	s.NoError(s.rules().Validate())
+	s.NoError(Rules{}.Validate())
+
+	invalid := []Rules{
+		{Redirects: []Redirect{{Type: RedirectDomain, From: "a.com", To: "b.com", Code: 307}}},
+		{Redirects: []Redirect{{Type: RedirectDomain, From: "a.com", To: "/path", Code: 301}}},
+		{Redirects: []Redirect{{Type: RedirectPath, From: "/a", To: "b.com; return 200", Code: 301}}},
+		{Redirects: []Redirect{{Type: RedirectPath, From: "/a/../b", To: "/b", Code: 301}}},
+		{Redirects: []Redirect{{Type: "regex", From: "/a", To: "/b", Code: 301}}},
+		{AuthPaths: []string{"admin"}},
+		{AuthPaths: []string{"/admin"}, Denies: []Deny{{Path: "/admin/", Scope: DenyAll}}},
+		{Denies: []Deny{{Path: "/", Scope: DenyAll}}},
+		{Denies: []Deny{{Path: "/a", Scope: "exec"}}},
+		{ErrorPages: ErrorPages{NotFound: "404.html"}},
+		{Hotlink: Hotlink{Extensions: []string{"php"}}},
+		{Hotlink: Hotlink{Extensions: []string{"jpg"}, Referers: []string{"a.com;"}}},
+	}
+	for _, rules := range invalid {
+		s.Error(rules.Validate())
+	}
+}
+
+func (s *SiteRuleTestSuite) TestHtpasswd() {
+	htpasswd, err := SetUser("", "alice", "secret")
+	s.NoError(err)
+	htpasswd, err = SetUser(htpasswd, "bob", "secret")
+	s.NoError(err)
+	htpasswd, err = SetUser(htpasswd, "alice", "changed")
+	s.NoError(err)
+	s.Equal([]string{"alice", "bob"}, Users(htpasswd))
+
+	// 与 OpenResty 相同的方式校验 {SSHA} 密码
+	for _, line := range strings.Split(htpasswd, "\n") {
+		if hash, ok := strings.CutPrefix(line, "alice:{SSHA}"); ok {
+			raw, err := base64.StdEncoding.DecodeString(hash)
+			s.NoError(err)
+			sum := sha1.Sum(append([]byte("changed"), raw[sha1.Size:]...))
+			s.Equal(sum[:], raw[:sha1.Size])
+		}
+	}
+
+	s.Equal([]string{"bob"}, Users(DeleteUser(htpasswd, "alice")))
+	_, err = SetUser(htpasswd, "a:b", "secret")
+	s.Error(err)
+	_, err = SetUser(htpasswd, "carol", "")
+	s.Error(err)
+}
@@ -0,0 +1,160 @@
+// Package sitestatus 切换网站配置文件的运行目录和默认文件，用于停止和暂停网站
+package sitestatus
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+const (
+	// Root 网站停止和暂停时使用的运行目录
+	Root = "/www/server/openresty/html"
+	// StopPage 网站停止时显示的页面
+	StopPage = "stop.html"
+	// SuspendedPage 网站暂停时显示的页面
+	SuspendedPage = "suspended.html"
+)
+
+var (
+	activePattern    = regexp.MustCompile(`(?m)^\s*(root|index)\s+(.+);`)
+	commentedPattern = regexp.MustCompile(`(?m)^\s*#\s*(root|index)\s+(.+);`)
+)
+
+// Stop 将网站的运行目录切换为停止页目录，默认文件切换为 page，原配置以注释保留，已停止的网站仅切换页面
+func Stop(conf, page string) (string, error) {
+	conf, err := replace(conf, "root", func(active, commented string) string {
+		if len(commented) > 0 {
+			return "    root " + Root + ";\n    # root " + commented + ";\n    "
+		}
+		return "    root " + Root + ";\n    # root " + active + ";\n    "
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return replace(conf, "index", func(active, commented string) string {
+		if len(commented) > 0 {
+			return "    index " + page + ";\n    # index " + commented + ";\n    "
+		}
+		return "    index " + page + ";\n    # index " + active + ";\n    "
+	})
+}
+
+// Start 恢复网站原来的运行目录和默认文件
+func Start(conf string) (string, error) {
+	for _, name := range []string{"root", "index"} {
+		var err error
+		conf, err = replace(conf, name, func(active, commented string) string {
+			if len(commented) > 0 {
+				return "    " + name + " " + commented + ";\n    "
+			}
+			return "    " + name + " " + active + ";\n    "
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return conf, nil
+}
+
+// Page 网站当前显示的停止页或暂停页，未停止时为空
+func Page(conf string) string {
+	block := cut(conf, "index")
+	if match := commentedPattern.FindStringSubmatch(block); len(match) != 3 {
+		return ""
+	}
+	for _, match := range activePattern.FindAllStringSubmatch(block, -1) {
+		if match[1] == "index" {
+			return match[2]
+		}
+	}
+
+	return ""
+}
+
+// replace 按标记位内当前生效的值和注释中保留的原值生成新的标记位内容
+func replace(conf, name string, render func(active, commented string) string) (string, error) {
+	begin, end := "# "+name+"标记位开始\n", "# "+name+"标记位结束"
+	block := cut(conf, name)
+	if len(strings.TrimSpace(block)) == 0 {
+		return "", errors.New("配置文件中缺少" + name + "标记位")
+	}
+
+	active, commented := "", ""
+	for _, match := range activePattern.FindAllStringSubmatch(block, -1) {
+		if match[1] == name {
+			active = match[2]
+			break
+		}
+	}
+	if match := commentedPattern.FindStringSubmatch(block); len(match) == 3 && match[1] == name {
+		commented = match[2]
+	}
+	if len(active) == 0 && len(commented) == 0 {
+		return "", errors.New("配置文件中" + name + "标记位格式错误")
+	}
+
+	return strings.Replace(conf, begin+block+end, begin+render(active, commented)+end, 1), nil
+}
+
+func cut(conf, name string) string {
+	begin, end := "# "+name+"标记位开始\n", "# "+name+"标记位结束"
+	start := strings.Index(conf, begin)
+	if start == -1 {
+		return ""
+	}
+	start += len(begin)
+	stop := strings.Index(conf[start:], end)
+	if stop == -1 {
+		return ""
+	}
+
+	return conf[start : start+stop]
+}
+
+// DefaultSuspendedPage 未自定义暂停页时使用的页面
+const DefaultSuspendedPage = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1.0">
+    <title>网站已暂停</title>
+    <style>
+        body {
+            background-color: #f9f9f9;
+            margin: 0;
+            padding: 0;
+        }
+        .container {
+            max-width: 800px;
+            margin: 2em auto;
+            background-color: #ffffff;
+            padding: 20px;
+            border-radius: 12px;
+            box-shadow: 0 4px 8px rgba(0, 0, 0, 0.1);
+        }
+        h1 {
+            font-size: 2.5em;
+            margin-top: 0;
+            margin-bottom: 20px;
+            text-align: center;
+            color: #333;
+            border-bottom: 2px solid #ddd;
+            padding-bottom: 0.5em;
+        }
+        p {
+            color: #555;
+            line-height: 1.8;
+        }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <h1>网站已暂停</h1>
+        <p>该网站已到期或被暂停服务，如需恢复请联系管理员。</p>
+    </div>
+</body>
+</html>
+`
@@ -0,0 +1,59 @@
+package sitestatus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SiteStatusTestSuite struct {
+	suite.Suite
+}
+
+func TestSiteStatusTestSuite(t *testing.T) {
+	suite.Run(t, &SiteStatusTestSuite{})
+}
+
+const vhost = `server {
+    # index标记位开始
+    index index.php index.html;
+    # index标记位结束
+    # root标记位开始
+    root /www/wwwroot/a.com;
+    # root标记位结束
+}
+`
+
+func (s *SiteStatusTestSuite) TestStop() {
+	stopped, err := Stop(vhost, StopPage)
+	s.NoError(err)
+	s.Contains(stopped, "    index stop.html;\n    # index index.php index.html;\n    # index标记位结束")
+	s.Contains(stopped, "    root /www/server/openresty/html;\n    # root /www/wwwroot/a.com;\n    # root标记位结束")
+	s.Equal(StopPage, Page(stopped))
+	s.Empty(Page(vhost))
+
+	// 已停止的网站仅切换页面，不丢失原配置
+	suspended, err := Stop(stopped, SuspendedPage)
+	s.NoError(err)
+	s.Contains(suspended, "    index suspended.html;\n    # index index.php index.html;\n    # index标记位结束")
+	s.Contains(suspended, "    root /www/server/openresty/html;\n    # root /www/wwwroot/a.com;\n    # root标记位结束")
+	s.Equal(SuspendedPage, Page(suspended))
+
+	_, err = Stop("server {}", StopPage)
+	s.Error(err)
+}
+
+func (s *SiteStatusTestSuite) TestStart() {
+	suspended, err := Stop(vhost, SuspendedPage)
+	s.NoError(err)
+	started, err := Start(suspended)
+	s.NoError(err)
+	s.Contains(started, "    index index.php index.html;\n    # index标记位结束")
+	s.Contains(started, "    root /www/wwwroot/a.com;\n    # root标记位结束")
+	s.NotContains(started, "# root /")
+	s.Empty(Page(started))
+
+	again, err := Start(started)
+	s.NoError(err)
+	s.Equal(started, again)
+}
@@ -0,0 +1,154 @@
+// Package streamproxy 生成 OpenResty stream 模块的 TCP/UDP 代理配置
+package streamproxy
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Dir 代理配置目录，由主配置文件的 stream 块引入
+const Dir = "/www/server/vhost/stream"
+
+// Backend 上游后端
+type Backend struct {
+	Address     string `json:"address"`      // host:port
+	Weight      int    `json:"weight"`       // 权重，0 时使用默认值 1
+	MaxFails    int    `json:"max_fails"`    // 失败次数达到后暂停转发，0 时使用默认值 1
+	FailTimeout int    `json:"fail_timeout"` // 暂停转发的秒数，0 时使用默认值 10
+	Backup      bool   `json:"backup"`       // 仅在其他后端不可用时转发
+}
+
+// Proxy 代理配置
+type Proxy struct {
+	Port                int       `json:"port"`
+	Protocol            string    `json:"protocol"` // tcp 或 udp
+	Backends            []Backend `json:"backends"`
+	Balance             string    `json:"balance"`               // 负载均衡方式，为空时轮询，可选 least_conn、hash、random
+	ProxyProtocol       bool      `json:"proxy_protocol"`        // 向后端发送 PROXY 协议头
+	AcceptProxyProtocol bool      `json:"accept_proxy_protocol"` // 监听端口接收 PROXY 协议头
+	ConnectTimeout      int       `json:"connect_timeout"`       // 连接后端超时秒数，0 时使用默认值
+	Timeout             int       `json:"timeout"`               // 连接空闲超时秒数，0 时使用默认值
+	SSL                 bool      `json:"ssl"`                   // 在监听端口终止 TLS
+}
+
+// Validate 校验代理配置
+func (p Proxy) Validate() error {
+	if p.Port < 1 || p.Port > 65535 {
+		return errors.New("监听端口必须在 1 到 65535 之间")
+	}
+	if p.Protocol != "tcp" && p.Protocol != "udp" {
+		return errors.New("协议只能为 tcp 或 udp")
+	}
+	if p.Balance != "" && p.Balance != "least_conn" && p.Balance != "hash" && p.Balance != "random" {
+		return errors.New("负载均衡方式 " + p.Balance + " 不支持")
+	}
+	if p.Protocol == "udp" && (p.SSL || p.ProxyProtocol || p.AcceptProxyProtocol) {
+		return errors.New("UDP 代理不支持 TLS 和 PROXY 协议")
+	}
+	if p.ConnectTimeout < 0 || p.Timeout < 0 {
+		return errors.New("超时时间不能为负数")
+	}
+	if len(p.Backends) == 0 {
+		return errors.New("请添加后端")
+	}
+
+	for _, backend := range p.Backends {
+		host, port, err := net.SplitHostPort(backend.Address)
+		if err != nil || len(host) == 0 || strings.ContainsAny(host, " ;{}") {
+			return errors.New("后端地址 " + backend.Address + " 不合法，需为 host:port")
+		}
+		if number, err := strconv.Atoi(port); err != nil || number < 1 || number > 65535 {
+			return errors.New("后端地址 " + backend.Address + " 的端口不合法")
+		}
+		if backend.Weight < 0 || backend.MaxFails < 0 || backend.FailTimeout < 0 {
+			return errors.New("后端 " + backend.Address + " 的权重和失败参数不能为负数")
+		}
+		if backend.Backup && (p.Balance == "hash" || p.Balance == "random") {
+			return errors.New("hash 和 random 负载均衡方式不支持备用后端")
+		}
+	}
+
+	return nil
+}
+
+// Render 生成代理配置，upstream 为上游名称，开启 TLS 时需传入证书和私钥路径
+func Render(upstream string, p Proxy, cert, key string) string {
+	var sb strings.Builder
+	sb.WriteString("upstream " + upstream + " {\n")
+	switch p.Balance {
+	case "least_conn":
+		sb.WriteString("    least_conn;\n")
+	case "hash":
+		sb.WriteString("    hash $remote_addr consistent;\n")
+	case "random":
+		sb.WriteString("    random two least_conn;\n")
+	}
+	for _, backend := range p.Backends {
+		sb.WriteString("    server " + backend.Address)
+		if backend.Weight > 0 {
+			sb.WriteString(" weight=" + strconv.Itoa(backend.Weight))
+		}
+		if backend.MaxFails > 0 {
+			sb.WriteString(" max_fails=" + strconv.Itoa(backend.MaxFails))
+		}
+		if backend.FailTimeout > 0 {
+			sb.WriteString(" fail_timeout=" + strconv.Itoa(backend.FailTimeout) + "s")
+		}
+		if backend.Backup {
+			sb.WriteString(" backup")
+		}
+		sb.WriteString(";\n")
+	}
+	sb.WriteString("}\n")
+
+	listen := strconv.Itoa(p.Port)
+	if p.Protocol == "udp" {
+		listen += " udp reuseport"
+	}
+	if p.SSL {
+		listen += " ssl"
+	}
+	if p.AcceptProxyProtocol {
+		listen += " proxy_protocol"
+	}
+	sb.WriteString("server {\n")
+	sb.WriteString("    listen " + listen + ";\n")
+	sb.WriteString("    listen [::]:" + listen + ";\n")
+	sb.WriteString("    proxy_pass " + upstream + ";\n")
+	if p.ConnectTimeout > 0 {
+		sb.WriteString("    proxy_connect_timeout " + strconv.Itoa(p.ConnectTimeout) + "s;\n")
+	}
+	if p.Timeout > 0 {
+		sb.WriteString("    proxy_timeout " + strconv.Itoa(p.Timeout) + "s;\n")
+	}
+	if p.ProxyProtocol {
+		sb.WriteString("    proxy_protocol on;\n")
+	}
+	if p.SSL {
+		sb.WriteString("    ssl_certificate " + cert + ";\n")
+		sb.WriteString("    ssl_certificate_key " + key + ";\n")
+		sb.WriteString("    ssl_protocols TLSv1.2 TLSv1.3;\n")
+		sb.WriteString("    ssl_session_timeout 1d;\n")
+	}
+	sb.WriteString("}\n")
+
+	return sb.String()
+}
+
+// Include 在主配置文件中添加引入代理配置的 stream 块，stream 块需与 http 块同级
+func Include(conf string) (string, bool, error) {
+	include := "include " + Dir + "/*.conf;"
+	if strings.Contains(conf, include) {
+		return conf, false, nil
+	}
+
+	index := strings.Index(conf, "\nhttp {")
+	if index == -1 {
+		return "", false, errors.New("配置文件中缺少 http 块")
+	}
+
+	block := "\nstream {\n    " + include + "\n}\n"
+	return conf[:index] + block + conf[index:], true, nil
+}
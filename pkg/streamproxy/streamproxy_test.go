@@ -0,0 +1,116 @@
+package streamproxy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type StreamProxyTestSuite struct {
+	suite.Suite
+}
+
+func TestStreamProxyTestSuite(t *testing.T) {
+	suite.Run(t, &StreamProxyTestSuite{})
+}
+
+func (s *StreamProxyTestSuite) proxy() Proxy {
+	return Proxy{
+		Port:     3306,
+		Protocol: "tcp",
+		Backends: []Backend{
+			{Address: "10.0.0.1:3306", Weight: 2, MaxFails: 3, FailTimeout: 30},
+			{Address: "10.0.0.2:3306", Backup: true},
+		},
+		Balance:        "least_conn",
+		ProxyProtocol:  true,
+		ConnectTimeout: 5,
+		Timeout:        600,
+	}
+}
+
+func (s *StreamProxyTestSuite) TestValidate() {
+	s.NoError(s.proxy().Validate())
+
+	udp := s.proxy()
+	udp.Protocol = "udp"
+	s.Error(udp.Validate())
+	udp.ProxyProtocol = false
+	s.NoError(udp.Validate())
+	udp.SSL = true
+	s.Error(udp.Validate())
+
+	invalid := s.proxy()
+	invalid.Port = 0
+	s.Error(invalid.Validate())
+
+	invalid = s.proxy()
+	invalid.Balance = "ip_hash"
+	s.Error(invalid.Validate())
+
+	invalid = s.proxy()
+	invalid.Balance = "hash"
+	s.Error(invalid.Validate())
+
+	invalid = s.proxy()
+	invalid.Backends = nil
+	s.Error(invalid.Validate())
+
+	for _, address := range []string{"10.0.0.1", "10.0.0.1:0", ":3306", "a;b:3306", "10.0.0.1:http"} {
+		invalid = s.proxy()
+		invalid.Backends = []Backend{{Address: address}}
+		s.Error(invalid.Validate(), address)
+	}
+
+	ipv6 := s.proxy()
+	ipv6.Backends = []Backend{{Address: "[::1]:3306"}, {Address: "db.internal:3306"}}
+	s.NoError(ipv6.Validate())
+}
+
+func (s *StreamProxyTestSuite) TestRender() {
+	s.Equal(`upstream stream_1 {
+    least_conn;
+    server 10.0.0.1:3306 weight=2 max_fails=3 fail_timeout=30s;
+    server 10.0.0.2:3306 backup;
+}
+server {
+    listen 3306;
+    listen [::]:3306;
+    proxy_pass stream_1;
+    proxy_connect_timeout 5s;
+    proxy_timeout 600s;
+    proxy_protocol on;
+}
+`, Render("stream_1", s.proxy(), "", ""))
+
+	ssl := s.proxy()
+	ssl.Balance = ""
+	ssl.ProxyProtocol = false
+	ssl.AcceptProxyProtocol = true
+	ssl.SSL = true
+	rendered := Render("stream_2", ssl, "/www/server/vhost/stream/ssl/2.pem", "/www/server/vhost/stream/ssl/2.key")
+	s.NotContains(rendered, "least_conn")
+	s.Contains(rendered, "    listen 3306 ssl proxy_protocol;\n    listen [::]:3306 ssl proxy_protocol;\n")
+	s.Contains(rendered, "    ssl_certificate /www/server/vhost/stream/ssl/2.pem;\n    ssl_certificate_key /www/server/vhost/stream/ssl/2.key;\n")
+
+	udp := Proxy{Port: 53, Protocol: "udp", Balance: "hash", Backends: []Backend{{Address: "1.1.1.1:53"}}}
+	rendered = Render("stream_3", udp, "", "")
+	s.Contains(rendered, "    hash $remote_addr consistent;\n")
+	s.Contains(rendered, "    listen 53 udp reuseport;\n")
+}
+
+func (s *StreamProxyTestSuite) TestInclude() {
+	conf := "worker_processes auto;\n\nhttp {\n    include mime.types;\n}\n"
+	included, changed, err := Include(conf)
+	s.NoError(err)
+	s.True(changed)
+	s.Equal("worker_processes auto;\n\nstream {\n    include /www/server/vhost/stream/*.conf;\n}\n\nhttp {\n    include mime.types;\n}\n", included)
+
+	again, changed, err := Include(included)
+	s.NoError(err)
+	s.False(changed)
+	s.Equal(included, again)
+
+	_, _, err = Include("events {}\n")
+	s.Error(err)
+}
@@ -0,0 +1,204 @@
+package tlsprofile
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Certificate 证书检查结果
+type Certificate struct {
+	KeyType     string    `json:"key_type"` // ECDSA P-256、RSA 2048 等
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	DNSNames    []string  `json:"dns_names"`
+	NotBefore   time.Time `json:"not_before"`
+	NotAfter    time.Time `json:"not_after"`
+	DaysLeft    int       `json:"days_left"`
+	ChainLength int       `json:"chain_length"` // 证书文件中的证书数量，包含中间证书
+	KeyMatch    bool      `json:"key_match"`    // 私钥与证书是否匹配
+	Trusted     bool      `json:"trusted"`      // 是否可由系统根证书验证
+	OCSPServer  []string  `json:"ocsp_server"`
+
+	leaf *x509.Certificate
+}
+
+// Report 网站的 TLS 配置报告
+type Report struct {
+	Profile      string        `json:"profile"`
+	Protocols    []string      `json:"protocols"`
+	Ciphers      []string      `json:"ciphers"`
+	HTTP3        bool          `json:"http3"`
+	OCSP         bool          `json:"ocsp"`
+	HttpRedirect bool          `json:"http_redirect"`
+	Hsts         bool          `json:"hsts"`
+	Certificates []Certificate `json:"certificates"`
+	Uncovered    []string      `json:"uncovered"` // 证书未覆盖的域名
+	Warnings     []string      `json:"warnings"`
+}
+
+// Inspect 检查 PEM 格式的证书链和私钥
+func Inspect(certPEM, keyPEM string, now time.Time) (Certificate, error) {
+	var chain []*x509.Certificate
+	rest := []byte(certPEM)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return Certificate{}, errors.New("解析证书失败: " + err.Error())
+		}
+		chain = append(chain, cert)
+	}
+	if len(chain) == 0 {
+		return Certificate{}, errors.New("未找到证书")
+	}
+
+	leaf := chain[0]
+	result := Certificate{
+		KeyType:     KeyType(leaf),
+		Subject:     leaf.Subject.CommonName,
+		Issuer:      leaf.Issuer.CommonName,
+		DNSNames:    leaf.DNSNames,
+		NotBefore:   leaf.NotBefore,
+		NotAfter:    leaf.NotAfter,
+		DaysLeft:    int(leaf.NotAfter.Sub(now).Hours() / 24),
+		ChainLength: len(chain),
+		OCSPServer:  leaf.OCSPServer,
+		leaf:        leaf,
+	}
+	if _, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM)); err == nil {
+		result.KeyMatch = true
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{Intermediates: intermediates, CurrentTime: now}); err == nil {
+		result.Trusted = true
+	}
+
+	return result, nil
+}
+
+// KeyType 证书的公钥类型
+func KeyType(cert *x509.Certificate) string {
+	switch key := cert.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		return "ECDSA " + key.Curve.Params().Name
+	case *rsa.PublicKey:
+		return "RSA " + strconv.Itoa(key.N.BitLen())
+	case ed25519.PublicKey:
+		return "Ed25519"
+	default:
+		return "未知"
+	}
+}
+
+// ValidateDual 校验双证书，两张证书需分别为 ECDSA 和 RSA
+func ValidateDual(first, second Certificate) error {
+	algorithm := func(cert Certificate) string {
+		return strings.Fields(cert.KeyType)[0]
+	}
+	if algorithm(first) == algorithm(second) || (algorithm(first) != "ECDSA" && algorithm(first) != "RSA") ||
+		(algorithm(second) != "ECDSA" && algorithm(second) != "RSA") {
+		return errors.New("双证书需为一张 ECDSA 证书和一张 RSA 证书")
+	}
+
+	return nil
+}
+
+// BuildReport 汇总 TLS 配置和证书检查结果，protocols 为默认网站启用的协议版本，domains 为网站绑定的域名
+func BuildReport(options Options, protocols string, certificates []Certificate, domains []string) Report {
+	profile, ok := Find(options.Profile)
+	if !ok {
+		profile, _ = Find("intermediate")
+	}
+
+	report := Report{
+		Profile:      profile.Name,
+		Protocols:    strings.Fields(protocols),
+		Ciphers:      make([]string, 0),
+		HTTP3:        options.HTTP3,
+		OCSP:         options.OCSP,
+		HttpRedirect: options.HttpRedirect,
+		Hsts:         options.Hsts,
+		Certificates: certificates,
+		Uncovered:    make([]string, 0),
+		Warnings:     make([]string, 0),
+	}
+	if len(profile.Ciphers) > 0 {
+		report.Ciphers = strings.Split(profile.Ciphers, ":")
+	}
+
+	if len(certificates) == 0 {
+		report.Warnings = append(report.Warnings, "未配置证书")
+		return report
+	}
+	for _, domain := range domains {
+		covered := false
+		for _, cert := range certificates {
+			if cert.leaf != nil && cert.leaf.VerifyHostname(domain) == nil {
+				covered = true
+			}
+		}
+		if !covered {
+			report.Uncovered = append(report.Uncovered, domain)
+		}
+	}
+	if len(report.Uncovered) > 0 {
+		report.Warnings = append(report.Warnings, "证书未覆盖域名："+strings.Join(report.Uncovered, ", "))
+	}
+
+	for _, cert := range certificates {
+		switch {
+		case cert.DaysLeft < 0:
+			report.Warnings = append(report.Warnings, cert.KeyType+" 证书已过期")
+		case cert.DaysLeft < 30:
+			report.Warnings = append(report.Warnings, cert.KeyType+" 证书将在 "+strconv.Itoa(cert.DaysLeft)+" 天后过期")
+		}
+		if !cert.KeyMatch {
+			report.Warnings = append(report.Warnings, cert.KeyType+" 证书与私钥不匹配")
+		}
+		if !cert.Trusted {
+			report.Warnings = append(report.Warnings, cert.KeyType+" 证书不受信任，可能为自签名证书或缺少中间证书")
+		}
+		if options.OCSP && len(cert.OCSPServer) == 0 {
+			report.Warnings = append(report.Warnings, cert.KeyType+" 证书未包含 OCSP 地址，OCSP 装订不会生效")
+		}
+	}
+
+	for _, protocol := range report.Protocols {
+		if protocol == "TLSv1" || protocol == "TLSv1.1" {
+			report.Warnings = append(report.Warnings, "默认网站启用了不安全的 "+protocol+"，所有网站共用该协议配置")
+		}
+	}
+	if profile.Name == "legacy" {
+		report.Warnings = append(report.Warnings, "legacy 预设启用了 CBC 和 3DES 等不安全的加密套件")
+	}
+	if !options.HttpRedirect {
+		report.Warnings = append(report.Warnings, "未开启 HTTP 跳转 HTTPS")
+	}
+	if !options.Hsts {
+		report.Warnings = append(report.Warnings, "未开启 HSTS")
+	}
+	if options.HTTP3 {
+		report.Warnings = append(report.Warnings, "HTTP/3 使用 UDP 443 端口，请确认防火墙已放行")
+	}
+
+	return report
+}
@@ -0,0 +1,209 @@
+// Package tlsprofile 生成网站的 TLS 配置并检查证书
+package tlsprofile
+
+import (
+	"errors"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultResolver OCSP 装订未指定 DNS 服务器时使用的默认值
+	DefaultResolver = "1.1.1.1 8.8.8.8"
+	// DefaultProtocols 默认网站未配置 ssl_protocols 时 OpenResty 启用的协议版本
+	DefaultProtocols = "TLSv1 TLSv1.1 TLSv1.2 TLSv1.3"
+)
+
+// Profile TLS 配置预设，参考 Mozilla SSL Configuration Generator
+//
+// OpenResty 握手时按同一 IP 和端口的默认网站协商协议版本，ssl_protocols 在各网站中不生效，
+// 因此协议版本由默认网站统一配置，预设只决定网站的加密套件
+type Profile struct {
+	Name                string `json:"name"`
+	Description         string `json:"description"`
+	Ciphers             string `json:"ciphers"` // TLSv1.2 及以下的加密套件，TLSv1.3 的加密套件不受此项影响
+	PreferServerCiphers bool   `json:"prefer_server_ciphers"`
+}
+
+// Profiles 支持的预设，按兼容性从低到高排列
+var Profiles = []Profile{
+	{
+		Name:        "modern",
+		Description: "仅使用 ECDHE 密钥交换的 AEAD 加密套件，适用于不需要兼容旧客户端的网站",
+		Ciphers:     "ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256:ECDHE-ECDSA-AES256-GCM-SHA384:ECDHE-RSA-AES256-GCM-SHA384:ECDHE-ECDSA-CHACHA20-POLY1305:ECDHE-RSA-CHACHA20-POLY1305",
+	},
+	{
+		Name:        "intermediate",
+		Description: "兼容绝大多数客户端，推荐使用",
+		Ciphers:     "ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256:ECDHE-ECDSA-AES256-GCM-SHA384:ECDHE-RSA-AES256-GCM-SHA384:ECDHE-ECDSA-CHACHA20-POLY1305:ECDHE-RSA-CHACHA20-POLY1305:DHE-RSA-AES128-GCM-SHA256:DHE-RSA-AES256-GCM-SHA384",
+	},
+	{
+		Name:                "legacy",
+		Description:         "额外启用 CBC 和 3DES 等旧加密套件，仅在需要兼容不支持 AEAD 加密套件的旧客户端时使用",
+		Ciphers:             "ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256:ECDHE-ECDSA-AES256-GCM-SHA384:ECDHE-RSA-AES256-GCM-SHA384:ECDHE-ECDSA-CHACHA20-POLY1305:ECDHE-RSA-CHACHA20-POLY1305:DHE-RSA-AES128-GCM-SHA256:DHE-RSA-AES256-GCM-SHA384:DHE-RSA-CHACHA20-POLY1305:ECDHE-ECDSA-AES128-SHA256:ECDHE-RSA-AES128-SHA256:ECDHE-ECDSA-AES128-SHA:ECDHE-RSA-AES128-SHA:ECDHE-ECDSA-AES256-SHA384:ECDHE-RSA-AES256-SHA384:ECDHE-ECDSA-AES256-SHA:ECDHE-RSA-AES256-SHA:DHE-RSA-AES128-SHA256:DHE-RSA-AES256-SHA256:AES128-GCM-SHA256:AES256-GCM-SHA384:AES128-SHA256:AES256-SHA256:AES128-SHA:AES256-SHA:DES-CBC3-SHA:@SECLEVEL=0",
+		PreferServerCiphers: true,
+	},
+}
+
+var (
+	protocolsPattern = regexp.MustCompile(`ssl_protocols\s+(.+?);`)
+	ciphersPattern   = regexp.MustCompile(`ssl_ciphers\s+(.+?);`)
+	resolverPattern  = regexp.MustCompile(`resolver\s+(.+?)\s+valid=`)
+)
+
+// Options 网站的 TLS 配置
+type Options struct {
+	Profile      string
+	HTTP3        bool
+	OCSP         bool
+	Resolver     string // OCSP 装订使用的 DNS 服务器，空格分隔
+	HttpRedirect bool
+	Hsts         bool
+}
+
+// Files 证书文件，Dual 为可选的第二张证书，用于同时提供 ECDSA 和 RSA 证书
+type Files struct {
+	Cert     string
+	Key      string
+	DualCert string
+	DualKey  string
+}
+
+// Find 按名称查找预设
+func Find(name string) (Profile, bool) {
+	for _, profile := range Profiles {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+
+	return Profile{}, false
+}
+
+// Validate 校验 TLS 配置
+func (o Options) Validate() error {
+	if _, ok := Find(o.Profile); !ok {
+		return errors.New("TLS 预设 " + o.Profile + " 不存在")
+	}
+	for _, server := range strings.Fields(o.Resolver) {
+		if net.ParseIP(strings.Trim(server, "[]")) == nil {
+			return errors.New("DNS 服务器 " + server + " 不合法，需为 IP 地址")
+		}
+	}
+
+	return nil
+}
+
+// Block 生成网站配置文件 ssl 标记位内的配置，以开始标记位开头，结束标记位需由原配置提供，
+// 协议版本由默认网站统一配置，不写入 ssl_protocols
+func Block(options Options, files Files) string {
+	profile, ok := Find(options.Profile)
+	if !ok {
+		profile, _ = Find("intermediate")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# ssl标记位开始\n")
+	sb.WriteString("    ssl_certificate " + files.Cert + ";\n")
+	sb.WriteString("    ssl_certificate_key " + files.Key + ";\n")
+	if len(files.DualCert) > 0 {
+		sb.WriteString("    ssl_certificate " + files.DualCert + ";\n")
+		sb.WriteString("    ssl_certificate_key " + files.DualKey + ";\n")
+	}
+	sb.WriteString("    ssl_session_timeout 1d;\n")
+	sb.WriteString("    ssl_session_cache shared:SSL:10m;\n")
+	sb.WriteString("    ssl_session_tickets off;\n")
+	sb.WriteString("    ssl_ciphers " + profile.Ciphers + ";\n")
+	if profile.PreferServerCiphers {
+		sb.WriteString("    ssl_prefer_server_ciphers on;\n")
+	} else {
+		sb.WriteString("    ssl_prefer_server_ciphers off;\n")
+	}
+	if options.OCSP {
+		resolver := options.Resolver
+		if len(strings.TrimSpace(resolver)) == 0 {
+			resolver = DefaultResolver
+		}
+		sb.WriteString("    # ocsp标记位开始\n")
+		sb.WriteString("    ssl_stapling on;\n")
+		sb.WriteString("    ssl_stapling_verify on;\n")
+		sb.WriteString("    ssl_trusted_certificate " + files.Cert + ";\n")
+		sb.WriteString("    resolver " + strings.Join(strings.Fields(resolver), " ") + " valid=300s;\n")
+		sb.WriteString("    resolver_timeout 5s;\n")
+		sb.WriteString("    # ocsp标记位结束\n")
+	}
+	if options.HTTP3 {
+		sb.WriteString("    # http3标记位开始\n")
+		sb.WriteString("    add_header Alt-Svc 'h3=\":443\"; ma=86400' always;\n")
+		sb.WriteString("    # http3标记位结束\n")
+	}
+	sb.WriteString("    ")
+	if options.HttpRedirect {
+		sb.WriteString(`# http重定向标记位开始
+    if ($server_port !~ 443){
+        return 301 https://$host$request_uri;
+    }
+    error_page 497  https://$host$request_uri;
+    # http重定向标记位结束
+    `)
+	}
+	if options.Hsts {
+		sb.WriteString(`# hsts标记位开始
+    add_header Strict-Transport-Security "max-age=63072000" always;
+    # hsts标记位结束
+    `)
+	}
+
+	return sb.String()
+}
+
+// Listen 生成端口的 listen 参数，开启 SSL 时 443 端口使用 HTTP/2，开启 HTTP/3 时额外监听 UDP
+func Listen(port uint, ssl, http3 bool) []string {
+	if port != 443 || !ssl {
+		return []string{strconv.FormatUint(uint64(port), 10)}
+	}
+
+	listens := []string{"443 ssl http2"}
+	if http3 {
+		listens = append(listens, "443 quic")
+	}
+
+	return listens
+}
+
+// Parse 从网站配置文件的 ssl 和 port 标记位内容解析 TLS 配置
+func Parse(ssl, ports string) Options {
+	options := Options{
+		Profile:      "intermediate",
+		HTTP3:        strings.Contains(ports, "quic"),
+		OCSP:         strings.Contains(ssl, "ssl_stapling on;"),
+		HttpRedirect: strings.Contains(ssl, "# http重定向标记位"),
+		Hsts:         strings.Contains(ssl, "# hsts标记位"),
+	}
+	if match := ciphersPattern.FindStringSubmatch(ssl); len(match) == 2 {
+		for _, profile := range Profiles {
+			if strings.TrimSpace(match[1]) == profile.Ciphers {
+				options.Profile = profile.Name
+			}
+		}
+	} else if Protocols(ssl) == "TLSv1.3" {
+		// 旧版本的 modern 预设只配置了 ssl_protocols
+		options.Profile = "modern"
+	}
+	if match := resolverPattern.FindStringSubmatch(ssl); len(match) == 2 {
+		options.Resolver = match[1]
+	}
+
+	return options
+}
+
+// Protocols 从默认网站的配置中解析启用的协议版本，未配置时为 OpenResty 的默认值
+func Protocols(conf string) string {
+	if match := protocolsPattern.FindStringSubmatch(conf); len(match) == 2 {
+		return strings.Join(strings.Fields(match[1]), " ")
+	}
+
+	return DefaultProtocols
+}
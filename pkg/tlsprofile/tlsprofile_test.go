@@ -0,0 +1,167 @@
+package tlsprofile
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TlsProfileTestSuite struct {
+	suite.Suite
+}
+
+func TestTlsProfileTestSuite(t *testing.T) {
+	suite.Run(t, &TlsProfileTestSuite{})
+}
+
+func (s *TlsProfileTestSuite) files() Files {
+	return Files{Cert: "/www/server/vhost/ssl/a.pem", Key: "/www/server/vhost/ssl/a.key"}
+}
+
+func (s *TlsProfileTestSuite) TestValidate() {
+	s.NoError(Options{Profile: "modern"}.Validate())
+	s.NoError(Options{Profile: "intermediate", OCSP: true, Resolver: "1.1.1.1 [2606:4700:4700::1111]"}.Validate())
+	s.Error(Options{Profile: "custom"}.Validate())
+	s.Error(Options{Profile: "legacy", Resolver: "dns.google"}.Validate())
+}
+
+func (s *TlsProfileTestSuite) TestBlock() {
+	block := Block(Options{Profile: "modern"}, s.files())
+	s.True(strings.HasPrefix(block, "# ssl标记位开始\n    ssl_certificate /www/server/vhost/ssl/a.pem;\n"))
+	s.True(strings.HasSuffix(block, "\n    "))
+	s.NotContains(block, "ssl_protocols")
+	s.Contains(block, "    ssl_ciphers ECDHE-ECDSA-AES128-GCM-SHA256:")
+	s.NotContains(block, ":DHE-RSA-")
+	s.NotContains(block, "ssl_stapling")
+
+	files := s.files()
+	files.DualCert, files.DualKey = "/www/server/vhost/ssl/a.dual.pem", "/www/server/vhost/ssl/a.dual.key"
+	block = Block(Options{Profile: "legacy", OCSP: true, HTTP3: true, HttpRedirect: true, Hsts: true}, files)
+	s.Contains(block, "    ssl_certificate /www/server/vhost/ssl/a.dual.pem;\n    ssl_certificate_key /www/server/vhost/ssl/a.dual.key;\n")
+	s.NotContains(block, "ssl_protocols")
+	s.Contains(block, "DES-CBC3-SHA")
+	s.Contains(block, "    ssl_prefer_server_ciphers on;\n")
+	s.Contains(block, "    ssl_stapling on;\n    ssl_stapling_verify on;\n    ssl_trusted_certificate /www/server/vhost/ssl/a.pem;\n    resolver 1.1.1.1 8.8.8.8 valid=300s;\n")
+	s.Contains(block, "add_header Alt-Svc 'h3=\":443\"; ma=86400' always;")
+	s.Contains(block, "# http重定向标记位开始")
+	s.Contains(block, "# hsts标记位开始")
+
+	options := Parse(block, "    listen 80;\n    listen 443 ssl http2;\n    listen 443 quic;\n")
+	s.Equal(Options{Profile: "legacy", HTTP3: true, OCSP: true, Resolver: "1.1.1.1 8.8.8.8", HttpRedirect: true, Hsts: true}, options)
+
+	options = Parse(Block(Options{Profile: "intermediate", OCSP: true, Resolver: " 9.9.9.9 "}, s.files()), "    listen 443 ssl http2;\n")
+	s.Equal(Options{Profile: "intermediate", OCSP: true, Resolver: "9.9.9.9"}, options)
+
+	// 旧版本的 modern 预设只配置了 ssl_protocols
+	options = Parse("    ssl_protocols TLSv1.3;\n", "    listen 443 ssl http2;\n")
+	s.Equal("modern", options.Profile)
+}
+
+func (s *TlsProfileTestSuite) TestProtocols() {
+	s.Equal("TLSv1.2 TLSv1.3", Protocols("    ssl_protocols  TLSv1.2   TLSv1.3;\n    ssl_reject_handshake on;\n"))
+	s.Equal(DefaultProtocols, Protocols("    ssl_reject_handshake on;\n"))
+}
+
+func (s *TlsProfileTestSuite) TestListen() {
+	s.Equal([]string{"80"}, Listen(80, true, true))
+	s.Equal([]string{"443"}, Listen(443, false, true))
+	s.Equal([]string{"443 ssl http2"}, Listen(443, true, false))
+	s.Equal([]string{"443 ssl http2", "443 quic"}, Listen(443, true, true))
+}
+
+func (s *TlsProfileTestSuite) TestInspect() {
+	now := time.Now()
+	certPEM, keyPEM := s.certificate(s.ecdsaKey(), []string{"example.com", "*.example.com"}, now.Add(10*24*time.Hour))
+	cert, err := Inspect(certPEM, keyPEM, now)
+	s.NoError(err)
+	s.Equal("ECDSA P-256", cert.KeyType)
+	s.Equal([]string{"example.com", "*.example.com"}, cert.DNSNames)
+	s.Equal(1, cert.ChainLength)
+	s.True(cert.KeyMatch)
+	s.False(cert.Trusted)
+	s.InDelta(9, cert.DaysLeft, 1)
+
+	_, otherKey := s.certificate(s.ecdsaKey(), []string{"example.com"}, now.Add(time.Hour))
+	mismatched, err := Inspect(certPEM, otherKey, now)
+	s.NoError(err)
+	s.False(mismatched.KeyMatch)
+
+	_, err = Inspect("not a certificate", keyPEM, now)
+	s.Error(err)
+
+	rsaPEM, rsaKeyPEM := s.certificate(s.rsaKey(), []string{"example.com"}, now.Add(90*24*time.Hour))
+	rsaCert, err := Inspect(rsaPEM, rsaKeyPEM, now)
+	s.NoError(err)
+	s.Equal("RSA 2048", rsaCert.KeyType)
+
+	s.NoError(ValidateDual(cert, rsaCert))
+	s.Error(ValidateDual(cert, cert))
+}
+
+func (s *TlsProfileTestSuite) TestBuildReport() {
+	now := time.Now()
+	certPEM, keyPEM := s.certificate(s.ecdsaKey(), []string{"example.com", "*.example.com"}, now.Add(10*24*time.Hour))
+	cert, err := Inspect(certPEM, keyPEM, now)
+	s.NoError(err)
+
+	report := BuildReport(Options{Profile: "legacy", OCSP: true, HTTP3: true}, DefaultProtocols, []Certificate{cert}, []string{"example.com", "www.example.com", "example.org"})
+	s.Equal("legacy", report.Profile)
+	s.Equal([]string{"TLSv1", "TLSv1.1", "TLSv1.2", "TLSv1.3"}, report.Protocols)
+	s.Contains(report.Ciphers, "DES-CBC3-SHA")
+	s.Equal([]string{"example.org"}, report.Uncovered)
+	warnings := strings.Join(report.Warnings, "\n")
+	s.Contains(warnings, "example.org")
+	s.Contains(warnings, "天后过期")
+	s.Contains(warnings, "不受信任")
+	s.Contains(warnings, "OCSP")
+	s.Contains(warnings, "TLSv1.1")
+	s.Contains(warnings, "3DES")
+	s.Contains(warnings, "HSTS")
+	s.Contains(warnings, "UDP 443")
+
+	empty := BuildReport(Options{Profile: "modern"}, "TLSv1.2 TLSv1.3", nil, []string{"example.com"})
+	s.Equal([]string{"TLSv1.2", "TLSv1.3"}, empty.Protocols)
+	s.NotContains(empty.Ciphers, "DES-CBC3-SHA")
+	s.Equal([]string{"未配置证书"}, empty.Warnings)
+}
+
+func (s *TlsProfileTestSuite) ecdsaKey() crypto.Signer {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.Require().NoError(err)
+	return key
+}
+
+func (s *TlsProfileTestSuite) rsaKey() crypto.Signer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	s.Require().NoError(err)
+	return key
+}
+
+// certificate 生成自签名证书
+func (s *TlsProfileTestSuite) certificate(key crypto.Signer, domains []string, notAfter time.Time) (string, string) {
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: domains[0]},
+		DNSNames:     domains,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	s.Require().NoError(err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	s.Require().NoError(err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+}
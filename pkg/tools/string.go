@@ -3,8 +3,10 @@ package tools
 import (
 	"crypto/md5"
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"io"
+	"regexp"
 	"strings"
 	"text/template"
 	"unicode/utf8"
@@ -80,6 +82,26 @@ func Cut(str, begin, end string) string {
 	return string([]rune(str)[b:e])
 }
 
+var nginxHttpBlock = regexp.MustCompile(`(?m)^\s*http\s*\{[ \t]*\n`)
+
+// NginxInclude 确保 OpenResty 主配置文件的 http 块引入了 file，返回新的配置及是否有修改
+func NginxInclude(conf, file string) (string, bool, error) {
+	line := "include " + file + ";"
+	for _, l := range strings.Split(conf, "\n") {
+		if strings.Join(strings.Fields(l), " ") == line {
+			return conf, false, nil
+		}
+	}
+
+	// 插入到 http 块首，需在网站配置文件引入之前
+	loc := nginxHttpBlock.FindStringIndex(conf)
+	if loc == nil {
+		return "", false, errors.New("主配置文件中缺少http块")
+	}
+
+	return conf[:loc[1]] + "    " + line + "\n" + conf[loc[1]:], true, nil
+}
+
 // Escape 转义字符串
 func Escape(str string) string {
 	return template.HTMLEscapeString(str)
@@ -45,3 +45,19 @@ func (s *StringHelperTestSuite) TestFormatBytes() {
 func (s *StringHelperTestSuite) TestCut() {
 	s.Equal("aoZ", Cut("HaoZi", "H", "i"))
 }
+
+func (s *StringHelperTestSuite) TestNginxInclude() {
+	conf := "events {\n}\n\nhttp {\n    include mime.types;\n    include /www/server/vhost/*.conf;\n}\n"
+	conf, changed, err := NginxInclude(conf, "limit_zones.conf")
+	s.NoError(err)
+	s.True(changed)
+	s.Equal("events {\n}\n\nhttp {\n    include limit_zones.conf;\n    include mime.types;\n    include /www/server/vhost/*.conf;\n}\n", conf)
+
+	again, changed, err := NginxInclude(conf, "limit_zones.conf")
+	s.NoError(err)
+	s.False(changed)
+	s.Equal(conf, again)
+
+	_, _, err = NginxInclude("events {\n}\n", "limit_zones.conf")
+	s.Error(err)
+}
@@ -0,0 +1,109 @@
+package waf
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// LogFormat 拦截日志格式，需写入 http 块，网站通过 access_log 的 if=$waf_blocking_log 只记录被拦截的请求
+const LogFormat = `log_format waf_block escape=json '{"time":"$time_iso8601","ip":"$remote_addr","type":"$waf_rule_type","rule":"$waf_rule_details","method":"$request_method","uri":"$request_uri","user_agent":"$http_user_agent"}';`
+
+// maxLine 单行日志的最大长度，超出的行会被跳过
+const maxLine = 64 << 10
+
+// Event 一次拦截
+type Event struct {
+	Time      string `json:"time"`
+	IP        string `json:"ip"`
+	Type      string `json:"type"` // 规则类型，如 BLACK-URL、CC-DENY
+	Rule      string `json:"rule"` // 命中的规则
+	Method    string `json:"method"`
+	URI       string `json:"uri"`
+	UserAgent string `json:"user_agent"`
+}
+
+// Count 拦截次数
+type Count struct {
+	Key   string `json:"key"`
+	Type  string `json:"type,omitempty"`
+	Count int    `json:"count"`
+}
+
+// Summary 拦截日志汇总
+type Summary struct {
+	Total  int     `json:"total"`
+	Rules  []Count `json:"rules"`  // 按规则统计
+	IPs    []Count `json:"ips"`    // 按 IP 统计
+	Events []Event `json:"events"` // 最近的拦截，按时间倒序
+}
+
+// Summarize 读取拦截日志，统计各规则和 IP 的拦截次数，保留最近 recent 条拦截，统计结果保留前 top 项
+func Summarize(r io.Reader, recent, top int) (Summary, error) {
+	summary := Summary{Rules: make([]Count, 0), IPs: make([]Count, 0), Events: make([]Event, 0)}
+	rules := make(map[[2]string]int)
+	ips := make(map[string]int)
+
+	reader := bufio.NewReaderSize(r, maxLine)
+	for {
+		line, isPrefix, err := reader.ReadLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Summary{}, err
+		}
+		if isPrefix {
+			// 跳过超长行的剩余部分
+			for isPrefix && err == nil {
+				_, isPrefix, err = reader.ReadLine()
+			}
+			continue
+		}
+
+		var event Event
+		if err = json.Unmarshal(line, &event); err != nil || len(event.Type) == 0 {
+			continue
+		}
+		summary.Total++
+		rules[[2]string{event.Type, event.Rule}]++
+		ips[event.IP]++
+		summary.Events = append(summary.Events, event)
+		if len(summary.Events) > recent {
+			summary.Events = summary.Events[1:]
+		}
+	}
+
+	for i, j := 0, len(summary.Events)-1; i < j; i, j = i+1, j-1 {
+		summary.Events[i], summary.Events[j] = summary.Events[j], summary.Events[i]
+	}
+	for key, count := range rules {
+		summary.Rules = append(summary.Rules, Count{Key: key[1], Type: key[0], Count: count})
+	}
+	for ip, count := range ips {
+		summary.IPs = append(summary.IPs, Count{Key: ip, Count: count})
+	}
+	summary.Rules = topCounts(summary.Rules, top)
+	summary.IPs = topCounts(summary.IPs, top)
+
+	return summary, nil
+}
+
+// topCounts 按次数倒序排列并保留前 top 项
+func topCounts(counts []Count, top int) []Count {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		if counts[i].Type != counts[j].Type {
+			return counts[i].Type < counts[j].Type
+		}
+		return counts[i].Key < counts[j].Key
+	})
+	if len(counts) > top {
+		counts = counts[:top]
+	}
+
+	return counts
+}
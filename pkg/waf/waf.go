@@ -0,0 +1,194 @@
+// Package waf ngx_waf 规则文件的校验与合并，以及拦截日志的解析
+package waf
+
+import (
+	"errors"
+	"net"
+	"regexp"
+	"strings"
+
+	"panel/pkg/tools"
+)
+
+// GlobalRulePath 全局规则目录
+const GlobalRulePath = "/www/server/openresty/ngx_waf/assets/rules/"
+
+var (
+	rulePathLine  = regexp.MustCompile(`(?m)^[ \t]*waf_rule_path\s+[^;]*;[ \t]*\n?`)
+	accessLogLine = regexp.MustCompile(`(?m)^[ \t]*access_log\s+[^;]*;[ \t]*\n?`)
+)
+
+// Files ngx_waf 规则目录中必须存在的规则文件
+var Files = []string{
+	"ipv4", "ipv6", "url", "args", "user-agent", "referer", "cookie", "post",
+	"white-ipv4", "white-ipv6", "white-url", "white-referer",
+}
+
+// Rules 网站规则，在全局规则的基础上追加
+type Rules struct {
+	AllowIPs  []string `json:"allow_ips"`  // IP 白名单，支持 CIDR 和 a-b 形式的范围
+	DenyIPs   []string `json:"deny_ips"`   // IP 黑名单
+	AllowURLs []string `json:"allow_urls"` // URL 白名单正则
+	URL       []string `json:"url"`        // 以下均为黑名单正则
+	Args      []string `json:"args"`
+	UserAgent []string `json:"user_agent"`
+	Referer   []string `json:"referer"`
+	Cookie    []string `json:"cookie"`
+	Post      []string `json:"post"`
+}
+
+// Validate 校验网站规则
+func (r Rules) Validate() error {
+	for _, ip := range append(append([]string{}, r.AllowIPs...), r.DenyIPs...) {
+		if _, err := ipFamily(ip); err != nil {
+			return err
+		}
+	}
+	for _, patterns := range [][]string{r.AllowURLs, r.URL, r.Args, r.UserAgent, r.Referer, r.Cookie, r.Post} {
+		for _, pattern := range patterns {
+			if err := validatePattern(pattern); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Empty 是否没有任何网站规则
+func (r Rules) Empty() bool {
+	return len(r.AllowIPs)+len(r.DenyIPs)+len(r.AllowURLs)+len(r.URL)+len(r.Args)+
+		len(r.UserAgent)+len(r.Referer)+len(r.Cookie)+len(r.Post) == 0
+}
+
+// ValidateFile 校验规则文件，正则的语法由 OpenResty 检查配置时校验
+func ValidateFile(name, content string) error {
+	valid := false
+	for _, file := range Files {
+		valid = valid || file == name
+	}
+	if !valid {
+		return errors.New("规则文件 " + name + " 不存在")
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if strings.HasSuffix(name, "ipv4") || strings.HasSuffix(name, "ipv6") {
+			family, err := ipFamily(line)
+			if err != nil {
+				return err
+			}
+			if !strings.HasSuffix(name, family) {
+				return errors.New("IP " + line + " 不能写入 " + name)
+			}
+			continue
+		}
+		if err := validatePattern(line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Merge 将网站规则追加到全局规则文件后，返回网站规则目录中各文件的内容
+func Merge(global map[string]string, rules Rules) map[string]string {
+	site := make(map[string][]string)
+	for _, ip := range rules.DenyIPs {
+		family, _ := ipFamily(ip)
+		site[family] = append(site[family], ip)
+	}
+	for _, ip := range rules.AllowIPs {
+		family, _ := ipFamily(ip)
+		site["white-"+family] = append(site["white-"+family], ip)
+	}
+	site["white-url"] = rules.AllowURLs
+	site["url"] = rules.URL
+	site["args"] = rules.Args
+	site["user-agent"] = rules.UserAgent
+	site["referer"] = rules.Referer
+	site["cookie"] = rules.Cookie
+	site["post"] = rules.Post
+
+	files := make(map[string]string)
+	for _, name := range Files {
+		var sb strings.Builder
+		for _, line := range strings.Split(global[name], "\n") {
+			if line = strings.TrimSpace(line); len(line) > 0 {
+				sb.WriteString(line + "\n")
+			}
+		}
+		for _, line := range site[name] {
+			sb.WriteString(strings.TrimSpace(line) + "\n")
+		}
+		files[name] = sb.String()
+	}
+
+	return files
+}
+
+// Write 更新网站配置文件 waf 标记位中的规则目录和拦截日志
+func Write(conf, rulePath, logFile string) (string, error) {
+	begin, end := "# waf标记位开始", "# waf标记位结束"
+	if !strings.Contains(conf, begin) || !strings.Contains(conf, end) {
+		return "", errors.New("配置文件中缺少waf标记位")
+	}
+
+	old := tools.Cut(conf, begin, end)
+	block := rulePathLine.ReplaceAllString(old, "")
+	block = accessLogLine.ReplaceAllString(block, "")
+	block = strings.TrimRight(block, " \t") +
+		"    waf_rule_path " + rulePath + ";\n" +
+		"    access_log " + logFile + " waf_block if=$waf_blocking_log;\n    "
+
+	return strings.Replace(conf, begin+old+end, begin+block+end, 1), nil
+}
+
+// ipFamily 校验 IP、CIDR 或 IP 范围，返回 ipv4 或 ipv6
+func ipFamily(ip string) (string, error) {
+	invalid := errors.New("IP " + ip + " 不合法")
+	var ips []net.IP
+	if strings.Contains(ip, "/") {
+		parsed, _, err := net.ParseCIDR(ip)
+		if err != nil {
+			return "", invalid
+		}
+		ips = append(ips, parsed)
+	} else if start, end, ok := strings.Cut(ip, "-"); ok {
+		ips = append(ips, net.ParseIP(start), net.ParseIP(end))
+	} else {
+		ips = append(ips, net.ParseIP(ip))
+	}
+
+	family := ""
+	for _, parsed := range ips {
+		if parsed == nil {
+			return "", invalid
+		}
+		current := "ipv6"
+		if parsed.To4() != nil {
+			current = "ipv4"
+		}
+		if len(family) > 0 && family != current {
+			return "", invalid
+		}
+		family = current
+	}
+
+	return family, nil
+}
+
+// validatePattern 规则文件每行一条正则
+func validatePattern(pattern string) error {
+	if len(strings.TrimSpace(pattern)) == 0 || strings.ContainsAny(pattern, "\r\n") {
+		return errors.New("规则 " + pattern + " 不合法")
+	}
+	if len(pattern) > 1024 {
+		return errors.New("规则长度不能超过 1024")
+	}
+
+	return nil
+}
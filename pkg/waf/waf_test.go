@@ -0,0 +1,121 @@
+package waf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type WafTestSuite struct {
+	suite.Suite
+}
+
+func TestWafTestSuite(t *testing.T) {
+	suite.Run(t, &WafTestSuite{})
+}
+
+func (s *WafTestSuite) TestValidate() {
+	s.NoError(Rules{
+		AllowIPs: []string{"127.0.0.1", "10.0.0.0/8", "::1"},
+		DenyIPs:  []string{"1.1.1.1-1.1.1.9", "2001:db8::/32"},
+		URL:      []string{`(?i)\.(bak|sql)$`},
+	}.Validate())
+
+	s.Error(Rules{DenyIPs: []string{"1.1.1"}}.Validate())
+	s.Error(Rules{DenyIPs: []string{"1.1.1.1-::1"}}.Validate())
+	s.Error(Rules{AllowIPs: []string{"10.0.0.0/33"}}.Validate())
+	s.Error(Rules{URL: []string{" "}}.Validate())
+	s.Error(Rules{Args: []string{"a\nb"}}.Validate())
+	s.Error(Rules{Post: []string{strings.Repeat("a", 1025)}}.Validate())
+
+	s.True(Rules{}.Empty())
+	s.False(Rules{Cookie: []string{"a"}}.Empty())
+}
+
+func (s *WafTestSuite) TestValidateFile() {
+	s.NoError(ValidateFile("ipv4", "1.1.1.1\n\n10.0.0.0/8\n"))
+	s.NoError(ValidateFile("white-ipv6", "::1\n"))
+	s.NoError(ValidateFile("user-agent", "(?i)sqlmap\n"))
+	s.Error(ValidateFile("ipv4", "::1\n"))
+	s.Error(ValidateFile("white-ipv6", "127.0.0.1\n"))
+	s.Error(ValidateFile("ipv4", "abc\n"))
+	s.Error(ValidateFile("../ipv4", ""))
+}
+
+func (s *WafTestSuite) TestMerge() {
+	files := Merge(map[string]string{
+		"ipv4":       "1.1.1.1\n\n",
+		"user-agent": "(?i)sqlmap",
+	}, Rules{
+		AllowIPs:  []string{"127.0.0.1", "::1"},
+		DenyIPs:   []string{"2.2.2.2", "2001:db8::/32"},
+		AllowURLs: []string{"^/api/"},
+		UserAgent: []string{"(?i)curl"},
+	})
+
+	s.Len(files, len(Files))
+	s.Equal("1.1.1.1\n2.2.2.2\n", files["ipv4"])
+	s.Equal("2001:db8::/32\n", files["ipv6"])
+	s.Equal("127.0.0.1\n", files["white-ipv4"])
+	s.Equal("::1\n", files["white-ipv6"])
+	s.Equal("^/api/\n", files["white-url"])
+	s.Equal("(?i)sqlmap\n(?i)curl\n", files["user-agent"])
+	s.Equal("", files["cookie"])
+}
+
+func (s *WafTestSuite) TestWrite() {
+	conf := `server
+{
+    # waf标记位开始
+    waf on;
+    waf_rule_path /www/server/openresty/ngx_waf/assets/rules/;
+    waf_mode DYNAMIC;
+    waf_cache capacity=50;
+    # waf标记位结束
+}
+`
+	expected := `server
+{
+    # waf标记位开始
+    waf on;
+    waf_mode DYNAMIC;
+    waf_cache capacity=50;
+    waf_rule_path /www/server/vhost/waf/a.com/;
+    access_log /www/wwwlogs/waf/a.com.log waf_block if=$waf_blocking_log;
+    # waf标记位结束
+}
+`
+	conf, err := Write(conf, "/www/server/vhost/waf/a.com/", "/www/wwwlogs/waf/a.com.log")
+	s.NoError(err)
+	s.Equal(expected, conf)
+
+	conf, err = Write(conf, "/www/server/vhost/waf/a.com/", "/www/wwwlogs/waf/a.com.log")
+	s.NoError(err)
+	s.Equal(expected, conf)
+
+	_, err = Write("server {}", GlobalRulePath, "/www/wwwlogs/waf/a.com.log")
+	s.Error(err)
+}
+
+func (s *WafTestSuite) TestSummarize() {
+	log := `{"time":"2024-01-01T00:00:01+08:00","ip":"1.1.1.1","type":"BLACK-URL","rule":"\\.sql$","method":"GET","uri":"/a.sql","user_agent":"curl"}
+{"time":"2024-01-01T00:00:02+08:00","ip":"1.1.1.1","type":"BLACK-URL","rule":"\\.sql$","method":"GET","uri":"/b.sql","user_agent":"curl"}
+not json
+{"time":"2024-01-01T00:00:03+08:00","ip":"2.2.2.2","type":"CC-DENY","rule":"","method":"GET","uri":"/","user_agent":""}
+{"time":"2024-01-01T00:00:04+08:00","ip":"","type":"","rule":""}
+`
+	summary, err := Summarize(strings.NewReader(log), 2, 1)
+	s.NoError(err)
+	s.Equal(3, summary.Total)
+	s.Equal([]Count{{Key: `\.sql$`, Type: "BLACK-URL", Count: 2}}, summary.Rules)
+	s.Equal([]Count{{Key: "1.1.1.1", Count: 2}}, summary.IPs)
+	s.Len(summary.Events, 2)
+	s.Equal("2.2.2.2", summary.Events[0].IP)
+	s.Equal("/b.sql", summary.Events[1].URI)
+
+	summary, err = Summarize(strings.NewReader(strings.Repeat("a", maxLine+10)+"\n"+log), 10, 10)
+	s.NoError(err)
+	s.Equal(3, summary.Total)
+	s.Len(summary.IPs, 2)
+}
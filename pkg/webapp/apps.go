@@ -0,0 +1,191 @@
+// Package webapp 网站一键部署的 PHP 应用
+package webapp
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+const (
+	TypeComposer = "composer" // 通过 composer create-project 创建
+	TypeArchive  = "archive"  // 下载压缩包解压
+
+	ConfigLaravel   = "laravel"   // 写入 .env
+	ConfigWordPress = "wordpress" // 由 wp-config-sample.php 生成 wp-config.php
+)
+
+var (
+	packagePattern = regexp.MustCompile(`^[a-z0-9]([_.-]?[a-z0-9]+)*/[a-z0-9](([_.]|-{1,2})?[a-z0-9]+)*$`)
+	versionPattern = regexp.MustCompile(`^[a-zA-Z0-9.*^~|@ <>=,-]+$`)
+	dirPattern     = regexp.MustCompile(`^[a-zA-Z0-9_-]+(/[a-zA-Z0-9_-]+)*$`)
+)
+
+// App 可部署的应用
+type App struct {
+	Slug        string   `json:"slug"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Type        string   `json:"type"`
+	Package     string   `json:"package,omitempty"`  // composer 包名
+	Version     string   `json:"version,omitempty"`  // composer 版本约束，为空时安装最新稳定版
+	URL         string   `json:"url,omitempty"`      // 压缩包下载地址，仅支持 tar.gz
+	Checksum    string   `json:"checksum,omitempty"` // 压缩包官方发布的 SHA-1 或 SHA-256 校验值地址
+	Dir         string   `json:"dir,omitempty"`      // 压缩包内的应用目录
+	Root        string   `json:"root"`               // 相对网站目录的运行目录，为空时为网站目录
+	Writable    []string `json:"writable"`           // 需要写权限的目录
+	Rewrite     string   `json:"rewrite"`
+	MinPhp      int      `json:"min_php"`
+	Extensions  []string `json:"extensions"` // 依赖的 PHP 扩展
+	Config      string   `json:"config,omitempty"`
+	Databases   []string `json:"databases"` // 支持自动配置的数据库类型
+}
+
+var apps = []App{
+	{
+		Slug:        "laravel",
+		Name:        "Laravel",
+		Description: "Laravel 是一个优雅的 PHP Web 应用框架。",
+		Type:        TypeComposer,
+		Package:     "laravel/laravel",
+		Root:        "public",
+		Writable:    []string{"storage", "bootstrap/cache"},
+		Rewrite: `location / {
+    try_files $uri $uri/ /index.php?$query_string;
+}
+`,
+		MinPhp:     80,
+		Extensions: []string{"ctype", "curl", "fileinfo", "mbstring", "openssl", "pdo", "tokenizer", "xml"},
+		Config:     ConfigLaravel,
+		Databases:  []string{"mysql", "postgresql"},
+	},
+	{
+		Slug:        "symfony",
+		Name:        "Symfony",
+		Description: "Symfony 是一组可复用的 PHP 组件及 Web 应用框架。",
+		Type:        TypeComposer,
+		Package:     "symfony/skeleton",
+		Root:        "public",
+		Writable:    []string{"var"},
+		Rewrite: `location / {
+    try_files $uri /index.php$is_args$args;
+}
+`,
+		MinPhp:     81,
+		Extensions: []string{"ctype", "iconv"},
+	},
+	{
+		Slug:        "thinkphp",
+		Name:        "ThinkPHP",
+		Description: "ThinkPHP 是一个免费开源的快速、简单的面向对象的轻量级 PHP 开发框架。",
+		Type:        TypeComposer,
+		Package:     "topthink/think",
+		Root:        "public",
+		Writable:    []string{"runtime"},
+		Rewrite: `location / {
+    if (!-e $request_filename) {
+        rewrite ^(.*)$ /index.php?s=$1 last;
+        break;
+    }
+}
+`,
+		MinPhp:     74,
+		Extensions: []string{"mbstring", "pdo"},
+	},
+	{
+		Slug:        "wordpress",
+		Name:        "WordPress",
+		Description: "WordPress 是使用 PHP 开发的博客平台，也可以用作内容管理系统。",
+		Type:        TypeArchive,
+		URL:         "https://cn.wordpress.org/latest-zh_CN.tar.gz",
+		Checksum:    "https://cn.wordpress.org/latest-zh_CN.tar.gz.sha1",
+		Dir:         "wordpress",
+		Writable:    []string{"wp-content"},
+		Rewrite: `location / {
+    try_files $uri $uri/ /index.php?$args;
+}
+rewrite /wp-admin$ $scheme://$host$uri/ permanent;
+`,
+		MinPhp:     74,
+		Extensions: []string{"mysqli", "curl", "gd", "mbstring", "openssl", "xml", "zip"},
+		Config:     ConfigWordPress,
+		Databases:  []string{"mysql"},
+	},
+}
+
+// Apps 获取可部署的应用
+func Apps() []App {
+	return append([]App{}, apps...)
+}
+
+// Find 查找应用
+func Find(slug string) (App, bool) {
+	for _, app := range apps {
+		if app.Slug == slug {
+			return app, true
+		}
+	}
+
+	return App{}, false
+}
+
+// Composer 生成自定义 composer 包的应用
+func Composer(pkg, version, root string, writable []string) App {
+	return App{
+		Slug:     "composer",
+		Name:     pkg,
+		Type:     TypeComposer,
+		Package:  pkg,
+		Version:  version,
+		Root:     root,
+		Writable: writable,
+		Rewrite: `location / {
+    try_files $uri $uri/ /index.php?$query_string;
+}
+`,
+	}
+}
+
+// Validate 校验应用配置
+func (a App) Validate() error {
+	switch a.Type {
+	case TypeComposer:
+		if !packagePattern.MatchString(a.Package) {
+			return errors.New("composer 包名不合法")
+		}
+		if len(a.Version) > 0 && !versionPattern.MatchString(a.Version) {
+			return errors.New("composer 版本约束不合法")
+		}
+	case TypeArchive:
+		if !strings.HasPrefix(a.URL, "https://") || strings.ContainsAny(a.URL, "'\" \n") {
+			return errors.New("下载地址不合法")
+		}
+		if !strings.HasPrefix(a.Checksum, "https://") || strings.ContainsAny(a.Checksum, "'\" \n") {
+			return errors.New("校验值地址不合法")
+		}
+		if len(a.Dir) > 0 && !dirPattern.MatchString(a.Dir) {
+			return errors.New("压缩包目录不合法")
+		}
+	default:
+		return errors.New("不支持的应用类型")
+	}
+
+	for _, dir := range append([]string{a.Root}, a.Writable...) {
+		if len(dir) > 0 && !dirPattern.MatchString(dir) {
+			return errors.New("目录 " + dir + " 不合法")
+		}
+	}
+
+	return nil
+}
+
+// SupportDatabase 应用是否支持自动配置该类型的数据库
+func (a App) SupportDatabase(dbType string) bool {
+	for _, item := range a.Databases {
+		if item == dbType {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,254 @@
+package webapp
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"panel/pkg/shell"
+)
+
+// wordPressKeys wp-config.php 中的密钥
+var wordPressKeys = []string{"AUTH_KEY", "SECURE_AUTH_KEY", "LOGGED_IN_KEY", "NONCE_KEY", "AUTH_SALT", "SECURE_AUTH_SALT", "LOGGED_IN_SALT", "NONCE_SALT"}
+
+// Database 应用使用的数据库，Type 为空时不配置数据库
+type Database struct {
+	Type     string // mysql 或 postgresql
+	Name     string
+	User     string
+	Password string
+}
+
+// Options 部署参数
+type Options struct {
+	Path          string // 网站目录
+	Php           int    // PHP 版本，如 82
+	Owner         string // 网站文件所有者，用户组固定为 www
+	Vhost         string // 网站配置文件，部署成功后修改其中的运行目录，为空时不修改
+	Rewrite       string // 网站伪静态配置文件，部署成功后写入应用的伪静态规则
+	MysqlPassword string // MySQL root 密码，用于创建 MySQL 数据库
+	Database      Database
+}
+
+// Validate 校验部署参数
+func (o Options) Validate() error {
+	if !shell.ValidPath(o.Path) {
+		return errors.New("网站目录不合法")
+	}
+	if o.Php <= 0 {
+		return errors.New("请先为网站选择 PHP 版本")
+	}
+	if !shell.NamePattern.MatchString(o.Owner) {
+		return errors.New("网站文件所有者不合法")
+	}
+	if len(o.Vhost) > 0 && (!shell.ValidPath(o.Vhost) || !shell.ValidPath(o.Rewrite)) {
+		return errors.New("网站配置文件路径不合法")
+	}
+	if len(o.Database.Type) == 0 {
+		return nil
+	}
+	if o.Database.Type != "mysql" && o.Database.Type != "postgresql" {
+		return errors.New("不支持的数据库类型")
+	}
+	if !shell.NamePattern.MatchString(o.Database.Name) || !shell.NamePattern.MatchString(o.Database.User) {
+		return errors.New("数据库名或用户名不合法")
+	}
+	if !shell.PasswordPattern.MatchString(o.Database.Password) {
+		return errors.New("数据库密码只能包含字母、数字和 _@#%+=.,:~!^*()- 符号")
+	}
+
+	return nil
+}
+
+// Script 生成部署应用的 bash 脚本，应用会先安装到临时目录再复制到网站目录
+func Script(app App, opts Options) (string, error) {
+	if err := app.Validate(); err != nil {
+		return "", err
+	}
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+	if len(opts.Database.Type) > 0 && !app.SupportDatabase(opts.Database.Type) {
+		return "", errors.New(app.Name + " 不支持自动配置该类型的数据库")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(shell.Header)
+	sb.WriteString("appName=" + shell.Quote(app.Name) + "\n")
+	sb.WriteString("sitePath=" + shell.Quote(opts.Path) + "\n")
+	sb.WriteString(fmt.Sprintf("php=/www/server/php/%d/bin/php\n", opts.Php))
+	sb.WriteString(`tmpPath=$(mktemp -d /tmp/panel-app-XXXXXX)
+
+set -e
+trap 'echo -e $HR; echo "${appName} 部署失败"' ERR
+trap 'rm -rf "${tmpPath}"' EXIT
+
+echo "开始部署 ${appName}"
+`)
+
+	switch app.Type {
+	case TypeComposer:
+		sb.WriteString(`if [ ! -f /usr/local/bin/composer ]; then
+    wget -T 60 -t 3 -O "${tmpPath}/composer.phar" https://getcomposer.org/download/latest-stable/composer.phar
+    wget -T 20 -t 3 -O "${tmpPath}/composer.phar.sha256sum" https://getcomposer.org/download/latest-stable/composer.phar.sha256sum
+    if ! (cd "${tmpPath}" && sha256sum --status -c composer.phar.sha256sum); then
+        echo -e $HR
+        echo "错误：composer checksum 校验失败，文件可能被篡改或不完整，已终止操作"
+        exit 1
+    fi
+    install -m 755 "${tmpPath}/composer.phar" /usr/local/bin/composer
+fi
+export COMPOSER_ALLOW_SUPERUSER=1
+export COMPOSER_HOME=/tmp/composer
+`)
+		command := `${php} /usr/local/bin/composer create-project --no-interaction --prefer-dist ` + shell.Quote(app.Package) + ` "${tmpPath}/app"`
+		if len(app.Version) > 0 {
+			command += " " + shell.Quote(app.Version)
+		}
+		sb.WriteString(command + "\n")
+		sb.WriteString(`srcPath="${tmpPath}/app"` + "\n")
+	case TypeArchive:
+		sb.WriteString(`wget -T 120 -t 3 -O "${tmpPath}/app.tar.gz" ` + shell.Quote(app.URL) + "\n")
+		sb.WriteString(`checksum=$(wget -T 20 -t 3 -qO- ` + shell.Quote(app.Checksum) + ` | awk '{print $1}')
+case ${#checksum} in
+    40) checksumCommand=sha1sum ;;
+    64) checksumCommand=sha256sum ;;
+    *) checksumCommand="" ;;
+esac
+if [ -z "${checksumCommand}" ] || ! echo "${checksum}  ${tmpPath}/app.tar.gz" | ${checksumCommand} --status -c; then
+    echo -e $HR
+    echo "错误：${appName} checksum 校验失败，文件可能被篡改或不完整，已终止操作"
+    exit 1
+fi
+`)
+		sb.WriteString(`tar -zxf "${tmpPath}/app.tar.gz" -C "${tmpPath}"` + "\n")
+		sb.WriteString(`rm -f "${tmpPath}/app.tar.gz"` + "\n")
+		sb.WriteString(`srcPath="${tmpPath}/` + app.Dir + `"` + "\n")
+	}
+
+	sb.WriteString(`
+# 删除面板创建的默认页面后复制到网站目录
+rm -f "${sitePath}/index.html"
+cp -a "${srcPath}/." "${sitePath}/"
+cd "${sitePath}"
+`)
+	if len(app.Root) > 0 {
+		sb.WriteString(`# 防跨站配置随运行目录迁移
+if [ -f .user.ini ] && [ ! -f ` + app.Root + `/.user.ini ]; then
+    cp .user.ini ` + app.Root + `/.user.ini
+fi
+`)
+	}
+
+	if len(opts.Database.Type) > 0 {
+		writeCreateDatabase(&sb, opts)
+		switch app.Config {
+		case ConfigLaravel:
+			writeLaravelConfig(&sb, opts.Database)
+		case ConfigWordPress:
+			if err := writeWordPressConfig(&sb, opts.Database); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	sb.WriteString("\n# 设置权限\n")
+	sb.WriteString(`chown -R ` + opts.Owner + `:www "${sitePath}"` + "\n")
+	for _, dir := range app.Writable {
+		sb.WriteString(`if [ -d ` + dir + ` ]; then chmod -R ug+rwX ` + dir + `; fi` + "\n")
+	}
+	if len(opts.Vhost) > 0 {
+		writeVhost(&sb, app, opts)
+	}
+	sb.WriteString(`
+echo -e $HR
+echo "${appName} 部署成功"
+`)
+
+	return sb.String(), nil
+}
+
+// writeCreateDatabase 创建应用使用的数据库及用户
+func writeCreateDatabase(sb *strings.Builder, opts Options) {
+	db := opts.Database
+	sb.WriteString("\n# 创建数据库\n")
+	switch db.Type {
+	case "mysql":
+		sb.WriteString(`MYSQL_PWD=` + shell.Quote(opts.MysqlPassword) + ` /www/server/mysql/bin/mysql -uroot -e "CREATE DATABASE IF NOT EXISTS ` + db.Name + ` DEFAULT CHARSET utf8mb4 COLLATE utf8mb4_general_ci; CREATE USER IF NOT EXISTS '` + db.User + `'@'localhost' IDENTIFIED BY '` + db.Password + `'; GRANT ALL PRIVILEGES ON ` + db.Name + `.* TO '` + db.User + `'@'localhost'; FLUSH PRIVILEGES;"` + "\n")
+	case "postgresql":
+		sb.WriteString(`su - postgres -c "psql" <<'EOF'
+CREATE DATABASE ` + db.Name + `;
+CREATE USER ` + db.User + ` WITH PASSWORD '` + db.Password + `';
+ALTER DATABASE ` + db.Name + ` OWNER TO ` + db.User + `;
+GRANT ALL PRIVILEGES ON DATABASE ` + db.Name + ` TO ` + db.User + `;
+EOF
+echo "host    ` + db.Name + `    ` + db.User + `    127.0.0.1/32    scram-sha-256" >> /www/server/postgresql/data/pg_hba.conf
+systemctl reload postgresql
+`)
+	}
+}
+
+// writeVhost 文件部署成功后再切换运行目录和伪静态，部署失败时网站保持原样
+func writeVhost(sb *strings.Builder, app App, opts Options) {
+	root := strings.TrimSuffix(opts.Path, "/")
+	if len(app.Root) > 0 {
+		root += "/" + app.Root
+	}
+	sb.WriteString("\n# 设置运行目录和伪静态\n")
+	sb.WriteString(`sed -i '/# root标记位开始/,/# root标记位结束/s|root .*;|root ` + root + `;|' ` + shell.Quote(opts.Vhost) + "\n")
+	sb.WriteString(`cat > ` + shell.Quote(opts.Rewrite) + ` <<'EOF'
+` + app.Rewrite + `
+EOF
+systemctl reload openresty
+`)
+}
+
+// writeLaravelConfig 写入 .env 中的数据库配置并执行迁移
+func writeLaravelConfig(sb *strings.Builder, db Database) {
+	connection, port := "mysql", "3306"
+	if db.Type == "postgresql" {
+		connection, port = "pgsql", "5432"
+	}
+	values := [][2]string{
+		{"DB_CONNECTION", connection},
+		{"DB_HOST", "127.0.0.1"},
+		{"DB_PORT", port},
+		{"DB_DATABASE", db.Name},
+		{"DB_USERNAME", db.User},
+		{"DB_PASSWORD", db.Password},
+	}
+
+	sb.WriteString("\n# 配置数据库\n")
+	for _, value := range values {
+		// 新版 Laravel 的 .env 中部分数据库配置默认被注释
+		sb.WriteString(`sed -i -E 's|^#? ?` + value[0] + `=.*|` + value[0] + `=` + value[1] + `|' .env` + "\n")
+	}
+	sb.WriteString(`${php} artisan migrate --force || echo "数据库迁移失败，请检查数据库配置后手动执行 php artisan migrate"` + "\n")
+}
+
+// writeWordPressConfig 由 wp-config-sample.php 生成 wp-config.php
+func writeWordPressConfig(sb *strings.Builder, db Database) error {
+	sb.WriteString("\n# 配置数据库\n")
+	sb.WriteString("cp wp-config-sample.php wp-config.php\n")
+	sb.WriteString(`sed -i "s|database_name_here|` + db.Name + `|; s|username_here|` + db.User + `|; s|password_here|` + db.Password + `|; s|'localhost'|'127.0.0.1'|" wp-config.php` + "\n")
+	for _, key := range wordPressKeys {
+		secret, err := randomHex(32)
+		if err != nil {
+			return err
+		}
+		sb.WriteString(`sed -i "s|define( *'` + key + `', *'put your unique phrase here' *);|define( '` + key + `', '` + secret + `' );|" wp-config.php` + "\n")
+	}
+
+	return nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
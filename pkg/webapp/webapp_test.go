@@ -0,0 +1,109 @@
+package webapp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type WebAppTestSuite struct {
+	suite.Suite
+}
+
+func TestWebAppTestSuite(t *testing.T) {
+	suite.Run(t, &WebAppTestSuite{})
+}
+
+func (s *WebAppTestSuite) TestApps() {
+	for _, app := range Apps() {
+		s.NoError(app.Validate(), app.Slug)
+		found, ok := Find(app.Slug)
+		s.True(ok)
+		s.Equal(app.Name, found.Name)
+	}
+
+	_, ok := Find("missing")
+	s.False(ok)
+}
+
+func (s *WebAppTestSuite) TestValidate() {
+	s.NoError(Composer("laravel/lumen", "^10.0", "public", []string{"storage"}).Validate())
+	s.Error(Composer("laravel", "", "public", nil).Validate())
+	s.Error(Composer("laravel/lumen'; id; '", "", "public", nil).Validate())
+	s.Error(Composer("laravel/lumen", "$(id)", "public", nil).Validate())
+	s.Error(Composer("laravel/lumen", "", "../public", nil).Validate())
+	s.Error(Composer("laravel/lumen", "", "public", []string{"/etc"}).Validate())
+
+	opts := Options{Path: "/www/wwwroot/example.com", Php: 82, Owner: "www"}
+	s.NoError(opts.Validate())
+	opts.Database = Database{Type: "mysql", Name: "example", User: "example", Password: "pa$$word"}
+	s.Error(opts.Validate())
+	opts.Database.Password = "Passw0rd!"
+	s.NoError(opts.Validate())
+	opts.Vhost = "/www/server/vhost/example.com.conf"
+	s.Error(opts.Validate())
+	opts.Rewrite = "/www/server/vhost/rewrite/example.com.conf"
+	s.NoError(opts.Validate())
+	opts.Path = "/www/wwwroot/../etc"
+	s.Error(opts.Validate())
+}
+
+func (s *WebAppTestSuite) TestScript() {
+	laravel, _ := Find("laravel")
+	script, err := Script(laravel, Options{
+		Path:     "/www/wwwroot/example.com",
+		Php:      82,
+		Owner:    "site_example",
+		Vhost:    "/www/server/vhost/example.com.conf",
+		Rewrite:  "/www/server/vhost/rewrite/example.com.conf",
+		Database: Database{Type: "postgresql", Name: "example", User: "example_user", Password: "Passw0rd!"},
+	})
+	s.NoError(err)
+	s.Contains(script, "CREATE USER example_user WITH PASSWORD 'Passw0rd!';")
+	s.Contains(script, `sed -i '/# root标记位开始/,/# root标记位结束/s|root .*;|root /www/wwwroot/example.com/public;|' '/www/server/vhost/example.com.conf'`)
+	s.Contains(script, "cat > '/www/server/vhost/rewrite/example.com.conf' <<'EOF'\n"+laravel.Rewrite)
+	// 下载和复制成功后才创建数据库和切换运行目录
+	s.Less(strings.Index(script, `cp -a "${srcPath}/." "${sitePath}/"`), strings.Index(script, "CREATE DATABASE"))
+	s.Less(strings.Index(script, "chmod -R ug+rwX bootstrap/cache"), strings.Index(script, "root标记位开始"))
+	s.Contains(script, "php=/www/server/php/82/bin/php\n")
+	s.Contains(script, `${php} /usr/local/bin/composer create-project --no-interaction --prefer-dist 'laravel/laravel' "${tmpPath}/app"`+"\n")
+	s.Contains(script, `sha256sum --status -c composer.phar.sha256sum`)
+	s.Less(strings.Index(script, "sha256sum --status"), strings.Index(script, "install -m 755"))
+	s.Contains(script, "cp .user.ini public/.user.ini")
+	s.Contains(script, `sed -i -E 's|^#? ?DB_CONNECTION=.*|DB_CONNECTION=pgsql|' .env`)
+	s.Contains(script, `sed -i -E 's|^#? ?DB_PASSWORD=.*|DB_PASSWORD=Passw0rd!|' .env`)
+	s.Contains(script, `chown -R site_example:www "${sitePath}"`)
+	s.Contains(script, "chmod -R ug+rwX bootstrap/cache")
+
+	wordpress, _ := Find("wordpress")
+	_, err = Script(wordpress, Options{
+		Path:     "/www/wwwroot/example.com",
+		Php:      82,
+		Owner:    "www",
+		Database: Database{Type: "postgresql", Name: "example", User: "example", Password: "Passw0rd"},
+	})
+	s.Error(err)
+
+	script, err = Script(wordpress, Options{
+		Path:     "/www/wwwroot/example.com",
+		Php:      74,
+		Owner:    "www",
+		Database: Database{Type: "mysql", Name: "example", User: "example", Password: "Passw0rd"},
+	})
+	s.NoError(err)
+	s.Contains(script, "CREATE USER IF NOT EXISTS 'example'@'localhost' IDENTIFIED BY 'Passw0rd';")
+	s.Contains(script, `srcPath="${tmpPath}/wordpress"`)
+	s.Contains(script, `wget -T 20 -t 3 -qO- 'https://cn.wordpress.org/latest-zh_CN.tar.gz.sha1'`)
+	s.Less(strings.Index(script, "--status -c"), strings.Index(script, "tar -zxf"))
+	s.Contains(script, "s|database_name_here|example|")
+	s.NotContains(script, "cp .user.ini")
+	s.Equal(len(wordPressKeys), strings.Count(script, "put your unique phrase here"))
+
+	// 未配置数据库时不修改配置文件
+	script, err = Script(Composer("symfony/skeleton", "6.4.*", "public", []string{"var"}), Options{Path: "/www/wwwroot/example.com", Php: 83, Owner: "www"})
+	s.NoError(err)
+	s.Contains(script, `"${tmpPath}/app" '6.4.*'`)
+	s.NotContains(script, ".env")
+	s.NotContains(script, "root标记位开始")
+}
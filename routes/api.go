@@ -42,20 +42,79 @@ func Api() {
 			r.Get("backupList", websiteController.BackupList)
 			r.Put("uploadBackup", websiteController.UploadBackup)
 			r.Delete("deleteBackup", websiteController.DeleteBackup)
+			r.Get("bundleList", websiteController.BundleList)
+			r.Put("uploadBundle", websiteController.UploadBundle)
+			r.Delete("deleteBundle", websiteController.DeleteBundle)
+			r.Post("inspectBundle", websiteController.InspectBundle)
+			r.Post("importBundle", websiteController.ImportBundle)
 		})
 		r.Prefix("websites").Middleware(middleware.Jwt(), middleware.MustInstall()).Group(func(r route.Router) {
 			websiteController := controllers.NewWebsiteController()
 			r.Get("/", websiteController.List)
 			r.Post("/", websiteController.Add)
+			r.Get("apps", websiteController.Apps)
+			r.Get("tlsProfiles", websiteController.TlsProfiles)
+			r.Post("extend", websiteController.Extend)
+			r.Post("suspend", websiteController.Suspend)
+			r.Post("resume", websiteController.Resume)
 			r.Delete("{id}", websiteController.Delete)
 			r.Get("{id}/config", websiteController.GetConfig)
 			r.Post("{id}/config", websiteController.SaveConfig)
+			r.Post("{id}/auth_users", websiteController.SaveAuthUser)
+			r.Delete("{id}/auth_users/{name}", websiteController.DeleteAuthUser)
 			r.Delete("{id}/log", websiteController.ClearLog)
 			r.Post("{id}/updateRemark", websiteController.UpdateRemark)
 			r.Post("{id}/createBackup", websiteController.CreateBackup)
 			r.Post("{id}/restoreBackup", websiteController.RestoreBackup)
 			r.Post("{id}/resetConfig", websiteController.ResetConfig)
 			r.Post("{id}/status", websiteController.Status)
+			r.Post("{id}/expiry", websiteController.SaveExpiry)
+			r.Get("{id}/stats", websiteController.Stats)
+			r.Get("{id}/pool", websiteController.GetPool)
+			r.Post("{id}/pool", websiteController.SavePool)
+			r.Delete("{id}/pool", websiteController.DeletePool)
+			r.Get("{id}/limit", websiteController.GetLimit)
+			r.Post("{id}/limit", websiteController.SaveLimit)
+			r.Delete("{id}/limit", websiteController.DeleteLimit)
+			r.Get("{id}/waf", websiteController.GetWaf)
+			r.Post("{id}/waf", websiteController.SaveWaf)
+			r.Get("{id}/waf/log", websiteController.WafLog)
+			r.Delete("{id}/waf/log", websiteController.ClearWafLog)
+			r.Get("{id}/country", websiteController.GetCountry)
+			r.Post("{id}/country", websiteController.SaveCountry)
+			r.Delete("{id}/country", websiteController.DeleteCountry)
+			r.Get("{id}/tls", websiteController.TlsReport)
+			r.Get("{id}/php", websiteController.GetPhpSettings)
+			r.Post("{id}/php", websiteController.SavePhpSettings)
+			r.Get("{id}/php/check", websiteController.CheckPhp)
+			r.Post("{id}/app", websiteController.InstallApp)
+			r.Post("{id}/export", websiteController.Export)
+
+			websiteDeployController := controllers.NewWebsiteDeployController()
+			r.Get("{id}/deploy", websiteDeployController.Show)
+			r.Post("{id}/deploy", websiteDeployController.Save)
+			r.Delete("{id}/deploy", websiteDeployController.Delete)
+			r.Post("{id}/deploy/run", websiteDeployController.Deploy)
+			r.Get("{id}/releases", websiteDeployController.Releases)
+			r.Post("{id}/releases/{release_id}/rollback", websiteDeployController.Rollback)
+
+			websiteCloneController := controllers.NewWebsiteCloneController()
+			r.Get("{id}/clones", websiteCloneController.List)
+			r.Post("{id}/clone", websiteCloneController.Clone)
+			r.Post("{id}/promote", websiteCloneController.Promote)
+		})
+		r.Prefix("webhooks").Group(func(r route.Router) {
+			websiteDeployController := controllers.NewWebsiteDeployController()
+			r.Post("deploy/{id}", websiteDeployController.Webhook)
+		})
+		r.Prefix("website_checks").Middleware(middleware.Jwt()).Group(func(r route.Router) {
+			websiteCheckController := controllers.NewWebsiteCheckController()
+			r.Get("/", websiteCheckController.List)
+			r.Post("/", websiteCheckController.Store)
+			r.Put("{id}", websiteCheckController.Update)
+			r.Delete("{id}", websiteCheckController.Destroy)
+			r.Get("{id}/results", websiteCheckController.Results)
+			r.Post("{id}/run", websiteCheckController.Run)
 		})
 		r.Prefix("cert").Middleware(middleware.Jwt()).Group(func(r route.Router) {
 			certController := controllers.NewCertController()
@@ -81,6 +140,14 @@ func Api() {
 			r.Post("renew", certController.Renew)
 			r.Post("manualDNS", certController.ManualDNS)
 		})
+		r.Prefix("stream").Middleware(middleware.Jwt()).Group(func(r route.Router) {
+			streamController := controllers.NewStreamController()
+			r.Get("proxies", streamController.ProxyList)
+			r.Post("proxies", streamController.ProxyStore)
+			r.Put("proxies/{id}", streamController.ProxyUpdate)
+			r.Get("proxies/{id}", streamController.ProxyShow)
+			r.Delete("proxies/{id}", streamController.ProxyDestroy)
+		})
 		r.Prefix("plugin").Middleware(middleware.Jwt()).Group(func(r route.Router) {
 			pluginController := controllers.NewPluginController()
 			r.Get("list", pluginController.List)
@@ -89,9 +156,37 @@ func Api() {
 			r.Post("update", pluginController.Update)
 			r.Post("updateShow", pluginController.UpdateShow)
 		})
+		r.Prefix("notification").Middleware(middleware.Jwt()).Group(func(r route.Router) {
+			notificationController := controllers.NewNotificationController()
+			r.Get("events", notificationController.Events)
+			r.Get("types", notificationController.Types)
+			r.Get("channels", notificationController.ChannelList)
+			r.Post("channels", notificationController.ChannelStore)
+			r.Put("channels/{id}", notificationController.ChannelUpdate)
+			r.Get("channels/{id}", notificationController.ChannelShow)
+			r.Delete("channels/{id}", notificationController.ChannelDestroy)
+			r.Post("channels/{id}/test", notificationController.ChannelTest)
+			r.Get("logs", notificationController.LogList)
+			r.Get("settings", notificationController.Settings)
+			r.Post("settings", notificationController.UpdateSettings)
+		})
+		r.Prefix("alert").Middleware(middleware.Jwt()).Group(func(r route.Router) {
+			alertController := controllers.NewAlertController()
+			r.Get("metrics", alertController.Metrics)
+			r.Get("rules", alertController.RuleList)
+			r.Post("rules", alertController.RuleStore)
+			r.Put("rules/{id}", alertController.RuleUpdate)
+			r.Delete("rules/{id}", alertController.RuleDestroy)
+			r.Get("silences", alertController.SilenceList)
+			r.Post("silences", alertController.SilenceStore)
+			r.Delete("silences/{id}", alertController.SilenceDestroy)
+		})
 		r.Prefix("cron").Middleware(middleware.Jwt()).Group(func(r route.Router) {
 			cronController := controllers.NewCronController()
 			r.Get("list", cronController.List)
+			r.Get("preview", cronController.Preview)
+			r.Get("drift", cronController.Drift)
+			r.Post("sync", cronController.Sync)
 			r.Get("{id}", cronController.Script)
 			r.Post("add", cronController.Add)
 			r.Put("{id}", cronController.Update)
@@ -112,6 +207,12 @@ func Api() {
 			r.Post("sshPort", safeController.SetSshPort)
 			r.Get("pingStatus", safeController.GetPingStatus)
 			r.Post("pingStatus", safeController.SetPingStatus)
+			r.Get("geoip", safeController.GetGeoIP)
+			r.Post("geoip", safeController.UploadGeoIP)
+			r.Post("geoipDownload", safeController.DownloadGeoIP)
+			r.Get("countryRules", safeController.GetCountryRules)
+			r.Post("countryRules", safeController.AddCountryRule)
+			r.Delete("countryRules", safeController.DeleteCountryRule)
 		})
 		r.Prefix("file").Middleware(middleware.Jwt()).Group(func(r route.Router) {
 			fileController := controllers.NewFileController()
@@ -137,6 +238,10 @@ func Api() {
 			r.Post("saveDays", monitorController.SaveDays)
 			r.Post("clear", monitorController.Clear)
 			r.Get("list", monitorController.List)
+			r.Get("query", monitorController.Query)
+			r.Get("processes", monitorController.Processes)
+			r.Get("services", monitorController.Services)
+			r.Get("service", monitorController.Service)
 			r.Get("switchAndDays", monitorController.SwitchAndDays)
 		})
 		r.Prefix("ssh").Middleware(middleware.Jwt()).Group(func(r route.Router) {
@@ -149,9 +254,15 @@ func Api() {
 			settingController := controllers.NewSettingController()
 			r.Get("list", settingController.List)
 			r.Post("update", settingController.Update)
+			r.Get("metricsToken", settingController.MetricsToken)
+			r.Post("metricsToken", settingController.UpdateMetricsToken)
 		})
 	})
 
+	// Prometheus 指标
+	metricsController := controllers.NewMetricsController()
+	facades.Route().Middleware(middleware.MetricsToken()).Get("metrics", metricsController.Index)
+
 	// 文档
 	swaggerController := controllers.NewSwaggerController()
 	facades.Route().Get("swagger", swaggerController.Index)
@@ -23,6 +23,8 @@ func Plugin() {
 			route.Post("config", openRestyController.SaveConfig)
 			route.Get("errorLog", openRestyController.ErrorLog)
 			route.Post("clearErrorLog", openRestyController.ClearErrorLog)
+			route.Get("wafRules", openRestyController.WafRules)
+			route.Post("wafRules", openRestyController.SaveWafRule)
 		})
 		r.Prefix("mysql57").Group(func(route route.Router) {
 			mysql57Controller := plugins.NewMysql57Controller()
@@ -146,15 +148,21 @@ func Plugin() {
 			route.Post("stop", php74Controller.Stop)
 			route.Post("restart", php74Controller.Restart)
 			route.Get("load", php74Controller.Load)
+			route.Get("fpmStatus", php74Controller.FpmStatus)
+			route.Get("fpmHistory", php74Controller.FpmHistory)
+			route.Get("slowRequests", php74Controller.SlowRequests)
 			route.Get("config", php74Controller.GetConfig)
 			route.Post("config", php74Controller.SaveConfig)
 			route.Get("errorLog", php74Controller.ErrorLog)
 			route.Get("slowLog", php74Controller.SlowLog)
 			route.Post("clearErrorLog", php74Controller.ClearErrorLog)
 			route.Post("clearSlowLog", php74Controller.ClearSlowLog)
+			route.Get("pools", php74Controller.Pools)
 			route.Get("extensions", php74Controller.GetExtensionList)
 			route.Post("extensions", php74Controller.InstallExtension)
 			route.Delete("extensions", php74Controller.UninstallExtension)
+			route.Post("extensions/pecl", php74Controller.InstallPecl)
+			route.Post("extensions/status", php74Controller.ExtensionStatus)
 		})
 		r.Prefix("php80").Group(func(route route.Router) {
 			php80Controller := plugins.NewPhp80Controller()
@@ -164,15 +172,21 @@ func Plugin() {
 			route.Post("stop", php80Controller.Stop)
 			route.Post("restart", php80Controller.Restart)
 			route.Get("load", php80Controller.Load)
+			route.Get("fpmStatus", php80Controller.FpmStatus)
+			route.Get("fpmHistory", php80Controller.FpmHistory)
+			route.Get("slowRequests", php80Controller.SlowRequests)
 			route.Get("config", php80Controller.GetConfig)
 			route.Post("config", php80Controller.SaveConfig)
 			route.Get("errorLog", php80Controller.ErrorLog)
 			route.Get("slowLog", php80Controller.SlowLog)
 			route.Post("clearErrorLog", php80Controller.ClearErrorLog)
 			route.Post("clearSlowLog", php80Controller.ClearSlowLog)
+			route.Get("pools", php80Controller.Pools)
 			route.Get("extensions", php80Controller.GetExtensionList)
 			route.Post("extensions", php80Controller.InstallExtension)
 			route.Delete("extensions", php80Controller.UninstallExtension)
+			route.Post("extensions/pecl", php80Controller.InstallPecl)
+			route.Post("extensions/status", php80Controller.ExtensionStatus)
 		})
 		r.Prefix("php81").Group(func(route route.Router) {
 			php81Controller := plugins.NewPhp81Controller()
@@ -182,15 +196,21 @@ func Plugin() {
 			route.Post("stop", php81Controller.Stop)
 			route.Post("restart", php81Controller.Restart)
 			route.Get("load", php81Controller.Load)
+			route.Get("fpmStatus", php81Controller.FpmStatus)
+			route.Get("fpmHistory", php81Controller.FpmHistory)
+			route.Get("slowRequests", php81Controller.SlowRequests)
 			route.Get("config", php81Controller.GetConfig)
 			route.Post("config", php81Controller.SaveConfig)
 			route.Get("errorLog", php81Controller.ErrorLog)
 			route.Get("slowLog", php81Controller.SlowLog)
 			route.Post("clearErrorLog", php81Controller.ClearErrorLog)
 			route.Post("clearSlowLog", php81Controller.ClearSlowLog)
+			route.Get("pools", php81Controller.Pools)
 			route.Get("extensions", php81Controller.GetExtensionList)
 			route.Post("extensions", php81Controller.InstallExtension)
 			route.Delete("extensions", php81Controller.UninstallExtension)
+			route.Post("extensions/pecl", php81Controller.InstallPecl)
+			route.Post("extensions/status", php81Controller.ExtensionStatus)
 		})
 		r.Prefix("php82").Group(func(route route.Router) {
 			php82Controller := plugins.NewPhp82Controller()
@@ -200,15 +220,21 @@ func Plugin() {
 			route.Post("stop", php82Controller.Stop)
 			route.Post("restart", php82Controller.Restart)
 			route.Get("load", php82Controller.Load)
+			route.Get("fpmStatus", php82Controller.FpmStatus)
+			route.Get("fpmHistory", php82Controller.FpmHistory)
+			route.Get("slowRequests", php82Controller.SlowRequests)
 			route.Get("config", php82Controller.GetConfig)
 			route.Post("config", php82Controller.SaveConfig)
 			route.Get("errorLog", php82Controller.ErrorLog)
 			route.Get("slowLog", php82Controller.SlowLog)
 			route.Post("clearErrorLog", php82Controller.ClearErrorLog)
 			route.Post("clearSlowLog", php82Controller.ClearSlowLog)
+			route.Get("pools", php82Controller.Pools)
 			route.Get("extensions", php82Controller.GetExtensionList)
 			route.Post("extensions", php82Controller.InstallExtension)
 			route.Delete("extensions", php82Controller.UninstallExtension)
+			route.Post("extensions/pecl", php82Controller.InstallPecl)
+			route.Post("extensions/status", php82Controller.ExtensionStatus)
 		})
 		r.Prefix("php83").Group(func(route route.Router) {
 			php83Controller := plugins.NewPhp83Controller()
@@ -218,15 +244,21 @@ func Plugin() {
 			route.Post("stop", php83Controller.Stop)
 			route.Post("restart", php83Controller.Restart)
 			route.Get("load", php83Controller.Load)
+			route.Get("fpmStatus", php83Controller.FpmStatus)
+			route.Get("fpmHistory", php83Controller.FpmHistory)
+			route.Get("slowRequests", php83Controller.SlowRequests)
 			route.Get("config", php83Controller.GetConfig)
 			route.Post("config", php83Controller.SaveConfig)
 			route.Get("errorLog", php83Controller.ErrorLog)
 			route.Get("slowLog", php83Controller.SlowLog)
 			route.Post("clearErrorLog", php83Controller.ClearErrorLog)
 			route.Post("clearSlowLog", php83Controller.ClearSlowLog)
+			route.Get("pools", php83Controller.Pools)
 			route.Get("extensions", php83Controller.GetExtensionList)
 			route.Post("extensions", php83Controller.InstallExtension)
 			route.Delete("extensions", php83Controller.UninstallExtension)
+			route.Post("extensions/pecl", php83Controller.InstallPecl)
+			route.Post("extensions/status", php83Controller.ExtensionStatus)
 		})
 		r.Prefix("phpmyadmin").Group(func(route route.Router) {
 			phpMyAdminController := plugins.NewPhpMyAdminController()
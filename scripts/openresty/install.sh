@@ -291,6 +291,9 @@ mkdir -p /www/server/vhost
 mkdir -p /www/server/vhost
 mkdir -p /www/server/vhost/rewrite
 mkdir -p /www/server/vhost/ssl
+mkdir -p /www/server/vhost/htpasswd
+mkdir -p /www/server/vhost/geo
+mkdir -p /www/server/vhost/stream/ssl
 
 # 写入主配置文件
 cat > ${openrestyPath}/conf/nginx.conf << EOF
@@ -314,10 +317,17 @@ events {
     multi_accept on;
 }
 
+stream {
+    include /www/server/vhost/stream/*.conf;
+}
+
 http {
     include mime.types;
     include proxy.conf;
     include default.conf;
+    include limit_zones.conf;
+    include waf_log.conf;
+    include /www/server/vhost/geo/*.conf;
     default_type application/octet-stream;
 
     server_names_hash_bucket_size 512;
@@ -383,6 +393,11 @@ http {
             include fastcgi_params;
             fastcgi_param SCRIPT_FILENAME \$fastcgi_script_name;
         }
+        location ~ ^/phpfpm_status/(?<version>\d+)/(?<pool>[a-zA-Z0-9._-]+)$ {
+            fastcgi_pass unix:/tmp/php-cgi-\$version-\$pool.sock;
+            include fastcgi_params;
+            fastcgi_param SCRIPT_FILENAME \$fastcgi_script_name;
+        }
     }
     include /www/server/vhost/*.conf;
 }
@@ -563,10 +578,20 @@ server
     server_name _;
     index index.html;
     root /www/server/openresty/html;
+    # 同一端口上各网站的协议版本以默认网站为准
+    ssl_protocols TLSv1.2 TLSv1.3;
     ssl_reject_handshake on;
 }
 EOF
 
+# 写入网站频率限制共享内存配置文件，由面板维护
+echo "" > ${openrestyPath}/conf/limit_zones.conf
+
+# 写入WAF拦截日志格式配置文件
+cat > ${openrestyPath}/conf/waf_log.conf << EOF
+log_format waf_block escape=json '{"time":"\$time_iso8601","ip":"\$remote_addr","type":"\$waf_rule_type","rule":"\$waf_rule_details","method":"\$request_method","uri":"\$request_uri","user_agent":"\$http_user_agent"}';
+EOF
+
 # 建立日志目录
 mkdir -p /www/wwwlogs/waf
 chown www:www /www/wwwlogs/waf
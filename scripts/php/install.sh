@@ -196,8 +196,13 @@ request_terminate_timeout = 100
 request_slowlog_timeout = 30
 pm.status_path = /phpfpm_status/${phpVersion}
 slowlog = var/log/slow.log
+
+include = ${phpPath}/etc/php-fpm.d/*.conf
 EOF
 
+# 网站独立进程池配置目录
+mkdir -p ${phpPath}/etc/php-fpm.d
+
 # 设置PHP进程数
 memTotal=$(free -m | grep Mem | awk '{print  $2}')
 if [[ ${memTotal} -gt 1024 && ${memTotal} -le 2048 ]]; then
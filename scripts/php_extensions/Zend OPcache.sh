@@ -25,7 +25,7 @@ phpVersion="$2" # PHP版本
 
 Install() {
     # 检查是否已经安装
-    isInstall=$(cat /www/server/php/${phpVersion}/etc/php.ini | grep '^zend_extension=opcache$')
+    isInstall=$(cat /www/server/php/${phpVersion}/etc/php.ini | grep -E '^;?zend_extension=opcache$')
     if [ "${isInstall}" != "" ]; then
         echo -e $HR
         echo "PHP-${phpVersion} 已安装 Zend OPcache"
@@ -45,7 +45,7 @@ Install() {
 
 Uninstall() {
     # 检查是否已经安装
-    isInstall=$(cat /www/server/php/${phpVersion}/etc/php.ini | grep '^zend_extension=opcache$')
+    isInstall=$(cat /www/server/php/${phpVersion}/etc/php.ini | grep -E '^;?zend_extension=opcache$')
     if [ "${isInstall}" == "" ]; then
         echo -e $HR
         echo "PHP-${phpVersion} 未安装 Zend OPcache"
@@ -25,7 +25,7 @@ phpVersion="$2" # PHP版本
 
 Install() {
     # 检查是否已经安装
-    isInstall=$(cat /www/server/php/${phpVersion}/etc/php.ini | grep '^extension=exif$')
+    isInstall=$(cat /www/server/php/${phpVersion}/etc/php.ini | grep -E '^;?extension=exif$')
     if [ "${isInstall}" != "" ]; then
         echo -e $HR
         echo "PHP-${phpVersion} 已安装 exif"
@@ -58,7 +58,7 @@ Install() {
 
 Uninstall() {
     # 检查是否已经安装
-    isInstall=$(cat /www/server/php/${phpVersion}/etc/php.ini | grep '^extension=exif$')
+    isInstall=$(cat /www/server/php/${phpVersion}/etc/php.ini | grep -E '^;?extension=exif$')
     if [ "${isInstall}" == "" ]; then
         echo -e $HR
         echo "PHP-${phpVersion} 未安装 exif"
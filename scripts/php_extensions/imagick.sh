@@ -28,7 +28,7 @@ imagickVersion="3.7.0"
 
 Install() {
     # 检查是否已经安装
-    isInstall=$(cat /www/server/php/${phpVersion}/etc/php.ini | grep '^extension=imagick$')
+    isInstall=$(cat /www/server/php/${phpVersion}/etc/php.ini | grep -E '^;?extension=imagick$')
     if [ "${isInstall}" != "" ]; then
         echo -e $HR
         echo "PHP-${phpVersion} 已安装 imagick"
@@ -88,7 +88,7 @@ Install() {
 
 Uninstall() {
     # 检查是否已经安装
-    isInstall=$(cat /www/server/php/${phpVersion}/etc/php.ini | grep '^extension=imagick$')
+    isInstall=$(cat /www/server/php/${phpVersion}/etc/php.ini | grep -E '^;?extension=imagick$')
     if [ "${isInstall}" == "" ]; then
         echo -e $HR
         echo "PHP-${phpVersion} 未安装 imagick"
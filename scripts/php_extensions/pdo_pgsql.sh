@@ -25,7 +25,7 @@ phpVersion="$2"
 
 Install() {
     # 检查是否已经安装
-    isInstall=$(cat /www/server/php/${phpVersion}/etc/php.ini | grep '^extension=pdo_pgsql$')
+    isInstall=$(cat /www/server/php/${phpVersion}/etc/php.ini | grep -E '^;?extension=pdo_pgsql$')
     if [ "${isInstall}" != "" ]; then
         echo -e $HR
         echo "PHP-${phpVersion} 已安装 pdo_pgsql"
@@ -58,7 +58,7 @@ Install() {
 
 Uninstall() {
     # 检查是否已经安装
-    isInstall=$(cat /www/server/php/${phpVersion}/etc/php.ini | grep '^extension=pdo_pgsql$')
+    isInstall=$(cat /www/server/php/${phpVersion}/etc/php.ini | grep -E '^;?extension=pdo_pgsql$')
     if [ "${isInstall}" == "" ]; then
         echo -e $HR
         echo "PHP-${phpVersion} 未安装 pdo_pgsql"
@@ -0,0 +1,151 @@
+#!/bin/bash
+export PATH=/bin:/sbin:/usr/bin:/usr/sbin:/usr/local/bin:/usr/local/sbin:$PATH
+
+: '
+Copyright (C) 2022 - now  HaoZi Technology Co., Ltd.
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published
+by the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+'
+
+HR="+----------------------------------------------------"
+
+# 用法：
+# pecl.sh install <PHP版本> <PECL包名> <包版本，为空时安装最新版> <扩展名> <是否为zend扩展 0|1> [编译选项...]
+# pecl.sh uninstall <PHP版本> <扩展名>
+action="$1"
+phpVersion="$2"
+phpPath="/www/server/php/${phpVersion}"
+
+Install() {
+    package="$1"
+    packageVersion="$2"
+    name="$3"
+    zend="$4"
+    shift 4
+
+    directive="extension"
+    if [ "${zend}" == "1" ]; then
+        directive="zend_extension"
+    fi
+
+    # 检查是否已经安装
+    isInstall=$(cat ${phpPath}/etc/php.ini | grep -E "^;?(zend_)?extension=${name}(\.so)?$")
+    if [ "${isInstall}" != "" ]; then
+        echo -e $HR
+        echo "PHP-${phpVersion} 已安装 ${name}"
+        exit 1
+    fi
+
+    archive="${package}"
+    if [ "${packageVersion}" != "" ]; then
+        archive="${package}-${packageVersion}"
+    fi
+
+    buildPath="${phpPath}/src/ext/pecl-${package}"
+    rm -rf ${buildPath}
+    mkdir -p ${buildPath}
+    cd ${buildPath}
+    echo "下载 https://pecl.php.net/get/${archive}"
+    wget -T 60 -t 3 -O ${package}.tgz https://pecl.php.net/get/${archive}
+    if [ "$?" != "0" ]; then
+        echo -e $HR
+        echo "PHP-${phpVersion} ${package} 下载失败，请检查包名和版本"
+        exit 1
+    fi
+
+    tar -zxf ${package}.tgz
+    rm -f ${package}.tgz
+    sourcePath=$(find . -mindepth 1 -maxdepth 1 -type d | head -n 1)
+    if [ "${sourcePath}" == "" ]; then
+        echo -e $HR
+        echo "PHP-${phpVersion} ${package} 源码解压失败"
+        exit 1
+    fi
+    cd ${sourcePath}
+
+    ${phpPath}/bin/phpize
+    echo "编译选项：$*"
+    ./configure --with-php-config=${phpPath}/bin/php-config "$@"
+    if [ "$?" != "0" ]; then
+        echo -e $HR
+        tail -n 50 config.log
+        echo -e $HR
+        echo "PHP-${phpVersion} ${package} 配置失败"
+        exit 1
+    fi
+    make -j$(nproc)
+    if [ "$?" != "0" ]; then
+        echo -e $HR
+        echo "PHP-${phpVersion} ${package} 编译失败"
+        exit 1
+    fi
+    make install
+    if [ "$?" != "0" ]; then
+        echo -e $HR
+        echo "PHP-${phpVersion} ${package} 安装失败"
+        exit 1
+    fi
+
+    extensionDir=$(${phpPath}/bin/php-config --extension-dir)
+    if [ ! -f "${extensionDir}/${name}.so" ]; then
+        echo -e $HR
+        echo "PHP-${phpVersion} 未找到 ${extensionDir}/${name}.so，请检查扩展名"
+        exit 1
+    fi
+
+    cd ${phpPath}/src/ext
+    rm -rf ${buildPath}
+
+    sed -i "/;haozi/a\\${directive}=${name}" ${phpPath}/etc/php.ini
+    if ! ${phpPath}/bin/php -r 'exit(extension_loaded($argv[1]) ? 0 : 1);' ${name}; then
+        sed -i -E "/^;?(zend_)?extension=${name}$/d" ${phpPath}/etc/php.ini
+        echo -e $HR
+        echo "PHP-${phpVersion} ${name} 加载失败，已从 php.ini 中移除"
+        exit 1
+    fi
+
+    # 重载PHP
+    systemctl reload php-fpm-${phpVersion}.service
+    echo -e $HR
+    echo "PHP-${phpVersion} ${name} 安装成功"
+}
+
+Uninstall() {
+    name="$1"
+
+    # 检查是否已经安装
+    isInstall=$(cat ${phpPath}/etc/php.ini | grep -E "^;?(zend_)?extension=${name}(\.so)?$")
+    if [ "${isInstall}" == "" ]; then
+        echo -e $HR
+        echo "PHP-${phpVersion} 未安装 ${name}"
+        exit 1
+    fi
+
+    sed -i -E "/^;?(zend_)?extension=${name}(\.so)?$/d" ${phpPath}/etc/php.ini
+    extensionDir=$(${phpPath}/bin/php-config --extension-dir)
+    rm -f ${extensionDir}/${name}.so
+
+    # 重载PHP
+    systemctl reload php-fpm-${phpVersion}.service
+    echo -e $HR
+    echo "PHP-${phpVersion} ${name} 卸载成功"
+}
+
+if [ "$action" == 'install' ]; then
+    shift 2
+    Install "$@"
+fi
+if [ "$action" == 'uninstall' ]; then
+    Uninstall "$3"
+fi
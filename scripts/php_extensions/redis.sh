@@ -27,7 +27,7 @@ phpredisVersion="5.3.7"
 
 Install() {
     # 检查是否已经安装
-    isInstall=$(cat /www/server/php/${phpVersion}/etc/php.ini | grep '^extension=redis$')
+    isInstall=$(cat /www/server/php/${phpVersion}/etc/php.ini | grep -E '^;?extension=redis$')
     if [ "${isInstall}" != "" ]; then
         echo -e $HR
         echo "PHP-${phpVersion} 已安装 redis"
@@ -76,7 +76,7 @@ Install() {
 
 Uninstall() {
     # 检查是否已经安装
-    isInstall=$(cat /www/server/php/${phpVersion}/etc/php.ini | grep '^extension=redis$')
+    isInstall=$(cat /www/server/php/${phpVersion}/etc/php.ini | grep -E '^;?extension=redis$')
     if [ "${isInstall}" == "" ]; then
         echo -e $HR
         echo "PHP-${phpVersion} 未安装 redis"
@@ -55,6 +55,22 @@ if version_lt "$oldVersion" "2.1.8"; then
     panel deleteSetting entrance
 fi
 
+if version_lt "$oldVersion" "2.1.26"; then
+    echo "更新面板到 v2.1.26 ..."
+    echo "Update panel to v2.1.26 ..."
+    panel syncCron
+    # 同一端口上各网站的协议版本以默认网站为准
+    if [ -f /www/server/openresty/conf/default.conf ] && ! grep -q "ssl_protocols" /www/server/openresty/conf/default.conf; then
+        sed -i 's/^\(\s*\)ssl_reject_handshake on;/\1ssl_protocols TLSv1.2 TLSv1.3;\n\1ssl_reject_handshake on;/' /www/server/openresty/conf/default.conf
+        systemctl reload openresty
+    fi
+    # 网站密码访问的用户文件仅允许 www 组读取
+    if [ -d /www/server/vhost/htpasswd ]; then
+        chown root:www /www/server/vhost/htpasswd/*.htpasswd > /dev/null 2>&1
+        chmod 640 /www/server/vhost/htpasswd/*.htpasswd > /dev/null 2>&1
+    fi
+fi
+
 echo $HR
 echo "更新结束"
 echo "Update finished"